@@ -0,0 +1,187 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BuilderImageMappingSpec defines the builder images selected for packages.
+type BuilderImageMappingSpec struct {
+	// rules select a builder image for a LeviathanBuild. Rules are evaluated in order
+	// and the first matching rule wins.
+	// +required
+	// +listType=atomic
+	// +kubebuilder:validation:MinItems=1
+	Rules []BuilderImageRule `json:"rules"`
+}
+
+// BuilderImageRule maps packages to a builder image.
+// A rule without packageNames and languages matches every build.
+type BuilderImageRule struct {
+	// packageNames are glob patterns (e.g. "web-*") matched against the packageName
+	// of the build. The rule matches any package when empty.
+	// +optional
+	// +listType=set
+	PackageNames []string `json:"packageNames,omitempty"`
+
+	// languages are matched against the language of the build.
+	// The rule matches any language when empty.
+	// +optional
+	// +listType=set
+	Languages []string `json:"languages,omitempty"`
+
+	// image is the builder image, without tag or digest
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// version is the tag, or "sha256:" digest, of the builder image.
+	// The image is used as is when empty.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// canary rolls out a new builder image to part of the builds matched by the
+	// rule. The rollout is paused when the builds running the canary image fail
+	// noticeably more often than those running the image of the rule.
+	// +optional
+	Canary *BuilderImageCanary `json:"canary,omitempty"`
+}
+
+// BuilderImageCanary is a builder image rolled out to a share of the builds.
+type BuilderImageCanary struct {
+	// image is the canary builder image, without tag or digest
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// version is the tag, or "sha256:" digest, of the canary builder image.
+	// The image is used as is when empty.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// percent is the share of the builds matched by the rule that run the canary
+	// image. Builds are assigned to the canary by a hash of their namespace and
+	// name, so raising the percentage keeps the builds already running it.
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percent int32 `json:"percent"`
+
+	// minBuilds is the number of finished builds needed on both images before
+	// their failure rates are compared.
+	// +optional
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	MinBuilds int32 `json:"minBuilds,omitempty"`
+
+	// maxFailureRateIncrease is the number of percentage points by which the
+	// failure rate of the canary image may exceed that of the image of the rule
+	// before the rollout is paused.
+	// +optional
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MaxFailureRateIncrease int32 `json:"maxFailureRateIncrease,omitempty"`
+}
+
+// BuilderImageMappingStatus defines the observed state of BuilderImageMapping.
+type BuilderImageMappingStatus struct {
+	// canaries reports the progress of the canary rollouts of the rules, by
+	// canary image.
+	// +optional
+	// +listType=map
+	// +listMapKey=image
+	Canaries []CanaryStatus `json:"canaries,omitempty"`
+}
+
+// CanaryStatus is the progress of the rollout of a canary builder image.
+type CanaryStatus struct {
+	// image is the reference of the canary image
+	// +required
+	Image string `json:"image"`
+
+	// startTime is when the rollout started. Only Jobs created since are counted.
+	// +required
+	StartTime metav1.Time `json:"startTime"`
+
+	// paused is set once the canary image has been found to fail more often.
+	// Builds no longer run the canary image while the rollout is paused; replace
+	// or remove the canary to start over.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// message explains the state of the rollout
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// canary counts the finished Jobs running the canary image
+	// +optional
+	Canary BuildOutcomes `json:"canary,omitzero"`
+
+	// stable counts the finished Jobs running the image of the rule
+	// +optional
+	Stable BuildOutcomes `json:"stable,omitzero"`
+}
+
+// BuildOutcomes counts finished Jobs.
+type BuildOutcomes struct {
+	// succeeded is the number of Jobs that completed
+	// +optional
+	Succeeded int32 `json:"succeeded,omitempty"`
+
+	// failed is the number of Jobs that failed
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+
+// BuilderImageMapping is the Schema for the builderimagemappings API.
+// The containers of a LeviathanBuild's jobTemplate that don't set an image run the
+// builder image selected by the BuilderImageMappings. Mappings are evaluated in
+// name order.
+type BuilderImageMapping struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the builder images selected by this mapping
+	// +required
+	Spec BuilderImageMappingSpec `json:"spec"`
+
+	// status defines the observed state of BuilderImageMapping
+	// +optional
+	Status BuilderImageMappingStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// BuilderImageMappingList contains a list of BuilderImageMapping
+type BuilderImageMappingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BuilderImageMapping `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BuilderImageMapping{}, &BuilderImageMappingList{})
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BuildTypeDefinitionSpec defines the pipeline of steps of a build type.
+type BuildTypeDefinitionSpec struct {
+	// buildType is the buildType of the LeviathanBuilds the definition applies to.
+	// It may be one of the built-in types or a new one; builds of a new type are
+	// built like Build builds, and neither verify nor publish.
+	// +required
+	BuildType BuildType `json:"buildType"`
+
+	// steps are the containers run by the builds of the type, in the order of
+	// their phase, then in the order they are listed. The last step runs as the
+	// container of the Job, the others as its init containers, all of them with
+	// the environment and the volumes of the build.
+	// +required
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	Steps []BuildStep `json:"steps"`
+}
+
+// BuildStepPhase is the stage of a pipeline a step runs in.
+// +kubebuilder:validation:Enum=Fetch;Pre;Build;Test;Publish;Post
+type BuildStepPhase string
+
+const (
+	// FetchPhase fetches the source of the build
+	FetchPhase BuildStepPhase = "Fetch"
+	// PrePhase prepares the build, e.g. restores caches or installs dependencies
+	PrePhase BuildStepPhase = "Pre"
+	// BuildPhase builds the package
+	BuildPhase BuildStepPhase = "Build"
+	// TestPhase tests the package
+	TestPhase BuildStepPhase = "Test"
+	// PublishPhase publishes the package
+	PublishPhase BuildStepPhase = "Publish"
+	// PostPhase runs after the package has been built and published, e.g. to report
+	PostPhase BuildStepPhase = "Post"
+)
+
+// BuildStep is a container of the pipeline of a build type.
+type BuildStep struct {
+	// name is the name of the container of the step
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// phase is the stage of the pipeline the step runs in
+	// +required
+	Phase BuildStepPhase `json:"phase"`
+
+	// image is the image of the step. The builder image selected by the
+	// BuilderImageMappings is run when unset.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// command is the entrypoint of the step. Each item is a Go text/template
+	// rendered with:
+	// - .Name and .Namespace, the name and namespace of the build;
+	// - .PackageName, the package built;
+	// - .Language, the language of the package;
+	// - .BuildType, the buildType of the build;
+	// - .SourceURL, the URL of the source of the build;
+	// - .Version, the version published by the build.
+	// Parameters of the build are environment variables of the step, and can be
+	// referenced as $(NAME).
+	// +optional
+	// +listType=atomic
+	Command []string `json:"command,omitempty"`
+
+	// args are the arguments of the entrypoint, rendered as the command is
+	// +optional
+	// +listType=atomic
+	Args []string `json:"args,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Build Type",type=string,JSONPath=`.spec.buildType`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BuildTypeDefinition is the Schema for the buildtypedefinitions API.
+// A BuildTypeDefinition declares the steps run by the LeviathanBuilds of a
+// buildType whose jobTemplate doesn't set containers, so that new build types
+// can be added without changing the operator. When several definitions are for
+// the same buildType, the first by name is used.
+type BuildTypeDefinition struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the pipeline of the build type
+	// +required
+	Spec BuildTypeDefinitionSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// BuildTypeDefinitionList contains a list of BuildTypeDefinition
+type BuildTypeDefinitionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BuildTypeDefinition `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BuildTypeDefinition{}, &BuildTypeDefinitionList{})
+}
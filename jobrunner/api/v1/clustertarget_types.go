@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterTargetSpec defines how the controller reaches a remote build cluster.
+type ClusterTargetSpec struct {
+	// kubeconfigSecretRef is the Secret holding the kubeconfig of the cluster,
+	// under the key "kubeconfig". The Jobs of builds are created in the cluster
+	// with its credentials, which must allow creating, reading and deleting Jobs
+	// and reading their pods.
+	// +required
+	KubeconfigSecretRef SecretReference `json:"kubeconfigSecretRef"`
+
+	// namespace is the namespace of the cluster the Jobs of builds are created in.
+	// They are created in a namespace of the name of the namespace of their build
+	// when unset.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Namespace string `json:"namespace,omitempty"`
+
+	// paused stops dispatching new runs to the cluster, e.g. while it is
+	// maintained. The runs already dispatched are still followed.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// Conditions of ClusterTargets.
+const (
+	// ReasonClusterReachable is the reason of Ready when the API server of the cluster answers
+	ReasonClusterReachable = "ClusterReachable"
+	// ReasonClusterUnreachable is the reason of Ready when the API server of the cluster can't be reached
+	ReasonClusterUnreachable = "ClusterUnreachable"
+	// ReasonInvalidKubeconfig is the reason of Ready when the kubeconfig Secret is missing or invalid
+	ReasonInvalidKubeconfig = "InvalidKubeconfig"
+	// ReasonPaused is the reason of Ready when spec.paused is set
+	ReasonPaused = "Paused"
+)
+
+// ClusterTargetStatus defines the observed state of ClusterTarget.
+type ClusterTargetStatus struct {
+	// serverVersion is the Kubernetes version of the cluster, as last reported by its API server
+	// +optional
+	ServerVersion string `json:"serverVersion,omitempty"`
+
+	// conditions represent the current state of the cluster. Ready is True while
+	// builds are dispatched to it, and False with the reason ClusterUnreachable,
+	// InvalidKubeconfig or Paused otherwise.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.serverVersion`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterTarget is the Schema for the clustertargets API.
+// A ClusterTarget registers a remote cluster builds can run in. Builds whose
+// spec.clusterSelector selects the labels of a Ready ClusterTarget have their
+// Jobs created in that cluster rather than in the cluster of the controller,
+// which follows them and reports their outcome in the status of the build.
+type ClusterTarget struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines how the cluster is reached
+	// +required
+	Spec ClusterTargetSpec `json:"spec"`
+
+	// status defines the observed state of ClusterTarget
+	// +optional
+	Status ClusterTargetStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterTargetList contains a list of ClusterTarget
+type ClusterTargetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterTarget `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterTarget{}, &ClusterTargetList{})
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+/*
+The condition types and reasons below are part of the API: tools waiting on
+builds (e.g. `kubectl wait --for=condition=Succeeded`) and alerting rules match
+on them, so they must not change once released. The helpers keep the summary
+conditions of every kind of the group consistent.
+*/
+
+// Condition types of LeviathanBuilds.
+const (
+	// ConditionReady summarizes the state of the object. It is True once its
+	// latest run succeeded, False when it failed, and Unknown while it runs.
+	ConditionReady = "Ready"
+	// ConditionSucceeded reports the outcome of the latest run
+	ConditionSucceeded = "Succeeded"
+
+	// ConditionInvalidJobTemplate is True when the Job of the build can't be created
+	ConditionInvalidJobTemplate = "InvalidJobTemplate"
+	// ConditionWaitingForMutex is True while a build waits for the Lease guarding its mutexKey
+	ConditionWaitingForMutex = "WaitingForMutex"
+	// ConditionWaitingForFetchSlot is True while a build that fetches its source waits
+	// for fewer builds of the cluster to be fetching theirs
+	ConditionWaitingForFetchSlot = "WaitingForFetchSlot"
+	// ConditionNamespaceTerminating is True when the namespace of the build is being deleted
+	ConditionNamespaceTerminating = "NamespaceTerminating"
+	// ConditionBuilderImageResolved is set on builds that need a builder image
+	ConditionBuilderImageResolved = "BuilderImageResolved"
+	// ConditionPublishPreflight reports the check of the publish target before a Job is created
+	ConditionPublishPreflight = "PublishPreflight"
+	// ConditionPatchTargetsApplied is set once the patch targets of a succeeded build have been handled
+	ConditionPatchTargetsApplied = "PatchTargetsApplied"
+	// ConditionDeferredByMaintenanceWindow is True while a build waits for a MaintenanceWindow to close
+	ConditionDeferredByMaintenanceWindow = "DeferredByMaintenanceWindow"
+	// ConditionPublishAuthorized reports whether the user who triggered a build may publish its package
+	ConditionPublishAuthorized = "PublishAuthorized"
+	// ConditionStalled is True while the heartbeat of the running run of a build has stopped
+	ConditionStalled = "Stalled"
+	// ConditionWaitingForCapacity is True while a build whose latest run failed for
+	// lack of node capacity waits for the nodes of the cluster to recover
+	ConditionWaitingForCapacity = "WaitingForCapacity"
+	// ConditionSigned reports the signing of the artifact published by the latest run
+	ConditionSigned = "Signed"
+	// ConditionDispatched reports whether the latest run of a build with a
+	// clusterSelector has been dispatched to a remote cluster
+	ConditionDispatched = "Dispatched"
+	// ConditionNonReproducible reports whether the second run of the latest run of
+	// a build verifying its reproducibility reported another digest than the first
+	ConditionNonReproducible = "NonReproducible"
+	// ConditionImageUpdated is True when the digest of the builder image of a
+	// build changed since its latest run was created
+	ConditionImageUpdated = "ImageUpdated"
+	// ConditionCredentialsRotatedDuringRun is True when a Secret the pods of the
+	// latest run read from changed while the run was running
+	ConditionCredentialsRotatedDuringRun = "CredentialsRotatedDuringRun"
+	// ConditionWaitingForApproval is True while the latest run of a build
+	// requiring a publishApproval waits for it
+	ConditionWaitingForApproval = "WaitingForApproval"
+	// ConditionPodCreationBlocked is True while the pods of the Job of the latest
+	// run are refused by the API server, e.g. by PodSecurity, a ResourceQuota or
+	// a LimitRange of the namespace
+	ConditionPodCreationBlocked = "PodCreationBlocked"
+
+	// ReportedConditionPrefix prefixes the types of the conditions reported by the
+	// build containers of the latest run, e.g. build.jcrs.dev/TestsPassed
+	ReportedConditionPrefix = "build.jcrs.dev/"
+)
+
+// Condition reasons of LeviathanBuilds.
+const (
+	// ReasonRunning is the reason of Ready and Succeeded while the Job of the latest run runs
+	ReasonRunning = "Running"
+	// ReasonJobComplete is the reason of Ready and Succeeded once the Job of the latest run completed
+	ReasonJobComplete = "JobComplete"
+	// ReasonJobFailed is the reason of Ready and Succeeded once the Job of the latest run failed
+	ReasonJobFailed = "JobFailed"
+	// ReasonExpired is the reason of Ready and Succeeded when the build didn't start within its expiresAfter
+	ReasonExpired = "Expired"
+	// ReasonRolledBack is the reason of Ready and Succeeded once the version published
+	// by the latest run was rolled back after its hooks failed
+	ReasonRolledBack = "RolledBack"
+	// ReasonReported is the reason of the reported conditions whose build container didn't set one
+	ReasonReported = "Reported"
+
+	// ReasonAccepted is the reason of InvalidJobTemplate when the Job is accepted by the API server
+	ReasonAccepted = "Accepted"
+	// ReasonConstructionFailed is the reason of InvalidJobTemplate when the Job can't be built from the spec
+	ReasonConstructionFailed = "ConstructionFailed"
+	// ReasonParametersUnresolved is the reason of InvalidJobTemplate when a source of
+	// the parameters of the build is missing
+	ReasonParametersUnresolved = "ParametersUnresolved"
+	// ReasonInvalidPublishPath is the reason of InvalidJobTemplate when the pathTemplate
+	// of the publishTarget doesn't render a valid path
+	ReasonInvalidPublishPath = "InvalidPublishPath"
+	// ReasonInvalidNamingTemplate is the reason of InvalidJobTemplate when the naming
+	// template of the build can't name the Job
+	ReasonInvalidNamingTemplate = "InvalidNamingTemplate"
+	// ReasonBackendUnavailable is the reason of InvalidJobTemplate when the
+	// executionBackend of the build isn't enabled in the controller or installed
+	ReasonBackendUnavailable = "BackendUnavailable"
+	// ReasonPipelineUnresolved is the reason of InvalidJobTemplate when the jobTemplate
+	// sets no containers and no BuildTypeDefinition provides the steps of the build
+	ReasonPipelineUnresolved = "PipelineUnresolved"
+
+	// ReasonAcquired is the reason of WaitingForMutex and WaitingForFetchSlot once
+	// the build holds the lock or fetch slot
+	ReasonAcquired = "Acquired"
+	// ReasonWaiting is the reason of WaitingForMutex and WaitingForFetchSlot while
+	// other builds hold the lock or every fetch slot
+	ReasonWaiting = "Waiting"
+
+	// ReasonInsufficientCapacity is the reason of WaitingForCapacity, and of Ready
+	// and Succeeded, while the build waits for the nodes to recover
+	ReasonInsufficientCapacity = "InsufficientCapacity"
+	// ReasonCapacityRecovered is the reason of WaitingForCapacity once the build runs again
+	ReasonCapacityRecovered = "CapacityRecovered"
+
+	// ReasonSigning is the reason of Signed while the signing Job runs
+	ReasonSigning = "Signing"
+	// ReasonSigned is the reason of Signed once the artifact is signed
+	ReasonSigned = "Signed"
+	// ReasonSigningFailed is the reason of Signed when the artifact can't be signed
+	ReasonSigningFailed = "SigningFailed"
+
+	// ReasonDispatched is the reason of Dispatched once the Job of the latest run is created in a remote cluster
+	ReasonDispatched = "Dispatched"
+	// ReasonNoClusterTarget is the reason of Dispatched while no Ready ClusterTarget is selected
+	ReasonNoClusterTarget = "NoClusterTarget"
+
+	// ReasonReproducing is the reason of NonReproducible while the second run runs
+	ReasonReproducing = "Reproducing"
+	// ReasonReproduced is the reason of NonReproducible once both runs reported the same digest
+	ReasonReproduced = "Reproduced"
+	// ReasonDigestMismatch is the reason of NonReproducible once both runs reported different digests
+	ReasonDigestMismatch = "DigestMismatch"
+	// ReasonReproductionFailed is the reason of NonReproducible when the second run fails
+	ReasonReproductionFailed = "ReproductionFailed"
+	// ReasonNoDigest is the reason of NonReproducible when a run didn't report a digest
+	ReasonNoDigest = "NoDigest"
+
+	// ReasonNamespaceTerminating is the reason of NamespaceTerminating
+	ReasonNamespaceTerminating = "NamespaceTerminating"
+
+	// ReasonResolved is the reason of BuilderImageResolved once a mapping selects an image
+	ReasonResolved = "Resolved"
+	// ReasonNoMatchingRule is the reason of BuilderImageResolved while no mapping selects an image
+	ReasonNoMatchingRule = "NoMatchingRule"
+
+	// ReasonDigestChanged is the reason of ImageUpdated when the builder image has a newer digest
+	ReasonDigestChanged = "DigestChanged"
+	// ReasonImageCurrent is the reason of ImageUpdated while the latest run uses the latest digest
+	ReasonImageCurrent = "ImageCurrent"
+
+	// ReasonSecretsRotated is the reason of CredentialsRotatedDuringRun when the
+	// data of a Secret of the run changed, or the Secret was deleted
+	ReasonSecretsRotated = "SecretsRotated"
+
+	// ReasonVersionAvailable is the reason of PublishPreflight when the version isn't published yet
+	ReasonVersionAvailable = "VersionAvailable"
+	// ReasonSkipped is the reason of PublishPreflight when an existing version skips the build
+	ReasonSkipped = "Skipped"
+	// ReasonReplacing is the reason of PublishPreflight when an existing version is replaced
+	ReasonReplacing = "Replacing"
+	// ReasonVersionConflict is the reason of PublishPreflight, and of the failed Succeeded condition,
+	// when the version already exists and the conflictPolicy is Fail
+	ReasonVersionConflict = "VersionConflict"
+
+	// ReasonApplied is the reason of PatchTargetsApplied once every target has been patched,
+	// and of the Complete condition of a LeviathanBuildBatchOperation applied to every build
+	ReasonApplied = "Applied"
+	// ReasonFeatureDisabled is the reason of conditions of features disabled in the controller
+	ReasonFeatureDisabled = "FeatureDisabled"
+	// ReasonInvalidPatch is the reason of PatchTargetsApplied when a patch can't be built
+	ReasonInvalidPatch = "InvalidPatch"
+	// ReasonImpersonationFailed is the reason of PatchTargetsApplied when the ServiceAccount can't be impersonated
+	ReasonImpersonationFailed = "ImpersonationFailed"
+	// ReasonDryRunFailed is the reason of PatchTargetsApplied when a dry run fails
+	ReasonDryRunFailed = "DryRunFailed"
+	// ReasonApplyFailed is the reason of PatchTargetsApplied when a patch fails
+	ReasonApplyFailed = "ApplyFailed"
+
+	// ReasonMaintenanceWindowOpen is the reason of DeferredByMaintenanceWindow
+	ReasonMaintenanceWindowOpen = "MaintenanceWindowOpen"
+
+	// ReasonPublisher is the reason of PublishAuthorized when the user is a publisher of the package
+	ReasonPublisher = "Publisher"
+	// ReasonNotAPublisher is the reason of PublishAuthorized when the user isn't a publisher of the package
+	ReasonNotAPublisher = "NotAPublisher"
+
+	// ReasonPublishApproved is the reason of WaitingForApproval once a PublishApproval approved the run
+	ReasonPublishApproved = "PublishApproved"
+	// ReasonApprovalTimedOut is the reason of WaitingForApproval once the timeout
+	// of the run passed, and of Ready and Succeeded when the run fails for it
+	ReasonApprovalTimedOut = "ApprovalTimedOut"
+
+	// ReasonPodSecurity is the reason of PodCreationBlocked when the pods violate
+	// the PodSecurity level enforced in the namespace
+	ReasonPodSecurity = "PodSecurity"
+	// ReasonResourceQuota is the reason of PodCreationBlocked when the pods exceed
+	// a ResourceQuota of the namespace, or don't set the resources it requires
+	ReasonResourceQuota = "ResourceQuota"
+	// ReasonLimitRange is the reason of PodCreationBlocked when the resources of
+	// the pods are out of the bounds of a LimitRange of the namespace
+	ReasonLimitRange = "LimitRange"
+	// ReasonAdmissionRejected is the reason of PodCreationBlocked when the pods are
+	// refused for another reason, e.g. by an admission webhook
+	ReasonAdmissionRejected = "AdmissionRejected"
+	// ReasonPodsCreated is the reason of PodCreationBlocked once the Job created its pods
+	ReasonPodsCreated = "PodsCreated"
+	// ReasonPodCreationBlocked is the reason of Ready while the pods of the latest
+	// run are refused
+	ReasonPodCreationBlocked = "PodCreationBlocked"
+
+	// ReasonHeartbeatMissed is the reason of Stalled when the build stopped touching its heartbeat file
+	ReasonHeartbeatMissed = "HeartbeatMissed"
+	// ReasonHeartbeating is the reason of Stalled while the build touches its heartbeat file
+	ReasonHeartbeating = "Heartbeating"
+)
+
+// SetReady sets the Ready condition. It reports whether the conditions changed.
+func SetReady(conditions *[]metav1.Condition, status metav1.ConditionStatus, generation int64, reason, message string) bool {
+	return meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setSucceeded sets the Succeeded condition, and Ready to the same status.
+func setSucceeded(conditions *[]metav1.Condition, status metav1.ConditionStatus, generation int64, reason, message string) bool {
+	changed := meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionSucceeded,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+	return SetReady(conditions, status, generation, reason, message) || changed
+}
+
+// MarkRunning records that the latest run hasn't finished yet.
+func MarkRunning(conditions *[]metav1.Condition, generation int64, reason, message string) bool {
+	return setSucceeded(conditions, metav1.ConditionUnknown, generation, reason, message)
+}
+
+// MarkSucceeded records that the latest run succeeded.
+func MarkSucceeded(conditions *[]metav1.Condition, generation int64, reason, message string) bool {
+	return setSucceeded(conditions, metav1.ConditionTrue, generation, reason, message)
+}
+
+// MarkFailed records that the latest run failed.
+func MarkFailed(conditions *[]metav1.Condition, generation int64, reason, message string) bool {
+	return setSucceeded(conditions, metav1.ConditionFalse, generation, reason, message)
+}
+
+// IsSucceeded reports whether the latest run succeeded.
+func IsSucceeded(conditions []metav1.Condition) bool {
+	return meta.IsStatusConditionTrue(conditions, ConditionSucceeded)
+}
+
+// IsFailed reports whether the latest run failed.
+func IsFailed(conditions []metav1.Condition) bool {
+	return meta.IsStatusConditionFalse(conditions, ConditionSucceeded)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Conditions", func() {
+	// Downstream automation matches on these strings, changing one is a breaking change
+	DescribeTable("keep their types and reasons stable",
+		func(constant, value string) {
+			Expect(constant).To(Equal(value))
+		},
+		Entry(nil, ConditionReady, "Ready"),
+		Entry(nil, ConditionSucceeded, "Succeeded"),
+		Entry(nil, ConditionInvalidJobTemplate, "InvalidJobTemplate"),
+		Entry(nil, ConditionWaitingForMutex, "WaitingForMutex"),
+		Entry(nil, ConditionWaitingForFetchSlot, "WaitingForFetchSlot"),
+		Entry(nil, ConditionNamespaceTerminating, "NamespaceTerminating"),
+		Entry(nil, ConditionBuilderImageResolved, "BuilderImageResolved"),
+		Entry(nil, ConditionPublishPreflight, "PublishPreflight"),
+		Entry(nil, ConditionPatchTargetsApplied, "PatchTargetsApplied"),
+		Entry(nil, ConditionDeferredByMaintenanceWindow, "DeferredByMaintenanceWindow"),
+		Entry(nil, ConditionPublishAuthorized, "PublishAuthorized"),
+		Entry(nil, ConditionStalled, "Stalled"),
+		Entry(nil, ConditionWaitingForCapacity, "WaitingForCapacity"),
+		Entry(nil, ConditionSigned, "Signed"),
+		Entry(nil, ConditionDispatched, "Dispatched"),
+		Entry(nil, ConditionNonReproducible, "NonReproducible"),
+		Entry(nil, ConditionImageUpdated, "ImageUpdated"),
+		Entry(nil, ConditionCredentialsRotatedDuringRun, "CredentialsRotatedDuringRun"),
+		Entry(nil, ConditionWaitingForApproval, "WaitingForApproval"),
+		Entry(nil, ConditionPodCreationBlocked, "PodCreationBlocked"),
+		Entry(nil, ReportedConditionPrefix, "build.jcrs.dev/"),
+		Entry(nil, ReasonRunning, "Running"),
+		Entry(nil, ReasonJobComplete, "JobComplete"),
+		Entry(nil, ReasonJobFailed, "JobFailed"),
+		Entry(nil, ReasonExpired, "Expired"),
+		Entry(nil, ReasonReported, "Reported"),
+		Entry(nil, ReasonAccepted, "Accepted"),
+		Entry(nil, ReasonConstructionFailed, "ConstructionFailed"),
+		Entry(nil, ReasonParametersUnresolved, "ParametersUnresolved"),
+		Entry(nil, ReasonInvalidPublishPath, "InvalidPublishPath"),
+		Entry(nil, ReasonBackendUnavailable, "BackendUnavailable"),
+		Entry(nil, ReasonPipelineUnresolved, "PipelineUnresolved"),
+		Entry(nil, ReasonAcquired, "Acquired"),
+		Entry(nil, ReasonWaiting, "Waiting"),
+		Entry(nil, ReasonInsufficientCapacity, "InsufficientCapacity"),
+		Entry(nil, ReasonCapacityRecovered, "CapacityRecovered"),
+		Entry(nil, ReasonSigning, "Signing"),
+		Entry(nil, ReasonSigned, "Signed"),
+		Entry(nil, ReasonSigningFailed, "SigningFailed"),
+		Entry(nil, ReasonDispatched, "Dispatched"),
+		Entry(nil, ReasonNoClusterTarget, "NoClusterTarget"),
+		Entry(nil, ReasonReproducing, "Reproducing"),
+		Entry(nil, ReasonReproduced, "Reproduced"),
+		Entry(nil, ReasonDigestMismatch, "DigestMismatch"),
+		Entry(nil, ReasonReproductionFailed, "ReproductionFailed"),
+		Entry(nil, ReasonNoDigest, "NoDigest"),
+		Entry(nil, ReasonNamespaceTerminating, "NamespaceTerminating"),
+		Entry(nil, ReasonResolved, "Resolved"),
+		Entry(nil, ReasonNoMatchingRule, "NoMatchingRule"),
+		Entry(nil, ReasonDigestChanged, "DigestChanged"),
+		Entry(nil, ReasonImageCurrent, "ImageCurrent"),
+		Entry(nil, ReasonSecretsRotated, "SecretsRotated"),
+		Entry(nil, ReasonVersionAvailable, "VersionAvailable"),
+		Entry(nil, ReasonSkipped, "Skipped"),
+		Entry(nil, ReasonReplacing, "Replacing"),
+		Entry(nil, ReasonVersionConflict, "VersionConflict"),
+		Entry(nil, ReasonApplied, "Applied"),
+		Entry(nil, ReasonFeatureDisabled, "FeatureDisabled"),
+		Entry(nil, ReasonInvalidPatch, "InvalidPatch"),
+		Entry(nil, ReasonImpersonationFailed, "ImpersonationFailed"),
+		Entry(nil, ReasonDryRunFailed, "DryRunFailed"),
+		Entry(nil, ReasonApplyFailed, "ApplyFailed"),
+		Entry(nil, ReasonMaintenanceWindowOpen, "MaintenanceWindowOpen"),
+		Entry(nil, ReasonPublisher, "Publisher"),
+		Entry(nil, ReasonNotAPublisher, "NotAPublisher"),
+		Entry(nil, ReasonPublishApproved, "PublishApproved"),
+		Entry(nil, ReasonApprovalTimedOut, "ApprovalTimedOut"),
+		Entry(nil, ReasonPodSecurity, "PodSecurity"),
+		Entry(nil, ReasonResourceQuota, "ResourceQuota"),
+		Entry(nil, ReasonLimitRange, "LimitRange"),
+		Entry(nil, ReasonAdmissionRejected, "AdmissionRejected"),
+		Entry(nil, ReasonPodsCreated, "PodsCreated"),
+		Entry(nil, ReasonPodCreationBlocked, "PodCreationBlocked"),
+		Entry(nil, ReasonHeartbeatMissed, "HeartbeatMissed"),
+		Entry(nil, ReasonHeartbeating, "Heartbeating"),
+	)
+
+	It("keeps Ready in line with the outcome of the latest run", func() {
+		var conditions []metav1.Condition
+		Expect(MarkRunning(&conditions, 1, ReasonRunning, "Job web-1-abcde is running")).To(BeTrue())
+		Expect(meta.FindStatusCondition(conditions, ConditionReady).Status).To(Equal(metav1.ConditionUnknown))
+		Expect(IsSucceeded(conditions) || IsFailed(conditions)).To(BeFalse())
+
+		Expect(MarkFailed(&conditions, 1, ReasonJobFailed, "Job web-1-abcde failed")).To(BeTrue())
+		Expect(IsFailed(conditions)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(conditions, ConditionReady)).To(BeTrue())
+		Expect(MarkFailed(&conditions, 1, ReasonJobFailed, "Job web-1-abcde failed")).To(BeFalse())
+
+		Expect(MarkSucceeded(&conditions, 2, ReasonJobComplete, "Job web-2-abcde completed")).To(BeTrue())
+		Expect(IsSucceeded(conditions)).To(BeTrue())
+		ready := meta.FindStatusCondition(conditions, ConditionReady)
+		Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+		Expect(ready.Reason).To(Equal(ReasonJobComplete))
+		Expect(ready.ObservedGeneration).To(Equal(int64(2)))
+	})
+})
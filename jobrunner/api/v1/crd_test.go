@@ -0,0 +1,490 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel"
+	structuraldefaulting "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/pruning"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"sigs.k8s.io/yaml"
+)
+
+/*
+These tests validate objects against the generated CRDs in config/crd/bases, the
+way the API server does: pruning, defaulting, then the OpenAPI schema, then the
+CEL rules. They catch markers that don't make it into the CRDs, samples that drift from the
+schema, and validation rules that reject valid objects. Run `make manifests`
+before the tests after changing the markers.
+*/
+
+// crdSchema validates objects of one kind against its CRD.
+type crdSchema struct {
+	structural *structuralschema.Structural
+	validator  validation.SchemaValidator
+	cel        *cel.Validator
+}
+
+// validate defaults obj and returns the validation errors the API server would report.
+func (s *crdSchema) validate(obj map[string]any) []string {
+	structuraldefaulting.Default(obj, s.structural)
+	errs := validation.ValidateCustomResource(nil, obj, s.validator)
+	celErrs, _ := s.cel.Validate(context.Background(), nil, s.structural, obj, nil, celconfig.RuntimeCELCostBudget)
+	errs = append(errs, celErrs...)
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+// prune drops the fields of obj the schema doesn't declare, and returns their
+// paths. The API server prunes objects as it decodes them.
+func (s *crdSchema) prune(obj map[string]any) []string {
+	return pruning.PruneWithOptions(obj, s.structural, true, structuralschema.UnknownFieldPathOptions{TrackUnknownFieldPaths: true})
+}
+
+// loadCRDSchemas returns the schemas of every version of the CRDs in
+// config/crd/bases, by kind and version.
+func loadCRDSchemas() map[schema.GroupVersionKind]*crdSchema {
+	files, err := filepath.Glob(filepath.Join("..", "..", "config", "crd", "bases", "*.yaml"))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(files).NotTo(BeEmpty())
+
+	schemas := make(map[schema.GroupVersionKind]*crdSchema)
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		Expect(err).NotTo(HaveOccurred())
+		var crd apiextensionsv1.CustomResourceDefinition
+		Expect(yaml.UnmarshalStrict(raw, &crd)).To(Succeed(), file)
+		Expect(crd.Spec.Versions).NotTo(BeEmpty(), file)
+
+		for _, version := range crd.Spec.Versions {
+			var props apiextensions.JSONSchemaProps
+			Expect(apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(
+				version.Schema.OpenAPIV3Schema, &props, nil)).To(Succeed(), file)
+			structural, err := structuralschema.NewStructural(&props)
+			Expect(err).NotTo(HaveOccurred(), file)
+			validator, _, err := validation.NewSchemaValidator(&props)
+			Expect(err).NotTo(HaveOccurred(), file)
+			gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+			schemas[gvk] = &crdSchema{
+				structural: structural,
+				validator:  validator,
+				cel:        cel.NewValidator(structural, true, celconfig.PerCallLimit),
+			}
+		}
+	}
+	return schemas
+}
+
+// loadSamples returns the objects of the samples in config/samples.
+func loadSamples() []*unstructured.Unstructured {
+	files, err := filepath.Glob(filepath.Join("..", "..", "config", "samples", "jcrs_*.yaml"))
+	Expect(err).NotTo(HaveOccurred())
+
+	var samples []*unstructured.Unstructured
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		Expect(err).NotTo(HaveOccurred())
+		decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(raw), 4096)
+		for {
+			obj := &unstructured.Unstructured{}
+			if err := decoder.Decode(&obj.Object); err == io.EOF {
+				break
+			} else {
+				Expect(err).NotTo(HaveOccurred(), file)
+			}
+			if obj.Object != nil {
+				samples = append(samples, obj)
+			}
+		}
+	}
+	Expect(samples).NotTo(BeEmpty())
+	return samples
+}
+
+var _ = Describe("CRD validation", func() {
+	var schemas map[schema.GroupVersionKind]*crdSchema
+
+	BeforeEach(func() {
+		schemas = loadCRDSchemas()
+	})
+
+	It("admits every sample", func() {
+		for _, sample := range loadSamples() {
+			crdSchema, ok := schemas[sample.GroupVersionKind()]
+			Expect(ok).To(BeTrue(), "no CRD for sample %s", sample.GetName())
+			Expect(crdSchema.prune(sample.Object)).To(BeEmpty(), sample.GetName())
+			Expect(crdSchema.validate(sample.Object)).To(BeEmpty(), sample.GetName())
+		}
+	})
+
+	It("prunes the fields the schemas don't declare, but the ones they preserve", func() {
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "LeviathanBuild",
+			"metadata":   map[string]any{"name": "web", "namespace": "default"},
+			"spec": map[string]any{
+				"packageName": "web",
+				"soucePath":   "/src",
+				"jobTemplate": map[string]any{},
+				"onSuccess": map[string]any{"patchTargets": []any{map[string]any{
+					"apiVersion": "apps/v1", "kind": "Deployment", "name": "web",
+					"patch": map[string]any{"spec": map[string]any{"replicaz": 2}},
+				}}},
+			},
+			"unknown": true,
+		}
+		Expect(schemas[GroupVersion.WithKind("LeviathanBuild")].prune(obj)).To(ConsistOf("spec.soucePath", "unknown"))
+		Expect(obj["spec"]).NotTo(HaveKey("soucePath"))
+		patchTarget := obj["spec"].(map[string]any)["onSuccess"].(map[string]any)["patchTargets"].([]any)[0]
+		Expect(patchTarget).To(HaveKeyWithValue("patch", HaveKeyWithValue("spec", HaveKey("replicaz"))))
+	})
+
+	DescribeTable("rejects invalid LeviathanBuilds",
+		func(spec map[string]any, message string) {
+			obj := map[string]any{
+				"apiVersion": GroupVersion.String(),
+				"kind":       "LeviathanBuild",
+				"metadata":   map[string]any{"name": "web", "namespace": "default"},
+				"spec": map[string]any{
+					"packageName": "web",
+					"jobTemplate": map[string]any{},
+				},
+			}
+			for key, value := range spec {
+				obj["spec"].(map[string]any)[key] = value
+			}
+			Expect(schemas[GroupVersion.WithKind("LeviathanBuild")].validate(obj)).To(ContainElement(ContainSubstring(message)))
+		},
+		Entry("build types that aren't type names", map[string]any{"buildType": "re-place"},
+			"spec.buildType: Invalid value"),
+		Entry("package names with spaces", map[string]any{"packageName": "my package"},
+			"spec.packageName: Invalid value"),
+		Entry("package names escaping their path", map[string]any{"packageName": "a/../b"},
+			"packageName must not contain '..'"),
+		Entry("HTTP sources that aren't http(s)", map[string]any{"sourceType": "HTTP", "sourceURL": "ftp://example.com/src.tgz"},
+			"sourceURL must be an http(s) URL when sourceType is HTTP"),
+		Entry("Git sources with an unsupported scheme", map[string]any{"sourceType": "Git", "sourceURL": "file:///etc"},
+			"sourceURL must be an https, ssh or git URL when sourceType is Git"),
+		Entry("S3 sources that aren't s3 URLs", map[string]any{"sourceType": "S3", "sourceURL": "https://bucket/src.tgz"},
+			"sourceURL must be an s3 URL when sourceType is S3"),
+		Entry("polling sources that can't be polled", map[string]any{"sourceType": "Git", "sourceURL": "git@example.com:web.git", "pollInterval": "5m"},
+			"pollInterval requires an HTTP or S3 source, or a Git source with an https sourceURL"),
+		Entry("polling sources too often", map[string]any{"sourceType": "HTTP", "sourceURL": "https://example.com/src.tgz", "pollInterval": "10s"},
+			"pollInterval must be at least 1m"),
+		Entry("versions that can't be used in URLs", map[string]any{"buildType": "Publish",
+			"publishTarget": map[string]any{"registryURL": "https://registry.example.com", "version": "1.0/../2"}},
+			"spec.publishTarget.version: Invalid value"),
+		Entry("service accounts that aren't valid names", map[string]any{
+			"onSuccess": map[string]any{"serviceAccountName": "Deployer"}},
+			"spec.onSuccess.serviceAccountName: Invalid value"),
+		Entry("cache claims that aren't valid names", map[string]any{
+			"source": map[string]any{"http": map[string]any{"cacheClaimName": "cache_claim"}}},
+			"spec.source.http.cacheClaimName: Invalid value"),
+		Entry("job patches of an unknown type", map[string]any{
+			"jobPatches": []any{map[string]any{"type": "Merge", "patch": "{}"}}},
+			`spec.jobPatches[0].type: Unsupported value: "Merge"`),
+		Entry("protection policies outside the enum", map[string]any{"protectionPolicy": "Protect"},
+			`spec.protectionPolicy: Unsupported value: "Protect"`),
+		Entry("heartbeat timeouts shorter than the interval", map[string]any{
+			"heartbeat": map[string]any{"interval": "10m", "timeout": "5m"}},
+			"spec.heartbeat: Invalid value"),
+		Entry("DNS policies outside the enum", map[string]any{"dnsPolicy": "Custom"},
+			`spec.dnsPolicy: Unsupported value: "Custom"`),
+		Entry("git submodules outside the enum", map[string]any{"source": map[string]any{"git": map[string]any{"submodules": "Shallow"}}},
+			`spec.source.git.submodules: Unsupported value: "Shallow"`),
+		Entry("parameters sources naming both a ConfigMap and a Secret", map[string]any{"parametersFrom": []any{map[string]any{
+			"configMapRef": map[string]any{"name": "config"}, "secretRef": map[string]any{"name": "secrets"}}}},
+			"exactly one of configMapRef and secretRef must be set"),
+		Entry("builds that expire right away", map[string]any{"expiresAfter": "0s"},
+			"expiresAfter must be positive"),
+		Entry("rollbacks without a store to delete the version from", map[string]any{"onHookFailure": "Rollback"},
+			"artifactRetention is required when onHookFailure is Rollback"),
+		Entry("package anti-affinities outside the enum", map[string]any{"spreadPolicy": map[string]any{"packageAntiAffinity": "Always"}},
+			`spec.spreadPolicy.packageAntiAffinity: Unsupported value: "Always"`),
+		Entry("more shards running at once than there are", map[string]any{"jobPolicy": map[string]any{"completions": int64(2), "parallelism": int64(3)}},
+			"parallelism must not exceed completions"),
+		Entry("remote clusters selected for another engine", map[string]any{"executionBackend": "ArgoWorkflow",
+			"clusterSelector": map[string]any{"matchLabels": map[string]any{"pool": "builds"}}},
+			"clusterSelector requires the Job executionBackend"),
+		Entry("reproducibility verified by Verify builds", map[string]any{"buildType": "Verify", "verifyReproducibility": true},
+			"verifyReproducibility can't be set on a Verify build"),
+		Entry("reproducibility verified by another engine", map[string]any{"executionBackend": "TektonPipelineRun", "verifyReproducibility": true},
+			"verifyReproducibility requires the Job executionBackend"),
+		Entry("publish approvals of another engine", map[string]any{"executionBackend": "ArgoWorkflow", "publishApproval": map[string]any{"required": true}},
+			"publishApproval requires the Job executionBackend"),
+		Entry("publish approval timeouts outside the enum", map[string]any{"publishApproval": map[string]any{"required": true, "onTimeout": "Retry"}},
+			`spec.publishApproval.onTimeout: Unsupported value: "Retry"`),
+		Entry("lifecycle hooks without a hook", map[string]any{"lifecycleHooks": map[string]any{"mode": "Wrapper"}},
+			"at least one of preBuild and postBuild must be set"),
+		Entry("lifecycle hooks without a command", map[string]any{"lifecycleHooks": map[string]any{"preBuild": map[string]any{"command": []any{}}}},
+			"spec.lifecycleHooks.preBuild.command: Invalid value"),
+	)
+
+	It("admits scoped package names and semver versions", func() {
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "LeviathanBuild",
+			"metadata":   map[string]any{"name": "web", "namespace": "default"},
+			"spec": map[string]any{
+				"packageName":   "@scope/web",
+				"buildType":     "BuildPublish",
+				"sourceType":    "Git",
+				"sourceURL":     "git@github.com:example/web.git",
+				"jobTemplate":   map[string]any{},
+				"publishTarget": map[string]any{"registryURL": "https://registry.example.com", "version": "1.2.3-rc.1+build.5"},
+			},
+		}
+		Expect(schemas[GroupVersion.WithKind("LeviathanBuild")].validate(obj)).To(BeEmpty())
+	})
+
+	DescribeTable("rejects v2 sources that don't match their type",
+		func(source map[string]any, message string) {
+			obj := map[string]any{
+				"apiVersion": GroupVersion.Group + "/v2",
+				"kind":       "LeviathanBuild",
+				"metadata":   map[string]any{"name": "web", "namespace": "default"},
+				"spec": map[string]any{
+					"packageName": "web",
+					"jobTemplate": map[string]any{},
+					"source":      source,
+				},
+			}
+			gvk := schema.GroupVersionKind{Group: GroupVersion.Group, Version: "v2", Kind: "LeviathanBuild"}
+			Expect(schemas[gvk].validate(obj)).To(ContainElement(ContainSubstring(message)))
+		},
+		Entry("a missing member", map[string]any{"type": "Git"},
+			"git is required when type is Git, and forbidden otherwise"),
+		Entry("a member of another type", map[string]any{"type": "Inline", "inline": map[string]any{"script": "make"},
+			"s3": map[string]any{"url": "s3://bucket/src.tgz"}},
+			"s3 is required when type is S3, and forbidden otherwise"),
+		Entry("a local path on a remote source", map[string]any{"type": "HTTP", "http": map[string]any{"url": "https://example.com/src.tgz"},
+			"local": map[string]any{"path": "src"}},
+			"local may only be set when type is Local"),
+		Entry("polling a source that can't be polled", map[string]any{"type": "Local", "pollInterval": "5m"},
+			"pollInterval requires a Git, S3 or HTTP source"),
+		Entry("a URL that doesn't suit the type", map[string]any{"type": "HTTP", "http": map[string]any{"url": "ftp://example.com/src.tgz"}},
+			"url must be an http(s) URL"),
+	)
+
+	It("rejects v2 verify builds that publish", func() {
+		obj := map[string]any{
+			"apiVersion": GroupVersion.Group + "/v2",
+			"kind":       "LeviathanBuild",
+			"metadata":   map[string]any{"name": "web", "namespace": "default"},
+			"spec": map[string]any{
+				"packageName": "web",
+				"jobTemplate": map[string]any{},
+				"source":      map[string]any{"type": "Local"},
+				"verify":      true,
+				"publish":     map[string]any{"mode": "AfterBuild"},
+			},
+		}
+		gvk := schema.GroupVersionKind{Group: GroupVersion.Group, Version: "v2", Kind: "LeviathanBuild"}
+		Expect(schemas[gvk].validate(obj)).To(ContainElement(ContainSubstring("publish can't be set on a verify build")))
+	})
+
+	It("rejects maintenance windows that never close", func() {
+		for _, duration := range []string{"0s", "336h"} {
+			obj := map[string]any{
+				"apiVersion": GroupVersion.String(),
+				"kind":       "MaintenanceWindow",
+				"metadata":   map[string]any{"name": "nightly"},
+				"spec":       map[string]any{"schedule": "0 22 * * *", "duration": duration},
+			}
+			Expect(schemas[GroupVersion.WithKind("MaintenanceWindow")].validate(obj)).To(ContainElement(ContainSubstring("duration must be positive and at most 168h")), duration)
+		}
+	})
+
+	It("only admits the summary of a namespace under its fixed name", func() {
+		summarySchema := schemas[GroupVersion.WithKind("LeviathanBuildSummary")]
+		obj := func(name string) map[string]any {
+			return map[string]any{
+				"apiVersion": GroupVersion.String(),
+				"kind":       "LeviathanBuildSummary",
+				"metadata":   map[string]any{"name": name, "namespace": "default"},
+			}
+		}
+		Expect(summarySchema.validate(obj(LeviathanBuildSummaryName))).To(BeEmpty())
+		Expect(summarySchema.validate(obj("builds"))).To(ContainElement(ContainSubstring("the summary of a namespace is named summary")))
+	})
+
+	It("only admits the defaults of a namespace under their fixed name", func() {
+		defaultsSchema := schemas[GroupVersion.WithKind("LeviathanBuildDefaults")]
+		obj := func(name string) map[string]any {
+			return map[string]any{
+				"apiVersion": GroupVersion.String(),
+				"kind":       "LeviathanBuildDefaults",
+				"metadata":   map[string]any{"name": name, "namespace": "team"},
+				"spec":       map[string]any{"retryPolicy": map[string]any{"backoffLimit": int64(2)}},
+			}
+		}
+		Expect(defaultsSchema.validate(obj(LeviathanBuildDefaultsName))).To(BeEmpty())
+		Expect(defaultsSchema.validate(obj("web"))).To(ContainElement(ContainSubstring("the defaults of a namespace are named default")))
+	})
+
+	It("rejects CredentialGrants without a Secret namespace", func() {
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "CredentialGrant",
+			"metadata":   map[string]any{"name": "publisher"},
+			"spec": map[string]any{
+				"secretRef":         map[string]any{"namespace": "", "name": "publisher"},
+				"namespaceSelector": map[string]any{},
+			},
+		}
+		Expect(schemas[GroupVersion.WithKind("CredentialGrant")].validate(obj)).To(ContainElement(ContainSubstring("spec.secretRef.namespace")))
+	})
+
+	It("rejects ClusterTargets creating Jobs in invalid namespaces", func() {
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "ClusterTarget",
+			"metadata":   map[string]any{"name": "builds-eu"},
+			"spec": map[string]any{
+				"kubeconfigSecretRef": map[string]any{"namespace": "jobrunner-system", "name": "builds-eu"},
+				"namespace":           "Builds",
+			},
+		}
+		Expect(schemas[GroupVersion.WithKind("ClusterTarget")].validate(obj)).To(ContainElement(ContainSubstring("spec.namespace")))
+	})
+
+	It("rejects BuildTypeDefinitions with steps outside the phases", func() {
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "BuildTypeDefinition",
+			"metadata":   map[string]any{"name": "lint"},
+			"spec": map[string]any{
+				"buildType": "Lint",
+				"steps":     []any{map[string]any{"name": "lint", "phase": "Check", "command": []any{"make", "lint"}}},
+			},
+		}
+		Expect(schemas[GroupVersion.WithKind("BuildTypeDefinition")].validate(obj)).To(ContainElement(ContainSubstring(`spec.steps[0].phase: Unsupported value: "Check"`)))
+	})
+
+	It("rejects batch operations outside the enum", func() {
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "LeviathanBuildBatchOperation",
+			"metadata":   map[string]any{"name": "freeze", "namespace": "default"},
+			"spec": map[string]any{
+				"operation": "Pause",
+				"selector":  map[string]any{"matchLabels": map[string]any{"team": "payments"}},
+			},
+		}
+		Expect(schemas[GroupVersion.WithKind("LeviathanBuildBatchOperation")].validate(obj)).To(ContainElement(ContainSubstring("spec.operation")))
+	})
+
+	It("rejects parameter overrides of batch operations that don't trigger builds", func() {
+		spec := map[string]any{
+			"operation":  "Trigger",
+			"selector":   map[string]any{"matchLabels": map[string]any{"team": "payments"}},
+			"parameters": map[string]any{"VERSION": "1.2.3-hotfix"},
+		}
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "LeviathanBuildBatchOperation",
+			"metadata":   map[string]any{"name": "hotfix", "namespace": "default"},
+			"spec":       spec,
+		}
+		schema := schemas[GroupVersion.WithKind("LeviathanBuildBatchOperation")]
+		Expect(schema.validate(obj)).To(BeEmpty())
+
+		spec["parameters"] = map[string]any{"1VERSION": "1.2.3-hotfix"}
+		Expect(schema.validate(obj)).To(ContainElement(ContainSubstring("parameter names must be environment variable names")))
+
+		spec["parameters"] = map[string]any{"VERSION": "1.2.3-hotfix"}
+		spec["operation"] = "Suspend"
+		Expect(schema.validate(obj)).To(ContainElement(ContainSubstring("parameters can only be overridden by a Trigger")))
+	})
+
+	It("rejects projects whose packages depend on themselves", func() {
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "LeviathanProject",
+			"metadata":   map[string]any{"name": "storefront", "namespace": "default"},
+			"spec": map[string]any{
+				"dependencies": []any{map[string]any{"package": "web", "dependsOn": []any{"api", "web"}}},
+			},
+		}
+		Expect(schemas[GroupVersion.WithKind("LeviathanProject")].validate(obj)).To(ContainElement(ContainSubstring("a package can't depend on itself")))
+	})
+
+	It("requires an approval of promotions, and keeps their spec immutable", func() {
+		spec := map[string]any{
+			"source": map[string]any{"namespace": "dev", "build": "web"},
+			"target": map[string]any{"registryURL": "registry.example.com/prod"},
+			"store":  "OCI",
+		}
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "PackagePromotion",
+			"metadata":   map[string]any{"name": "web-1.2.3", "namespace": "prod"},
+			"spec":       spec,
+		}
+		promotionSchema := schemas[GroupVersion.WithKind("PackagePromotion")]
+		Expect(promotionSchema.validate(obj)).To(BeEmpty())
+		Expect(spec).To(HaveKeyWithValue("requiredApprovals", int64(1)))
+
+		updated := runtime.DeepCopyJSON(obj)
+		updated["spec"].(map[string]any)["target"] = map[string]any{"registryURL": "registry.example.com/staging"}
+		errs, _ := promotionSchema.cel.Validate(context.Background(), nil, promotionSchema.structural, updated, obj, celconfig.RuntimeCELCostBudget)
+		Expect(errs.ToAggregate()).To(MatchError(ContainSubstring("the spec of a promotion is immutable")))
+
+		spec["requiredApprovals"] = int64(0)
+		Expect(promotionSchema.validate(obj)).To(ContainElement(ContainSubstring("spec.requiredApprovals")))
+	})
+
+	It("requires the run a publish approval approves, and keeps it immutable", func() {
+		spec := map[string]any{"build": "web", "runIndex": int64(3), "comment": "CHG-42"}
+		obj := map[string]any{
+			"apiVersion": GroupVersion.String(),
+			"kind":       "PublishApproval",
+			"metadata":   map[string]any{"name": "web-3", "namespace": "default"},
+			"spec":       spec,
+		}
+		approvalSchema := schemas[GroupVersion.WithKind("PublishApproval")]
+		Expect(approvalSchema.validate(obj)).To(BeEmpty())
+
+		updated := runtime.DeepCopyJSON(obj)
+		updated["spec"].(map[string]any)["runIndex"] = int64(4)
+		errs, _ := approvalSchema.cel.Validate(context.Background(), nil, approvalSchema.structural, updated, obj, celconfig.RuntimeCELCostBudget)
+		Expect(errs.ToAggregate()).To(MatchError(ContainSubstring("the spec of an approval is immutable")))
+
+		spec["runIndex"] = int64(0)
+		Expect(approvalSchema.validate(obj)).To(ContainElement(ContainSubstring("spec.runIndex")))
+	})
+})
@@ -0,0 +1,105 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CredentialGrantSpec defines which Secret is shared with the namespaces of builds.
+type CredentialGrantSpec struct {
+	// secretRef is the Secret shared, e.g. the credentials of a publish target or
+	// of a source kept in a central namespace
+	// +required
+	SecretRef SecretReference `json:"secretRef"`
+
+	// namespaceSelector selects the namespaces the Secret may be copied to. An
+	// empty selector selects every namespace.
+	// +required
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+}
+
+// SecretReference names a Secret of any namespace.
+type SecretReference struct {
+	// namespace is the namespace of the Secret
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Namespace string `json:"namespace"`
+
+	// name is the name of the Secret. The copies have the same name.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Name string `json:"name"`
+}
+
+// CredentialGrantStatus defines the observed state of CredentialGrant.
+type CredentialGrantStatus struct {
+	// namespaces are the namespaces holding a copy of the Secret
+	// +optional
+	// +listType=set
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// conflicts are the namespaces the Secret isn't copied to, because they
+	// already hold a Secret of the same name that isn't a copy of this grant
+	// +optional
+	// +listType=set
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.secretRef.namespace`
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.spec.secretRef.name`
+
+// CredentialGrant is the Schema for the credentialgrants API.
+// A CredentialGrant authorizes copying a Secret into the selected namespaces.
+// The Secret is only copied into the namespaces holding LeviathanBuilds that
+// reference a Secret of its name, and the copies are deleted once no build
+// references it anymore.
+type CredentialGrant struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the Secret shared and the namespaces it may be copied to
+	// +required
+	Spec CredentialGrantSpec `json:"spec"`
+
+	// status defines the observed state of CredentialGrant
+	// +optional
+	Status CredentialGrantStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// CredentialGrantList contains a list of CredentialGrant
+type CredentialGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CredentialGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CredentialGrant{}, &CredentialGrantList{})
+}
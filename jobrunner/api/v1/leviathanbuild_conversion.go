@@ -0,0 +1,54 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Hub marks this type as a conversion hub. The controller works on v1, and the
+// other versions of LeviathanBuild are converted to and from it.
+func (*LeviathanBuild) Hub() {}
+
+// ClusterBuildFrom converts lvBuild to a LeviathanClusterBuild of the same name
+// and spec. The status and the namespace of lvBuild aren't kept.
+func ClusterBuildFrom(lvBuild *LeviathanBuild) *LeviathanClusterBuild {
+	meta := *lvBuild.ObjectMeta.DeepCopy()
+	meta.Namespace = ""
+	meta.UID, meta.ResourceVersion, meta.Generation = "", "", 0
+	meta.CreationTimestamp, meta.DeletionTimestamp = metav1.Time{}, nil
+	meta.OwnerReferences, meta.Finalizers, meta.ManagedFields = nil, nil, nil
+	return &LeviathanClusterBuild{
+		TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "LeviathanClusterBuild"},
+		ObjectMeta: meta,
+		Spec:       *lvBuild.Spec.DeepCopy(),
+	}
+}
+
+// NamespacedBuild returns the LeviathanBuild standing for clusterBuild in
+// namespace, whose Jobs are constructed like those of any LeviathanBuild. It
+// has the name and UID of clusterBuild, so that the Jobs of clusterBuild are
+// recognized as its own.
+func (clusterBuild *LeviathanClusterBuild) NamespacedBuild(namespace string) *LeviathanBuild {
+	lvBuild := &LeviathanBuild{
+		ObjectMeta: *clusterBuild.ObjectMeta.DeepCopy(),
+		Spec:       *clusterBuild.Spec.DeepCopy(),
+		Status:     *clusterBuild.Status.DeepCopy(),
+	}
+	lvBuild.Namespace = namespace
+	return lvBuild
+}
@@ -3,7 +3,9 @@ package v1
 import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
@@ -12,64 +14,1021 @@ import (
 // More info: https://book.kubebuilder.io/reference/markers/crd-validation.html
 
 // LeviathanBuildSpec defines the desired state of LeviathanBuild
+// +kubebuilder:validation:XValidation:rule="!has(self.sourceType) || self.sourceType != 'Inline' || (has(self.source) && has(self.source.inline))",message="source.inline is required when sourceType is Inline"
+// +kubebuilder:validation:XValidation:rule="!has(self.sourceType) || self.sourceType != 'HTTP' || (has(self.sourceURL) && self.sourceURL.matches('^https?://'))",message="sourceURL must be an http(s) URL when sourceType is HTTP"
+// +kubebuilder:validation:XValidation:rule="!has(self.sourceType) || self.sourceType != 'Git' || !has(self.sourceURL) || self.sourceURL.matches('^(https://|ssh://|git://|git@)')",message="sourceURL must be an https, ssh or git URL when sourceType is Git"
+// +kubebuilder:validation:XValidation:rule="!has(self.sourceType) || self.sourceType != 'S3' || !has(self.sourceURL) || self.sourceURL.matches('^s3://')",message="sourceURL must be an s3 URL when sourceType is S3"
+// +kubebuilder:validation:XValidation:rule="!has(self.pollInterval) || (has(self.sourceURL) && (self.sourceType == 'HTTP' || self.sourceType == 'S3' || (self.sourceType == 'Git' && self.sourceURL.matches('^https://'))))",message="pollInterval requires an HTTP or S3 source, or a Git source with an https sourceURL"
+// +kubebuilder:validation:XValidation:rule="!has(self.pollInterval) || duration(self.pollInterval) >= duration('1m')",message="pollInterval must be at least 1m"
+// +kubebuilder:validation:XValidation:rule="!has(self.onHookFailure) || self.onHookFailure != 'Rollback' || has(self.artifactRetention)",message="artifactRetention is required when onHookFailure is Rollback"
+// +kubebuilder:validation:XValidation:rule="!has(self.signing) || !self.signing.enabled || has(self.publishTarget)",message="publishTarget is required when signing is enabled"
+// +kubebuilder:validation:XValidation:rule="!has(self.clusterSelector) || !has(self.executionBackend) || self.executionBackend == 'Job'",message="clusterSelector requires the Job executionBackend"
+// +kubebuilder:validation:XValidation:rule="!(has(self.verifyReproducibility) && self.verifyReproducibility) || !has(self.buildType) || self.buildType != 'Verify'",message="verifyReproducibility can't be set on a Verify build"
+// +kubebuilder:validation:XValidation:rule="!(has(self.verifyReproducibility) && self.verifyReproducibility) || ((!has(self.executionBackend) || self.executionBackend == 'Job') && !has(self.clusterSelector))",message="verifyReproducibility requires the Job executionBackend in the cluster of the controller"
+// +kubebuilder:validation:XValidation:rule="!has(self.publishApproval) || !self.publishApproval.required || ((!has(self.executionBackend) || self.executionBackend == 'Job') && !has(self.clusterSelector))",message="publishApproval requires the Job executionBackend in the cluster of the controller"
 type LeviathanBuildSpec struct {
 
-	// packageName is the name of the package being built/published
+	// packageName is the name of the package being built/published. It may be
+	// scoped (e.g. "@scope/name") but must not contain spaces or "..".
 	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=214
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9@_][A-Za-z0-9@._~/+-]*$`
+	// +kubebuilder:validation:XValidation:rule="!self.contains('..')",message="packageName must not contain '..'"
 	PackageName *string `json:"packageName"`
 
 	// TODO: Add webhooks to handle default setting on admission
 	// buildType is the type of build
 	// - "Build" (default): runs a build of the given package;
 	// - "BuildPublish": runs a build and publish of the given package;
-	// - "Publish": runs a publish of the given package
+	// - "Publish": runs a publish of the given package;
+	// - "Verify": runs a build that validates a change, such as a pull request,
+	//   without producing or publishing artifacts;
+	// - any other type declared by a BuildTypeDefinition, built like "Build".
 	// +optional
 	// +kubebuilder:default:=Build
 	BuildType BuildType `json:"buildType,omitempty"`
 
+	// language is the language the package is written in. It is used to select
+	// a builder image from the BuilderImageMappings.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	Language *string `json:"language,omitempty"`
+
 	// TODO: Add webhooks to handle default setting on admission
 	// sourceType indicates the type of source that should be pulled from
 	// - "Local" (default): Use a local path for the source
 	// - "Git": Pull the source from git
 	// - "S3": Pull the source from an s3 bucket
+	// - "Inline": Use the script given in source.inline
+	// - "HTTP": Download and extract the tarball or zip archive at sourceURL
+	// +optional
+	// +kubebuilder:default:=Local
+	SourceType SourceType `json:"sourceType,omitempty"`
+
+	// sourcePath indicates the path that the source should be pulled from
+	// +optional
+	// +kubebuilder:validation:MaxLength=4096
+	SourcePath *string `json:"sourcePath,omitempty"`
+
+	// sourceURL indicates the URL that the source should be pulled from. Its scheme
+	// must suit the sourceType: http(s) for "HTTP", https, ssh or git for "Git" and
+	// s3 for "S3".
+	// +optional
+	// +kubebuilder:validation:MaxLength=2048
+	SourceURL *string `json:"sourceURL,omitempty"`
+
+	// pollInterval is how often the source at sourceURL is checked for a new
+	// revision. The build runs again whenever the revision changes, the latest
+	// one found is reported in status.polledRevision. Builds polling the same
+	// source share its checks. The source isn't polled when unset.
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+
+	// rebuildOnImageChange runs the build again whenever the builder image it
+	// resolved from a BuilderImageMapping is pushed with a new digest, with the
+	// image pinned to that digest. Changes are always reported by the
+	// ImageUpdated condition.
+	// +optional
+	RebuildOnImageChange bool `json:"rebuildOnImageChange,omitempty"`
+
+	// source holds configuration specific to the selected sourceType
+	// +optional
+	Source *SourceSpec `json:"source,omitempty"`
+
+	// job defines the job that will be created when executing the given build.
+	// +required
+	JobTemplate batchv1.JobTemplateSpec `json:"jobTemplate"`
+
+	// propagation controls which of the LeviathanBuild's own labels and annotations
+	// are copied onto the Jobs and pod templates created for it.
+	// Labels and annotations set on the jobTemplate are always applied.
+	// +optional
+	Propagation *PropagationSpec `json:"propagation,omitempty"`
+
+	// publishTarget describes the registry the package is published to.
+	// Only used by the "Publish" and "BuildPublish" build types.
+	// +optional
+	PublishTarget *PublishTarget `json:"publishTarget,omitempty"`
+
+	// mutexKey serializes builds against a shared external resource. Builds in the
+	// same namespace with the same mutexKey run one at a time, in the order they
+	// started waiting. The lock is held through a coordination.k8s.io Lease for as
+	// long as the build's Job is running.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	MutexKey *string `json:"mutexKey,omitempty"`
+
+	// volumes are added to the pod spec of the build Job, next to the volumes of the
+	// jobTemplate. Their names must not collide with the volumes of the jobTemplate
+	// or those managed by the controller.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// volumeMounts are added to every container of the build Job. They may refer
+	// to volumes declared in volumes or in the jobTemplate.
+	// +optional
+	// +listType=map
+	// +listMapKey=mountPath
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// sidecars are service containers, such as docker-in-docker, that run next to
+	// the build and are stopped once the build containers have exited, so they
+	// don't keep the Job running. They run as native sidecars on clusters that
+	// support them; on older clusters every build container and sidecar must set a
+	// command, which is wrapped to stop the sidecars.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// jobPatches are applied in order to the Job constructed for the build, right
+	// before it is created, for the fields of the Job the LeviathanBuild doesn't
+	// expose. They are checked at admission against the Job of the jobTemplate, so
+	// JSON6902 operations may only refer to what the jobTemplate sets.
+	// +optional
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=16
+	JobPatches []JobPatch `json:"jobPatches,omitempty"`
+
+	// spotPolicy decides whether the build runs on spot (preemptible) nodes
+	// - "Prefer": runs on spot nodes when some are available;
+	// - "Require": only runs on spot nodes;
+	// - "Avoid": never runs on spot nodes.
+	// A run whose pod is interrupted on a spot node is run again, up to the retry
+	// limit of the controller, rather than reported as failed.
+	// The scheduling of the jobTemplate is left as is when unset.
+	// +optional
+	SpotPolicy SpotPolicy `json:"spotPolicy,omitempty"`
+
+	// protectionPolicy decides whether the pods of the build may be evicted
+	// - "None", the default: the pods may be evicted like any other;
+	// - "ProtectFromEviction": the pods are marked as not safe to evict for the
+	// cluster-autoscaler, run with the priority class configured for the operator
+	// unless the jobTemplate sets one, and a PodDisruptionBudget blocks their
+	// eviction by node drains while the build runs.
+	// +optional
+	ProtectionPolicy ProtectionPolicy `json:"protectionPolicy,omitempty"`
+
+	// checkpoint lets long builds resume after a failure. The build containers
+	// write checkpoints to a persistent volume, and a failed run is run again
+	// with RESUME_FROM_CHECKPOINT set, up to maxResumes times.
+	// +optional
+	Checkpoint *CheckpointSpec `json:"checkpoint,omitempty"`
+
+	// heartbeat tells hung builds from slow ones. The build containers touch the
+	// file at $HEARTBEAT_FILE at least every interval; a build whose file hasn't
+	// been touched for timeout is marked as Stalled, and its run restarted up to
+	// maxRestarts times.
+	// +optional
+	Heartbeat *HeartbeatSpec `json:"heartbeat,omitempty"`
+
+	// lifecycleHooks run commands in the build containers before and after the
+	// build, e.g. to warm caches or flush coverage data, without changing the
+	// builder image.
+	// +optional
+	LifecycleHooks *LifecycleHooks `json:"lifecycleHooks,omitempty"`
+
+	// onSuccess describes what happens once the build has succeeded.
+	// +optional
+	OnSuccess *OnSuccessSpec `json:"onSuccess,omitempty"`
+
+	// onHookFailure specifies what happens when the hooks run once a publishing
+	// build has succeeded, the patchTargets of onSuccess, fail
+	// - "Retry" (default): the hooks are retried every few minutes;
+	// - "Rollback": the version just published is deleted from the publish target,
+	// and the run is marked as failed with status.rolledBack set. The version is
+	// deleted from the store of artifactRetention, which is required.
+	// +optional
+	OnHookFailure HookFailurePolicy `json:"onHookFailure,omitempty"`
+
+	// signing signs the artifact published by every succeeded run with cosign,
+	// and attaches attestations to it. The artifact is the image
+	// <registryURL>/<path>@<digest> of the publishTarget, by the digest the
+	// build reports. The patchTargets of onSuccess are applied once it is signed.
+	// Only used by the "Publish" and "BuildPublish" build types.
+	// +optional
+	Signing *SigningSpec `json:"signing,omitempty"`
+
+	// publishApproval holds the publish of every succeeded run until it is
+	// approved by a PublishApproval, or its timeout passes. Runs are built with
+	// LEVIATHAN_PUBLISH=false, and their containers must not publish then; once
+	// approved, they run again as a Job of their own with LEVIATHAN_PUBLISH=true.
+	// Only used by the "Publish" and "BuildPublish" build types.
+	// +optional
+	PublishApproval *PublishApprovalPolicy `json:"publishApproval,omitempty"`
+
+	// verifyReproducibility runs every succeeded run of the build a second time,
+	// on another node than the first when the scheduler can, and compares the
+	// digests both runs report. The containers of the second run are given
+	// LEVIATHAN_REPRODUCE=true, and must not publish what they build. The outcome
+	// is reported by the NonReproducible condition and status.reproducibility.
+	// Not used by the "Verify" build type.
+	// +optional
+	VerifyReproducibility bool `json:"verifyReproducibility,omitempty"`
+
+	// artifactRetention describes which of the versions published by the build
+	// are kept. Older versions are pruned from the publish target.
+	// Only used by the "Publish" and "BuildPublish" build types.
+	// +optional
+	ArtifactRetention *ArtifactRetention `json:"artifactRetention,omitempty"`
+
+	// network overrides the proxy and trust bundle configured for the operator,
+	// which are injected into every build Job, and sets the network isolation of
+	// the build.
+	// +optional
+	Network *NetworkSpec `json:"network,omitempty"`
+
+	// dnsPolicy sets the DNS policy of the build pods, overriding the one of the
+	// jobTemplate. With None, dnsConfig must list the nameservers of the build.
+	// +optional
+	// +kubebuilder:validation:Enum=ClusterFirst;ClusterFirstWithHostNet;Default;None
+	DNSPolicy corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+
+	// dnsConfig sets the DNS parameters of the build pods, such as the nameservers
+	// and search domains of internal package mirrors, overriding the one of the
+	// jobTemplate. It is merged with the configuration of dnsPolicy.
+	// +optional
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+
+	// hostAliases are added to the hosts file of the build pods, after those of
+	// the jobTemplate.
+	// +optional
+	// +listType=map
+	// +listMapKey=ip
+	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty"`
+
+	// parametersFrom sets the keys of ConfigMaps and Secrets of the namespace of the
+	// build as environment variables of the build containers, which their command,
+	// args and env may refer to as $(KEY). The keys are read when the Job of a run
+	// is created, later sources taking precedence, and the values by the kubelet
+	// when the pods start, so they never appear in the Job or the status of the
+	// build. Keys that aren't valid environment variable names are skipped.
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	ParametersFrom []ParametersSource `json:"parametersFrom,omitempty"`
+
+	// checksumSecrets records a checksum of the data of every Secret the pods of
+	// a run read from, in the jcrs.jcrs.dev/secret-checksums annotation of the
+	// Job and its pod template. When one of them is rotated while the run is
+	// running, the CredentialsRotatedDuringRun condition reports it, as a run
+	// failing to publish may have used the credentials that were replaced.
+	// +optional
+	ChecksumSecrets bool `json:"checksumSecrets,omitempty"`
+
+	// credentials are Secrets that only the containers of the phase needing them
+	// are given, rather than every container of the Job: the source credentials
+	// to those fetching the source, and the registry credentials to those
+	// publishing the package.
+	// +optional
+	Credentials *BuildCredentials `json:"credentials,omitempty"`
+
+	// expiresAfter is how long after its creation the build may wait for its
+	// first Job, e.g. while held back by a MaintenanceWindow, its mutexKey or a
+	// missing builder image. A build that hasn't started by then is marked as
+	// failed with the Expired reason, and its Jobs are never created, rather
+	// than running a stale release late. Builds that have started once don't
+	// expire.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="expiresAfter must be positive"
+	ExpiresAfter *metav1.Duration `json:"expiresAfter,omitempty"`
+
+	// suspend holds back the Jobs of the build: while set, no Job is created,
+	// and a Job that is out of date isn't replaced. A running Job is left to
+	// finish. Suspending or resuming a build doesn't change who triggered it.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// naming names the Jobs of the build, and the ConfigMap of its inline script,
+	// after a template instead of the build. Names must follow a convention of
+	// the cluster, and are checked when the build is admitted.
+	// +optional
+	Naming *NamingSpec `json:"naming,omitempty"`
+
+	// jobPolicy runs the build as an Indexed Job of several shards, which may
+	// succeed before every shard has. It overrides the completions, parallelism,
+	// completionMode and successPolicy of the jobTemplate.
+	// +optional
+	JobPolicy *JobPolicy `json:"jobPolicy,omitempty"`
+
+	// spreadPolicy spreads the pods of the build across nodes or zones, such as
+	// the shards of its jobPolicy, and keeps them apart from the pods of the
+	// other builds of its package.
+	// +optional
+	SpreadPolicy *SpreadPolicy `json:"spreadPolicy,omitempty"`
+
+	// executionBackend is the engine the runs of the build are executed by:
+	// - "Job": a Job, the default;
+	// - "TektonPipelineRun": a Tekton PipelineRun of a single task, whose steps are
+	//   the init containers then the containers of the Job;
+	// - "ArgoWorkflow": an Argo Workflow of a single template running the pod of the Job.
+	// The Job of a run is constructed as usual and converted for the engine, which
+	// must be installed in the cluster. Features acting on the Job once it is
+	// created, such as heartbeats, checkpoints and spot retries, only apply to Jobs,
+	// and Strict network isolation, ProtectFromEviction, checksumSecrets and the
+	// sources fetched by the controller can't be used with other engines.
+	// +optional
+	ExecutionBackend ExecutionBackend `json:"executionBackend,omitempty"`
+
+	// clusterSelector dispatches the Jobs of the build to a remote cluster, among
+	// the Ready ClusterTargets whose labels it selects. The Job of a run is
+	// constructed as usual and created in the cluster, which must hold the
+	// ConfigMaps, Secrets and PersistentVolumeClaims it references. The registry
+	// pull Secret distributed by the controller is copied there, Inline sources
+	// and parametersFrom can't be used. As for other engines, what acts on the
+	// Job once it is created only applies to Jobs run in the cluster of the
+	// controller.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+}
+
+// ExecutionBackend is the engine running the builds.
+// +kubebuilder:validation:Enum=Job;TektonPipelineRun;ArgoWorkflow
+type ExecutionBackend string
+
+const (
+	// JobBackend runs builds as Jobs
+	JobBackend ExecutionBackend = "Job"
+
+	// TektonBackend runs builds as Tekton PipelineRuns
+	TektonBackend ExecutionBackend = "TektonPipelineRun"
+
+	// ArgoBackend runs builds as Argo Workflows
+	ArgoBackend ExecutionBackend = "ArgoWorkflow"
+)
+
+// ParametersSource is a ConfigMap or a Secret whose keys are parameters of a build.
+// Exactly one of configMapRef and secretRef must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.configMapRef) != has(self.secretRef)",message="exactly one of configMapRef and secretRef must be set"
+type ParametersSource struct {
+	// configMapRef names a ConfigMap holding parameters
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// secretRef names a Secret holding parameters
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// optional lets runs start without the source. Runs of builds with a missing
+	// source that isn't optional aren't started until it's created.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// BuildCredentials are the Secrets of a build given to the containers of a single
+// phase. The keys of each Secret are set as environment variables of those
+// containers, and of no other.
+type BuildCredentials struct {
+	// source names a Secret holding the credentials of the source of the build.
+	// It is given to the fetch init container, to the steps of the Fetch phase of
+	// a pipeline, and to the containers of the jobTemplate when they fetch the
+	// source themselves.
+	// +optional
+	Source *corev1.LocalObjectReference `json:"source,omitempty"`
+
+	// registry names a Secret holding the credentials of the registry the package
+	// is published to. It is given to the steps of the Publish phase of a
+	// pipeline, or to the containers of the jobTemplate.
+	// Only used by the "Publish" and "BuildPublish" build types.
+	// +optional
+	Registry *corev1.LocalObjectReference `json:"registry,omitempty"`
+}
+
+// HookFailurePolicy describes what happens when the hooks of a published build fail.
+// +kubebuilder:validation:Enum=Retry;Rollback
+type HookFailurePolicy string
+
+const (
+	// RetryOnHookFailure retries the hooks until they succeed
+	RetryOnHookFailure HookFailurePolicy = "Retry"
+
+	// RollbackOnHookFailure deletes the published version and fails the run
+	RollbackOnHookFailure HookFailurePolicy = "Rollback"
+)
+
+// OnSuccessSpec describes the actions taken after a successful publish.
+type OnSuccessSpec struct {
+	// serviceAccountName names the ServiceAccount, in the build's namespace, that the
+	// controller impersonates to patch the targets. Only the resources it is allowed
+	// to patch can be targeted.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	ServiceAccountName string `json:"serviceAccountName"`
+
+	// patchTargets are patched with server-side apply once a publishing build has
+	// succeeded, e.g. to roll a Deployment to the newly published image. All patches
+	// are validated with a dry run before any of them is applied.
+	// +optional
+	// +listType=atomic
+	PatchTargets []PatchTarget `json:"patchTargets,omitempty"`
+}
+
+// SigningSpec describes how the artifacts published by a build are signed.
+// +kubebuilder:validation:XValidation:rule="!self.enabled || has(self.keyRef) != has(self.keyless)",message="exactly one of keyRef and keyless must be set"
+type SigningSpec struct {
+	// enabled signs the artifact published by every succeeded run
+	// +required
+	Enabled bool `json:"enabled"`
+
+	// keyRef names the Secret, in the build's namespace, holding the cosign key
+	// pair: the private key in cosign.key, its password in cosign.password and the
+	// public key in cosign.pub, as created by cosign generate-key-pair k8s://.
+	// +optional
+	KeyRef *corev1.LocalObjectReference `json:"keyRef,omitempty"`
+
+	// keyless signs with a short-lived certificate issued by Fulcio for the
+	// ServiceAccount of the build, and records the signature in Rekor.
+	// +optional
+	Keyless *KeylessSigning `json:"keyless,omitempty"`
+
+	// attestations are attached to the artifact along with its signature
+	// - "Provenance": the SLSA provenance of the run, recorded by the controller;
+	// - "SBOM": an SPDX software bill of materials, generated from the artifact.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=2
+	Attestations []AttestationType `json:"attestations,omitempty"`
+
+	// credentialsSecretRef names a Secret of type kubernetes.io/dockerconfigjson, in
+	// the build's namespace, with the credentials of the registry the signature and
+	// attestations are pushed to.
+	// +optional
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// PublishApprovalPolicy describes who approves the publish of the runs of a build,
+// and how long runs wait for them.
+type PublishApprovalPolicy struct {
+	// required holds the publish of every succeeded run until it is approved
+	// +required
+	Required bool `json:"required"`
+
+	// approvers may approve the publish of the runs of the build: users by name,
+	// and groups as "group:<name>". Anyone allowed to create PublishApprovals in
+	// the namespace of the build may approve when empty.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=32
+	Approvers []string `json:"approvers,omitempty"`
+
+	// timeout is how long a succeeded run waits for its approval, after which
+	// onTimeout decides what happens. Runs wait for as long as it takes when unset.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// onTimeout decides what happens to runs still waiting once their timeout passed
+	// - "Fail" (default): the run fails without publishing;
+	// - "Publish": the run publishes as if it was approved.
+	// +optional
+	OnTimeout ApprovalTimeoutAction `json:"onTimeout,omitempty"`
+}
+
+// ApprovalTimeoutAction describes what happens to runs whose approval timed out.
+// +kubebuilder:validation:Enum=Fail;Publish
+type ApprovalTimeoutAction string
+
+const (
+	// FailOnApprovalTimeout fails the run without publishing
+	FailOnApprovalTimeout ApprovalTimeoutAction = "Fail"
+
+	// PublishOnApprovalTimeout publishes the run as if it was approved
+	PublishOnApprovalTimeout ApprovalTimeoutAction = "Publish"
+)
+
+// KeylessSigning describes the Sigstore instance of keyless signatures.
+type KeylessSigning struct {
+	// fulcioURL is the URL of the Fulcio certificate authority
+	// +optional
+	// +kubebuilder:default:="https://fulcio.sigstore.dev"
+	// +kubebuilder:validation:Pattern=`^https://`
+	FulcioURL string `json:"fulcioURL,omitempty"`
+
+	// rekorURL is the URL of the Rekor transparency log
+	// +optional
+	// +kubebuilder:default:="https://rekor.sigstore.dev"
+	// +kubebuilder:validation:Pattern=`^https://`
+	RekorURL string `json:"rekorURL,omitempty"`
+}
+
+// AttestationType is an attestation attached to a signed artifact.
+// +kubebuilder:validation:Enum=Provenance;SBOM
+type AttestationType string
+
+const (
+	// ProvenanceAttestation is the SLSA provenance of the run that published the artifact
+	ProvenanceAttestation AttestationType = "Provenance"
+
+	// SBOMAttestation is an SPDX software bill of materials of the artifact
+	SBOMAttestation AttestationType = "SBOM"
+)
+
+// PatchTarget describes an object in the build's namespace and the patch applied to it.
+type PatchTarget struct {
+	// apiVersion of the target, e.g. "apps/v1"
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	APIVersion string `json:"apiVersion"`
+
+	// kind of the target, e.g. "Deployment"
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Kind string `json:"kind"`
+
+	// name of the target
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
+
+	// patch is the partial object applied to the target, without apiVersion, kind
+	// and metadata.name. The references $(DIGEST), $(VERSION) and $(PACKAGE_NAME)
+	// are replaced by the published digest, version and package name.
+	// +required
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Patch runtime.RawExtension `json:"patch"`
+}
+
+// NetworkSpec overrides the network configuration injected into a build Job.
+// Unset fields keep the operator-level configuration, while fields set to an
+// empty value remove it for this build.
+type NetworkSpec struct {
+	// httpProxy is the proxy used for HTTP requests (HTTP_PROXY)
+	// +optional
+	HTTPProxy *string `json:"httpProxy,omitempty"`
+
+	// httpsProxy is the proxy used for HTTPS requests (HTTPS_PROXY)
+	// +optional
+	HTTPSProxy *string `json:"httpsProxy,omitempty"`
+
+	// noProxy lists the hosts that are reached without proxy (NO_PROXY)
+	// +optional
+	NoProxy *string `json:"noProxy,omitempty"`
+
+	// trustBundle selects the CA certificates trusted by the build.
+	// +optional
+	TrustBundle *TrustBundleRef `json:"trustBundle,omitempty"`
+
+	// isolation restricts the traffic of the build pods
+	// - "Default": the build pods are subject to the NetworkPolicies of the namespace only;
+	// - "Strict": a NetworkPolicy is created while the build runs, denying ingress and
+	// allowing egress only to the cluster DNS, the source and the publish target
+	// networks configured for the operator.
+	// +optional
+	// +kubebuilder:default:=Default
+	Isolation NetworkIsolation `json:"isolation,omitempty"`
+}
+
+// NetworkIsolation describes how the traffic of build pods is restricted.
+// +kubebuilder:validation:Enum=Default;Strict
+type NetworkIsolation string
+
+const (
+	// DefaultIsolation leaves the traffic of build pods to the namespace policies
+	DefaultIsolation NetworkIsolation = "Default"
+
+	// StrictIsolation only lets build pods reach DNS, their source and their publish target
+	StrictIsolation NetworkIsolation = "Strict"
+)
+
+// TrustBundleRef selects a PEM encoded CA bundle in a ConfigMap in the build's namespace.
+// The bundle replaces the system trust store of the build, so it should include any
+// public CAs the build relies on.
+type TrustBundleRef struct {
+	// name of the ConfigMap. An empty name disables the trust bundle.
+	// +required
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*)?$`
+	Name string `json:"name"`
+
+	// key of the bundle in the ConfigMap
+	// +optional
+	// +kubebuilder:default:=ca-bundle.crt
+	Key string `json:"key,omitempty"`
+}
+
+// JobPatchType is the kind of a patch to the Job of a build.
+// +kubebuilder:validation:Enum=StrategicMerge;JSON6902
+type JobPatchType string
+
+const (
+	// StrategicMergePatch is a strategic merge patch, as in kubectl patch --type=strategic
+	StrategicMergePatch JobPatchType = "StrategicMerge"
+	// JSON6902Patch is a list of RFC 6902 JSON patch operations
+	JSON6902Patch JobPatchType = "JSON6902"
+)
+
+// JobPatch is a kustomize-style patch to the Job of a build.
+type JobPatch struct {
+	// type of the patch
+	// - "StrategicMerge": a partial Job merged into the Job, as in kubectl patch --type=strategic;
+	// - "JSON6902": a list of JSON patch operations, e.g. [{"op": "add", "path": "/spec/suspend", "value": true}].
+	// +optional
+	// +kubebuilder:default:=StrategicMerge
+	Type JobPatchType `json:"type,omitempty"`
+
+	// patch is the patch, as YAML or JSON
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=65536
+	Patch string `json:"patch"`
+}
+
+// PublishTarget describes where and how a package is published.
+type PublishTarget struct {
+	// registryURL is the base URL of the package registry.
+	// The package version is looked up at <registryURL>/<path>/<version>, the
+	// path being the packageName unless pathTemplate is set.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=2048
+	RegistryURL string `json:"registryURL"`
+
+	// version is the version of the package being published. It is used in URLs
+	// and image tags, so it is limited to letters, digits and "._+-".
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=128
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_][A-Za-z0-9._+-]*$`
+	Version string `json:"version"`
+
+	// conflictPolicy specifies what happens when the version already exists in the registry
+	// - "Fail" (default): fail the build before any Job is created;
+	// - "Skip": skip the build, the existing version is kept;
+	// - "Replace": run the build and overwrite the existing version, the build
+	//   containers are given LEVIATHAN_REPLACE_VERSION=true to do so
+	// +optional
+	// +kubebuilder:default:=Fail
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// pathTemplate is a Go text/template rendering the path of the package under
+	// registryURL, in place of its packageName, e.g.
+	// "{{ .Namespace }}/{{ lower .BuildType }}/{{ .PackageName }}". It is
+	// rendered with the lower and upper functions, and:
+	// - .PackageName, the package built;
+	// - .Revision, the generation of the build spec;
+	// - .BuildType, the buildType of the build;
+	// - .Namespace, the namespace of the build;
+	// - .Version, the version published.
+	// The path is made of segments of letters, digits and "._+@-" separated by
+	// "/", and is checked when the build is admitted. The path rendered for the
+	// current spec is reported in status.publishPath, and set as
+	// LEVIATHAN_PUBLISH_PATH in the build containers.
+	// +optional
+	// +kubebuilder:validation:MaxLength=512
+	PathTemplate string `json:"pathTemplate,omitempty"`
+}
+
+// ConflictPolicy describes how an already published package version is handled.
+// +kubebuilder:validation:Enum=Fail;Skip;Replace
+type ConflictPolicy string
+
+const (
+	// FailOnConflict fails the build when the version already exists
+	FailOnConflict ConflictPolicy = "Fail"
+
+	// SkipOnConflict skips the build when the version already exists
+	SkipOnConflict ConflictPolicy = "Skip"
+
+	// ReplaceOnConflict overwrites the existing version
+	ReplaceOnConflict ConflictPolicy = "Replace"
+)
+
+// ArtifactRetention describes which published versions of a package are kept.
+// A version is pruned once it is beyond keepLast and older than keepDays; the
+// version currently in publishTarget is never pruned.
+type ArtifactRetention struct {
+	// store is the kind of storage the publishTarget registryURL points to
+	// - "S3": <registryURL> is the path-style URL of a bucket and prefix, versions
+	// are the objects at <registryURL>/<path>/<version>;
+	// - "OCI": <registryURL> is a registry and repository prefix, versions are the
+	// tags of the <path> repository. Deleting a tag deletes its manifest,
+	// along with any other tag of the same manifest.
+	// +required
+	Store ArtifactStore `json:"store"`
+
+	// keepLast is the number of most recent versions that are kept
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	KeepLast *int32 `json:"keepLast,omitempty"`
+
+	// keepDays is the number of days a version is kept after it was published
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	KeepDays *int32 `json:"keepDays,omitempty"`
+
+	// keepSuccessOnly prunes the versions published by failed runs, and only
+	// counts the versions of succeeded runs towards keepLast.
+	// +optional
+	KeepSuccessOnly bool `json:"keepSuccessOnly,omitempty"`
+
+	// dryRun only reports the versions that would be pruned in the status,
+	// without deleting them.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ArtifactStore is the kind of storage artifacts are published to.
+// +kubebuilder:validation:Enum=S3;OCI
+type ArtifactStore string
+
+const (
+	// S3ArtifactStore is an S3 compatible bucket
+	S3ArtifactStore ArtifactStore = "S3"
+
+	// OCIArtifactStore is an OCI registry
+	OCIArtifactStore ArtifactStore = "OCI"
+)
+
+// PropagationSpec controls metadata propagation from a LeviathanBuild to its children.
+type PropagationSpec struct {
+	// labels controls which LeviathanBuild labels are propagated.
+	// +optional
+	Labels *PropagationRule `json:"labels,omitempty"`
+
+	// annotations controls which LeviathanBuild annotations are propagated.
+	// +optional
+	Annotations *PropagationRule `json:"annotations,omitempty"`
+}
+
+// PropagationRule selects the metadata keys that are propagated.
+type PropagationRule struct {
+	// policy selects which keys are propagated
+	// - "None" (default): nothing is propagated;
+	// - "All": every key is propagated;
+	// - "Selector": only the keys listed in keys are propagated
+	// +optional
+	// +kubebuilder:default:=None
+	Policy PropagationPolicy `json:"policy,omitempty"`
+
+	// keys lists the keys propagated by the "Selector" policy.
+	// An entry ending in "/" matches every key with that prefix (e.g. "cost.example.com/").
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:items:MaxLength=317
+	Keys []string `json:"keys,omitempty"`
+}
+
+// PropagationPolicy describes which metadata keys are propagated.
+// +kubebuilder:validation:Enum=All;None;Selector
+type PropagationPolicy string
+
+const (
+	// PropagateAll propagates every key
+	PropagateAll PropagationPolicy = "All"
+
+	// PropagateNone propagates nothing
+	PropagateNone PropagationPolicy = "None"
+
+	// PropagateSelector propagates only the selected keys
+	PropagateSelector PropagationPolicy = "Selector"
+)
+
+// BuildType describes how the job will be handled.
+// Only one build type may be specified. Other types than the following are
+// declared by BuildTypeDefinitions. If none is specified, the default is build.
+// +kubebuilder:validation:MaxLength=63
+// +kubebuilder:validation:Pattern=`^[A-Z][A-Za-z0-9]*$`
+type BuildType string
+
+const (
+	// Build runs a build of the given package
+	Build BuildType = "Build"
+
+	// BuildPublish runs a build of the given package, then publishes it
+	BuildPublish BuildType = "BuildPublish"
+
+	// Publish runs a publish of the given package
+	Publish BuildType = "Publish"
+
+	// Verify runs a build of the given package that validates a change, without
+	// producing or publishing artifacts
+	Verify BuildType = "Verify"
+)
+
+// CheckpointSpec describes the checkpoint volume of a build.
+// The volume is mounted into the build containers at $CHECKPOINT_DIR.
+type CheckpointSpec struct {
+	// enabled mounts the checkpoint volume and resumes failed runs
+	// +required
+	Enabled bool `json:"enabled"`
+
+	// claimName names an existing PersistentVolumeClaim holding the checkpoints.
+	// When empty, a claim owned by the build is created.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*)?$`
+	ClaimName string `json:"claimName,omitempty"`
+
+	// size of the claim created for the build
+	// +optional
+	// +kubebuilder:default:="10Gi"
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// storageClassName of the claim created for the build. The default storage
+	// class is used when unset.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// maxResumes is the number of times a failed run is resumed from its checkpoint
+	// before the build is reported as failed.
+	// +optional
+	// +kubebuilder:default:=3
+	// +kubebuilder:validation:Minimum=0
+	MaxResumes int32 `json:"maxResumes,omitempty"`
+}
+
+// HeartbeatSpec describes the heartbeat of a build.
+// +kubebuilder:validation:XValidation:rule="duration(self.timeout) > duration(self.interval)",message="timeout must be longer than interval"
+type HeartbeatSpec struct {
+	// interval is how often the build containers touch $HEARTBEAT_FILE. It is
+	// passed to them in seconds as $HEARTBEAT_INTERVAL.
+	// +optional
+	// +kubebuilder:default:="5m"
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('10s')",message="interval must be at least 10s"
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// timeout is how long the build may go without a heartbeat before it is
+	// marked as Stalled.
+	// +optional
+	// +kubebuilder:default:="15m"
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// maxRestarts is the number of times a stalled run is deleted and run again.
+	// Stalled runs are left running when 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxRestarts int32 `json:"maxRestarts,omitempty"`
+}
+
+// LifecycleHookMode is how the lifecycle hooks of a build are run.
+// +kubebuilder:validation:Enum=Wrapper;ContainerLifecycle
+type LifecycleHookMode string
+
+const (
+	// WrapperHooks run the hooks from a shell script wrapping the command of the
+	// build containers.
+	WrapperHooks LifecycleHookMode = "Wrapper"
+	// ContainerLifecycleHooks run the hooks as the postStart and preStop hooks of
+	// the build containers.
+	ContainerLifecycleHooks LifecycleHookMode = "ContainerLifecycle"
+)
+
+// LifecycleHooks describes the commands run in the build containers around the build.
+// +kubebuilder:validation:XValidation:rule="has(self.preBuild) || has(self.postBuild)",message="at least one of preBuild and postBuild must be set"
+type LifecycleHooks struct {
+	// mode is how the hooks are run:
+	// - "Wrapper" (default): the command of every build container, which must be
+	//   set, is run by /bin/sh after preBuild, and postBuild is run once it has
+	//   exited, whatever its outcome. A failed preBuild fails the container, a
+	//   failed postBuild doesn't change its exit code;
+	// - "ContainerLifecycle": preBuild is the postStart hook of the build
+	//   containers, and postBuild their preStop hook. The kubelet runs postStart
+	//   alongside the command, and preStop only when the container is stopped
+	//   before it exits, e.g. once the activeDeadlineSeconds of the Job has passed.
+	// +optional
+	// +kubebuilder:default:=Wrapper
+	Mode LifecycleHookMode `json:"mode,omitempty"`
+
+	// preBuild runs before the build.
+	// +optional
+	PreBuild *LifecycleHook `json:"preBuild,omitempty"`
+
+	// postBuild runs after the build.
+	// +optional
+	PostBuild *LifecycleHook `json:"postBuild,omitempty"`
+}
+
+// LifecycleHook describes a command run in the build containers.
+type LifecycleHook struct {
+	// command is run in the build container, with its environment, volumes and
+	// working directory. It isn't run in a shell; as for the command of a
+	// container, $(VAR_NAME) references to its environment are expanded.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=32
+	Command []string `json:"command"`
+}
+
+// NamingSpec describes how the child objects of a build are named.
+type NamingSpec struct {
+	// template is a Go text/template rendering the name of the Job of a run,
+	// e.g. "ci-{{ .PackageName }}-r{{ .Revision }}-{{ .RunIndex }}". It is
+	// rendered with:
+	// - .Name, the name of the build;
+	// - .PackageName, the package built;
+	// - .Revision, the generation of the build spec;
+	// - .RunIndex, the index of the run, from 1.
+	// The name must be a DNS-1123 label and differ between runs. The ConfigMap
+	// of an inline script, shared by the runs, is named with .RunIndex 0. A
+	// name already taken by another object blocks the run with the
+	// InvalidJobTemplate condition.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Template string `json:"template"`
+}
+
+// JobPolicy describes the shards of a sharded build.
+// +kubebuilder:validation:XValidation:rule="!has(self.parallelism) || self.parallelism <= self.completions",message="parallelism must not exceed completions"
+type JobPolicy struct {
+	// completions is the number of shards of the build. Each shard runs with its
+	// index, from 0, in $JOB_COMPLETION_INDEX.
+	// +kubebuilder:validation:Minimum=1
+	Completions int32 `json:"completions"`
+
+	// parallelism is the number of shards running at once. All of them run at
+	// once when unset.
 	// +optional
-	// +kubebuilder:default:=Local
-	SourceType SourceType `json:"sourceType,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	Parallelism *int32 `json:"parallelism,omitempty"`
 
-	// sourcePath indicates the path that the source should be pulled from
+	// successPolicy declares the build successful once the given shards
+	// succeeded, and stops the shards still running. The build succeeds once
+	// every shard has when unset. It requires Kubernetes 1.31 or later: on older
+	// clusters, builds with a successPolicy are held back with the
+	// InvalidJobTemplate condition rather than waiting for every shard.
 	// +optional
-	SourcePath *string `json:"sourcePath,omitempty"`
+	SuccessPolicy *batchv1.SuccessPolicy `json:"successPolicy,omitempty"`
+}
 
-	// sourceURL indicates the URL that the source should be pulled from
+// SpreadPolicy spreads the pods of a build across the topology of the cluster.
+type SpreadPolicy struct {
+	// topologySpreadConstraints are added to the pods of the build. Constraints
+	// without a labelSelector spread the pods of the build.
 	// +optional
-	SourceURL *string `json:"sourceURL,omitempty"`
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=8
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
 
-	// job defines the job that will be created when executing the given build.
-	// +required
-	JobTemplate batchv1.JobTemplateSpec `json:"jobTemplate"`
+	// packageAntiAffinity keeps the pods of the build away from the pods of the
+	// builds of the same package in the namespace, the pods of the build included
+	// - "None" (default): pods are scheduled regardless of the package;
+	// - "Preferred": pods prefer a topology domain without pods of the package;
+	// - "Required": pods only run in a topology domain without pods of the
+	//   package, and wait for one otherwise.
+	// +optional
+	PackageAntiAffinity PackageAntiAffinity `json:"packageAntiAffinity,omitempty"`
+
+	// topologyKey is the node label whose values are the topology domains the
+	// package anti-affinity applies to, the node by default.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=317
+	TopologyKey string `json:"topologyKey,omitempty"`
 }
 
-// BuildType describes how the job will be handled.
-// Only one of the following build types may be specified.
-// If none of the following types is specified, the default is build.
-// +kubebuilder:validation:Enum=Build;BuildPublish;Publish
-type BuildType string
+// PackageAntiAffinity describes how the pods of builds of a package keep apart.
+// +kubebuilder:validation:Enum=None;Preferred;Required
+type PackageAntiAffinity string
 
 const (
-	// Build runs a build of the given package
-	Build BuildType = "Build"
+	// NoPackageAntiAffinity schedules pods regardless of the package
+	NoPackageAntiAffinity PackageAntiAffinity = "None"
 
-	// BuildPublish runs a build of the given package, then publishes it
-	BuildPublish BuildType = "BuildPublish"
+	// PreferredPackageAntiAffinity prefers topology domains without pods of the package
+	PreferredPackageAntiAffinity PackageAntiAffinity = "Preferred"
 
-	// Publish runs a publish of the given package
-	Publish BuildType = "Publish"
+	// RequiredPackageAntiAffinity requires topology domains without pods of the package
+	RequiredPackageAntiAffinity PackageAntiAffinity = "Required"
+)
+
+// SpotPolicy describes whether a build runs on spot nodes.
+// +kubebuilder:validation:Enum=Prefer;Require;Avoid
+type SpotPolicy string
+
+const (
+	// SpotPrefer schedules the build on spot nodes when possible
+	SpotPrefer SpotPolicy = "Prefer"
+
+	// SpotRequire only schedules the build on spot nodes
+	SpotRequire SpotPolicy = "Require"
+
+	// SpotAvoid never schedules the build on spot nodes
+	SpotAvoid SpotPolicy = "Avoid"
+)
+
+// ProtectionPolicy describes whether the pods of a build may be evicted.
+// +kubebuilder:validation:Enum=None;ProtectFromEviction
+type ProtectionPolicy string
+
+const (
+	// NoProtection lets the pods of the build be evicted
+	NoProtection ProtectionPolicy = "None"
+
+	// ProtectFromEviction keeps the pods of the build from being evicted by
+	// autoscaler scale-downs and node drains
+	ProtectFromEviction ProtectionPolicy = "ProtectFromEviction"
 )
 
 // SourceType indicates the type of source that should be pulled from
 // Only one of the following build types may be specified.
 // If none of the following types is specified, the default is local.
-// +kubebuilder:validation:Enum=Local;Git;S3
+// +kubebuilder:validation:Enum=Local;Git;S3;Inline;HTTP
 type SourceType string
 
 const (
@@ -81,8 +1040,96 @@ const (
 
 	// Pull the source from an s3 bucket
 	S3Source SourceType = "S3"
+
+	// Use an inline script as the source
+	InlineSource SourceType = "Inline"
+
+	// Download an archive over HTTP(S)
+	HTTPSource SourceType = "HTTP"
+)
+
+// SourceSpec holds configuration specific to a source type.
+type SourceSpec struct {
+	// inline holds the script used by the "Inline" source type
+	// +optional
+	Inline *InlineSourceSpec `json:"inline,omitempty"`
+
+	// http configures the "HTTP" source type
+	// +optional
+	HTTP *HTTPSourceSpec `json:"http,omitempty"`
+
+	// git configures the "Git" source type. Git sources with a git configuration
+	// are cloned into the workspace by the fetch init container; those without are
+	// left to the build containers.
+	// +optional
+	Git *GitSourceSpec `json:"git,omitempty"`
+}
+
+// HTTPSourceSpec configures downloading the source archive over HTTP(S).
+// The archive format (tar, tar.gz or zip) is detected from its content.
+type HTTPSourceSpec struct {
+	// secretRef names a Secret in the build's namespace holding the credentials used
+	// for the download: a "token" key for bearer authentication, or "username" and
+	// "password" keys for basic authentication.
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// cacheClaimName names a PersistentVolumeClaim used to cache the archive between
+	// builds. Cached archives are only downloaded again when the server reports
+	// a change through ETag or Last-Modified.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	CacheClaimName *string `json:"cacheClaimName,omitempty"`
+}
+
+// GitSourceSpec configures the clone of a Git repository by the fetch init
+// container. The default branch of the repository is cloned without its history.
+type GitSourceSpec struct {
+	// submodules selects whether the submodules of the repository are cloned too,
+	// along with their own submodules. They aren't by default.
+	// +optional
+	Submodules GitSubmodules `json:"submodules,omitempty"`
+
+	// lfs fetches the Git LFS objects of the checked out files. They are left as
+	// pointer files by default.
+	// +optional
+	LFS bool `json:"lfs,omitempty"`
+
+	// sparsePaths limits the checkout to these directories of the repository, for
+	// builds of a subtree of a monorepo. The files at the root of the repository
+	// are checked out too. The whole repository is checked out when empty.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=100
+	// +kubebuilder:validation:items:MinLength=1
+	// +kubebuilder:validation:items:MaxLength=4096
+	SparsePaths []string `json:"sparsePaths,omitempty"`
+}
+
+// GitSubmodules describes whether the submodules of a Git repository are cloned.
+// +kubebuilder:validation:Enum=Recursive;None
+type GitSubmodules string
+
+const (
+	// RecursiveSubmodules clones the submodules of the repository, recursively
+	RecursiveSubmodules GitSubmodules = "Recursive"
+
+	// NoSubmodules leaves the submodules of the repository out
+	NoSubmodules GitSubmodules = "None"
 )
 
+// InlineSourceSpec describes a build whose source is a short script.
+// The script is stored in a ConfigMap owned by the LeviathanBuild and mounted
+// into the build containers, where the builder image is expected to execute it.
+type InlineSourceSpec struct {
+	// script is the content of the build script
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=65536
+	Script string `json:"script"`
+}
+
 // LeviathanBuildStatus defines the observed state of LeviathanBuild.
 type LeviathanBuildStatus struct {
 
@@ -97,6 +1144,153 @@ type LeviathanBuildStatus struct {
 	// +optional
 	LastJobTime *metav1.Time `json:"lastJobTime,omitempty"`
 
+	// runIndex is the index of the latest run of the build. It is incremented every
+	// time a new Job is created for the build.
+	// +optional
+	RunIndex int64 `json:"runIndex,omitempty"`
+
+	// publishPath is the path of the package under the registryURL of the
+	// publishTarget, as rendered by its pathTemplate for the current spec. It
+	// is reported as soon as the spec is reconciled, so the path can be
+	// confirmed before a run publishes to it.
+	// +optional
+	PublishPath string `json:"publishPath,omitempty"`
+
+	// publishedDigest is the digest of the artifact published by the current Job,
+	// as reported by the build through its termination message.
+	// +optional
+	PublishedDigest string `json:"publishedDigest,omitempty"`
+
+	// rolledBack is set when the version published by the current Job was deleted
+	// from the publish target because its hooks failed, see spec.onHookFailure.
+	// +optional
+	RolledBack bool `json:"rolledBack,omitempty"`
+
+	// signature describes the signature of the artifact published by the current
+	// Job, and how it is verified, once it is signed. See spec.signing.
+	// +optional
+	Signature *SignatureStatus `json:"signature,omitempty"`
+
+	// reproducibility is the outcome of the second run of the current Job, when
+	// the build verifies its reproducibility. See spec.verifyReproducibility.
+	// +optional
+	Reproducibility *ReproducibilityStatus `json:"reproducibility,omitempty"`
+
+	// publishApproval is the approval of the publish of the current Job, when
+	// the build requires one. See spec.publishApproval.
+	// +optional
+	PublishApproval *PublishApprovalStatus `json:"publishApproval,omitempty"`
+
+	// sourceRevision immutably identifies the source built by the current Job,
+	// e.g. the sha256 digest of a downloaded archive or inline script.
+	// It is empty until the revision has been resolved.
+	// +optional
+	SourceRevision string `json:"sourceRevision,omitempty"`
+
+	// sourceMirror is the s3 URI of the internal copy of the source built by the
+	// current Job, when the operator mirrors fetched sources. The runs of the build
+	// after the first fetch the source from it rather than from sourceURL, until the
+	// source or its polled revision changes.
+	// +optional
+	SourceMirror string `json:"sourceMirror,omitempty"`
+
+	// polledRevision is the latest revision of the source found by polling it,
+	// see spec.pollInterval: the commit of the default branch of a Git source,
+	// or the ETag or Last-Modified date of an HTTP or S3 source.
+	// +optional
+	PolledRevision string `json:"polledRevision,omitempty"`
+
+	// builderImage is the builder image selected by the BuilderImageMappings for
+	// the containers of the jobTemplate that don't set an image.
+	// +optional
+	BuilderImage *ResolvedBuilderImage `json:"builderImage,omitempty"`
+
+	// pipeline is the pipeline of steps run by the build, from the
+	// BuildTypeDefinition of its buildType, when its jobTemplate doesn't set
+	// containers.
+	// +optional
+	Pipeline *ResolvedPipeline `json:"pipeline,omitempty"`
+
+	// buildEnvironment is a snapshot of what the latest run ran with, from which
+	// it can be replayed with `leviathan rerun --exact`.
+	// +optional
+	BuildEnvironment *BuildEnvironment `json:"buildEnvironment,omitempty"`
+
+	// archiveURL is the URL of the record of the build in object storage. The
+	// record is written once a run has finished, and again before the build is
+	// deleted.
+	// +optional
+	ArchiveURL string `json:"archiveURL,omitempty"`
+
+	// estimatedCost is the cost of the latest finished run, estimated from the
+	// resources requested by its pods and how long they ran.
+	// +optional
+	EstimatedCost *EstimatedCost `json:"estimatedCost,omitempty"`
+
+	// spotInterruptions is the number of times in a row the latest run has been
+	// run again after its pod was interrupted on a spot node.
+	// +optional
+	SpotInterruptions int32 `json:"spotInterruptions,omitempty"`
+
+	// checkpointResumes is the number of times the latest run has been resumed
+	// from its checkpoint after failing.
+	// +optional
+	CheckpointResumes int32 `json:"checkpointResumes,omitempty"`
+
+	// stallRestarts is the number of times the latest run has been restarted
+	// after its heartbeat stopped.
+	// +optional
+	StallRestarts int32 `json:"stallRestarts,omitempty"`
+
+	// exportedRunIndex is the latest run whose outcome has been sent to the
+	// CloudEvents sink of the controller.
+	// +optional
+	ExportedRunIndex int64 `json:"exportedRunIndex,omitempty"`
+
+	// recordedRunIndex is the latest run recorded in the external run history
+	// store of the controller.
+	// +optional
+	RecordedRunIndex int64 `json:"recordedRunIndex,omitempty"`
+
+	// archivedLogsRunIndex is the latest run whose logs have been written to the
+	// archive, next to the record of the build.
+	// +optional
+	ArchivedLogsRunIndex int64 `json:"archivedLogsRunIndex,omitempty"`
+
+	// recentRuns summarizes the latest finished runs of the build, newest first,
+	// so that trends in their duration and result show without a history store.
+	// +optional
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=10
+	RecentRuns []RecentRun `json:"recentRuns,omitempty"`
+
+	// cluster is the ClusterTarget the latest run of the build was dispatched to
+	// by its clusterSelector.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// publishedArtifacts are the versions published by the runs of the build
+	// that haven't been pruned, recorded while artifactRetention is set.
+	// +optional
+	PublishedArtifacts []PublishedArtifact `json:"publishedArtifacts,omitempty"`
+
+	// artifactRetention reports the enforcement of the artifactRetention policy.
+	// +optional
+	ArtifactRetention *ArtifactRetentionStatus `json:"artifactRetention,omitempty"`
+
+	// blockingReason explains why the next run of the build isn't starting. It is
+	// unset while nothing holds the build back.
+	// +optional
+	BlockingReason *BlockingReason `json:"blockingReason,omitempty"`
+
+	// progress is the latest steps reported by the build containers of the running
+	// run, the oldest first. It is cleared when a new run starts, and only kept up
+	// to date when the controller follows the logs of builds.
+	// +optional
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=20
+	Progress []ProgressEntry `json:"progress,omitempty"`
+
 	// For Kubernetes API conventions, see:
 	// https://github.com/kubernetes/community/blob/master/contributors/devel/sig-architecture/api-conventions.md#typical-status-properties
 
@@ -109,14 +1303,360 @@ type LeviathanBuildStatus struct {
 	// - "Degraded": the resource failed to reach or maintain its desired state
 	//
 	// The status of each condition is one of True, False, or Unknown.
+	//
+	// The build containers of a run may report conditions of their own, such as
+	// TestsPassed, in their termination message. They are kept until the next
+	// run, with their type prefixed by "build.jcrs.dev/".
 	// +listType=map
 	// +listMapKey=type
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// BlockingReason explains why the next run of a build isn't starting.
+type BlockingReason struct {
+	// reason is the gate holding the build back
+	// - "NamespaceTerminating": the namespace of the build is being deleted;
+	// - "BuilderImageUnresolved": no BuilderImageMapping selects a builder image;
+	// - "ParametersUnresolved": a source of the parameters is missing;
+	// - "InvalidJobTemplate": the Job can't be built, or is refused by the API server;
+	// - "MaintenanceWindow": a MaintenanceWindow is open;
+	// - "PublishNotAuthorized": the build wasn't triggered by a publisher of its package;
+	// - "VersionPublished": the version is already published by the publish target;
+	// - "WaitingForMutex": another build holds the Lease of the mutexKey;
+	// - "WaitingForFetchSlot": as many builds as the controller allows are fetching their source;
+	// - "WaitingForCapacity": the latest run failed for lack of node capacity;
+	// - "NoClusterTarget": no Ready ClusterTarget is selected by the clusterSelector;
+	// - "Suspended": spec.suspend is set.
+	// +required
+	Reason BlockingReasonType `json:"reason"`
+
+	// details describes what the build waits for
+	// +optional
+	Details string `json:"details,omitempty"`
+}
+
+// BlockingReasonType is a gate the next run of a build waits on.
+// +kubebuilder:validation:Enum=NamespaceTerminating;BuilderImageUnresolved;ParametersUnresolved;InvalidJobTemplate;MaintenanceWindow;PublishNotAuthorized;VersionPublished;WaitingForMutex;WaitingForFetchSlot;WaitingForCapacity;NoClusterTarget;Suspended
+type BlockingReasonType string
+
+const (
+	// BlockedByNamespaceTerminating blocks the builds of a namespace being deleted
+	BlockedByNamespaceTerminating BlockingReasonType = "NamespaceTerminating"
+	// BlockedByBuilderImage blocks builds no BuilderImageMapping selects a builder image for
+	BlockedByBuilderImage BlockingReasonType = "BuilderImageUnresolved"
+	// BlockedByParameters blocks builds with a missing source of parameters
+	BlockedByParameters BlockingReasonType = "ParametersUnresolved"
+	// BlockedByInvalidJobTemplate blocks builds whose Job can't be created
+	BlockedByInvalidJobTemplate BlockingReasonType = "InvalidJobTemplate"
+	// BlockedByMaintenanceWindow blocks builds while a MaintenanceWindow is open
+	BlockedByMaintenanceWindow BlockingReasonType = "MaintenanceWindow"
+	// BlockedByPublishAuthorization blocks builds not triggered by a publisher of their package
+	BlockedByPublishAuthorization BlockingReasonType = "PublishNotAuthorized"
+	// BlockedByPublishedVersion blocks builds whose version is already published
+	BlockedByPublishedVersion BlockingReasonType = "VersionPublished"
+	// BlockedByMutex blocks builds waiting for the Lease of their mutexKey
+	BlockedByMutex BlockingReasonType = "WaitingForMutex"
+	// BlockedByFetchSlot blocks builds waiting for other builds to fetch their source
+	BlockedByFetchSlot BlockingReasonType = "WaitingForFetchSlot"
+	// BlockedByCapacity blocks builds waiting for the nodes to recover capacity
+	BlockedByCapacity BlockingReasonType = "WaitingForCapacity"
+	// BlockedByClusterTarget blocks builds no Ready ClusterTarget can run
+	BlockedByClusterTarget BlockingReasonType = "NoClusterTarget"
+	// BlockedBySuspend blocks suspended builds
+	BlockedBySuspend BlockingReasonType = "Suspended"
+)
+
+// PublishApprovalStatus describes the approval of the publish of a run.
+type PublishApprovalStatus struct {
+	// runIndex is the run waiting for its approval
+	// +required
+	RunIndex int64 `json:"runIndex"`
+
+	// requestedAt is when the run succeeded and started waiting
+	// +required
+	RequestedAt metav1.Time `json:"requestedAt"`
+
+	// approval is the PublishApproval that approved the run. It is empty when
+	// the run was published once its timeout passed.
+	// +optional
+	Approval string `json:"approval,omitempty"`
+
+	// approvedBy is the user who approved the run
+	// +optional
+	ApprovedBy string `json:"approvedBy,omitempty"`
+
+	// decidedAt is when the run was approved, or timed out
+	// +optional
+	DecidedAt *metav1.Time `json:"decidedAt,omitempty"`
+
+	// job is the Job publishing the run, once it is approved
+	// +optional
+	Job string `json:"job,omitempty"`
+}
+
+// ReproducibilityStatus describes the second run of a build verifying its reproducibility.
+type ReproducibilityStatus struct {
+	// runIndex is the run reproduced
+	// +required
+	RunIndex int64 `json:"runIndex"`
+
+	// job is the Job running the build a second time
+	// +required
+	Job string `json:"job"`
+
+	// digest is the digest reported by the first run
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// reproducedDigest is the digest reported by the second run, once it succeeded
+	// +optional
+	ReproducedDigest string `json:"reproducedDigest,omitempty"`
+
+	// diffConfigMap is the ConfigMap summarizing what differs between the outputs
+	// of both runs, when their digests don't match. It is deleted with the Job of
+	// the second run.
+	// +optional
+	DiffConfigMap string `json:"diffConfigMap,omitempty"`
+}
+
+// SignatureStatus describes the signature of a published artifact.
+type SignatureStatus struct {
+	// image is the signed artifact, by digest
+	// +required
+	Image string `json:"image"`
+
+	// signedAt is when the signing Job completed
+	// +required
+	SignedAt metav1.Time `json:"signedAt"`
+
+	// attestations are the attestations attached to the artifact
+	// +optional
+	// +listType=set
+	Attestations []AttestationType `json:"attestations,omitempty"`
+
+	// publicKey verifies the signatures made with a keyRef, as the cosign reference
+	// k8s://<namespace>/<secret> of the public key
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// certificateIdentity is the identity of keyless signatures, the ServiceAccount
+	// of the build
+	// +optional
+	CertificateIdentity string `json:"certificateIdentity,omitempty"`
+
+	// certificateOIDCIssuer is the issuer of the identity of keyless signatures
+	// +optional
+	CertificateOIDCIssuer string `json:"certificateOIDCIssuer,omitempty"`
+
+	// rekorURL is the transparency log keyless signatures are recorded in
+	// +optional
+	RekorURL string `json:"rekorURL,omitempty"`
+}
+
+// RunResult is how a run of a build finished.
+// +kubebuilder:validation:Enum=Succeeded;Failed
+type RunResult string
+
+const (
+	// RunSucceeded is the result of runs that succeeded
+	RunSucceeded RunResult = "Succeeded"
+	// RunFailed is the result of runs that failed, or whose published version was rolled back
+	RunFailed RunResult = "Failed"
+)
+
+// RecentRun summarizes a finished run of a build.
+type RecentRun struct {
+	// runIndex is the index of the run
+	// +required
+	RunIndex int64 `json:"runIndex"`
+
+	// startTime is the time the run started. It is unset for runs that never started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// duration is the time the run took, from its start to its completion
+	// +optional
+	Duration metav1.Duration `json:"duration,omitzero"`
+
+	// result is how the run finished
+	// +required
+	Result RunResult `json:"result"`
+
+	// revision is the source revision built, when known
+	// +optional
+	Revision string `json:"revision,omitempty"`
+}
+
+// PublishedArtifact is a version published by a run of a build.
+type PublishedArtifact struct {
+	// registryURL is the registry the version was published to
+	// +required
+	RegistryURL string `json:"registryURL"`
+
+	// version is the published version
+	// +required
+	Version string `json:"version"`
+
+	// path is the path of the package under registryURL the version was
+	// published at, when it isn't the packageName of the build
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// digest is the digest reported by the run, if any
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// runIndex is the run that published the version
+	// +required
+	RunIndex int64 `json:"runIndex"`
+
+	// succeeded is set when the run succeeded
+	// +optional
+	Succeeded bool `json:"succeeded,omitempty"`
+
+	// publishedAt is the time the run finished
+	// +required
+	PublishedAt metav1.Time `json:"publishedAt"`
+}
+
+// ArtifactRetentionStatus reports the versions pruned by the artifactRetention policy.
+type ArtifactRetentionStatus struct {
+	// pending are the versions selected for pruning. They are deleted by the next
+	// pass of the policy, unless it is a dry run.
+	// +optional
+	Pending []string `json:"pending,omitempty"`
+
+	// lastPruned are the versions deleted by the last pass of the policy
+	// +optional
+	LastPruned []string `json:"lastPruned,omitempty"`
+
+	// lastPruneTime is the time versions were last deleted
+	// +optional
+	LastPruneTime *metav1.Time `json:"lastPruneTime,omitempty"`
+
+	// message describes the last failure to delete a version
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ResolvedBuilderImage records the builder image selected for a build.
+type ResolvedBuilderImage struct {
+	// image is the selected builder image, including its tag or digest
+	Image string `json:"image"`
+
+	// mapping is the name of the BuilderImageMapping the image was selected from
+	Mapping string `json:"mapping"`
+
+	// canary is set when the image is the canary image of the matching rule
+	// +optional
+	Canary bool `json:"canary,omitempty"`
+
+	// digest is the latest digest the registry reported for the image. It is
+	// only set while the controller polls builder images.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
+// ResolvedPipeline is the pipeline of steps of a build.
+type ResolvedPipeline struct {
+	// definition is the name of the BuildTypeDefinition the steps come from
+	Definition string `json:"definition"`
+
+	// steps are the steps of the definition in the order they run, with their
+	// command and args rendered for the build
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=16
+	Steps []BuildStep `json:"steps"`
+}
+
+// BuildEnvironment is a snapshot of what a run of a build ran with.
+type BuildEnvironment struct {
+	// runIndex is the run the snapshot was taken for
+	// +required
+	RunIndex int64 `json:"runIndex"`
+
+	// generation is the generation of the spec the run was created from
+	// +required
+	Generation int64 `json:"generation"`
+
+	// operatorVersion is the version of the controller that created the Job of the run
+	// +optional
+	OperatorVersion string `json:"operatorVersion,omitempty"`
+
+	// sourceRevision immutably identifies the source built by the run, once resolved
+	// +optional
+	SourceRevision string `json:"sourceRevision,omitempty"`
+
+	// builderImage is the builder image selected for the containers that don't set an image
+	// +optional
+	BuilderImage string `json:"builderImage,omitempty"`
+
+	// containers are the containers of the Job of the run, init containers first
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Containers []BuildContainer `json:"containers,omitempty"`
+}
+
+// BuildContainer is a container of a run in a BuildEnvironment.
+type BuildContainer struct {
+	// name of the container
+	// +required
+	Name string `json:"name"`
+
+	// image of the container, as set on the Job
+	// +required
+	Image string `json:"image"`
+
+	// imageID is the exact image the container ran, including its digest, as
+	// reported by the kubelet. It is empty until a pod of the run has started.
+	// +optional
+	ImageID string `json:"imageID,omitempty"`
+
+	// parameters are the environment variables the container was given a value for,
+	// not those read from ConfigMaps, Secrets or fields of the pod
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// ProgressEntry is a step reported by a build container, by writing a line
+// "::progress <step>::<message>" to its logs.
+type ProgressEntry struct {
+	// time is when the line was logged
+	// +required
+	Time metav1.MicroTime `json:"time"`
+
+	// step names the step of the build
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Step string `json:"step"`
+
+	// message describes what the step is doing
+	// +optional
+	// +kubebuilder:validation:MaxLength=256
+	Message string `json:"message,omitempty"`
+}
+
+// EstimatedCost is the estimated cost of a run of a build.
+type EstimatedCost struct {
+	// amount is the estimated cost, as a decimal number
+	// +required
+	Amount string `json:"amount"`
+
+	// currency of the amount, as set in the pricing data
+	// +optional
+	Currency string `json:"currency,omitempty"`
+
+	// runIndex is the run the cost was estimated for
+	// +required
+	RunIndex int64 `json:"runIndex"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 
 // LeviathanBuild is the Schema for the leviathanbuilds API
 type LeviathanBuild struct {
@@ -0,0 +1,157 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LeviathanBuildBatchOperationSpec defines the operation and the builds it applies to.
+// +kubebuilder:validation:XValidation:rule="!has(self.parameters) || self.operation == 'Trigger'",message="parameters can only be overridden by a Trigger"
+type LeviathanBuildBatchOperationSpec struct {
+	// operation is what is done to every selected build
+	// - "Trigger": runs the build again, as a new build created like `leviathan rerun` does;
+	// - "Suspend": sets spec.suspend, so no new Job of the build is started;
+	// - "Resume": clears spec.suspend;
+	// - "Cancel": deletes the build, along with its running Job
+	// +required
+	Operation BatchOperationType `json:"operation"`
+
+	// selector selects the LeviathanBuilds of the namespace of the operation.
+	// An empty selector selects every build of the namespace.
+	// +required
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// parameters override the values of parameters of the builds created by a
+	// Trigger, by name, for that run only. Every overridden parameter must be
+	// declared by the spec.parametersFrom of the selected builds, or their runs
+	// aren't started.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=64
+	// +kubebuilder:validation:XValidation:rule="self.all(k, k.matches('^[-._a-zA-Z][-._a-zA-Z0-9]*$'))",message="parameter names must be environment variable names"
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// BatchOperationType is an operation applied to a group of builds.
+// +kubebuilder:validation:Enum=Trigger;Suspend;Resume;Cancel
+type BatchOperationType string
+
+const (
+	// TriggerOperation runs the selected builds again
+	TriggerOperation BatchOperationType = "Trigger"
+
+	// SuspendOperation suspends the selected builds
+	SuspendOperation BatchOperationType = "Suspend"
+
+	// ResumeOperation resumes the selected builds
+	ResumeOperation BatchOperationType = "Resume"
+
+	// CancelOperation deletes the selected builds
+	CancelOperation BatchOperationType = "Cancel"
+)
+
+// Conditions of LeviathanBuildBatchOperations.
+const (
+	// ConditionComplete is True once the operation has been applied
+	ConditionComplete = "Complete"
+	// ReasonBuildsFailed is the reason of Complete when the operation failed for some builds
+	ReasonBuildsFailed = "BuildsFailed"
+	// ReasonInvalidSelector is the reason of Complete when the selector can't be used
+	ReasonInvalidSelector = "InvalidSelector"
+)
+
+// LeviathanBuildBatchOperationStatus defines the observed state of LeviathanBuildBatchOperation.
+type LeviathanBuildBatchOperationStatus struct {
+	// builds is the outcome of the operation for each selected build
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Builds []BatchOperationResult `json:"builds,omitempty"`
+
+	// completionTime is when the operation was applied. An operation is only
+	// applied once.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// conditions represent the current state of the operation. Complete is set
+	// once the operation has been applied, with the reason Applied when it
+	// succeeded for every build, BuildsFailed when it failed for some of them,
+	// or InvalidSelector.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// BatchOperationResult is the outcome of a batch operation for a build.
+type BatchOperationResult struct {
+	// name is the name of the build
+	// +required
+	Name string `json:"name"`
+
+	// succeeded reports whether the operation was applied to the build
+	// +required
+	Succeeded bool `json:"succeeded"`
+
+	// message details the outcome, e.g. the build created by a Trigger or why
+	// the operation failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Operation",type=string,JSONPath=`.spec.operation`
+// +kubebuilder:printcolumn:name="Complete",type=string,JSONPath=`.status.conditions[?(@.type=="Complete")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// LeviathanBuildBatchOperation is the Schema for the leviathanbuildbatchoperations API.
+// A LeviathanBuildBatchOperation triggers, suspends, resumes or cancels the
+// LeviathanBuilds of its namespace selected by a label selector, once, and
+// records the outcome for each of them. It is kept as a record of who operated
+// on which builds. Creating one operates on builds through the controller, so
+// it should only be allowed to those who may change the builds themselves.
+type LeviathanBuildBatchOperation struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the operation and the builds it applies to. It can't be
+	// changed once created.
+	// +required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+	Spec LeviathanBuildBatchOperationSpec `json:"spec"`
+
+	// status defines the observed state of LeviathanBuildBatchOperation
+	// +optional
+	Status LeviathanBuildBatchOperationStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// LeviathanBuildBatchOperationList contains a list of LeviathanBuildBatchOperation
+type LeviathanBuildBatchOperationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LeviathanBuildBatchOperation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LeviathanBuildBatchOperation{}, &LeviathanBuildBatchOperationList{})
+}
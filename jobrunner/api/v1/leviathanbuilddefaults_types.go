@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LeviathanBuildDefaultsName is the name of the LeviathanBuildDefaults of a
+// namespace, which holds at most one.
+const LeviathanBuildDefaultsName = "default"
+
+// LeviathanBuildDefaultsSpec defines the defaults of the builds of a namespace.
+type LeviathanBuildDefaultsSpec struct {
+	// retryPolicy defaults how often the Jobs of builds are retried
+	// +optional
+	RetryPolicy *DefaultRetryPolicy `json:"retryPolicy,omitempty"`
+
+	// resources defaults the compute resources of the containers of the
+	// jobTemplate of builds setting neither requests nor limits
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// DefaultRetryPolicy defines how often the Jobs of builds are retried.
+type DefaultRetryPolicy struct {
+	// backoffLimit defaults the backoffLimit of the jobTemplate of builds
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// activeDeadlineSeconds defaults the activeDeadlineSeconds of the
+	// jobTemplate of builds
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'default'",message="the defaults of a namespace are named default"
+
+// LeviathanBuildDefaults is the Schema for the leviathanbuilddefaults API.
+// The LeviathanBuildDefaults named default of a namespace sets the policy of
+// the builds created in it: the defaulting webhook fills the fields builds
+// leave unset from it. Builds are only defaulted when created, so changes to
+// the defaults apply to the builds created afterwards.
+type LeviathanBuildDefaults struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the defaults of the builds of the namespace
+	// +required
+	Spec LeviathanBuildDefaultsSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// LeviathanBuildDefaultsList contains a list of LeviathanBuildDefaults
+type LeviathanBuildDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LeviathanBuildDefaults `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LeviathanBuildDefaults{}, &LeviathanBuildDefaultsList{})
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LeviathanBuildSummaryName is the name of the LeviathanBuildSummary of every namespace.
+const LeviathanBuildSummaryName = "summary"
+
+// LeviathanBuildSummaryStatus counts the LeviathanBuilds of a namespace.
+type LeviathanBuildSummaryStatus struct {
+	// total is the number of LeviathanBuilds in the namespace
+	// +optional
+	Total int32 `json:"total"`
+
+	// pending is the number of builds whose first run hasn't started yet
+	// +optional
+	Pending int32 `json:"pending"`
+
+	// running is the number of builds whose latest run hasn't finished
+	// +optional
+	Running int32 `json:"running"`
+
+	// succeeded is the number of builds whose latest run succeeded
+	// +optional
+	Succeeded int32 `json:"succeeded"`
+
+	// failed is the number of builds whose latest run failed
+	// +optional
+	Failed int32 `json:"failed"`
+
+	// failed24h is the number of builds whose latest run failed in the last 24 hours
+	// +optional
+	Failed24h int32 `json:"failed24h"`
+
+	// averageDuration is the average duration of the latest finished run of the builds
+	// +optional
+	AverageDuration *metav1.Duration `json:"averageDuration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'summary'",message="the summary of a namespace is named summary"
+// +kubebuilder:printcolumn:name="Total",type=integer,JSONPath=`.status.total`
+// +kubebuilder:printcolumn:name="Running",type=integer,JSONPath=`.status.running`
+// +kubebuilder:printcolumn:name="Failed24h",type=integer,JSONPath=`.status.failed24h`
+// +kubebuilder:printcolumn:name="AvgDuration",type=string,JSONPath=`.status.averageDuration`
+
+// LeviathanBuildSummary is the Schema for the leviathanbuildsummaries API.
+// The controller maintains a single summary, named "summary", in every namespace
+// holding LeviathanBuilds, so `kubectl get leviathanbuildsummaries -A` gives an
+// overview of the builds of the cluster.
+type LeviathanBuildSummary struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// status counts the builds of the namespace
+	// +optional
+	Status LeviathanBuildSummaryStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// LeviathanBuildSummaryList contains a list of LeviathanBuildSummary
+type LeviathanBuildSummaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LeviathanBuildSummary `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LeviathanBuildSummary{}, &LeviathanBuildSummaryList{})
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Package",type=string,JSONPath=`.spec.packageName`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// LeviathanClusterBuild is the Schema for the leviathanclusterbuilds API.
+// A LeviathanClusterBuild is a build of the platform that doesn't belong to a
+// namespace. Its spec is the spec of a LeviathanBuild, and its Jobs run in the
+// build namespace the controller is configured with. Only the Job of a cluster
+// build is managed: the gates and hooks of LeviathanBuilds relying on objects
+// of their namespace, like MaintenanceWindows, mutexes, checkpoints or
+// PackageOwnerships, don't apply to it.
+type LeviathanClusterBuild struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of LeviathanClusterBuild
+	// +required
+	Spec LeviathanBuildSpec `json:"spec"`
+
+	// status defines the observed state of LeviathanClusterBuild
+	// +optional
+	Status LeviathanBuildStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// LeviathanClusterBuildList contains a list of LeviathanClusterBuild
+type LeviathanClusterBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LeviathanClusterBuild `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LeviathanClusterBuild{}, &LeviathanClusterBuildList{})
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProjectLabel puts a LeviathanBuild in the LeviathanProject of its namespace
+// it names. The builds created to run a build again keep its labels, so they
+// belong to its project too.
+const ProjectLabel = "jcrs.jcrs.dev/project"
+
+// LeviathanProjectSpec defines the packages of a project and how they're built.
+type LeviathanProjectSpec struct {
+	// dependencies declares the packages each package of the project depends
+	// on, which are built first when the project is rebuilt. Packages without
+	// an entry don't depend on any other.
+	// +optional
+	// +listType=map
+	// +listMapKey=package
+	// +kubebuilder:validation:MaxItems=256
+	Dependencies []PackageDependencies `json:"dependencies,omitempty"`
+
+	// revision is the source revision the packages of the project are expected
+	// to be built at, e.g. the tag of a release. When unset, the packages are
+	// counted as built at the revision most of them have been built at.
+	// +optional
+	// +kubebuilder:validation:MaxLength=256
+	Revision string `json:"revision,omitempty"`
+
+	// defaults sets the fields the builds of the project leave unset when they
+	// are created. They win over the LeviathanBuildDefaults of the namespace.
+	// +optional
+	Defaults *LeviathanBuildDefaultsSpec `json:"defaults,omitempty"`
+
+	// rebuild triggers the project: every time it's set to a new value, e.g. a
+	// timestamp or the name of a release, the latest build of every package of
+	// the project is run again, in dependency order. A package is only built
+	// once the packages it depends on have been.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	Rebuild string `json:"rebuild,omitempty"`
+}
+
+// PackageDependencies declares the packages a package depends on.
+// +kubebuilder:validation:XValidation:rule="!self.dependsOn.exists(p, p == self.package)",message="a package can't depend on itself"
+type PackageDependencies struct {
+	// package is the packageName of the builds of the package
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Package string `json:"package"`
+
+	// dependsOn are the packages of the project the package depends on
+	// +required
+	// +listType=set
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	DependsOn []string `json:"dependsOn"`
+}
+
+// ProjectPackageState is the state of the latest build of a package of a project.
+// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed;Blocked
+type ProjectPackageState string
+
+const (
+	// PackagePending is the state of a package whose build hasn't started yet
+	PackagePending ProjectPackageState = "Pending"
+	// PackageRunning is the state of a package whose build is running
+	PackageRunning ProjectPackageState = "Running"
+	// PackageSucceeded is the state of a package whose latest run succeeded
+	PackageSucceeded ProjectPackageState = "Succeeded"
+	// PackageFailed is the state of a package whose latest run failed
+	PackageFailed ProjectPackageState = "Failed"
+	// PackageBlocked is the state of a package of a rebuild that isn't built
+	// because a package it depends on failed
+	PackageBlocked ProjectPackageState = "Blocked"
+)
+
+// Conditions of LeviathanProjects.
+const (
+	// ConditionRebuilt reports the latest rebuild of a project. It is Unknown
+	// while the rebuild runs.
+	ConditionRebuilt = "Rebuilt"
+	// ReasonRebuilding is the reason of Rebuilt while packages are being rebuilt
+	ReasonRebuilding = "Rebuilding"
+	// ReasonPackagesRebuilt is the reason of Rebuilt once every package has been rebuilt
+	ReasonPackagesRebuilt = "PackagesRebuilt"
+	// ReasonPackagesFailed is the reason of Rebuilt when some packages failed to rebuild
+	ReasonPackagesFailed = "PackagesFailed"
+	// ReasonDependencyCycle is the reason of Rebuilt when the dependencies of
+	// the packages of a project form a cycle
+	ReasonDependencyCycle = "DependencyCycle"
+)
+
+// LeviathanProjectStatus defines the observed state of LeviathanProject.
+type LeviathanProjectStatus struct {
+	// packages is the number of packages of the project
+	// +optional
+	Packages int32 `json:"packages"`
+
+	// built is the number of packages whose latest run succeeded at revision
+	// +optional
+	Built int32 `json:"built"`
+
+	// revision is the source revision the packages are counted as built at
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// summary sums up the project, e.g. "3/5 packages built at v1.2.0"
+	// +optional
+	Summary string `json:"summary,omitempty"`
+
+	// packageStatuses is the state of the latest build of every package of the
+	// project, by package
+	// +optional
+	// +listType=map
+	// +listMapKey=package
+	PackageStatuses []ProjectPackageStatus `json:"packageStatuses,omitempty"`
+
+	// rebuild is the latest rebuild of the project
+	// +optional
+	Rebuild *ProjectRebuildStatus `json:"rebuild,omitempty"`
+
+	// conditions represent the current state of the project
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ProjectPackageStatus is the state of a package of a project.
+type ProjectPackageStatus struct {
+	// package is the packageName of the builds of the package
+	// +required
+	Package string `json:"package"`
+
+	// build is the name of the latest build of the package
+	// +required
+	Build string `json:"build"`
+
+	// state is the state of the latest run of the build
+	// +required
+	State ProjectPackageState `json:"state"`
+
+	// revision is the source revision the build last built
+	// +optional
+	Revision string `json:"revision,omitempty"`
+}
+
+// ProjectRebuildStatus is the state of a rebuild of a project.
+type ProjectRebuildStatus struct {
+	// rebuild is the spec.rebuild the rebuild was triggered by
+	// +required
+	Rebuild string `json:"rebuild"`
+
+	// startTime is when the rebuild was triggered
+	// +required
+	StartTime metav1.Time `json:"startTime"`
+
+	// completionTime is when the last package was rebuilt, or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// packages is the state of every package of the rebuild, by package
+	// +optional
+	// +listType=map
+	// +listMapKey=package
+	Packages []ProjectPackageStatus `json:"packages,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Built",type=string,JSONPath=`.status.summary`
+// +kubebuilder:printcolumn:name="Rebuilt",type=string,JSONPath=`.status.conditions[?(@.type=="Rebuilt")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// LeviathanProject is the Schema for the leviathanprojects API.
+// A LeviathanProject groups the related packages of a namespace: the
+// LeviathanBuilds labeled with its name under ProjectLabel. It rolls up the
+// state of the latest build of every package, sets the defaults of the builds
+// of the project, and rebuilds all of its packages in dependency order.
+type LeviathanProject struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the packages of the project and how they're built
+	// +optional
+	Spec LeviathanProjectSpec `json:"spec,omitempty,omitzero"`
+
+	// status defines the observed state of LeviathanProject
+	// +optional
+	Status LeviathanProjectStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// LeviathanProjectList contains a list of LeviathanProject
+type LeviathanProjectList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LeviathanProject `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LeviathanProject{}, &LeviathanProjectList{})
+}
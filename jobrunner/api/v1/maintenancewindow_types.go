@@ -0,0 +1,81 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaintenanceWindowSpec defines when the window is open and which builds it holds back.
+type MaintenanceWindowSpec struct {
+	// schedule is the cron schedule at which the window opens, e.g. "0 22 * * 1-5"
+	// for 22:00 on weekdays. It has five fields: minute, hour, day of the month,
+	// month and day of the week.
+	// +required
+	// +kubebuilder:validation:MinLength=9
+	// +kubebuilder:validation:MaxLength=256
+	Schedule string `json:"schedule"`
+
+	// duration is how long the window stays open, at most a week
+	// +required
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s') && duration(self) <= duration('168h')",message="duration must be positive and at most 168h"
+	Duration metav1.Duration `json:"duration"`
+
+	// timeZone is the IANA time zone of the schedule, e.g. "Europe/Paris".
+	// The schedule is in UTC when empty.
+	// +optional
+	// +kubebuilder:validation:MaxLength=64
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// namespaces are the namespaces whose builds are held back by the window.
+	// The window applies to every namespace when empty.
+	// +optional
+	// +listType=set
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// MaintenanceWindow is the Schema for the maintenancewindows API.
+// No new build Jobs are started while a window is open; the Jobs already running
+// finish. Builds annotated with jcrs.jcrs.dev/maintenance-override: "true" are
+// started anyway.
+type MaintenanceWindow struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines when the window is open
+	// +required
+	Spec MaintenanceWindowSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// MaintenanceWindowList contains a list of MaintenanceWindow
+type MaintenanceWindowList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MaintenanceWindow `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MaintenanceWindow{}, &MaintenanceWindowList{})
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PackageOwnershipSpec defines who may publish a package.
+type PackageOwnershipSpec struct {
+	// packageName is the name of the package, as set in the packageName of the
+	// LeviathanBuilds publishing it
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=214
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9@_][A-Za-z0-9@._~/+-]*$`
+	PackageName string `json:"packageName"`
+
+	// publishers are the users allowed to publish the package, by username as
+	// authenticated by the API server, e.g. "jane@example.com" or
+	// "system:serviceaccount:ci:deployer" for a ServiceAccount.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Publishers []string `json:"publishers"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Package",type=string,JSONPath=`.spec.packageName`
+
+// PackageOwnership is the Schema for the packageownerships API.
+// Once a package has a PackageOwnership, its LeviathanBuilds only publish it when
+// they were last triggered by one of its publishers. A package with several
+// PackageOwnerships may be published by the publishers of any of them.
+type PackageOwnership struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines who may publish the package
+	// +required
+	Spec PackageOwnershipSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// PackageOwnershipList contains a list of PackageOwnership
+type PackageOwnershipList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PackageOwnership `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PackageOwnership{}, &PackageOwnershipList{})
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PackagePromotionSpec defines the artifact a promotion copies and where to.
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="the spec of a promotion is immutable, create another promotion instead"
+type PackagePromotionSpec struct {
+	// source is the build whose published artifact is promoted
+	// +required
+	Source PromotionSource `json:"source"`
+
+	// target is where the artifact is promoted to
+	// +required
+	Target PromotionTarget `json:"target"`
+
+	// store is the kind of storage both the source and target registryURLs
+	// point to, see the artifactRetention of LeviathanBuilds
+	// +required
+	Store ArtifactStore `json:"store"`
+
+	// requiredApprovals is the number of distinct users that must approve the
+	// promotion before it is promoted
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	RequiredApprovals int32 `json:"requiredApprovals,omitempty"`
+}
+
+// PromotionSource is a LeviathanBuild whose published artifact is promoted.
+type PromotionSource struct {
+	// namespace of the build. Defaults to the namespace of the promotion.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	Namespace string `json:"namespace,omitempty"`
+
+	// build is the name of the LeviathanBuild. Its latest run must have
+	// succeeded and published the version.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Build string `json:"build"`
+
+	// version is the published version promoted. Defaults to the version of the
+	// publishTarget of the build.
+	// +optional
+	// +kubebuilder:validation:MaxLength=128
+	Version string `json:"version,omitempty"`
+}
+
+// PromotionTarget is where an artifact is promoted to.
+type PromotionTarget struct {
+	// registryURL is the base URL the artifact is copied under, as
+	// <registryURL>/<packageName>/<version>, e.g. the production path of the
+	// registry the build publishes to
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=512
+	RegistryURL string `json:"registryURL"`
+
+	// version the artifact is promoted as. Defaults to the source version.
+	// +optional
+	// +kubebuilder:validation:MaxLength=128
+	Version string `json:"version,omitempty"`
+}
+
+// PromotionApproval is the approval of a promotion by a user.
+type PromotionApproval struct {
+	// approvedBy is the user who approved the promotion. It is set by the
+	// admission webhook to the user updating the status of the promotion, and
+	// can't be set to anyone else.
+	// +required
+	ApprovedBy string `json:"approvedBy"`
+
+	// approvedAt is when the promotion was approved
+	// +optional
+	ApprovedAt metav1.Time `json:"approvedAt,omitzero"`
+
+	// comment is a note of the approver, e.g. the change request it was approved for
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	Comment string `json:"comment,omitempty"`
+}
+
+// Conditions of PackagePromotions.
+const (
+	// ConditionApproved reports whether a promotion has its required approvals
+	ConditionApproved = "Approved"
+	// ReasonAwaitingApproval is the reason of Approved while approvals are
+	// missing, and of the WaitingForApproval, Ready and Succeeded conditions of
+	// LeviathanBuilds while their latest run waits for its publishApproval
+	ReasonAwaitingApproval = "AwaitingApproval"
+	// ReasonApprovalsReceived is the reason of Approved once every required approval was given
+	ReasonApprovalsReceived = "ApprovalsReceived"
+
+	// ConditionPromoted reports whether the artifact of a promotion was
+	// promoted. It is Unknown until it is promoted, or fails to.
+	ConditionPromoted = "Promoted"
+	// ReasonSourceNotReady is the reason of Promoted while the source build
+	// doesn't exist, hasn't succeeded or didn't publish the version
+	ReasonSourceNotReady = "SourceNotReady"
+	// ReasonPromoting is the reason of Promoted while the promotion waits for
+	// its approvals, or is being promoted
+	ReasonPromoting = "Promoting"
+	// ReasonArtifactPromoted is the reason of Promoted once the artifact was copied
+	ReasonArtifactPromoted = "ArtifactPromoted"
+	// ReasonPromotionFailed is the reason of Promoted when copying the artifact failed
+	ReasonPromotionFailed = "PromotionFailed"
+	// ReasonSourceRepublished is the reason of Promoted when the source version
+	// was published again after the promotion pinned its digest, the approvals
+	// being for another artifact
+	ReasonSourceRepublished = "SourceRepublished"
+)
+
+// PackagePromotionStatus defines the observed state of PackagePromotion.
+type PackagePromotionStatus struct {
+	// approvals are the approvals given to the promotion, by user. They can only
+	// be added, through the status subresource, by the users allowed to update
+	// packagepromotions/status.
+	// +optional
+	// +listType=map
+	// +listMapKey=approvedBy
+	// +kubebuilder:validation:MaxItems=32
+	Approvals []PromotionApproval `json:"approvals,omitempty"`
+
+	// packageName is the path of the package of the source build under its
+	// registryURL: its packageName, or the path its pathTemplate rendered
+	// +optional
+	PackageName string `json:"packageName,omitempty"`
+
+	// sourceRegistryURL is the registry the source version was published to
+	// +optional
+	SourceRegistryURL string `json:"sourceRegistryURL,omitempty"`
+
+	// version is the source version promoted
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// digest is the digest of the source version when the promotion first saw
+	// it published. Only this artifact is promoted.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// promotedDigest is the digest of the promoted artifact, as reported by the target
+	// +optional
+	PromotedDigest string `json:"promotedDigest,omitempty"`
+
+	// promotedAt is when the artifact was promoted
+	// +optional
+	PromotedAt *metav1.Time `json:"promotedAt,omitempty"`
+
+	// conditions represent the current state of the promotion
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Build",type=string,JSONPath=`.spec.source.build`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Approved",type=string,JSONPath=`.status.conditions[?(@.type=="Approved")].status`
+// +kubebuilder:printcolumn:name="Promoted",type=string,JSONPath=`.status.conditions[?(@.type=="Promoted")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PackagePromotion is the Schema for the packagepromotions API.
+// A PackagePromotion copies the artifact published by a successful
+// LeviathanBuild, possibly of another namespace, to another registry path,
+// e.g. from the dev path of a registry to its prod path, once enough users
+// approved it. Approvals are added to its status, which only the users bound to
+// the packagepromotion-approver-role can update.
+type PackagePromotion struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the artifact promoted and where to
+	// +required
+	Spec PackagePromotionSpec `json:"spec"`
+
+	// status defines the observed state of PackagePromotion
+	// +optional
+	Status PackagePromotionStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// PackagePromotionList contains a list of PackagePromotion
+type PackagePromotionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PackagePromotion `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PackagePromotion{}, &PackagePromotionList{})
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PublishApprovalSpec defines the run a PublishApproval approves the publish of.
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="the spec of an approval is immutable, create another approval instead"
+type PublishApprovalSpec struct {
+	// build is the name of the LeviathanBuild, in the namespace of the approval
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Build string `json:"build"`
+
+	// runIndex is the run approved. Only the run waiting for its approval is
+	// published, an approval of another run is ignored.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	RunIndex int64 `json:"runIndex"`
+
+	// approvedBy is the user who approved the run. It is set by the admission
+	// webhook to the user creating the approval, and can't be set to anyone else.
+	// +optional
+	ApprovedBy string `json:"approvedBy,omitempty"`
+
+	// comment is a note of the approver, e.g. the change request it was approved for
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	Comment string `json:"comment,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Build",type=string,JSONPath=`.spec.build`
+// +kubebuilder:printcolumn:name="Run",type=integer,JSONPath=`.spec.runIndex`
+// +kubebuilder:printcolumn:name="Approved By",type=string,JSONPath=`.spec.approvedBy`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PublishApproval is the Schema for the publishapprovals API.
+// A PublishApproval approves the publish of a run of a LeviathanBuild whose
+// spec.publishApproval requires one. Only the users bound to the
+// publishapproval-approver-role can create them, and only the approvers of the
+// build, when it names any, are admitted.
+type PublishApproval struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the run approved
+	// +required
+	Spec PublishApprovalSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// PublishApprovalList contains a list of PublishApproval
+type PublishApprovalList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PublishApproval `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PublishApproval{}, &PublishApprovalList{})
+}
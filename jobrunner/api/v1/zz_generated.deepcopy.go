@@ -5,32 +5,2240 @@
 package v1
 
 import (
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactRetention) DeepCopyInto(out *ArtifactRetention) {
+	*out = *in
+	if in.KeepLast != nil {
+		in, out := &in.KeepLast, &out.KeepLast
+		*out = new(int32)
+		**out = **in
+	}
+	if in.KeepDays != nil {
+		in, out := &in.KeepDays, &out.KeepDays
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactRetention.
+func (in *ArtifactRetention) DeepCopy() *ArtifactRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactRetentionStatus) DeepCopyInto(out *ArtifactRetentionStatus) {
+	*out = *in
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastPruned != nil {
+		in, out := &in.LastPruned, &out.LastPruned
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastPruneTime != nil {
+		in, out := &in.LastPruneTime, &out.LastPruneTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactRetentionStatus.
+func (in *ArtifactRetentionStatus) DeepCopy() *ArtifactRetentionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactRetentionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BatchOperationResult) DeepCopyInto(out *BatchOperationResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BatchOperationResult.
+func (in *BatchOperationResult) DeepCopy() *BatchOperationResult {
+	if in == nil {
+		return nil
+	}
+	out := new(BatchOperationResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlockingReason) DeepCopyInto(out *BlockingReason) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlockingReason.
+func (in *BlockingReason) DeepCopy() *BlockingReason {
+	if in == nil {
+		return nil
+	}
+	out := new(BlockingReason)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildContainer) DeepCopyInto(out *BuildContainer) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildContainer.
+func (in *BuildContainer) DeepCopy() *BuildContainer {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildContainer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildCredentials) DeepCopyInto(out *BuildCredentials) {
+	*out = *in
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Registry != nil {
+		in, out := &in.Registry, &out.Registry
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildCredentials.
+func (in *BuildCredentials) DeepCopy() *BuildCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildEnvironment) DeepCopyInto(out *BuildEnvironment) {
+	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]BuildContainer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildEnvironment.
+func (in *BuildEnvironment) DeepCopy() *BuildEnvironment {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildEnvironment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildOutcomes) DeepCopyInto(out *BuildOutcomes) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildOutcomes.
+func (in *BuildOutcomes) DeepCopy() *BuildOutcomes {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildOutcomes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildStep) DeepCopyInto(out *BuildStep) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildStep.
+func (in *BuildStep) DeepCopy() *BuildStep {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildTypeDefinition) DeepCopyInto(out *BuildTypeDefinition) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildTypeDefinition.
+func (in *BuildTypeDefinition) DeepCopy() *BuildTypeDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildTypeDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BuildTypeDefinition) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildTypeDefinitionList) DeepCopyInto(out *BuildTypeDefinitionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BuildTypeDefinition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildTypeDefinitionList.
+func (in *BuildTypeDefinitionList) DeepCopy() *BuildTypeDefinitionList {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildTypeDefinitionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BuildTypeDefinitionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildTypeDefinitionSpec) DeepCopyInto(out *BuildTypeDefinitionSpec) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]BuildStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildTypeDefinitionSpec.
+func (in *BuildTypeDefinitionSpec) DeepCopy() *BuildTypeDefinitionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildTypeDefinitionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuilderImageCanary) DeepCopyInto(out *BuilderImageCanary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuilderImageCanary.
+func (in *BuilderImageCanary) DeepCopy() *BuilderImageCanary {
+	if in == nil {
+		return nil
+	}
+	out := new(BuilderImageCanary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuilderImageMapping) DeepCopyInto(out *BuilderImageMapping) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuilderImageMapping.
+func (in *BuilderImageMapping) DeepCopy() *BuilderImageMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(BuilderImageMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BuilderImageMapping) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuilderImageMappingList) DeepCopyInto(out *BuilderImageMappingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BuilderImageMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuilderImageMappingList.
+func (in *BuilderImageMappingList) DeepCopy() *BuilderImageMappingList {
+	if in == nil {
+		return nil
+	}
+	out := new(BuilderImageMappingList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BuilderImageMappingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuilderImageMappingSpec) DeepCopyInto(out *BuilderImageMappingSpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]BuilderImageRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuilderImageMappingSpec.
+func (in *BuilderImageMappingSpec) DeepCopy() *BuilderImageMappingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BuilderImageMappingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuilderImageMappingStatus) DeepCopyInto(out *BuilderImageMappingStatus) {
+	*out = *in
+	if in.Canaries != nil {
+		in, out := &in.Canaries, &out.Canaries
+		*out = make([]CanaryStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuilderImageMappingStatus.
+func (in *BuilderImageMappingStatus) DeepCopy() *BuilderImageMappingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BuilderImageMappingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuilderImageRule) DeepCopyInto(out *BuilderImageRule) {
+	*out = *in
+	if in.PackageNames != nil {
+		in, out := &in.PackageNames, &out.PackageNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Languages != nil {
+		in, out := &in.Languages, &out.Languages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(BuilderImageCanary)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuilderImageRule.
+func (in *BuilderImageRule) DeepCopy() *BuilderImageRule {
+	if in == nil {
+		return nil
+	}
+	out := new(BuilderImageRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	out.Canary = in.Canary
+	out.Stable = in.Stable
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryStatus.
+func (in *CanaryStatus) DeepCopy() *CanaryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheckpointSpec) DeepCopyInto(out *CheckpointSpec) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheckpointSpec.
+func (in *CheckpointSpec) DeepCopy() *CheckpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CheckpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTarget) DeepCopyInto(out *ClusterTarget) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTarget.
+func (in *ClusterTarget) DeepCopy() *ClusterTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterTarget) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTargetList) DeepCopyInto(out *ClusterTargetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTargetList.
+func (in *ClusterTargetList) DeepCopy() *ClusterTargetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTargetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterTargetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTargetSpec) DeepCopyInto(out *ClusterTargetSpec) {
+	*out = *in
+	out.KubeconfigSecretRef = in.KubeconfigSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTargetSpec.
+func (in *ClusterTargetSpec) DeepCopy() *ClusterTargetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTargetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTargetStatus) DeepCopyInto(out *ClusterTargetStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTargetStatus.
+func (in *ClusterTargetStatus) DeepCopy() *ClusterTargetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTargetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialGrant) DeepCopyInto(out *CredentialGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialGrant.
+func (in *CredentialGrant) DeepCopy() *CredentialGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CredentialGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialGrantList) DeepCopyInto(out *CredentialGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CredentialGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialGrantList.
+func (in *CredentialGrantList) DeepCopy() *CredentialGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CredentialGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialGrantSpec) DeepCopyInto(out *CredentialGrantSpec) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialGrantSpec.
+func (in *CredentialGrantSpec) DeepCopy() *CredentialGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialGrantStatus) DeepCopyInto(out *CredentialGrantStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conflicts != nil {
+		in, out := &in.Conflicts, &out.Conflicts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialGrantStatus.
+func (in *CredentialGrantStatus) DeepCopy() *CredentialGrantStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialGrantStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultRetryPolicy) DeepCopyInto(out *DefaultRetryPolicy) {
+	*out = *in
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultRetryPolicy.
+func (in *DefaultRetryPolicy) DeepCopy() *DefaultRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EstimatedCost) DeepCopyInto(out *EstimatedCost) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EstimatedCost.
+func (in *EstimatedCost) DeepCopy() *EstimatedCost {
+	if in == nil {
+		return nil
+	}
+	out := new(EstimatedCost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSourceSpec) DeepCopyInto(out *GitSourceSpec) {
+	*out = *in
+	if in.SparsePaths != nil {
+		in, out := &in.SparsePaths, &out.SparsePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSourceSpec.
+func (in *GitSourceSpec) DeepCopy() *GitSourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPSourceSpec) DeepCopyInto(out *HTTPSourceSpec) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.CacheClaimName != nil {
+		in, out := &in.CacheClaimName, &out.CacheClaimName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSourceSpec.
+func (in *HTTPSourceSpec) DeepCopy() *HTTPSourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPSourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeartbeatSpec) DeepCopyInto(out *HeartbeatSpec) {
+	*out = *in
+	out.Interval = in.Interval
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeartbeatSpec.
+func (in *HeartbeatSpec) DeepCopy() *HeartbeatSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HeartbeatSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InlineSourceSpec) DeepCopyInto(out *InlineSourceSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InlineSourceSpec.
+func (in *InlineSourceSpec) DeepCopy() *InlineSourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InlineSourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobPatch) DeepCopyInto(out *JobPatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobPatch.
+func (in *JobPatch) DeepCopy() *JobPatch {
+	if in == nil {
+		return nil
+	}
+	out := new(JobPatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobPolicy) DeepCopyInto(out *JobPolicy) {
+	*out = *in
+	if in.Parallelism != nil {
+		in, out := &in.Parallelism, &out.Parallelism
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SuccessPolicy != nil {
+		in, out := &in.SuccessPolicy, &out.SuccessPolicy
+		*out = new(batchv1.SuccessPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobPolicy.
+func (in *JobPolicy) DeepCopy() *JobPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(JobPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeylessSigning) DeepCopyInto(out *KeylessSigning) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeylessSigning.
+func (in *KeylessSigning) DeepCopy() *KeylessSigning {
+	if in == nil {
+		return nil
+	}
+	out := new(KeylessSigning)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LeviathanBuild) DeepCopyInto(out *LeviathanBuild) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuild.
+func (in *LeviathanBuild) DeepCopy() *LeviathanBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildBatchOperation) DeepCopyInto(out *LeviathanBuildBatchOperation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildBatchOperation.
+func (in *LeviathanBuildBatchOperation) DeepCopy() *LeviathanBuildBatchOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildBatchOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanBuildBatchOperation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildBatchOperationList) DeepCopyInto(out *LeviathanBuildBatchOperationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LeviathanBuildBatchOperation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildBatchOperationList.
+func (in *LeviathanBuildBatchOperationList) DeepCopy() *LeviathanBuildBatchOperationList {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildBatchOperationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanBuildBatchOperationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildBatchOperationSpec) DeepCopyInto(out *LeviathanBuildBatchOperationSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildBatchOperationSpec.
+func (in *LeviathanBuildBatchOperationSpec) DeepCopy() *LeviathanBuildBatchOperationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildBatchOperationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildBatchOperationStatus) DeepCopyInto(out *LeviathanBuildBatchOperationStatus) {
+	*out = *in
+	if in.Builds != nil {
+		in, out := &in.Builds, &out.Builds
+		*out = make([]BatchOperationResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildBatchOperationStatus.
+func (in *LeviathanBuildBatchOperationStatus) DeepCopy() *LeviathanBuildBatchOperationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildBatchOperationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildDefaults) DeepCopyInto(out *LeviathanBuildDefaults) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildDefaults.
+func (in *LeviathanBuildDefaults) DeepCopy() *LeviathanBuildDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanBuildDefaults) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildDefaultsList) DeepCopyInto(out *LeviathanBuildDefaultsList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LeviathanBuildDefaults, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildDefaultsList.
+func (in *LeviathanBuildDefaultsList) DeepCopy() *LeviathanBuildDefaultsList {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildDefaultsList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanBuildDefaultsList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildDefaultsSpec) DeepCopyInto(out *LeviathanBuildDefaultsSpec) {
+	*out = *in
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(DefaultRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildDefaultsSpec.
+func (in *LeviathanBuildDefaultsSpec) DeepCopy() *LeviathanBuildDefaultsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildDefaultsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildList) DeepCopyInto(out *LeviathanBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LeviathanBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildList.
+func (in *LeviathanBuildList) DeepCopy() *LeviathanBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildSpec) DeepCopyInto(out *LeviathanBuildSpec) {
+	*out = *in
+	if in.PackageName != nil {
+		in, out := &in.PackageName, &out.PackageName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Language != nil {
+		in, out := &in.Language, &out.Language
+		*out = new(string)
+		**out = **in
+	}
+	if in.SourcePath != nil {
+		in, out := &in.SourcePath, &out.SourcePath
+		*out = new(string)
+		**out = **in
+	}
+	if in.SourceURL != nil {
+		in, out := &in.SourceURL, &out.SourceURL
+		*out = new(string)
+		**out = **in
+	}
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(SourceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.JobTemplate.DeepCopyInto(&out.JobTemplate)
+	if in.Propagation != nil {
+		in, out := &in.Propagation, &out.Propagation
+		*out = new(PropagationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PublishTarget != nil {
+		in, out := &in.PublishTarget, &out.PublishTarget
+		*out = new(PublishTarget)
+		**out = **in
+	}
+	if in.MutexKey != nil {
+		in, out := &in.MutexKey, &out.MutexKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]corev1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]corev1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]corev1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.JobPatches != nil {
+		in, out := &in.JobPatches, &out.JobPatches
+		*out = make([]JobPatch, len(*in))
+		copy(*out, *in)
+	}
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		*out = new(CheckpointSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Heartbeat != nil {
+		in, out := &in.Heartbeat, &out.Heartbeat
+		*out = new(HeartbeatSpec)
+		**out = **in
+	}
+	if in.LifecycleHooks != nil {
+		in, out := &in.LifecycleHooks, &out.LifecycleHooks
+		*out = new(LifecycleHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OnSuccess != nil {
+		in, out := &in.OnSuccess, &out.OnSuccess
+		*out = new(OnSuccessSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Signing != nil {
+		in, out := &in.Signing, &out.Signing
+		*out = new(SigningSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PublishApproval != nil {
+		in, out := &in.PublishApproval, &out.PublishApproval
+		*out = new(PublishApprovalPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ArtifactRetention != nil {
+		in, out := &in.ArtifactRetention, &out.ArtifactRetention
+		*out = new(ArtifactRetention)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNSConfig != nil {
+		in, out := &in.DNSConfig, &out.DNSConfig
+		*out = new(corev1.PodDNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HostAliases != nil {
+		in, out := &in.HostAliases, &out.HostAliases
+		*out = make([]corev1.HostAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ParametersFrom != nil {
+		in, out := &in.ParametersFrom, &out.ParametersFrom
+		*out = make([]ParametersSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(BuildCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExpiresAfter != nil {
+		in, out := &in.ExpiresAfter, &out.ExpiresAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Naming != nil {
+		in, out := &in.Naming, &out.Naming
+		*out = new(NamingSpec)
+		**out = **in
+	}
+	if in.JobPolicy != nil {
+		in, out := &in.JobPolicy, &out.JobPolicy
+		*out = new(JobPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SpreadPolicy != nil {
+		in, out := &in.SpreadPolicy, &out.SpreadPolicy
+		*out = new(SpreadPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildSpec.
+func (in *LeviathanBuildSpec) DeepCopy() *LeviathanBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildStatus) DeepCopyInto(out *LeviathanBuildStatus) {
+	*out = *in
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]corev1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastJobTime != nil {
+		in, out := &in.LastJobTime, &out.LastJobTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Signature != nil {
+		in, out := &in.Signature, &out.Signature
+		*out = new(SignatureStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Reproducibility != nil {
+		in, out := &in.Reproducibility, &out.Reproducibility
+		*out = new(ReproducibilityStatus)
+		**out = **in
+	}
+	if in.PublishApproval != nil {
+		in, out := &in.PublishApproval, &out.PublishApproval
+		*out = new(PublishApprovalStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BuilderImage != nil {
+		in, out := &in.BuilderImage, &out.BuilderImage
+		*out = new(ResolvedBuilderImage)
+		**out = **in
+	}
+	if in.Pipeline != nil {
+		in, out := &in.Pipeline, &out.Pipeline
+		*out = new(ResolvedPipeline)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BuildEnvironment != nil {
+		in, out := &in.BuildEnvironment, &out.BuildEnvironment
+		*out = new(BuildEnvironment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EstimatedCost != nil {
+		in, out := &in.EstimatedCost, &out.EstimatedCost
+		*out = new(EstimatedCost)
+		**out = **in
+	}
+	if in.RecentRuns != nil {
+		in, out := &in.RecentRuns, &out.RecentRuns
+		*out = make([]RecentRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PublishedArtifacts != nil {
+		in, out := &in.PublishedArtifacts, &out.PublishedArtifacts
+		*out = make([]PublishedArtifact, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ArtifactRetention != nil {
+		in, out := &in.ArtifactRetention, &out.ArtifactRetention
+		*out = new(ArtifactRetentionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BlockingReason != nil {
+		in, out := &in.BlockingReason, &out.BlockingReason
+		*out = new(BlockingReason)
+		**out = **in
+	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = make([]ProgressEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildStatus.
+func (in *LeviathanBuildStatus) DeepCopy() *LeviathanBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildSummary) DeepCopyInto(out *LeviathanBuildSummary) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildSummary.
+func (in *LeviathanBuildSummary) DeepCopy() *LeviathanBuildSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanBuildSummary) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildSummaryList) DeepCopyInto(out *LeviathanBuildSummaryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LeviathanBuildSummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildSummaryList.
+func (in *LeviathanBuildSummaryList) DeepCopy() *LeviathanBuildSummaryList {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildSummaryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanBuildSummaryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanBuildSummaryStatus) DeepCopyInto(out *LeviathanBuildSummaryStatus) {
+	*out = *in
+	if in.AverageDuration != nil {
+		in, out := &in.AverageDuration, &out.AverageDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildSummaryStatus.
+func (in *LeviathanBuildSummaryStatus) DeepCopy() *LeviathanBuildSummaryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanBuildSummaryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanClusterBuild) DeepCopyInto(out *LeviathanClusterBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanClusterBuild.
+func (in *LeviathanClusterBuild) DeepCopy() *LeviathanClusterBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanClusterBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanClusterBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanClusterBuildList) DeepCopyInto(out *LeviathanClusterBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LeviathanClusterBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanClusterBuildList.
+func (in *LeviathanClusterBuildList) DeepCopy() *LeviathanClusterBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanClusterBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanClusterBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanProject) DeepCopyInto(out *LeviathanProject) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanProject.
+func (in *LeviathanProject) DeepCopy() *LeviathanProject {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanProject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanProject) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanProjectList) DeepCopyInto(out *LeviathanProjectList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LeviathanProject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanProjectList.
+func (in *LeviathanProjectList) DeepCopy() *LeviathanProjectList {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanProjectList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeviathanProjectList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanProjectSpec) DeepCopyInto(out *LeviathanProjectSpec) {
+	*out = *in
+	if in.Dependencies != nil {
+		in, out := &in.Dependencies, &out.Dependencies
+		*out = make([]PackageDependencies, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(LeviathanBuildDefaultsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanProjectSpec.
+func (in *LeviathanProjectSpec) DeepCopy() *LeviathanProjectSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanProjectSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeviathanProjectStatus) DeepCopyInto(out *LeviathanProjectStatus) {
+	*out = *in
+	if in.PackageStatuses != nil {
+		in, out := &in.PackageStatuses, &out.PackageStatuses
+		*out = make([]ProjectPackageStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rebuild != nil {
+		in, out := &in.Rebuild, &out.Rebuild
+		*out = new(ProjectRebuildStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanProjectStatus.
+func (in *LeviathanProjectStatus) DeepCopy() *LeviathanProjectStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LeviathanProjectStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LifecycleHook) DeepCopyInto(out *LifecycleHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LifecycleHook.
+func (in *LifecycleHook) DeepCopy() *LifecycleHook {
+	if in == nil {
+		return nil
+	}
+	out := new(LifecycleHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LifecycleHooks) DeepCopyInto(out *LifecycleHooks) {
+	*out = *in
+	if in.PreBuild != nil {
+		in, out := &in.PreBuild, &out.PreBuild
+		*out = new(LifecycleHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostBuild != nil {
+		in, out := &in.PostBuild, &out.PostBuild
+		*out = new(LifecycleHook)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LifecycleHooks.
+func (in *LifecycleHooks) DeepCopy() *LifecycleHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(LifecycleHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MaintenanceWindow) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindowList) DeepCopyInto(out *MaintenanceWindowList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindowList.
+func (in *MaintenanceWindowList) DeepCopy() *MaintenanceWindowList {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindowList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MaintenanceWindowList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindowSpec) DeepCopyInto(out *MaintenanceWindowSpec) {
+	*out = *in
+	out.Duration = in.Duration
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindowSpec.
+func (in *MaintenanceWindowSpec) DeepCopy() *MaintenanceWindowSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindowSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamingSpec) DeepCopyInto(out *NamingSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamingSpec.
+func (in *NamingSpec) DeepCopy() *NamingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
+	*out = *in
+	if in.HTTPProxy != nil {
+		in, out := &in.HTTPProxy, &out.HTTPProxy
+		*out = new(string)
+		**out = **in
+	}
+	if in.HTTPSProxy != nil {
+		in, out := &in.HTTPSProxy, &out.HTTPSProxy
+		*out = new(string)
+		**out = **in
+	}
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = new(string)
+		**out = **in
+	}
+	if in.TrustBundle != nil {
+		in, out := &in.TrustBundle, &out.TrustBundle
+		*out = new(TrustBundleRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSpec.
+func (in *NetworkSpec) DeepCopy() *NetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnSuccessSpec) DeepCopyInto(out *OnSuccessSpec) {
+	*out = *in
+	if in.PatchTargets != nil {
+		in, out := &in.PatchTargets, &out.PatchTargets
+		*out = make([]PatchTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnSuccessSpec.
+func (in *OnSuccessSpec) DeepCopy() *OnSuccessSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OnSuccessSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageDependencies) DeepCopyInto(out *PackageDependencies) {
+	*out = *in
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageDependencies.
+func (in *PackageDependencies) DeepCopy() *PackageDependencies {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageDependencies)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageOwnership) DeepCopyInto(out *PackageOwnership) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageOwnership.
+func (in *PackageOwnership) DeepCopy() *PackageOwnership {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageOwnership)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PackageOwnership) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageOwnershipList) DeepCopyInto(out *PackageOwnershipList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PackageOwnership, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageOwnershipList.
+func (in *PackageOwnershipList) DeepCopy() *PackageOwnershipList {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageOwnershipList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PackageOwnershipList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageOwnershipSpec) DeepCopyInto(out *PackageOwnershipSpec) {
+	*out = *in
+	if in.Publishers != nil {
+		in, out := &in.Publishers, &out.Publishers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageOwnershipSpec.
+func (in *PackageOwnershipSpec) DeepCopy() *PackageOwnershipSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageOwnershipSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackagePromotion) DeepCopyInto(out *PackagePromotion) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackagePromotion.
+func (in *PackagePromotion) DeepCopy() *PackagePromotion {
+	if in == nil {
+		return nil
+	}
+	out := new(PackagePromotion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PackagePromotion) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackagePromotionList) DeepCopyInto(out *PackagePromotionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PackagePromotion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackagePromotionList.
+func (in *PackagePromotionList) DeepCopy() *PackagePromotionList {
+	if in == nil {
+		return nil
+	}
+	out := new(PackagePromotionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PackagePromotionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackagePromotionSpec) DeepCopyInto(out *PackagePromotionSpec) {
+	*out = *in
+	out.Source = in.Source
+	out.Target = in.Target
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackagePromotionSpec.
+func (in *PackagePromotionSpec) DeepCopy() *PackagePromotionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PackagePromotionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackagePromotionStatus) DeepCopyInto(out *PackagePromotionStatus) {
+	*out = *in
+	if in.Approvals != nil {
+		in, out := &in.Approvals, &out.Approvals
+		*out = make([]PromotionApproval, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PromotedAt != nil {
+		in, out := &in.PromotedAt, &out.PromotedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackagePromotionStatus.
+func (in *PackagePromotionStatus) DeepCopy() *PackagePromotionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PackagePromotionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParametersSource) DeepCopyInto(out *ParametersSource) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParametersSource.
+func (in *ParametersSource) DeepCopy() *ParametersSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ParametersSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatchTarget) DeepCopyInto(out *PatchTarget) {
+	*out = *in
+	in.Patch.DeepCopyInto(&out.Patch)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchTarget.
+func (in *PatchTarget) DeepCopy() *PatchTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(PatchTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProgressEntry) DeepCopyInto(out *ProgressEntry) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProgressEntry.
+func (in *ProgressEntry) DeepCopy() *ProgressEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(ProgressEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectPackageStatus) DeepCopyInto(out *ProjectPackageStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectPackageStatus.
+func (in *ProjectPackageStatus) DeepCopy() *ProjectPackageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectPackageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectRebuildStatus) DeepCopyInto(out *ProjectRebuildStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Packages != nil {
+		in, out := &in.Packages, &out.Packages
+		*out = make([]ProjectPackageStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectRebuildStatus.
+func (in *ProjectRebuildStatus) DeepCopy() *ProjectRebuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectRebuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionApproval) DeepCopyInto(out *PromotionApproval) {
+	*out = *in
+	in.ApprovedAt.DeepCopyInto(&out.ApprovedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionApproval.
+func (in *PromotionApproval) DeepCopy() *PromotionApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionSource) DeepCopyInto(out *PromotionSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionSource.
+func (in *PromotionSource) DeepCopy() *PromotionSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionTarget) DeepCopyInto(out *PromotionTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionTarget.
+func (in *PromotionTarget) DeepCopy() *PromotionTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropagationRule) DeepCopyInto(out *PropagationRule) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropagationRule.
+func (in *PropagationRule) DeepCopy() *PropagationRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PropagationRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropagationSpec) DeepCopyInto(out *PropagationSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = new(PropagationRule)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = new(PropagationRule)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropagationSpec.
+func (in *PropagationSpec) DeepCopy() *PropagationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PropagationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublishApproval) DeepCopyInto(out *PublishApproval) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuild.
-func (in *LeviathanBuild) DeepCopy() *LeviathanBuild {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublishApproval.
+func (in *PublishApproval) DeepCopy() *PublishApproval {
 	if in == nil {
 		return nil
 	}
-	out := new(LeviathanBuild)
+	out := new(PublishApproval)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *LeviathanBuild) DeepCopyObject() runtime.Object {
+func (in *PublishApproval) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -38,31 +2246,31 @@ func (in *LeviathanBuild) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LeviathanBuildList) DeepCopyInto(out *LeviathanBuildList) {
+func (in *PublishApprovalList) DeepCopyInto(out *PublishApprovalList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]LeviathanBuild, len(*in))
+		*out = make([]PublishApproval, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildList.
-func (in *LeviathanBuildList) DeepCopy() *LeviathanBuildList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublishApprovalList.
+func (in *PublishApprovalList) DeepCopy() *PublishApprovalList {
 	if in == nil {
 		return nil
 	}
-	out := new(LeviathanBuildList)
+	out := new(PublishApprovalList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *LeviathanBuildList) DeepCopyObject() runtime.Object {
+func (in *PublishApprovalList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -70,63 +2278,302 @@ func (in *LeviathanBuildList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LeviathanBuildSpec) DeepCopyInto(out *LeviathanBuildSpec) {
+func (in *PublishApprovalPolicy) DeepCopyInto(out *PublishApprovalPolicy) {
 	*out = *in
-	if in.PackageName != nil {
-		in, out := &in.PackageName, &out.PackageName
-		*out = new(string)
+	if in.Approvers != nil {
+		in, out := &in.Approvers, &out.Approvers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
 		**out = **in
 	}
-	if in.SourcePath != nil {
-		in, out := &in.SourcePath, &out.SourcePath
-		*out = new(string)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublishApprovalPolicy.
+func (in *PublishApprovalPolicy) DeepCopy() *PublishApprovalPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PublishApprovalPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublishApprovalSpec) DeepCopyInto(out *PublishApprovalSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublishApprovalSpec.
+func (in *PublishApprovalSpec) DeepCopy() *PublishApprovalSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PublishApprovalSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublishApprovalStatus) DeepCopyInto(out *PublishApprovalStatus) {
+	*out = *in
+	in.RequestedAt.DeepCopyInto(&out.RequestedAt)
+	if in.DecidedAt != nil {
+		in, out := &in.DecidedAt, &out.DecidedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublishApprovalStatus.
+func (in *PublishApprovalStatus) DeepCopy() *PublishApprovalStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PublishApprovalStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublishTarget) DeepCopyInto(out *PublishTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublishTarget.
+func (in *PublishTarget) DeepCopy() *PublishTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(PublishTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublishedArtifact) DeepCopyInto(out *PublishedArtifact) {
+	*out = *in
+	in.PublishedAt.DeepCopyInto(&out.PublishedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublishedArtifact.
+func (in *PublishedArtifact) DeepCopy() *PublishedArtifact {
+	if in == nil {
+		return nil
+	}
+	out := new(PublishedArtifact)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecentRun) DeepCopyInto(out *RecentRun) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecentRun.
+func (in *RecentRun) DeepCopy() *RecentRun {
+	if in == nil {
+		return nil
+	}
+	out := new(RecentRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReproducibilityStatus) DeepCopyInto(out *ReproducibilityStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReproducibilityStatus.
+func (in *ReproducibilityStatus) DeepCopy() *ReproducibilityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReproducibilityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResolvedBuilderImage) DeepCopyInto(out *ResolvedBuilderImage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedBuilderImage.
+func (in *ResolvedBuilderImage) DeepCopy() *ResolvedBuilderImage {
+	if in == nil {
+		return nil
+	}
+	out := new(ResolvedBuilderImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResolvedPipeline) DeepCopyInto(out *ResolvedPipeline) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]BuildStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedPipeline.
+func (in *ResolvedPipeline) DeepCopy() *ResolvedPipeline {
+	if in == nil {
+		return nil
+	}
+	out := new(ResolvedPipeline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SignatureStatus) DeepCopyInto(out *SignatureStatus) {
+	*out = *in
+	in.SignedAt.DeepCopyInto(&out.SignedAt)
+	if in.Attestations != nil {
+		in, out := &in.Attestations, &out.Attestations
+		*out = make([]AttestationType, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SignatureStatus.
+func (in *SignatureStatus) DeepCopy() *SignatureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SignatureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigningSpec) DeepCopyInto(out *SigningSpec) {
+	*out = *in
+	if in.KeyRef != nil {
+		in, out := &in.KeyRef, &out.KeyRef
+		*out = new(corev1.LocalObjectReference)
 		**out = **in
 	}
-	if in.SourceURL != nil {
-		in, out := &in.SourceURL, &out.SourceURL
-		*out = new(string)
+	if in.Keyless != nil {
+		in, out := &in.Keyless, &out.Keyless
+		*out = new(KeylessSigning)
+		**out = **in
+	}
+	if in.Attestations != nil {
+		in, out := &in.Attestations, &out.Attestations
+		*out = make([]AttestationType, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(corev1.LocalObjectReference)
 		**out = **in
 	}
-	in.JobTemplate.DeepCopyInto(&out.JobTemplate)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildSpec.
-func (in *LeviathanBuildSpec) DeepCopy() *LeviathanBuildSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningSpec.
+func (in *SigningSpec) DeepCopy() *SigningSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(LeviathanBuildSpec)
+	out := new(SigningSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LeviathanBuildStatus) DeepCopyInto(out *LeviathanBuildStatus) {
+func (in *SourceSpec) DeepCopyInto(out *SourceSpec) {
 	*out = *in
-	if in.Active != nil {
-		in, out := &in.Active, &out.Active
-		*out = make([]corev1.ObjectReference, len(*in))
-		copy(*out, *in)
+	if in.Inline != nil {
+		in, out := &in.Inline, &out.Inline
+		*out = new(InlineSourceSpec)
+		**out = **in
 	}
-	if in.LastJobTime != nil {
-		in, out := &in.LastJobTime, &out.LastJobTime
-		*out = (*in).DeepCopy()
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPSourceSpec)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(GitSourceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourceSpec.
+func (in *SourceSpec) DeepCopy() *SourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpreadPolicy) DeepCopyInto(out *SpreadPolicy) {
+	*out = *in
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeviathanBuildStatus.
-func (in *LeviathanBuildStatus) DeepCopy() *LeviathanBuildStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpreadPolicy.
+func (in *SpreadPolicy) DeepCopy() *SpreadPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(LeviathanBuildStatus)
+	out := new(SpreadPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrustBundleRef) DeepCopyInto(out *TrustBundleRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrustBundleRef.
+func (in *TrustBundleRef) DeepCopy() *TrustBundleRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TrustBundleRef)
 	in.DeepCopyInto(out)
 	return out
 }
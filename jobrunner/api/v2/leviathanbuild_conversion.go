@@ -0,0 +1,445 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+v1 is the hub: v2 is converted to and from it by the conversion webhook.
+
+A few combinations of fields have no equivalent in the other version, such as a
+v1 sourceURL on a Local source, or a v2 retryPolicy without a checkpoint. They
+don't change what the build does, but dropping them would change the object of
+a client doing a round trip through the other version. They are kept in an
+annotation instead, and restored when the object is converted back.
+*/
+
+const (
+	// v1FieldsAnnotation holds the fields of a v1 LeviathanBuild that v2 can't represent
+	v1FieldsAnnotation = "jcrs.jcrs.dev/v1-fields"
+	// v2FieldsAnnotation holds the fields of a v2 LeviathanBuild that v1 can't represent
+	v2FieldsAnnotation = "jcrs.jcrs.dev/v2-fields"
+)
+
+// v1Fields are the fields of a v1 spec lost by a conversion to v2.
+type v1Fields struct {
+	SourcePath        *string                       `json:"sourcePath,omitempty"`
+	SourceURL         *string                       `json:"sourceURL,omitempty"`
+	Inline            *jcrsv1.InlineSourceSpec      `json:"inline,omitempty"`
+	HTTP              *jcrsv1.HTTPSourceSpec        `json:"http,omitempty"`
+	Git               *jcrsv1.GitSourceSpec         `json:"git,omitempty"`
+	PublishTarget     *jcrsv1.PublishTarget         `json:"publishTarget,omitempty"`
+	ArtifactRetention *jcrsv1.ArtifactRetention     `json:"artifactRetention,omitempty"`
+	OnSuccess         *jcrsv1.OnSuccessSpec         `json:"onSuccess,omitempty"`
+	OnHookFailure     jcrsv1.HookFailurePolicy      `json:"onHookFailure,omitempty"`
+	Signing           *jcrsv1.SigningSpec           `json:"signing,omitempty"`
+	PublishApproval   *jcrsv1.PublishApprovalPolicy `json:"publishApproval,omitempty"`
+}
+
+// v2Fields are the fields of a v2 spec lost by a conversion to v1.
+type v2Fields struct {
+	Local       *LocalSource    `json:"local,omitempty"`
+	Scheduling  *SchedulingSpec `json:"scheduling,omitempty"`
+	RetryPolicy *RetryPolicy    `json:"retryPolicy,omitempty"`
+}
+
+// ConvertTo converts this LeviathanBuild to the Hub version (v1).
+func (src *LeviathanBuild) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*jcrsv1.LeviathanBuild)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = specToV1(&src.Spec)
+	dst.Status = *src.Status.DeepCopy()
+
+	if fields := unstash[v1Fields](&dst.ObjectMeta, v1FieldsAnnotation); fields != nil {
+		restoreV1Fields(&dst.Spec, fields)
+	}
+	return stash(&dst.ObjectMeta, v2FieldsAnnotation, lostV2Fields(&src.Spec, specFromV1(&dst.Spec)))
+}
+
+// ConvertFrom converts from the Hub version (v1) to this version.
+func (dst *LeviathanBuild) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*jcrsv1.LeviathanBuild)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = specFromV1(&src.Spec)
+	dst.Status = *src.Status.DeepCopy()
+
+	if fields := unstash[v2Fields](&dst.ObjectMeta, v2FieldsAnnotation); fields != nil {
+		restoreV2Fields(&dst.Spec, fields)
+	}
+	return stash(&dst.ObjectMeta, v1FieldsAnnotation, lostV1Fields(&src.Spec, specToV1(&dst.Spec)))
+}
+
+// specToV1 converts a v2 spec to v1.
+func specToV1(src *LeviathanBuildSpec) jcrsv1.LeviathanBuildSpec {
+	dst := jcrsv1.LeviathanBuildSpec{
+		Language:       src.Language,
+		SourceType:     src.Source.Type,
+		JobTemplate:    src.JobTemplate,
+		Propagation:    src.Propagation,
+		Volumes:        src.Volumes,
+		VolumeMounts:   src.VolumeMounts,
+		Sidecars:       src.Sidecars,
+		JobPatches:     src.JobPatches,
+		Heartbeat:      src.Heartbeat,
+		LifecycleHooks: src.LifecycleHooks,
+		Network:        src.Network,
+		DNSPolicy:      src.DNSPolicy,
+		DNSConfig:      src.DNSConfig,
+		HostAliases:    src.HostAliases,
+		ParametersFrom: src.ParametersFrom,
+		ExpiresAfter:   src.ExpiresAfter,
+		Suspend:        src.Suspend,
+		Naming:         src.Naming,
+		JobPolicy:      src.JobPolicy,
+		PollInterval:   src.Source.PollInterval,
+
+		ExecutionBackend:      src.ExecutionBackend,
+		VerifyReproducibility: src.VerifyReproducibility,
+		RebuildOnImageChange:  src.RebuildOnImageChange,
+		ChecksumSecrets:       src.ChecksumSecrets,
+		Credentials:           src.Credentials,
+	}
+	if src.PackageName != "" {
+		dst.PackageName = ptr.To(src.PackageName)
+	}
+
+	source := &jcrsv1.SourceSpec{Inline: src.Source.Inline}
+	switch {
+	case src.Source.Local != nil:
+		dst.SourcePath = src.Source.Local.Path
+	case src.Source.Git != nil:
+		dst.SourceURL, dst.SourcePath = src.Source.Git.URL, src.Source.Git.Path
+		git := jcrsv1.GitSourceSpec{Submodules: src.Source.Git.Submodules, LFS: src.Source.Git.LFS, SparsePaths: src.Source.Git.SparsePaths}
+		if !equality.Semantic.DeepEqual(git, jcrsv1.GitSourceSpec{}) {
+			source.Git = &git
+		}
+	case src.Source.S3 != nil:
+		dst.SourceURL, dst.SourcePath = src.Source.S3.URL, src.Source.S3.Path
+	case src.Source.HTTP != nil:
+		dst.SourcePath = src.Source.HTTP.Path
+		if src.Source.HTTP.URL != "" {
+			dst.SourceURL = ptr.To(src.Source.HTTP.URL)
+		}
+		if src.Source.HTTP.SecretRef != nil || src.Source.HTTP.CacheClaimName != nil {
+			source.HTTP = &jcrsv1.HTTPSourceSpec{SecretRef: src.Source.HTTP.SecretRef, CacheClaimName: src.Source.HTTP.CacheClaimName}
+		}
+	}
+	if source.Inline != nil || source.HTTP != nil || source.Git != nil {
+		dst.Source = source
+	}
+
+	dst.BuildType = jcrsv1.Build
+	if src.BuildType != "" {
+		dst.BuildType = src.BuildType
+	}
+	if src.Verify {
+		dst.BuildType = jcrsv1.Verify
+	}
+	if publish := src.Publish; publish != nil {
+		dst.BuildType = jcrsv1.BuildPublish
+		if publish.Mode == PublishOnly {
+			dst.BuildType = jcrsv1.Publish
+		}
+		if publish.Target != (jcrsv1.PublishTarget{}) {
+			dst.PublishTarget = ptr.To(publish.Target)
+		}
+		dst.ArtifactRetention = publish.ArtifactRetention
+		dst.OnSuccess = publish.OnSuccess
+		dst.OnHookFailure = publish.OnHookFailure
+		dst.Signing = publish.Signing
+		dst.PublishApproval = publish.Approval
+	}
+
+	if scheduling := src.Scheduling; scheduling != nil {
+		dst.SpotPolicy = scheduling.SpotPolicy
+		dst.ProtectionPolicy = scheduling.ProtectionPolicy
+		dst.MutexKey = scheduling.MutexKey
+		dst.SpreadPolicy = scheduling.SpreadPolicy
+		dst.ClusterSelector = scheduling.ClusterSelector
+	}
+
+	if checkpoint := src.Checkpoint; checkpoint != nil {
+		dst.Checkpoint = &jcrsv1.CheckpointSpec{
+			Enabled:          checkpoint.Enabled,
+			ClaimName:        checkpoint.ClaimName,
+			Size:             checkpoint.Size,
+			StorageClassName: checkpoint.StorageClassName,
+		}
+		if src.RetryPolicy != nil {
+			dst.Checkpoint.MaxResumes = src.RetryPolicy.MaxResumes
+		}
+	}
+	return dst
+}
+
+// specFromV1 converts a v1 spec to v2.
+func specFromV1(src *jcrsv1.LeviathanBuildSpec) LeviathanBuildSpec {
+	dst := LeviathanBuildSpec{
+		PackageName:    ptr.Deref(src.PackageName, ""),
+		Language:       src.Language,
+		Source:         BuildSource{Type: src.SourceType, PollInterval: src.PollInterval},
+		JobTemplate:    src.JobTemplate,
+		Propagation:    src.Propagation,
+		Volumes:        src.Volumes,
+		VolumeMounts:   src.VolumeMounts,
+		Sidecars:       src.Sidecars,
+		JobPatches:     src.JobPatches,
+		Heartbeat:      src.Heartbeat,
+		LifecycleHooks: src.LifecycleHooks,
+		Network:        src.Network,
+		DNSPolicy:      src.DNSPolicy,
+		DNSConfig:      src.DNSConfig,
+		HostAliases:    src.HostAliases,
+		ParametersFrom: src.ParametersFrom,
+		ExpiresAfter:   src.ExpiresAfter,
+		Suspend:        src.Suspend,
+		Naming:         src.Naming,
+		JobPolicy:      src.JobPolicy,
+
+		ExecutionBackend:      src.ExecutionBackend,
+		VerifyReproducibility: src.VerifyReproducibility,
+		RebuildOnImageChange:  src.RebuildOnImageChange,
+		ChecksumSecrets:       src.ChecksumSecrets,
+		Credentials:           src.Credentials,
+	}
+
+	source := ptr.Deref(src.Source, jcrsv1.SourceSpec{})
+	switch src.SourceType {
+	case jcrsv1.LocalSource, "":
+		if src.SourcePath != nil {
+			dst.Source.Local = &LocalSource{Path: src.SourcePath}
+		}
+	case jcrsv1.GitSource:
+		dst.Source.Git = &GitSource{URL: src.SourceURL, Path: src.SourcePath}
+		if source.Git != nil {
+			dst.Source.Git.Submodules = source.Git.Submodules
+			dst.Source.Git.LFS = source.Git.LFS
+			dst.Source.Git.SparsePaths = source.Git.SparsePaths
+		}
+	case jcrsv1.S3Source:
+		dst.Source.S3 = &S3Source{URL: src.SourceURL, Path: src.SourcePath}
+	case jcrsv1.HTTPSource:
+		dst.Source.HTTP = &HTTPSource{URL: ptr.Deref(src.SourceURL, ""), Path: src.SourcePath}
+		if source.HTTP != nil {
+			dst.Source.HTTP.SecretRef = source.HTTP.SecretRef
+			dst.Source.HTTP.CacheClaimName = source.HTTP.CacheClaimName
+		}
+	case jcrsv1.InlineSource:
+		dst.Source.Inline = source.Inline
+	}
+
+	switch src.BuildType {
+	case "", jcrsv1.Build:
+		// Built-in builds are the default
+	case jcrsv1.Verify:
+		dst.Verify = true
+	case jcrsv1.BuildPublish, jcrsv1.Publish:
+		dst.Publish = &PublishSpec{
+			Mode:              PublishAfterBuild,
+			Target:            ptr.Deref(src.PublishTarget, jcrsv1.PublishTarget{}),
+			ArtifactRetention: src.ArtifactRetention,
+			OnSuccess:         src.OnSuccess,
+			OnHookFailure:     src.OnHookFailure,
+			Signing:           src.Signing,
+			Approval:          src.PublishApproval,
+		}
+		if src.BuildType == jcrsv1.Publish {
+			dst.Publish.Mode = PublishOnly
+		}
+	default:
+		dst.BuildType = src.BuildType
+	}
+
+	if src.SpotPolicy != "" || src.ProtectionPolicy != "" || src.MutexKey != nil || src.SpreadPolicy != nil || src.ClusterSelector != nil {
+		dst.Scheduling = &SchedulingSpec{SpotPolicy: src.SpotPolicy, ProtectionPolicy: src.ProtectionPolicy, MutexKey: src.MutexKey,
+			SpreadPolicy: src.SpreadPolicy, ClusterSelector: src.ClusterSelector}
+	}
+
+	if checkpoint := src.Checkpoint; checkpoint != nil {
+		dst.Checkpoint = &CheckpointSpec{
+			Enabled:          checkpoint.Enabled,
+			ClaimName:        checkpoint.ClaimName,
+			Size:             checkpoint.Size,
+			StorageClassName: checkpoint.StorageClassName,
+		}
+		if checkpoint.MaxResumes != 0 {
+			dst.RetryPolicy = &RetryPolicy{MaxResumes: checkpoint.MaxResumes}
+		}
+	}
+	return dst
+}
+
+// lostV1Fields returns the fields of spec that were lost by a round trip
+// through v2, or nil.
+func lostV1Fields(spec *jcrsv1.LeviathanBuildSpec, roundTripped jcrsv1.LeviathanBuildSpec) *v1Fields {
+	fields := v1Fields{}
+	lost := func(a, b any) bool { return !equality.Semantic.DeepEqual(a, b) }
+	if lost(spec.SourcePath, roundTripped.SourcePath) {
+		fields.SourcePath = spec.SourcePath
+	}
+	if lost(spec.SourceURL, roundTripped.SourceURL) {
+		fields.SourceURL = spec.SourceURL
+	}
+	source, roundTrippedSource := ptr.Deref(spec.Source, jcrsv1.SourceSpec{}), ptr.Deref(roundTripped.Source, jcrsv1.SourceSpec{})
+	if lost(source.Inline, roundTrippedSource.Inline) {
+		fields.Inline = source.Inline
+	}
+	if lost(source.HTTP, roundTrippedSource.HTTP) {
+		fields.HTTP = source.HTTP
+	}
+	if lost(source.Git, roundTrippedSource.Git) {
+		fields.Git = source.Git
+	}
+	if lost(spec.PublishTarget, roundTripped.PublishTarget) {
+		fields.PublishTarget = spec.PublishTarget
+	}
+	if lost(spec.ArtifactRetention, roundTripped.ArtifactRetention) {
+		fields.ArtifactRetention = spec.ArtifactRetention
+	}
+	if lost(spec.OnSuccess, roundTripped.OnSuccess) {
+		fields.OnSuccess = spec.OnSuccess
+	}
+	if lost(spec.OnHookFailure, roundTripped.OnHookFailure) {
+		fields.OnHookFailure = spec.OnHookFailure
+	}
+	if lost(spec.Signing, roundTripped.Signing) {
+		fields.Signing = spec.Signing
+	}
+	if lost(spec.PublishApproval, roundTripped.PublishApproval) {
+		fields.PublishApproval = spec.PublishApproval
+	}
+	if fields == (v1Fields{}) {
+		return nil
+	}
+	return &fields
+}
+
+// restoreV1Fields sets the lost fields of spec that haven't been set since.
+func restoreV1Fields(spec *jcrsv1.LeviathanBuildSpec, fields *v1Fields) {
+	if spec.SourcePath == nil {
+		spec.SourcePath = fields.SourcePath
+	}
+	if spec.SourceURL == nil {
+		spec.SourceURL = fields.SourceURL
+	}
+	if fields.Inline != nil || fields.HTTP != nil || fields.Git != nil {
+		if spec.Source == nil {
+			spec.Source = &jcrsv1.SourceSpec{}
+		}
+		if spec.Source.Inline == nil {
+			spec.Source.Inline = fields.Inline
+		}
+		if spec.Source.HTTP == nil {
+			spec.Source.HTTP = fields.HTTP
+		}
+		if spec.Source.Git == nil {
+			spec.Source.Git = fields.Git
+		}
+	}
+	if spec.PublishTarget == nil {
+		spec.PublishTarget = fields.PublishTarget
+	}
+	if spec.ArtifactRetention == nil {
+		spec.ArtifactRetention = fields.ArtifactRetention
+	}
+	if spec.OnSuccess == nil {
+		spec.OnSuccess = fields.OnSuccess
+	}
+	if spec.OnHookFailure == "" {
+		spec.OnHookFailure = fields.OnHookFailure
+	}
+	if spec.Signing == nil {
+		spec.Signing = fields.Signing
+	}
+	if spec.PublishApproval == nil {
+		spec.PublishApproval = fields.PublishApproval
+	}
+}
+
+// lostV2Fields returns the fields of spec that were lost by a round trip
+// through v1, or nil.
+func lostV2Fields(spec *LeviathanBuildSpec, roundTripped LeviathanBuildSpec) *v2Fields {
+	fields := v2Fields{}
+	lost := func(a, b any) bool { return !equality.Semantic.DeepEqual(a, b) }
+	if lost(spec.Source.Local, roundTripped.Source.Local) {
+		fields.Local = spec.Source.Local
+	}
+	if lost(spec.Scheduling, roundTripped.Scheduling) {
+		fields.Scheduling = spec.Scheduling
+	}
+	if lost(spec.RetryPolicy, roundTripped.RetryPolicy) {
+		fields.RetryPolicy = spec.RetryPolicy
+	}
+	if fields == (v2Fields{}) {
+		return nil
+	}
+	return &fields
+}
+
+// restoreV2Fields sets the lost fields of spec that haven't been set since.
+func restoreV2Fields(spec *LeviathanBuildSpec, fields *v2Fields) {
+	if spec.Source.Local == nil && (spec.Source.Type == jcrsv1.LocalSource || spec.Source.Type == "") {
+		spec.Source.Local = fields.Local
+	}
+	if spec.Scheduling == nil {
+		spec.Scheduling = fields.Scheduling
+	}
+	if spec.RetryPolicy == nil {
+		spec.RetryPolicy = fields.RetryPolicy
+	}
+}
+
+// stash records fields, if any, in the annotation key of meta.
+func stash[T any](meta *metav1.ObjectMeta, key string, fields *T) error {
+	if fields == nil {
+		return nil
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	metav1.SetMetaDataAnnotation(meta, key, string(raw))
+	return nil
+}
+
+// unstash removes the annotation key from meta and returns the fields it recorded.
+// An annotation that can't be parsed is dropped, rather than failing the conversion
+// and making the object unreadable.
+func unstash[T any](meta *metav1.ObjectMeta, key string) *T {
+	raw, ok := meta.Annotations[key]
+	if !ok {
+		return nil
+	}
+	delete(meta.Annotations, key)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+	var fields T
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return nil
+	}
+	return &fields
+}
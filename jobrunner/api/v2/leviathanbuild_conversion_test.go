@@ -0,0 +1,174 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/randfill"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// fillFuncs restrict the objects filled at random to those the CRD admits.
+var fillFuncs = []any{
+	func(spec *jcrsv1.LeviathanBuildSpec, c randfill.Continue) {
+		c.FillNoCustom(spec)
+		spec.PackageName = ptr.To("pkg-" + c.String(8))
+		spec.BuildType = pick(c, jcrsv1.Build, jcrsv1.BuildPublish, jcrsv1.Publish, jcrsv1.Verify, "Lint")
+		spec.SourceType = pick(c, jcrsv1.LocalSource, jcrsv1.GitSource, jcrsv1.S3Source, jcrsv1.HTTPSource, jcrsv1.InlineSource)
+		// An empty source is the same as none
+		if spec.Source != nil && spec.Source.Inline == nil && spec.Source.HTTP == nil && spec.Source.Git == nil {
+			spec.Source = nil
+		}
+	},
+	func(spec *LeviathanBuildSpec, c randfill.Continue) {
+		c.FillNoCustom(spec)
+		spec.PackageName = "pkg-" + c.String(8)
+		source := BuildSource{Type: pick(c, jcrsv1.LocalSource, jcrsv1.GitSource, jcrsv1.S3Source, jcrsv1.HTTPSource, jcrsv1.InlineSource)}
+		switch source.Type {
+		case jcrsv1.LocalSource:
+			source.Local = spec.Source.Local
+		case jcrsv1.GitSource:
+			source.Git = ptr.To(ptr.Deref(spec.Source.Git, GitSource{}))
+		case jcrsv1.S3Source:
+			source.S3 = ptr.To(ptr.Deref(spec.Source.S3, S3Source{}))
+		case jcrsv1.HTTPSource:
+			source.HTTP = ptr.To(ptr.Deref(spec.Source.HTTP, HTTPSource{}))
+		case jcrsv1.InlineSource:
+			source.Inline = ptr.To(ptr.Deref(spec.Source.Inline, jcrsv1.InlineSourceSpec{}))
+		}
+		spec.Source = source
+		if spec.Publish != nil {
+			spec.Verify = false
+			spec.Publish.Mode = pick(c, PublishAfterBuild, PublishOnly)
+		}
+		spec.BuildType = ""
+		if spec.Publish == nil && !spec.Verify {
+			spec.BuildType = pick[jcrsv1.BuildType](c, "", "Lint")
+		}
+	},
+	// The kind and apiVersion are set when the object is serialized
+	func(*metav1.TypeMeta, randfill.Continue) {},
+	func(meta *metav1.ObjectMeta, c randfill.Continue) {
+		meta.Name = c.String(8)
+		meta.Namespace = c.String(8)
+		c.Fill(&meta.Labels)
+		c.Fill(&meta.Annotations)
+	},
+	// The types shared by both versions are copied as is, a name is enough to tell them apart
+	func(template *batchv1.JobTemplateSpec, c randfill.Continue) {
+		template.Spec.Template.Spec.Containers = []corev1.Container{{Name: c.String(8)}}
+	},
+	func(container *corev1.Container, c randfill.Continue) { container.Name = c.String(8) },
+	func(volume *corev1.Volume, c randfill.Continue) { volume.Name = c.String(8) },
+	func(status *jcrsv1.LeviathanBuildStatus, c randfill.Continue) { status.RunIndex = c.Int63() },
+	func(raw *runtime.RawExtension, c randfill.Continue) {
+		raw.Raw = []byte(`{"data":{"version":"$(VERSION)"}}`)
+	},
+}
+
+// pick returns one of values at random.
+func pick[T any](c randfill.Continue, values ...T) T {
+	return values[c.Intn(len(values))]
+}
+
+var _ = Describe("LeviathanBuild conversion", func() {
+	var filler *randfill.Filler
+
+	BeforeEach(func() {
+		filler = randfill.NewWithSeed(GinkgoRandomSeed()).NilChance(0.3).NumElements(1, 2).Funcs(fillFuncs...)
+	})
+
+	It("converts v1 to v2 and back without loss", func() {
+		for range 500 {
+			original := &jcrsv1.LeviathanBuild{}
+			filler.Fill(original)
+
+			v2 := &LeviathanBuild{}
+			Expect(v2.ConvertFrom(original.DeepCopy())).To(Succeed())
+			v1 := &jcrsv1.LeviathanBuild{}
+			Expect(v2.ConvertTo(v1)).To(Succeed())
+			Expect(v1).To(Equal(original))
+		}
+	})
+
+	It("converts v2 to v1 and back without loss", func() {
+		for range 500 {
+			original := &LeviathanBuild{}
+			filler.Fill(original)
+
+			v1 := &jcrsv1.LeviathanBuild{}
+			Expect(original.DeepCopy().ConvertTo(v1)).To(Succeed())
+			v2 := &LeviathanBuild{}
+			Expect(v2.ConvertFrom(v1)).To(Succeed())
+			Expect(v2).To(Equal(original))
+		}
+	})
+
+	It("moves the v1 fields into their v2 groups", func() {
+		v1 := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName:      ptr.To("web"),
+				BuildType:        jcrsv1.Publish,
+				SourceType:       jcrsv1.GitSource,
+				SourceURL:        ptr.To("https://github.com/example/web.git"),
+				Source:           &jcrsv1.SourceSpec{Git: &jcrsv1.GitSourceSpec{LFS: true, SparsePaths: []string{"web"}}},
+				PublishTarget:    &jcrsv1.PublishTarget{RegistryURL: "https://registry.example.com", Version: "1.0.0"},
+				SpotPolicy:       jcrsv1.SpotPrefer,
+				ProtectionPolicy: jcrsv1.ProtectFromEviction,
+				Checkpoint:       &jcrsv1.CheckpointSpec{Enabled: true, MaxResumes: 2},
+			},
+		}
+		v2 := &LeviathanBuild{}
+		Expect(v2.ConvertFrom(v1)).To(Succeed())
+
+		Expect(v2.Annotations).To(BeEmpty())
+		Expect(v2.Spec.PackageName).To(Equal("web"))
+		Expect(v2.Spec.Source).To(Equal(BuildSource{Type: jcrsv1.GitSource, Git: &GitSource{
+			URL: ptr.To("https://github.com/example/web.git"), LFS: true, SparsePaths: []string{"web"},
+		}}))
+		Expect(v2.Spec.Publish).To(Equal(&PublishSpec{Mode: PublishOnly, Target: *v1.Spec.PublishTarget}))
+		Expect(v2.Spec.Scheduling).To(Equal(&SchedulingSpec{SpotPolicy: jcrsv1.SpotPrefer, ProtectionPolicy: jcrsv1.ProtectFromEviction}))
+		Expect(v2.Spec.Checkpoint).To(Equal(&CheckpointSpec{Enabled: true}))
+		Expect(v2.Spec.RetryPolicy).To(Equal(&RetryPolicy{MaxResumes: 2}))
+	})
+
+	It("keeps the v1 fields v2 can't represent in an annotation", func() {
+		v1 := &jcrsv1.LeviathanBuild{Spec: jcrsv1.LeviathanBuildSpec{
+			PackageName: ptr.To("web"),
+			BuildType:   jcrsv1.Build,
+			SourceType:  jcrsv1.LocalSource,
+			SourceURL:   ptr.To("https://github.com/example/web.git"),
+		}}
+		v2 := &LeviathanBuild{}
+		Expect(v2.ConvertFrom(v1.DeepCopy())).To(Succeed())
+		Expect(v2.Annotations).To(HaveKeyWithValue(v1FieldsAnnotation, `{"sourceURL":"https://github.com/example/web.git"}`))
+
+		By("ignoring the fields set since in v2")
+		v2.Spec.Source = BuildSource{Type: jcrsv1.GitSource, Git: &GitSource{URL: ptr.To("git@github.com:example/web.git")}}
+		Expect(v2.ConvertTo(v1)).To(Succeed())
+		Expect(v1.Annotations).To(BeEmpty())
+		Expect(v1.Spec.SourceURL).To(Equal(ptr.To("git@github.com:example/web.git")))
+	})
+})
@@ -0,0 +1,546 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+v2 regroups the fields of v1 that only make sense together: the source is a union
+discriminated by its type, the publish target and what happens after publishing
+live under publish, and the scheduling and retry settings are first-class types.
+The types that didn't change are shared with v1, and so is the status, which is
+written by the controller through v1.
+
+Every v1 LeviathanBuild can be represented in v2 and the other way around; see
+leviathanbuild_conversion.go.
+*/
+
+// LeviathanBuildSpec defines the desired state of LeviathanBuild
+// +kubebuilder:validation:XValidation:rule="!(has(self.verify) && self.verify) || !has(self.publish)",message="publish can't be set on a verify build"
+// +kubebuilder:validation:XValidation:rule="!has(self.buildType) || (!(has(self.verify) && self.verify) && !has(self.publish))",message="buildType can't be set on a verify or publishing build"
+// +kubebuilder:validation:XValidation:rule="!has(self.scheduling) || !has(self.scheduling.clusterSelector) || !has(self.executionBackend) || self.executionBackend == 'Job'",message="scheduling.clusterSelector requires the Job executionBackend"
+// +kubebuilder:validation:XValidation:rule="!(has(self.verifyReproducibility) && self.verifyReproducibility) || !(has(self.verify) && self.verify)",message="verifyReproducibility can't be set on a verify build"
+// +kubebuilder:validation:XValidation:rule="!(has(self.verifyReproducibility) && self.verifyReproducibility) || ((!has(self.executionBackend) || self.executionBackend == 'Job') && (!has(self.scheduling) || !has(self.scheduling.clusterSelector)))",message="verifyReproducibility requires the Job executionBackend in the cluster of the controller"
+// +kubebuilder:validation:XValidation:rule="!has(self.publish) || !has(self.publish.approval) || !self.publish.approval.required || ((!has(self.executionBackend) || self.executionBackend == 'Job') && (!has(self.scheduling) || !has(self.scheduling.clusterSelector)))",message="publish.approval requires the Job executionBackend in the cluster of the controller"
+type LeviathanBuildSpec struct {
+	// packageName is the name of the package being built/published. It may be
+	// scoped (e.g. "@scope/name") but must not contain spaces or "..".
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=214
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9@_][A-Za-z0-9@._~/+-]*$`
+	// +kubebuilder:validation:XValidation:rule="!self.contains('..')",message="packageName must not contain '..'"
+	PackageName string `json:"packageName"`
+
+	// language is the language the package is written in. It is used to select
+	// a builder image from the BuilderImageMappings.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	Language *string `json:"language,omitempty"`
+
+	// source describes where the source of the build comes from. The local
+	// source is used when unset.
+	// +optional
+	// +kubebuilder:default:={type: Local}
+	Source BuildSource `json:"source,omitempty,omitzero"`
+
+	// jobTemplate defines the job that will be created when executing the build.
+	// +required
+	JobTemplate batchv1.JobTemplateSpec `json:"jobTemplate"`
+
+	// publish describes how the package is published. The package is only built
+	// when unset.
+	// +optional
+	Publish *PublishSpec `json:"publish,omitempty"`
+
+	// verify runs a build that validates a change, such as a pull request, without
+	// producing or publishing artifacts. It can't be set with publish.
+	// +optional
+	Verify bool `json:"verify,omitempty"`
+
+	// buildType is a build type declared by a BuildTypeDefinition, whose steps
+	// the build runs when its jobTemplate doesn't set containers. Builds of such
+	// a type are built like other builds, and can't verify or publish.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="!(self in ['Build', 'BuildPublish', 'Publish', 'Verify'])",message="buildType must not be a built-in build type, which are set with verify and publish"
+	BuildType jcrsv1.BuildType `json:"buildType,omitempty"`
+
+	// verifyReproducibility runs every succeeded run of the build a second time,
+	// on another node when possible, and compares the digests both runs report.
+	// The second run is given LEVIATHAN_REPRODUCE=true and must not publish. It
+	// can't be set on a verify build.
+	// +optional
+	VerifyReproducibility bool `json:"verifyReproducibility,omitempty"`
+
+	// rebuildOnImageChange runs the build again whenever the builder image it
+	// resolved from a BuilderImageMapping is pushed with a new digest, with the
+	// image pinned to that digest.
+	// +optional
+	RebuildOnImageChange bool `json:"rebuildOnImageChange,omitempty"`
+
+	// propagation controls which of the LeviathanBuild's own labels and annotations
+	// are copied onto the Jobs and pod templates created for it.
+	// Labels and annotations set on the jobTemplate are always applied.
+	// +optional
+	Propagation *jcrsv1.PropagationSpec `json:"propagation,omitempty"`
+
+	// volumes are added to the pod spec of the build Job, next to the volumes of the
+	// jobTemplate. Their names must not collide with the volumes of the jobTemplate
+	// or those managed by the controller.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// volumeMounts are added to every container of the build Job. They may refer
+	// to volumes declared in volumes or in the jobTemplate.
+	// +optional
+	// +listType=map
+	// +listMapKey=mountPath
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// sidecars are service containers, such as docker-in-docker, that run next to
+	// the build and are stopped once the build containers have exited.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// jobPatches are applied in order to the Job constructed for the build, right
+	// before it is created. JSON6902 operations may only refer to what the
+	// jobTemplate sets.
+	// +optional
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=16
+	JobPatches []jcrsv1.JobPatch `json:"jobPatches,omitempty"`
+
+	// scheduling decides where and when the Jobs of the build run.
+	// +optional
+	Scheduling *SchedulingSpec `json:"scheduling,omitempty"`
+
+	// checkpoint mounts a persistent volume the build containers write checkpoints
+	// to, at $CHECKPOINT_DIR.
+	// +optional
+	Checkpoint *CheckpointSpec `json:"checkpoint,omitempty"`
+
+	// retryPolicy describes how failed runs of the build are retried.
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// heartbeat tells hung builds from slow ones. The build containers touch the
+	// file at $HEARTBEAT_FILE at least every interval; a build whose file hasn't
+	// been touched for timeout is marked as Stalled, and its run restarted up to
+	// maxRestarts times.
+	// +optional
+	Heartbeat *jcrsv1.HeartbeatSpec `json:"heartbeat,omitempty"`
+
+	// lifecycleHooks run commands in the build containers before and after the
+	// build, e.g. to warm caches or flush coverage data.
+	// +optional
+	LifecycleHooks *jcrsv1.LifecycleHooks `json:"lifecycleHooks,omitempty"`
+
+	// network overrides the proxy and trust bundle configured for the operator,
+	// which are injected into every build Job, and sets the network isolation of
+	// the build.
+	// +optional
+	Network *jcrsv1.NetworkSpec `json:"network,omitempty"`
+
+	// dnsPolicy sets the DNS policy of the build pods, overriding the one of the
+	// jobTemplate. With None, dnsConfig must list the nameservers of the build.
+	// +optional
+	// +kubebuilder:validation:Enum=ClusterFirst;ClusterFirstWithHostNet;Default;None
+	DNSPolicy corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+
+	// dnsConfig sets the DNS parameters of the build pods, such as the nameservers
+	// and search domains of internal package mirrors, overriding the one of the
+	// jobTemplate. It is merged with the configuration of dnsPolicy.
+	// +optional
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+
+	// hostAliases are added to the hosts file of the build pods, after those of
+	// the jobTemplate.
+	// +optional
+	// +listType=map
+	// +listMapKey=ip
+	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty"`
+
+	// parametersFrom sets the keys of ConfigMaps and Secrets of the namespace of the
+	// build as environment variables of the build containers, which their command,
+	// args and env may refer to as $(KEY). The keys are read when the Job of a run
+	// is created, later sources taking precedence, and the values by the kubelet
+	// when the pods start, so they never appear in the Job or the status of the
+	// build. Keys that aren't valid environment variable names are skipped.
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	ParametersFrom []jcrsv1.ParametersSource `json:"parametersFrom,omitempty"`
+
+	// checksumSecrets records a checksum of the data of every Secret the pods of
+	// a run read from, in the jcrs.jcrs.dev/secret-checksums annotation of the
+	// Job and its pod template. When one of them is rotated while the run is
+	// running, the CredentialsRotatedDuringRun condition reports it, as a run
+	// failing to publish may have used the credentials that were replaced.
+	// +optional
+	ChecksumSecrets bool `json:"checksumSecrets,omitempty"`
+
+	// credentials are Secrets that only the containers of the phase needing them
+	// are given, rather than every container of the Job: the source credentials
+	// to those fetching the source, and the registry credentials to those
+	// publishing the package.
+	// +optional
+	Credentials *jcrsv1.BuildCredentials `json:"credentials,omitempty"`
+
+	// expiresAfter is how long after its creation the build may wait for its
+	// first Job, e.g. while held back by a MaintenanceWindow, its mutexKey or a
+	// missing builder image. A build that hasn't started by then is marked as
+	// failed with the Expired reason, and its Jobs are never created, rather
+	// than running a stale release late. Builds that have started once don't
+	// expire.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="expiresAfter must be positive"
+	ExpiresAfter *metav1.Duration `json:"expiresAfter,omitempty"`
+
+	// suspend holds back the Jobs of the build: while set, no Job is created,
+	// and a Job that is out of date isn't replaced. A running Job is left to
+	// finish. Suspending or resuming a build doesn't change who triggered it.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// naming names the Jobs of the build, and the ConfigMap of its inline script,
+	// after a template instead of the build. Names must follow a convention of
+	// the cluster, and are checked when the build is admitted.
+	// +optional
+	Naming *jcrsv1.NamingSpec `json:"naming,omitempty"`
+
+	// jobPolicy runs the build as an Indexed Job of several shards, which may
+	// succeed before every shard has. It overrides the completions, parallelism,
+	// completionMode and successPolicy of the jobTemplate.
+	// +optional
+	JobPolicy *jcrsv1.JobPolicy `json:"jobPolicy,omitempty"`
+
+	// executionBackend is the engine the runs of the build are executed by: a
+	// Job, the default, a Tekton PipelineRun or an Argo Workflow.
+	// +optional
+	ExecutionBackend jcrsv1.ExecutionBackend `json:"executionBackend,omitempty"`
+}
+
+// BuildSource describes the source of a build. Only the member named by type may
+// be set.
+// +union
+// +kubebuilder:validation:XValidation:rule="!has(self.local) || self.type == 'Local'",message="local may only be set when type is Local"
+// +kubebuilder:validation:XValidation:rule="has(self.git) == (self.type == 'Git')",message="git is required when type is Git, and forbidden otherwise"
+// +kubebuilder:validation:XValidation:rule="has(self.s3) == (self.type == 'S3')",message="s3 is required when type is S3, and forbidden otherwise"
+// +kubebuilder:validation:XValidation:rule="has(self.http) == (self.type == 'HTTP')",message="http is required when type is HTTP, and forbidden otherwise"
+// +kubebuilder:validation:XValidation:rule="has(self.inline) == (self.type == 'Inline')",message="inline is required when type is Inline, and forbidden otherwise"
+// +kubebuilder:validation:XValidation:rule="!has(self.pollInterval) || self.type in ['Git', 'S3', 'HTTP']",message="pollInterval requires a Git, S3 or HTTP source"
+// +kubebuilder:validation:XValidation:rule="!has(self.pollInterval) || duration(self.pollInterval) >= duration('1m')",message="pollInterval must be at least 1m"
+type BuildSource struct {
+	// type of the source
+	// - "Local": use a local path for the source;
+	// - "Git": pull the source from git;
+	// - "S3": pull the source from an s3 bucket;
+	// - "HTTP": download and extract the tarball or zip archive at a URL;
+	// - "Inline": use a script as the source
+	// +required
+	// +unionDiscriminator
+	Type jcrsv1.SourceType `json:"type"`
+
+	// local configures the "Local" source type
+	// +optional
+	Local *LocalSource `json:"local,omitempty"`
+
+	// git configures the "Git" source type
+	// +optional
+	Git *GitSource `json:"git,omitempty"`
+
+	// s3 configures the "S3" source type
+	// +optional
+	S3 *S3Source `json:"s3,omitempty"`
+
+	// http configures the "HTTP" source type
+	// +optional
+	HTTP *HTTPSource `json:"http,omitempty"`
+
+	// inline configures the "Inline" source type
+	// +optional
+	Inline *jcrsv1.InlineSourceSpec `json:"inline,omitempty"`
+
+	// pollInterval is how often the source is checked for a new revision. The
+	// build runs again whenever the revision changes. The source isn't polled
+	// when unset.
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+}
+
+// LocalSource describes a source on a local path.
+type LocalSource struct {
+	// path the source is read from
+	// +optional
+	// +kubebuilder:validation:MaxLength=4096
+	Path *string `json:"path,omitempty"`
+}
+
+// GitSource describes a source pulled from git. Repositories with submodules,
+// lfs or sparsePaths set are cloned into the workspace by the fetch init
+// container; others are left to the build containers.
+type GitSource struct {
+	// url of the repository
+	// +optional
+	// +kubebuilder:validation:MaxLength=2048
+	// +kubebuilder:validation:XValidation:rule="self.matches('^(https://|ssh://|git://|git@)')",message="url must be an https, ssh or git URL"
+	URL *string `json:"url,omitempty"`
+
+	// path of the source in the repository
+	// +optional
+	// +kubebuilder:validation:MaxLength=4096
+	Path *string `json:"path,omitempty"`
+
+	// submodules selects whether the submodules of the repository are cloned too,
+	// along with their own submodules. They aren't by default.
+	// +optional
+	Submodules jcrsv1.GitSubmodules `json:"submodules,omitempty"`
+
+	// lfs fetches the Git LFS objects of the checked out files. They are left as
+	// pointer files by default.
+	// +optional
+	LFS bool `json:"lfs,omitempty"`
+
+	// sparsePaths limits the checkout to these directories of the repository, for
+	// builds of a subtree of a monorepo. The files at the root of the repository
+	// are checked out too. The whole repository is checked out when empty.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=100
+	// +kubebuilder:validation:items:MinLength=1
+	// +kubebuilder:validation:items:MaxLength=4096
+	SparsePaths []string `json:"sparsePaths,omitempty"`
+}
+
+// S3Source describes a source pulled from an s3 bucket.
+type S3Source struct {
+	// url of the source, e.g. s3://bucket/prefix
+	// +optional
+	// +kubebuilder:validation:MaxLength=2048
+	// +kubebuilder:validation:XValidation:rule="self.matches('^s3://')",message="url must be an s3 URL"
+	URL *string `json:"url,omitempty"`
+
+	// path of the source in the bucket
+	// +optional
+	// +kubebuilder:validation:MaxLength=4096
+	Path *string `json:"path,omitempty"`
+}
+
+// HTTPSource describes a source archive downloaded over HTTP(S). The archive
+// format (tar, tar.gz or zip) is detected from its content.
+type HTTPSource struct {
+	// url of the archive
+	// +required
+	// +kubebuilder:validation:MaxLength=2048
+	// +kubebuilder:validation:XValidation:rule="self.matches('^https?://')",message="url must be an http(s) URL"
+	URL string `json:"url"`
+
+	// path of the source in the archive
+	// +optional
+	// +kubebuilder:validation:MaxLength=4096
+	Path *string `json:"path,omitempty"`
+
+	// secretRef names a Secret in the build's namespace holding the credentials used
+	// for the download: a "token" key for bearer authentication, or "username" and
+	// "password" keys for basic authentication.
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// cacheClaimName names a PersistentVolumeClaim used to cache the archive between
+	// builds. Cached archives are only downloaded again when the server reports
+	// a change through ETag or Last-Modified.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	CacheClaimName *string `json:"cacheClaimName,omitempty"`
+}
+
+// PublishSpec describes how and where a package is published.
+// +kubebuilder:validation:XValidation:rule="!has(self.onHookFailure) || self.onHookFailure != 'Rollback' || has(self.artifactRetention)",message="artifactRetention is required when onHookFailure is Rollback"
+type PublishSpec struct {
+	// mode decides whether the package is built before it is published
+	// - "AfterBuild" (default): runs a build, then publishes it;
+	// - "Only": only publishes the package
+	// +optional
+	// +kubebuilder:default:=AfterBuild
+	Mode PublishMode `json:"mode,omitempty"`
+
+	// target is the registry the package is published to
+	// +required
+	Target jcrsv1.PublishTarget `json:"target"`
+
+	// artifactRetention describes which of the versions published by the build
+	// are kept. Older versions are pruned from the target.
+	// +optional
+	ArtifactRetention *jcrsv1.ArtifactRetention `json:"artifactRetention,omitempty"`
+
+	// onSuccess describes what happens once the package has been published.
+	// +optional
+	OnSuccess *jcrsv1.OnSuccessSpec `json:"onSuccess,omitempty"`
+
+	// onHookFailure specifies what happens when the hooks of onSuccess fail
+	// - "Retry" (default): the hooks are retried every few minutes;
+	// - "Rollback": the version just published is deleted from the target, and
+	// the run is marked as failed. The version is deleted from the store of
+	// artifactRetention, which is required.
+	// +optional
+	OnHookFailure jcrsv1.HookFailurePolicy `json:"onHookFailure,omitempty"`
+
+	// signing signs the published artifact with cosign, and attaches attestations
+	// to it. The artifact is the image <registryURL>/<path>@<digest> of the
+	// target, by the digest the build reports. The patchTargets of onSuccess are
+	// applied once it is signed.
+	// +optional
+	Signing *jcrsv1.SigningSpec `json:"signing,omitempty"`
+
+	// approval holds the publish of every succeeded run until it is approved by
+	// a PublishApproval, or its timeout passes. Runs are built with
+	// LEVIATHAN_PUBLISH=false, and publish as a Job of their own once approved.
+	// +optional
+	Approval *jcrsv1.PublishApprovalPolicy `json:"approval,omitempty"`
+}
+
+// PublishMode describes whether a published package is built first.
+// +kubebuilder:validation:Enum=AfterBuild;Only
+type PublishMode string
+
+const (
+	// PublishAfterBuild builds the package, then publishes it
+	PublishAfterBuild PublishMode = "AfterBuild"
+
+	// PublishOnly publishes the package without building it
+	PublishOnly PublishMode = "Only"
+)
+
+// SchedulingSpec decides where and when the Jobs of a build run.
+type SchedulingSpec struct {
+	// spotPolicy decides whether the build runs on spot (preemptible) nodes
+	// - "Prefer": runs on spot nodes when some are available;
+	// - "Require": only runs on spot nodes;
+	// - "Avoid": never runs on spot nodes.
+	// The scheduling of the jobTemplate is left as is when unset.
+	// +optional
+	SpotPolicy jcrsv1.SpotPolicy `json:"spotPolicy,omitempty"`
+
+	// protectionPolicy decides whether the pods of the build may be evicted
+	// - "None", the default: the pods may be evicted like any other;
+	// - "ProtectFromEviction": the pods are marked as not safe to evict for the
+	// cluster-autoscaler, run with the priority class configured for the operator
+	// unless the jobTemplate sets one, and a PodDisruptionBudget blocks their
+	// eviction by node drains while the build runs.
+	// +optional
+	ProtectionPolicy jcrsv1.ProtectionPolicy `json:"protectionPolicy,omitempty"`
+
+	// mutexKey serializes builds against a shared external resource. Builds in the
+	// same namespace with the same mutexKey run one at a time, in the order they
+	// started waiting.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	MutexKey *string `json:"mutexKey,omitempty"`
+
+	// spreadPolicy spreads the pods of the build across nodes or zones, such as
+	// its shards, and keeps them apart from the pods of the other builds of its
+	// package.
+	// +optional
+	SpreadPolicy *jcrsv1.SpreadPolicy `json:"spreadPolicy,omitempty"`
+
+	// clusterSelector dispatches the Jobs of the build to a remote cluster, among
+	// the Ready ClusterTargets whose labels it selects. The cluster must hold the
+	// ConfigMaps, Secrets and PersistentVolumeClaims the Job references.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+}
+
+// CheckpointSpec describes the checkpoint volume of a build.
+type CheckpointSpec struct {
+	// enabled mounts the checkpoint volume
+	// +required
+	Enabled bool `json:"enabled"`
+
+	// claimName names an existing PersistentVolumeClaim holding the checkpoints.
+	// When empty, a claim owned by the build is created.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*)?$`
+	ClaimName string `json:"claimName,omitempty"`
+
+	// size of the claim created for the build
+	// +optional
+	// +kubebuilder:default:="10Gi"
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// storageClassName of the claim created for the build. The default storage
+	// class is used when unset.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// RetryPolicy describes how failed runs of a build are retried. A run interrupted
+// on a spot node is always run again, up to the retry limit of the controller.
+type RetryPolicy struct {
+	// maxResumes is the number of times a failed run is resumed from its checkpoint,
+	// with RESUME_FROM_CHECKPOINT set, before the build is reported as failed.
+	// Only used when the checkpoint is enabled.
+	// +optional
+	// +kubebuilder:default:=3
+	// +kubebuilder:validation:Minimum=0
+	MaxResumes int32 `json:"maxResumes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// LeviathanBuild is the Schema for the leviathanbuilds API
+type LeviathanBuild struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of LeviathanBuild
+	// +required
+	Spec LeviathanBuildSpec `json:"spec"`
+
+	// status defines the observed state of LeviathanBuild
+	// +optional
+	Status jcrsv1.LeviathanBuildStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// LeviathanBuildList contains a list of LeviathanBuild
+type LeviathanBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LeviathanBuild `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LeviathanBuild{}, &LeviathanBuildList{})
+}
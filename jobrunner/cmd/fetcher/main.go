@@ -0,0 +1,175 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The fetcher runs as the "fetch" init container of build Jobs and populates the
+// shared workspace with the build's source. It is configured by the controller
+// through FETCH_* environment variables, and reports the fetched revision back to
+// the controller as a JSON encoded fetch.Result in its termination message.
+// When a mirror bucket is configured through FETCH_MIRROR_*, the fetched source
+// is copied to it, and restored from it by the later runs of the build.
+// FETCH_BANDWIDTH_LIMIT caps the rate archives are downloaded at, in bytes per
+// second, and FETCH_PARALLELISM the submodules and LFS objects of a Git source
+// downloaded at once.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"test.jcrs.dev/jobrunner/internal/archive"
+	"test.jcrs.dev/jobrunner/internal/fetch"
+)
+
+func main() {
+	opts := zap.Options{}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	log := ctrl.Log.WithName("fetcher")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sourceType := os.Getenv("FETCH_SOURCE_TYPE")
+	if sourceType != "HTTP" && sourceType != "Git" {
+		log.Info("Nothing to fetch for source type", "sourceType", sourceType)
+		return
+	}
+
+	bandwidthLimit, err := intEnv("FETCH_BANDWIDTH_LIMIT")
+	if err != nil {
+		log.Error(err, "Invalid bandwidth limit")
+		os.Exit(1)
+	}
+	parallelism, err := intEnv("FETCH_PARALLELISM")
+	if err != nil {
+		log.Error(err, "Invalid parallelism")
+		os.Exit(1)
+	}
+
+	/*
+		With a mirror, runs after the first restore the copy of the source the first
+		one wrote to the mirror bucket, instead of fetching it from its origin again.
+	*/
+	var mirror *fetch.MirrorOptions
+	if bucket := os.Getenv("FETCH_MIRROR_BUCKET"); bucket != "" {
+		mirror = &fetch.MirrorOptions{
+			Store: archive.NewS3Store(os.Getenv("FETCH_MIRROR_ENDPOINT"), bucket, os.Getenv("FETCH_MIRROR_REGION"),
+				os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), 0),
+			Key:            os.Getenv("FETCH_MIRROR_KEY"),
+			BandwidthLimit: bandwidthLimit,
+		}
+		result, err := fetch.FromMirror(ctx, *mirror, os.Getenv("FETCH_DEST"))
+		if err != nil {
+			log.Error(err, "Failed to fetch source from mirror", "mirror", mirror.URI())
+			os.Exit(1)
+		}
+		if result != nil {
+			log.Info("Fetched source from mirror", "mirror", result.Mirror, "revision", result.Revision)
+			if err := writeTerminationMessage(result); err != nil {
+				log.Error(err, "Failed to write termination message")
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	var result *fetch.Result
+	switch sourceType {
+	case "HTTP":
+		result, err = fetch.HTTP(ctx, fetch.HTTPOptions{
+			URL:      os.Getenv("FETCH_URL"),
+			Dest:     os.Getenv("FETCH_DEST"),
+			CacheDir: os.Getenv("FETCH_CACHE_DIR"),
+			Username: os.Getenv("FETCH_USERNAME"),
+			Password: os.Getenv("FETCH_PASSWORD"),
+			Token:    os.Getenv("FETCH_TOKEN"),
+
+			BandwidthLimit: bandwidthLimit,
+		})
+	case "Git":
+		var sparsePaths []string
+		if paths := os.Getenv("FETCH_GIT_SPARSE_PATHS"); paths != "" {
+			sparsePaths = strings.Split(paths, "\n")
+		}
+		result, err = fetch.Git(ctx, fetch.GitOptions{
+			URL:         os.Getenv("FETCH_URL"),
+			Dest:        os.Getenv("FETCH_DEST"),
+			Submodules:  os.Getenv("FETCH_GIT_SUBMODULES") == "Recursive",
+			LFS:         os.Getenv("FETCH_GIT_LFS") == "true",
+			SparsePaths: sparsePaths,
+			Parallelism: int(parallelism),
+		})
+	}
+	if err != nil {
+		log.Error(err, "Failed to fetch source", "url", os.Getenv("FETCH_URL"))
+		os.Exit(1)
+	}
+	log.Info("Fetched source", "url", os.Getenv("FETCH_URL"), "cached", result.Cached, "revision", result.Revision)
+
+	// Builds don't start from sources that couldn't be mirrored
+	if mirror != nil {
+		if err := fetch.Mirror(ctx, *mirror, os.Getenv("FETCH_DEST"), result); err != nil {
+			log.Error(err, "Failed to mirror source", "mirror", mirror.URI())
+			os.Exit(1)
+		}
+		log.Info("Mirrored source", "mirror", result.Mirror)
+	}
+	if err := writeTerminationMessage(result); err != nil {
+		log.Error(err, "Failed to write termination message")
+		os.Exit(1)
+	}
+}
+
+// intEnv returns the non-negative integer of the environment variable name, or 0 when it is unset.
+func intEnv(name string) (int64, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("%s: %d is negative", name, value)
+	}
+	return value, nil
+}
+
+// writeTerminationMessage writes the fetch result where the kubelet picks it up as
+// the container's termination message.
+func writeTerminationMessage(result *fetch.Result) error {
+	path := os.Getenv("FETCH_TERMINATION_MESSAGE_PATH")
+	if path == "" {
+		path = "/dev/termination-log"
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
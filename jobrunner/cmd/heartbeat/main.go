@@ -0,0 +1,71 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The heartbeat command runs as the "heartbeat" sidecar of builds with a
+// heartbeat. It creates the heartbeat file the build containers touch, then waits
+// for the build to end:
+//
+//	heartbeat
+//
+// It is also the readiness probe of the sidecar, failing once the file is older
+// than the timeout of the build:
+//
+//	heartbeat check
+//
+// It is configured by the controller through HEARTBEAT_* environment variables.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"test.jcrs.dev/jobrunner/internal/heartbeat"
+)
+
+func main() {
+	file := os.Getenv("HEARTBEAT_FILE")
+
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		timeout, err := time.ParseDuration(os.Getenv("HEARTBEAT_TIMEOUT"))
+		if err == nil {
+			err = heartbeat.Check(file, timeout, time.Now())
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := heartbeat.Init(file); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to create heartbeat file:", err)
+		os.Exit(1)
+	}
+
+	// Without native sidecars, the build containers leave markers once they've exited
+	var done []string
+	if markers := os.Getenv("HEARTBEAT_DONE"); markers != "" {
+		done = strings.Split(markers, ",")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	heartbeat.Wait(ctx, done, time.Second)
+}
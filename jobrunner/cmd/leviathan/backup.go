@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/backup"
+)
+
+// runExportAll implements the export-all subcommand.
+func runExportAll(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export-all", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), exportAllUsage)
+		flags.PrintDefaults()
+	}
+	var namespace, file string
+	flags.StringVar(&namespace, "namespace", "", "Namespace of the build definitions, every namespace and the cluster scoped definitions when empty.")
+	flags.StringVar(&file, "o", "", "File the bundle is written to, the standard output when empty.")
+	_ = flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	c, err := backupClient()
+	if err != nil {
+		return err
+	}
+	bundle, err := backup.Export(ctx, c, namespace)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d objects referencing %d ConfigMaps and Secrets to %s\n", len(bundle.Objects), len(bundle.References), file)
+	return nil
+}
+
+// runImportAll implements the import-all subcommand.
+func runImportAll(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import-all", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), importAllUsage)
+		flags.PrintDefaults()
+	}
+	var file, onConflict string
+	flags.StringVar(&file, "f", "", "File of the bundle exported by export-all.")
+	flags.StringVar(&onConflict, "on-conflict", string(backup.SkipConflicts), "What is done with the objects of the bundle that already exist: skip, overwrite or rename.")
+	_ = flags.Parse(args)
+	if file == "" || flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	bundle := &backup.Bundle{}
+	if err := yaml.UnmarshalStrict(data, bundle); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	c, err := backupClient()
+	if err != nil {
+		return err
+	}
+	missing, err := backup.MissingReferences(ctx, c, bundle)
+	if err != nil {
+		return err
+	}
+	for _, ref := range missing {
+		fmt.Fprintf(os.Stderr, "warning: %s %s/%s referenced by %s doesn't exist\n",
+			ref.Kind, ref.Namespace, ref.Name, strings.Join(ref.ReferencedBy, ", "))
+	}
+	results, err := backup.Import(ctx, c, bundle, backup.ConflictStrategy(onConflict))
+	if err != nil {
+		return err
+	}
+	return printImportResults(results)
+}
+
+// backupClient returns a client of the objects exported and imported.
+func backupClient() (client.Client, error) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(jcrsv1.AddToScheme(scheme))
+	return client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+}
+
+// printImportResults prints the outcome of the import of every object, and fails
+// when any of them couldn't be imported.
+func printImportResults(results []backup.Result) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tRESULT")
+	failed := 0
+	for _, result := range results {
+		outcome := string(result.Action)
+		switch {
+		case result.Err != nil:
+			outcome = "Failed: " + result.Err.Error()
+			failed++
+		case result.Action == backup.Renamed:
+			outcome += " to " + result.NewName
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Kind, result.Namespace, result.Name, outcome)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to import %d objects", failed)
+	}
+	return nil
+}
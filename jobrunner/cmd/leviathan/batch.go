@@ -0,0 +1,134 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/batch"
+)
+
+// batchCommands maps the batch subcommands to the operation they apply.
+var batchCommands = map[string]jcrsv1.BatchOperationType{
+	"trigger": jcrsv1.TriggerOperation,
+	"suspend": jcrsv1.SuspendOperation,
+	"resume":  jcrsv1.ResumeOperation,
+	"cancel":  jcrsv1.CancelOperation,
+}
+
+// runBatch implements the trigger, suspend, resume and cancel subcommands.
+func runBatch(ctx context.Context, command string, args []string) error {
+	operation := batchCommands[command]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	flags.Usage = func() {
+		if operation == jcrsv1.TriggerOperation {
+			fmt.Fprintln(flags.Output(), triggerUsage)
+		} else {
+			fmt.Fprintf(flags.Output(), batchUsage+"\n", command)
+		}
+		flags.PrintDefaults()
+	}
+	var namespace, selector string
+	var record bool
+	params := parameters{}
+	flags.StringVar(&selector, "l", "", "Label selector of the builds.")
+	flags.StringVar(&namespace, "namespace", "default", "Namespace of the builds.")
+	flags.BoolVar(&record, "record", false, "Create a LeviathanBuildBatchOperation the controller applies, instead of applying the operation directly.")
+	if operation == jcrsv1.TriggerOperation {
+		flags.Var(params, "X", "Override the parameter NAME with VALUE for the triggered builds, as NAME=VALUE. Can be repeated.")
+	}
+	_ = flags.Parse(args)
+	if selector == "" || flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	labelSelector, err := metav1.ParseToLabelSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(jcrsv1.AddToScheme(scheme))
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	if record {
+		batchOperation := &jcrsv1.LeviathanBuildBatchOperation{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, GenerateName: command + "-"},
+			Spec: jcrsv1.LeviathanBuildBatchOperationSpec{
+				Operation: operation, Selector: *labelSelector, Parameters: params,
+			},
+		}
+		if err := c.Create(ctx, batchOperation); err != nil {
+			return err
+		}
+		fmt.Printf("leviathanbuildbatchoperation.jcrs.jcrs.dev/%s created\n", batchOperation.Name)
+		return nil
+	}
+
+	parsed, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return err
+	}
+	results, err := batch.Run(ctx, c, namespace, parsed, operation, batch.Options{Parameters: params})
+	if err != nil {
+		return err
+	}
+	return printResults(results)
+}
+
+// printResults prints the outcome of a batch operation for every build, and
+// fails when the operation failed for any of them.
+func printResults(results []jcrsv1.BatchOperationResult) error {
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "No builds selected.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "BUILD\tRESULT\tMESSAGE")
+	var failed []string
+	for _, result := range results {
+		outcome := "Succeeded"
+		if !result.Succeeded {
+			outcome = "Failed"
+			failed = append(failed, result.Name)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Name, outcome, result.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return errors.New("failed for " + strings.Join(failed, ", "))
+	}
+	return nil
+}
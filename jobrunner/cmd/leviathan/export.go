@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/controller"
+	"test.jcrs.dev/jobrunner/internal/interop"
+)
+
+// runExport implements the export subcommand.
+func runExport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), exportUsage)
+		flags.PrintDefaults()
+	}
+	var namespace, format string
+	flags.StringVar(&namespace, "namespace", "default", "Namespace of the build.")
+	flags.StringVar(&format, "format", "", "Format the build is exported as, tekton or argo.")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 || format == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	converter, err := interop.ForFormat(interop.Format(format))
+	if err != nil {
+		return err
+	}
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(jcrsv1.AddToScheme(scheme))
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	lvBuild := &jcrsv1.LeviathanBuild{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: flags.Arg(0)}, lvBuild); err != nil {
+		return err
+	}
+	job, err := latestRunJob(ctx, c, lvBuild)
+	if err != nil {
+		return err
+	}
+
+	obj, warnings, err := converter.Convert(exportedJob(job))
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// latestRunJob returns the Job of the latest run of lvBuild, which holds the
+// Job the controller constructed for it.
+func latestRunJob(ctx context.Context, c client.Reader, lvBuild *jcrsv1.LeviathanBuild) (*batchv1.Job, error) {
+	if lvBuild.Status.RunIndex == 0 {
+		return nil, fmt.Errorf("build %s hasn't run yet", lvBuild.Name)
+	}
+	job, err := runJob(ctx, c, lvBuild, lvBuild.Status.RunIndex)
+	if err == nil && job == nil {
+		err = fmt.Errorf("run %d of build %s has no Job", lvBuild.Status.RunIndex, lvBuild.Name)
+	}
+	return job, err
+}
+
+// runJob returns the Job of the run runIndex of lvBuild, or nil when it has been
+// deleted.
+func runJob(ctx context.Context, c client.Reader, lvBuild *jcrsv1.LeviathanBuild, runIndex int64) (*batchv1.Job, error) {
+	var jobs batchv1.JobList
+	if err := c.List(ctx, &jobs, client.InNamespace(lvBuild.Namespace),
+		client.MatchingLabels(controller.RunLabels(lvBuild, runIndex))); err != nil {
+		return nil, err
+	}
+	for i := range jobs.Items {
+		// Shortened names may collide, the owner tells the builds apart
+		if metav1.IsControlledBy(&jobs.Items[i], lvBuild) {
+			return &jobs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// exportedJob returns job without what the API server set on it, so that the
+// exported object can be created in any namespace or cluster.
+func exportedJob(job *batchv1.Job) *batchv1.Job {
+	exported := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    job.Namespace,
+			GenerateName: job.GenerateName,
+			Labels:       job.Labels,
+			Annotations:  job.Annotations,
+		},
+		Spec: *job.Spec.DeepCopy(),
+	}
+	if exported.GenerateName == "" {
+		exported.Name = job.Name
+	}
+	exported.Spec.Selector = nil
+	exported.Spec.ManualSelector = nil
+	labels := map[string]string{}
+	for key, value := range exported.Spec.Template.Labels {
+		if key != "controller-uid" && key != "job-name" && !strings.HasPrefix(key, "batch.kubernetes.io/") {
+			labels[key] = value
+		}
+	}
+	exported.Spec.Template.Labels = labels
+	return exported
+}
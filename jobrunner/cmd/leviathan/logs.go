@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/archive"
+	"test.jcrs.dev/jobrunner/internal/runlogs"
+)
+
+// logsFollowInterval is how often the pods of a followed run are checked for
+// containers that started or restarted.
+const logsFollowInterval = 2 * time.Second
+
+// runLogs implements the logs subcommand.
+func runLogs(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("logs", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), logsUsage)
+		flags.PrintDefaults()
+	}
+	var namespace, archiveEndpoint, archiveBucket, archiveRegion string
+	var follow bool
+	var run int64
+	flags.StringVar(&namespace, "namespace", "default", "Namespace of the build.")
+	flags.BoolVar(&follow, "follow", false, "Follow the logs of the run until it finishes.")
+	flags.Int64Var(&run, "run", 0, "Index of the run whose logs are shown, the latest run when 0.")
+	flags.StringVar(&archiveEndpoint, "archive-endpoint", "https://s3.amazonaws.com",
+		"The S3 compatible endpoint of the archive. Credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.")
+	flags.StringVar(&archiveBucket, "archive-bucket", "",
+		"The bucket the controller archives builds to, which the logs of runs whose pods are gone are read from.")
+	flags.StringVar(&archiveRegion, "archive-region", "us-east-1", "The region of the archive bucket.")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config := ctrl.GetConfigOrDie()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(jcrsv1.AddToScheme(scheme))
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	src := runlogs.ClientsetSource{Clientset: clientset}
+
+	lvBuild := &jcrsv1.LeviathanBuild{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: flags.Arg(0)}, lvBuild); err != nil {
+		return err
+	}
+	runIndex := lvBuild.Status.RunIndex
+	if run > 0 {
+		runIndex = run
+	}
+	if runIndex == 0 {
+		return fmt.Errorf("build %s hasn't run yet", lvBuild.Name)
+	}
+
+	job, err := runJob(ctx, c, lvBuild, runIndex)
+	if err != nil {
+		return err
+	}
+	if job != nil {
+		list := func(ctx context.Context) ([]corev1.Pod, bool, error) {
+			if err := c.Get(ctx, client.ObjectKeyFromObject(job), job); err != nil {
+				return nil, false, err
+			}
+			var pods corev1.PodList
+			if err := c.List(ctx, &pods, client.InNamespace(job.Namespace),
+				client.MatchingLabels{batchv1.ControllerUidLabel: string(job.UID)}); err != nil {
+				return nil, false, err
+			}
+			return pods.Items, jobFinished(job), nil
+		}
+		pods, finished, err := list(ctx)
+		if err != nil {
+			return err
+		}
+		if follow && !finished {
+			return runlogs.Follow(ctx, src, list, logsFollowInterval, os.Stdout)
+		}
+		if len(pods) > 0 {
+			return runlogs.Write(ctx, src, pods, os.Stdout)
+		}
+	}
+
+	// The pods of finished runs go away with their Job, their logs are archived
+	if archiveBucket == "" {
+		return fmt.Errorf("the pods of run %d of build %s are gone, its logs can be read from the archive with --archive-bucket", runIndex, lvBuild.Name)
+	}
+	store := archive.NewS3Store(archiveEndpoint, archiveBucket, archiveRegion,
+		os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), 30*time.Second)
+	_, err = store.Get(ctx, runlogs.ArchiveKey(lvBuild.Namespace, lvBuild.Name, lvBuild.UID, runIndex), os.Stdout)
+	if errors.Is(err, archive.ErrNotFound) {
+		return fmt.Errorf("the logs of run %d of build %s haven't been archived", runIndex, lvBuild.Name)
+	}
+	return err
+}
+
+// jobFinished reports whether job has completed or failed.
+func jobFinished(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
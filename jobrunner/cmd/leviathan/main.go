@@ -0,0 +1,261 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The leviathan command operates on LeviathanBuilds from outside the cluster.
+//
+//	leviathan rerun [--exact] [--namespace NS] [--name NAME] [-X NAME=VALUE]... BUILD
+//	leviathan rerun [--exact] [--name NAME] [-X NAME=VALUE]... --from-archive RECORD
+//	leviathan runs list [--namespace NS | --all-namespaces] [--limit N] [--history-url URL] [BUILD]
+//	leviathan trigger -l SELECTOR [--namespace NS] [--record] [-X NAME=VALUE]...
+//	leviathan (suspend | resume | cancel) -l SELECTOR [--namespace NS] [--record]
+//	leviathan export --format (tekton | argo) [--namespace NS] BUILD
+//	leviathan export-all [--namespace NS] [-o FILE]
+//	leviathan import-all -f FILE [--on-conflict (skip | overwrite | rename)]
+//	leviathan logs [--follow] [--namespace NS] [--run N] [--archive-bucket BUCKET] BUILD
+//	leviathan simulate -f FILE [--namespace NS] [--default-duration D] [--max-builds-per-namespace N] [-o json]
+//	leviathan rollback [--namespace NS] [--to-generation N] BUILD
+//
+// rerun creates a build that runs BUILD again. With --exact, the new build is
+// pinned to the build environment recorded by the latest run of BUILD. Historical
+// builds are replayed from their archive record with --from-archive.
+//
+// rerun and trigger override the value of the parameter NAME of the new builds
+// with -X NAME=VALUE, which can be repeated. The parameters must be declared by
+// spec.parametersFrom of the builds; the builds themselves aren't changed.
+//
+// runs list lists the finished runs of BUILD, or of every build, the latest
+// first. Runs are read from the Jobs of the builds, or from the history server
+// the controller records them in with --history-url.
+//
+// trigger, suspend, resume and cancel apply an operation to every build matching
+// the label selector and print the outcome for each of them. With --record, a
+// LeviathanBuildBatchOperation is created instead, and the controller applies it.
+//
+// export prints the Job of the latest run of BUILD as a Tekton PipelineRun or an
+// Argo Workflow. What the engine can't represent is dropped, with a warning.
+//
+// export-all exports the build definitions of the cluster, or of a namespace,
+// into a bundle for disaster recovery and cluster migrations: the manifests of
+// the LeviathanBuilds, LeviathanClusterBuilds, LeviathanProjects and the objects
+// configuring them, and the names of the ConfigMaps and Secrets they reference,
+// without their values. import-all creates the objects of a bundle, and warns
+// about the referenced ConfigMaps and Secrets that don't exist. Objects that
+// already exist are skipped, overwritten or created under a generated name,
+// according to --on-conflict.
+//
+// logs prints the logs of the latest run of BUILD, or of run N, with the lines of
+// every init container and container prefixed with its name: the name of the
+// step for the steps of a pipeline. With --follow, the logs of a running run are
+// followed until it finishes, through the restarts of its containers and pods.
+// The logs of runs whose pods are gone are read from the archive of the
+// controller with --archive-bucket.
+//
+// simulate simulates the scheduling of the LeviathanBuilds of FILE against the
+// builds, quotas, mutexKey Leases and MaintenanceWindows of the cluster, without
+// creating them, and prints how long each of them would queue and the peaks of
+// the resources requested in their namespaces. Runs are expected to take as
+// long as the recent runs of their build or package, or --default-duration.
+//
+// rollback applies the spec of generation N of BUILD again with server-side
+// apply, from the spec history the controller keeps with the SpecHistory feature
+// gate, and lists the generations in the history without --to-generation. The
+// rollback makes a new generation; the fields that another field manager set
+// and generation N doesn't are left as they are, with a warning.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/rerun"
+	"test.jcrs.dev/jobrunner/internal/version"
+)
+
+const (
+	rerunUsage     = `usage: leviathan rerun [--exact] [--namespace NS] [--name NAME] [-X NAME=VALUE]... (BUILD | --from-archive RECORD)`
+	runsListUsage  = `usage: leviathan runs list [--namespace NS | --all-namespaces] [--limit N] [--history-url URL] [BUILD]`
+	batchUsage     = `usage: leviathan %s -l SELECTOR [--namespace NS] [--record]`
+	triggerUsage   = `usage: leviathan trigger -l SELECTOR [--namespace NS] [--record] [-X NAME=VALUE]...`
+	exportUsage    = `usage: leviathan export --format (tekton | argo) [--namespace NS] BUILD`
+	exportAllUsage = `usage: leviathan export-all [--namespace NS] [-o FILE]`
+	importAllUsage = `usage: leviathan import-all -f FILE [--on-conflict (skip | overwrite | rename)]`
+	logsUsage      = `usage: leviathan logs [--follow] [--namespace NS] [--run N] [--archive-bucket BUCKET] BUILD`
+	simulateUsage  = `usage: leviathan simulate -f FILE [--namespace NS] [--default-duration D] [--max-builds-per-namespace N] [-o json]`
+	rollbackUsage  = `usage: leviathan rollback [--namespace NS] [--to-generation N] BUILD`
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var command string
+	var err error
+	switch {
+	case len(os.Args) >= 2 && os.Args[1] == "rerun":
+		command = "rerun"
+		err = runRerun(ctx, os.Args[2:])
+	case len(os.Args) >= 3 && os.Args[1] == "runs" && os.Args[2] == "list":
+		command = "runs list"
+		err = runRunsList(ctx, os.Args[3:])
+	case len(os.Args) >= 2 && batchCommands[os.Args[1]] != "":
+		command = os.Args[1]
+		err = runBatch(ctx, command, os.Args[2:])
+	case len(os.Args) >= 2 && os.Args[1] == "export":
+		command = "export"
+		err = runExport(ctx, os.Args[2:])
+	case len(os.Args) >= 2 && os.Args[1] == "export-all":
+		command = "export-all"
+		err = runExportAll(ctx, os.Args[2:])
+	case len(os.Args) >= 2 && os.Args[1] == "import-all":
+		command = "import-all"
+		err = runImportAll(ctx, os.Args[2:])
+	case len(os.Args) >= 2 && os.Args[1] == "logs":
+		command = "logs"
+		err = runLogs(ctx, os.Args[2:])
+	case len(os.Args) >= 2 && os.Args[1] == "simulate":
+		command = "simulate"
+		err = runSimulate(ctx, os.Args[2:])
+	case len(os.Args) >= 2 && os.Args[1] == "rollback":
+		command = "rollback"
+		err = runRollback(ctx, os.Args[2:])
+	default:
+		fmt.Fprintln(os.Stderr, rerunUsage)
+		fmt.Fprintln(os.Stderr, runsListUsage)
+		fmt.Fprintln(os.Stderr, triggerUsage)
+		fmt.Fprintf(os.Stderr, batchUsage+"\n", "(suspend | resume | cancel)")
+		fmt.Fprintln(os.Stderr, exportUsage)
+		fmt.Fprintln(os.Stderr, exportAllUsage)
+		fmt.Fprintln(os.Stderr, importAllUsage)
+		fmt.Fprintln(os.Stderr, logsUsage)
+		fmt.Fprintln(os.Stderr, simulateUsage)
+		fmt.Fprintln(os.Stderr, rollbackUsage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "leviathan %s: %v\n", command, err)
+		os.Exit(1)
+	}
+}
+
+// runRerun implements the rerun subcommand.
+func runRerun(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("rerun", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), rerunUsage)
+		flags.PrintDefaults()
+	}
+	var namespace, name, fromArchive string
+	var exact bool
+	params := parameters{}
+	flags.BoolVar(&exact, "exact", false, "Pin the rerun to the recorded build environment of the build.")
+	flags.Var(params, "X", "Override the parameter NAME with VALUE for the rerun, as NAME=VALUE. Can be repeated.")
+	flags.StringVar(&namespace, "namespace", "default", "Namespace of the build.")
+	flags.StringVar(&name, "name", "", "Name of the new build, generated from the name of the build when empty.")
+	flags.StringVar(&fromArchive, "from-archive", "", "Archive record of the build to rerun, instead of a build in the cluster.")
+	_ = flags.Parse(args)
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(jcrsv1.AddToScheme(scheme))
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	var lvBuild *jcrsv1.LeviathanBuild
+	switch {
+	case fromArchive != "" && flags.NArg() == 0:
+		if lvBuild, err = readArchiveRecord(fromArchive); err != nil {
+			return err
+		}
+	case fromArchive == "" && flags.NArg() == 1:
+		lvBuild = &jcrsv1.LeviathanBuild{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: flags.Arg(0)}, lvBuild); err != nil {
+			return err
+		}
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	opts := rerun.Options{Exact: exact, Name: name, Parameters: params}
+	if version.Version != "devel" {
+		opts.OperatorVersion = version.Version
+	}
+	newBuild, warnings, err := rerun.New(lvBuild, opts)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, "warning:", warning)
+	}
+	if err := c.Create(ctx, newBuild); err != nil {
+		return err
+	}
+	fmt.Printf("leviathanbuild.jcrs.jcrs.dev/%s created\n", newBuild.Name)
+	return nil
+}
+
+// parameters is a flag.Value collecting parameter overrides given as NAME=VALUE.
+type parameters map[string]string
+
+func (p parameters) String() string {
+	pairs := make([]string, 0, len(p))
+	for name, value := range p {
+		pairs = append(pairs, name+"="+value)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (p parameters) Set(s string) error {
+	name, value, err := rerun.ParseParameter(s)
+	if err != nil {
+		return err
+	}
+	p[name] = value
+	return nil
+}
+
+// readArchiveRecord returns the build of the archive record in file.
+func readArchiveRecord(file string) (*jcrsv1.LeviathanBuild, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var record struct {
+		Build *jcrsv1.LeviathanBuild `json:"build"`
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	if record.Build == nil {
+		return nil, fmt.Errorf("%s: not an archive record", file)
+	}
+	return record.Build, nil
+}
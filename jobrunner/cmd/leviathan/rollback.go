@@ -0,0 +1,180 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/spechistory"
+)
+
+// rollbackFieldManager is the field manager of the specs applied by rollback
+const rollbackFieldManager = "leviathan-rollback"
+
+// runRollback implements the rollback subcommand.
+func runRollback(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("rollback", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), rollbackUsage)
+		flags.PrintDefaults()
+	}
+	var namespace string
+	var generation int64
+	flags.StringVar(&namespace, "namespace", "default", "Namespace of the build.")
+	flags.Int64Var(&generation, "to-generation", 0, "Generation of the spec to roll back to. The recorded generations are listed when 0.")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 || generation < 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	c, err := backupClient()
+	if err != nil {
+		return err
+	}
+	lvBuild := &jcrsv1.LeviathanBuild{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: flags.Arg(0)}, lvBuild); err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: spechistory.ConfigMapName(lvBuild)}, cm); err != nil {
+		return fmt.Errorf("reading the spec history of %s, is the SpecHistory feature gate enabled? %w", lvBuild.Name, err)
+	}
+	if !metav1.IsControlledBy(cm, lvBuild) {
+		return fmt.Errorf("ConfigMap %s doesn't hold the spec history of %s", cm.Name, lvBuild.Name)
+	}
+	if generation == 0 {
+		return printSpecHistory(lvBuild, cm)
+	}
+
+	snapshot, err := spechistory.Get(cm, generation)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(snapshot.Spec, lvBuild.Spec) {
+		fmt.Printf("%s already has the spec of generation %d\n", lvBuild.Name, generation)
+		return nil
+	}
+	if owner := metav1.GetControllerOf(lvBuild); owner != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s is controlled by %s %s, which may revert the rollback\n", lvBuild.Name, owner.Kind, owner.Name)
+	}
+
+	spec, err := specFields(snapshot.Spec)
+	if err != nil {
+		return err
+	}
+	applied := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	applied.SetAPIVersion(jcrsv1.GroupVersion.String())
+	applied.SetKind("LeviathanBuild")
+	applied.SetNamespace(lvBuild.Namespace)
+	applied.SetName(lvBuild.Name)
+	if err := c.Patch(ctx, applied, client.Apply, client.FieldOwner(rollbackFieldManager), client.ForceOwnership); err != nil {
+		return err
+	}
+	fmt.Printf("Rolled %s back to the spec of generation %d, as generation %d\n", lvBuild.Name, generation, applied.GetGeneration())
+
+	// Server-side apply leaves the fields other field managers set and the snapshot doesn't
+	rolledBack, _, _ := unstructured.NestedMap(applied.Object, "spec")
+	for _, field := range differingFields(spec, rolledBack) {
+		fmt.Fprintf(os.Stderr, "Warning: spec.%s differs from generation %d, it is set by another field manager\n", field, generation)
+	}
+	return nil
+}
+
+// specFields returns the fields of spec, as they are encoded. The unset fields
+// encoded as null are left out, they aren't part of the applied configuration.
+func specFields(spec jcrsv1.LeviathanBuildSpec) (map[string]any, error) {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	dropNulls(fields)
+	return fields, nil
+}
+
+// dropNulls removes the null fields of the objects in value, recursively.
+func dropNulls(value any) {
+	switch value := value.(type) {
+	case map[string]any:
+		for field, v := range value {
+			if v == nil {
+				delete(value, field)
+				continue
+			}
+			dropNulls(v)
+		}
+	case []any:
+		for _, v := range value {
+			dropNulls(v)
+		}
+	}
+}
+
+// differingFields returns the top level fields whose values differ between want and got.
+func differingFields(want, got map[string]any) []string {
+	var fields []string
+	for field, value := range got {
+		if !reflect.DeepEqual(want[field], value) {
+			fields = append(fields, field)
+		}
+	}
+	for field := range want {
+		if _, ok := got[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// printSpecHistory prints the generations recorded in the spec history of lvBuild held by cm.
+func printSpecHistory(lvBuild *jcrsv1.LeviathanBuild, cm *corev1.ConfigMap) error {
+	snapshots, err := spechistory.Snapshots(cm)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "GENERATION\tRECORDED\tCURRENT")
+	now := time.Now()
+	for _, snapshot := range snapshots {
+		current := ""
+		if snapshot.Generation == lvBuild.Generation {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%d\t%s ago\t%s\n", snapshot.Generation, duration.HumanDuration(now.Sub(snapshot.RecordedAt.Time)), current)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"test.jcrs.dev/jobrunner/internal/history"
+)
+
+// runRunsList implements the runs list subcommand.
+func runRunsList(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("runs list", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), runsListUsage)
+		flags.PrintDefaults()
+	}
+	var namespace, historyURL string
+	var allNamespaces bool
+	var limit int
+	flags.StringVar(&namespace, "namespace", "default", "Namespace of the builds.")
+	flags.BoolVar(&allNamespaces, "all-namespaces", false, "List the runs of the builds of every namespace.")
+	flags.IntVar(&limit, "limit", 20, "Maximum number of runs listed, all of them when 0.")
+	flags.StringVar(&historyURL, "history-url", "", "URL of the history server the runs are recorded in, instead of the Jobs of the builds.")
+	_ = flags.Parse(args)
+	if flags.NArg() > 1 || (allNamespaces && flags.NArg() == 1) {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var reader client.Reader
+	if historyURL == "" {
+		scheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+		if err != nil {
+			return err
+		}
+		reader = c
+	}
+	store, err := history.New(historyURL, reader, 30*time.Second)
+	if err != nil {
+		return err
+	}
+
+	query := history.Query{Namespace: namespace, Build: flags.Arg(0), Limit: limit}
+	if allNamespaces {
+		query.Namespace = ""
+	}
+	runs, err := store.List(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	if allNamespaces {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "BUILD\tRUN\tOUTCOME\tFINISHED\tDURATION\tJOB")
+	now := time.Now()
+	for _, run := range runs {
+		if allNamespaces {
+			fmt.Fprintf(w, "%s\t", run.Namespace)
+		}
+		elapsed := "<unknown>"
+		if !run.StartTime.IsZero() {
+			elapsed = duration.HumanDuration(run.CompletionTime.Sub(run.StartTime))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s ago\t%s\t%s\n", run.Build, run.Index, run.Outcome,
+			duration.HumanDuration(now.Sub(run.CompletionTime)), elapsed, run.Job)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/controller"
+	webhookv1 "test.jcrs.dev/jobrunner/internal/webhook/v1"
+)
+
+// runSimulate implements the simulate subcommand.
+func runSimulate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), simulateUsage)
+		flags.PrintDefaults()
+	}
+	var namespace, file, output string
+	var defaultDuration time.Duration
+	var maxBuildsPerNamespace int
+	flags.StringVar(&file, "f", "", "File holding the LeviathanBuilds to simulate as YAML or JSON documents, - for the standard input.")
+	flags.StringVar(&namespace, "namespace", "default", "Namespace of the builds that don't set one.")
+	flags.DurationVar(&defaultDuration, "default-duration", 10*time.Minute,
+		"Expected duration of the runs of builds that have no recent runs to go by, nor any build of their package.")
+	flags.IntVar(&maxBuildsPerNamespace, "max-builds-per-namespace", 0,
+		"The --max-builds-per-namespace of the manager, the maximum number of builds of namespaces without the max builds annotation. 0 is unlimited.")
+	flags.StringVar(&output, "o", "", "Output format, json for the simulation as JSON instead of tables.")
+	_ = flags.Parse(args)
+	if file == "" || flags.NArg() != 0 || (output != "" && output != "json") {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	builds, err := readBuilds(file, namespace)
+	if err != nil {
+		return err
+	}
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(jcrsv1.AddToScheme(scheme))
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	r := &controller.LeviathanBuildReconciler{Client: c, Scheme: scheme}
+	now := time.Now()
+	simulation, err := r.Simulate(ctx, builds, controller.SimulationOptions{
+		Now:             now,
+		DefaultDuration: defaultDuration,
+		MaxBuilds: func(ctx context.Context, namespace string) (int, error) {
+			var ns corev1.Namespace
+			if err := c.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+				return 0, client.IgnoreNotFound(err)
+			}
+			return webhookv1.MaxBuilds(&ns, maxBuildsPerNamespace)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(simulation)
+	}
+	return printSimulation(simulation, now)
+}
+
+// readBuilds returns the LeviathanBuilds of file, in namespace when they don't
+// set one. Builds with only a generateName are named after it.
+func readBuilds(file, namespace string) ([]jcrsv1.LeviathanBuild, error) {
+	var in io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(in, 4096)
+	var builds []jcrsv1.LeviathanBuild
+	for {
+		var lvBuild jcrsv1.LeviathanBuild
+		if err := decoder.Decode(&lvBuild); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if lvBuild.Kind == "" && lvBuild.Name == "" && lvBuild.GenerateName == "" {
+			// An empty document
+			continue
+		}
+		if lvBuild.Kind != "LeviathanBuild" {
+			return nil, fmt.Errorf("%s: document %d is a %s, not a LeviathanBuild", file, len(builds)+1, lvBuild.Kind)
+		}
+		if lvBuild.Namespace == "" {
+			lvBuild.Namespace = namespace
+		}
+		if lvBuild.Name == "" {
+			if lvBuild.GenerateName == "" {
+				return nil, fmt.Errorf("%s: document %d has neither a name nor a generateName", file, len(builds)+1)
+			}
+			lvBuild.Name = lvBuild.GenerateName + strconv.Itoa(len(builds)+1)
+		}
+		builds = append(builds, lvBuild)
+	}
+	if len(builds) == 0 {
+		return nil, fmt.Errorf("%s: no LeviathanBuilds", file)
+	}
+	return builds, nil
+}
+
+// printSimulation prints the expected schedule of the simulated builds and the
+// peaks of the resources they request, relative to now.
+func printSimulation(simulation *controller.Simulation, now time.Time) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "BUILD\tQUEUE TIME\tFINISHES IN\tWAITED FOR")
+	for _, build := range simulation.Builds {
+		name := build.Namespace + "/" + build.Name
+		if build.NeverStarts != "" {
+			fmt.Fprintf(w, "%s\tnever\t-\t%s\n", name, build.NeverStarts)
+			continue
+		}
+		waitedFor := strings.Join(build.WaitedFor, ", ")
+		if waitedFor == "" {
+			waitedFor = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, build.QueueTime.Round(time.Second), build.Finish.Sub(now).Round(time.Second), waitedFor)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "NAMESPACE\tRESOURCE\tPEAK\tIN\tQUOTA")
+	for _, peak := range simulation.Peaks {
+		hard := "-"
+		if peak.Hard != nil {
+			hard = peak.Hard.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", peak.Namespace, peak.Resource, peak.Peak.String(), peak.At.Sub(now).Round(time.Second), hard)
+	}
+	return w.Flush()
+}
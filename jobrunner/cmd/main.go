@@ -17,20 +17,34 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -38,10 +52,36 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	jcrsv2 "test.jcrs.dev/jobrunner/api/v2"
+	"test.jcrs.dev/jobrunner/internal/apihealth"
+	"test.jcrs.dev/jobrunner/internal/archive"
+	"test.jcrs.dev/jobrunner/internal/cloudevents"
 	"test.jcrs.dev/jobrunner/internal/controller"
+	"test.jcrs.dev/jobrunner/internal/crds"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	"test.jcrs.dev/jobrunner/internal/grpcapi"
+	"test.jcrs.dev/jobrunner/internal/history"
+	"test.jcrs.dev/jobrunner/internal/imagepoll"
+	"test.jcrs.dev/jobrunner/internal/interop"
+	"test.jcrs.dev/jobrunner/internal/logging"
+	"test.jcrs.dev/jobrunner/internal/maintenance"
+	"test.jcrs.dev/jobrunner/internal/profiling"
+	"test.jcrs.dev/jobrunner/internal/progress"
+	"test.jcrs.dev/jobrunner/internal/promotion"
+	"test.jcrs.dev/jobrunner/internal/registry"
+	"test.jcrs.dev/jobrunner/internal/retention"
+	"test.jcrs.dev/jobrunner/internal/runlogs"
+	"test.jcrs.dev/jobrunner/internal/schedule"
+	"test.jcrs.dev/jobrunner/internal/signing"
+	"test.jcrs.dev/jobrunner/internal/sourcepoll"
+	"test.jcrs.dev/jobrunner/internal/version"
+	webhookv1 "test.jcrs.dev/jobrunner/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
+// cgroupRoot is where the cgroup of the container of the manager is mounted
+const cgroupRoot = "/sys/fs/cgroup"
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -49,8 +89,10 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 
 	utilruntime.Must(jcrsv1.AddToScheme(scheme))
+	utilruntime.Must(jcrsv2.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -63,6 +105,55 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var registryTimeout time.Duration
+	var backoffBase, backoffMax time.Duration
+	var slowReconcileThreshold time.Duration
+	var enablePprof bool
+	var runtimeTuner profiling.Tuner
+	var clusterBuildNamespace string
+	var apiErrorThreshold float64
+	var apiRetries int
+	var apiCooldown time.Duration
+	var archiveEndpoint, archiveBucket, archiveRegion string
+	var archiveTimeout time.Duration
+	var pricingConfigMap string
+	var spotNodeLabel, spotNodeTaint string
+	var spotMaxRetries int
+	var protectedPriorityClass string
+	var verify controller.VerifyConfig
+	var signingConfig signing.Config
+	var sourceMirror controller.SourceMirrorConfig
+	var fetchConfig controller.FetchConfig
+	var fetchBandwidthLimit string
+	var maxConcurrentFetches int
+	var specHistoryLimit int
+	var manageCRDs bool
+	var crdCheckInterval time.Duration
+	var maxBuildsPerNamespace int
+	var uniquePackageNames bool
+	var strictFields bool
+	var artifactS3Region string
+	var artifactTimeout time.Duration
+	var promotionTimeout time.Duration
+	var sourcePollS3Endpoint, sourcePollS3Region string
+	var sourcePollTimeout time.Duration
+	var builderImagePollInterval, builderImagePollTimeout time.Duration
+	var cloudEventsSink string
+	var pullSecret, pullSecretNamespaceSelector string
+	var cloudEventsTimeout time.Duration
+	var historyURL string
+	var maintenanceSchedule string
+	var historyRetention, archiveRetention time.Duration
+	var historyTimeout time.Duration
+	var fetcherImage, gitFetcherImage string
+	var network controller.NetworkConfig
+	var isolationSourceCIDRs, isolationPublishCIDRs string
+	var grpcAddr, grpcTokensFile, grpcCertPath string
+	var grpcInsecure bool
+	var grpcRateLimit float64
+	var grpcRateBurst int
+	var jobPruner controller.JobPruner
+	var jobPrunerQPS float64
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -81,13 +172,199 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&registryTimeout, "registry-timeout", 10*time.Second,
+		"The timeout for requests checking whether a package version is already published.")
+	flag.StringVar(&fetcherImage, "fetcher-image", "controller:latest",
+		"The image running the fetch init container and the heartbeat sidecar of build Jobs. It should match the manager image.")
+	flag.StringVar(&gitFetcherImage, "git-fetcher-image", "git-fetcher:latest",
+		"The image running the fetch init container of build Jobs cloning a Git source. It should match the git-fetcher image of the manager.")
+	flag.DurationVar(&backoffBase, "reconcile-backoff-base", 5*time.Second,
+		"The initial requeue delay of a LeviathanBuild after a failed reconcile.")
+	flag.DurationVar(&backoffMax, "reconcile-backoff-max", 10*time.Minute,
+		"The maximum requeue delay of a LeviathanBuild whose reconciles keep failing.")
+	flag.DurationVar(&slowReconcileThreshold, "slow-reconcile-threshold", 5*time.Second,
+		"The duration above which a LeviathanBuild reconcile is logged. Set to 0 to disable.")
+	flag.BoolVar(&enablePprof, "enable-pprof", false,
+		"If set, the pprof profiles of the manager are served on /debug/pprof/ by the metrics server. Requires --metrics-secure.")
+	flag.BoolVar(&runtimeTuner.MaxProcs, "auto-gomaxprocs", true,
+		"If set, GOMAXPROCS is set to the CPU limit of the container of the manager, unless set in the environment.")
+	flag.Float64Var(&runtimeTuner.MemoryLimitRatio, "memory-limit-ratio", 0.9,
+		"The ratio of the memory limit of the container of the manager the Go runtime is limited to, unless GOMEMLIMIT is "+
+			"set in the environment. Set to 0 to leave the runtime unlimited.")
+	flag.DurationVar(&runtimeTuner.Interval, "runtime-tuning-interval", time.Minute,
+		"How often the limits of the container of the manager are read again, as pods can be resized in place. "+
+			"Set to 0 to only read them at startup.")
+	flag.Float64Var(&apiErrorThreshold, "api-error-threshold", 0.5,
+		"The fraction of the latest requests to the API server that may fail with a transient error before the "+
+			"LeviathanBuild controller holds its requests back. Set to 0 to send them regardless.")
+	flag.IntVar(&apiRetries, "api-retries", 3,
+		"The number of times a request of the LeviathanBuild controller failing with a transient error is retried.")
+	flag.DurationVar(&apiCooldown, "api-cooldown", 30*time.Second,
+		"How long requests of the LeviathanBuild controller are held back once too many of them failed.")
+	flag.IntVar(&jobPruner.HistoryLimit, "job-history-limit", 0,
+		"The number of latest runs whose finished Jobs are kept per LeviathanBuild by the Job pruner, with the "+
+			"ParallelJobPruner feature gate. Only the Jobs of earlier runs of Verify builds are deleted when 0.")
+	flag.IntVar(&jobPruner.Workers, "job-pruner-workers", 4, "The number of LeviathanBuilds whose Jobs are pruned in parallel.")
+	flag.Int64Var(&jobPruner.PageSize, "job-pruner-page-size", 500, "The number of Jobs the Job pruner lists per request.")
+	flag.DurationVar(&jobPruner.Interval, "job-pruner-interval", 5*time.Minute, "The time between two sweeps of the Job pruner.")
+	flag.Float64Var(&jobPrunerQPS, "job-pruner-qps", 20,
+		"The maximum number of Jobs deleted per second by the Job pruner. Set to 0 to delete them unpaced.")
+	flag.StringVar(&network.HTTPProxy, "build-http-proxy", "", "The HTTP_PROXY injected into build Jobs.")
+	flag.StringVar(&network.HTTPSProxy, "build-https-proxy", "", "The HTTPS_PROXY injected into build Jobs.")
+	flag.StringVar(&network.NoProxy, "build-no-proxy", "", "The NO_PROXY injected into build Jobs.")
+	flag.StringVar(&network.TrustBundleName, "build-trust-bundle", "",
+		"The name of a ConfigMap, present in every build namespace, holding the CA bundle mounted into build Jobs.")
+	flag.StringVar(&network.TrustBundleKey, "build-trust-bundle-key", "ca-bundle.crt",
+		"The key of the CA bundle in the trust bundle ConfigMap.")
+	flag.StringVar(&isolationSourceCIDRs, "build-isolation-source-cidrs", "",
+		"A comma separated list of the CIDRs builds with a Strict network isolation may reach to fetch their source, "+
+			"including the build proxy if any.")
+	flag.StringVar(&isolationPublishCIDRs, "build-isolation-publish-cidrs", "",
+		"A comma separated list of the CIDRs builds with a Strict network isolation may reach to publish, "+
+			"including the build proxy if any.")
+	flag.StringVar(&archiveEndpoint, "archive-endpoint", "https://s3.amazonaws.com",
+		"The S3 compatible endpoint builds are archived to. Credentials are read from "+
+			"AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.")
+	flag.StringVar(&archiveBucket, "archive-bucket", "", "The bucket builds are archived to. Builds aren't archived when empty.")
+	flag.StringVar(&archiveRegion, "archive-region", "us-east-1", "The region of the archive bucket.")
+	flag.DurationVar(&archiveTimeout, "archive-timeout", 30*time.Second, "Timeout for writing a build to the archive.")
+	flag.StringVar(&sourceMirror.Endpoint, "source-mirror-endpoint", "https://s3.amazonaws.com",
+		"The S3 compatible endpoint fetched sources are mirrored to. Builds with a Strict network isolation "+
+			"must be able to reach it through the source CIDRs.")
+	flag.StringVar(&sourceMirror.Bucket, "source-mirror-bucket", "",
+		"The bucket fetched sources are mirrored to, and fetched from by the later runs of their build. "+
+			"Sources aren't mirrored when empty.")
+	flag.StringVar(&sourceMirror.Region, "source-mirror-region", "us-east-1", "The region of the source mirror bucket.")
+	flag.StringVar(&sourceMirror.SecretName, "source-mirror-secret", "",
+		"The name of a Secret, present in every build namespace, holding the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY "+
+			"the fetcher writes to the source mirror bucket with.")
+	flag.StringVar(&fetchBandwidthLimit, "fetch-bandwidth-limit", "",
+		"The rate, in bytes per second as a quantity such as 50Mi, each fetch init container downloads archives "+
+			"from their origin or the source mirror at. Downloads aren't limited when empty. Git transfers aren't limited.")
+	flag.IntVar(&fetchConfig.Parallelism, "fetch-parallelism", 0,
+		"The number of submodules and LFS objects the fetch init container of a Git source downloads at once. "+
+			"Git's defaults apply when 0.")
+	flag.IntVar(&maxConcurrentFetches, "max-concurrent-fetches", 0,
+		"The maximum number of builds of the cluster fetching their source at once. Builds wait in "+
+			"WaitingForFetchSlot for a free slot before creating their Job. Fetches aren't limited when 0.")
+	flag.IntVar(&specHistoryLimit, "spec-history-limit", 10,
+		"The number of generations of the spec of every LeviathanBuild kept for leviathan rollback, with the "+
+			"SpecHistory feature gate.")
+	flag.StringVar(&pricingConfigMap, "pricing-configmap", "",
+		"The namespace/name of a ConfigMap holding the hourly price of resources, used to estimate the cost of builds. "+
+			"Costs aren't estimated when empty.")
+	flag.StringVar(&spotNodeLabel, "spot-node-label", "karpenter.sh/capacity-type=spot",
+		"The key=value label of spot nodes, used to schedule builds according to their spotPolicy.")
+	flag.StringVar(&spotNodeTaint, "spot-node-taint", "",
+		"The key[=value]:effect taint of spot nodes, tolerated by builds that may run on them.")
+	flag.IntVar(&spotMaxRetries, "spot-max-retries", 3,
+		"The number of consecutive runs of a build interrupted on spot nodes that are run again.")
+	flag.StringVar(&protectedPriorityClass, "protected-priority-class", "",
+		"The PriorityClass of the pods of builds protected from eviction that don't set one. Their priority is left as is when empty.")
+	flag.StringVar(&verify.PriorityClassName, "verify-priority-class", "",
+		"The PriorityClass of the pods of Verify builds that don't set one. Their priority is left as is when empty.")
+	flag.DurationVar(&verify.TTL, "verify-build-ttl", time.Hour,
+		"How long Verify builds are kept once their latest run has finished. They are kept until deleted when 0.")
+	flag.StringVar(&signingConfig.CosignImage, "cosign-image", "ghcr.io/sigstore/cosign/cosign:v2.4.1",
+		"The image of cosign, which signs the artifacts of builds with spec.signing when ArtifactSigning is enabled.")
+	flag.StringVar(&signingConfig.SyftImage, "syft-image", "docker.io/anchore/syft:v1.18.1",
+		"The image of syft, which generates the SBOM attestations of artifacts.")
+	flag.StringVar(&signingConfig.OIDCIssuer, "signing-oidc-issuer", "https://kubernetes.default.svc.cluster.local",
+		"The issuer of the ServiceAccount tokens of the cluster, recorded as the issuer of keyless signatures.")
+	flag.BoolVar(&manageCRDs, "manage-crds", false,
+		"If set, the CustomResourceDefinitions bundled with the operator are applied over installed ones that are missing, "+
+			"older or validated differently.")
+	flag.DurationVar(&crdCheckInterval, "crd-check-interval", 10*time.Minute,
+		"How often the installed CustomResourceDefinitions are compared with the bundled ones, besides at startup.")
+	flag.StringVar(&clusterBuildNamespace, "cluster-build-namespace", "jobrunner-builds",
+		"The namespace the Jobs of LeviathanClusterBuilds run in, when the ClusterBuilds feature is enabled. It must exist.")
+	flag.IntVar(&maxBuildsPerNamespace, "max-builds-per-namespace", 0,
+		"The number of LeviathanBuilds a namespace may hold, unless its "+webhookv1.MaxBuildsAnnotation+" annotation says otherwise. "+
+			"Unlimited when 0.")
+	flag.BoolVar(&uniquePackageNames, "unique-package-names", false,
+		"If set, a LeviathanBuild is denied while another active build of its namespace builds the same package, unless the "+
+			webhookv1.UniquePackageNamesAnnotation+" annotation of the namespace says otherwise.")
+	flag.BoolVar(&strictFields, "strict-fields", false,
+		"If set, LeviathanBuilds and LeviathanClusterBuilds with fields under spec that their spec doesn't declare are denied, "+
+			"with the nearest declared field as a suggestion.")
+	flag.StringVar(&artifactS3Region, "artifact-s3-region", "us-east-1",
+		"The region of the S3 buckets versions are pruned from by the artifact retention policy of builds, or rolled back from.")
+	flag.DurationVar(&artifactTimeout, "artifact-timeout", 30*time.Second,
+		"Timeout for deleting a version pruned by the artifact retention policy of a build, or rolled back.")
+	flag.DurationVar(&promotionTimeout, "promotion-timeout", 10*time.Minute,
+		"Timeout for copying an artifact promoted by a PackagePromotion, or every blob of an OCI artifact, when the "+
+			"PackagePromotions feature is enabled. S3 artifacts are promoted in the region of --artifact-s3-region.")
+	flag.StringVar(&sourcePollS3Endpoint, "source-poll-s3-endpoint", "https://s3.amazonaws.com",
+		"The S3 compatible endpoint the sources of S3 builds with a pollInterval are polled from, when the SourcePolling "+
+			"feature is enabled. Credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.")
+	flag.StringVar(&sourcePollS3Region, "source-poll-s3-region", "us-east-1", "The region of the buckets S3 sources are polled from.")
+	flag.DurationVar(&sourcePollTimeout, "source-poll-timeout", 30*time.Second, "Timeout for checking the source of a build for a new revision.")
+	flag.DurationVar(&builderImagePollInterval, "builder-image-poll-interval", 15*time.Minute,
+		"How often the builder images of builds are checked for a new digest, when the BuilderImagePolling feature is enabled.")
+	flag.DurationVar(&builderImagePollTimeout, "builder-image-poll-timeout", 30*time.Second,
+		"Timeout for checking a builder image for a new digest.")
+	flag.StringVar(&cloudEventsSink, "cloudevents-sink", "",
+		"The URL the CloudEvents of builds are sent to, e.g. https://broker.example.com or nats://nats:4222/builds. "+
+			"Builds aren't exported when empty.")
+	flag.DurationVar(&cloudEventsTimeout, "cloudevents-timeout", 10*time.Second, "Timeout for sending a CloudEvent to the sink.")
+	flag.StringVar(&historyURL, "history-url", "",
+		"The URL of the history server the runs of builds are recorded in, e.g. http://localhost:8090 for a sidecar. "+
+			"Runs are only kept as the Jobs of builds when empty.")
+	flag.DurationVar(&historyTimeout, "history-timeout", 10*time.Second, "Timeout for recording a run in the history server.")
+	flag.StringVar(&maintenanceSchedule, "history-maintenance-schedule", "0 3 * * *",
+		"The cron schedule, in UTC, at which the history server is compacted and the runs and archived builds past "+
+			"their retention are pruned.")
+	flag.DurationVar(&historyRetention, "history-retention", 0,
+		"How long the runs recorded in the history server are kept. They are kept until it drops them when 0.")
+	flag.DurationVar(&archiveRetention, "archive-retention", 0,
+		"How long archived builds are kept in the archive bucket. They are kept forever when 0.")
+	flag.StringVar(&pullSecret, "pull-secret", "",
+		"The namespace/name of a registry pull Secret copied into the namespaces of builds and attached to their pods, "+
+			"when the PullSecretDistribution feature is enabled.")
+	flag.StringVar(&pullSecretNamespaceSelector, "pull-secret-namespace-selector", "",
+		"The label selector of the namespaces the pull Secret is copied to. Every namespace holding builds when empty.")
+	flag.StringVar(&grpcAddr, "grpc-bind-address", "",
+		"The address the gRPC build API binds to, e.g. :9090. The API isn't served when empty.")
+	flag.StringVar(&grpcTokensFile, "grpc-tokens-file", "",
+		"The file holding the bearer tokens accepted by the gRPC build API, one '<name> <token> <namespace>[,<namespace>...] [<user>]' per line. "+
+			"The requests of a token are made as its user, 'jobrunner-grpc:<name>' when unset.")
+	flag.StringVar(&grpcCertPath, "grpc-cert-path", "",
+		"The directory that contains the tls.crt and tls.key of the gRPC build API.")
+	flag.BoolVar(&grpcInsecure, "grpc-insecure", false,
+		"If set, the gRPC build API is served in plaintext when --grpc-cert-path is empty, exposing its bearer tokens.")
+	flag.Float64Var(&grpcRateLimit, "grpc-rate-limit", 5, "The requests per second each token may send to the gRPC build API.")
+	flag.IntVar(&grpcRateBurst, "grpc-rate-burst", 10, "The bursts of requests each token may send to the gRPC build API.")
+	flag.Var(featuregates.DefaultFeatureGate, "feature-gates",
+		"A set of key=value pairs that describe feature gates for alpha/experimental features. Options are:\n"+
+			strings.Join(featuregates.DefaultFeatureGate.KnownFeatures(), "\n"))
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	// The sink writes every verbosity, so the logs of builds annotated with a log
+	// level can be raised above the configured one
+	level := opts.Level
+	if level == nil {
+		level = zapcore.InfoLevel
+		if opts.Development {
+			level = zapcore.DebugLevel
+		}
+	}
+	opts.Level = zapcore.Level(-logging.MaxVerbosity)
+	ctrl.SetLogger(logging.New(zap.New(zap.UseFlagOptions(&opts)), logging.Verbosity(level)))
+
+	// The Go runtime is sized to the limits of the container before anything starts
+	if runtimeTuner.MemoryLimitRatio < 0 || runtimeTuner.MemoryLimitRatio > 1 {
+		setupLog.Error(nil, "--memory-limit-ratio must be between 0 and 1", "memory-limit-ratio", runtimeTuner.MemoryLimitRatio)
+		os.Exit(1)
+	}
+	runtimeTuner.Root = cgroupRoot
+	runtimeTuner.Log = ctrl.Log.WithName("runtime")
+	if err := runtimeTuner.Tune(); err != nil {
+		setupLog.Error(err, "unable to read the limits of the container, the Go runtime is left as is")
+	}
 
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
@@ -151,6 +428,15 @@ func main() {
 		metricsServerOptions.FilterProvider = filters.WithAuthenticationAndAuthorization
 	}
 
+	// Profiles expose the memory of the manager, so they're never served without authn/authz
+	if enablePprof {
+		if !secureMetrics {
+			setupLog.Error(nil, "--enable-pprof requires --metrics-secure")
+			os.Exit(1)
+		}
+		metricsServerOptions.ExtraHandlers = profiling.Handlers()
+	}
+
 	// If the certificate is not specified, controller-runtime will automatically
 	// generate self-signed certificates for the metrics server. While convenient for development and testing,
 	// this setup is not recommended for production.
@@ -178,8 +464,28 @@ func main() {
 		})
 	}
 
+	// Only the Jobs managed by the controller are cached, without their pod template
+	var cacheOptions cache.Options
+	cacheOptions.ByObject = map[client.Object]cache.ByObject{}
+	if featuregates.Enabled(featuregates.ManagedJobCache) {
+		cacheOptions.ByObject[&batchv1.Job{}] = controller.ManagedJobCache()
+	}
+	// Only the Events of Jobs whose pods are refused, or the Warnings mirrored on builds, are cached
+	if featuregates.Enabled(featuregates.PodCreationChecks) || featuregates.Enabled(featuregates.JobEventMirroring) {
+		cacheOptions.ByObject[&corev1.Event{}] = controller.JobEventCache()
+	}
+	// Namespaces are cached without the fields nobody reads
+	cacheOptions.ByObject[&corev1.Namespace{}] = controller.NamespaceCache()
+	// Secrets and ConfigMaps are only watched by their metadata, and read from the
+	// API server, so that those of the whole cluster are never held in memory
+	clientOptions := client.Options{Cache: &client.CacheOptions{
+		DisableFor: []client.Object{&corev1.Secret{}, &corev1.ConfigMap{}},
+	}}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  cacheOptions,
+		Client:                 clientOptions,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -202,13 +508,393 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := (&controller.LeviathanBuildReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	// The Jobs created before they were labeled as managed are labeled before the cache starts
+	if featuregates.Enabled(featuregates.ManagedJobCache) {
+		directClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to create client")
+			os.Exit(1)
+		}
+		if _, err := controller.LabelManagedJobs(ctrl.LoggerInto(context.Background(), setupLog), directClient); err != nil {
+			setupLog.Error(err, "unable to label the Jobs managed by the controller")
+			os.Exit(1)
+		}
+	}
+
+	// Sidecars of builds are wrapped when the cluster can't run them natively
+	nativeSidecars, err := controller.NativeSidecarsSupported(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to detect native sidecar support, sidecars will be wrapped")
+	}
+	// Sharded builds with a successPolicy are held back when the cluster can't honor it
+	successPolicies, err := controller.SuccessPoliciesSupported(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to detect successPolicy support, builds with a successPolicy won't run")
+	}
+
+	var buildArchive archive.Store
+	var archiveStore *archive.S3Store
+	var runLogs runlogs.Source
+	if archiveBucket != "" {
+		archiveStore = archive.NewS3Store(archiveEndpoint, archiveBucket, archiveRegion,
+			os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), archiveTimeout)
+		buildArchive = archiveStore
+		// The logs of finished runs are archived with the builds
+		clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create the clientset reading the logs of runs")
+			os.Exit(1)
+		}
+		runLogs = runlogs.ClientsetSource{Clientset: clientset}
+	}
+
+	var pricing controller.Pricing
+	if pricingConfigMap != "" {
+		namespace, name, ok := strings.Cut(pricingConfigMap, "/")
+		if !ok {
+			setupLog.Error(nil, "--pricing-configmap must be namespace/name", "value", pricingConfigMap)
+			os.Exit(1)
+		}
+		pricing = &controller.ConfigMapPricing{Reader: mgr.GetClient(), Key: types.NamespacedName{Namespace: namespace, Name: name}}
+	}
+
+	spot, err := controller.ParseSpotConfig(spotNodeLabel, spotNodeTaint, int32(spotMaxRetries))
+	if err != nil {
+		setupLog.Error(err, "invalid spot node configuration")
+		os.Exit(1)
+	}
+
+	if network.SourceCIDRs, err = controller.ParseCIDRs(isolationSourceCIDRs); err != nil {
+		setupLog.Error(err, "invalid network isolation source CIDRs")
+		os.Exit(1)
+	}
+	if network.PublishCIDRs, err = controller.ParseCIDRs(isolationPublishCIDRs); err != nil {
+		setupLog.Error(err, "invalid network isolation publish CIDRs")
+		os.Exit(1)
+	}
+
+	pullSecretConfig, err := controller.ParsePullSecretConfig(pullSecret, pullSecretNamespaceSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid pull Secret configuration")
+		os.Exit(1)
+	}
+
+	var cloudEvents cloudevents.Sink
+	if cloudEventsSink != "" {
+		if cloudEvents, err = cloudevents.NewSink(cloudEventsSink, cloudEventsTimeout); err != nil {
+			setupLog.Error(err, "invalid CloudEvents sink")
+			os.Exit(1)
+		}
+	}
+
+	var runHistory history.Store
+	if historyURL != "" {
+		if runHistory, err = history.New(historyURL, nil, historyTimeout); err != nil {
+			setupLog.Error(err, "invalid history store")
+			os.Exit(1)
+		}
+	}
+
+	// Versions are only deleted, by retention policies or rollbacks, with the ArtifactRetention feature
+	var pruners map[jcrsv1.ArtifactStore]retention.Pruner
+	if featuregates.Enabled(featuregates.ArtifactRetention) {
+		pruners = map[jcrsv1.ArtifactStore]retention.Pruner{
+			jcrsv1.S3ArtifactStore: retention.NewS3Pruner(artifactS3Region,
+				os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), artifactTimeout),
+			jcrsv1.OCIArtifactStore: retention.NewOCIPruner(
+				os.Getenv("REGISTRY_USERNAME"), os.Getenv("REGISTRY_PASSWORD"), artifactTimeout),
+		}
+	}
+
+	// Builds wait for a flaky API server to recover, rather than failing every reconcile against it
+	var buildClient client.Client = mgr.GetClient()
+	var apiBreaker *apihealth.Breaker
+	if apiErrorThreshold > 0 {
+		apiBreaker = apihealth.NewBreaker(apiErrorThreshold, apiCooldown)
+		buildClient = apihealth.NewClient(mgr.GetClient(), apiBreaker, apiRetries, time.Second)
+	}
+
+	// Sources are polled once for all the builds polling them, by a single Poller
+	var poller *sourcepoll.Poller
+	if featuregates.Enabled(featuregates.SourcePolling) {
+		pollClient := &http.Client{Timeout: sourcePollTimeout}
+		poller = sourcepoll.NewPoller(map[jcrsv1.SourceType]sourcepoll.Checker{
+			jcrsv1.HTTPSource: &sourcepoll.HTTPChecker{Client: pollClient},
+			jcrsv1.GitSource:  &sourcepoll.GitChecker{Client: pollClient},
+			jcrsv1.S3Source: &sourcepoll.S3Checker{
+				Client:          pollClient,
+				Endpoint:        sourcePollS3Endpoint,
+				Region:          sourcePollS3Region,
+				AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+				SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			},
+		})
+		if err := mgr.Add(poller); err != nil {
+			setupLog.Error(err, "unable to add source poller")
+			os.Exit(1)
+		}
+	}
+
+	// Builder images are checked once for all the builds running them, by a single Poller
+	var imagePoller *imagepoll.Poller
+	if featuregates.Enabled(featuregates.BuilderImageMappings) && featuregates.Enabled(featuregates.BuilderImagePolling) {
+		resolver := &registry.HTTPDigestResolver{Client: &http.Client{Timeout: builderImagePollTimeout}}
+		imagePoller = imagepoll.NewPoller(resolver, builderImagePollInterval)
+		if err := mgr.Add(imagePoller); err != nil {
+			setupLog.Error(err, "unable to add builder image poller")
+			os.Exit(1)
+		}
+	}
+
+	// The logs of running builds are followed for their progress markers by a single Tracker
+	var progressTracker *progress.Tracker
+	if featuregates.Enabled(featuregates.BuildProgress) {
+		clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create the clientset of the progress tracker")
+			os.Exit(1)
+		}
+		progressTracker = progress.NewTracker(progress.ClientsetLogs{Clientset: clientset})
+		if err := mgr.Add(progressTracker); err != nil {
+			setupLog.Error(err, "unable to add progress tracker")
+			os.Exit(1)
+		}
+	}
+
+	// Builds with an executionBackend aren't run unless their engine is enabled
+	var backends map[jcrsv1.ExecutionBackend]interop.Converter
+	if featuregates.Enabled(featuregates.ExecutionBackends) {
+		backends = map[jcrsv1.ExecutionBackend]interop.Converter{
+			jcrsv1.TektonBackend: interop.TektonConverter{},
+			jcrsv1.ArgoBackend:   interop.ArgoConverter{},
+		}
+	}
+
+	if fetchBandwidthLimit != "" {
+		limit, err := resource.ParseQuantity(fetchBandwidthLimit)
+		if err != nil || limit.Sign() <= 0 {
+			setupLog.Error(err, "--fetch-bandwidth-limit must be a positive quantity", "limit", fetchBandwidthLimit)
+			os.Exit(1)
+		}
+		fetchConfig.BandwidthLimit = limit.Value()
+	}
+	if fetchConfig.Parallelism < 0 || maxConcurrentFetches < 0 {
+		setupLog.Error(nil, "--fetch-parallelism and --max-concurrent-fetches can't be negative")
+		os.Exit(1)
+	}
+	var fetchSlots *controller.FetchSlots
+	if maxConcurrentFetches > 0 {
+		fetchSlots = controller.NewFetchSlots(maxConcurrentFetches)
+	}
+
+	var capacity *controller.CapacityMonitor
+	if featuregates.Enabled(featuregates.CapacityWaits) {
+		capacity = controller.NewCapacityMonitor()
+	}
+
+	// Artifacts are only signed when the feature is enabled
+	if !featuregates.Enabled(featuregates.ArtifactSigning) {
+		signingConfig.CosignImage = ""
+	}
+
+	// The specs of builds are only kept when the feature is enabled
+	if specHistoryLimit < 1 {
+		setupLog.Error(nil, "--spec-history-limit must be positive")
+		os.Exit(1)
+	}
+	if !featuregates.Enabled(featuregates.SpecHistory) {
+		specHistoryLimit = 0
+	}
+
+	// Builds with a clusterSelector aren't run unless remote clusters are enabled
+	var clusters *controller.ClusterRegistry
+	if featuregates.Enabled(featuregates.RemoteClusters) {
+		clusters = controller.NewClusterRegistry(mgr.GetAPIReader())
+	}
+
+	reconcileStats := controller.NewReconcileStats()
+	buildReconciler := &controller.LeviathanBuildReconciler{
+		Client:                 buildClient,
+		Scheme:                 mgr.GetScheme(),
+		Registry:               registry.NewHTTPChecker(registryTimeout),
+		FetcherImage:           fetcherImage,
+		GitFetcherImage:        gitFetcherImage,
+		Backoff:                controller.NewBackoff(backoffBase, backoffMax),
+		JobCache:               controller.NewJobCache(),
+		Stats:                  reconcileStats,
+		Network:                network,
+		SlowReconcileThreshold: slowReconcileThreshold,
+		OperatorVersion:        version.Version,
+		APIReader:              mgr.GetAPIReader(),
+		NativeSidecars:         nativeSidecars,
+		SuccessPolicies:        successPolicies,
+		Archive:                buildArchive,
+		RunLogs:                runLogs,
+		Pricing:                pricing,
+		Spot:                   spot,
+		Recorder:               mgr.GetEventRecorderFor("leviathanbuild-controller"),
+		CloudEvents:            cloudEvents,
+		History:                runHistory,
+		PullSecret:             pullSecretConfig,
+
+		ProtectedPriorityClassName: protectedPriorityClass,
+		Verify:                     verify,
+		ServiceAccountClient:       controller.NewServiceAccountClientFunc(mgr.GetConfig(), mgr.GetScheme(), mgr.GetRESTMapper()),
+		Pruners:                    pruners,
+		Poller:                     poller,
+		ImagePoller:                imagePoller,
+		Progress:                   progressTracker,
+		Backends:                   backends,
+		Capacity:                   capacity,
+		Signing:                    signingConfig,
+		Clusters:                   clusters,
+		SourceMirror:               sourceMirror,
+		Fetch:                      fetchConfig,
+		FetchSlots:                 fetchSlots,
+		SpecHistoryLimit:           specHistoryLimit,
+	}
+	if err := buildReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "LeviathanBuild")
 		os.Exit(1)
 	}
+	if featuregates.Enabled(featuregates.BuilderImageMappings) && featuregates.Enabled(featuregates.BuilderImageCanaries) {
+		if err := (&controller.BuilderImageRolloutReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "BuilderImageRollout")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.ArtifactRetention) {
+		if err := (&controller.ArtifactRetentionReconciler{
+			Client:  mgr.GetClient(),
+			Scheme:  mgr.GetScheme(),
+			Pruners: pruners,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ArtifactRetention")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.JobEventMirroring) {
+		if err := (&controller.JobEventReconciler{
+			Client:   mgr.GetClient(),
+			Recorder: mgr.GetEventRecorderFor("leviathanbuild-controller"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "JobEvents")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.PullSecretDistribution) && pullSecret != "" {
+		if err := (&controller.PullSecretReconciler{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			APIReader: mgr.GetAPIReader(),
+			Config:    pullSecretConfig,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PullSecret")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.CredentialGrants) {
+		if err := (&controller.CredentialGrantReconciler{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			APIReader: mgr.GetAPIReader(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CredentialGrant")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.BatchOperations) {
+		if err := (&controller.LeviathanBuildBatchOperationReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "LeviathanBuildBatchOperation")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.ClusterBuilds) {
+		if err := (&controller.LeviathanClusterBuildReconciler{
+			Client:         mgr.GetClient(),
+			Scheme:         mgr.GetScheme(),
+			Builds:         buildReconciler,
+			BuildNamespace: clusterBuildNamespace,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "LeviathanClusterBuild")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.RemoteClusters) {
+		if err := (&controller.ClusterTargetReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Clusters: clusters,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ClusterTarget")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.Projects) {
+		if err := (&controller.LeviathanProjectReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "LeviathanProject")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.PackagePromotions) {
+		if err := (&controller.PackagePromotionReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("packagepromotion-controller"),
+			Promoters: map[jcrsv1.ArtifactStore]promotion.Promoter{
+				jcrsv1.S3ArtifactStore: promotion.NewS3Promoter(artifactS3Region,
+					os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), promotionTimeout),
+				jcrsv1.OCIArtifactStore: promotion.NewOCIPromoter(
+					os.Getenv("REGISTRY_USERNAME"), os.Getenv("REGISTRY_PASSWORD"), promotionTimeout),
+			},
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PackagePromotion")
+			os.Exit(1)
+		}
+	}
+	if featuregates.Enabled(featuregates.BuildSummaries) {
+		if err := (&controller.LeviathanBuildSummaryReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "LeviathanBuildSummary")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1.SetupLeviathanBuildWebhookWithManager(mgr, maxBuildsPerNamespace, uniquePackageNames, strictFields); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "LeviathanBuild")
+			os.Exit(1)
+		}
+		if featuregates.Enabled(featuregates.ClusterBuilds) {
+			if err := webhookv1.SetupLeviathanClusterBuildWebhookWithManager(mgr, strictFields); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "LeviathanClusterBuild")
+				os.Exit(1)
+			}
+		}
+		if featuregates.Enabled(featuregates.PackagePromotions) {
+			if err := webhookv1.SetupPackagePromotionWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "PackagePromotion")
+				os.Exit(1)
+			}
+		}
+		if featuregates.Enabled(featuregates.PublishApprovals) {
+			if err := webhookv1.SetupPublishApprovalWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "PublishApproval")
+				os.Exit(1)
+			}
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -227,6 +913,133 @@ func main() {
 		}
 	}
 
+	bundledCRDs, err := crds.Bundled()
+	if err != nil {
+		setupLog.Error(err, "unable to read the bundled CustomResourceDefinitions")
+		os.Exit(1)
+	}
+	if err := mgr.Add(&crds.Monitor{
+		Reader:   mgr.GetAPIReader(),
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("crd-monitor"),
+		CRDs:     bundledCRDs,
+		Interval: crdCheckInterval,
+		Manage:   manageCRDs,
+	}); err != nil {
+		setupLog.Error(err, "unable to add the CustomResourceDefinition monitor to manager")
+		os.Exit(1)
+	}
+
+	if grpcAddr != "" {
+		if grpcCertPath == "" && !grpcInsecure {
+			setupLog.Error(nil, "--grpc-bind-address requires --grpc-cert-path, or --grpc-insecure to serve the API in plaintext")
+			os.Exit(1)
+		}
+		tokens, err := grpcapi.LoadTokens(grpcTokensFile, rate.Limit(grpcRateLimit), grpcRateBurst)
+		if err != nil {
+			setupLog.Error(err, "unable to read the tokens of the gRPC build API", "grpc-tokens-file", grpcTokensFile)
+			os.Exit(1)
+		}
+		clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create the clientset of the gRPC build API")
+			os.Exit(1)
+		}
+		var grpcTLS *tls.Config
+		if grpcCertPath != "" {
+			grpcCertWatcher, err := certwatcher.New(filepath.Join(grpcCertPath, "tls.crt"), filepath.Join(grpcCertPath, "tls.key"))
+			if err != nil {
+				setupLog.Error(err, "Failed to initialize gRPC certificate watcher")
+				os.Exit(1)
+			}
+			if err := mgr.Add(grpcCertWatcher); err != nil {
+				setupLog.Error(err, "unable to add gRPC certificate watcher to manager")
+				os.Exit(1)
+			}
+			// gRPC needs HTTP/2, which --enable-http2 leaves to the metrics and webhook servers
+			grpcTLS = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: grpcCertWatcher.GetCertificate}
+		}
+		if err := mgr.Add(&grpcapi.Server{
+			Client:     mgr.GetClient(),
+			UserClient: controller.NewUserClientFunc(mgr.GetConfig(), mgr.GetScheme(), mgr.GetRESTMapper()),
+			Clientset:  clientset,
+			Scheme:     mgr.GetScheme(),
+			Tokens:     tokens,
+			Addr:       grpcAddr,
+			TLSConfig:  grpcTLS,
+		}); err != nil {
+			setupLog.Error(err, "unable to add the gRPC build API to manager")
+			os.Exit(1)
+		}
+	}
+
+	// The history server and the archive are maintained when there is something to maintain
+	var historyMaintenance *maintenance.Runner
+	_, compacts := runHistory.(history.Compactor)
+	if compacts || (archiveStore != nil && archiveRetention > 0) {
+		maintenanceCron, err := schedule.Parse(maintenanceSchedule)
+		if err != nil {
+			setupLog.Error(err, "invalid history maintenance schedule", "history-maintenance-schedule", maintenanceSchedule)
+			os.Exit(1)
+		}
+		historyMaintenance = &maintenance.Runner{
+			History:          runHistory,
+			HistoryRetention: historyRetention,
+			ArchiveRetention: archiveRetention,
+			Schedule:         maintenanceCron,
+		}
+		if archiveStore != nil {
+			historyMaintenance.Archive = archiveStore
+		}
+		if err := mgr.Add(historyMaintenance); err != nil {
+			setupLog.Error(err, "unable to add the history maintenance to manager")
+			os.Exit(1)
+		}
+	}
+
+	// The gates builds wait on are served behind the authentication of the metrics server
+	if err := mgr.AddMetricsServerExtraHandler("/debug/gates", controller.GatesHandler(mgr.GetClient())); err != nil {
+		setupLog.Error(err, "unable to add the gates handler to the metrics server")
+		os.Exit(1)
+	}
+	if err := mgr.AddMetricsServerExtraHandler(controller.ReconcileStatsPath, reconcileStats); err != nil {
+		setupLog.Error(err, "unable to add the reconcile stats handler to the metrics server")
+		os.Exit(1)
+	}
+	if err := mgr.Add(&runtimeTuner); err != nil {
+		setupLog.Error(err, "unable to add the runtime tuner to manager")
+		os.Exit(1)
+	}
+	if featuregates.Enabled(featuregates.ParallelJobPruner) {
+		if jobPruner.Workers < 1 || jobPruner.PageSize < 1 || jobPruner.Interval <= 0 || jobPrunerQPS < 0 {
+			setupLog.Error(nil, "--job-pruner-workers, --job-pruner-page-size and --job-pruner-interval must be positive, "+
+				"--job-pruner-qps can't be negative")
+			os.Exit(1)
+		}
+		jobPruner.Client = mgr.GetClient()
+		jobPruner.APIReader = mgr.GetAPIReader()
+		if jobPrunerQPS > 0 {
+			jobPruner.Limiter = rate.NewLimiter(rate.Limit(jobPrunerQPS), max(1, int(jobPrunerQPS)))
+		}
+		if err := mgr.Add(&jobPruner); err != nil {
+			setupLog.Error(err, "unable to add the Job pruner to manager")
+			os.Exit(1)
+		}
+	}
+
+	if apiBreaker != nil {
+		if err := mgr.AddMetricsServerExtraHandler("/debug/api-health", apihealth.Handler(apiBreaker)); err != nil {
+			setupLog.Error(err, "unable to add the API health handler to the metrics server")
+			os.Exit(1)
+		}
+	}
+	if historyMaintenance != nil {
+		if err := mgr.AddMetricsServerExtraHandler("/debug/history-maintenance", maintenance.Handler(historyMaintenance)); err != nil {
+			setupLog.Error(err, "unable to add the history maintenance handler to the metrics server")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
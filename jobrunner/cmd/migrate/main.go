@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The migrate command moves the objects of the jobrunner CRDs to the storage
+// version of their CRD, and drops the versions they were stored in before from the
+// status of the CRDs. It runs as a Job, see config/migration, before a release
+// stops serving a version that objects may still be stored in.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"test.jcrs.dev/jobrunner/internal/migration"
+)
+
+func main() {
+	var crds string
+	flag.StringVar(&crds, "crds",
+		"leviathanbuilds.jcrs.jcrs.dev,builderimagemappings.jcrs.jcrs.dev,maintenancewindows.jcrs.jcrs.dev,packageownerships.jcrs.jcrs.dev,leviathanbuildsummaries.jcrs.jcrs.dev,leviathanbuilddefaults.jcrs.jcrs.dev,credentialgrants.jcrs.jcrs.dev,leviathanbuildbatchoperations.jcrs.jcrs.dev,leviathanclusterbuilds.jcrs.jcrs.dev,clustertargets.jcrs.jcrs.dev,buildtypedefinitions.jcrs.jcrs.dev,leviathanprojects.jcrs.jcrs.dev,packagepromotions.jcrs.jcrs.dev,publishapprovals.jcrs.jcrs.dev",
+		"Comma separated list of the CustomResourceDefinitions to migrate.")
+	opts := zap.Options{}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	log := ctrl.Log.WithName("migrate")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		log.Error(err, "Failed to create client")
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, crd := range strings.Split(crds, ",") {
+		if crd = strings.TrimSpace(crd); crd == "" {
+			continue
+		}
+		result, err := migration.StorageVersion(logf.IntoContext(ctx, log), c, crd)
+		if err != nil {
+			log.Error(err, "Failed to migrate", "CustomResourceDefinition", crd)
+			failed = true
+			continue
+		}
+		log.Info("Migrated", "CustomResourceDefinition", crd, "storageVersion", result.StorageVersion,
+			"migrated", result.Migrated, "skipped", result.Skipped)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apihealth keeps the controller working through a flaky API server.
+// Requests failing with a transient error are retried with a backoff, and once
+// too many of them fail, a circuit breaker holds requests back for a while, so
+// that builds wait for the control plane to recover instead of every reconcile
+// failing against it.
+package apihealth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// ConditionDegraded is the condition of the controller that reports a failing API server
+	ConditionDegraded = "APIServerDegraded"
+
+	// ReasonErrorRateExceeded is the reason of APIServerDegraded while requests are held back
+	ReasonErrorRateExceeded = "ErrorRateExceeded"
+
+	// ReasonHealthy is the reason of APIServerDegraded while requests go through
+	ReasonHealthy = "Healthy"
+
+	// window is the number of latest requests the error rate is computed over
+	window = 20
+	// minRequests is the number of requests below which the circuit doesn't open
+	minRequests = 10
+)
+
+// ErrCircuitOpen is returned for requests held back while the API server is degraded.
+var ErrCircuitOpen = errors.New("API server is degraded, request held back")
+
+var (
+	apiErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobrunner_api_errors_total",
+			Help: "Number of requests of the controller to the API server that failed with a transient error, by verb",
+		},
+		[]string{"verb"},
+	)
+	apiCircuitOpen = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "jobrunner_api_circuit_open",
+			Help: "Whether requests of the controller to the API server are held back because too many of them failed",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(apiErrorsTotal, apiCircuitOpen)
+}
+
+// IsTransient reports whether err is a failure of the API server, or of the
+// connection to it, that may go away when retried.
+func IsTransient(err error) bool {
+	return apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err) ||
+		utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// notApplied reports whether err guarantees that the request wasn't applied, so
+// that a write can be retried without being applied twice.
+func notApplied(err error) bool {
+	return apierrors.IsTooManyRequests(err) || apierrors.IsServiceUnavailable(err) || utilnet.IsConnectionRefused(err)
+}
+
+// Breaker opens once the rate of transient errors among the latest requests
+// exceeds a threshold. While open, requests are held back; after the cooldown a
+// single request goes through, and closes the breaker if it succeeds.
+type Breaker struct {
+	threshold float64
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	outcomes  []bool
+	next      int
+	failures  int
+	openUntil time.Time
+	probing   bool
+	condition metav1.Condition
+}
+
+// NewBreaker returns a Breaker opening for cooldown when more than threshold, a
+// fraction between 0 and 1, of the latest requests failed.
+func NewBreaker(threshold float64, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow returns ErrCircuitOpen when the request should be held back.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// Record records the outcome of an allowed request.
+func (b *Breaker) Record(err error) {
+	failed := IsTransient(err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.openUntil.IsZero() {
+		// Requests sent before the breaker opened don't close it
+		if !b.probing {
+			return
+		}
+		b.probing = false
+		if failed {
+			b.openUntil = b.now().Add(b.cooldown)
+			return
+		}
+		b.outcomes, b.next, b.failures = nil, 0, 0
+		b.openUntil = time.Time{}
+		b.setCondition(metav1.ConditionFalse, ReasonHealthy, "Requests to the API server succeed")
+		return
+	}
+
+	if len(b.outcomes) < window {
+		b.outcomes = append(b.outcomes, failed)
+	} else {
+		if b.outcomes[b.next] {
+			b.failures--
+		}
+		b.outcomes[b.next] = failed
+		b.next = (b.next + 1) % window
+	}
+	if failed {
+		b.failures++
+	}
+	rate := float64(b.failures) / float64(len(b.outcomes))
+	if len(b.outcomes) >= minRequests && rate > b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+		b.setCondition(metav1.ConditionTrue, ReasonErrorRateExceeded,
+			fmt.Sprintf("%d of the latest %d requests to the API server failed, requests are held back for %s", b.failures, len(b.outcomes), b.cooldown))
+	}
+}
+
+// setCondition sets the APIServerDegraded condition and the metric following it.
+func (b *Breaker) setCondition(status metav1.ConditionStatus, reason, message string) {
+	conditions := []metav1.Condition{b.condition}
+	if b.condition.Type == "" {
+		conditions = nil
+	}
+	meta.SetStatusCondition(&conditions, metav1.Condition{Type: ConditionDegraded, Status: status, Reason: reason, Message: message})
+	b.condition = conditions[0]
+	if status == metav1.ConditionTrue {
+		apiCircuitOpen.Set(1)
+	} else {
+		apiCircuitOpen.Set(0)
+	}
+}
+
+// Condition returns the APIServerDegraded condition of the controller.
+func (b *Breaker) Condition() metav1.Condition {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.condition.Type == "" {
+		return metav1.Condition{Type: ConditionDegraded, Status: metav1.ConditionFalse, Reason: ReasonHealthy}
+	}
+	return b.condition
+}
+
+// Handler serves the APIServerDegraded condition of breaker as JSON.
+func Handler(breaker *Breaker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(breaker.Condition())
+	})
+}
+
+// Client retries the Get, List and Create requests of a client.Client failing
+// with a transient error, and holds them back while its Breaker is open. Creates
+// are only retried when the error guarantees the object wasn't created.
+type Client struct {
+	client.Client
+
+	breaker *Breaker
+	retries int
+	delay   time.Duration
+}
+
+// NewClient returns a Client retrying the requests of c up to retries times,
+// waiting delay before the first retry and twice as long before each next one.
+func NewClient(c client.Client, breaker *Breaker, retries int, delay time.Duration) *Client {
+	return &Client{Client: c, breaker: breaker, retries: retries, delay: delay}
+}
+
+// Get implements client.Client.
+func (c *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return c.do(ctx, "get", IsTransient, func() error { return c.Client.Get(ctx, key, obj, opts...) })
+}
+
+// List implements client.Client.
+func (c *Client) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.do(ctx, "list", IsTransient, func() error { return c.Client.List(ctx, list, opts...) })
+}
+
+// Create implements client.Client.
+func (c *Client) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return c.do(ctx, "create", notApplied, func() error { return c.Client.Create(ctx, obj, opts...) })
+}
+
+// do sends a request with call, retrying it while it fails with an error retriable accepts.
+func (c *Client) do(ctx context.Context, verb string, retriable func(error) bool, call func() error) error {
+	delay := c.delay
+	var err error
+	for attempt := 0; ; attempt++ {
+		// A retry held back reports the error of the last attempt
+		if open := c.breaker.Allow(); open != nil {
+			if err != nil {
+				return err
+			}
+			return open
+		}
+		err = call()
+		c.breaker.Record(err)
+		if IsTransient(err) {
+			apiErrorsTotal.WithLabelValues(verb).Inc()
+		}
+		if err == nil || attempt >= c.retries || !retriable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
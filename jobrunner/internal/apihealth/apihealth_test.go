@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apihealth
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("API health", func() {
+	var (
+		ctx     context.Context
+		now     time.Time
+		breaker *Breaker
+		calls   int
+		failing int
+		failure error
+		c       *Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+		breaker = NewBreaker(0.5, time.Minute)
+		breaker.now = func() time.Time { return now }
+		calls, failing = 0, 0
+		failure = apierrors.NewServiceUnavailable("etcd is unavailable")
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		fail := func() error {
+			calls++
+			if failing > 0 {
+				failing--
+				return failure
+			}
+			return nil
+		}
+		inner := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "settings"}},
+		).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if err := fail(); err != nil {
+					return err
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if err := fail(); err != nil {
+					return err
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+		c = NewClient(inner, breaker, 3, time.Millisecond)
+	})
+
+	get := func() error {
+		return c.Get(ctx, client.ObjectKey{Namespace: "ci", Name: "settings"}, &corev1.ConfigMap{})
+	}
+
+	It("retries requests failing with a transient error", func() {
+		failing = 2
+		Expect(get()).To(Succeed())
+		Expect(calls).To(Equal(3))
+
+		By("not retrying other errors")
+		calls = 0
+		err := c.Get(ctx, client.ObjectKey{Namespace: "ci", Name: "missing"}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("only retries creates that weren't applied", func() {
+		failing, failure = 1, apierrors.NewTimeoutError("request timed out", 1)
+		Expect(apierrors.IsTimeout(c.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "a"}}))).To(BeTrue())
+		Expect(calls).To(Equal(1))
+
+		calls, failing, failure = 0, 1, apierrors.NewTooManyRequests("slow down", 1)
+		Expect(c.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "b"}})).To(Succeed())
+		Expect(calls).To(Equal(2))
+	})
+
+	It("holds requests back while too many of them fail", func() {
+		for range 3 {
+			Expect(get()).To(Succeed())
+		}
+		failing = 100
+		Expect(apierrors.IsServiceUnavailable(get())).To(BeTrue())
+		Expect(apierrors.IsServiceUnavailable(get())).To(BeTrue())
+		Expect(get()).To(MatchError(ErrCircuitOpen))
+		condition := breaker.Condition()
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(ReasonErrorRateExceeded))
+
+		By("letting a single request through after the cooldown")
+		calls = 0
+		now = now.Add(time.Minute)
+		Expect(apierrors.IsServiceUnavailable(get())).To(BeTrue())
+		Expect(calls).To(Equal(1))
+		Expect(get()).To(MatchError(ErrCircuitOpen))
+
+		By("closing once the API server recovers")
+		failing = 0
+		now = now.Add(time.Minute)
+		Expect(get()).To(Succeed())
+		Expect(get()).To(Succeed())
+		Expect(breaker.Condition().Status).To(Equal(metav1.ConditionFalse))
+	})
+})
@@ -0,0 +1,275 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package archive stores records of LeviathanBuilds in object storage, so their
+// history outlives the objects in the cluster.
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Store writes objects to object storage.
+type Store interface {
+	// Put writes data under key and returns the URL of the object.
+	Put(ctx context.Context, key string, data []byte) (string, error)
+}
+
+// ErrNotFound is returned for objects missing from the bucket.
+var ErrNotFound = errors.New("object not found")
+
+// Object describes an object of a bucket.
+type Object struct {
+	Key          string
+	LastModified time.Time
+	Size         int64
+}
+
+// S3Store writes objects to a bucket through the S3 API, using path-style URLs
+// (<endpoint>/<bucket>/<key>) and AWS Signature Version 4. Besides Amazon S3, it
+// works with S3 compatible storage, like Google Cloud Storage with HMAC keys or MinIO.
+type S3Store struct {
+	Client *http.Client
+
+	// Endpoint is the URL of the storage service, e.g. https://s3.us-east-1.amazonaws.com
+	Endpoint string
+	Bucket   string
+	// Region the requests are signed for. Google Cloud Storage accepts "auto".
+	Region string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// now returns the time requests are signed at, for tests
+	now func() time.Time
+}
+
+// NewS3Store returns an S3Store using a client with the given timeout.
+func NewS3Store(endpoint, bucket, region, accessKeyID, secretAccessKey string, timeout time.Duration) *S3Store {
+	return &S3Store{
+		Client:          &http.Client{Timeout: timeout},
+		Endpoint:        strings.TrimSuffix(endpoint, "/"),
+		Bucket:          bucket,
+		Region:          region,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+	}
+}
+
+// Put implements Store.
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) (string, error) {
+	return s.PutObject(ctx, key, http.Header{"Content-Type": []string{"application/json"}}, data)
+}
+
+// PutObject writes data under key with the given headers, such as its
+// Content-Type or X-Amz-Meta-* metadata, and returns the URL of the object.
+func (s *S3Store) PutObject(ctx context.Context, key string, header http.Header, data []byte) (string, error) {
+	target, _, err := s.do(ctx, http.MethodPut, key, header, data, nil)
+	return target, err
+}
+
+// Get writes the content of the object at key to w and returns its headers. It
+// returns ErrNotFound when the object doesn't exist.
+func (s *S3Store) Get(ctx context.Context, key string, w io.Writer) (http.Header, error) {
+	_, header, err := s.do(ctx, http.MethodGet, key, nil, nil, w)
+	return header, err
+}
+
+// Delete deletes the object at key. Deleting a missing object succeeds.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, _, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	return err
+}
+
+// Head returns the headers of the object at key, such as its ETag, without
+// downloading it.
+func (s *S3Store) Head(ctx context.Context, key string) (http.Header, error) {
+	_, header, err := s.do(ctx, http.MethodHead, key, nil, nil, nil)
+	return header, err
+}
+
+// listBucketResult is the response of ListObjectsV2.
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the objects of the bucket whose key starts with prefix, in key
+// order, following the pages of ListObjectsV2.
+func (s *S3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		// The query is signed as is, so its parameters are sorted and encoded for the signature
+		query := "list-type=2&prefix=" + uriEncode(prefix, false)
+		if token != "" {
+			query = "continuation-token=" + uriEncode(token, false) + "&" + query
+		}
+		var body bytes.Buffer
+		target := s.Endpoint + "/" + uriEncode(s.Bucket, false) + "?" + query
+		if _, err := s.send(ctx, http.MethodGet, target, nil, nil, &body); err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		if err := xml.Unmarshal(body.Bytes(), &result); err != nil {
+			return nil, fmt.Errorf("invalid response listing %s: %w", s.Bucket, err)
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, Object{Key: content.Key, LastModified: content.LastModified, Size: content.Size})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// do sends a signed request for the object at key and returns its URL and the
+// headers of the response. The body of the response is copied to w, if set.
+func (s *S3Store) do(ctx context.Context, method, key string, header http.Header, data []byte, w io.Writer) (string, http.Header, error) {
+	target := s.Endpoint + "/" + uriEncode(s.Bucket, false) + "/" + uriEncode(key, true)
+	respHeader, err := s.send(ctx, method, target, header, data, w)
+	if err != nil {
+		return "", nil, err
+	}
+	return target, respHeader, nil
+}
+
+// send sends a signed request to target and returns the headers of the response.
+// The body of the response is copied to w, if set.
+func (s *S3Store) send(ctx context.Context, method, target string, header http.Header, data []byte, w io.Writer) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.sign(req, data)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound && method != http.MethodDelete {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotFound, method, target)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %q for %s %s: %s", resp.Status, method, target, strings.TrimSpace(string(body)))
+	}
+	if w != nil {
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			return nil, err
+		}
+	}
+	return resp.Header, nil
+}
+
+// sign adds the AWS Signature Version 4 of req, covering all of its headers, to
+// req. See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *S3Store) sign(req *http.Request, payload []byte) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payloadHash := sha256.Sum256(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.SecretAccessKey)
+	for _, part := range []string{date, s.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode percent-encodes every byte of value but the unreserved characters, as
+// required by the signature. Slashes are kept when keepSlash is set.
+func uriEncode(value string, keepSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/' && keepSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup exports the build definitions of a cluster into a portable
+// bundle, and imports them back, for disaster recovery and cluster migrations.
+//
+// A bundle holds the manifests of the objects defining builds, without what the
+// API server set on them, and the names of the ConfigMaps and Secrets they
+// reference. Their values aren't exported: the ConfigMaps and Secrets are
+// restored by other means, and missing ones are reported on import.
+package backup
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// BundleKind is the kind of bundles, telling them apart from other documents
+const BundleKind = "LeviathanBackup"
+
+// Bundle is the portable export of the build definitions of a cluster.
+type Bundle struct {
+	// Kind is BundleKind
+	Kind string `json:"kind"`
+	// ExportedAt is when the bundle was exported
+	ExportedAt metav1.Time `json:"exportedAt"`
+	// Namespace is the namespace the bundle was exported from, empty for the
+	// whole cluster
+	Namespace string `json:"namespace,omitempty"`
+	// Objects are the manifests of the exported objects, in the order they're
+	// imported: cluster scoped definitions before the builds using them.
+	Objects []unstructured.Unstructured `json:"objects"`
+	// References are the ConfigMaps and Secrets the objects reference
+	References []Reference `json:"references,omitempty"`
+}
+
+// exportedLists are the kinds of objects defining builds, in the order they're
+// imported. Batch operations, summaries, promotions and approvals record what
+// happened in the cluster rather than define builds, they aren't exported.
+var exportedLists = []func() client.ObjectList{
+	func() client.ObjectList { return &jcrsv1.BuildTypeDefinitionList{} },
+	func() client.ObjectList { return &jcrsv1.BuilderImageMappingList{} },
+	func() client.ObjectList { return &jcrsv1.ClusterTargetList{} },
+	func() client.ObjectList { return &jcrsv1.CredentialGrantList{} },
+	func() client.ObjectList { return &jcrsv1.MaintenanceWindowList{} },
+	func() client.ObjectList { return &jcrsv1.PackageOwnershipList{} },
+	func() client.ObjectList { return &jcrsv1.LeviathanClusterBuildList{} },
+	func() client.ObjectList { return &jcrsv1.LeviathanBuildDefaultsList{} },
+	func() client.ObjectList { return &jcrsv1.LeviathanProjectList{} },
+	func() client.ObjectList { return &jcrsv1.LeviathanBuildList{} },
+}
+
+// Export returns the bundle of the build definitions of namespace, or of the
+// whole cluster when namespace is empty. Cluster scoped definitions are only
+// exported with the whole cluster. Objects controlled by another object, such as
+// the builds of a LeviathanProject, are left to be created again by it.
+func Export(ctx context.Context, c client.Client, namespace string) (*Bundle, error) {
+	bundle := &Bundle{Kind: BundleKind, ExportedAt: metav1.Now(), Namespace: namespace, Objects: []unstructured.Unstructured{}}
+	references := map[string]*Reference{}
+	for _, newList := range exportedLists {
+		list := newList()
+		if namespace != "" {
+			namespaced, err := apiutil.IsObjectNamespaced(list, c.Scheme(), c.RESTMapper())
+			if err != nil {
+				return nil, err
+			}
+			if !namespaced {
+				continue
+			}
+		}
+		if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		slices.SortFunc(items, func(a, b runtime.Object) int {
+			return strings.Compare(objectKey(a.(client.Object)), objectKey(b.(client.Object)))
+		})
+		for _, item := range items {
+			obj := item.(client.Object)
+			if metav1.GetControllerOf(obj) != nil {
+				continue
+			}
+			exported, err := exportedObject(obj, c.Scheme())
+			if err != nil {
+				return nil, err
+			}
+			bundle.Objects = append(bundle.Objects, *exported)
+			for _, ref := range referencesOf(obj) {
+				key := ref.key()
+				if references[key] == nil {
+					references[key] = &ref
+				}
+				references[key].ReferencedBy = append(references[key].ReferencedBy, exported.GetKind()+" "+objectKey(obj))
+			}
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(references)) {
+		bundle.References = append(bundle.References, *references[key])
+	}
+	return bundle, nil
+}
+
+// exportedObject returns the manifest of obj without its status and what the API
+// server set on it, so that it can be created in any cluster.
+func exportedObject(obj client.Object, scheme *runtime.Scheme) (*unstructured.Unstructured, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return nil, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	exported := &unstructured.Unstructured{Object: content}
+	exported.SetGroupVersionKind(gvk)
+	exported.SetUID("")
+	exported.SetResourceVersion("")
+	exported.SetGeneration(0)
+	exported.SetCreationTimestamp(metav1.Time{})
+	exported.SetManagedFields(nil)
+	exported.SetOwnerReferences(nil)
+	exported.SetFinalizers(nil)
+	unstructured.RemoveNestedField(exported.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(exported.Object, "status")
+	return exported, nil
+}
+
+// objectKey returns the namespace/name of obj, or its name when cluster scoped.
+func objectKey(obj client.Object) string {
+	return client.ObjectKeyFromObject(obj).String()
+}
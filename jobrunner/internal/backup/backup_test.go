@@ -0,0 +1,184 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Backup", func() {
+	var (
+		ctx    context.Context
+		scheme *runtime.Scheme
+		mapper meta.RESTMapper
+	)
+
+	clusterScoped := map[string]bool{
+		"BuildTypeDefinition": true, "BuilderImageMapping": true, "ClusterTarget": true, "CredentialGrant": true,
+		"MaintenanceWindow": true, "PackageOwnership": true, "LeviathanClusterBuild": true,
+	}
+
+	newClient := func(objs ...client.Object) client.Client {
+		return fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(objs...).Build()
+	}
+
+	newBuild := func(namespace, name string) *jcrsv1.LeviathanBuild {
+		return &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{"team": "web"}},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName:    ptr.To(name),
+				ParametersFrom: []jcrsv1.ParametersSource{{ConfigMapRef: &corev1.LocalObjectReference{Name: "params"}}},
+				JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:    "build",
+						EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "token"}}}},
+					}},
+				}}}},
+			},
+			Status: jcrsv1.LeviathanBuildStatus{RunIndex: 3},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+		defaultMapper := meta.NewDefaultRESTMapper(nil)
+		for gvk := range scheme.AllKnownTypes() {
+			scope := meta.RESTScopeNamespace
+			if clusterScoped[gvk.Kind] {
+				scope = meta.RESTScopeRoot
+			}
+			defaultMapper.Add(gvk, scope)
+		}
+		mapper = defaultMapper
+	})
+
+	It("exports the build definitions without what the API server set on them", func() {
+		project := &jcrsv1.LeviathanProject{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "shop", UID: "shop-uid"}}
+		owned := newBuild("ci", "shop-api")
+		owned.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: jcrsv1.GroupVersion.String(), Kind: "LeviathanProject", Name: "shop", UID: "shop-uid", Controller: ptr.To(true),
+		}}
+		c := newClient(
+			newBuild("ci", "web"), newBuild("tools", "lint"), owned, project,
+			&jcrsv1.BuildTypeDefinition{ObjectMeta: metav1.ObjectMeta{Name: "go"}},
+			&jcrsv1.LeviathanBuildBatchOperation{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "suspend-all"}},
+		)
+
+		bundle, err := Export(ctx, c, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.Kind).To(Equal(BundleKind))
+		var exported []string
+		for _, obj := range bundle.Objects {
+			exported = append(exported, obj.GetKind()+" "+obj.GetNamespace()+"/"+obj.GetName())
+			Expect(obj.GetResourceVersion()).To(BeEmpty())
+			Expect(obj.Object).NotTo(HaveKey("status"))
+		}
+		Expect(exported).To(Equal([]string{
+			"BuildTypeDefinition /go", "LeviathanProject ci/shop", "LeviathanBuild ci/web", "LeviathanBuild tools/lint",
+		}))
+		Expect(bundle.Objects[2].GetLabels()).To(Equal(map[string]string{"team": "web"}))
+		Expect(bundle.References).To(Equal([]Reference{
+			{Kind: "ConfigMap", Namespace: "ci", Name: "params", ReferencedBy: []string{"LeviathanBuild ci/web"}},
+			{Kind: "ConfigMap", Namespace: "tools", Name: "params", ReferencedBy: []string{"LeviathanBuild tools/lint"}},
+			{Kind: "Secret", Namespace: "ci", Name: "token", ReferencedBy: []string{"LeviathanBuild ci/web"}},
+			{Kind: "Secret", Namespace: "tools", Name: "token", ReferencedBy: []string{"LeviathanBuild tools/lint"}},
+		}))
+
+		By("only exporting the namespaced definitions of a namespace")
+		bundle, err = Export(ctx, c, "tools")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.Objects).To(HaveLen(1))
+		Expect(bundle.Objects[0].GetName()).To(Equal("lint"))
+	})
+
+	It("imports a bundle, resolving conflicts with the strategy", func() {
+		bundle, err := Export(ctx, newClient(newBuild("ci", "web"), newBuild("ci", "api")), "")
+		Expect(err).NotTo(HaveOccurred())
+		// The bundle survives being written to a file
+		data, err := yaml.Marshal(bundle)
+		Expect(err).NotTo(HaveOccurred())
+		bundle = &Bundle{}
+		Expect(yaml.UnmarshalStrict(data, bundle)).To(Succeed())
+
+		existing := newBuild("ci", "web")
+		existing.Spec.PackageName = ptr.To("website")
+		c := newClient(existing)
+		packageName := func(name string) string {
+			lvBuild := &jcrsv1.LeviathanBuild{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "ci", Name: name}, lvBuild)).To(Succeed())
+			return ptr.Deref(lvBuild.Spec.PackageName, "")
+		}
+
+		results, err := Import(ctx, c, bundle, SkipConflicts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(Equal([]Result{
+			{Kind: "LeviathanBuild", Namespace: "ci", Name: "api", Action: Created},
+			{Kind: "LeviathanBuild", Namespace: "ci", Name: "web", Action: Skipped},
+		}))
+		Expect(packageName("api")).To(Equal("api"))
+		Expect(packageName("web")).To(Equal("website"))
+
+		By("renaming the objects that exist")
+		results, err = Import(ctx, c, bundle, RenameConflicts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(results[1].Action).To(Equal(Renamed))
+		Expect(results[1].NewName).To(HavePrefix("web-"))
+		Expect(packageName(results[1].NewName)).To(Equal("web"))
+		Expect(packageName("web")).To(Equal("website"))
+
+		By("overwriting the objects that exist")
+		results, err = Import(ctx, c, bundle, OverwriteConflicts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[1]).To(Equal(Result{Kind: "LeviathanBuild", Namespace: "ci", Name: "web", Action: Overwritten}))
+		Expect(packageName("web")).To(Equal("web"))
+
+		By("refusing unknown strategies and documents")
+		Expect(Import(ctx, c, bundle, "merge")).Error().To(MatchError(ContainSubstring("unknown conflict strategy")))
+		Expect(Import(ctx, c, &Bundle{Kind: "List"}, SkipConflicts)).Error().To(MatchError(ContainSubstring("not a LeviathanBackup bundle")))
+	})
+
+	It("reports the referenced ConfigMaps and Secrets that don't exist", func() {
+		bundle, err := Export(ctx, newClient(newBuild("ci", "web")), "")
+		Expect(err).NotTo(HaveOccurred())
+
+		c := newClient(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "params"}})
+		missing, err := MissingReferences(ctx, c, bundle)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(missing).To(Equal([]Reference{
+			{Kind: "Secret", Namespace: "ci", Name: "token", ReferencedBy: []string{"LeviathanBuild ci/web"}},
+		}))
+	})
+})
@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConflictStrategy is what Import does with an object of the bundle that already
+// exists in the cluster.
+type ConflictStrategy string
+
+const (
+	// SkipConflicts leaves the object of the cluster as is
+	SkipConflicts ConflictStrategy = "skip"
+	// OverwriteConflicts replaces the object of the cluster by the one of the bundle
+	OverwriteConflicts ConflictStrategy = "overwrite"
+	// RenameConflicts creates the object of the bundle under a generated name
+	RenameConflicts ConflictStrategy = "rename"
+)
+
+// ConflictStrategies are the supported conflict strategies
+var ConflictStrategies = []ConflictStrategy{SkipConflicts, OverwriteConflicts, RenameConflicts}
+
+// Action is what Import did with an object of the bundle.
+type Action string
+
+const (
+	// Created is the action of objects that didn't exist in the cluster
+	Created Action = "Created"
+	// Skipped is the action of existing objects with SkipConflicts
+	Skipped Action = "Skipped"
+	// Overwritten is the action of existing objects with OverwriteConflicts
+	Overwritten Action = "Overwritten"
+	// Renamed is the action of existing objects with RenameConflicts
+	Renamed Action = "Renamed"
+)
+
+// Result is the outcome of the import of an object of the bundle.
+type Result struct {
+	// Kind, Namespace and Name identify the object in the bundle
+	Kind      string
+	Namespace string
+	Name      string
+	// Action is what was done with the object, when Err is nil
+	Action Action
+	// NewName is the name the object was created under when Renamed
+	NewName string
+	// Err is why the object couldn't be imported
+	Err error
+}
+
+// Import creates the objects of bundle in the order of the bundle, resolving
+// conflicts with the objects of the cluster with strategy, and returns the
+// outcome for each of them. An object that can't be imported doesn't stop the
+// import of the others.
+//
+// Renamed objects are created under a name generated from their own; the
+// objects of the bundle referring to them by name are left as they are.
+func Import(ctx context.Context, c client.Client, bundle *Bundle, strategy ConflictStrategy) ([]Result, error) {
+	if bundle.Kind != BundleKind {
+		return nil, fmt.Errorf("not a %s bundle: kind is %q", BundleKind, bundle.Kind)
+	}
+	if !slices.Contains(ConflictStrategies, strategy) {
+		return nil, fmt.Errorf("unknown conflict strategy %q", strategy)
+	}
+
+	results := make([]Result, 0, len(bundle.Objects))
+	for i := range bundle.Objects {
+		obj := bundle.Objects[i].DeepCopy()
+		result := Result{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName(), Action: Created}
+		err := c.Create(ctx, obj.DeepCopy())
+		if apierrors.IsAlreadyExists(err) {
+			result.Action, result.NewName, err = resolveConflict(ctx, c, obj, strategy)
+		}
+		result.Err = err
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// resolveConflict imports obj, which already exists in the cluster, with strategy.
+func resolveConflict(ctx context.Context, c client.Client, obj *unstructured.Unstructured, strategy ConflictStrategy) (Action, string, error) {
+	switch strategy {
+	case OverwriteConflicts:
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+			return "", "", err
+		}
+		obj.SetResourceVersion(existing.GetResourceVersion())
+		if err := c.Update(ctx, obj); err != nil {
+			return "", "", err
+		}
+		return Overwritten, "", nil
+
+	case RenameConflicts:
+		obj.SetGenerateName(obj.GetName() + "-")
+		obj.SetName("")
+		if err := c.Create(ctx, obj); err != nil {
+			return "", "", err
+		}
+		return Renamed, obj.GetName(), nil
+	}
+	return Skipped, "", nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// Reference is a ConfigMap or a Secret referenced by the objects of a bundle.
+type Reference struct {
+	// Kind is ConfigMap or Secret
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the object. It is empty for the objects
+	// referenced by LeviathanClusterBuilds, which are looked up in the namespaces
+	// their builds run in.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object
+	Name string `json:"name"`
+	// ReferencedBy are the objects of the bundle referencing it, as "<kind> <key>"
+	ReferencedBy []string `json:"referencedBy,omitempty"`
+}
+
+// referencesOf returns the ConfigMaps and Secrets obj references.
+func referencesOf(obj client.Object) []Reference {
+	var refs []Reference
+	add := func(kind, namespace, name string) {
+		if name != "" {
+			refs = append(refs, Reference{Kind: kind, Namespace: namespace, Name: name})
+		}
+	}
+	switch obj := obj.(type) {
+	case *jcrsv1.LeviathanBuild:
+		refs = buildReferences(&obj.Spec, obj.Namespace)
+	case *jcrsv1.LeviathanClusterBuild:
+		refs = buildReferences(&obj.Spec, "")
+	case *jcrsv1.CredentialGrant:
+		add("Secret", obj.Spec.SecretRef.Namespace, obj.Spec.SecretRef.Name)
+	case *jcrsv1.ClusterTarget:
+		add("Secret", obj.Spec.KubeconfigSecretRef.Namespace, obj.Spec.KubeconfigSecretRef.Name)
+	}
+	slices.SortFunc(refs, func(a, b Reference) int { return strings.Compare(a.key(), b.key()) })
+	return slices.CompactFunc(refs, func(a, b Reference) bool { return a.key() == b.key() })
+}
+
+// key identifies the object ref references.
+func (ref Reference) key() string {
+	return ref.Kind + " " + ref.Namespace + "/" + ref.Name
+}
+
+// buildReferences returns the ConfigMaps and Secrets of namespace spec references.
+func buildReferences(spec *jcrsv1.LeviathanBuildSpec, namespace string) []Reference {
+	var refs []Reference
+	add := func(kind string, ref *corev1.LocalObjectReference) {
+		if ref != nil && ref.Name != "" {
+			refs = append(refs, Reference{Kind: kind, Namespace: namespace, Name: ref.Name})
+		}
+	}
+	if spec.Source != nil && spec.Source.HTTP != nil {
+		add("Secret", spec.Source.HTTP.SecretRef)
+	}
+	for _, source := range spec.ParametersFrom {
+		add("ConfigMap", source.ConfigMapRef)
+		add("Secret", source.SecretRef)
+	}
+	if spec.Credentials != nil {
+		add("Secret", spec.Credentials.Source)
+		add("Secret", spec.Credentials.Registry)
+	}
+	if spec.Signing != nil {
+		add("Secret", spec.Signing.KeyRef)
+		add("Secret", spec.Signing.CredentialsSecretRef)
+	}
+	if spec.Network != nil && spec.Network.TrustBundle != nil {
+		add("ConfigMap", &corev1.LocalObjectReference{Name: spec.Network.TrustBundle.Name})
+	}
+
+	podSpec := &spec.JobTemplate.Spec.Template.Spec
+	for i := range podSpec.ImagePullSecrets {
+		add("Secret", &podSpec.ImagePullSecrets[i])
+	}
+	for _, volumes := range [][]corev1.Volume{podSpec.Volumes, spec.Volumes} {
+		for _, volume := range volumes {
+			if volume.ConfigMap != nil {
+				add("ConfigMap", &volume.ConfigMap.LocalObjectReference)
+			}
+			if volume.Secret != nil {
+				add("Secret", &corev1.LocalObjectReference{Name: volume.Secret.SecretName})
+			}
+			if volume.Projected == nil {
+				continue
+			}
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					add("ConfigMap", &source.ConfigMap.LocalObjectReference)
+				}
+				if source.Secret != nil {
+					add("Secret", &source.Secret.LocalObjectReference)
+				}
+			}
+		}
+	}
+	containers := slices.Concat(podSpec.InitContainers, podSpec.Containers, spec.Sidecars)
+	for _, container := range containers {
+		for _, env := range container.EnvFrom {
+			if env.ConfigMapRef != nil {
+				add("ConfigMap", &env.ConfigMapRef.LocalObjectReference)
+			}
+			if env.SecretRef != nil {
+				add("Secret", &env.SecretRef.LocalObjectReference)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				add("ConfigMap", &env.ValueFrom.ConfigMapKeyRef.LocalObjectReference)
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				add("Secret", &env.ValueFrom.SecretKeyRef.LocalObjectReference)
+			}
+		}
+	}
+	return refs
+}
+
+// MissingReferences returns the references of bundle that don't exist in the
+// cluster. References without a namespace can't be checked, and aren't returned.
+// Only the metadata of the ConfigMaps and Secrets is read.
+func MissingReferences(ctx context.Context, c client.Reader, bundle *Bundle) ([]Reference, error) {
+	var missing []Reference
+	for _, ref := range bundle.References {
+		if ref.Namespace == "" {
+			continue
+		}
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(ref.Kind))
+		err := c.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, obj)
+		if apierrors.IsNotFound(err) {
+			missing = append(missing, ref)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package batch triggers, suspends, resumes or cancels the LeviathanBuilds of a
+// namespace selected by a label selector. It is used by the leviathan command and
+// by the controller of LeviathanBuildBatchOperations.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/rerun"
+)
+
+// OperationAnnotation records the name of the LeviathanBuildBatchOperation that created a build
+const OperationAnnotation = "jcrs.jcrs.dev/batch-operation"
+
+// Options configure a batch operation.
+type Options struct {
+	// Name is the name of the LeviathanBuildBatchOperation applied, if any. The
+	// builds created by a Trigger are then named <build>-<name> and annotated with
+	// it, so applying the operation again neither creates them twice nor selects
+	// them. They're given a generated name when empty.
+	Name string
+	// Parameters override the values of parameters of the builds created by a
+	// Trigger, by name.
+	Parameters map[string]string
+}
+
+// Run applies operation to the builds of namespace selected by selector, and
+// returns its outcome for each of them, by name. The builds are selected once,
+// before any of them is operated on; an error is only returned when they can't
+// be listed.
+func Run(ctx context.Context, c client.Client, namespace string, selector labels.Selector, operation jcrsv1.BatchOperationType, opts Options) ([]jcrsv1.BatchOperationResult, error) {
+	var builds jcrsv1.LeviathanBuildList
+	if err := c.List(ctx, &builds, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(builds.Items, func(a, b jcrsv1.LeviathanBuild) int { return strings.Compare(a.Name, b.Name) })
+
+	results := make([]jcrsv1.BatchOperationResult, 0, len(builds.Items))
+	for i := range builds.Items {
+		lvBuild := &builds.Items[i]
+		if opts.Name != "" && lvBuild.Annotations[OperationAnnotation] == opts.Name {
+			continue
+		}
+		result := jcrsv1.BatchOperationResult{Name: lvBuild.Name, Succeeded: true}
+		message, err := apply(ctx, c, lvBuild, operation, opts)
+		if err != nil {
+			result.Succeeded = false
+			message = err.Error()
+		}
+		result.Message = message
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// apply applies operation to lvBuild, and describes what was done.
+func apply(ctx context.Context, c client.Client, lvBuild *jcrsv1.LeviathanBuild, operation jcrsv1.BatchOperationType, opts Options) (string, error) {
+	switch operation {
+	case jcrsv1.TriggerOperation:
+		name := ""
+		if opts.Name != "" {
+			name = lvBuild.Name + "-" + opts.Name
+		}
+		newBuild, _, err := rerun.New(lvBuild, rerun.Options{Name: name, Parameters: opts.Parameters})
+		if err != nil {
+			return "", err
+		}
+		if opts.Name != "" {
+			newBuild.Annotations[OperationAnnotation] = opts.Name
+		}
+		if err := c.Create(ctx, newBuild); err != nil && (name == "" || !apierrors.IsAlreadyExists(err)) {
+			return "", err
+		}
+		return "Created " + newBuild.Name, nil
+
+	case jcrsv1.SuspendOperation, jcrsv1.ResumeOperation:
+		suspend, done := operation == jcrsv1.SuspendOperation, "Suspended"
+		if !suspend {
+			done = "Resumed"
+		}
+		if lvBuild.Spec.Suspend == suspend {
+			return "Already " + strings.ToLower(done), nil
+		}
+		patch := client.MergeFrom(lvBuild.DeepCopy())
+		lvBuild.Spec.Suspend = suspend
+		if err := c.Patch(ctx, lvBuild, patch); err != nil {
+			return "", err
+		}
+		return done, nil
+
+	case jcrsv1.CancelOperation:
+		if err := c.Delete(ctx, lvBuild, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return "", err
+		}
+		return "Deleted", nil
+	}
+	return "", fmt.Errorf("unknown operation %q", operation)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batch
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/rerun"
+)
+
+var _ = Describe("Batch operations", func() {
+	var (
+		ctx      context.Context
+		c        client.Client
+		payments labels.Selector
+	)
+
+	newBuild := func(name, team string, suspend bool) *jcrsv1.LeviathanBuild {
+		return &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: name, Labels: map[string]string{"team": team}},
+			Spec:       jcrsv1.LeviathanBuildSpec{Suspend: suspend},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			newBuild("ledger", "payments", false),
+			newBuild("api", "payments", true),
+			newBuild("web", "frontend", false),
+		).Build()
+		payments = labels.SelectorFromSet(labels.Set{"team": "payments"})
+	})
+
+	list := func() []jcrsv1.LeviathanBuild {
+		var builds jcrsv1.LeviathanBuildList
+		Expect(c.List(ctx, &builds, client.InNamespace("ci"))).To(Succeed())
+		return builds.Items
+	}
+
+	It("suspends and resumes the selected builds", func() {
+		results, err := Run(ctx, c, "ci", payments, jcrsv1.SuspendOperation, Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(Equal([]jcrsv1.BatchOperationResult{
+			{Name: "api", Succeeded: true, Message: "Already suspended"},
+			{Name: "ledger", Succeeded: true, Message: "Suspended"},
+		}))
+		Expect(list()).To(ConsistOf(
+			HaveField("Spec.Suspend", true),
+			HaveField("Spec.Suspend", true),
+			SatisfyAll(HaveField("Name", "web"), HaveField("Spec.Suspend", false)),
+		))
+
+		results, err = Run(ctx, c, "ci", payments, jcrsv1.ResumeOperation, Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveEach(HaveField("Message", "Resumed")))
+		Expect(list()).To(HaveEach(HaveField("Spec.Suspend", false)))
+	})
+
+	It("triggers the selected builds once per operation", func() {
+		for range 2 {
+			results, err := Run(ctx, c, "ci", payments, jcrsv1.TriggerOperation, Options{Name: "release"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(Equal([]jcrsv1.BatchOperationResult{
+				{Name: "api", Succeeded: true, Message: "Created api-release"},
+				{Name: "ledger", Succeeded: true, Message: "Created ledger-release"},
+			}))
+		}
+		Expect(list()).To(HaveLen(5))
+		Expect(list()).To(ContainElement(SatisfyAll(
+			HaveField("Name", "api-release"),
+			HaveField("Spec.Suspend", false),
+			HaveField("Annotations", HaveKeyWithValue(rerun.RerunOfAnnotation, "ci/api")),
+			HaveField("Annotations", HaveKeyWithValue(OperationAnnotation, "release")),
+		)))
+	})
+
+	It("overrides the parameters of the triggered builds", func() {
+		_, err := Run(ctx, c, "ci", payments, jcrsv1.TriggerOperation, Options{
+			Name: "hotfix", Parameters: map[string]string{"VERSION": "1.2.3-hotfix"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list()).To(ContainElement(SatisfyAll(
+			HaveField("Name", "ledger-hotfix"),
+			HaveField("Annotations", HaveKeyWithValue(rerun.ParameterOverridesAnnotation, `{"VERSION":"1.2.3-hotfix"}`)),
+		)))
+		Expect(list()).To(ContainElement(SatisfyAll(
+			HaveField("Name", "ledger"),
+			HaveField("Annotations", BeEmpty()),
+		)))
+	})
+
+	It("reports the builds the operation failed for", func() {
+		c = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+			Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				if obj.GetName() == "api" {
+					return errors.New("forbidden")
+				}
+				return c.Delete(ctx, obj, opts...)
+			},
+		})
+		results, err := Run(ctx, c, "ci", payments, jcrsv1.CancelOperation, Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(Equal([]jcrsv1.BatchOperationResult{
+			{Name: "api", Succeeded: false, Message: "forbidden"},
+			{Name: "ledger", Succeeded: true, Message: "Deleted"},
+		}))
+		Expect(list()).To(ConsistOf(HaveField("Name", "api"), HaveField("Name", "web")))
+	})
+})
@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cloudevents sends CloudEvents (https://cloudevents.io, version 1.0) to
+// the sinks external systems listen on. Sinks are selected by the scheme of their
+// URL; HTTP(S) and NATS sinks are built in, and other transports are added with
+// RegisterSink, e.g. Kafka by a build of the controller linking a Kafka client.
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// SpecVersion is the version of the CloudEvents specification of the events
+const SpecVersion = "1.0"
+
+// Event is a CloudEvent with JSON data.
+type Event struct {
+	// ID identifies the event. Sending the same event again keeps its ID, so
+	// consumers can drop duplicates.
+	ID string `json:"id"`
+	// Source identifies the context in which the event happened
+	Source string `json:"source"`
+	// Type is the type of the event, e.g. dev.jcrs.build.started
+	Type string `json:"type"`
+	// Subject is the subject of the event in the context of its source
+	Subject string    `json:"subject,omitempty"`
+	Time    time.Time `json:"time"`
+	// Data is marshalled to JSON
+	Data any `json:"data,omitempty"`
+}
+
+// structuredEvent is an Event in the structured content mode of the JSON format.
+type structuredEvent struct {
+	SpecVersion     string `json:"specversion"`
+	DataContentType string `json:"datacontenttype"`
+	Event
+}
+
+// MarshalStructured returns event in the structured content mode of the JSON format.
+func MarshalStructured(event Event) ([]byte, error) {
+	return json.Marshal(structuredEvent{SpecVersion: SpecVersion, DataContentType: "application/json", Event: event})
+}
+
+// Sink sends events.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// SinkFactory returns the Sink of a URL.
+type SinkFactory func(sinkURL *url.URL, timeout time.Duration) (Sink, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]SinkFactory{
+		"http":  newHTTPSink,
+		"https": newHTTPSink,
+		"nats":  newNATSSink,
+	}
+)
+
+// RegisterSink makes the sinks of the given URL scheme available to NewSink.
+func RegisterSink(scheme string, factory SinkFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[scheme] = factory
+}
+
+// NewSink returns the Sink of sinkURL, whose sends time out after timeout.
+func NewSink(sinkURL string, timeout time.Duration) (Sink, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return nil, err
+	}
+	factoriesMu.RLock()
+	factory, ok := factories[u.Scheme]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported CloudEvents sink %q", u.Scheme)
+	}
+	return factory(u, timeout)
+}
+
+// HTTPSink posts events to a URL in the binary content mode of the HTTP binding:
+// the attributes of the event are sent as ce- headers, and its data as the body.
+type HTTPSink struct {
+	Client *http.Client
+	URL    string
+}
+
+func newHTTPSink(sinkURL *url.URL, timeout time.Duration) (Sink, error) {
+	return &HTTPSink{Client: &http.Client{Timeout: timeout}, URL: sinkURL.String()}, nil
+}
+
+// Send implements Sink.
+func (s *HTTPSink) Send(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Ce-Specversion", SpecVersion)
+	req.Header.Set("Ce-Id", event.ID)
+	req.Header.Set("Ce-Source", event.Source)
+	req.Header.Set("Ce-Type", event.Type)
+	req.Header.Set("Ce-Time", event.Time.UTC().Format(time.RFC3339))
+	if event.Subject != "" {
+		req.Header.Set("Ce-Subject", event.Subject)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("sending %s event to %s: %s", event.Type, s.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sinks", func() {
+	event := Event{
+		ID:      "uid-3-dev.jcrs.build.succeeded",
+		Source:  "/apis/jcrs.jcrs.dev/v1/namespaces/default/leviathanbuilds/web",
+		Type:    "dev.jcrs.build.succeeded",
+		Subject: "3",
+		Time:    time.Date(2025, time.June, 2, 12, 0, 0, 0, time.UTC),
+		Data:    map[string]string{"packageName": "web"},
+	}
+
+	It("posts events in the binary content mode", func() {
+		var header http.Header
+		var body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		sink, err := NewSink(server.URL+"/events", time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sink.Send(context.Background(), event)).To(Succeed())
+		Expect(header.Get("Ce-Specversion")).To(Equal("1.0"))
+		Expect(header.Get("Ce-Id")).To(Equal(event.ID))
+		Expect(header.Get("Ce-Type")).To(Equal("dev.jcrs.build.succeeded"))
+		Expect(header.Get("Ce-Source")).To(Equal(event.Source))
+		Expect(header.Get("Ce-Subject")).To(Equal("3"))
+		Expect(header.Get("Ce-Time")).To(Equal("2025-06-02T12:00:00Z"))
+		Expect(body).To(MatchJSON(`{"packageName":"web"}`))
+
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+		Expect(sink.Send(context.Background(), event)).To(MatchError(ContainSubstring("502")))
+	})
+
+	It("publishes events to NATS subjects", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = listener.Close() }()
+
+		received := make(chan []string, 1)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := listener.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			defer func() { _ = conn.Close() }()
+			_, _ = io.WriteString(conn, "INFO {\"server_id\":\"test\"}\r\n")
+
+			reader := bufio.NewReader(conn)
+			var lines []string
+			for {
+				line, err := reader.ReadString('\n')
+				Expect(err).NotTo(HaveOccurred())
+				line = strings.TrimSpace(line)
+				lines = append(lines, line)
+				if fields := strings.Fields(line); fields[0] == "PUB" {
+					size, _ := strconv.Atoi(fields[2])
+					payload := make([]byte, size+2)
+					_, err := io.ReadFull(reader, payload)
+					Expect(err).NotTo(HaveOccurred())
+					lines = append(lines, string(payload[:size]))
+				}
+				if line == "PING" {
+					_, _ = io.WriteString(conn, "PONG\r\n")
+					received <- lines
+					return
+				}
+			}
+		}()
+
+		sink, err := NewSink("nats://deployer:secret@"+listener.Addr().String()+"/builds.web", time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sink.Send(context.Background(), event)).To(Succeed())
+
+		var lines []string
+		Eventually(received).Should(Receive(&lines))
+		Expect(lines).To(HaveLen(4))
+		var connect natsConnect
+		Expect(json.Unmarshal([]byte(strings.TrimPrefix(lines[0], "CONNECT ")), &connect)).To(Succeed())
+		Expect(connect.User).To(Equal("deployer"))
+		Expect(connect.Pass).To(Equal("secret"))
+		Expect(lines[1]).To(HavePrefix("PUB builds.web "))
+		Expect(lines[2]).To(MatchJSON(`{
+			"specversion": "1.0",
+			"datacontenttype": "application/json",
+			"id": "uid-3-dev.jcrs.build.succeeded",
+			"source": "/apis/jcrs.jcrs.dev/v1/namespaces/default/leviathanbuilds/web",
+			"type": "dev.jcrs.build.succeeded",
+			"subject": "3",
+			"time": "2025-06-02T12:00:00Z",
+			"data": {"packageName": "web"}
+		}`))
+	})
+
+	It("selects sinks by scheme", func() {
+		_, err := NewSink("kafka://broker:9092/builds", time.Second)
+		Expect(err).To(MatchError(ContainSubstring("unsupported")))
+
+		var sent []Event
+		RegisterSink("kafka", func(u *url.URL, _ time.Duration) (Sink, error) {
+			Expect(u.Host).To(Equal("broker:9092"))
+			return sinkFunc(func(_ context.Context, event Event) error {
+				sent = append(sent, event)
+				return nil
+			}), nil
+		})
+		DeferCleanup(func() {
+			factoriesMu.Lock()
+			defer factoriesMu.Unlock()
+			delete(factories, "kafka")
+		})
+		sink, err := NewSink("kafka://broker:9092/builds", time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sink.Send(context.Background(), event)).To(Succeed())
+		Expect(sent).To(ConsistOf(event))
+	})
+})
+
+type sinkFunc func(ctx context.Context, event Event) error
+
+func (f sinkFunc) Send(ctx context.Context, event Event) error { return f(ctx, event) }
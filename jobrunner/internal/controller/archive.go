@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/runlogs"
+)
+
+/*
+When an archive store is configured, a record of every build is written to object
+storage, so its history survives the build being deleted or its cluster going
+away. The record holds the whole LeviathanBuild, and a reference to the Events of
+the build, which expire long before the record does.
+
+The record is written once a run has finished, and a finalizer makes sure the
+final state of the build is written before it is deleted. Builds are keyed by
+namespace, name and UID, so a build recreated with the same name doesn't
+overwrite the history of the previous one.
+
+The logs of every finished run are written next to the record, while its pods
+still exist, so `leviathan logs` can show them once the pods are gone.
+*/
+
+const (
+	// archiveFinalizer holds the deletion of a build until it has been archived
+	archiveFinalizer = "jcrs.jcrs.dev/archive"
+
+	// archiveRetryInterval is how often a failed archive is retried
+	archiveRetryInterval = 5 * time.Minute
+)
+
+// archiveRecord is the record of a build written to the archive.
+type archiveRecord struct {
+	Build      *jcrsv1.LeviathanBuild `json:"build"`
+	Events     eventsReference        `json:"events"`
+	ArchivedAt metav1.Time            `json:"archivedAt"`
+}
+
+// eventsReference selects the Events of a build.
+type eventsReference struct {
+	Namespace     string `json:"namespace"`
+	FieldSelector string `json:"fieldSelector"`
+}
+
+// archiveKey returns the key of the record of lvBuild.
+func archiveKey(lvBuild *jcrsv1.LeviathanBuild) string {
+	return path.Join(lvBuild.Namespace, lvBuild.Name, string(lvBuild.UID)+".json")
+}
+
+// archiveBuild writes the record of lvBuild to the archive and returns its URL.
+func (r *LeviathanBuildReconciler) archiveBuild(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (string, error) {
+	build := lvBuild.DeepCopy()
+	build.SetGroupVersionKind(jcrsv1.GroupVersion.WithKind("LeviathanBuild"))
+	build.ManagedFields = nil
+	data, err := json.Marshal(archiveRecord{
+		Build: build,
+		Events: eventsReference{
+			Namespace:     lvBuild.Namespace,
+			FieldSelector: "involvedObject.uid=" + string(lvBuild.UID),
+		},
+		ArchivedAt: metav1.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return r.Archive.Put(ctx, archiveKey(lvBuild), data)
+}
+
+// archiveRunLogs writes the logs of the finished run runIndex of lvBuild by job
+// to the archive, once. The logs of runs whose pods are already gone are lost.
+func (r *LeviathanBuildReconciler) archiveRunLogs(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64) error {
+	if r.Archive == nil || r.RunLogs == nil || lvBuild.Status.ArchivedLogsRunIndex >= runIndex {
+		return nil
+	}
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return err
+	}
+	if len(pods.Items) > 0 {
+		var logs bytes.Buffer
+		if err := runlogs.Write(ctx, r.RunLogs, pods.Items, &logs); err != nil {
+			return err
+		}
+		if _, err := r.Archive.Put(ctx, runlogs.ArchiveKey(lvBuild.Namespace, lvBuild.Name, lvBuild.UID, runIndex), logs.Bytes()); err != nil {
+			return err
+		}
+	}
+	lvBuild.Status.ArchivedLogsRunIndex = runIndex
+	return nil
+}
+
+// reconcileArchiveFinalizer adds the archive finalizer to lvBuild, or archives the
+// build and releases it when it is being deleted. It reports whether the build is
+// being deleted, in which case there is nothing else to reconcile.
+func (r *LeviathanBuildReconciler) reconcileArchiveFinalizer(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (bool, error) {
+	if lvBuild.DeletionTimestamp.IsZero() {
+		if r.Archive != nil && controllerutil.AddFinalizer(lvBuild, archiveFinalizer) {
+			return false, r.Update(ctx, lvBuild)
+		}
+		return false, nil
+	}
+	if !controllerutil.ContainsFinalizer(lvBuild, archiveFinalizer) {
+		return true, nil
+	}
+
+	// Builds are released even when archiving has been turned off since the finalizer was added
+	if r.Archive != nil {
+		url, err := r.archiveBuild(ctx, lvBuild)
+		if err != nil {
+			return true, err
+		}
+		logf.FromContext(ctx).Info("Archived deleted LeviathanBuild", "url", url)
+	}
+	controllerutil.RemoveFinalizer(lvBuild, archiveFinalizer)
+	return true, r.Update(ctx, lvBuild)
+}
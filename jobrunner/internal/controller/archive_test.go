@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// memoryStore is an archive.Store keeping objects in memory.
+type memoryStore map[string][]byte
+
+func (s memoryStore) Put(_ context.Context, key string, data []byte) (string, error) {
+	s[key] = data
+	return "s3://builds/" + key, nil
+}
+
+var _ = Describe("Build archive", func() {
+	var (
+		ctx     context.Context
+		c       client.Client
+		store   memoryStore
+		r       *LeviathanBuildReconciler
+		lvBuild *jcrsv1.LeviathanBuild
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: types.UID("web-uid")},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("web")},
+			Status:     jcrsv1.LeviathanBuildStatus{RunIndex: 3},
+		}
+		c = newFakeClient(lvBuild)
+		store = memoryStore{}
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme(), Archive: store}
+	})
+
+	It("archives builds before they are deleted", func() {
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(lvBuild), lvBuild)).To(Succeed())
+		Expect(r.reconcileArchiveFinalizer(ctx, lvBuild)).To(BeFalse())
+		Expect(lvBuild.Finalizers).To(ContainElement(archiveFinalizer))
+
+		Expect(c.Delete(ctx, lvBuild)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(lvBuild), lvBuild)).To(Succeed())
+		Expect(r.reconcileArchiveFinalizer(ctx, lvBuild)).To(BeTrue())
+		err := c.Get(ctx, client.ObjectKeyFromObject(lvBuild), lvBuild)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		Expect(store).To(HaveKey("default/web/web-uid.json"))
+		var record map[string]any
+		Expect(json.Unmarshal(store["default/web/web-uid.json"], &record)).To(Succeed())
+		Expect(record).To(HaveKeyWithValue("build", And(
+			HaveKeyWithValue("kind", "LeviathanBuild"),
+			HaveKeyWithValue("status", HaveKeyWithValue("runIndex", BeEquivalentTo(3))),
+		)))
+		Expect(record).To(HaveKeyWithValue("events", HaveKeyWithValue("fieldSelector", "involvedObject.uid=web-uid")))
+	})
+
+	It("releases builds when archiving has been turned off", func() {
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(lvBuild), lvBuild)).To(Succeed())
+		Expect(r.reconcileArchiveFinalizer(ctx, lvBuild)).To(BeFalse())
+		Expect(c.Delete(ctx, lvBuild)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(lvBuild), lvBuild)).To(Succeed())
+
+		r.Archive = nil
+		Expect(r.reconcileArchiveFinalizer(ctx, lvBuild)).To(BeTrue())
+		err := c.Get(ctx, client.ObjectKeyFromObject(lvBuild), lvBuild)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(store).To(BeEmpty())
+	})
+
+	It("archives the logs of every finished run once", func() {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-3", Namespace: "default"}}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-3-abcde", Namespace: "default", Labels: map[string]string{batchv1.JobNameLabel: "web-3"}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "build",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}},
+			}}},
+		}
+		Expect(c.Create(ctx, pod)).To(Succeed())
+		logs := &staticLogs{logs: "compiled\n"}
+		r.RunLogs = logs
+
+		Expect(r.archiveRunLogs(ctx, lvBuild, job, 3)).To(Succeed())
+		Expect(string(store["default/web/web-uid/run-3.log"])).To(Equal("[build] compiled\n"))
+		Expect(lvBuild.Status.ArchivedLogsRunIndex).To(Equal(int64(3)))
+
+		Expect(r.archiveRunLogs(ctx, lvBuild, job, 3)).To(Succeed())
+		Expect(logs.streams).To(Equal(1))
+	})
+})
+
+// staticLogs is a runlogs.Source returning the same logs for every container.
+type staticLogs struct {
+	logs    string
+	streams int
+}
+
+func (s *staticLogs) Stream(_ context.Context, _ *corev1.Pod, _ string, _ bool) (io.ReadCloser, error) {
+	s.streams++
+	return io.NopCloser(strings.NewReader(s.logs)), nil
+}
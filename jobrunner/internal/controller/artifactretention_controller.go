@@ -0,0 +1,142 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/retention"
+)
+
+const (
+	// retentionRetryInterval is how often versions that failed to be deleted are retried
+	retentionRetryInterval = 5 * time.Minute
+
+	// retentionCheckInterval is how often the policies keeping versions for a number
+	// of days are checked again
+	retentionCheckInterval = time.Hour
+)
+
+// ArtifactRetentionReconciler enforces the artifactRetention policy of LeviathanBuild objects
+type ArtifactRetentionReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Pruners delete versions from each kind of storage. Versions stored in a kind
+	// of storage without a Pruner are only reported.
+	Pruners map[jcrsv1.ArtifactStore]retention.Pruner
+}
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds/status,verbs=get;update;patch
+
+// Reconcile deletes the versions published by a LeviathanBuild that its retention
+// policy doesn't keep, once they have been reported as pending in its status.
+func (r *ArtifactRetentionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var lvBuild jcrsv1.LeviathanBuild
+	if err := r.Get(ctx, req.NamespacedName, &lvBuild); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	policy := lvBuild.Spec.ArtifactRetention
+	observed := lvBuild.Status.DeepCopy()
+
+	result := ctrl.Result{}
+	if policy == nil || lvBuild.Spec.PublishTarget == nil || !lvBuild.DeletionTimestamp.IsZero() {
+		lvBuild.Status.ArtifactRetention = nil
+	} else {
+		status := &jcrsv1.ArtifactRetentionStatus{}
+		if lvBuild.Status.ArtifactRetention != nil {
+			status = lvBuild.Status.ArtifactRetention.DeepCopy()
+		}
+		pending := status.Pending
+		status.Pending, status.Message = nil, ""
+
+		pruner := r.Pruners[policy.Store]
+		if pruner == nil && !policy.DryRun {
+			status.Message = fmt.Sprintf("Versions stored in %s aren't supported by this controller", policy.Store)
+		}
+		var pruned []string
+		for _, artifact := range prunableArtifacts(&lvBuild, time.Now()) {
+			// Only versions that were already reported are deleted
+			if policy.DryRun || pruner == nil || !slices.Contains(pending, artifact.Version) {
+				status.Pending = append(status.Pending, artifact.Version)
+				continue
+			}
+			err := pruner.Delete(ctx, artifact.RegistryURL, artifactPath(&lvBuild, artifact), artifact.Version, artifact.Digest)
+			if err != nil {
+				log.Error(err, "Failed to delete published version", "version", artifact.Version)
+				status.Pending = append(status.Pending, artifact.Version)
+				status.Message = fmt.Sprintf("Failed to delete version %s: %v", artifact.Version, err)
+				result.RequeueAfter = retentionRetryInterval
+				continue
+			}
+			log.Info("Deleted published version", "version", artifact.Version)
+			pruned = append(pruned, artifact.Version)
+			lvBuild.Status.PublishedArtifacts = slices.DeleteFunc(lvBuild.Status.PublishedArtifacts, func(a jcrsv1.PublishedArtifact) bool {
+				return a.RunIndex == artifact.RunIndex
+			})
+		}
+		if len(pruned) > 0 {
+			status.LastPruned = pruned
+			status.LastPruneTime = ptr.To(metav1.Now())
+		}
+		lvBuild.Status.ArtifactRetention = status
+
+		// Versions kept for a number of days expire without any change to the build
+		if policy.KeepDays != nil && result.RequeueAfter == 0 {
+			result.RequeueAfter = retentionCheckInterval
+		}
+	}
+
+	if equality.Semantic.DeepEqual(observed, &lvBuild.Status) {
+		return result, nil
+	}
+	if err := r.Status().Update(ctx, &lvBuild); err != nil {
+		log.Error(err, "unable to update LeviathanBuild status")
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ArtifactRetentionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Only builds with a policy, or still reporting one, are of interest
+	hasPolicy := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		lvBuild, ok := obj.(*jcrsv1.LeviathanBuild)
+		return ok && (lvBuild.Spec.ArtifactRetention != nil || lvBuild.Status.ArtifactRetention != nil)
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jcrsv1.LeviathanBuild{}, builder.WithPredicates(hasPolicy)).
+		Named("artifactretention").
+		Complete(r)
+}
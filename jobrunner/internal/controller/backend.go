@@ -0,0 +1,248 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Builds with an executionBackend other than Job run on another engine. The Job
+of a run is constructed as for any other build, then converted by the Converter
+of the backend, and the object of the engine is created instead of the Job. The
+object of the latest run is found by the run labels, and replaced when the Job
+it was converted from changes, which is recorded as a hash of the spec of the Job.
+
+New runs pass the same checks as Jobs, see checkNewRun, are dry run like Jobs so
+that an object the API server refuses is reported rather than retried, and hold
+the mutex of their build, see acquireRunMutex, while they run. What the
+controller sets up around the pods of Jobs, the NetworkPolicy of Strict
+isolation, the PodDisruptionBudget of ProtectFromEviction, Secret checksums and
+fetch slots, is refused by the webhook for other engines. What acts on the Job
+once it is created, such as heartbeats, checkpoints, spot retries and onSuccess
+hooks, doesn't apply to them either. Their objects aren't watched, since the
+engine may not be installed when the controller starts: running builds are
+checked again every 30 seconds.
+*/
+
+// +kubebuilder:rbac:groups=tekton.dev,resources=pipelineruns,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=argoproj.io,resources=workflows,verbs=get;list;watch;create;delete
+
+const (
+	// jobSpecHashAnnotation records the hash of the spec of the Job an object of
+	// another engine was converted from
+	jobSpecHashAnnotation = "jcrs.jcrs.dev/job-spec-hash"
+
+	// backendRecheckInterval is how often the runs of builds on another engine are checked
+	backendRecheckInterval = 30 * time.Second
+
+	// backendConversionReason is the reason of the Events recorded for what the
+	// conversion of a Job for another engine drops
+	backendConversionReason = "BackendConversion"
+)
+
+// usesBackend reports whether lvBuild runs on another engine than Jobs.
+func usesBackend(lvBuild *jcrsv1.LeviathanBuild) bool {
+	backend := lvBuild.Spec.ExecutionBackend
+	return backend != "" && backend != jcrsv1.JobBackend
+}
+
+// jobSpecHash returns the hash of the spec of job.
+func jobSpecHash(job *batchv1.Job) (string, error) {
+	data, err := json.Marshal(job.Spec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// reconcileBackendRun runs desiredJob on the executionBackend of lvBuild, and
+// sets the status of lvBuild from the run of the engine. No new run is started
+// while window is open.
+func (r *LeviathanBuildReconciler) reconcileBackendRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, desiredJob *batchv1.Job, observed *jcrsv1.LeviathanBuildStatus, window *openMaintenanceWindow) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	blocked := func(reason string, err error) (ctrl.Result, error) {
+		log.Info("Execution backend can't run the build", "executionBackend", lvBuild.Spec.ExecutionBackend, "reason", err.Error())
+		setInvalidJobTemplate(lvBuild, reason, err)
+		setBlocked(lvBuild, jcrsv1.BlockedByInvalidJobTemplate, jcrsv1.ConditionInvalidJobTemplate)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: invalidJobTemplateRetryInterval}, nil
+	}
+
+	converter := r.Backends[lvBuild.Spec.ExecutionBackend]
+	if converter == nil {
+		return blocked(jcrsv1.ReasonBackendUnavailable, fmt.Errorf("executionBackend %s isn't enabled in the controller", lvBuild.Spec.ExecutionBackend))
+	}
+	hash, err := jobSpecHash(desiredJob)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	latest, latestRunIndex, err := r.latestBackendRun(ctx, lvBuild, converter.GroupVersionKind())
+	if meta.IsNoMatchError(err) {
+		return blocked(jcrsv1.ReasonBackendUnavailable, fmt.Errorf("%s isn't installed in the cluster", converter.GroupVersionKind().Kind))
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// An outdated run is replaced, unless the build is suspended or a MaintenanceWindow is open
+	outdated := latest != nil && latest.GetAnnotations()[jobSpecHashAnnotation] != hash
+	if outdated && !lvBuild.Spec.Suspend && window == nil {
+		log.Info("Run doesn't match desired state, deleting it", "kind", latest.GetKind(), "name", latest.GetName())
+		r.event(lvBuild, corev1.EventTypeNormal, jobOutOfDateReason, "Replacing %s %s", latest.GetKind(), latest.GetName())
+		if err := r.Delete(ctx, latest, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		latest = nil
+	}
+
+	if latest == nil {
+		runIndex := max(lvBuild.Status.RunIndex, latestRunIndex) + 1
+		job := desiredJob.DeepCopy()
+		job.GenerateName = jobGenerateName(lvBuild, runIndex)
+		setRunLabels(lvBuild, job, runIndex)
+		if job.Annotations == nil {
+			job.Annotations = map[string]string{}
+		}
+		job.Annotations[jobSpecHashAnnotation] = hash
+		obj, warnings, err := converter.Convert(job)
+		if err != nil {
+			return blocked(jcrsv1.ReasonConstructionFailed, err)
+		}
+		if err := r.Create(ctx, obj.DeepCopy(), client.DryRunAll); isJobRejected(err) {
+			return blocked(string(apierrors.ReasonForError(err)), err)
+		} else if meta.IsNoMatchError(err) {
+			return blocked(jcrsv1.ReasonBackendUnavailable, fmt.Errorf("%s isn't installed in the cluster", obj.GetKind()))
+		} else if isNamespaceTerminatingError(err) {
+			r.markNamespaceTerminating(ctx, lvBuild, observed)
+			return ctrl.Result{}, nil
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+		if proceed, result, err := r.checkNewRun(ctx, lvBuild, observed, window); !proceed {
+			return result, err
+		}
+		if proceed, result, err := r.acquireRunMutex(ctx, lvBuild, observed); !proceed {
+			return result, err
+		}
+		for _, warning := range warnings {
+			r.event(lvBuild, corev1.EventTypeWarning, backendConversionReason, "%s", warning)
+		}
+		if err := r.startRun(ctx, lvBuild, job, runIndex); err != nil {
+			r.abandonRun(ctx, lvBuild)
+			return ctrl.Result{}, err
+		}
+		log.Info("Creating a new run", "kind", obj.GetKind(), "namespace", obj.GetNamespace(), "generateName", obj.GetGenerateName())
+		if err := r.Create(ctx, obj); err != nil {
+			r.abandonRun(ctx, lvBuild)
+			if meta.IsNoMatchError(err) {
+				return blocked(jcrsv1.ReasonBackendUnavailable, fmt.Errorf("%s isn't installed in the cluster", obj.GetKind()))
+			}
+			if isNamespaceTerminatingError(err) {
+				r.markNamespaceTerminating(ctx, lvBuild, observed)
+				return ctrl.Result{}, nil
+			}
+			return ctrl.Result{}, err
+		}
+		setInvalidJobTemplate(lvBuild, "", nil)
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRunning, obj.GetKind()+" "+obj.GetName()+" is running")
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: backendRecheckInterval}, nil
+	}
+
+	setInvalidJobTemplate(lvBuild, "", nil)
+	result := ctrl.Result{}
+	name := latest.GetKind() + " " + latest.GetName()
+	finished, succeeded, message := converter.Finished(latest)
+	switch {
+	case !finished:
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRunning, name+" is running")
+		result.RequeueAfter = backendRecheckInterval
+	case succeeded:
+		jcrsv1.MarkSucceeded(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonJobComplete, name+" completed")
+	default:
+		if message != "" {
+			message = ": " + message
+		}
+		jcrsv1.MarkFailed(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonJobFailed, name+" failed"+message)
+	}
+	// The mutex is held for as long as the run runs
+	if finished {
+		if err := r.releaseMutex(ctx, lvBuild); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if _, err := r.renewMutex(ctx, lvBuild); err != nil {
+		return ctrl.Result{}, err
+	}
+	if outdated {
+		holdOutdatedRun(lvBuild, window, &result)
+	}
+	if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// latestBackendRun returns the object of kind gvk of the latest run of lvBuild,
+// if any, and its run index.
+func (r *LeviathanBuildReconciler) latestBackendRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, gvk schema.GroupVersionKind) (*unstructured.Unstructured, int64, error) {
+	var runs unstructured.UnstructuredList
+	runs.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.List(ctx, &runs, client.InNamespace(lvBuild.Namespace), client.MatchingLabels{buildLabel: labelValue(lvBuild.Name)}); err != nil {
+		return nil, 0, err
+	}
+	var latest *unstructured.Unstructured
+	var latestIndex int64
+	for i := range runs.Items {
+		run := &runs.Items[i]
+		// Shortened names may collide, the owner tells the builds apart
+		if !metav1.IsControlledBy(run, lvBuild) {
+			continue
+		}
+		index, err := strconv.ParseInt(run.GetLabels()[runIndexLabel], 10, 64)
+		if err != nil {
+			continue
+		}
+		if latest == nil || index > latestIndex {
+			latest, latestIndex = run, index
+		}
+	}
+	return latest, latestIndex, nil
+}
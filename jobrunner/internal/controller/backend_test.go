@@ -0,0 +1,280 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	"test.jcrs.dev/jobrunner/internal/interop"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Execution backends", func() {
+	var (
+		ctx     context.Context
+		r       *LeviathanBuildReconciler
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	pipelineRuns := func() []unstructured.Unstructured {
+		var runs unstructured.UnstructuredList
+		runs.SetGroupVersionKind(interop.PipelineRunGVK.GroupVersion().WithKind("PipelineRunList"))
+		Expect(r.List(ctx, &runs, client.InNamespace("ci"))).To(Succeed())
+		return runs.Items
+	}
+	reconcile := func() {
+		observed := lvBuild.Status.DeepCopy()
+		_, err := r.reconcileBackendRun(ctx, lvBuild, job, observed, nil)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	invalidJobTemplate := func() *metav1.Condition {
+		return meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionInvalidJobTemplate)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := newTestScheme()
+		// Only Tekton is installed in the cluster
+		scheme.AddKnownTypeWithName(interop.PipelineRunGVK, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(interop.PipelineRunGVK.GroupVersion().WithKind("PipelineRunList"), &unstructured.UnstructuredList{})
+
+		// The fake client stores objects of any kind, Workflows are refused as by a
+		// cluster without Argo
+		notInstalled := interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				if gvk := list.GetObjectKind().GroupVersionKind(); gvk.Group == interop.WorkflowGVK.Group {
+					return &meta.NoKindMatchError{GroupKind: interop.WorkflowGVK.GroupKind(), SearchedVersions: []string{gvk.Version}}
+				}
+				return c.List(ctx, list, opts...)
+			},
+		}
+
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "ci").Obj()
+		lvBuild.Spec.ExecutionBackend = jcrsv1.TektonBackend
+		r = &LeviathanBuildReconciler{
+			Client: newFakeClientBuilder().WithScheme(scheme).WithInterceptorFuncs(notInstalled).
+				WithObjects(lvBuild).Build(),
+			Scheme: scheme,
+			Backends: map[jcrsv1.ExecutionBackend]interop.Converter{
+				jcrsv1.TektonBackend: interop.TektonConverter{},
+				jcrsv1.ArgoBackend:   interop.ArgoConverter{},
+			},
+		}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(lvBuild), lvBuild)).To(Succeed())
+
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Labels: map[string]string{}, Annotations: map[string]string{}}}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build", Image: "golang:1.24", Command: []string{"make"}}}
+		Expect(controllerutil.SetControllerReference(lvBuild, job, scheme)).To(Succeed())
+	})
+
+	It("runs the build as the object of its engine", func() {
+		reconcile()
+
+		runs := pipelineRuns()
+		Expect(runs).To(HaveLen(1))
+		Expect(runs[0].GetLabels()).To(HaveKeyWithValue(runIndexLabel, "1"))
+		Expect(runs[0].GetAnnotations()).To(HaveKey(jobSpecHashAnnotation))
+		Expect(metav1.IsControlledBy(&runs[0], lvBuild)).To(BeTrue())
+		Expect(lvBuild.Status.RunIndex).To(BeEquivalentTo(1))
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded).Reason).To(Equal(jcrsv1.ReasonRunning))
+	})
+
+	It("sets the status of the build from its run", func() {
+		reconcile()
+		run := &pipelineRuns()[0]
+		Expect(unstructured.SetNestedSlice(run.Object, []any{map[string]any{
+			"type": "Succeeded", "status": "True", "reason": "Succeeded",
+		}}, "status", "conditions")).To(Succeed())
+		Expect(r.Update(ctx, run)).To(Succeed())
+
+		reconcile()
+		Expect(pipelineRuns()).To(HaveLen(1))
+		Expect(jcrsv1.IsSucceeded(lvBuild.Status.Conditions)).To(BeTrue())
+	})
+
+	It("replaces the run when the Job of the build changes", func() {
+		reconcile()
+		first := pipelineRuns()[0].GetName()
+		meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+			Type: jcrsv1.ConditionPatchTargetsApplied, Status: metav1.ConditionTrue, Reason: jcrsv1.ReasonApplied,
+			ObservedGeneration: lvBuild.Generation,
+		})
+
+		job.Spec.Template.Spec.Containers[0].Image = "golang:1.25"
+		reconcile()
+		runs := pipelineRuns()
+		Expect(runs).To(HaveLen(1))
+		Expect(runs[0].GetName()).NotTo(Equal(first))
+		Expect(runs[0].GetLabels()).To(HaveKeyWithValue(runIndexLabel, "2"))
+		By("patching the targets again once the new run succeeds")
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPatchTargetsApplied)).To(BeNil())
+	})
+
+	It("resets the status of the previous run", func() {
+		lvBuild.Status.RunIndex = 1
+		lvBuild.Status.RolledBack = true
+		lvBuild.Status.SourceRevision = "sha256:previous"
+		lvBuild.Status.Reproducibility = &jcrsv1.ReproducibilityStatus{RunIndex: 1}
+		lvBuild.Status.PublishApproval = &jcrsv1.PublishApprovalStatus{RunIndex: 1}
+		reconcile()
+
+		Expect(lvBuild.Status.RunIndex).To(BeEquivalentTo(2))
+		Expect(lvBuild.Status.RolledBack).To(BeFalse())
+		Expect(lvBuild.Status.SourceRevision).To(BeEmpty())
+		Expect(lvBuild.Status.Reproducibility).To(BeNil())
+		Expect(lvBuild.Status.PublishApproval).To(BeNil())
+		Expect(lvBuild.Status.BuildEnvironment).NotTo(BeNil())
+	})
+
+	It("keeps the run of a suspended build", func() {
+		reconcile()
+		lvBuild.Spec.Suspend = true
+		job.Spec.Template.Spec.Containers[0].Image = "golang:1.25"
+		reconcile()
+
+		Expect(pipelineRuns()).To(HaveLen(1))
+		Expect(lvBuild.Status.BlockingReason).NotTo(BeNil())
+		Expect(lvBuild.Status.BlockingReason.Reason).To(Equal(jcrsv1.BlockedBySuspend))
+	})
+
+	It("refuses to publish a package the build wasn't triggered by a publisher of", func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PackageOwnership: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PackageOwnership: false})).To(Succeed())
+		})
+		Expect(r.Create(ctx, &jcrsv1.PackageOwnership{
+			ObjectMeta: metav1.ObjectMeta{Name: "web"},
+			Spec:       jcrsv1.PackageOwnershipSpec{PackageName: "web", Publishers: []string{"jane"}},
+		})).To(Succeed())
+		lvBuild.Spec.PackageName = ptr.To("web")
+		lvBuild.Spec.BuildType = jcrsv1.BuildPublish
+		lvBuild.Annotations = map[string]string{TriggeredByAnnotation: "john"}
+		Expect(r.Update(ctx, lvBuild)).To(Succeed())
+		reconcile()
+
+		Expect(pipelineRuns()).To(BeEmpty())
+		Expect(lvBuild.Status.BlockingReason.Reason).To(Equal(jcrsv1.BlockedByPublishAuthorization))
+		Expect(meta.IsStatusConditionFalse(lvBuild.Status.Conditions, jcrsv1.ConditionPublishAuthorized)).To(BeTrue())
+
+		lvBuild.Annotations[TriggeredByAnnotation] = "jane"
+		Expect(r.Update(ctx, lvBuild)).To(Succeed())
+		reconcile()
+		Expect(pipelineRuns()).To(HaveLen(1))
+	})
+
+	It("holds the mutex of the build while its run runs", func() {
+		other := utiltesting.MakeLeviathanBuild("api", "ci").MutexKey("deploy").Obj()
+		Expect(r.Create(ctx, other)).To(Succeed())
+		Expect(r.acquireMutex(ctx, other)).To(BeTrue())
+		lvBuild.Spec.MutexKey = ptr.To("deploy")
+		Expect(r.Update(ctx, lvBuild)).To(Succeed())
+
+		By("waiting for the build holding the mutex")
+		reconcile()
+		Expect(pipelineRuns()).To(BeEmpty())
+		Expect(lvBuild.Status.BlockingReason.Reason).To(Equal(jcrsv1.BlockedByMutex))
+
+		By("taking the mutex once released")
+		Expect(r.releaseMutex(ctx, other)).To(Succeed())
+		reconcile()
+		Expect(pipelineRuns()).To(HaveLen(1))
+		Expect(r.acquireMutex(ctx, other)).To(BeFalse())
+
+		By("releasing it once the run has finished")
+		run := &pipelineRuns()[0]
+		Expect(unstructured.SetNestedSlice(run.Object, []any{map[string]any{
+			"type": "Succeeded", "status": "False", "reason": "Failed",
+		}}, "status", "conditions")).To(Succeed())
+		Expect(r.Update(ctx, run)).To(Succeed())
+		reconcile()
+		Expect(jcrsv1.IsFailed(lvBuild.Status.Conditions)).To(BeTrue())
+		Expect(r.acquireMutex(ctx, other)).To(BeTrue())
+	})
+
+	It("releases the mutex of the build when its run can't be created", func() {
+		lvBuild.Spec.MutexKey = ptr.To("deploy")
+		Expect(r.Update(ctx, lvBuild)).To(Succeed())
+		r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*unstructured.Unstructured); ok && len((&client.CreateOptions{}).ApplyOptions(opts).DryRun) == 0 {
+					return apierrors.NewServiceUnavailable("etcd is unavailable")
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		})
+		observed := lvBuild.Status.DeepCopy()
+		_, err := r.reconcileBackendRun(ctx, lvBuild, job, observed, nil)
+		Expect(err).To(HaveOccurred())
+
+		other := utiltesting.MakeLeviathanBuild("api", "ci").MutexKey("deploy").Obj()
+		Expect(r.Create(ctx, other)).To(Succeed())
+		Expect(r.acquireMutex(ctx, other)).To(BeTrue())
+	})
+
+	It("blocks builds on an engine that isn't installed", func() {
+		lvBuild.Spec.ExecutionBackend = jcrsv1.ArgoBackend
+		reconcile()
+
+		Expect(invalidJobTemplate()).NotTo(BeNil())
+		Expect(invalidJobTemplate().Reason).To(Equal(jcrsv1.ReasonBackendUnavailable))
+		Expect(invalidJobTemplate().Message).To(ContainSubstring("Workflow isn't installed"))
+		Expect(lvBuild.Status.BlockingReason.Reason).To(Equal(jcrsv1.BlockedByInvalidJobTemplate))
+	})
+
+	It("blocks builds whose run the API server refuses", func() {
+		r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				return apierrors.NewInvalid(interop.PipelineRunGVK.GroupKind(), obj.GetName(), field.ErrorList{
+					field.Invalid(field.NewPath("spec", "taskRunTemplate"), "", "unknown field"),
+				})
+			},
+		})
+		reconcile()
+
+		Expect(invalidJobTemplate().Reason).To(Equal(string(metav1.StatusReasonInvalid)))
+		Expect(invalidJobTemplate().Message).To(ContainSubstring("unknown field"))
+		Expect(lvBuild.Status.BlockingReason.Reason).To(Equal(jcrsv1.BlockedByInvalidJobTemplate))
+		Expect(lvBuild.Status.RunIndex).To(BeZero())
+		Expect(pipelineRuns()).To(BeEmpty())
+	})
+
+	It("blocks builds on an engine that isn't enabled", func() {
+		r.Backends = nil
+		reconcile()
+
+		Expect(invalidJobTemplate().Reason).To(Equal(jcrsv1.ReasonBackendUnavailable))
+		Expect(pipelineRuns()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// degradedAPIRetryInterval is how often a build is reconciled again while its
+// requests are held back by a degraded API server. That isn't a failure of the
+// build, so it doesn't count towards its Backoff.
+const degradedAPIRetryInterval = 30 * time.Second
+
+// Backoff tracks consecutive reconcile failures per object and computes an
+// exponentially growing requeue delay, capped at Max. The failure count is reset
+// when a reconcile succeeds or when the object's generation changes, so a spec
+// fix is picked up immediately instead of waiting out the previous backoff.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	mu      sync.Mutex
+	entries map[types.NamespacedName]backoffEntry
+}
+
+type backoffEntry struct {
+	failures   int
+	generation int64
+}
+
+// NewBackoff returns a Backoff starting at base and capped at max.
+func NewBackoff(base, max time.Duration) *Backoff {
+	return &Backoff{Base: base, Max: max, entries: make(map[types.NamespacedName]backoffEntry)}
+}
+
+// Failure records a failed reconcile of the given object and returns how long to
+// wait before the next attempt.
+func (b *Backoff) Failure(key types.NamespacedName, generation int64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry := b.entries[key]
+	if entry.generation != generation {
+		entry = backoffEntry{generation: generation}
+	}
+	entry.failures++
+	b.entries[key] = entry
+
+	delay := b.Base
+	for i := 1; i < entry.failures && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	reconcileBackoffSeconds.WithLabelValues(key.Namespace, key.Name).Set(delay.Seconds())
+	return delay
+}
+
+// Reset forgets the failures recorded for the given object.
+func (b *Backoff) Reset(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.entries[key]; !ok {
+		return
+	}
+	delete(b.entries, key)
+	reconcileBackoffSeconds.DeleteLabelValues(key.Namespace, key.Name)
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Backoff", func() {
+	key := types.NamespacedName{Namespace: "default", Name: "backoff-test"}
+
+	It("doubles the delay up to the maximum", func() {
+		b := NewBackoff(time.Second, 5*time.Second)
+		Expect(b.Failure(key, 1)).To(Equal(time.Second))
+		Expect(b.Failure(key, 1)).To(Equal(2 * time.Second))
+		Expect(b.Failure(key, 1)).To(Equal(4 * time.Second))
+		Expect(b.Failure(key, 1)).To(Equal(5 * time.Second))
+		Expect(b.Failure(key, 1)).To(Equal(5 * time.Second))
+	})
+
+	It("starts over after a reset or a spec change", func() {
+		b := NewBackoff(time.Second, time.Minute)
+		b.Failure(key, 1)
+		Expect(b.Failure(key, 1)).To(Equal(2 * time.Second))
+		Expect(b.Failure(key, 2)).To(Equal(time.Second))
+
+		b.Failure(key, 2)
+		b.Reset(key)
+		Expect(b.Failure(key, 2)).To(Equal(time.Second))
+	})
+})
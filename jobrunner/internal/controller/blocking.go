@@ -0,0 +1,100 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A build can be held back before its next run by several gates, each recorded in
+its own condition. So users don't have to know them all, the gate that stopped
+the latest reconcile is summarized in status.blockingReason. It is cleared at
+the start of every reconcile, and set again by the gate that returns before a
+Job is created, so it is unset once a Job runs.
+
+The gates of every build are also served as JSON on /debug/gates of the metrics
+server, to look into builds that aren't starting across namespaces.
+*/
+
+// gateConditions are the conditions of the gates a build waits on before its next run.
+var gateConditions = []string{
+	jcrsv1.ConditionNamespaceTerminating,
+	jcrsv1.ConditionBuilderImageResolved,
+	jcrsv1.ConditionInvalidJobTemplate,
+	jcrsv1.ConditionDeferredByMaintenanceWindow,
+	jcrsv1.ConditionPublishAuthorized,
+	jcrsv1.ConditionPublishPreflight,
+	jcrsv1.ConditionWaitingForMutex,
+	jcrsv1.ConditionWaitingForFetchSlot,
+	jcrsv1.ConditionWaitingForCapacity,
+	jcrsv1.ConditionDispatched,
+}
+
+// setBlocked records that lvBuild is held back by reason, detailed by the
+// message of its condition of conditionType.
+func setBlocked(lvBuild *jcrsv1.LeviathanBuild, reason jcrsv1.BlockingReasonType, conditionType string) {
+	blocking := &jcrsv1.BlockingReason{Reason: reason}
+	if condition := meta.FindStatusCondition(lvBuild.Status.Conditions, conditionType); condition != nil {
+		blocking.Details = condition.Message
+	}
+	lvBuild.Status.BlockingReason = blocking
+}
+
+// buildGates are the gates of a build, as served by GatesHandler.
+type buildGates struct {
+	Namespace      string                 `json:"namespace"`
+	Name           string                 `json:"name"`
+	BlockingReason *jcrsv1.BlockingReason `json:"blockingReason,omitempty"`
+	Gates          []metav1.Condition     `json:"gates"`
+}
+
+// GatesHandler serves the gates of the LeviathanBuilds read with reader, as a
+// JSON list. The namespace query parameter selects the builds of a namespace.
+func GatesHandler(reader client.Reader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var builds jcrsv1.LeviathanBuildList
+		if err := reader.List(req.Context(), &builds, client.InNamespace(req.URL.Query().Get("namespace"))); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		gates := make([]buildGates, 0, len(builds.Items))
+		for _, lvBuild := range builds.Items {
+			entry := buildGates{
+				Namespace:      lvBuild.Namespace,
+				Name:           lvBuild.Name,
+				BlockingReason: lvBuild.Status.BlockingReason,
+				Gates:          []metav1.Condition{},
+			}
+			for _, conditionType := range gateConditions {
+				if condition := meta.FindStatusCondition(lvBuild.Status.Conditions, conditionType); condition != nil {
+					entry.Gates = append(entry.Gates, *condition)
+				}
+			}
+			gates = append(gates, entry)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(gates)
+	})
+}
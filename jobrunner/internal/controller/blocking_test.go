@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Blocking reasons", func() {
+	newBuild := func(namespace, name string) *jcrsv1.LeviathanBuild {
+		return &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       jcrsv1.LeviathanBuildSpec{MutexKey: ptr.To("publisher")},
+		}
+	}
+
+	It("details the blocking reason with the message of its condition", func() {
+		lvBuild := newBuild("default", "a")
+		setWaitingForMutex(lvBuild, true)
+		setBlocked(lvBuild, jcrsv1.BlockedByMutex, jcrsv1.ConditionWaitingForMutex)
+
+		Expect(lvBuild.Status.BlockingReason).To(Equal(&jcrsv1.BlockingReason{
+			Reason:  jcrsv1.BlockedByMutex,
+			Details: "Waiting for another build holding mutexKey publisher",
+		}))
+	})
+
+	Describe("GatesHandler", func() {
+		var c client.Client
+
+		BeforeEach(func() {
+			waiting := newBuild("team-a", "waiting")
+			setWaitingForMutex(waiting, true)
+			setBlocked(waiting, jcrsv1.BlockedByMutex, jcrsv1.ConditionWaitingForMutex)
+			jcrsv1.MarkRunning(&waiting.Status.Conditions, waiting.Generation, jcrsv1.ReasonRunning, "not a gate")
+
+			c = newFakeClient(waiting, newBuild("team-b", "idle"))
+			Expect(c.Status().Update(context.Background(), waiting)).To(Succeed())
+		})
+
+		serve := func(target string) []buildGates {
+			rec := httptest.NewRecorder()
+			GatesHandler(c).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+			var gates []buildGates
+			Expect(json.Unmarshal(rec.Body.Bytes(), &gates)).To(Succeed())
+			return gates
+		}
+
+		It("serves the gates of every build", func() {
+			gates := serve("/debug/gates")
+			Expect(gates).To(HaveLen(2))
+			Expect(gates).To(ContainElement(SatisfyAll(
+				HaveField("Name", "idle"),
+				HaveField("BlockingReason", BeNil()),
+				HaveField("Gates", BeEmpty()),
+			)))
+		})
+
+		It("serves only gating conditions, of the builds of the namespace asked for", func() {
+			gates := serve("/debug/gates?namespace=team-a")
+			Expect(gates).To(HaveLen(1))
+			Expect(gates[0].Name).To(Equal("waiting"))
+			Expect(gates[0].BlockingReason.Reason).To(Equal(jcrsv1.BlockedByMutex))
+			Expect(gates[0].Gates).To(ConsistOf(HaveField("Type", jcrsv1.ConditionWaitingForMutex)))
+		})
+	})
+})
@@ -0,0 +1,252 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+Containers of the jobTemplate that don't set an image run the builder image selected
+by the BuilderImageMappings. The image is resolved on every reconcile, so when a
+mapping changes the desired Job changes with it and the Job is re-rolled.
+
+To know which builds to reconcile when a mapping changes, builds are indexed by the
+mapping their image was resolved from. Builds that still wait for a matching rule
+are indexed as unresolved.
+*/
+
+const (
+	// builderImageMappingKey indexes LeviathanBuilds by the BuilderImageMapping their image was resolved from
+	builderImageMappingKey = ".status.builderImage.mapping"
+	// builderImageUnresolved is the index value of builds needing a builder image that hasn't been resolved
+	builderImageUnresolved = "<unresolved>"
+
+	// builderImageMappingLabel records the BuilderImageMapping the builder image of a Job was resolved from
+	builderImageMappingLabel = "jcrs.jcrs.dev/builder-image-mapping"
+	// builderImageLabel records a hash of the builder image of a Job
+	builderImageLabel = "jcrs.jcrs.dev/builder-image"
+)
+
+// needsBuilderImage reports whether any container of the jobTemplate of lvBuild,
+// or step of its pipeline, leaves its image to the BuilderImageMappings.
+func needsBuilderImage(lvBuild *jcrsv1.LeviathanBuild) bool {
+	if slices.ContainsFunc(lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers, func(c corev1.Container) bool {
+		return c.Image == ""
+	}) {
+		return true
+	}
+	return lvBuild.Status.Pipeline != nil && slices.ContainsFunc(lvBuild.Status.Pipeline.Steps, func(step jcrsv1.BuildStep) bool {
+		return step.Image == ""
+	})
+}
+
+// builderImageRuleMatches reports whether rule selects the builder image of lvBuild.
+func builderImageRuleMatches(rule jcrsv1.BuilderImageRule, lvBuild *jcrsv1.LeviathanBuild) bool {
+	if len(rule.Languages) > 0 && !slices.Contains(rule.Languages, ptr.Deref(lvBuild.Spec.Language, "")) {
+		return false
+	}
+	if len(rule.PackageNames) == 0 {
+		return true
+	}
+	packageName := ptr.Deref(lvBuild.Spec.PackageName, "")
+	return slices.ContainsFunc(rule.PackageNames, func(pattern string) bool {
+		matched, _ := path.Match(pattern, packageName)
+		return matched
+	})
+}
+
+// builderImageRef returns the image reference selected by rule.
+func builderImageRef(rule jcrsv1.BuilderImageRule) string {
+	return imageRef(rule.Image, rule.Version)
+}
+
+// imageRef joins an image and its tag or digest.
+func imageRef(image, version string) string {
+	switch {
+	case version == "":
+		return image
+	case strings.HasPrefix(version, "sha256:"):
+		return image + "@" + version
+	default:
+		return image + ":" + version
+	}
+}
+
+// resolveBuilderImage selects the builder image of lvBuild from mappings, which are
+// evaluated in name order. It returns nil when no rule matches.
+func resolveBuilderImage(mappings []jcrsv1.BuilderImageMapping, lvBuild *jcrsv1.LeviathanBuild) *jcrsv1.ResolvedBuilderImage {
+	mappings = slices.Clone(mappings)
+	slices.SortFunc(mappings, func(a, b jcrsv1.BuilderImageMapping) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, mapping := range mappings {
+		for _, rule := range mapping.Spec.Rules {
+			if builderImageRuleMatches(rule, lvBuild) {
+				if canaryImage, ok := selectCanary(&mapping, rule, lvBuild); ok {
+					return &jcrsv1.ResolvedBuilderImage{Image: canaryImage, Mapping: mapping.Name, Canary: true}
+				}
+				return &jcrsv1.ResolvedBuilderImage{Image: builderImageRef(rule), Mapping: mapping.Name}
+			}
+		}
+	}
+	return nil
+}
+
+// reconcileBuilderImage resolves the builder image of lvBuild and records it in the
+// status. It reports whether the Job can be created: builds needing a builder image
+// wait until a mapping provides one.
+func (r *LeviathanBuildReconciler) reconcileBuilderImage(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (bool, error) {
+	if !featuregates.Enabled(featuregates.BuilderImageMappings) || !needsBuilderImage(lvBuild) {
+		lvBuild.Status.BuilderImage = nil
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionBuilderImageResolved)
+		return true, nil
+	}
+
+	var mappings jcrsv1.BuilderImageMappingList
+	if err := r.List(ctx, &mappings); err != nil {
+		return false, err
+	}
+	lvBuild.Status.BuilderImage = resolveBuilderImage(mappings.Items, lvBuild)
+
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionBuilderImageResolved,
+		Status:             metav1.ConditionFalse,
+		Reason:             jcrsv1.ReasonNoMatchingRule,
+		Message:            "No BuilderImageMapping selects a builder image for this package",
+		ObservedGeneration: lvBuild.Generation,
+	}
+	if resolved := lvBuild.Status.BuilderImage; resolved != nil {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = jcrsv1.ReasonResolved
+		condition.Message = "Builder image " + resolved.Image + " selected by BuilderImageMapping " + resolved.Mapping
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, condition)
+	return lvBuild.Status.BuilderImage != nil, nil
+}
+
+// setBuilderImage sets the resolved builder image on the containers and init
+// containers of job that don't set an image, see builderImageRunRef.
+func setBuilderImage(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if lvBuild.Status.BuilderImage == nil {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			if containers[i].Image == "" {
+				containers[i].Image = builderImageRunRef(lvBuild)
+			}
+		}
+	}
+	// The outcome of the Job is counted by the canary rollout of the mapping
+	job.Labels[builderImageMappingLabel] = labelValue(lvBuild.Status.BuilderImage.Mapping)
+	job.Labels[builderImageLabel] = nameHash(lvBuild.Status.BuilderImage.Image)
+	if digest := lvBuild.Status.BuilderImage.Digest; digest != "" {
+		job.Annotations[builderImageDigestAnnotation] = digest
+	}
+}
+
+// indexBuilderImageMapping is the index function for builderImageMappingKey.
+func indexBuilderImageMapping(rawObj client.Object) []string {
+	lvBuild := rawObj.(*jcrsv1.LeviathanBuild)
+	if !needsBuilderImage(lvBuild) {
+		return nil
+	}
+	if lvBuild.Status.BuilderImage == nil {
+		return []string{builderImageUnresolved}
+	}
+	return []string{lvBuild.Status.BuilderImage.Mapping}
+}
+
+// buildsForBuilderImageMapping maps a changed BuilderImageMapping to the builds whose
+// builder image may change: the builds resolved from it or from a mapping evaluated
+// after it, and the unresolved builds.
+func (r *LeviathanBuildReconciler) buildsForBuilderImageMapping(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	var mappings jcrsv1.BuilderImageMappingList
+	if err := r.List(ctx, &mappings); err != nil {
+		log.Error(err, "Unable to list BuilderImageMappings")
+		return nil
+	}
+	keys := []string{builderImageUnresolved, obj.GetName()}
+	for _, mapping := range mappings.Items {
+		if mapping.Name > obj.GetName() {
+			keys = append(keys, mapping.Name)
+		}
+	}
+
+	var requests []reconcile.Request
+	for _, key := range keys {
+		var builds jcrsv1.LeviathanBuildList
+		if err := r.List(ctx, &builds, client.MatchingFields{builderImageMappingKey: key}); err != nil {
+			log.Error(err, "Unable to list LeviathanBuilds", "mapping", key)
+			continue
+		}
+		for _, lvBuild := range builds.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: lvBuild.Namespace,
+				Name:      lvBuild.Name,
+			}})
+		}
+	}
+	return requests
+}
+
+// builderImageSelectionChanged filters out the updates of a BuilderImageMapping
+// that can't change the images it selects: the counts of its canary rollouts
+// change with every finished Job, but only pausing a rollout matters to builds.
+var builderImageSelectionChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldMapping, okOld := e.ObjectOld.(*jcrsv1.BuilderImageMapping)
+		newMapping, okNew := e.ObjectNew.(*jcrsv1.BuilderImageMapping)
+		if !okOld || !okNew || oldMapping.Generation != newMapping.Generation {
+			return true
+		}
+		return !slices.Equal(pausedCanaries(oldMapping), pausedCanaries(newMapping))
+	},
+}
+
+// pausedCanaries returns the canary images of mapping whose rollout is paused.
+func pausedCanaries(mapping *jcrsv1.BuilderImageMapping) []string {
+	var paused []string
+	for _, canary := range mapping.Status.Canaries {
+		if canary.Paused {
+			paused = append(paused, canary.Image)
+		}
+	}
+	return paused
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Builder image selection", func() {
+	newBuild := func(name, language, image string) *jcrsv1.LeviathanBuild {
+		lvBuild := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To(name)},
+		}
+		if language != "" {
+			lvBuild.Spec.Language = ptr.To(language)
+		}
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build", Image: image}}
+		return lvBuild
+	}
+
+	mappings := []jcrsv1.BuilderImageMapping{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "z-default"},
+			Spec: jcrsv1.BuilderImageMappingSpec{Rules: []jcrsv1.BuilderImageRule{
+				{Image: "busybox"},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a-languages"},
+			Spec: jcrsv1.BuilderImageMappingSpec{Rules: []jcrsv1.BuilderImageRule{
+				{PackageNames: []string{"web-*"}, Image: "node", Version: "22"},
+				{Languages: []string{"go"}, Image: "golang", Version: "sha256:abc"},
+			}},
+		},
+	}
+
+	It("selects the first matching rule in mapping name order", func() {
+		Expect(resolveBuilderImage(mappings, newBuild("web-ui", "go", ""))).To(Equal(
+			&jcrsv1.ResolvedBuilderImage{Image: "node:22", Mapping: "a-languages"}))
+		Expect(resolveBuilderImage(mappings, newBuild("api", "go", ""))).To(Equal(
+			&jcrsv1.ResolvedBuilderImage{Image: "golang@sha256:abc", Mapping: "a-languages"}))
+		Expect(resolveBuilderImage(mappings, newBuild("api", "rust", ""))).To(Equal(
+			&jcrsv1.ResolvedBuilderImage{Image: "busybox", Mapping: "z-default"}))
+		Expect(resolveBuilderImage(mappings[1:], newBuild("api", "", ""))).To(BeNil())
+	})
+
+	It("enqueues the builds a changed mapping may affect", func() {
+		resolvedBy := func(lvBuild *jcrsv1.LeviathanBuild, mapping string) *jcrsv1.LeviathanBuild {
+			lvBuild.Status.BuilderImage = &jcrsv1.ResolvedBuilderImage{Image: "busybox", Mapping: mapping}
+			return lvBuild
+		}
+		c := newFakeClientBuilder().
+			WithObjects(&mappings[0], &mappings[1]).
+			WithObjects(
+				resolvedBy(newBuild("by-a", "", ""), "a-languages"),
+				resolvedBy(newBuild("by-z", "", ""), "z-default"),
+				newBuild("unresolved", "", ""),
+				newBuild("fixed-image", "", "busybox"),
+			).
+			WithIndex(&jcrsv1.LeviathanBuild{}, builderImageMappingKey, indexBuilderImageMapping).
+			Build()
+		r := &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+
+		request := func(name string) reconcile.Request {
+			return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+		}
+		Expect(r.buildsForBuilderImageMapping(context.Background(), &mappings[1])).To(ConsistOf(
+			request("unresolved"), request("by-a"), request("by-z")))
+		Expect(r.buildsForBuilderImageMapping(context.Background(), &mappings[0])).To(ConsistOf(
+			request("unresolved"), request("by-z")))
+	})
+})
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const (
+	// builderImageDigestAnnotation records the digest of the builder image a Job
+	// was created with
+	builderImageDigestAnnotation = "jcrs.jcrs.dev/builder-image-digest"
+
+	// builderImagePollFailedReason is the reason of the Event recorded when the
+	// builder image of a build can't be checked
+	builderImagePollFailedReason = "BuilderImagePollFailed"
+)
+
+/*
+The builder images resolved from the BuilderImageMappings are usually tags, which
+move when the image is rebuilt, e.g. to fix a CVE of its base image. Builds watch
+their builder image through the ImagePoller, which checks each image once for all
+the builds running it and sends the builds an event when its digest changes. The
+digest is recorded in status.builderImage.digest and on the Job, and the
+ImageUpdated condition is True while the Job of the latest run was created with
+another digest than the latest.
+
+Builds with a rebuildOnImageChange run the image pinned to its digest: a new
+digest makes the Job outdated, and it's replaced like after a change to the spec.
+Pinning also keeps nodes from running an older image they have cached under the
+tag. Images that are already pinned by the mapping never change and aren't
+watched.
+*/
+
+// reconcileBuilderImageDigest watches the builder image of lvBuild, or stops
+// watching it, and records its latest digest in the status of lvBuild. previous
+// is the builder image recorded before it was resolved again.
+func (r *LeviathanBuildReconciler) reconcileBuilderImageDigest(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, previous *jcrsv1.ResolvedBuilderImage) {
+	if r.ImagePoller == nil {
+		return
+	}
+	key := client.ObjectKeyFromObject(lvBuild)
+	resolved := lvBuild.Status.BuilderImage
+	if resolved == nil || strings.Contains(resolved.Image, "@") {
+		r.ImagePoller.Forget(key)
+		return
+	}
+
+	digest, err := r.ImagePoller.Watch(ctx, key, resolved.Image)
+	if digest == "" && previous != nil && previous.Image == resolved.Image {
+		// An image that can't be checked keeps the last digest found
+		digest = previous.Digest
+	}
+	resolved.Digest = digest
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to check builder image", "image", resolved.Image)
+		r.event(lvBuild, corev1.EventTypeWarning, builderImagePollFailedReason, "Failed to check builder image %s: %v", resolved.Image, err)
+	}
+}
+
+// builderImageRunRef returns the reference of the builder image the Job of
+// lvBuild runs: the resolved image, pinned to its digest when the build is
+// rebuilt on image changes.
+func builderImageRunRef(lvBuild *jcrsv1.LeviathanBuild) string {
+	resolved := lvBuild.Status.BuilderImage
+	if !lvBuild.Spec.RebuildOnImageChange || resolved.Digest == "" || strings.Contains(resolved.Image, "@") {
+		return resolved.Image
+	}
+	return resolved.Image + "@" + resolved.Digest
+}
+
+// setImageUpdated sets the ImageUpdated condition of lvBuild from the digest of
+// the builder image job was created with, and records an Event when the image
+// has just been updated.
+func (r *LeviathanBuildReconciler) setImageUpdated(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	resolved := lvBuild.Status.BuilderImage
+	ran := job.Annotations[builderImageDigestAnnotation]
+	if resolved == nil || resolved.Digest == "" || ran == "" {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionImageUpdated)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionImageUpdated,
+		Status:             metav1.ConditionFalse,
+		Reason:             jcrsv1.ReasonImageCurrent,
+		Message:            "Job " + job.Name + " runs the latest digest of builder image " + resolved.Image,
+		ObservedGeneration: lvBuild.Generation,
+	}
+	if ran != resolved.Digest {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = jcrsv1.ReasonDigestChanged
+		condition.Message = "Builder image " + resolved.Image + " changed from " + ran + " to " + resolved.Digest
+		if !meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionImageUpdated) {
+			r.event(lvBuild, corev1.EventTypeNormal, jcrsv1.ReasonDigestChanged, "Builder image %s changed to %s", resolved.Image, resolved.Digest)
+		}
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, condition)
+}
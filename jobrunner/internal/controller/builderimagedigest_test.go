@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/record"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/imagepoll"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+// staticResolver returns digest, or err, and counts the images it resolves.
+type staticResolver struct {
+	digest string
+	err    error
+	calls  int
+}
+
+func (s *staticResolver) Digest(_ context.Context, _ string) (string, error) {
+	s.calls++
+	return s.digest, s.err
+}
+
+var _ = Describe("Builder image digests", func() {
+	var (
+		ctx      context.Context
+		resolver *staticResolver
+		recorder *record.FakeRecorder
+		r        *LeviathanBuildReconciler
+		lvBuild  *jcrsv1.LeviathanBuild
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		resolver = &staticResolver{digest: "sha256:aaa"}
+		recorder = record.NewFakeRecorder(10)
+		r = &LeviathanBuildReconciler{
+			Scheme:      newTestScheme(),
+			Recorder:    recorder,
+			ImagePoller: imagepoll.NewPoller(resolver, time.Hour),
+		}
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "images").Obj()
+		lvBuild.Status.BuilderImage = &jcrsv1.ResolvedBuilderImage{Image: "registry.example.com/go:1.24", Mapping: "go"}
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+	})
+
+	It("reports the builds whose latest run used an older digest", func() {
+		r.reconcileBuilderImageDigest(ctx, lvBuild, nil)
+		Expect(lvBuild.Status.BuilderImage.Digest).To(Equal("sha256:aaa"))
+		job, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		job.Name = "web-1"
+		Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal("registry.example.com/go:1.24"))
+		Expect(job.Annotations).To(HaveKeyWithValue(builderImageDigestAnnotation, "sha256:aaa"))
+
+		r.setImageUpdated(lvBuild, job)
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionImageUpdated)).To(HaveField("Reason", jcrsv1.ReasonImageCurrent))
+
+		By("leaving the Job in place when the digest changes")
+		lvBuild.Status.BuilderImage.Digest = "sha256:bbb"
+		desired, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diffJobSpecs(&job.Spec, &desired.Spec)).To(BeEmpty())
+		r.setImageUpdated(lvBuild, job)
+		Expect(meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionImageUpdated)).To(BeTrue())
+		Expect(recorder.Events).To(Receive(Equal("Normal DigestChanged Builder image registry.example.com/go:1.24 changed to sha256:bbb")))
+
+		r.setImageUpdated(lvBuild, job)
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("runs the build again on the new digest with rebuildOnImageChange", func() {
+		lvBuild.Spec.RebuildOnImageChange = true
+		r.reconcileBuilderImageDigest(ctx, lvBuild, nil)
+		job, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal("registry.example.com/go:1.24@sha256:aaa"))
+
+		lvBuild.Status.BuilderImage.Digest = "sha256:bbb"
+		desired, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diffJobSpecs(&job.Spec, &desired.Spec)).To(ConsistOf("template.spec.containers[build].image"))
+	})
+
+	It("keeps the last digest when the image can't be checked", func() {
+		resolver.err = errors.New("connection refused")
+		previous := &jcrsv1.ResolvedBuilderImage{Image: "registry.example.com/go:1.24", Mapping: "go", Digest: "sha256:aaa"}
+		r.reconcileBuilderImageDigest(ctx, lvBuild, previous)
+		Expect(lvBuild.Status.BuilderImage.Digest).To(Equal("sha256:aaa"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning BuilderImagePollFailed Failed to check builder image registry.example.com/go:1.24: connection refused")))
+
+		By("dropping it when the mapping selects another image")
+		lvBuild.Status.BuilderImage.Image = "registry.example.com/go:1.25"
+		r.reconcileBuilderImageDigest(ctx, lvBuild, previous)
+		Expect(lvBuild.Status.BuilderImage.Digest).To(BeEmpty())
+	})
+
+	It("doesn't check images pinned by digest", func() {
+		lvBuild.Status.BuilderImage.Image = "registry.example.com/go@sha256:ccc"
+		lvBuild.Spec.RebuildOnImageChange = true
+		r.reconcileBuilderImageDigest(ctx, lvBuild, nil)
+		Expect(resolver.calls).To(BeZero())
+		job, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal("registry.example.com/go@sha256:ccc"))
+		Expect(job.Annotations).NotTo(HaveKey(builderImageDigestAnnotation))
+	})
+})
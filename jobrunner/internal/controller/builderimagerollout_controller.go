@@ -0,0 +1,133 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// BuilderImageRolloutReconciler tracks the canary rollouts of BuilderImageMapping objects
+type BuilderImageRolloutReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=builderimagemappings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=builderimagemappings/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch
+
+// Reconcile counts the outcomes of the Jobs of every canary rollout of a
+// BuilderImageMapping, and pauses the rollouts whose canary fails too often.
+func (r *BuilderImageRolloutReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var mapping jcrsv1.BuilderImageMapping
+	if err := r.Get(ctx, req.NamespacedName, &mapping); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.MatchingLabels{builderImageMappingLabel: labelValue(mapping.Name)}); err != nil {
+		log.Error(err, "Failed to list Jobs")
+		return ctrl.Result{}, err
+	}
+
+	// Rollouts are tracked by canary image, and start over when it changes
+	now := metav1.Now()
+	var canaries []jcrsv1.CanaryStatus
+	for _, rule := range mapping.Spec.Rules {
+		if rule.Canary == nil {
+			continue
+		}
+		image := imageRef(rule.Canary.Image, rule.Canary.Version)
+		// Rules sharing a canary image share its rollout
+		if slices.ContainsFunc(canaries, func(c jcrsv1.CanaryStatus) bool { return c.Image == image }) {
+			continue
+		}
+
+		status := jcrsv1.CanaryStatus{Image: image, StartTime: now}
+		if existing := findCanaryStatus(&mapping, image); existing != nil {
+			status = *existing.DeepCopy()
+		}
+		wasPaused := status.Paused
+		evaluateCanary(&status, rule, jobs.Items)
+		if status.Paused && !wasPaused {
+			log.Info("Pausing canary rollout", "image", image, "reason", status.Message)
+		}
+		canaries = append(canaries, status)
+	}
+
+	if equality.Semantic.DeepEqual(canaries, mapping.Status.Canaries) {
+		return ctrl.Result{}, nil
+	}
+	mapping.Status.Canaries = canaries
+	if err := r.Status().Update(ctx, &mapping); err != nil {
+		log.Error(err, "unable to update BuilderImageMapping status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// mappingForJob maps a Job to the BuilderImageMapping its builder image was resolved from.
+func (r *BuilderImageRolloutReconciler) mappingForJob(ctx context.Context, obj client.Object) []reconcile.Request {
+	label := obj.GetLabels()[builderImageMappingLabel]
+	if label == "" {
+		return nil
+	}
+
+	// Long mapping names are hashed in the label, so the mapping is looked up
+	var mappings jcrsv1.BuilderImageMappingList
+	if err := r.List(ctx, &mappings); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list BuilderImageMappings")
+		return nil
+	}
+	for _, mapping := range mappings.Items {
+		if labelValue(mapping.Name) == label {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: mapping.Name}}}
+		}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BuilderImageRolloutReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Only Jobs whose outcome may count for a rollout are of interest
+	hasMapping := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[builderImageMappingLabel] != ""
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jcrsv1.BuilderImageMapping{}).
+		Watches(&batchv1.Job{}, handler.EnqueueRequestsFromMapFunc(r.mappingForJob), builder.WithPredicates(hasMapping)).
+		Named("builderimagerollout").
+		Complete(r)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	batchv1 "k8s.io/api/batch/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+A rule of a BuilderImageMapping may roll out a canary image to a percentage of the
+builds it matches. Builds are assigned to the canary by a hash of their namespace
+and name, so the assignment of a build doesn't change from one reconcile to the
+next, and raising the percentage only adds builds to the canary.
+
+Every Job is labeled with the mapping and a hash of the image it runs. The
+BuilderImageRollout controller counts the outcomes of the Jobs created since the
+rollout started on both images, and pauses the rollout when the canary fails more
+often than the image of the rule by more than the tolerated margin. A paused
+canary is no longer selected, so its builds are rolled back to the image of the
+rule like on any other change of a mapping.
+*/
+
+// canaryBucket returns the bucket, between 0 and 99, of lvBuild for the rollout of image.
+func canaryBucket(lvBuild *jcrsv1.LeviathanBuild, image string) int32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(lvBuild.Namespace + "/" + lvBuild.Name + "/" + image))
+	return int32(h.Sum32() % 100)
+}
+
+// findCanaryStatus returns the rollout status of image in mapping, or nil.
+func findCanaryStatus(mapping *jcrsv1.BuilderImageMapping, image string) *jcrsv1.CanaryStatus {
+	for i := range mapping.Status.Canaries {
+		if mapping.Status.Canaries[i].Image == image {
+			return &mapping.Status.Canaries[i]
+		}
+	}
+	return nil
+}
+
+// selectCanary returns the canary image of rule when lvBuild is assigned to it.
+func selectCanary(mapping *jcrsv1.BuilderImageMapping, rule jcrsv1.BuilderImageRule, lvBuild *jcrsv1.LeviathanBuild) (string, bool) {
+	if rule.Canary == nil || !featuregates.Enabled(featuregates.BuilderImageCanaries) {
+		return "", false
+	}
+	image := imageRef(rule.Canary.Image, rule.Canary.Version)
+	if status := findCanaryStatus(mapping, image); status != nil && status.Paused {
+		return "", false
+	}
+	return image, canaryBucket(lvBuild, image) < rule.Canary.Percent
+}
+
+// countOutcome adds the outcome of job to outcomes, if it has finished.
+func countOutcome(outcomes *jcrsv1.BuildOutcomes, job *batchv1.Job) {
+	switch _, finishedType := isJobFinished(job); finishedType {
+	case batchv1.JobComplete:
+		outcomes.Succeeded++
+	case batchv1.JobFailed:
+		outcomes.Failed++
+	}
+}
+
+// failureRate returns the percentage of failed Jobs in outcomes.
+func failureRate(outcomes jcrsv1.BuildOutcomes) float64 {
+	finished := outcomes.Succeeded + outcomes.Failed
+	if finished == 0 {
+		return 0
+	}
+	return 100 * float64(outcomes.Failed) / float64(finished)
+}
+
+// evaluateCanary counts the Jobs of the rollout of the canary of rule, and pauses
+// the rollout when the canary image fails too often. jobs are the Jobs whose builder
+// image was resolved from the mapping.
+func evaluateCanary(status *jcrsv1.CanaryStatus, rule jcrsv1.BuilderImageRule, jobs []batchv1.Job) {
+	canaryHash, stableHash := nameHash(status.Image), nameHash(builderImageRef(rule))
+	status.Canary, status.Stable = jcrsv1.BuildOutcomes{}, jcrsv1.BuildOutcomes{}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.CreationTimestamp.Before(&status.StartTime) {
+			continue
+		}
+		switch job.Labels[builderImageLabel] {
+		case canaryHash:
+			countOutcome(&status.Canary, job)
+		case stableHash:
+			countOutcome(&status.Stable, job)
+		}
+	}
+	if status.Paused {
+		return
+	}
+
+	canaryRate, stableRate := failureRate(status.Canary), failureRate(status.Stable)
+	minBuilds := max(rule.Canary.MinBuilds, 1)
+	if status.Canary.Succeeded+status.Canary.Failed < minBuilds || status.Stable.Succeeded+status.Stable.Failed < minBuilds {
+		status.Message = fmt.Sprintf("Waiting for %d finished builds on both images", minBuilds)
+		return
+	}
+	if canaryRate-stableRate > float64(rule.Canary.MaxFailureRateIncrease) {
+		status.Paused = true
+		status.Message = fmt.Sprintf("Paused: %.0f%% of the canary builds failed, against %.0f%% on %s", canaryRate, stableRate, builderImageRef(rule))
+		return
+	}
+	status.Message = fmt.Sprintf("Healthy: %.0f%% of the canary builds failed, against %.0f%% on %s", canaryRate, stableRate, builderImageRef(rule))
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+var _ = Describe("Canary builder images", func() {
+	var mapping *jcrsv1.BuilderImageMapping
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.BuilderImageCanaries: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.BuilderImageCanaries: false})).To(Succeed())
+		})
+
+		mapping = &jcrsv1.BuilderImageMapping{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Generation: 1},
+			Spec: jcrsv1.BuilderImageMappingSpec{Rules: []jcrsv1.BuilderImageRule{{
+				Image:   "builder",
+				Version: "1",
+				Canary:  &jcrsv1.BuilderImageCanary{Image: "builder", Version: "2", Percent: 25, MinBuilds: 4, MaxFailureRateIncrease: 10},
+			}}},
+		}
+	})
+
+	newBuild := func(i int) *jcrsv1.LeviathanBuild {
+		lvBuild := &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("build-%d", i), Namespace: "default"}}
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+		return lvBuild
+	}
+
+	// canaryBuilds returns how many of 1000 builds run the canary image
+	canaryBuilds := func() int {
+		count := 0
+		for i := range 1000 {
+			if resolved := resolveBuilderImage([]jcrsv1.BuilderImageMapping{*mapping}, newBuild(i)); resolved.Canary {
+				Expect(resolved.Image).To(Equal("builder:2"))
+				count++
+			} else {
+				Expect(resolved.Image).To(Equal("builder:1"))
+			}
+		}
+		return count
+	}
+
+	It("assigns the configured share of builds to the canary", func() {
+		Expect(canaryBuilds()).To(BeNumerically("~", 250, 50))
+
+		By("keeping the builds already on the canary when the share grows")
+		var before []string
+		for i := range 100 {
+			if resolveBuilderImage([]jcrsv1.BuilderImageMapping{*mapping}, newBuild(i)).Canary {
+				before = append(before, newBuild(i).Name)
+			}
+		}
+		mapping.Spec.Rules[0].Canary.Percent = 50
+		for _, name := range before {
+			lvBuild := newBuild(0)
+			lvBuild.Name = name
+			Expect(resolveBuilderImage([]jcrsv1.BuilderImageMapping{*mapping}, lvBuild).Canary).To(BeTrue())
+		}
+
+		By("not selecting a paused canary")
+		mapping.Status.Canaries = []jcrsv1.CanaryStatus{{Image: "builder:2", Paused: true}}
+		Expect(canaryBuilds()).To(BeZero())
+	})
+
+	It("ignores canaries when the feature is disabled", func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.BuilderImageCanaries: false})).To(Succeed())
+		Expect(canaryBuilds()).To(BeZero())
+	})
+
+	Context("when tracking the rollout", func() {
+		var (
+			c     client.Client
+			r     *BuilderImageRolloutReconciler
+			start time.Time
+			jobs  int
+		)
+
+		BeforeEach(func() {
+			c = newFakeClient(mapping)
+			r = &BuilderImageRolloutReconciler{Client: c, Scheme: c.Scheme()}
+			start, jobs = time.Now().Add(-time.Hour), 0
+		})
+
+		addJobs := func(image string, finished batchv1.JobConditionType, count int, created time.Time) {
+			for range count {
+				jobs++
+				job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+					Name:              fmt.Sprintf("job-%d", jobs),
+					Namespace:         "default",
+					CreationTimestamp: metav1.NewTime(created),
+					Labels:            map[string]string{builderImageMappingLabel: "default", builderImageLabel: nameHash(image)},
+				}}
+				job.Status.Conditions = []batchv1.JobCondition{{Type: finished, Status: corev1.ConditionTrue}}
+				Expect(c.Create(context.Background(), job)).To(Succeed())
+			}
+		}
+		reconcileRollout := func() jcrsv1.CanaryStatus {
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(mapping)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(mapping), mapping)).To(Succeed())
+			Expect(mapping.Status.Canaries).To(HaveLen(1))
+			return mapping.Status.Canaries[0]
+		}
+
+		It("pauses the rollout when the canary fails more often", func() {
+			status := reconcileRollout()
+			Expect(status.Image).To(Equal("builder:2"))
+			Expect(status.Paused).To(BeFalse())
+
+			// The start time is moved back so the Jobs below count
+			mapping.Status.Canaries[0].StartTime = metav1.NewTime(start)
+			Expect(c.Status().Update(context.Background(), mapping)).To(Succeed())
+
+			addJobs("builder:1", batchv1.JobComplete, 9, start.Add(time.Minute))
+			addJobs("builder:1", batchv1.JobFailed, 1, start.Add(time.Minute))
+			addJobs("builder:1", batchv1.JobFailed, 5, start.Add(-time.Minute))
+			addJobs("builder:2", batchv1.JobComplete, 2, start.Add(time.Minute))
+			addJobs("builder:2", batchv1.JobFailed, 1, start.Add(time.Minute))
+			status = reconcileRollout()
+			Expect(status.Stable).To(Equal(jcrsv1.BuildOutcomes{Succeeded: 9, Failed: 1}))
+			Expect(status.Canary).To(Equal(jcrsv1.BuildOutcomes{Succeeded: 2, Failed: 1}))
+			Expect(status.Paused).To(BeFalse())
+			Expect(status.Message).To(ContainSubstring("Waiting for 4 finished builds"))
+
+			addJobs("builder:2", batchv1.JobFailed, 1, start.Add(time.Minute))
+			status = reconcileRollout()
+			Expect(status.Paused).To(BeTrue())
+			Expect(status.Message).To(ContainSubstring("50% of the canary builds failed, against 10%"))
+
+			By("starting over when the canary image changes")
+			mapping.Spec.Rules[0].Canary.Version = "3"
+			Expect(c.Update(context.Background(), mapping)).To(Succeed())
+			status = reconcileRollout()
+			Expect(status.Image).To(Equal("builder:3"))
+			Expect(status.Paused).To(BeFalse())
+			Expect(status.Stable).To(BeZero())
+		})
+
+		It("only re-resolves builds when a rollout is paused", func() {
+			updated := mapping.DeepCopy()
+			updated.Status.Canaries = []jcrsv1.CanaryStatus{{Image: "builder:2", Canary: jcrsv1.BuildOutcomes{Succeeded: 1}}}
+			Expect(builderImageSelectionChanged.Update(event.UpdateEvent{ObjectOld: mapping, ObjectNew: updated})).To(BeFalse())
+
+			paused := updated.DeepCopy()
+			paused.Status.Canaries[0].Paused = true
+			Expect(builderImageSelectionChanged.Update(event.UpdateEvent{ObjectOld: updated, ObjectNew: paused})).To(BeTrue())
+
+			updated.Generation = 2
+			Expect(builderImageSelectionChanged.Update(event.UpdateEvent{ObjectOld: mapping, ObjectNew: updated})).To(BeTrue())
+		})
+	})
+
+	It("labels Jobs with the resolved builder image", func() {
+		lvBuild := newBuild(0)
+		lvBuild.Status.BuilderImage = &jcrsv1.ResolvedBuilderImage{Image: "builder:2", Mapping: "default", Canary: true}
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+		setBuilderImage(lvBuild, job)
+		Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal("builder:2"))
+		Expect(job.Labels).To(HaveKeyWithValue(builderImageMappingLabel, "default"))
+		Expect(job.Labels).To(HaveKeyWithValue(builderImageLabel, nameHash("builder:2")))
+	})
+})
@@ -0,0 +1,294 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A run that fails because the cluster ran out of room, its pods evicted under node
+pressure, preempted, rejected by their node or never scheduled for insufficient
+resources, would most likely fail again if it were retried right away. The build
+is held in WaitingForCapacity instead, and run again once a node recovers: a node
+that becomes Ready or schedulable, loses a pressure condition, gains allocatable
+resources, or joins the cluster.
+
+Disrupted pods fail the Job right away through a pod failure policy, rather than
+counting against its backoffLimit. Recoveries are only known while the controller
+runs: a build waiting when the controller restarts waits for the next one.
+*/
+
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+
+const (
+	// waitingForCapacityKey indexes the LeviathanBuilds waiting for capacity
+	waitingForCapacityKey = ".status.conditions.waitingForCapacity"
+
+	// capacityRecoveredReason is the reason of the Event recorded when a build
+	// waiting for capacity runs again
+	capacityRecoveredReason = "CapacityRecovered"
+
+	// evictedReason is the reason kubelets set on the pods they evict under node pressure
+	evictedReason = "Evicted"
+)
+
+// pressureConditions are the node conditions under which kubelets evict pods.
+var pressureConditions = []corev1.NodeConditionType{
+	corev1.NodeMemoryPressure,
+	corev1.NodeDiskPressure,
+	corev1.NodePIDPressure,
+}
+
+// CapacityMonitor records when the nodes of the cluster last recovered capacity.
+type CapacityMonitor struct {
+	mu        sync.Mutex
+	started   time.Time
+	recovered time.Time
+}
+
+// NewCapacityMonitor returns a CapacityMonitor. Nodes created before now don't
+// count as recoveries, they are listed when the controller starts.
+func NewCapacityMonitor() *CapacityMonitor {
+	return &CapacityMonitor{started: time.Now()}
+}
+
+// recover records a recovery at now.
+func (m *CapacityMonitor) recover(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.After(m.recovered) {
+		m.recovered = now
+	}
+}
+
+// recoveredSince reports whether capacity recovered after t.
+func (m *CapacityMonitor) recoveredSince(t time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.recovered.After(t)
+}
+
+// predicate returns the node events that signal a recovery of capacity.
+func (m *CapacityMonitor) predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			node, ok := e.Object.(*corev1.Node)
+			return ok && node.CreationTimestamp.After(m.started) && nodeHasCapacity(node)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			return ok && nodeCapacityRecovered(oldNode, newNode)
+		},
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// nodeHasCapacity reports whether pods may be scheduled on node.
+func nodeHasCapacity(node *corev1.Node) bool {
+	if node.Spec.Unschedulable || nodeCondition(node, corev1.NodeReady) != corev1.ConditionTrue {
+		return false
+	}
+	for _, conditionType := range pressureConditions {
+		if nodeCondition(node, conditionType) == corev1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeCapacityRecovered reports whether newNode has more room for pods than oldNode.
+func nodeCapacityRecovered(oldNode, newNode *corev1.Node) bool {
+	if !nodeHasCapacity(newNode) {
+		return false
+	}
+	if !nodeHasCapacity(oldNode) {
+		return true
+	}
+	for name, quantity := range newNode.Status.Allocatable {
+		if previous, ok := oldNode.Status.Allocatable[name]; !ok || quantity.Cmp(previous) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeCondition returns the status of the condition of conditionType of node.
+func nodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) corev1.ConditionStatus {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status
+		}
+	}
+	return corev1.ConditionUnknown
+}
+
+// addCapacityFailurePolicy fails the build Job as soon as one of its pods is
+// disrupted, so evictions don't count against its backoffLimit.
+func (r *LeviathanBuildReconciler) addCapacityFailurePolicy(job *batchv1.Job) {
+	// Pod failure policies are only supported for pods that aren't restarted in place
+	if r.Capacity == nil || job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		return
+	}
+	if job.Spec.PodFailurePolicy == nil {
+		job.Spec.PodFailurePolicy = &batchv1.PodFailurePolicy{}
+	}
+	// Builds that may run on spot nodes already fail on disruptions
+	for _, rule := range job.Spec.PodFailurePolicy.Rules {
+		for _, pattern := range rule.OnPodConditions {
+			if rule.Action == batchv1.PodFailurePolicyActionFailJob && pattern.Type == corev1.DisruptionTarget {
+				return
+			}
+		}
+	}
+	job.Spec.PodFailurePolicy.Rules = append([]batchv1.PodFailurePolicyRule{{
+		Action: batchv1.PodFailurePolicyActionFailJob,
+		OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{
+			{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue},
+		},
+	}}, job.Spec.PodFailurePolicy.Rules...)
+}
+
+// capacityFailure returns why the failed job ran out of capacity, or "" when
+// it failed for another reason.
+func (r *LeviathanBuildReconciler) capacityFailure(ctx context.Context, job *batchv1.Job) (string, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		switch {
+		case pod.Status.Reason == evictedReason:
+			return fmt.Sprintf("pod %s was evicted: %s", pod.Name, pod.Status.Message), nil
+		case strings.HasPrefix(pod.Status.Reason, "OutOf"):
+			return fmt.Sprintf("pod %s was rejected by its node: %s", pod.Name, pod.Status.Message), nil
+		}
+		for _, condition := range pod.Status.Conditions {
+			switch {
+			case condition.Type == corev1.DisruptionTarget && condition.Status == corev1.ConditionTrue &&
+				(condition.Reason == corev1.PodReasonTerminationByKubelet || condition.Reason == corev1.PodReasonPreemptionByScheduler):
+				return fmt.Sprintf("pod %s was disrupted: %s", pod.Name, condition.Message), nil
+			case condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse &&
+				condition.Reason == corev1.PodReasonUnschedulable && strings.Contains(condition.Message, "Insufficient"):
+				return fmt.Sprintf("pod %s couldn't be scheduled: %s", pod.Name, condition.Message), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// waitForCapacity reports whether job, the finished Job of the latest run of
+// lvBuild, failed for lack of capacity and the build waits for the nodes to
+// recover, or whether they recovered and the build should be run again.
+func (r *LeviathanBuildReconciler) waitForCapacity(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, finishedType batchv1.JobConditionType) (waiting, rerun bool, err error) {
+	if r.Capacity == nil {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForCapacity)
+		return false, false, nil
+	}
+	if finishedType != batchv1.JobFailed {
+		return false, false, nil
+	}
+
+	condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForCapacity)
+	if condition != nil && condition.Status == metav1.ConditionTrue {
+		if r.Capacity.recoveredSince(condition.LastTransitionTime.Time) {
+			return false, true, nil
+		}
+		return true, false, nil
+	}
+
+	failure, err := r.capacityFailure(ctx, job)
+	if err != nil || failure == "" {
+		return false, false, err
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionWaitingForCapacity,
+		Status:             metav1.ConditionTrue,
+		Reason:             jcrsv1.ReasonInsufficientCapacity,
+		Message:            "Job " + job.Name + " ran out of capacity, " + failure,
+		ObservedGeneration: lvBuild.Generation,
+	})
+	return true, false, nil
+}
+
+// endCapacityWait records that lvBuild, if it was waiting for capacity, runs again.
+func (r *LeviathanBuildReconciler) endCapacityWait(lvBuild *jcrsv1.LeviathanBuild) {
+	condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForCapacity)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return
+	}
+	waited := time.Since(condition.LastTransitionTime.Time)
+	capacityWaitSeconds.WithLabelValues(lvBuild.Namespace).Observe(waited.Seconds())
+	r.event(lvBuild, corev1.EventTypeNormal, capacityRecoveredReason, "Running the build again after waiting %s for capacity", waited.Round(time.Second))
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionWaitingForCapacity,
+		Status:             metav1.ConditionFalse,
+		Reason:             jcrsv1.ReasonCapacityRecovered,
+		Message:            "The build runs again",
+		ObservedGeneration: lvBuild.Generation,
+	})
+}
+
+// indexWaitingForCapacity is the index function for waitingForCapacityKey.
+func indexWaitingForCapacity(rawObj client.Object) []string {
+	lvBuild := rawObj.(*jcrsv1.LeviathanBuild)
+	if !meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForCapacity) {
+		return nil
+	}
+	return []string{"true"}
+}
+
+// buildsForRecoveredNode records the recovery of capacity signalled by a node,
+// and maps it to the builds waiting for capacity.
+func (r *LeviathanBuildReconciler) buildsForRecoveredNode(ctx context.Context, _ client.Object) []reconcile.Request {
+	r.Capacity.recover(time.Now())
+	var builds jcrsv1.LeviathanBuildList
+	if err := r.List(ctx, &builds, client.MatchingFields{waitingForCapacityKey: "true"}); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list LeviathanBuilds waiting for capacity")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(builds.Items))
+	for _, lvBuild := range builds.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: lvBuild.Namespace,
+			Name:      lvBuild.Name,
+		}})
+	}
+	return requests
+}
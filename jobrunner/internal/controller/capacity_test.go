@@ -0,0 +1,195 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Capacity waits", func() {
+	var (
+		ctx     context.Context
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+		r       *LeviathanBuildReconciler
+	)
+
+	node := func(ready corev1.ConditionStatus, cpu string, pressure ...corev1.NodeConditionType) *corev1.Node {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}}
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}
+		for _, conditionType := range pressure {
+			n.Status.Conditions = append(n.Status.Conditions, corev1.NodeCondition{Type: conditionType, Status: corev1.ConditionTrue})
+		}
+		n.Status.Allocatable = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+		return n
+	}
+	withPods := func(pods ...*corev1.Pod) {
+		builder := newFakeClientBuilder()
+		for _, pod := range pods {
+			pod.Namespace = "default"
+			pod.Labels = map[string]string{batchv1.JobNameLabel: job.Name}
+			builder = builder.WithObjects(pod)
+		}
+		r.Client = builder.Build()
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "default").Obj()
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1-abcde", Namespace: "default"}}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		r = &LeviathanBuildReconciler{Capacity: NewCapacityMonitor()}
+	})
+
+	It("signals a recovery when a node gets room for pods again", func() {
+		Expect(nodeCapacityRecovered(node(corev1.ConditionFalse, "4"), node(corev1.ConditionTrue, "4"))).To(BeTrue())
+		Expect(nodeCapacityRecovered(node(corev1.ConditionTrue, "4", corev1.NodeMemoryPressure), node(corev1.ConditionTrue, "4"))).To(BeTrue())
+		Expect(nodeCapacityRecovered(node(corev1.ConditionTrue, "4"), node(corev1.ConditionTrue, "8"))).To(BeTrue())
+
+		Expect(nodeCapacityRecovered(node(corev1.ConditionTrue, "4"), node(corev1.ConditionTrue, "4"))).To(BeFalse())
+		Expect(nodeCapacityRecovered(node(corev1.ConditionTrue, "8"), node(corev1.ConditionTrue, "4"))).To(BeFalse())
+		Expect(nodeCapacityRecovered(node(corev1.ConditionFalse, "4"), node(corev1.ConditionTrue, "4", corev1.NodeDiskPressure))).To(BeFalse())
+		cordoned := node(corev1.ConditionTrue, "4")
+		cordoned.Spec.Unschedulable = true
+		Expect(nodeCapacityRecovered(cordoned, node(corev1.ConditionTrue, "4"))).To(BeTrue())
+	})
+
+	It("only counts the nodes joining the cluster once the controller runs", func() {
+		joined := node(corev1.ConditionTrue, "4")
+		joined.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Minute))
+		Expect(r.Capacity.predicate().Create(event.CreateEvent{Object: joined})).To(BeTrue())
+
+		listed := node(corev1.ConditionTrue, "4")
+		listed.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		Expect(r.Capacity.predicate().Create(event.CreateEvent{Object: listed})).To(BeFalse())
+	})
+
+	It("fails the Job of a disrupted pod once, whatever the spot policy", func() {
+		r.addCapacityFailurePolicy(job)
+		Expect(job.Spec.PodFailurePolicy.Rules).To(HaveLen(1))
+		Expect(job.Spec.PodFailurePolicy.Rules[0].Action).To(Equal(batchv1.PodFailurePolicyActionFailJob))
+
+		r.addCapacityFailurePolicy(job)
+		Expect(job.Spec.PodFailurePolicy.Rules).To(HaveLen(1))
+
+		restarted := &batchv1.Job{}
+		restarted.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+		r.addCapacityFailurePolicy(restarted)
+		Expect(restarted.Spec.PodFailurePolicy).To(BeNil())
+	})
+
+	DescribeTable("tells capacity failures apart from other failures",
+		func(pod *corev1.Pod, reason string) {
+			pod.Name = "web-1-abcde-x"
+			withPods(pod)
+			failure, err := r.capacityFailure(ctx, job)
+			Expect(err).NotTo(HaveOccurred())
+			if reason == "" {
+				Expect(failure).To(BeEmpty())
+			} else {
+				Expect(failure).To(ContainSubstring(reason))
+			}
+		},
+		Entry("evicted under node pressure",
+			&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted", Message: "The node was low on resource: memory."}},
+			"was evicted: The node was low on resource: memory."),
+		Entry("rejected by its node",
+			&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "OutOfcpu"}},
+			"was rejected by its node"),
+		Entry("preempted",
+			&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed, Conditions: []corev1.PodCondition{{
+				Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue, Reason: corev1.PodReasonPreemptionByScheduler,
+			}}}},
+			"was disrupted"),
+		Entry("unschedulable for insufficient resources",
+			&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending, Conditions: []corev1.PodCondition{{
+				Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient memory.",
+			}}}},
+			"couldn't be scheduled: 0/3 nodes are available: 3 Insufficient memory."),
+		Entry("failed build step",
+			&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed}},
+			""),
+		Entry("unschedulable for a node selector",
+			&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending, Conditions: []corev1.PodCondition{{
+				Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector.",
+			}}}},
+			""),
+	)
+
+	It("holds a build out of capacity until a node recovers", func() {
+		withPods(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1-abcde-x"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"},
+		})
+
+		waiting, rerun, err := r.waitForCapacity(ctx, lvBuild, job, batchv1.JobFailed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(waiting).To(BeTrue())
+		Expect(rerun).To(BeFalse())
+		condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForCapacity)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(jcrsv1.ReasonInsufficientCapacity))
+
+		// The recovery is only signalled once the build waits
+		condition.LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Minute))
+		waiting, rerun, err = r.waitForCapacity(ctx, lvBuild, job, batchv1.JobFailed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(waiting).To(BeTrue())
+		Expect(rerun).To(BeFalse())
+
+		r.Capacity.recover(time.Now())
+		waiting, rerun, err = r.waitForCapacity(ctx, lvBuild, job, batchv1.JobFailed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(waiting).To(BeFalse())
+		Expect(rerun).To(BeTrue())
+
+		r.endCapacityWait(lvBuild)
+		condition = meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForCapacity)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(jcrsv1.ReasonCapacityRecovered))
+		Expect(indexWaitingForCapacity(lvBuild)).To(BeEmpty())
+	})
+
+	It("leaves the failures of builds alone when disabled", func() {
+		meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+			Type: jcrsv1.ConditionWaitingForCapacity, Status: metav1.ConditionTrue, Reason: jcrsv1.ReasonInsufficientCapacity,
+		})
+		Expect(indexWaitingForCapacity(lvBuild)).To(Equal([]string{"true"}))
+
+		r.Capacity = nil
+		waiting, rerun, err := r.waitForCapacity(ctx, lvBuild, job, batchv1.JobFailed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(waiting).To(BeFalse())
+		Expect(rerun).To(BeFalse())
+		Expect(lvBuild.Status.Conditions).To(BeEmpty())
+	})
+})
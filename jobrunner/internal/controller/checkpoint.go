@@ -0,0 +1,163 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Builds with a checkpoint get a persistent volume, mounted at $CHECKPOINT_DIR,
+that the builder image writes its progress to. When a run fails, the controller
+runs the build again with RESUME_FROM_CHECKPOINT set, so the builder picks up
+where it left off instead of starting over. The number of resumes is bounded by
+the build and recorded in its status; it starts over when the spec changes.
+
+The claim is created by the controller unless the build names its own, and is
+owned by the build so that checkpoints go away with it.
+*/
+
+const (
+	checkpointVolumeName = "checkpoint"
+	checkpointMountPath  = "/var/run/leviathan/checkpoint"
+
+	// checkpointDirEnv tells the builder image where to write its checkpoints
+	checkpointDirEnv = "CHECKPOINT_DIR"
+
+	// resumeFromCheckpointEnv is set on the runs resuming from a checkpoint
+	resumeFromCheckpointEnv = "RESUME_FROM_CHECKPOINT"
+
+	// resumedFromCheckpointReason is the reason of the Event recorded when a run is resumed
+	resumedFromCheckpointReason = "ResumedFromCheckpoint"
+
+	// checkpointClaimSuffix is appended to the name of the build to name its claim
+	checkpointClaimSuffix = "-checkpoint"
+)
+
+// defaultCheckpointSize is the size of the claims created without an explicit size.
+var defaultCheckpointSize = resource.MustParse("10Gi")
+
+// checkpointEnabled reports whether lvBuild has a checkpoint volume.
+func checkpointEnabled(lvBuild *jcrsv1.LeviathanBuild) bool {
+	return lvBuild.Spec.Checkpoint != nil && lvBuild.Spec.Checkpoint.Enabled
+}
+
+// checkpointClaimName returns the name of the claim holding the checkpoints of lvBuild.
+func checkpointClaimName(lvBuild *jcrsv1.LeviathanBuild) string {
+	if name := lvBuild.Spec.Checkpoint.ClaimName; name != "" {
+		return name
+	}
+	return shortenName(lvBuild.Name, validation.DNS1123SubdomainMaxLength-len(checkpointClaimSuffix)) + checkpointClaimSuffix
+}
+
+// reconcileCheckpointClaim creates the claim holding the checkpoints of lvBuild,
+// unless the build names its own. The spec of an existing claim is left alone, as
+// most of it can't be changed.
+func (r *LeviathanBuildReconciler) reconcileCheckpointClaim(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) error {
+	if !checkpointEnabled(lvBuild) || lvBuild.Spec.Checkpoint.ClaimName != "" {
+		return nil
+	}
+	key := client.ObjectKey{Namespace: lvBuild.Namespace, Name: checkpointClaimName(lvBuild)}
+	if err := r.Get(ctx, key, &corev1.PersistentVolumeClaim{}); !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	size := defaultCheckpointSize
+	if lvBuild.Spec.Checkpoint.Size != nil {
+		size = *lvBuild.Spec.Checkpoint.Size
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: lvBuild.Spec.Checkpoint.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+	if err := ctrl.SetControllerReference(lvBuild, pvc, r.Scheme); err != nil {
+		return err
+	}
+	return client.IgnoreAlreadyExists(r.Create(ctx, pvc))
+}
+
+// addCheckpoint mounts the checkpoint volume into the build Job.
+func addCheckpoint(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if !checkpointEnabled(lvBuild) {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	addVolume(podSpec, corev1.Volume{
+		Name: checkpointVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: checkpointClaimName(lvBuild)},
+		},
+	})
+	addVolumeMount(podSpec, corev1.VolumeMount{Name: checkpointVolumeName, MountPath: checkpointMountPath})
+	setEnv(podSpec, corev1.EnvVar{Name: checkpointDirEnv, Value: checkpointMountPath})
+	setResumeFromCheckpoint(lvBuild, job)
+}
+
+// setResumeFromCheckpoint sets RESUME_FROM_CHECKPOINT on the containers of the
+// build Job writing checkpoints when the run resumes from a checkpoint, and
+// removes it otherwise.
+func setResumeFromCheckpoint(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	for i := range job.Spec.Template.Spec.Containers {
+		c := &job.Spec.Template.Spec.Containers[i]
+		if !hasEnv(c, checkpointDirEnv) {
+			continue
+		}
+		c.Env = slices.DeleteFunc(c.Env, func(env corev1.EnvVar) bool { return env.Name == resumeFromCheckpointEnv })
+		if lvBuild.Status.CheckpointResumes > 0 {
+			c.Env = append(c.Env, corev1.EnvVar{Name: resumeFromCheckpointEnv, Value: "true"})
+		}
+	}
+}
+
+// hasEnv reports whether c sets the named environment variable.
+func hasEnv(c *corev1.Container, name string) bool {
+	return slices.ContainsFunc(c.Env, func(env corev1.EnvVar) bool { return env.Name == name })
+}
+
+// resumeFromCheckpoint reports whether the failed Job of the latest run of lvBuild
+// should be resumed from its checkpoint, counting the resume if so. The desired
+// Job is updated to resume.
+func (r *LeviathanBuildReconciler) resumeFromCheckpoint(lvBuild *jcrsv1.LeviathanBuild, job, desiredJob *batchv1.Job, finishedType batchv1.JobConditionType) bool {
+	if !checkpointEnabled(lvBuild) || finishedType != batchv1.JobFailed ||
+		lvBuild.Status.CheckpointResumes >= lvBuild.Spec.Checkpoint.MaxResumes {
+		return false
+	}
+
+	lvBuild.Status.CheckpointResumes++
+	setResumeFromCheckpoint(lvBuild, desiredJob)
+	r.event(lvBuild, corev1.EventTypeNormal, resumedFromCheckpointReason,
+		"Job %s failed, resuming the build from its checkpoint (%d/%d)", job.Name, lvBuild.Status.CheckpointResumes, lvBuild.Spec.Checkpoint.MaxResumes)
+	return true
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Checkpoints", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "default").Obj()
+		lvBuild.Spec.Checkpoint = &jcrsv1.CheckpointSpec{Enabled: true, MaxResumes: 2}
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1-abcde"}}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+	})
+
+	It("creates the claim of the build once", func() {
+		c := newFakeClient()
+		r := &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+		lvBuild.Spec.Checkpoint.Size = ptr.To(resource.MustParse("50Gi"))
+
+		Expect(r.reconcileCheckpointClaim(context.Background(), lvBuild)).To(Succeed())
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "web-checkpoint"}, pvc)).To(Succeed())
+		Expect(pvc.Spec.Resources.Requests.Storage().String()).To(Equal("50Gi"))
+		Expect(pvc.OwnerReferences).To(ConsistOf(HaveField("UID", lvBuild.UID)))
+		Expect(r.reconcileCheckpointClaim(context.Background(), lvBuild)).To(Succeed())
+
+		By("using the claim named by the build")
+		lvBuild.Spec.Checkpoint.ClaimName = "shared-cache"
+		Expect(r.reconcileCheckpointClaim(context.Background(), lvBuild)).To(Succeed())
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "shared-cache"}, pvc)).NotTo(Succeed())
+	})
+
+	It("mounts the checkpoint volume and resumes failed runs up to maxResumes", func() {
+		addCheckpoint(lvBuild, job)
+		build := job.Spec.Template.Spec.Containers[0]
+		Expect(build.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: checkpointVolumeName, MountPath: checkpointMountPath}))
+		Expect(build.Env).To(ConsistOf(corev1.EnvVar{Name: checkpointDirEnv, Value: checkpointMountPath}))
+		Expect(job.Spec.Template.Spec.Volumes).To(ConsistOf(HaveField("PersistentVolumeClaim.ClaimName", "web-checkpoint")))
+
+		recorder := record.NewFakeRecorder(10)
+		r := &LeviathanBuildReconciler{Recorder: recorder}
+		desiredJob := job.DeepCopy()
+		Expect(r.resumeFromCheckpoint(lvBuild, job, desiredJob, batchv1.JobComplete)).To(BeFalse())
+		for range 2 {
+			Expect(r.resumeFromCheckpoint(lvBuild, job, desiredJob, batchv1.JobFailed)).To(BeTrue())
+		}
+		Expect(r.resumeFromCheckpoint(lvBuild, job, desiredJob, batchv1.JobFailed)).To(BeFalse())
+		Expect(lvBuild.Status.CheckpointResumes).To(Equal(int32(2)))
+		Expect(recorder.Events).To(HaveLen(2))
+		Expect(desiredJob.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: resumeFromCheckpointEnv, Value: "true"}))
+
+		By("not resuming once the count starts over")
+		lvBuild.Status.CheckpointResumes = 0
+		setResumeFromCheckpoint(lvBuild, desiredJob)
+		Expect(desiredJob.Spec.Template.Spec.Containers[0].Env).To(ConsistOf(corev1.EnvVar{Name: checkpointDirEnv, Value: checkpointMountPath}))
+	})
+})
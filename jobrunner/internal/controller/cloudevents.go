@@ -0,0 +1,127 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/utils/ptr"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/cloudevents"
+)
+
+/*
+When a CloudEvents sink is configured, the runs of builds are exported as
+CloudEvents, so external systems can react to them without watching the cluster.
+
+The start of a run is sent once its Job has been created, on a best effort basis.
+The outcome of a run is tracked in the status, and sent again until the sink
+accepts it. Events keep their ID when sent again, so consumers can drop the
+duplicates.
+*/
+
+const (
+	buildStartedEvent      = "dev.jcrs.build.started"
+	buildSucceededEvent    = "dev.jcrs.build.succeeded"
+	buildFailedEvent       = "dev.jcrs.build.failed"
+	artifactPublishedEvent = "dev.jcrs.artifact.published"
+
+	// cloudEventsRetryInterval is how often failed exports are retried
+	cloudEventsRetryInterval = time.Minute
+)
+
+// buildEventData is the data of the events of a run.
+type buildEventData struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	PackageName string `json:"packageName,omitempty"`
+	RunIndex    int64  `json:"runIndex"`
+	Job         string `json:"job"`
+	Revision    string `json:"revision,omitempty"`
+	Digest      string `json:"digest,omitempty"`
+	RegistryURL string `json:"registryURL,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+// buildEvent returns the event of the given type for the run of lvBuild by job.
+func buildEvent(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64, eventType string, at time.Time) cloudevents.Event {
+	data := buildEventData{
+		Namespace:   lvBuild.Namespace,
+		Name:        lvBuild.Name,
+		PackageName: ptr.Deref(lvBuild.Spec.PackageName, ""),
+		RunIndex:    runIndex,
+		Job:         job.Name,
+		Revision:    lvBuild.Status.SourceRevision,
+	}
+	if publishes(lvBuild.Spec.BuildType) && lvBuild.Spec.PublishTarget != nil {
+		data.RegistryURL = lvBuild.Spec.PublishTarget.RegistryURL
+		data.Version = lvBuild.Spec.PublishTarget.Version
+		data.Digest = lvBuild.Status.PublishedDigest
+	}
+	return cloudevents.Event{
+		ID:      fmt.Sprintf("%s-%d-%s", lvBuild.UID, runIndex, eventType),
+		Source:  fmt.Sprintf("/apis/%s/namespaces/%s/leviathanbuilds/%s", jcrsv1.GroupVersion, lvBuild.Namespace, lvBuild.Name),
+		Type:    eventType,
+		Subject: job.Name,
+		Time:    at,
+		Data:    data,
+	}
+}
+
+// exportStarted sends the start of the run of lvBuild by job. Failures are only logged.
+func (r *LeviathanBuildReconciler) exportStarted(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64) {
+	if r.CloudEvents == nil {
+		return
+	}
+	if err := r.CloudEvents.Send(ctx, buildEvent(lvBuild, job, runIndex, buildStartedEvent, time.Now())); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to export build start", "Job.Name", job.Name)
+	}
+}
+
+// exportFinished sends the outcome of the run of lvBuild by job, once, and the
+// artifact it published.
+func (r *LeviathanBuildReconciler) exportFinished(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64, finishedType batchv1.JobConditionType) error {
+	if r.CloudEvents == nil || lvBuild.Status.ExportedRunIndex >= runIndex {
+		return nil
+	}
+	finishedAt := time.Now()
+	for _, c := range job.Status.Conditions {
+		if c.Type == finishedType {
+			finishedAt = c.LastTransitionTime.Time
+		}
+	}
+
+	eventTypes := []string{buildFailedEvent}
+	if finishedType == batchv1.JobComplete {
+		eventTypes = []string{buildSucceededEvent}
+		if publishes(lvBuild.Spec.BuildType) && lvBuild.Spec.PublishTarget != nil {
+			eventTypes = append(eventTypes, artifactPublishedEvent)
+		}
+	}
+	for _, eventType := range eventTypes {
+		if err := r.CloudEvents.Send(ctx, buildEvent(lvBuild, job, runIndex, eventType, finishedAt)); err != nil {
+			return err
+		}
+	}
+	lvBuild.Status.ExportedRunIndex = runIndex
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/cloudevents"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+// fakeSink records the events sent to it, and fails while err is set.
+type fakeSink struct {
+	events []cloudevents.Event
+	err    error
+}
+
+func (s *fakeSink) Send(_ context.Context, event cloudevents.Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+var _ = Describe("CloudEvents export", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+		sink    *fakeSink
+		r       *LeviathanBuildReconciler
+	)
+
+	BeforeEach(func() {
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "default").Publish("https://registry.example.com", "1.2.3").Obj()
+		lvBuild.Status.SourceRevision = "sha256:abcd"
+		lvBuild.Status.PublishedDigest = "sha256:0123"
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-2-abcde"}}
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		sink = &fakeSink{}
+		r = &LeviathanBuildReconciler{CloudEvents: sink}
+	})
+
+	It("describes the run in the events", func() {
+		event := buildEvent(lvBuild, job, 2, buildSucceededEvent, job.Status.Conditions[0].LastTransitionTime.Time)
+		Expect(event.ID).To(Equal("web-uid-2-dev.jcrs.build.succeeded"))
+		Expect(event.Source).To(Equal("/apis/jcrs.jcrs.dev/v1/namespaces/default/leviathanbuilds/web"))
+		Expect(event.Subject).To(Equal("web-2-abcde"))
+		Expect(event.Data).To(Equal(buildEventData{
+			Namespace: "default", Name: "web", PackageName: "web", RunIndex: 2, Job: "web-2-abcde",
+			Revision: "sha256:abcd", Digest: "sha256:0123", RegistryURL: "https://registry.example.com", Version: "1.2.3",
+		}))
+	})
+
+	It("exports the outcome of a run once the sink accepts it", func() {
+		sink.err = errors.New("unavailable")
+		Expect(r.exportFinished(context.Background(), lvBuild, job, 2, batchv1.JobComplete)).NotTo(Succeed())
+		Expect(lvBuild.Status.ExportedRunIndex).To(BeZero())
+
+		sink.err = nil
+		Expect(r.exportFinished(context.Background(), lvBuild, job, 2, batchv1.JobComplete)).To(Succeed())
+		Expect(sink.events).To(HaveExactElements(
+			HaveField("Type", buildSucceededEvent),
+			HaveField("Type", artifactPublishedEvent),
+		))
+		Expect(lvBuild.Status.ExportedRunIndex).To(Equal(int64(2)))
+
+		By("not exporting the run again")
+		Expect(r.exportFinished(context.Background(), lvBuild, job, 2, batchv1.JobComplete)).To(Succeed())
+		Expect(sink.events).To(HaveLen(2))
+
+		By("exporting the failure of the next run")
+		Expect(r.exportFinished(context.Background(), lvBuild, job, 3, batchv1.JobFailed)).To(Succeed())
+		Expect(sink.events).To(HaveLen(3))
+		Expect(sink.events[2].Type).To(Equal(buildFailedEvent))
+	})
+})
@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// clusterProbeInterval is how often the API servers of ClusterTargets are checked
+const clusterProbeInterval = time.Minute
+
+// ClusterTargetReconciler checks that the remote clusters of ClusterTarget objects can be reached
+type ClusterTargetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Clusters connects to the clusters of ClusterTargets.
+	Clusters *ClusterRegistry
+}
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=clustertargets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=clustertargets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// Reconcile probes the API server of the cluster of a ClusterTarget, and reports
+// in its Ready condition whether builds can be dispatched to it. Clusters are
+// probed again every minute, and when their kubeconfig Secret changes.
+func (r *ClusterTargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var target jcrsv1.ClusterTarget
+	if err := r.Get(ctx, req.NamespacedName, &target); apierrors.IsNotFound(err) {
+		r.Clusters.Forget(req.Name)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := target.Status.DeepCopy()
+	ready := func(conditionStatus metav1.ConditionStatus, reason, message string) {
+		jcrsv1.SetReady(&status.Conditions, conditionStatus, target.Generation, reason, message)
+	}
+	if target.Spec.Paused {
+		ready(metav1.ConditionFalse, jcrsv1.ReasonPaused, "spec.paused is set")
+	} else if cluster, err := r.Clusters.Cluster(ctx, &target); isKubeconfigError(err) {
+		ready(metav1.ConditionFalse, jcrsv1.ReasonInvalidKubeconfig, err.Error())
+	} else if err != nil {
+		log.Error(err, "Failed to read kubeconfig Secret")
+		return ctrl.Result{}, err
+	} else if version, err := cluster.ServerVersion(); err != nil {
+		log.Info("Cluster unreachable", "error", err.Error())
+		ready(metav1.ConditionFalse, jcrsv1.ReasonClusterUnreachable, err.Error())
+	} else {
+		status.ServerVersion = version
+		ready(metav1.ConditionTrue, jcrsv1.ReasonClusterReachable, "Kubernetes "+version)
+	}
+
+	result := ctrl.Result{RequeueAfter: clusterProbeInterval}
+	if equality.Semantic.DeepEqual(*status, target.Status) {
+		return result, nil
+	}
+	target.Status = *status
+	if err := r.Status().Update(ctx, &target); err != nil {
+		log.Error(err, "unable to update ClusterTarget status")
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// targetsForSecret maps a Secret to the ClusterTargets whose kubeconfig it holds.
+func (r *ClusterTargetReconciler) targetsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var targets jcrsv1.ClusterTargetList
+	if err := r.List(ctx, &targets); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list ClusterTargets")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, target := range targets.Items {
+		ref := target.Spec.KubeconfigSecretRef
+		if ref.Namespace == obj.GetNamespace() && ref.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: target.Name}})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterTargetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jcrsv1.ClusterTarget{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.targetsForSecret),
+			builder.OnlyMetadata).
+		Named("clustertarget").
+		Complete(r)
+}
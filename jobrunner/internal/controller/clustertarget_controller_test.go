@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("ClusterTarget controller", func() {
+	var (
+		ctx      context.Context
+		r        *ClusterTargetReconciler
+		clusters fakeClusters
+	)
+
+	probe := func(objs ...client.Object) *jcrsv1.ClusterTarget {
+		t := &jcrsv1.ClusterTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: "eu"},
+			Spec: jcrsv1.ClusterTargetSpec{
+				KubeconfigSecretRef: jcrsv1.SecretReference{Namespace: "jobrunner-system", Name: "eu"},
+			},
+		}
+		objs = append(objs, t)
+		c := newFakeClient(objs...)
+		r = &ClusterTargetReconciler{Client: c, Scheme: c.Scheme(), Clusters: &ClusterRegistry{Reader: c, Connect: clusters.connect}}
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "eu"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(t), t)).To(Succeed())
+		return t
+	}
+	kubeconfig := func(server string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "jobrunner-system", Name: "eu"},
+			Data:       map[string][]byte{kubeconfigKey: kubeconfigFor(server)},
+		}
+	}
+	ready := func(t *jcrsv1.ClusterTarget) *metav1.Condition {
+		return meta.FindStatusCondition(t.Status.Conditions, jcrsv1.ConditionReady)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusters = fakeClusters{
+			"https://eu.example.com":   newFakeCluster("v1.33.1"),
+			"https://down.example.com": newFakeCluster(""),
+		}
+	})
+
+	It("marks reachable clusters as Ready with their version", func() {
+		t := probe(kubeconfig("https://eu.example.com"))
+		Expect(ready(t).Status).To(Equal(metav1.ConditionTrue))
+		Expect(t.Status.ServerVersion).To(Equal("v1.33.1"))
+	})
+
+	It("reports unreachable clusters", func() {
+		t := probe(kubeconfig("https://down.example.com"))
+		Expect(ready(t).Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready(t).Reason).To(Equal(jcrsv1.ReasonClusterUnreachable))
+	})
+
+	It("reports missing kubeconfig Secrets", func() {
+		t := probe()
+		Expect(ready(t).Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready(t).Reason).To(Equal(jcrsv1.ReasonInvalidKubeconfig))
+	})
+})
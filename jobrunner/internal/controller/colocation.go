@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A ReadWriteOnce volume can only be attached to one node at a time. When the pod of
+a new run lands on another node than the one the volume of the previous run is
+still attached to, it waits for the volume to be detached and attached again,
+which takes minutes with most CSI drivers. So the pods of a new run prefer the
+node its ReadWriteOnce claims are attached to, as reported by their
+VolumeAttachment. The preference is soft: a build isn't held back when that node
+is full. Volumes whose PersistentVolume is restricted to some nodes by its node
+affinity, like local volumes, need nothing more, the scheduler already only
+considers those nodes.
+
+The node is chosen once, when the Job is created, and recorded on the Job, so
+the Jobs constructed by later reconciles get the same affinity and aren't taken
+for out of date when the volume moves.
+*/
+
+const (
+	// cacheNodeAnnotation records the node the pods of a Job prefer, as the node
+	// its ReadWriteOnce claims were attached to when it was created
+	cacheNodeAnnotation = "jcrs.jcrs.dev/cache-node"
+
+	// nodeNameField selects a node by name in node affinity
+	nodeNameField = "metadata.name"
+)
+
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=volumeattachments,verbs=get;list;watch
+
+// attachedNode returns the node the first ReadWriteOnce claim mounted by the pods
+// of job is attached to, or "" if there's none.
+func (r *LeviathanBuildReconciler) attachedNode(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) (string, error) {
+	var volumes []string
+	for _, volume := range job.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		claim := &corev1.PersistentVolumeClaim{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: lvBuild.Namespace, Name: volume.PersistentVolumeClaim.ClaimName}, claim); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+		if claim.Spec.VolumeName != "" && readWriteOnce(claim) {
+			volumes = append(volumes, claim.Spec.VolumeName)
+		}
+	}
+	if len(volumes) == 0 {
+		return "", nil
+	}
+
+	var attachments storagev1.VolumeAttachmentList
+	if err := r.List(ctx, &attachments); err != nil {
+		return "", err
+	}
+	for _, volume := range volumes {
+		for _, attachment := range attachments.Items {
+			source := attachment.Spec.Source.PersistentVolumeName
+			if source != nil && *source == volume && attachment.Status.Attached {
+				return attachment.Spec.NodeName, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// readWriteOnce reports whether claim can only be mounted from a single node.
+func readWriteOnce(claim *corev1.PersistentVolumeClaim) bool {
+	modes := claim.Spec.AccessModes
+	if slices.Contains(modes, corev1.ReadWriteMany) || slices.Contains(modes, corev1.ReadOnlyMany) {
+		return false
+	}
+	return slices.Contains(modes, corev1.ReadWriteOnce) || slices.Contains(modes, corev1.ReadWriteOncePod)
+}
+
+// preferNode makes the pods of job prefer node, in place of the node they preferred
+// before, and records it on job. They prefer no node when node is empty.
+func preferNode(job *batchv1.Job, node string) {
+	podSpec := &job.Spec.Template.Spec
+	if previous := job.Annotations[cacheNodeAnnotation]; previous != "" && podSpec.Affinity != nil && podSpec.Affinity.NodeAffinity != nil {
+		nodeAffinity := podSpec.Affinity.NodeAffinity
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = slices.DeleteFunc(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			func(term corev1.PreferredSchedulingTerm) bool {
+				return equality.Semantic.DeepEqual(term, preferredNode(previous))
+			})
+		// The affinity of a jobTemplate that had none is dropped with the term
+		if equality.Semantic.DeepEqual(*nodeAffinity, corev1.NodeAffinity{}) {
+			podSpec.Affinity.NodeAffinity = nil
+		}
+		if equality.Semantic.DeepEqual(*podSpec.Affinity, corev1.Affinity{}) {
+			podSpec.Affinity = nil
+		}
+	}
+	delete(job.Annotations, cacheNodeAnnotation)
+	if node == "" {
+		return
+	}
+
+	if job.Annotations == nil {
+		job.Annotations = make(map[string]string)
+	}
+	job.Annotations[cacheNodeAnnotation] = node
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := podSpec.Affinity.NodeAffinity
+	nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, preferredNode(node))
+}
+
+// preferredNode returns the scheduling term preferring node.
+func preferredNode(node string) corev1.PreferredSchedulingTerm {
+	return corev1.PreferredSchedulingTerm{Weight: 100, Preference: corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{{
+		Key:      nodeNameField,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{node},
+	}}}}
+}
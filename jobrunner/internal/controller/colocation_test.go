@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Co-location with cache volumes", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	claim := func(name, volume string, mode corev1.PersistentVolumeAccessMode) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PersistentVolumeClaimSpec{AccessModes: []corev1.PersistentVolumeAccessMode{mode}, VolumeName: volume},
+		}
+	}
+	attachment := func(volume, node string, attached bool) *storagev1.VolumeAttachment {
+		return &storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-" + volume},
+			Spec: storagev1.VolumeAttachmentSpec{
+				Attacher: "ebs.csi.aws.com",
+				NodeName: node,
+				Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: ptr.To(volume)},
+			},
+			Status: storagev1.VolumeAttachmentStatus{Attached: attached},
+		}
+	}
+
+	BeforeEach(func() {
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "default").Obj()
+		job = &batchv1.Job{}
+		for _, name := range []string{"shared", "cache"} {
+			job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+				Name:         name,
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name}},
+			})
+		}
+	})
+
+	It("finds the node the ReadWriteOnce claims of the build are attached to", func() {
+		c := newFakeClientBuilder().WithObjects(
+			claim("shared", "pv-shared", corev1.ReadWriteMany),
+			claim("cache", "pv-cache", corev1.ReadWriteOnce),
+			attachment("pv-shared", "node-a", true),
+			attachment("pv-cache", "node-b", true),
+		).Build()
+		r := &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+
+		node, err := r.attachedNode(context.Background(), lvBuild, job)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(node).To(Equal("node-b"))
+
+		By("ignoring volumes being detached")
+		c = newFakeClientBuilder().WithObjects(
+			claim("cache", "pv-cache", corev1.ReadWriteOnce),
+			attachment("pv-cache", "node-b", false),
+		).Build()
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+		node, err = r.attachedNode(context.Background(), lvBuild, job)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(node).To(BeEmpty())
+	})
+
+	It("replaces the node preferred before", func() {
+		template := corev1.PreferredSchedulingTerm{Weight: 10, Preference: corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"builds"}}},
+		}}
+		job.Spec.Template.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{template},
+		}}
+
+		preferNode(job, "node-a")
+		preferNode(job, "node-b")
+		Expect(job.Annotations).To(HaveKeyWithValue(cacheNodeAnnotation, "node-b"))
+		Expect(job.Spec.Template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(Equal(
+			[]corev1.PreferredSchedulingTerm{template, preferredNode("node-b")}))
+
+		preferNode(job, "")
+		Expect(job.Annotations).NotTo(HaveKey(cacheNodeAnnotation))
+		Expect(job.Spec.Template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(Equal(
+			[]corev1.PreferredSchedulingTerm{template}))
+
+		By("dropping the affinity of a jobTemplate that had none")
+		job.Spec.Template.Spec.Affinity = nil
+		preferNode(job, "node-a")
+		Expect(job.Spec.Template.Spec.Affinity).NotTo(BeNil())
+		preferNode(job, "")
+		Expect(job.Spec.Template.Spec.Affinity).To(BeNil())
+	})
+})
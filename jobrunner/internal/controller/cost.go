@@ -0,0 +1,190 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The cost of a run is estimated from the resources requested by each of its pods,
+multiplied by how long the pod ran, at the prices of a Pricing. Prices are per hour
+and per unit of resource: a CPU core, a GiB of memory or storage, or one of any
+other resource such as a GPU.
+
+The estimate of a run is recorded once it has finished, and added to the cost
+metrics of its namespace at the same time, so every run is counted once.
+*/
+
+// Prices are the hourly prices of resources.
+type Prices struct {
+	// Currency of the prices
+	Currency string
+	// PerHour is the price of one unit of each resource for an hour
+	PerHour map[corev1.ResourceName]float64
+}
+
+// Pricing provides the prices used to estimate the cost of builds.
+type Pricing interface {
+	Prices(ctx context.Context) (Prices, error)
+}
+
+// pricingCurrencyKey is the key of the currency in a pricing ConfigMap
+const pricingCurrencyKey = "currency"
+
+// ConfigMapPricing reads prices from a ConfigMap. Every key but "currency" is a
+// resource name, e.g. "cpu", "memory" or "nvidia.com/gpu", holding its price.
+type ConfigMapPricing struct {
+	Reader client.Reader
+	Key    client.ObjectKey
+}
+
+// Prices implements Pricing.
+func (p *ConfigMapPricing) Prices(ctx context.Context) (Prices, error) {
+	var cm corev1.ConfigMap
+	if err := p.Reader.Get(ctx, p.Key, &cm); err != nil {
+		return Prices{}, err
+	}
+	prices := Prices{Currency: cm.Data[pricingCurrencyKey], PerHour: make(map[corev1.ResourceName]float64)}
+	for key, value := range cm.Data {
+		if key == pricingCurrencyKey {
+			continue
+		}
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Prices{}, fmt.Errorf("invalid price of %s in ConfigMap %s: %w", key, p.Key, err)
+		}
+		prices.PerHour[corev1.ResourceName(key)] = price
+	}
+	return prices, nil
+}
+
+// podRequests returns the resources requested by a pod: the largest of the
+// requests of its init containers, which run one at a time, and of its containers
+// and sidecars, which run together.
+func podRequests(spec *corev1.PodSpec) corev1.ResourceList {
+	running := corev1.ResourceList{}
+	addRequests := func(requests corev1.ResourceList) {
+		for name, quantity := range requests {
+			total := running[name]
+			total.Add(quantity)
+			running[name] = total
+		}
+	}
+	for _, c := range spec.Containers {
+		addRequests(c.Resources.Requests)
+	}
+	for _, c := range spec.InitContainers {
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			addRequests(c.Resources.Requests)
+		}
+	}
+	for _, c := range spec.InitContainers {
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			continue
+		}
+		for name, quantity := range c.Resources.Requests {
+			if total, ok := running[name]; !ok || quantity.Cmp(total) > 0 {
+				running[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	addRequests(spec.Overhead)
+	return running
+}
+
+// podRuntime returns how long pod ran, up to now for pods that are still running.
+func podRuntime(pod *corev1.Pod, now time.Time) time.Duration {
+	if pod.Status.StartTime == nil {
+		return 0
+	}
+	end := now
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		end = pod.Status.StartTime.Time
+		for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+			for _, status := range statuses {
+				if terminated := status.State.Terminated; terminated != nil && terminated.FinishedAt.After(end) {
+					end = terminated.FinishedAt.Time
+				}
+			}
+		}
+	}
+	return end.Sub(pod.Status.StartTime.Time)
+}
+
+// resourceUnits returns quantity in the unit resources are priced in.
+func resourceUnits(name corev1.ResourceName, quantity resource.Quantity) float64 {
+	switch name {
+	case corev1.ResourceMemory, corev1.ResourceStorage, corev1.ResourceEphemeralStorage:
+		return quantity.AsApproximateFloat64() / (1 << 30)
+	default:
+		return quantity.AsApproximateFloat64()
+	}
+}
+
+// podCost returns the cost of pod at the given prices.
+func podCost(pod *corev1.Pod, prices Prices, now time.Time) float64 {
+	hours := podRuntime(pod, now).Hours()
+	cost := 0.0
+	for name, quantity := range podRequests(&pod.Spec) {
+		cost += prices.PerHour[name] * resourceUnits(name, quantity) * hours
+	}
+	return cost
+}
+
+// estimateCost records the estimated cost of job, the finished Job of the given
+// run of lvBuild, unless it has already been recorded.
+func (r *LeviathanBuildReconciler) estimateCost(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64) error {
+	if r.Pricing == nil {
+		return nil
+	}
+	if cost := lvBuild.Status.EstimatedCost; cost != nil && cost.RunIndex == runIndex {
+		return nil
+	}
+	prices, err := r.Pricing.Prices(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return err
+	}
+	now := time.Now()
+	total := 0.0
+	for i := range pods.Items {
+		total += podCost(&pods.Items[i], prices, now)
+	}
+
+	lvBuild.Status.EstimatedCost = &jcrsv1.EstimatedCost{
+		Amount:   strconv.FormatFloat(total, 'f', 4, 64),
+		Currency: prices.Currency,
+		RunIndex: runIndex,
+	}
+	buildCostTotal.WithLabelValues(lvBuild.Namespace, prices.Currency).Add(total)
+	return nil
+}
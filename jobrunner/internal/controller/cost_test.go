@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Build cost estimation", func() {
+	requests := func(cpu, memory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}}
+	}
+
+	It("adds up the containers running together", func() {
+		spec := &corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "fetch", Resources: requests("4", "1Gi")},
+				{Name: "dind", Resources: requests("1", "2Gi"), RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways)},
+			},
+			Containers: []corev1.Container{{Name: "build", Resources: requests("2", "4Gi")}},
+		}
+		total := podRequests(spec)
+		Expect(total.Cpu().String()).To(Equal("4"))
+		Expect(total.Memory().String()).To(Equal("6Gi"))
+	})
+
+	Context("when a run has finished", func() {
+		var (
+			c       client.Client
+			r       *LeviathanBuildReconciler
+			lvBuild *jcrsv1.LeviathanBuild
+			job     *batchv1.Job
+		)
+
+		BeforeEach(func() {
+			start := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-1-abcde-xyz", Namespace: "cost", Labels: map[string]string{batchv1.JobNameLabel: "web-1-abcde"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "build", Resources: requests("2", "4Gi")}}},
+				Status: corev1.PodStatus{
+					Phase:     corev1.PodSucceeded,
+					StartTime: ptr.To(metav1.NewTime(start)),
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "build", State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(start.Add(30 * time.Minute))},
+					}}},
+				},
+			}
+			pricing := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "pricing", Namespace: "system"},
+				Data:       map[string]string{"currency": "EUR", "cpu": "0.04", "memory": "0.005"},
+			}
+			c = newFakeClient(pod, pricing)
+			r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme(), Pricing: &ConfigMapPricing{Reader: c, Key: client.ObjectKeyFromObject(pricing)}}
+			lvBuild = &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "cost"}}
+			job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1-abcde", Namespace: "cost"}}
+		})
+
+		It("records the cost of the run once", func() {
+			Expect(r.estimateCost(context.Background(), lvBuild, job, 1)).To(Succeed())
+			// (2 cores * 0.04 + 4 GiB * 0.005) * 0.5 hour
+			Expect(lvBuild.Status.EstimatedCost).To(Equal(&jcrsv1.EstimatedCost{Amount: "0.0500", Currency: "EUR", RunIndex: 1}))
+			Expect(testutil.ToFloat64(buildCostTotal.WithLabelValues("cost", "EUR"))).To(BeNumerically("~", 0.05, 1e-9))
+
+			Expect(r.estimateCost(context.Background(), lvBuild, job, 1)).To(Succeed())
+			Expect(testutil.ToFloat64(buildCostTotal.WithLabelValues("cost", "EUR"))).To(BeNumerically("~", 0.05, 1e-9))
+		})
+
+		It("fails on invalid prices", func() {
+			var pricing corev1.ConfigMap
+			Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "system", Name: "pricing"}, &pricing)).To(Succeed())
+			pricing.Data["cpu"] = "cheap"
+			Expect(c.Update(context.Background(), &pricing)).To(Succeed())
+			Expect(r.estimateCost(context.Background(), lvBuild, job, 1)).To(MatchError(ContainSubstring("invalid price of cpu")))
+			Expect(lvBuild.Status.EstimatedCost).To(BeNil())
+		})
+	})
+})
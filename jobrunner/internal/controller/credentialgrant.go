@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Credentials such as those of publish targets and sources are often managed in a
+central namespace, and used to be copied by hand into the namespaces of builds.
+A CredentialGrant authorizes the controller to copy one of these Secrets into the
+namespaces it selects. Copies are made on demand: a namespace only gets one while
+it holds builds referencing a Secret of that name, through an HTTP source, their
+parameters or their credentials, and the copy is deleted once none does anymore.
+The copies are kept in sync with the Secret so rotating it is a single update.
+
+Granting is an explicit decision of the cluster administrators, who own the
+cluster-scoped CredentialGrants: namespaces that aren't selected never get the
+Secret, whatever their builds reference. The copies are owned by their grant,
+and are garbage collected along with it. A Secret of the same name that isn't a
+copy of the grant is left alone, and reported as a conflict in its status.
+*/
+
+const (
+	// referencedSecretsKey indexes LeviathanBuilds by the names of the Secrets they reference
+	referencedSecretsKey = ".spec.secretRefs"
+
+	// credentialGrantLabel names the CredentialGrant a copied Secret belongs to
+	credentialGrantLabel = "jcrs.jcrs.dev/credential-grant"
+	// credentialGrantSourceAnnotation records the namespace/name of the Secret a copy is synced from
+	credentialGrantSourceAnnotation = "jcrs.jcrs.dev/credential-grant-source"
+)
+
+// referencedSecrets returns the sorted names of the Secrets of its namespace lvBuild references.
+func referencedSecrets(lvBuild *jcrsv1.LeviathanBuild) []string {
+	var names []string
+	if source := lvBuild.Spec.Source; source != nil && source.HTTP != nil && source.HTTP.SecretRef != nil {
+		names = append(names, source.HTTP.SecretRef.Name)
+	}
+	for _, source := range lvBuild.Spec.ParametersFrom {
+		if source.SecretRef != nil {
+			names = append(names, source.SecretRef.Name)
+		}
+	}
+	if credentials := lvBuild.Spec.Credentials; credentials != nil {
+		for _, secret := range []*corev1.LocalObjectReference{credentials.Source, credentials.Registry} {
+			if secret != nil {
+				names = append(names, secret.Name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// indexReferencedSecrets is the index function for referencedSecretsKey.
+func indexReferencedSecrets(rawObj client.Object) []string {
+	return referencedSecrets(rawObj.(*jcrsv1.LeviathanBuild))
+}
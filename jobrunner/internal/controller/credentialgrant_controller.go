@@ -0,0 +1,286 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// CredentialGrantReconciler copies the Secrets of CredentialGrant objects into the namespaces of LeviathanBuild objects
+type CredentialGrantReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// APIReader reads Secrets from the API server. Secrets aren't cached, to keep
+	// the credentials of the whole cluster out of the memory of the controller.
+	APIReader client.Reader
+}
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=credentialgrants,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=credentialgrants/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;delete
+
+// Reconcile syncs the copies of the Secret of a CredentialGrant: a copy is created
+// or updated in every selected namespace holding builds that reference it, and
+// deleted from the other namespaces.
+func (r *CredentialGrantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var grant jcrsv1.CredentialGrant
+	if err := r.Get(ctx, req.NamespacedName, &grant); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	wanted, err := r.grantedNamespaces(ctx, &grant)
+	if err != nil {
+		log.Error(err, "Failed to find the namespaces of the grant")
+		return ctrl.Result{}, err
+	}
+
+	var copies corev1.SecretList
+	if err := r.APIReader.List(ctx, &copies, client.MatchingLabels{credentialGrantLabel: labelValue(grant.Name)}); err != nil {
+		log.Error(err, "Failed to list Secret copies")
+		return ctrl.Result{}, err
+	}
+	existing := make(map[string]*corev1.Secret)
+	for i := range copies.Items {
+		secret := &copies.Items[i]
+		if !metav1.IsControlledBy(secret, &grant) {
+			continue
+		}
+		if !slices.Contains(wanted, secret.Namespace) || secret.Name != grant.Spec.SecretRef.Name {
+			log.Info("Deleting Secret copy", "namespace", secret.Namespace, "Secret", secret.Name)
+			if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, err
+			}
+			continue
+		}
+		existing[secret.Namespace] = secret
+	}
+
+	var status jcrsv1.CredentialGrantStatus
+	var source corev1.Secret
+	err = r.APIReader.Get(ctx, types.NamespacedName(grant.Spec.SecretRef), &source)
+	switch {
+	case apierrors.IsNotFound(err):
+		// The source is watched, so the copies are synced once it is created
+		log.Info("Granted Secret not found", "Secret", grant.Spec.SecretRef)
+		wanted = nil
+		for ns := range existing {
+			status.Namespaces = append(status.Namespaces, ns)
+		}
+		slices.Sort(status.Namespaces)
+	case err != nil:
+		log.Error(err, "Failed to get granted Secret", "Secret", grant.Spec.SecretRef)
+		return ctrl.Result{}, err
+	}
+
+	for _, ns := range wanted {
+		synced, err := r.syncCopy(ctx, &grant, &source, ns, existing[ns])
+		if err != nil {
+			log.Error(err, "Failed to sync Secret copy", "namespace", ns)
+			return ctrl.Result{}, err
+		}
+		if synced {
+			status.Namespaces = append(status.Namespaces, ns)
+		} else {
+			status.Conflicts = append(status.Conflicts, ns)
+		}
+	}
+
+	if equality.Semantic.DeepEqual(status, grant.Status) {
+		return ctrl.Result{}, nil
+	}
+	grant.Status = status
+	if err := r.Status().Update(ctx, &grant); err != nil {
+		log.Error(err, "unable to update CredentialGrant status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// grantedNamespaces returns the sorted namespaces the Secret of grant is copied to:
+// those selected by the grant holding builds that reference a Secret of its name.
+// The namespace of the Secret already holds it.
+func (r *CredentialGrantReconciler) grantedNamespaces(ctx context.Context, grant *jcrsv1.CredentialGrant) ([]string, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&grant.Spec.NamespaceSelector)
+	if err != nil {
+		// Nothing is granted until the selector is fixed
+		logf.FromContext(ctx).Info("Invalid namespace selector", "error", err.Error())
+		return nil, nil
+	}
+
+	var builds jcrsv1.LeviathanBuildList
+	if err := r.List(ctx, &builds, client.MatchingFields{referencedSecretsKey: grant.Spec.SecretRef.Name}); err != nil {
+		return nil, err
+	}
+	var namespaces []string
+	for _, lvBuild := range builds.Items {
+		if lvBuild.Namespace == grant.Spec.SecretRef.Namespace || slices.Contains(namespaces, lvBuild.Namespace) {
+			continue
+		}
+		var ns corev1.Namespace
+		if err := r.Get(ctx, client.ObjectKey{Name: lvBuild.Namespace}, &ns); apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if ns.DeletionTimestamp != nil || ns.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	slices.Sort(namespaces)
+	return namespaces, nil
+}
+
+// syncCopy creates or updates the copy of source in namespace. existing is the
+// copy made by grant, when there is one. It reports false when namespace holds
+// a Secret of the same name that isn't a copy of grant, which is left alone.
+func (r *CredentialGrantReconciler) syncCopy(ctx context.Context, grant *jcrsv1.CredentialGrant, source *corev1.Secret, namespace string, existing *corev1.Secret) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	if existing == nil {
+		var other corev1.Secret
+		err := r.APIReader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: source.Name}, &other)
+		if err == nil {
+			log.Info("Not replacing a Secret that isn't a copy of the grant", "namespace", namespace, "Secret", source.Name)
+			return false, nil
+		} else if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+	}
+
+	// The type of a Secret is immutable, a copy of another type is replaced
+	if existing != nil && existing.Type != source.Type {
+		if err := r.Delete(ctx, existing); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		existing = nil
+	}
+	if existing == nil {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        source.Name,
+				Namespace:   namespace,
+				Labels:      map[string]string{credentialGrantLabel: labelValue(grant.Name)},
+				Annotations: map[string]string{credentialGrantSourceAnnotation: source.Namespace + "/" + source.Name},
+			},
+			Type: source.Type,
+			Data: source.Data,
+		}
+		if err := ctrl.SetControllerReference(grant, secret, r.Scheme); err != nil {
+			return false, err
+		}
+		log.Info("Creating Secret copy", "namespace", namespace, "Secret", source.Name)
+		return true, r.Create(ctx, secret)
+	}
+	if equality.Semantic.DeepEqual(existing.Data, source.Data) {
+		return true, nil
+	}
+	log.Info("Updating Secret copy", "namespace", namespace, "Secret", source.Name)
+	existing.Data = source.Data
+	return true, r.Update(ctx, existing)
+}
+
+// grantsOf maps an object to the CredentialGrants matching it.
+func (r *CredentialGrantReconciler) grantsOf(ctx context.Context, matches func(*jcrsv1.CredentialGrant) bool) []reconcile.Request {
+	var grants jcrsv1.CredentialGrantList
+	if err := r.List(ctx, &grants); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list CredentialGrants")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range grants.Items {
+		if matches(&grants.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: grants.Items[i].Name}})
+		}
+	}
+	return requests
+}
+
+// grantsForBuild maps a LeviathanBuild to the CredentialGrants of the Secrets it references.
+func (r *CredentialGrantReconciler) grantsForBuild(ctx context.Context, obj client.Object) []reconcile.Request {
+	names := referencedSecrets(obj.(*jcrsv1.LeviathanBuild))
+	if len(names) == 0 {
+		return nil
+	}
+	return r.grantsOf(ctx, func(grant *jcrsv1.CredentialGrant) bool {
+		return slices.Contains(names, grant.Spec.SecretRef.Name)
+	})
+}
+
+// grantsForSecret maps a Secret to the CredentialGrants copying it.
+func (r *CredentialGrantReconciler) grantsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.grantsOf(ctx, func(grant *jcrsv1.CredentialGrant) bool {
+		return grant.Spec.SecretRef.Namespace == obj.GetNamespace() && grant.Spec.SecretRef.Name == obj.GetName()
+	})
+}
+
+// allGrants maps a Namespace to every CredentialGrant, as any may select it.
+func (r *CredentialGrantReconciler) allGrants(ctx context.Context, _ client.Object) []reconcile.Request {
+	return r.grantsOf(ctx, func(*jcrsv1.CredentialGrant) bool { return true })
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CredentialGrantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &jcrsv1.LeviathanBuild{}, referencedSecretsKey, indexReferencedSecrets); err != nil {
+		return err
+	}
+
+	// Builds only matter when they start or stop referencing a Secret
+	referencesChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !slices.Equal(referencedSecrets(e.ObjectOld.(*jcrsv1.LeviathanBuild)), referencedSecrets(e.ObjectNew.(*jcrsv1.LeviathanBuild)))
+		},
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jcrsv1.CredentialGrant{}).
+		Owns(&corev1.Secret{}, builder.OnlyMetadata).
+		Watches(&jcrsv1.LeviathanBuild{}, handler.EnqueueRequestsFromMapFunc(r.grantsForBuild),
+			builder.WithPredicates(referencesChanged)).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.allGrants),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.grantsForSecret),
+			builder.OnlyMetadata).
+		Named("credentialgrant").
+		Complete(r)
+}
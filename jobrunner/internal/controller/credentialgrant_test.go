@@ -0,0 +1,159 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Credential grants", func() {
+	var (
+		c      client.Client
+		r      *CredentialGrantReconciler
+		source *corev1.Secret
+		grant  *jcrsv1.CredentialGrant
+	)
+
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	build := func(ns string, secret string) *jcrsv1.LeviathanBuild {
+		lvBuild := utiltesting.MakeLeviathanBuild("web", ns).Obj()
+		if secret != "" {
+			lvBuild.Spec.ParametersFrom = []jcrsv1.ParametersSource{{SecretRef: &corev1.LocalObjectReference{Name: secret}}}
+		}
+		return lvBuild
+	}
+	reconcileGrant := func() {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: grant.Name}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(context.Background(), client.ObjectKeyFromObject(grant), grant)).To(Succeed())
+	}
+	getCopy := func(ns string) (*corev1.Secret, error) {
+		secret := &corev1.Secret{}
+		return secret, c.Get(context.Background(), client.ObjectKey{Namespace: ns, Name: "publisher"}, secret)
+	}
+
+	BeforeEach(func() {
+		source = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "publisher", Namespace: "credentials"},
+			Data:       map[string][]byte{"token": []byte("s3cr3t")},
+		}
+		grant = &jcrsv1.CredentialGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "publisher"},
+			Spec: jcrsv1.CredentialGrantSpec{
+				SecretRef:         jcrsv1.SecretReference{Namespace: "credentials", Name: "publisher"},
+				NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"builds": "true"}},
+			},
+		}
+		c = newFakeClientBuilder().
+			WithIndex(&jcrsv1.LeviathanBuild{}, referencedSecretsKey, indexReferencedSecrets).
+			WithObjects(
+				source, grant,
+				namespace("credentials", nil),
+				namespace("team-a", map[string]string{"builds": "true"}),
+				namespace("team-b", map[string]string{"builds": "true"}),
+				namespace("team-c", nil),
+				build("team-a", "publisher"), build("team-b", "other"), build("team-c", "publisher"),
+			).Build()
+		r = &CredentialGrantReconciler{Client: c, Scheme: c.Scheme(), APIReader: c}
+	})
+
+	It("indexes the Secrets referenced by builds", func() {
+		lvBuild := build("team-a", "publisher")
+		lvBuild.Spec.Source = &jcrsv1.SourceSpec{HTTP: &jcrsv1.HTTPSourceSpec{SecretRef: &corev1.LocalObjectReference{Name: "download"}}}
+		lvBuild.Spec.ParametersFrom = append(lvBuild.Spec.ParametersFrom,
+			jcrsv1.ParametersSource{ConfigMapRef: &corev1.LocalObjectReference{Name: "settings"}},
+			jcrsv1.ParametersSource{SecretRef: &corev1.LocalObjectReference{Name: "download"}})
+		Expect(referencedSecrets(lvBuild)).To(Equal([]string{"download", "publisher"}))
+
+		lvBuild.Spec.Credentials = &jcrsv1.BuildCredentials{Registry: &corev1.LocalObjectReference{Name: "registry"}}
+		Expect(referencedSecrets(lvBuild)).To(Equal([]string{"download", "publisher", "registry"}))
+	})
+
+	It("copies the Secret into the selected namespaces whose builds reference it", func() {
+		reconcileGrant()
+		secret, err := getCopy("team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Data).To(Equal(source.Data))
+		Expect(secret.Labels).To(HaveKeyWithValue(credentialGrantLabel, "publisher"))
+		Expect(metav1.IsControlledBy(secret, grant)).To(BeTrue())
+		Expect(grant.Status.Namespaces).To(Equal([]string{"team-a"}))
+
+		for _, ns := range []string{"team-b", "team-c"} {
+			_, err := getCopy(ns)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue(), ns)
+		}
+	})
+
+	It("rotates the copies and deletes them once no build references the Secret", func() {
+		reconcileGrant()
+		source.Data["token"] = []byte("r0tated")
+		Expect(c.Update(context.Background(), source)).To(Succeed())
+		reconcileGrant()
+		secret, err := getCopy("team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Data).To(Equal(source.Data))
+
+		Expect(c.Delete(context.Background(), build("team-a", ""))).To(Succeed())
+		reconcileGrant()
+		_, err = getCopy("team-a")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(grant.Status.Namespaces).To(BeEmpty())
+	})
+
+	It("deletes the copies of namespaces that are no longer selected", func() {
+		reconcileGrant()
+		ns := &corev1.Namespace{}
+		Expect(c.Get(context.Background(), client.ObjectKey{Name: "team-a"}, ns)).To(Succeed())
+		ns.Labels = nil
+		Expect(c.Update(context.Background(), ns)).To(Succeed())
+		reconcileGrant()
+		_, err := getCopy("team-a")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("leaves Secrets it doesn't manage alone and reports them", func() {
+		own := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "publisher", Namespace: "team-a"}, StringData: map[string]string{"token": "team"}}
+		Expect(c.Create(context.Background(), own)).To(Succeed())
+		reconcileGrant()
+		secret, err := getCopy("team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Labels).NotTo(HaveKey(credentialGrantLabel))
+		Expect(grant.Status.Conflicts).To(Equal([]string{"team-a"}))
+		Expect(grant.Status.Namespaces).To(BeEmpty())
+	})
+
+	It("maps builds and Secrets to their grants", func() {
+		Expect(r.grantsForBuild(context.Background(), build("team-b", "publisher"))).To(HaveLen(1))
+		Expect(r.grantsForBuild(context.Background(), build("team-b", "other"))).To(BeEmpty())
+		Expect(r.grantsForSecret(context.Background(), source)).To(HaveLen(1))
+		Expect(r.grantsForSecret(context.Background(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "publisher", Namespace: "team-a"}})).To(BeEmpty())
+	})
+})
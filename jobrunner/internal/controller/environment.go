@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The build environment is a snapshot of what a run ran with, so it can be replayed
+exactly. It's taken from the Job when the run is created: the spec generation, the
+version of the controller, the builder image and the image and literal environment
+of every container. What is only known once the run has started is added as it
+becomes known: the digests of the images the kubelet pulled, and the revision of
+a fetched source.
+
+Runs whose Job was created before the snapshot existed get one from their Job, without
+the version of the controller that created it.
+*/
+
+// newBuildEnvironment returns the snapshot of the run of job.
+func newBuildEnvironment(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64, operatorVersion string) *jcrsv1.BuildEnvironment {
+	env := &jcrsv1.BuildEnvironment{
+		RunIndex:        runIndex,
+		Generation:      lvBuild.Generation,
+		OperatorVersion: operatorVersion,
+		SourceRevision:  lvBuild.Status.SourceRevision,
+	}
+	if lvBuild.Status.BuilderImage != nil {
+		env.BuilderImage = lvBuild.Status.BuilderImage.Image
+	}
+	podSpec := &job.Spec.Template.Spec
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for _, c := range containers {
+			container := jcrsv1.BuildContainer{Name: c.Name, Image: c.Image}
+			for _, envVar := range c.Env {
+				if envVar.ValueFrom != nil {
+					continue
+				}
+				if container.Parameters == nil {
+					container.Parameters = make(map[string]string)
+				}
+				container.Parameters[envVar.Name] = envVar.Value
+			}
+			env.Containers = append(env.Containers, container)
+		}
+	}
+	return env
+}
+
+// recordBuildEnvironment completes the snapshot of the run of job with the image
+// digests reported by its pods and the source revision.
+func (r *LeviathanBuildReconciler) recordBuildEnvironment(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64) error {
+	env := lvBuild.Status.BuildEnvironment
+	if env == nil || env.RunIndex != runIndex {
+		env = newBuildEnvironment(lvBuild, job, runIndex, "")
+		lvBuild.Status.BuildEnvironment = env
+	}
+	if env.SourceRevision == "" {
+		env.SourceRevision = lvBuild.Status.SourceRevision
+	}
+
+	missing := make(map[string]*jcrsv1.BuildContainer)
+	for i := range env.Containers {
+		if env.Containers[i].ImageID == "" {
+			missing[env.Containers[i].Name] = &env.Containers[i]
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+			for _, status := range statuses {
+				if container := missing[status.Name]; container != nil && status.ImageID != "" {
+					container.ImageID = status.ImageID
+				}
+			}
+		}
+	}
+	return nil
+}
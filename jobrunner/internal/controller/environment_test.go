@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Build environment", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", Generation: 3},
+			Status: jcrsv1.LeviathanBuildStatus{
+				BuilderImage: &jcrsv1.ResolvedBuilderImage{Image: "builders/go:1.24"},
+			},
+		}
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-2"}}
+		job.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: fetchContainerName, Image: "fetcher:v1"}}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{
+			Name:  "build",
+			Image: "builders/go:1.24",
+			Env: []corev1.EnvVar{
+				{Name: "GOFLAGS", Value: "-mod=vendor"},
+				{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "token"}}},
+			},
+		}}
+	})
+
+	It("snapshots the run as its Job is created", func() {
+		env := newBuildEnvironment(lvBuild, job, 2, "v1.4.0")
+
+		Expect(env).To(Equal(&jcrsv1.BuildEnvironment{
+			RunIndex:        2,
+			Generation:      3,
+			OperatorVersion: "v1.4.0",
+			BuilderImage:    "builders/go:1.24",
+			Containers: []jcrsv1.BuildContainer{
+				{Name: fetchContainerName, Image: "fetcher:v1"},
+				{Name: "build", Image: "builders/go:1.24", Parameters: map[string]string{"GOFLAGS": "-mod=vendor"}},
+			},
+		}))
+	})
+
+	It("records the image digests of the pods and the source revision", func() {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: "web-2-abcde", Labels: map[string]string{batchv1.JobNameLabel: job.Name},
+		}}
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{Name: fetchContainerName, ImageID: "docker.io/library/fetcher@sha256:aaa"}}
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "build", ImageID: "docker.io/builders/go@sha256:bbb"}}
+		r := &LeviathanBuildReconciler{Client: newFakeClient(pod)}
+
+		lvBuild.Status.BuildEnvironment = newBuildEnvironment(lvBuild, job, 2, "v1.4.0")
+		lvBuild.Status.SourceRevision = "0123abc"
+		Expect(r.recordBuildEnvironment(context.Background(), lvBuild, job, 2)).To(Succeed())
+
+		env := lvBuild.Status.BuildEnvironment
+		Expect(env.OperatorVersion).To(Equal("v1.4.0"))
+		Expect(env.SourceRevision).To(Equal("0123abc"))
+		Expect(env.Containers).To(ConsistOf(
+			HaveField("ImageID", "docker.io/library/fetcher@sha256:aaa"),
+			HaveField("ImageID", "docker.io/builders/go@sha256:bbb"),
+		))
+	})
+
+	It("snapshots runs created without one from their Job", func() {
+		r := &LeviathanBuildReconciler{Client: newFakeClient()}
+		lvBuild.Status.BuildEnvironment = &jcrsv1.BuildEnvironment{RunIndex: 1, OperatorVersion: "v1.3.0"}
+
+		Expect(r.recordBuildEnvironment(context.Background(), lvBuild, job, 2)).To(Succeed())
+
+		env := lvBuild.Status.BuildEnvironment
+		Expect(env.RunIndex).To(Equal(int64(2)))
+		Expect(env.OperatorVersion).To(BeEmpty())
+		Expect(env.Containers).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A build held back long enough, by a MaintenanceWindow, its mutexKey, a missing
+builder image or parameter, would otherwise publish a stale release hours after
+it was asked for. A build with an expiresAfter that hasn't started its first Job
+within that long of its creation is expired instead: Ready and Succeeded are set
+to False with the Expired reason, an Event is recorded and counted, and no Job is
+ever created for it. Raising expiresAfter lets an expired build start again.
+
+Queued builds are reconciled again when they expire, so they're marked even when
+nothing else about them changes.
+*/
+
+// expiredReason is the reason of the Event recorded when a build expires
+const expiredReason = "Expired"
+
+// expiresIn returns how long the queued lvBuild has left before it expires.
+// ok is false for builds that have no expiresAfter or have started.
+func expiresIn(lvBuild *jcrsv1.LeviathanBuild, now time.Time) (time.Duration, bool) {
+	if lvBuild.Spec.ExpiresAfter == nil || lvBuild.Status.RunIndex > 0 || lvBuild.CreationTimestamp.IsZero() {
+		return 0, false
+	}
+	return lvBuild.CreationTimestamp.Add(lvBuild.Spec.ExpiresAfter.Duration).Sub(now), true
+}
+
+// expireQueuedBuild marks lvBuild as expired when it hasn't started within its
+// expiresAfter, and reports whether it has expired.
+func (r *LeviathanBuildReconciler) expireQueuedBuild(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, now time.Time) (bool, error) {
+	if remaining, ok := expiresIn(lvBuild, now); !ok || remaining > 0 {
+		return false, nil
+	}
+	// A Job whose creation didn't make it into the status has started the build
+	if _, latestRunIndex, err := r.latestJob(ctx, lvBuild); err != nil || latestRunIndex > 0 {
+		return false, err
+	}
+
+	succeeded := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded)
+	alreadyExpired := succeeded != nil && succeeded.Reason == jcrsv1.ReasonExpired
+	jcrsv1.MarkFailed(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonExpired,
+		"The build didn't start within "+lvBuild.Spec.ExpiresAfter.Duration.String()+" of its creation")
+	// Whatever held the build back no longer matters, and it doesn't hold back the builds queued behind it
+	if err := r.releaseMutex(ctx, lvBuild); err != nil {
+		return false, err
+	}
+	meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForMutex)
+	meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForFetchSlot)
+	setDeferredByMaintenanceWindow(lvBuild, nil, nil)
+	if !alreadyExpired {
+		r.event(lvBuild, corev1.EventTypeWarning, expiredReason, "Build didn't start within %s of its creation, it won't run",
+			lvBuild.Spec.ExpiresAfter.Duration)
+		buildsExpiredTotal.WithLabelValues(lvBuild.Namespace).Inc()
+	}
+	return true, nil
+}
+
+// requeueBeforeExpiry requeues the queued lvBuild no later than when it expires.
+func requeueBeforeExpiry(lvBuild *jcrsv1.LeviathanBuild, result ctrl.Result, now time.Time) ctrl.Result {
+	remaining, ok := expiresIn(lvBuild, now)
+	if !ok || remaining <= 0 {
+		return result
+	}
+	if result.RequeueAfter == 0 || remaining < result.RequeueAfter {
+		result.RequeueAfter = remaining
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Build expiry", func() {
+	var (
+		ctx       context.Context
+		lvBuild   *jcrsv1.LeviathanBuild
+		r         *LeviathanBuildReconciler
+		c         client.Client
+		recorder  *record.FakeRecorder
+		createdAt time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = newFakeClient()
+		recorder = record.NewFakeRecorder(10)
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme(), Recorder: recorder}
+		createdAt = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "expiry", UID: "release-uid", CreationTimestamp: metav1.NewTime(createdAt)},
+			Spec:       jcrsv1.LeviathanBuildSpec{ExpiresAfter: &metav1.Duration{Duration: time.Hour}},
+		}
+	})
+
+	It("only expires queued builds with an expiresAfter", func() {
+		remaining, ok := expiresIn(lvBuild, createdAt.Add(20*time.Minute))
+		Expect(ok).To(BeTrue())
+		Expect(remaining).To(Equal(40 * time.Minute))
+
+		lvBuild.Status.RunIndex = 1
+		_, ok = expiresIn(lvBuild, createdAt.Add(20*time.Minute))
+		Expect(ok).To(BeFalse())
+
+		lvBuild.Status.RunIndex = 0
+		lvBuild.Spec.ExpiresAfter = nil
+		_, ok = expiresIn(lvBuild, createdAt.Add(20*time.Minute))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("marks builds that didn't start in time as expired, once", func() {
+		expired, err := r.expireQueuedBuild(ctx, lvBuild, createdAt.Add(59*time.Minute))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(BeFalse())
+
+		setDeferredByMaintenanceWindow(lvBuild, &openMaintenanceWindow{name: "freeze", end: createdAt.Add(2 * time.Hour)}, nil)
+		expired, err = r.expireQueuedBuild(ctx, lvBuild, createdAt.Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(BeTrue())
+		succeeded := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded)
+		Expect(succeeded).NotTo(BeNil())
+		Expect(succeeded.Status).To(Equal(metav1.ConditionFalse))
+		Expect(succeeded.Reason).To(Equal(jcrsv1.ReasonExpired))
+		Expect(jcrsv1.IsFailed(lvBuild.Status.Conditions)).To(BeTrue())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionDeferredByMaintenanceWindow)).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning Expired Build didn't start within 1h0m0s of its creation")))
+		Expect(testutil.ToFloat64(buildsExpiredTotal.WithLabelValues("expiry"))).To(Equal(1.0))
+
+		By("not counting the build again")
+		expired, err = r.expireQueuedBuild(ctx, lvBuild, createdAt.Add(2*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(BeTrue())
+		Expect(recorder.Events).NotTo(Receive())
+		Expect(testutil.ToFloat64(buildsExpiredTotal.WithLabelValues("expiry"))).To(Equal(1.0))
+
+		By("letting it start once expiresAfter is raised")
+		lvBuild.Spec.ExpiresAfter.Duration = 3 * time.Hour
+		Expect(r.expireQueuedBuild(ctx, lvBuild, createdAt.Add(2*time.Hour))).To(BeFalse())
+	})
+
+	It("doesn't expire builds whose Job was created", func() {
+		Expect(c.Create(ctx, &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name:      "release-1-x7k2p",
+			Namespace: "expiry",
+			Labels:    RunLabels(lvBuild, 1),
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: jcrsv1.GroupVersion.String(), Kind: "LeviathanBuild", Name: "release", UID: lvBuild.UID, Controller: ptr.To(true),
+			}},
+		}})).To(Succeed())
+		Expect(r.expireQueuedBuild(ctx, lvBuild, createdAt.Add(2*time.Hour))).To(BeFalse())
+	})
+
+	It("requeues queued builds no later than when they expire", func() {
+		now := createdAt.Add(50 * time.Minute)
+		Expect(requeueBeforeExpiry(lvBuild, reconcile.Result{}, now).RequeueAfter).To(Equal(10 * time.Minute))
+		Expect(requeueBeforeExpiry(lvBuild, reconcile.Result{RequeueAfter: time.Minute}, now).RequeueAfter).To(Equal(time.Minute))
+
+		lvBuild.Status.RunIndex = 1
+		Expect(requeueBeforeExpiry(lvBuild, reconcile.Result{}, now).RequeueAfter).To(BeZero())
+	})
+})
@@ -0,0 +1,228 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"path"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const (
+	fetchContainerName = "fetch"
+
+	workspaceVolumeName = "workspace"
+	workspaceMountPath  = "/workspace"
+	// workspaceEnv tells the build containers where the fetched source is
+	workspaceEnv = "LEVIATHAN_WORKSPACE"
+
+	fetchCacheVolumeName = "fetch-cache"
+	fetchCacheMountPath  = "/cache"
+
+	// sourceMirrorAccessKeyIDKey and sourceMirrorSecretAccessKeyKey are the keys of
+	// the credentials of the mirror bucket in the source mirror Secret
+	sourceMirrorAccessKeyIDKey     = "AWS_ACCESS_KEY_ID"
+	sourceMirrorSecretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+)
+
+/*
+For compliance, the sources a build fetches from outside may be mirrored to an
+internal bucket: the fetch init container copies the fetched workspace to it,
+and the runs of the build after the first, whether retries, resumes or runs
+again after a spec change, restore the copy rather than reach the internet.
+
+The fetcher decides whether the source has been mirrored, looking for it under
+a key derived from the build and its source, so the Job of a run doesn't change
+once it has written the mirror. The key changes with the source: a different
+sourceURL or source, or a new polled revision, is fetched from its origin and
+mirrored again. Reruns are new builds, which start with a fresh mirror.
+*/
+
+// SourceMirrorConfig configures the mirroring of fetched sources to a bucket.
+type SourceMirrorConfig struct {
+	// Endpoint is the URL of the S3 compatible storage service, e.g. https://s3.us-east-1.amazonaws.com
+	Endpoint string
+	// Bucket the sources are mirrored to. Sources aren't mirrored when empty.
+	Bucket string
+	// Region the requests are signed for
+	Region string
+	// SecretName names a Secret holding the AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY the fetcher writes to the bucket with. It is expected
+	// to exist in the namespace of every build. The fetcher runs without
+	// credentials when empty, e.g. with a bucket granted to the nodes.
+	SecretName string
+}
+
+// FetchConfig tunes the downloads of the fetch init containers.
+type FetchConfig struct {
+	// BandwidthLimit caps the rate the fetcher downloads archives, from their
+	// origin or the source mirror, in bytes per second. Unlimited when zero.
+	BandwidthLimit int64
+	// Parallelism is the number of submodules and LFS objects a Git fetch
+	// downloads at once. Git's defaults apply when zero.
+	Parallelism int
+}
+
+// sourceMirrorKey returns the key of the mirror of the source of lvBuild.
+func sourceMirrorKey(lvBuild *jcrsv1.LeviathanBuild) string {
+	// Marshaling a struct of strings and a source spec can't fail
+	source, _ := json.Marshal(struct {
+		SourceType     jcrsv1.SourceType  `json:"sourceType"`
+		SourceURL      string             `json:"sourceURL,omitempty"`
+		Source         *jcrsv1.SourceSpec `json:"source,omitempty"`
+		PolledRevision string             `json:"polledRevision,omitempty"`
+	}{lvBuild.Spec.SourceType, ptr.Deref(lvBuild.Spec.SourceURL, ""), lvBuild.Spec.Source, lvBuild.Status.PolledRevision})
+	digest := strings.TrimPrefix(contentRevision(string(source)), "sha256:")
+	return path.Join(lvBuild.Namespace, lvBuild.Name, string(lvBuild.UID), digest[:16]+".tar.gz")
+}
+
+// needsFetch reports whether the source of lvBuild is fetched by the fetch init container.
+func needsFetch(lvBuild *jcrsv1.LeviathanBuild) bool {
+	return lvBuild.Spec.SourceType == jcrsv1.HTTPSource || gitSource(lvBuild) != nil
+}
+
+// gitSource returns the configuration of the Git source of lvBuild cloned by the
+// fetch init container, or nil when the build clones its source itself.
+func gitSource(lvBuild *jcrsv1.LeviathanBuild) *jcrsv1.GitSourceSpec {
+	if lvBuild.Spec.SourceType != jcrsv1.GitSource || lvBuild.Spec.Source == nil {
+		return nil
+	}
+	return lvBuild.Spec.Source.Git
+}
+
+// addFetchInitContainer prepends the fetch init container to the build Job. It
+// downloads the source into a workspace volume shared with the build containers.
+// Git sources are cloned by the fetcher of the Git fetcher image, which has git.
+func (r *LeviathanBuildReconciler) addFetchInitContainer(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if !needsFetch(lvBuild) {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+
+	addVolume(podSpec, corev1.Volume{
+		Name:         workspaceVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	addVolumeMount(podSpec, corev1.VolumeMount{Name: workspaceVolumeName, MountPath: workspaceMountPath})
+	setEnv(podSpec, corev1.EnvVar{Name: workspaceEnv, Value: workspaceMountPath})
+
+	fetch := corev1.Container{
+		Name:  fetchContainerName,
+		Image: r.FetcherImage,
+		// The fetcher ships in the manager image next to the manager binary
+		Command: []string{fetcherCommand},
+		Env: []corev1.EnvVar{
+			{Name: "FETCH_SOURCE_TYPE", Value: string(lvBuild.Spec.SourceType)},
+			{Name: "FETCH_DEST", Value: workspaceMountPath},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: workspaceVolumeName, MountPath: workspaceMountPath},
+		},
+	}
+	if lvBuild.Spec.SourceURL != nil {
+		fetch.Env = append(fetch.Env, corev1.EnvVar{Name: "FETCH_URL", Value: *lvBuild.Spec.SourceURL})
+	}
+
+	if git := gitSource(lvBuild); git != nil {
+		fetch.Image = r.GitFetcherImage
+		if git.Submodules != "" {
+			fetch.Env = append(fetch.Env, corev1.EnvVar{Name: "FETCH_GIT_SUBMODULES", Value: string(git.Submodules)})
+		}
+		if git.LFS {
+			fetch.Env = append(fetch.Env, corev1.EnvVar{Name: "FETCH_GIT_LFS", Value: "true"})
+		}
+		if len(git.SparsePaths) > 0 {
+			fetch.Env = append(fetch.Env, corev1.EnvVar{Name: "FETCH_GIT_SPARSE_PATHS", Value: strings.Join(git.SparsePaths, "\n")})
+		}
+	}
+
+	var httpSource *jcrsv1.HTTPSourceSpec
+	if lvBuild.Spec.Source != nil {
+		httpSource = lvBuild.Spec.Source.HTTP
+	}
+	if httpSource != nil && httpSource.SecretRef != nil {
+		for _, cred := range []struct{ env, key string }{
+			{"FETCH_TOKEN", "token"},
+			{"FETCH_USERNAME", "username"},
+			{"FETCH_PASSWORD", "password"},
+		} {
+			fetch.Env = append(fetch.Env, corev1.EnvVar{
+				Name: cred.env,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: *httpSource.SecretRef,
+					Key:                  cred.key,
+					Optional:             ptr.To(true),
+				}},
+			})
+		}
+	}
+	if httpSource != nil && httpSource.CacheClaimName != nil {
+		addVolume(podSpec, corev1.Volume{
+			Name: fetchCacheVolumeName,
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: *httpSource.CacheClaimName,
+			}},
+		})
+		fetch.VolumeMounts = append(fetch.VolumeMounts, corev1.VolumeMount{Name: fetchCacheVolumeName, MountPath: fetchCacheMountPath})
+		fetch.Env = append(fetch.Env, corev1.EnvVar{Name: "FETCH_CACHE_DIR", Value: fetchCacheMountPath})
+	}
+
+	if r.Fetch.BandwidthLimit > 0 {
+		fetch.Env = append(fetch.Env, corev1.EnvVar{Name: "FETCH_BANDWIDTH_LIMIT", Value: strconv.FormatInt(r.Fetch.BandwidthLimit, 10)})
+	}
+	if r.Fetch.Parallelism > 0 && gitSource(lvBuild) != nil {
+		fetch.Env = append(fetch.Env, corev1.EnvVar{Name: "FETCH_PARALLELISM", Value: strconv.Itoa(r.Fetch.Parallelism)})
+	}
+
+	r.addSourceMirror(lvBuild, &fetch)
+
+	podSpec.InitContainers = append([]corev1.Container{fetch}, podSpec.InitContainers...)
+}
+
+// addSourceMirror configures the fetch init container to mirror the source of
+// lvBuild, or restore it from its mirror.
+func (r *LeviathanBuildReconciler) addSourceMirror(lvBuild *jcrsv1.LeviathanBuild, fetch *corev1.Container) {
+	mirror := r.SourceMirror
+	if mirror.Bucket == "" {
+		return
+	}
+	fetch.Env = append(fetch.Env,
+		corev1.EnvVar{Name: "FETCH_MIRROR_ENDPOINT", Value: mirror.Endpoint},
+		corev1.EnvVar{Name: "FETCH_MIRROR_BUCKET", Value: mirror.Bucket},
+		corev1.EnvVar{Name: "FETCH_MIRROR_REGION", Value: mirror.Region},
+		corev1.EnvVar{Name: "FETCH_MIRROR_KEY", Value: sourceMirrorKey(lvBuild)},
+	)
+	if mirror.SecretName == "" {
+		return
+	}
+	for _, key := range []string{sourceMirrorAccessKeyIDKey, sourceMirrorSecretAccessKeyKey} {
+		fetch.Env = append(fetch.Env, corev1.EnvVar{
+			Name: key,
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: mirror.SecretName},
+				Key:                  key,
+			}},
+		})
+	}
+}
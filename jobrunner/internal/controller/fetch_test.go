@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Fetch init container", func() {
+	var (
+		r       *LeviathanBuildReconciler
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		r = &LeviathanBuildReconciler{FetcherImage: "controller:v1", GitFetcherImage: "git-fetcher:v1"}
+		lvBuild = &jcrsv1.LeviathanBuild{Spec: jcrsv1.LeviathanBuildSpec{
+			SourceType: jcrsv1.GitSource,
+			SourceURL:  ptr.To("https://github.com/example/monorepo.git"),
+		}}
+		job = &batchv1.Job{}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+	})
+
+	It("leaves Git sources without a git configuration to the build", func() {
+		Expect(needsFetch(lvBuild)).To(BeFalse())
+		r.addFetchInitContainer(lvBuild, job)
+		Expect(job.Spec.Template.Spec.InitContainers).To(BeEmpty())
+	})
+
+	It("clones Git sources with the Git fetcher image", func() {
+		lvBuild.Spec.Source = &jcrsv1.SourceSpec{Git: &jcrsv1.GitSourceSpec{
+			Submodules:  jcrsv1.RecursiveSubmodules,
+			LFS:         true,
+			SparsePaths: []string{"services/web", "libs/common"},
+		}}
+		Expect(needsFetch(lvBuild)).To(BeTrue())
+		r.addFetchInitContainer(lvBuild, job)
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.InitContainers).To(HaveLen(1))
+		fetch := podSpec.InitContainers[0]
+		Expect(fetch.Image).To(Equal("git-fetcher:v1"))
+		Expect(fetch.Env).To(ConsistOf(
+			corev1.EnvVar{Name: "FETCH_SOURCE_TYPE", Value: "Git"},
+			corev1.EnvVar{Name: "FETCH_DEST", Value: workspaceMountPath},
+			corev1.EnvVar{Name: "FETCH_URL", Value: "https://github.com/example/monorepo.git"},
+			corev1.EnvVar{Name: "FETCH_GIT_SUBMODULES", Value: "Recursive"},
+			corev1.EnvVar{Name: "FETCH_GIT_LFS", Value: "true"},
+			corev1.EnvVar{Name: "FETCH_GIT_SPARSE_PATHS", Value: "services/web\nlibs/common"},
+		))
+		Expect(podSpec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: workspaceEnv, Value: workspaceMountPath}))
+	})
+
+	It("ignores the git configuration of other source types", func() {
+		lvBuild.Spec.SourceType = jcrsv1.HTTPSource
+		lvBuild.Spec.Source = &jcrsv1.SourceSpec{Git: &jcrsv1.GitSourceSpec{LFS: true}}
+		r.addFetchInitContainer(lvBuild, job)
+
+		fetch := job.Spec.Template.Spec.InitContainers[0]
+		Expect(fetch.Image).To(Equal("controller:v1"))
+		Expect(fetch.Env).NotTo(ContainElement(HaveField("Name", "FETCH_GIT_LFS")))
+	})
+
+	It("passes the download tuning of the controller to the fetcher", func() {
+		r.Fetch = FetchConfig{BandwidthLimit: 50 << 20, Parallelism: 8}
+		lvBuild.Spec.Source = &jcrsv1.SourceSpec{Git: &jcrsv1.GitSourceSpec{Submodules: jcrsv1.RecursiveSubmodules}}
+		r.addFetchInitContainer(lvBuild, job)
+		Expect(job.Spec.Template.Spec.InitContainers[0].Env).To(ContainElements(
+			corev1.EnvVar{Name: "FETCH_BANDWIDTH_LIMIT", Value: "52428800"},
+			corev1.EnvVar{Name: "FETCH_PARALLELISM", Value: "8"},
+		))
+
+		By("leaving the parallelism out of HTTP sources")
+		lvBuild.Spec.SourceType, lvBuild.Spec.Source = jcrsv1.HTTPSource, nil
+		job.Spec.Template.Spec.InitContainers = nil
+		r.addFetchInitContainer(lvBuild, job)
+		env := job.Spec.Template.Spec.InitContainers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "FETCH_BANDWIDTH_LIMIT", Value: "52428800"}))
+		Expect(env).NotTo(ContainElement(HaveField("Name", "FETCH_PARALLELISM")))
+	})
+
+	It("mirrors the source under a key that changes with it", func() {
+		r.SourceMirror = SourceMirrorConfig{Endpoint: "https://minio:9000", Bucket: "sources", Region: "auto", SecretName: "mirror"}
+		lvBuild.Namespace, lvBuild.Name, lvBuild.UID = "default", "web", "web-uid"
+		lvBuild.Spec.SourceType = jcrsv1.HTTPSource
+		key := sourceMirrorKey(lvBuild)
+		Expect(key).To(MatchRegexp(`^default/web/web-uid/[0-9a-f]{16}\.tar\.gz$`))
+
+		r.addFetchInitContainer(lvBuild, job)
+		fetch := job.Spec.Template.Spec.InitContainers[0]
+		Expect(fetch.Env).To(ContainElements(
+			corev1.EnvVar{Name: "FETCH_MIRROR_ENDPOINT", Value: "https://minio:9000"},
+			corev1.EnvVar{Name: "FETCH_MIRROR_BUCKET", Value: "sources"},
+			corev1.EnvVar{Name: "FETCH_MIRROR_REGION", Value: "auto"},
+			corev1.EnvVar{Name: "FETCH_MIRROR_KEY", Value: key},
+			HaveField("ValueFrom.SecretKeyRef.Name", "mirror"),
+		))
+
+		By("keeping the key across runs of the same source")
+		lvBuild.Generation++
+		lvBuild.Status.SourceMirror = "s3://sources/" + key
+		Expect(sourceMirrorKey(lvBuild)).To(Equal(key))
+
+		By("changing the key with a new polled revision")
+		lvBuild.Status.PolledRevision = `"v2"`
+		polled := sourceMirrorKey(lvBuild)
+		Expect(polled).NotTo(Equal(key))
+
+		By("changing the key with the source")
+		lvBuild.Spec.SourceURL = ptr.To("https://example.com/web-2.tar.gz")
+		Expect(sourceMirrorKey(lvBuild)).NotTo(BeElementOf(key, polled))
+	})
+})
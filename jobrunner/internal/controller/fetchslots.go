@@ -0,0 +1,189 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Large sources fetched by many builds starting together saturate the network of
+the nodes. The controller may limit the builds of the cluster in their fetch
+phase: a build whose source is fetched by the fetch init container doesn't
+create the Job of its next run while as many builds as allowed are fetching
+theirs, and waits in WaitingForFetchSlot, checking again for a free slot.
+
+A build holds a slot from the moment its Job is created until the fetch init
+container of its pod has terminated. Slots are counted from the pods of the
+cluster, so they survive restarts of the controller, with a reservation held by
+the Job of a build until its pod is seen. Pods that haven't been scheduled don't
+hold a slot: they don't use the network of any node yet. Unlike the Lease of a
+mutexKey, slots aren't handed out first come, first served, but to the first
+build reconciled once one is free.
+*/
+
+const (
+	// fetcherCommand is the command of the fetch init containers of build Jobs
+	fetcherCommand = "/fetcher"
+
+	// fetchSlotPollInterval is how often builds waiting for a fetch slot check again
+	fetchSlotPollInterval = 15 * time.Second
+	// fetchSlotReservation is how long the Job of a build holds a slot before its pod is seen
+	fetchSlotReservation = 2 * time.Minute
+)
+
+// FetchSlots limits the builds of the cluster fetching their source at once.
+type FetchSlots struct {
+	// Max is the number of builds that may fetch their source at once
+	Max int
+
+	mu sync.Mutex
+	// reservations are the slots of the builds whose pod hasn't been seen yet
+	reservations map[types.NamespacedName]fetchReservation
+	now          func() time.Time
+}
+
+// fetchReservation is the slot reserved by a build, for the Job it created once it has.
+type fetchReservation struct {
+	job types.NamespacedName
+	at  time.Time
+}
+
+// NewFetchSlots returns FetchSlots letting max builds fetch their source at once.
+func NewFetchSlots(max int) *FetchSlots {
+	return &FetchSlots{Max: max, reservations: make(map[types.NamespacedName]fetchReservation), now: time.Now}
+}
+
+// acquire reports whether lvBuild may create a Job fetching its source, and
+// reserves a slot for it if so. The pods of the cluster are read with reader.
+func (s *FetchSlots) acquire(ctx context.Context, reader client.Reader, lvBuild *jcrsv1.LeviathanBuild) (bool, error) {
+	var pods corev1.PodList
+	if err := reader.List(ctx, &pods, client.HasLabels{batchv1.JobNameLabel}); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[types.NamespacedName]bool)
+	fetching := make(map[types.NamespacedName]bool)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !runsFetcher(pod) {
+			continue
+		}
+		job := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Labels[batchv1.JobNameLabel]}
+		seen[job] = true
+		if isFetching(pod) {
+			fetching[job] = true
+		}
+	}
+
+	now := s.now()
+	for build, reservation := range s.reservations {
+		if seen[reservation.job] || now.Sub(reservation.at) > fetchSlotReservation {
+			delete(s.reservations, build)
+		}
+	}
+	key := client.ObjectKeyFromObject(lvBuild)
+	if _, ok := s.reservations[key]; ok {
+		return true, nil
+	}
+	if len(fetching)+len(s.reservations) >= s.Max {
+		return false, nil
+	}
+	s.reservations[key] = fetchReservation{at: now}
+	return true, nil
+}
+
+// started records the Job created by lvBuild with its reserved slot.
+func (s *FetchSlots) started(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := client.ObjectKeyFromObject(lvBuild)
+	if _, ok := s.reservations[key]; ok {
+		s.reservations[key] = fetchReservation{job: client.ObjectKeyFromObject(job), at: s.now()}
+	}
+}
+
+// release frees the slot reserved by lvBuild, which didn't create its Job.
+func (s *FetchSlots) release(lvBuild *jcrsv1.LeviathanBuild) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.reservations, client.ObjectKeyFromObject(lvBuild))
+}
+
+// runsFetcher reports whether pod has the fetch init container of a build Job.
+func runsFetcher(pod *corev1.Pod) bool {
+	return slices.ContainsFunc(pod.Spec.InitContainers, func(c corev1.Container) bool {
+		return c.Name == fetchContainerName && slices.Equal(c.Command, []string{fetcherCommand})
+	})
+}
+
+// isFetching reports whether the scheduled pod is fetching the source of its build.
+func isFetching(pod *corev1.Pod) bool {
+	if pod.Spec.NodeName == "" || pod.Status.Phase != corev1.PodPending {
+		return false
+	}
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name == fetchContainerName {
+			return status.State.Terminated == nil
+		}
+	}
+	// The kubelet hasn't reported the status of the pod yet
+	return true
+}
+
+// acquireFetchSlot reports whether lvBuild may create the Job of its next run
+// as far as its fetch phase is concerned, and records it in WaitingForFetchSlot.
+func (r *LeviathanBuildReconciler) acquireFetchSlot(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (bool, error) {
+	if r.FetchSlots == nil || !needsFetch(lvBuild) {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForFetchSlot)
+		return true, nil
+	}
+	acquired, err := r.FetchSlots.acquire(ctx, r, lvBuild)
+	if err != nil {
+		return false, err
+	}
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionWaitingForFetchSlot,
+		Status:             metav1.ConditionFalse,
+		Reason:             jcrsv1.ReasonAcquired,
+		Message:            "The build holds a fetch slot",
+		ObservedGeneration: lvBuild.Generation,
+	}
+	if !acquired {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = jcrsv1.ReasonWaiting
+		condition.Message = "Waiting for one of the " + strconv.Itoa(r.FetchSlots.Max) + " builds fetching their source to finish"
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, condition)
+	return acquired, nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Fetch slots", func() {
+	var (
+		ctx   context.Context
+		c     client.Client
+		r     *LeviathanBuildReconciler
+		slots *FetchSlots
+		now   time.Time
+	)
+
+	newBuild := func(name string) *jcrsv1.LeviathanBuild {
+		return &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				SourceType: jcrsv1.HTTPSource,
+				SourceURL:  ptr.To("https://example.com/" + name + ".tar.gz"),
+			},
+		}
+	}
+
+	// newPod returns the pod of the Job named job, on node, whose fetch init
+	// container has terminated when fetched.
+	newPod := func(job, node string, fetched bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: job + "-pod", Labels: map[string]string{batchv1.JobNameLabel: job}},
+			Spec: corev1.PodSpec{
+				NodeName:       node,
+				InitContainers: []corev1.Container{{Name: fetchContainerName, Command: []string{fetcherCommand}}},
+				Containers:     []corev1.Container{{Name: "build"}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		}
+		state := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+		if fetched {
+			pod.Status.Phase = corev1.PodRunning
+			state = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}
+		}
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{Name: fetchContainerName, State: state}}
+		return pod
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = newFakeClient()
+
+		now = time.Now()
+		slots = NewFetchSlots(2)
+		slots.now = func() time.Time { return now }
+		r = &LeviathanBuildReconciler{Client: c, FetchSlots: slots}
+	})
+
+	It("counts the scheduled pods still fetching their source", func() {
+		for _, pod := range []*corev1.Pod{
+			newPod("fetching", "node-1", false),
+			newPod("fetched", "node-1", true),
+			newPod("unscheduled", "", false),
+		} {
+			Expect(c.Create(ctx, pod)).To(Succeed())
+		}
+
+		first := newBuild("first")
+		Expect(r.acquireFetchSlot(ctx, first)).To(BeTrue())
+		Expect(meta.FindStatusCondition(first.Status.Conditions, jcrsv1.ConditionWaitingForFetchSlot)).To(HaveField("Reason", jcrsv1.ReasonAcquired))
+
+		second := newBuild("second")
+		Expect(r.acquireFetchSlot(ctx, second)).To(BeFalse())
+		condition := meta.FindStatusCondition(second.Status.Conditions, jcrsv1.ConditionWaitingForFetchSlot)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(jcrsv1.ReasonWaiting))
+
+		By("keeping the slot of a build that reconciles again")
+		Expect(r.acquireFetchSlot(ctx, first)).To(BeTrue())
+	})
+
+	It("hands out the slot of a build once its fetch has completed", func() {
+		first, second := newBuild("first"), newBuild("second")
+		slots.Max = 1
+		Expect(r.acquireFetchSlot(ctx, first)).To(BeTrue())
+		slots.started(first, &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "first-1"}})
+		Expect(r.acquireFetchSlot(ctx, second)).To(BeFalse())
+
+		By("counting the pod of the Job instead of its reservation")
+		pod := newPod("first-1", "node-1", false)
+		Expect(c.Create(ctx, pod)).To(Succeed())
+		Expect(r.acquireFetchSlot(ctx, second)).To(BeFalse())
+
+		By("freeing the slot once the fetch init container terminated")
+		fetched := newPod("first-1", "node-1", true)
+		pod.Status = fetched.Status
+		Expect(c.Status().Update(ctx, pod)).To(Succeed())
+		Expect(r.acquireFetchSlot(ctx, second)).To(BeTrue())
+	})
+
+	It("frees the reservations of Jobs whose pod doesn't show up", func() {
+		first, second := newBuild("first"), newBuild("second")
+		slots.Max = 1
+		Expect(r.acquireFetchSlot(ctx, first)).To(BeTrue())
+		Expect(r.acquireFetchSlot(ctx, second)).To(BeFalse())
+
+		now = now.Add(fetchSlotReservation + time.Second)
+		Expect(r.acquireFetchSlot(ctx, second)).To(BeTrue())
+
+		By("freeing the reservation of a build that failed to create its Job")
+		slots.release(second)
+		Expect(r.acquireFetchSlot(ctx, first)).To(BeTrue())
+	})
+
+	It("doesn't hold back builds that don't fetch their source", func() {
+		slots.Max = 0
+		lvBuild := newBuild("inline")
+		lvBuild.Spec.SourceType = jcrsv1.GitSource
+		lvBuild.Status.Conditions = []metav1.Condition{{Type: jcrsv1.ConditionWaitingForFetchSlot, Status: metav1.ConditionTrue}}
+		Expect(r.acquireFetchSlot(ctx, lvBuild)).To(BeTrue())
+		Expect(lvBuild.Status.Conditions).To(BeEmpty())
+	})
+})
@@ -0,0 +1,176 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A build that runs for hours can't be told apart from a hung one by its Job alone.
+Builds with a heartbeat prove they're progressing: their build containers touch
+the file at $HEARTBEAT_FILE, in a volume shared with the heartbeat sidecar, at least
+every $HEARTBEAT_INTERVAL seconds.
+
+The sidecar creates the file when it starts, and its readiness probe fails once
+the file is older than the timeout of the build. The controller reads the
+readiness of the sidecar from the pods of the Job: a sidecar that has been running
+for longer than the timeout and isn't ready means the build has stopped touching
+the file. The build is then marked as Stalled, and the run is deleted and run
+again up to maxRestarts times. The readiness of the sidecar is part of the
+readiness of the pod, which the Job counts, so a stall is noticed through the
+watch on Jobs; builds are checked again every minute anyway.
+*/
+
+const (
+	heartbeatContainerName = "heartbeat"
+	heartbeatVolumeName    = "heartbeat"
+	heartbeatMountPath     = "/var/run/leviathan/heartbeat"
+	heartbeatFileEnv       = "HEARTBEAT_FILE"
+	heartbeatIntervalEnv   = "HEARTBEAT_INTERVAL"
+
+	// heartbeatRecheckInterval is how often the heartbeat of a running build is checked
+	heartbeatRecheckInterval = time.Minute
+
+	// stalledReason is the reason of the Event recorded when a build stalls
+	stalledReason = "Stalled"
+)
+
+// heartbeatFile is the file the build containers touch.
+var heartbeatFile = path.Join(heartbeatMountPath, "heartbeat")
+
+// addHeartbeat mounts the heartbeat volume into the build containers of builds
+// with a heartbeat and tells them where the heartbeat file is.
+func addHeartbeat(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	spec := lvBuild.Spec.Heartbeat
+	if spec == nil {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	addVolume(podSpec, corev1.Volume{
+		Name:         heartbeatVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	addVolumeMount(podSpec, corev1.VolumeMount{Name: heartbeatVolumeName, MountPath: heartbeatMountPath})
+	setEnv(podSpec, corev1.EnvVar{Name: heartbeatFileEnv, Value: heartbeatFile})
+	setEnv(podSpec, corev1.EnvVar{Name: heartbeatIntervalEnv, Value: strconv.Itoa(int(spec.Interval.Seconds()))})
+}
+
+// heartbeatSidecar returns the heartbeat sidecar of lvBuild, or nil when it has
+// no heartbeat.
+func (r *LeviathanBuildReconciler) heartbeatSidecar(lvBuild *jcrsv1.LeviathanBuild) *corev1.Container {
+	spec := lvBuild.Spec.Heartbeat
+	if spec == nil {
+		return nil
+	}
+	// The file is checked often enough for a stall to be noticed well within the timeout
+	period := min(max(spec.Timeout.Duration/10, 10*time.Second), time.Minute)
+	return &corev1.Container{
+		Name:  heartbeatContainerName,
+		Image: r.FetcherImage,
+		// The heartbeat ships in the manager image next to the manager binary
+		Command: []string{"/heartbeat"},
+		Env: []corev1.EnvVar{
+			{Name: heartbeatFileEnv, Value: heartbeatFile},
+			{Name: "HEARTBEAT_TIMEOUT", Value: spec.Timeout.Duration.String()},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: heartbeatVolumeName, MountPath: heartbeatMountPath},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{Command: []string{"/heartbeat", "check"}},
+			},
+			PeriodSeconds:    int32(period.Seconds()),
+			FailureThreshold: 1,
+		},
+	}
+}
+
+// heartbeatStalled reports whether the heartbeat sidecar of status has been
+// running for longer than timeout without being ready.
+func heartbeatStalled(status corev1.ContainerStatus, timeout time.Duration, now time.Time) bool {
+	running := status.State.Running
+	return status.Name == heartbeatContainerName && running != nil && !status.Ready &&
+		now.Sub(running.StartedAt.Time) > timeout
+}
+
+// checkHeartbeat sets the Stalled condition of lvBuild from the heartbeat
+// sidecars of the running pods of job, and reports whether the build stalled.
+func (r *LeviathanBuildReconciler) checkHeartbeat(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) (bool, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return false, err
+	}
+	timeout := lvBuild.Spec.Heartbeat.Timeout.Duration
+	now := time.Now()
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if !heartbeatStalled(status, timeout, now) {
+				continue
+			}
+			message := fmt.Sprintf("Pod %s has had no heartbeat for more than %s", pod.Name, timeout)
+			if meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+				Type:               jcrsv1.ConditionStalled,
+				Status:             metav1.ConditionTrue,
+				Reason:             jcrsv1.ReasonHeartbeatMissed,
+				Message:            message,
+				ObservedGeneration: lvBuild.Generation,
+			}) {
+				r.event(lvBuild, corev1.EventTypeWarning, stalledReason, "%s", message)
+			}
+			return true, nil
+		}
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionStalled,
+		Status:             metav1.ConditionFalse,
+		Reason:             jcrsv1.ReasonHeartbeating,
+		Message:            "The build touches its heartbeat file",
+		ObservedGeneration: lvBuild.Generation,
+	})
+	return false, nil
+}
+
+// restartStalled reports whether the stalled run of job is run again, and
+// records the restart.
+func (r *LeviathanBuildReconciler) restartStalled(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) bool {
+	maxRestarts := lvBuild.Spec.Heartbeat.MaxRestarts
+	if lvBuild.Status.StallRestarts >= maxRestarts {
+		return false
+	}
+	lvBuild.Status.StallRestarts++
+	meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionStalled)
+	r.event(lvBuild, corev1.EventTypeWarning, stalledReason,
+		"Job %s stalled, running the build again (%d/%d)", job.Name, lvBuild.Status.StallRestarts, maxRestarts)
+	return true
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Heartbeat", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "release"},
+			Spec: jcrsv1.LeviathanBuildSpec{Heartbeat: &jcrsv1.HeartbeatSpec{
+				Interval:    metav1.Duration{Duration: 5 * time.Minute},
+				Timeout:     metav1.Duration{Duration: 15 * time.Minute},
+				MaxRestarts: 1,
+			}},
+		}
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "release-1"}}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build", Image: "builder", Command: []string{"make"}}}
+	})
+
+	It("tells the build containers where to touch the heartbeat file", func() {
+		addHeartbeat(lvBuild, job)
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.Volumes).To(ConsistOf(HaveField("Name", heartbeatVolumeName)))
+		Expect(podSpec.Containers[0].VolumeMounts).To(ConsistOf(corev1.VolumeMount{Name: heartbeatVolumeName, MountPath: heartbeatMountPath}))
+		Expect(podSpec.Containers[0].Env).To(ConsistOf(
+			corev1.EnvVar{Name: "HEARTBEAT_FILE", Value: "/var/run/leviathan/heartbeat/heartbeat"},
+			corev1.EnvVar{Name: "HEARTBEAT_INTERVAL", Value: "300"},
+		))
+	})
+
+	It("runs the heartbeat sidecar next to the build", func() {
+		r := &LeviathanBuildReconciler{FetcherImage: "controller:v1", NativeSidecars: true}
+		Expect(r.addSidecars(lvBuild, job)).To(Succeed())
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.InitContainers).To(HaveLen(1))
+		heartbeat := podSpec.InitContainers[0]
+		Expect(heartbeat.Name).To(Equal(heartbeatContainerName))
+		Expect(heartbeat.Image).To(Equal("controller:v1"))
+		Expect(heartbeat.RestartPolicy).To(Equal(ptr.To(corev1.ContainerRestartPolicyAlways)))
+		Expect(heartbeat.Env).To(ContainElement(corev1.EnvVar{Name: "HEARTBEAT_TIMEOUT", Value: "15m0s"}))
+		Expect(heartbeat.ReadinessProbe.Exec.Command).To(Equal([]string{"/heartbeat", "check"}))
+		Expect(heartbeat.ReadinessProbe.PeriodSeconds).To(Equal(int32(60)))
+
+		By("watching the markers of the build containers on older clusters")
+		job.Spec.Template.Spec.InitContainers = nil
+		r.NativeSidecars = false
+		Expect(r.addSidecars(lvBuild, job)).To(Succeed())
+
+		podSpec = job.Spec.Template.Spec
+		Expect(podSpec.Containers).To(HaveLen(2))
+		heartbeat = podSpec.Containers[1]
+		Expect(heartbeat.Command).To(Equal([]string{"/heartbeat"}))
+		Expect(heartbeat.Env).To(ContainElement(corev1.EnvVar{Name: "HEARTBEAT_DONE", Value: "/var/run/leviathan/sidecars/build.done"}))
+		Expect(heartbeat.VolumeMounts).To(ContainElement(HaveField("Name", sidecarSignalVolumeName)))
+	})
+
+	It("marks builds whose heartbeat stopped as stalled", func() {
+		now := time.Now()
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: "release-1-abcde", Labels: map[string]string{batchv1.JobNameLabel: job.Name},
+		}}
+		pod.Status.Phase = corev1.PodRunning
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:  heartbeatContainerName,
+			Ready: true,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(-time.Hour))}},
+		}}
+		c := newFakeClient(pod)
+		recorder := record.NewFakeRecorder(10)
+		r := &LeviathanBuildReconciler{Client: c, Recorder: recorder}
+		ctx := context.Background()
+
+		Expect(r.checkHeartbeat(ctx, lvBuild, job)).To(BeFalse())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionStalled)).To(
+			HaveField("Reason", jcrsv1.ReasonHeartbeating))
+
+		pod.Status.InitContainerStatuses[0].Ready = false
+		Expect(c.Status().Update(ctx, pod)).To(Succeed())
+		Expect(r.checkHeartbeat(ctx, lvBuild, job)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionStalled)).To(BeTrue())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Pod release-1-abcde has had no heartbeat")))
+
+		By("restarting the run up to maxRestarts times")
+		Expect(r.restartStalled(lvBuild, job)).To(BeTrue())
+		Expect(lvBuild.Status.StallRestarts).To(Equal(int32(1)))
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionStalled)).To(BeNil())
+		Expect(r.restartStalled(lvBuild, job)).To(BeFalse())
+	})
+
+	DescribeTable("tells stalled heartbeats from starting ones",
+		func(ready bool, startedAgo time.Duration, stalled bool) {
+			now := time.Now()
+			status := corev1.ContainerStatus{
+				Name:  heartbeatContainerName,
+				Ready: ready,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(-startedAgo))}},
+			}
+			Expect(heartbeatStalled(status, 15*time.Minute, now)).To(Equal(stalled))
+		},
+		Entry("ready", true, time.Hour, false),
+		Entry("not ready yet", false, time.Minute, false),
+		Entry("not ready for longer than the timeout", false, time.Hour, true),
+	)
+})
@@ -0,0 +1,64 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UserClientFunc returns a client acting as the named user.
+type UserClientFunc func(user string) (client.Client, error)
+
+// NewUserClientFunc returns a UserClientFunc impersonating users with the given
+// configuration. The clients are created once per user and share the given
+// RESTMapper, usually the one of the manager.
+func NewUserClientFunc(cfg *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper) UserClientFunc {
+	var (
+		mu      sync.Mutex
+		clients = map[string]client.Client{}
+	)
+	return func(user string) (client.Client, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if c, ok := clients[user]; ok {
+			return c, nil
+		}
+
+		impersonating := rest.CopyConfig(cfg)
+		impersonating.Impersonate = rest.ImpersonationConfig{UserName: user}
+		c, err := client.New(impersonating, client.Options{Scheme: scheme, Mapper: mapper})
+		if err != nil {
+			return nil, err
+		}
+		clients[user] = c
+		return c, nil
+	}
+}
+
+// NewServiceAccountClientFunc returns a ServiceAccountClientFunc impersonating
+// ServiceAccounts with the given configuration, as NewUserClientFunc does users.
+func NewServiceAccountClientFunc(cfg *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper) ServiceAccountClientFunc {
+	clientFor := NewUserClientFunc(cfg, scheme, mapper)
+	return func(namespace, name string) (client.Client, error) {
+		return clientFor("system:serviceaccount:" + namespace + ":" + name)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("Impersonating clients", func() {
+	It("creates a client once per ServiceAccount", func() {
+		scheme := newTestScheme()
+		mapper := meta.NewDefaultRESTMapper(nil)
+		clientFor := NewServiceAccountClientFunc(&rest.Config{Host: "https://127.0.0.1:6443"}, scheme, mapper)
+
+		deployer, err := clientFor("default", "deployer")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deployer.RESTMapper()).To(BeIdenticalTo(mapper))
+		Expect(clientFor("default", "deployer")).To(BeIdenticalTo(deployer))
+
+		By("creating separate clients for other ServiceAccounts")
+		Expect(clientFor("default", "publisher")).NotTo(BeIdenticalTo(deployer))
+		Expect(clientFor("staging", "deployer")).NotTo(BeIdenticalTo(deployer))
+	})
+
+	It("creates a client once per user", func() {
+		clientFor := NewUserClientFunc(&rest.Config{Host: "https://127.0.0.1:6443"}, newTestScheme(), meta.NewDefaultRESTMapper(nil))
+		jane, err := clientFor("jane@example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(clientFor("jane@example.com")).To(BeIdenticalTo(jane))
+		Expect(clientFor("joe@example.com")).NotTo(BeIdenticalTo(jane))
+	})
+})
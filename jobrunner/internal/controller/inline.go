@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const (
+	inlineScriptKey        = "build.sh"
+	inlineScriptVolumeName = "inline-source"
+	inlineScriptMountPath  = "/leviathan/source"
+
+	// inlineScriptEnv tells the builder image where to find the script to execute
+	inlineScriptEnv = "LEVIATHAN_INLINE_SCRIPT"
+
+	// scriptHashAnnotation records the script hash on the pod template, so that
+	// changing the script changes the Job spec and the Job gets recreated.
+	scriptHashAnnotation = "jcrs.jcrs.dev/script-hash"
+)
+
+// inlineScriptConfigMapName returns the name of the ConfigMap holding the inline
+// script. Builds with a naming template name it with run index 0; the template is
+// checked before the Job is created, so a template that can't name it falls back
+// to the default name.
+func inlineScriptConfigMapName(lvBuild *jcrsv1.LeviathanBuild) string {
+	if name, err := TemplateName(lvBuild, 0); err == nil && name != "" {
+		return name
+	}
+	return lvBuild.Name + "-script"
+}
+
+// inlineScript returns the inline script of lvBuild, if its source type is Inline.
+func inlineScript(lvBuild *jcrsv1.LeviathanBuild) (string, bool) {
+	if lvBuild.Spec.SourceType != jcrsv1.InlineSource || lvBuild.Spec.Source == nil || lvBuild.Spec.Source.Inline == nil {
+		return "", false
+	}
+	return lvBuild.Spec.Source.Inline.Script, true
+}
+
+// reconcileInlineScript creates or updates the ConfigMap holding the inline script,
+// controlled by owner: lvBuild itself, or the cluster build it stands for. A
+// ConfigMap of the same name that isn't controlled by owner is left alone.
+func (r *LeviathanBuildReconciler) reconcileInlineScript(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, owner client.Object) error {
+	script, ok := inlineScript(lvBuild)
+	if !ok {
+		return nil
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      inlineScriptConfigMapName(lvBuild),
+			Namespace: lvBuild.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		if !cm.CreationTimestamp.IsZero() && !metav1.IsControlledBy(cm, owner) {
+			return fmt.Errorf("ConfigMap %s already exists and doesn't belong to the build", cm.Name)
+		}
+		cm.Data = map[string]string{inlineScriptKey: script}
+		return ctrl.SetControllerReference(owner, cm, r.Scheme)
+	})
+	return err
+}
+
+// addInlineScript mounts the inline script ConfigMap into the build Job.
+func addInlineScript(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	script, ok := inlineScript(lvBuild)
+	if !ok {
+		return
+	}
+	job.Spec.Template.Annotations[scriptHashAnnotation] = contentRevision(script)
+
+	mode := int32(0o555)
+	podSpec := &job.Spec.Template.Spec
+	addVolume(podSpec, corev1.Volume{
+		Name: inlineScriptVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: inlineScriptConfigMapName(lvBuild)},
+				DefaultMode:          &mode,
+			},
+		},
+	})
+	addVolumeMount(podSpec, corev1.VolumeMount{
+		Name:      inlineScriptVolumeName,
+		MountPath: inlineScriptMountPath,
+		ReadOnly:  true,
+	})
+	setEnv(podSpec, corev1.EnvVar{Name: inlineScriptEnv, Value: path.Join(inlineScriptMountPath, inlineScriptKey)})
+}
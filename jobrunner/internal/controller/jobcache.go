@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Most reconciles of a build don't follow a change to it: they follow its Job, its
+pods or a requeue. Constructing the desired Job copies the whole jobTemplate,
+allocates the label and annotation maps and, with jobPatches, round trips the Job
+through JSON, only to end up with the Job of the previous reconcile.
+
+The JobCache keeps the Job constructed for each build along with what it was
+constructed from. The resourceVersion of the build changes with every write to it,
+so it stands for its spec, metadata and status; the status may also have been
+changed in memory by the reconcile before the Job is constructed, so the builder
+image it selected, the pipeline it resolved and the checkpoint resumes it counted
+are compared on their own, as are the parameters read from their sources and
+whether the pull Secret is referenced. The configuration of the reconciler is
+fixed for the life of the cache.
+
+The cached Job is never handed out: every Get returns a copy, which the reconcile
+is free to change.
+*/
+
+// JobCache caches the Jobs constructed for builds.
+type JobCache struct {
+	mu      sync.Mutex
+	entries map[types.NamespacedName]jobCacheEntry
+}
+
+type jobCacheEntry struct {
+	uid             types.UID
+	resourceVersion string
+	builderImage    jcrsv1.ResolvedBuilderImage
+	pipeline        *jcrsv1.ResolvedPipeline
+	resumes         int32
+	params          []corev1.EnvVar
+	pullSecret      bool
+	job             *batchv1.Job
+}
+
+// NewJobCache returns an empty JobCache.
+func NewJobCache() *JobCache {
+	return &JobCache{entries: make(map[types.NamespacedName]jobCacheEntry)}
+}
+
+// Get returns a copy of the Job constructed for lvBuild, constructing it with
+// construct unless lvBuild hasn't changed since it was. Jobs that fail to be
+// constructed aren't cached. A nil JobCache constructs the Job every time.
+func (c *JobCache) Get(lvBuild *jcrsv1.LeviathanBuild, params []corev1.EnvVar, pullSecret bool,
+	construct func(*jcrsv1.LeviathanBuild, []corev1.EnvVar, bool) (*batchv1.Job, error)) (*batchv1.Job, error) {
+	if c == nil {
+		return construct(lvBuild, params, pullSecret)
+	}
+
+	key := types.NamespacedName{Namespace: lvBuild.Namespace, Name: lvBuild.Name}
+	builderImage := builderImageOf(lvBuild)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && entry.uid == lvBuild.UID && entry.resourceVersion == lvBuild.ResourceVersion &&
+		entry.builderImage == builderImage && equality.Semantic.DeepEqual(entry.pipeline, lvBuild.Status.Pipeline) && entry.resumes == lvBuild.Status.CheckpointResumes && entry.pullSecret == pullSecret && equality.Semantic.DeepEqual(entry.params, params) {
+		return entry.job.DeepCopy(), nil
+	}
+
+	job, err := construct(lvBuild, params, pullSecret)
+	if err != nil {
+		return nil, err
+	}
+	entry = jobCacheEntry{
+		uid:             lvBuild.UID,
+		resourceVersion: lvBuild.ResourceVersion,
+		builderImage:    builderImage,
+		pipeline:        lvBuild.Status.Pipeline.DeepCopy(),
+		resumes:         lvBuild.Status.CheckpointResumes,
+		params:          params,
+		pullSecret:      pullSecret,
+		job:             job.DeepCopy(),
+	}
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+	return job, nil
+}
+
+// Forget drops the Job cached for the build key.
+func (c *JobCache) Forget(key types.NamespacedName) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// builderImageOf returns the builder image selected for lvBuild, if any.
+func builderImageOf(lvBuild *jcrsv1.LeviathanBuild) jcrsv1.ResolvedBuilderImage {
+	if lvBuild.Status.BuilderImage == nil {
+		return jcrsv1.ResolvedBuilderImage{}
+	}
+	return *lvBuild.Status.BuilderImage
+}
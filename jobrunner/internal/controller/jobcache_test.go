@@ -0,0 +1,160 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// benchmarkBuild returns a build using most of what the construction of its Job
+// handles, and a reconciler to construct it with.
+func benchmarkBuild() (*jcrsv1.LeviathanBuild, *LeviathanBuildReconciler) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = jcrsv1.AddToScheme(scheme)
+	lvBuild := &jcrsv1.LeviathanBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "default", UID: "web-uid", ResourceVersion: "1",
+			Labels: map[string]string{"team": "web"},
+		},
+		Spec: jcrsv1.LeviathanBuildSpec{
+			PackageName: ptr.To("web"),
+			SourceType:  jcrsv1.GitSource,
+			SourceURL:   ptr.To("https://github.com/example/web.git"),
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name: "build",
+						Args: []string{"make", "build"},
+						Env:  []corev1.EnvVar{{Name: "CI", Value: "true"}},
+					}},
+				}}},
+			},
+			Sidecars:   []corev1.Container{{Name: "docker", Image: "docker:dind"}},
+			JobPatches: []jcrsv1.JobPatch{{Type: jcrsv1.StrategicMergePatch, Patch: "spec:\n  backoffLimit: 0"}},
+		},
+		Status: jcrsv1.LeviathanBuildStatus{
+			BuilderImage: &jcrsv1.ResolvedBuilderImage{Image: "builder:1.0", Mapping: "default"},
+		},
+	}
+	return lvBuild, &LeviathanBuildReconciler{Scheme: scheme, FetcherImage: "fetcher:latest", GitFetcherImage: "git-fetcher:latest", NativeSidecars: true}
+}
+
+var _ = Describe("JobCache", func() {
+	var (
+		lvBuild      *jcrsv1.LeviathanBuild
+		r            *LeviathanBuildReconciler
+		cache        *JobCache
+		constructed  int
+		countedBuild func(*jcrsv1.LeviathanBuild, []corev1.EnvVar, bool) (*batchv1.Job, error)
+	)
+
+	BeforeEach(func() {
+		lvBuild, r = benchmarkBuild()
+		cache = NewJobCache()
+		constructed = 0
+		countedBuild = func(lvBuild *jcrsv1.LeviathanBuild, params []corev1.EnvVar, pullSecret bool) (*batchv1.Job, error) {
+			constructed++
+			return r.constructJob(lvBuild, params, pullSecret)
+		}
+	})
+
+	It("constructs the Job again once the build has changed", func() {
+		job, err := cache.Get(lvBuild, nil, false, countedBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(constructed).To(Equal(1))
+
+		By("handing out copies")
+		job.Spec.Template.Spec.Containers[0].Image = "changed"
+		cached, err := cache.Get(lvBuild, nil, false, countedBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(constructed).To(Equal(1))
+		Expect(cached.Spec.Template.Spec.Containers[0].Image).To(Equal("builder:1.0"))
+
+		lvBuild.ResourceVersion = "2"
+		_, _ = cache.Get(lvBuild, nil, false, countedBuild)
+		Expect(constructed).To(Equal(2))
+
+		lvBuild.Status.BuilderImage = &jcrsv1.ResolvedBuilderImage{Image: "builder:2.0", Mapping: "default"}
+		job, _ = cache.Get(lvBuild, nil, false, countedBuild)
+		Expect(constructed).To(Equal(3))
+		Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal("builder:2.0"))
+
+		_, _ = cache.Get(lvBuild, []corev1.EnvVar{{Name: "REGION", Value: "eu"}}, false, countedBuild)
+		Expect(constructed).To(Equal(4))
+		_, _ = cache.Get(lvBuild, []corev1.EnvVar{{Name: "REGION", Value: "eu"}}, true, countedBuild)
+		Expect(constructed).To(Equal(5))
+
+		cache.Forget(types.NamespacedName{Namespace: "default", Name: "web"})
+		_, _ = cache.Get(lvBuild, []corev1.EnvVar{{Name: "REGION", Value: "eu"}}, true, countedBuild)
+		Expect(constructed).To(Equal(6))
+	})
+
+	It("doesn't cache Jobs that fail to be constructed", func() {
+		lvBuild.Spec.JobPatches = []jcrsv1.JobPatch{{Patch: `{"spec": {"suspended": true}}`}}
+		_, err := cache.Get(lvBuild, nil, false, countedBuild)
+		Expect(err).To(HaveOccurred())
+		_, err = cache.Get(lvBuild, nil, false, countedBuild)
+		Expect(err).To(HaveOccurred())
+		Expect(constructed).To(Equal(2))
+	})
+
+	It("allocates a fraction of a construction on a hit", func() {
+		constructAllocs := testing.AllocsPerRun(20, func() { _, _ = r.constructJob(lvBuild, nil, false) })
+		_, _ = cache.Get(lvBuild, nil, false, r.constructJob)
+		hitAllocs := testing.AllocsPerRun(20, func() { _, _ = cache.Get(lvBuild, nil, false, r.constructJob) })
+		Expect(hitAllocs).To(BeNumerically("<", constructAllocs/4))
+	})
+})
+
+func BenchmarkConstructJob(b *testing.B) {
+	lvBuild, r := benchmarkBuild()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := r.constructJob(lvBuild, nil, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJobCacheHit(b *testing.B) {
+	lvBuild, r := benchmarkBuild()
+	cache := NewJobCache()
+	if _, err := cache.Get(lvBuild, nil, false, r.constructJob); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := cache.Get(lvBuild, nil, false, r.constructJob); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
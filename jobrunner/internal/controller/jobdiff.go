@@ -0,0 +1,186 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+/*
+A Job that no longer matches its build is replaced, which throws its progress
+away, so the controller says why. diffJobSpecs walks the spec of the existing Job
+and the desired one side by side and returns the paths of the fields that differ,
+such as template.spec.containers[build].image. Lists of named items, like
+containers, env and volumes, are matched by name, so a container added in front
+of the others is one change rather than a change to every container.
+
+Fields are compared with the semantic equality of Kubernetes, like the decision
+to replace the Job, so quantities such as "1" and "1000m" are equal and every
+difference found is one the controller acts on.
+*/
+
+const (
+	// maxReportedChanges is the number of changed fields named in logs, events and conditions
+	maxReportedChanges = 5
+
+	// jobOutOfDateReason is the reason of the Event recorded when a Job is replaced
+	jobOutOfDateReason = "JobOutOfDate"
+)
+
+// diffJobSpecs returns the sorted paths of the fields that differ between the
+// existing and the desired Job spec. It returns nil when they're equal.
+func diffJobSpecs(existing, desired *batchv1.JobSpec) []string {
+	var changes []string
+	diffValues("", reflect.ValueOf(*existing), reflect.ValueOf(*desired), &changes)
+	sort.Strings(changes)
+	return changes
+}
+
+// summarizeChanges renders the changes returned by diffJobSpecs in a short message.
+func summarizeChanges(changes []string) string {
+	if len(changes) <= maxReportedChanges {
+		return strings.Join(changes, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(changes[:maxReportedChanges], ", "), len(changes)-maxReportedChanges)
+}
+
+// diffValues appends the paths under path where a and b differ to changes. A
+// value whose parts are all equal, such as a list in another order, is reported
+// as a whole.
+func diffValues(path string, a, b reflect.Value, changes *[]string) {
+	if equality.Semantic.DeepEqual(a.Interface(), b.Interface()) {
+		return
+	}
+	found := len(*changes)
+	switch a.Kind() {
+	case reflect.Ptr:
+		if !a.IsNil() && !b.IsNil() {
+			diffValues(path, a.Elem(), b.Elem(), changes)
+		}
+	case reflect.Struct:
+		diffStructs(path, a, b, changes)
+	case reflect.Slice:
+		diffSlices(path, a, b, changes)
+	case reflect.Map:
+		if a.Type().Key().Kind() == reflect.String {
+			diffMaps(path, a, b, changes)
+		}
+	}
+	if len(*changes) == found {
+		*changes = append(*changes, strings.TrimPrefix(path, "."))
+	}
+}
+
+// diffStructs compares the exported fields of two structs under their JSON name.
+// Structs with unexported fields, such as quantities, are compared as a whole.
+func diffStructs(path string, a, b reflect.Value, changes *[]string) {
+	t := a.Type()
+	for i := range t.NumField() {
+		if !t.Field(i).IsExported() {
+			return
+		}
+	}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		fieldPath := path
+		switch {
+		case name == "-":
+			continue
+		case f.Anonymous || strings.Contains(opts, "inline"):
+		case name == "":
+			fieldPath += "." + f.Name
+		default:
+			fieldPath += "." + name
+		}
+		diffValues(fieldPath, a.Field(i), b.Field(i), changes)
+	}
+}
+
+// diffSlices compares lists of named items by name, and other lists item by item.
+func diffSlices(path string, a, b reflect.Value, changes *[]string) {
+	if !hasNames(a.Type().Elem()) {
+		if a.Len() == b.Len() {
+			for i := range a.Len() {
+				diffValues(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i), changes)
+			}
+		}
+		return
+	}
+
+	byName := func(v reflect.Value) (map[string]reflect.Value, []string) {
+		items := make(map[string]reflect.Value, v.Len())
+		var names []string
+		for i := range v.Len() {
+			name := v.Index(i).FieldByName("Name").String()
+			if _, ok := items[name]; !ok {
+				names = append(names, name)
+			}
+			items[name] = v.Index(i)
+		}
+		return items, names
+	}
+	aItems, aNames := byName(a)
+	bItems, bNames := byName(b)
+	for _, name := range aNames {
+		if _, ok := bItems[name]; !ok {
+			*changes = append(*changes, strings.TrimPrefix(path, ".")+"["+name+"]")
+		}
+	}
+	for _, name := range bNames {
+		itemPath := path + "[" + name + "]"
+		if aItem, ok := aItems[name]; ok {
+			diffValues(itemPath, aItem, bItems[name], changes)
+		} else {
+			*changes = append(*changes, strings.TrimPrefix(itemPath, "."))
+		}
+	}
+}
+
+// hasNames reports whether the items of a list are identified by their name.
+func hasNames(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := t.FieldByName("Name")
+	return ok && f.Type.Kind() == reflect.String
+}
+
+// diffMaps compares two maps with string keys key by key.
+func diffMaps(path string, a, b reflect.Value, changes *[]string) {
+	keys := make(map[string]bool)
+	for _, m := range []reflect.Value{a, b} {
+		for _, key := range m.MapKeys() {
+			keys[key.String()] = true
+		}
+	}
+	for key := range keys {
+		keyPath := path + "[" + key + "]"
+		av, bv := a.MapIndex(reflect.ValueOf(key).Convert(a.Type().Key())), b.MapIndex(reflect.ValueOf(key).Convert(b.Type().Key()))
+		if !av.IsValid() || !bv.IsValid() {
+			*changes = append(*changes, strings.TrimPrefix(keyPath, "."))
+			continue
+		}
+		diffValues(keyPath, av, bv, changes)
+	}
+}
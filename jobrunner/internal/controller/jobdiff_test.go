@@ -0,0 +1,86 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("Job spec diff", func() {
+	var existing *batchv1.JobSpec
+
+	BeforeEach(func() {
+		existing = &batchv1.JobSpec{
+			BackoffLimit: ptr.To[int32](2),
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:  "build",
+					Image: "golang:1.24",
+					Env:   []corev1.EnvVar{{Name: "GOFLAGS", Value: "-mod=mod"}, {Name: "CGO_ENABLED", Value: "0"}},
+					Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1"),
+					}},
+				}},
+			}},
+		}
+	})
+
+	It("finds no change between equal specs", func() {
+		desired := existing.DeepCopy()
+		desired.Template.Spec.Containers[0].Resources.Limits[corev1.ResourceCPU] = resource.MustParse("1000m")
+		Expect(diffJobSpecs(existing, desired)).To(BeEmpty())
+	})
+
+	It("names the fields that changed", func() {
+		desired := existing.DeepCopy()
+		build := &desired.Template.Spec.Containers[0]
+		build.Image = "golang:1.25"
+		build.Env[1].Value = "1"
+		build.Env = append(build.Env, corev1.EnvVar{Name: "GOPROXY", Value: "off"})
+		build.Resources.Limits[corev1.ResourceMemory] = resource.MustParse("1Gi")
+		build.Resources.Limits[corev1.ResourceCPU] = resource.MustParse("2")
+		desired.BackoffLimit = nil
+		desired.Template.Spec.Containers = append([]corev1.Container{{Name: "lint"}}, *build)
+
+		Expect(diffJobSpecs(existing, desired)).To(Equal([]string{
+			"backoffLimit",
+			"template.spec.containers[build].env[CGO_ENABLED].value",
+			"template.spec.containers[build].env[GOPROXY]",
+			"template.spec.containers[build].image",
+			"template.spec.containers[build].resources.limits[cpu]",
+			"template.spec.containers[build].resources.limits[memory]",
+			"template.spec.containers[lint]",
+		}))
+	})
+
+	It("reports lists that only changed order as a whole", func() {
+		desired := existing.DeepCopy()
+		env := desired.Template.Spec.Containers[0].Env
+		env[0], env[1] = env[1], env[0]
+		Expect(diffJobSpecs(existing, desired)).To(Equal([]string{"template.spec.containers[build].env"}))
+	})
+
+	It("summarizes long diffs", func() {
+		Expect(summarizeChanges([]string{"a", "b"})).To(Equal("a, b"))
+		Expect(summarizeChanges([]string{"a", "b", "c", "d", "e", "f", "g"})).To(Equal("a, b, c, d, e and 2 more"))
+	})
+})
@@ -0,0 +1,183 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+What goes wrong with a run is mostly told by the Events of its Job and pods: a
+pod that can't be scheduled, a container restarting in a loop or killed for its
+memory. They're recorded on objects the owner of the build seldom looks at, and
+are gone with the Job.
+
+The JobEventReconciler records the Warning Events of the Jobs of builds and of
+their pods with the reasons below on the builds again, with the same type and
+reason, and the message prefixed with the object it was recorded on, so that
+kubectl describe on a build tells what happened to its runs. An Event seen again
+is recorded again, and counted along with the first by the recorder. Events
+recorded before the reconciler started aren't mirrored, so that a restart
+doesn't record them twice.
+*/
+
+// mirroredEventReasons are the reasons of the Events recorded on builds again
+var mirroredEventReasons = map[string]bool{
+	"FailedScheduling": true,
+	"BackOff":          true,
+	"OOMKilling":       true,
+	"FailedMount":      true,
+	"Evicted":          true,
+}
+
+// mirrorsEvent reports whether event is an Event of a Job or a pod mirrored on builds.
+func mirrorsEvent(event *corev1.Event) bool {
+	return event.Type == corev1.EventTypeWarning && mirroredEventReasons[event.Reason] &&
+		(event.InvolvedObject.Kind == "Pod" || event.InvolvedObject.Kind == "Job")
+}
+
+// mirroredEvents selects the Events mirrored on builds.
+var mirroredEvents = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	event, ok := obj.(*corev1.Event)
+	return ok && mirrorsEvent(event)
+})
+
+// mirroredEvent is an Event as it was last mirrored.
+type mirroredEvent struct {
+	uid   types.UID
+	count int32
+}
+
+// JobEventReconciler records the Events of the Jobs of builds and their pods on the builds.
+type JobEventReconciler struct {
+	client.Client
+
+	// Recorder records the Events on builds.
+	Recorder record.EventRecorder
+
+	// started is when the reconciler started, Events seen last before aren't mirrored
+	started time.Time
+
+	mu sync.Mutex
+	// mirrored are the Events mirrored, by key
+	mirrored map[types.NamespacedName]mirroredEvent
+}
+
+// Reconcile records the Event on the build owning the Job or pod it is about.
+func (r *JobEventReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var event corev1.Event
+	if err := r.Get(ctx, req.NamespacedName, &event); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			r.forget(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !mirrorsEvent(&event) || eventTime(&event).Before(r.started) || !r.observe(&event) {
+		return ctrl.Result{}, nil
+	}
+
+	lvBuild, err := r.buildOf(ctx, &event)
+	if lvBuild == nil || err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	r.Recorder.Eventf(lvBuild, event.Type, event.Reason, "%s %s: %s", event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Message)
+	return ctrl.Result{}, nil
+}
+
+// observe records the count of event, and reports whether it wasn't mirrored at
+// that count yet.
+func (r *JobEventReconciler) observe(event *corev1.Event) bool {
+	count := event.Count
+	if event.Series != nil {
+		count = event.Series.Count
+	}
+	key := client.ObjectKeyFromObject(event)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mirrored == nil {
+		r.mirrored = map[types.NamespacedName]mirroredEvent{}
+	}
+	if last, ok := r.mirrored[key]; ok && last.uid == event.UID && last.count >= count {
+		return false
+	}
+	r.mirrored[key] = mirroredEvent{uid: event.UID, count: count}
+	return true
+}
+
+// forget drops what was mirrored of the deleted Event key.
+func (r *JobEventReconciler) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mirrored, key)
+}
+
+// buildOf returns the build owning the Job event is about, or the Job of the pod
+// event is about, or nil when it isn't owned by a build.
+func (r *JobEventReconciler) buildOf(ctx context.Context, event *corev1.Event) (*jcrsv1.LeviathanBuild, error) {
+	involved := event.InvolvedObject
+	jobName := involved.Name
+	if involved.Kind == "Pod" {
+		var pod corev1.Pod
+		if err := r.Get(ctx, client.ObjectKey{Namespace: involved.Namespace, Name: involved.Name}, &pod); err != nil {
+			return nil, err
+		}
+		owner := metav1.GetControllerOf(&pod)
+		if owner == nil || owner.Kind != "Job" || owner.APIVersion != batchv1.SchemeGroupVersion.String() {
+			return nil, nil
+		}
+		jobName = owner.Name
+	}
+	var job batchv1.Job
+	if err := r.Get(ctx, client.ObjectKey{Namespace: involved.Namespace, Name: jobName}, &job); err != nil {
+		return nil, err
+	}
+	owner := metav1.GetControllerOf(&job)
+	if owner == nil || owner.APIVersion != apiGVStr || owner.Kind != "LeviathanBuild" {
+		return nil, nil
+	}
+	lvBuild := &jcrsv1.LeviathanBuild{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: job.Namespace, Name: owner.Name}, lvBuild); err != nil {
+		return nil, err
+	}
+	if lvBuild.UID != owner.UID {
+		return nil, nil
+	}
+	return lvBuild, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *JobEventReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.started = time.Now()
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Event{}, builder.WithPredicates(mirroredEvents)).
+		Named("jobevents").
+		Complete(r)
+}
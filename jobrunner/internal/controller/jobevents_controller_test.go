@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Job event mirroring", func() {
+	var (
+		ctx      context.Context
+		c        client.Client
+		recorder *record.FakeRecorder
+		r        *JobEventReconciler
+		started  time.Time
+	)
+
+	newEvent := func(name, kind, involved, eventType, reason string, count int32, seen time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "team", UID: types.UID("uid-" + name)},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Namespace: "team", Name: involved},
+			Type:           eventType,
+			Reason:         reason,
+			Message:        "0/3 nodes are available: 3 Insufficient cpu.",
+			Count:          count,
+			LastTimestamp:  metav1.NewTime(seen),
+		}
+	}
+
+	reconcile := func(event *corev1.Event) {
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(event)})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		lvBuild := utiltesting.MakeLeviathanBuild("web", "team").Obj()
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-1", Namespace: "team", UID: "job-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: jcrsv1.GroupVersion.String(), Kind: "LeviathanBuild", Name: "web", UID: "web-uid", Controller: ptr.To(true),
+			}},
+		}}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-1-x7k2p", Namespace: "team",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "batch/v1", Kind: "Job", Name: "web-1", UID: "job-uid", Controller: ptr.To(true),
+			}},
+		}}
+		other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "team"}}
+		c = newFakeClient(lvBuild, job, pod, other)
+		recorder = record.NewFakeRecorder(10)
+		started = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		r = &JobEventReconciler{Client: c, Recorder: recorder, started: started}
+	})
+
+	It("records the Warnings of the pods and Jobs of builds on the builds", func() {
+		scheduling := newEvent("web-1-x7k2p.1", "Pod", "web-1-x7k2p", corev1.EventTypeWarning, "FailedScheduling", 1, started.Add(time.Minute))
+		Expect(c.Create(ctx, scheduling)).To(Succeed())
+		reconcile(scheduling)
+		Expect(recorder.Events).To(Receive(Equal("Warning FailedScheduling Pod web-1-x7k2p: 0/3 nodes are available: 3 Insufficient cpu.")))
+
+		By("recording it once per occurrence")
+		reconcile(scheduling)
+		Expect(recorder.Events).NotTo(Receive())
+		scheduling.Count = 2
+		Expect(c.Update(ctx, scheduling)).To(Succeed())
+		reconcile(scheduling)
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning FailedScheduling Pod web-1-x7k2p")))
+
+		By("recording the Events of the Job")
+		backoff := newEvent("web-1.1", "Job", "web-1", corev1.EventTypeWarning, "BackOff", 1, started.Add(time.Minute))
+		Expect(c.Create(ctx, backoff)).To(Succeed())
+		reconcile(backoff)
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning BackOff Job web-1: ")))
+	})
+
+	It("leaves the other Events alone", func() {
+		for _, event := range []*corev1.Event{
+			newEvent("web-1-x7k2p.2", "Pod", "web-1-x7k2p", corev1.EventTypeNormal, "Scheduled", 1, started.Add(time.Minute)),
+			newEvent("web-1-x7k2p.3", "Pod", "web-1-x7k2p", corev1.EventTypeWarning, "FailedScheduling", 1, started.Add(-time.Minute)),
+			newEvent("db-0.1", "Pod", "db-0", corev1.EventTypeWarning, "BackOff", 1, started.Add(time.Minute)),
+			newEvent("gone.1", "Pod", "gone", corev1.EventTypeWarning, "BackOff", 1, started.Add(time.Minute)),
+		} {
+			Expect(c.Create(ctx, event)).To(Succeed())
+			reconcile(event)
+		}
+		Expect(recorder.Events).NotTo(Receive())
+	})
+})
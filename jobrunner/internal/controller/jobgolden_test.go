@@ -0,0 +1,201 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The Jobs constructed for a matrix of builds, every source type by every build
+type, with and without the optional settings of the spec, are compared to the
+golden manifests of testdata/jobs. A change to the construction of Jobs that
+changes what gets deployed fails these tests until the manifests are rewritten
+with make update-golden, so that the change shows up in the diff of the
+manifests.
+*/
+
+var updateGolden = flag.Bool("update-golden", false, "Rewrite the golden Job manifests of testdata/jobs.")
+
+// goldenSources are the builds of every source type, by source type.
+var goldenSources = map[jcrsv1.SourceType]func(*jcrsv1.LeviathanBuildSpec){
+	jcrsv1.LocalSource: func(spec *jcrsv1.LeviathanBuildSpec) {
+		spec.SourcePath = ptr.To("/src/web")
+	},
+	jcrsv1.GitSource: func(spec *jcrsv1.LeviathanBuildSpec) {
+		spec.SourceURL = ptr.To("https://github.com/example/web.git")
+		spec.Source = &jcrsv1.SourceSpec{Git: &jcrsv1.GitSourceSpec{
+			Submodules: jcrsv1.RecursiveSubmodules, LFS: true, SparsePaths: []string{"services/web"},
+		}}
+	},
+	jcrsv1.S3Source: func(spec *jcrsv1.LeviathanBuildSpec) {
+		spec.SourceURL = ptr.To("s3://sources/web.tar.gz")
+	},
+	jcrsv1.InlineSource: func(spec *jcrsv1.LeviathanBuildSpec) {
+		spec.Source = &jcrsv1.SourceSpec{Inline: &jcrsv1.InlineSourceSpec{Script: "make build"}}
+	},
+	jcrsv1.HTTPSource: func(spec *jcrsv1.LeviathanBuildSpec) {
+		spec.SourceURL = ptr.To("https://downloads.example.com/web.tar.gz")
+		spec.Source = &jcrsv1.SourceSpec{HTTP: &jcrsv1.HTTPSourceSpec{
+			SecretRef: &corev1.LocalObjectReference{Name: "downloads"}, CacheClaimName: ptr.To("web-cache"),
+		}}
+	},
+}
+
+// goldenOverrides sets the optional settings of the spec that change the Job.
+func goldenOverrides(spec *jcrsv1.LeviathanBuildSpec) {
+	spec.JobTemplate.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+	}
+	spec.Volumes = []corev1.Volume{{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	spec.VolumeMounts = []corev1.VolumeMount{{Name: "cache", MountPath: "/cache"}}
+	spec.Sidecars = []corev1.Container{{Name: "docker", Image: "docker:dind"}}
+	spec.JobPatches = []jcrsv1.JobPatch{{Type: jcrsv1.StrategicMergePatch, Patch: "spec:\n  backoffLimit: 0"}}
+	spec.SpotPolicy = jcrsv1.SpotPrefer
+	spec.ProtectionPolicy = jcrsv1.ProtectFromEviction
+	spec.Checkpoint = &jcrsv1.CheckpointSpec{Enabled: true, MaxResumes: 2}
+	spec.Heartbeat = &jcrsv1.HeartbeatSpec{
+		Interval: metav1.Duration{Duration: time.Minute}, Timeout: metav1.Duration{Duration: 10 * time.Minute}, MaxRestarts: 1,
+	}
+	spec.Network = &jcrsv1.NetworkSpec{
+		HTTPSProxy:  ptr.To("http://build-proxy:3128"),
+		TrustBundle: &jcrsv1.TrustBundleRef{Name: "build-ca", Key: "ca.crt"},
+		Isolation:   jcrsv1.StrictIsolation,
+	}
+	spec.DNSPolicy = corev1.DNSNone
+	spec.DNSConfig = &corev1.PodDNSConfig{Nameservers: []string{"10.0.0.10"}}
+	spec.HostAliases = []corev1.HostAlias{{IP: "10.0.0.20", Hostnames: []string{"mirror.internal"}}}
+}
+
+// goldenBuild returns the build of the matrix for sourceType and buildType.
+func goldenBuild(sourceType jcrsv1.SourceType, buildType jcrsv1.BuildType, overrides bool) *jcrsv1.LeviathanBuild {
+	lvBuild := &jcrsv1.LeviathanBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "default", UID: "web-uid",
+			Labels:      map[string]string{"team": "web"},
+			Annotations: map[string]string{"owner": "web@example.com"},
+		},
+		Spec: jcrsv1.LeviathanBuildSpec{
+			PackageName: ptr.To("web"),
+			BuildType:   buildType,
+			SourceType:  sourceType,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name: "build",
+						Args: []string{"make", "build"},
+						Env:  []corev1.EnvVar{{Name: "CI", Value: "true"}},
+					}},
+				}}},
+			},
+		},
+		Status: jcrsv1.LeviathanBuildStatus{
+			BuilderImage: &jcrsv1.ResolvedBuilderImage{Image: "builder:1.0", Mapping: "default"},
+		},
+	}
+	goldenSources[sourceType](&lvBuild.Spec)
+	if buildType == jcrsv1.Publish || buildType == jcrsv1.BuildPublish {
+		lvBuild.Spec.PublishTarget = &jcrsv1.PublishTarget{
+			RegistryURL: "https://registry.example.com/packages", Version: "1.2.3", ConflictPolicy: jcrsv1.FailOnConflict,
+		}
+	}
+	if overrides {
+		goldenOverrides(&lvBuild.Spec)
+	}
+	return lvBuild
+}
+
+// goldenReconciler returns the reconciler the Jobs of the matrix are constructed with.
+func goldenReconciler() *LeviathanBuildReconciler {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = jcrsv1.AddToScheme(scheme)
+	return &LeviathanBuildReconciler{
+		Scheme:          scheme,
+		FetcherImage:    "fetcher:latest",
+		GitFetcherImage: "git-fetcher:latest",
+		NativeSidecars:  true,
+		Network: NetworkConfig{
+			HTTPProxy: "http://proxy:3128", HTTPSProxy: "http://proxy:3128", NoProxy: ".svc,.cluster.local",
+			TrustBundleName: "ca-bundle", TrustBundleKey: "ca-bundle.crt",
+		},
+		Spot: SpotConfig{
+			NodeLabelKey: "node.example.com/spot", NodeLabelValue: "true",
+			Taint: &corev1.Taint{Key: "node.example.com/spot", Effect: corev1.TaintEffectNoSchedule},
+		},
+		ProtectedPriorityClassName: "builds-protected",
+		Verify:                     VerifyConfig{PriorityClassName: "builds-verify"},
+		PullSecret:                 PullSecretConfig{Source: types.NamespacedName{Namespace: "jobrunner-system", Name: "registry"}},
+	}
+}
+
+var _ = Describe("Job construction", func() {
+	sourceTypes := []jcrsv1.SourceType{jcrsv1.LocalSource, jcrsv1.GitSource, jcrsv1.S3Source, jcrsv1.InlineSource, jcrsv1.HTTPSource}
+	buildTypes := []jcrsv1.BuildType{jcrsv1.Build, jcrsv1.BuildPublish, jcrsv1.Publish, jcrsv1.Verify}
+
+	for _, sourceType := range sourceTypes {
+		for _, buildType := range buildTypes {
+			for _, overrides := range []bool{false, true} {
+				variant := "defaults"
+				if overrides {
+					variant = "overrides"
+				}
+				name := strings.ToLower(string(sourceType) + "-" + string(buildType) + "-" + variant)
+
+				It("matches the golden manifest "+name, func() {
+					lvBuild := goldenBuild(sourceType, buildType, overrides)
+					params := []corev1.EnvVar{{Name: "REGION", ValueFrom: &corev1.EnvVarSource{
+						ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "settings"}, Key: "REGION"},
+					}}}
+					job, err := goldenReconciler().constructJob(lvBuild, params, overrides)
+					Expect(err).NotTo(HaveOccurred())
+					got, err := yaml.Marshal(job)
+					Expect(err).NotTo(HaveOccurred())
+
+					path := filepath.Join("testdata", "jobs", name+".yaml")
+					if *updateGolden {
+						Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+						Expect(os.WriteFile(path, got, 0o644)).To(Succeed())
+					}
+					want, err := os.ReadFile(path)
+					Expect(err).NotTo(HaveOccurred(), "run the tests with -update-golden to write the golden manifest")
+					Expect(string(got)).To(Equal(string(want)), "run the tests with -update-golden once the change is intended")
+				})
+			}
+		}
+	}
+})
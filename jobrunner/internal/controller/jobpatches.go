@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The jobPatches of a build are applied last, to the Job with everything the
+controller adds, the way kustomize patches rendered manifests. The patched Job is
+decoded strictly, so a patch setting a field the Job doesn't have fails rather
+than being dropped silently.
+
+The namespace and the owner of the Job can't be patched: they're reset once the
+patches are applied.
+*/
+
+// ApplyJobPatches applies patches to job in order.
+func ApplyJobPatches(job *batchv1.Job, patches []jcrsv1.JobPatch) error {
+	for i, patch := range patches {
+		if err := ApplyJobPatch(job, patch); err != nil {
+			return fmt.Errorf("jobPatches[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ApplyJobPatch applies patch to job.
+func ApplyJobPatch(job *batchv1.Job, patch jcrsv1.JobPatch) error {
+	doc, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if doc, err = applyJobPatch(doc, patch); err != nil {
+		return err
+	}
+
+	patched := &batchv1.Job{}
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(patched); err != nil {
+		return fmt.Errorf("the patched Job is invalid: %w", err)
+	}
+	patched.Namespace = job.Namespace
+	patched.OwnerReferences = job.OwnerReferences
+	*job = *patched
+	return nil
+}
+
+// applyJobPatch applies patch to the JSON document of a Job.
+func applyJobPatch(doc []byte, patch jcrsv1.JobPatch) ([]byte, error) {
+	raw, err := yaml.YAMLToJSON([]byte(patch.Patch))
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	switch patch.Type {
+	case jcrsv1.JSON6902Patch:
+		operations, err := jsonpatch.DecodePatch(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON6902 patch: %w", err)
+		}
+		return operations.Apply(doc)
+	case jcrsv1.StrategicMergePatch, "":
+		return strategicpatch.StrategicMergePatch(doc, raw, batchv1.Job{})
+	default:
+		return nil, fmt.Errorf("unknown patch type %q", patch.Type)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Job patches", func() {
+	var job *batchv1.Job
+
+	BeforeEach(func() {
+		job = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{{Name: "web", Controller: ptr.To(true)}},
+			},
+			Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "build", Image: "node:22"},
+					{Name: "fetch", Image: "fetcher"},
+				},
+			}}},
+		}
+	})
+
+	It("applies strategic merge and JSON6902 patches in order", func() {
+		Expect(ApplyJobPatches(job, []jcrsv1.JobPatch{
+			{Type: jcrsv1.StrategicMergePatch, Patch: `
+spec:
+  activeDeadlineSeconds: 600
+  template:
+    spec:
+      containers:
+      - name: build
+        resources:
+          limits:
+            memory: 4Gi`},
+			{Type: jcrsv1.JSON6902Patch, Patch: `[{"op": "replace", "path": "/spec/activeDeadlineSeconds", "value": 900}]`},
+		})).To(Succeed())
+
+		Expect(job.Spec.ActiveDeadlineSeconds).To(Equal(ptr.To[int64](900)))
+		containers := job.Spec.Template.Spec.Containers
+		Expect(containers).To(HaveLen(2))
+		Expect(containers[0].Image).To(Equal("node:22"))
+		Expect(containers[0].Resources.Limits.Memory().String()).To(Equal("4Gi"))
+		Expect(containers[1].Name).To(Equal("fetch"))
+	})
+
+	It("keeps the namespace and owner of the Job", func() {
+		Expect(ApplyJobPatches(job, []jcrsv1.JobPatch{{Patch: `{"metadata": {"namespace": "kube-system", "ownerReferences": null}}`}})).To(Succeed())
+		Expect(job.Namespace).To(Equal("default"))
+		Expect(job.OwnerReferences).To(HaveLen(1))
+	})
+
+	It("fails on patches that don't apply or set unknown fields", func() {
+		Expect(ApplyJobPatches(job, []jcrsv1.JobPatch{
+			{Patch: `{"spec": {"suspend": true}}`},
+			{Type: jcrsv1.JSON6902Patch, Patch: `[{"op": "remove", "path": "/spec/template/spec/volumes/0"}]`},
+		})).To(MatchError(HavePrefix("jobPatches[1]: ")))
+
+		Expect(ApplyJobPatches(job, []jcrsv1.JobPatch{{Patch: `{"spec": {"suspended": true}}`}})).To(
+			MatchError(ContainSubstring("the patched Job is invalid")))
+		Expect(ApplyJobPatches(job, []jcrsv1.JobPatch{{Patch: `spec: [`}})).To(
+			MatchError(ContainSubstring("invalid patch")))
+	})
+})
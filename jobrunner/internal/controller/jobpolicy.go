@@ -0,0 +1,68 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Sharded builds run as an Indexed Job: every pod gets its shard index in
+$JOB_COMPLETION_INDEX, and the Job completes once a pod of every index has
+succeeded. A successPolicy lets the Job complete earlier, once the shards it
+names have succeeded, for builds where some shards are optional.
+
+successPolicy is only served by Kubernetes 1.31 and later. Older API servers
+silently drop the field, so the Job would wait for every shard, and would never
+match the desired Job, which keeps the field. The controller detects the version
+of the cluster at startup, and fails the construction of the Job of builds with a
+successPolicy on older clusters instead.
+*/
+
+// successPolicyVersion is the first Kubernetes version serving the successPolicy of Jobs
+var successPolicyVersion = version.MajorMinor(1, 31)
+
+// errSuccessPolicyUnsupported is the construction error of Jobs with a successPolicy on older clusters
+var errSuccessPolicyUnsupported = errors.New("jobPolicy.successPolicy requires Kubernetes 1.31 or later")
+
+// SuccessPoliciesSupported reports whether the cluster behind cfg serves the successPolicy of Jobs.
+func SuccessPoliciesSupported(cfg *rest.Config) (bool, error) {
+	return serverVersionAtLeast(cfg, successPolicyVersion)
+}
+
+// addJobPolicy runs the build Job as the shards of the jobPolicy of lvBuild.
+func (r *LeviathanBuildReconciler) addJobPolicy(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) error {
+	policy := lvBuild.Spec.JobPolicy
+	if policy == nil {
+		return nil
+	}
+	if policy.SuccessPolicy != nil && !r.SuccessPolicies {
+		return errSuccessPolicyUnsupported
+	}
+	job.Spec.CompletionMode = ptr.To(batchv1.IndexedCompletion)
+	job.Spec.Completions = ptr.To(policy.Completions)
+	job.Spec.Parallelism = ptr.To(ptr.Deref(policy.Parallelism, policy.Completions))
+	job.Spec.SuccessPolicy = policy.SuccessPolicy.DeepCopy()
+	return nil
+}
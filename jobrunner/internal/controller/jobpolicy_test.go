@@ -0,0 +1,60 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Job policy", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{Spec: jcrsv1.LeviathanBuildSpec{
+			JobPolicy: &jcrsv1.JobPolicy{Completions: 4},
+		}}
+		job = &batchv1.Job{Spec: batchv1.JobSpec{Completions: ptr.To[int32](1), Parallelism: ptr.To[int32](1)}}
+	})
+
+	It("runs the shards of the build as an Indexed Job", func() {
+		Expect((&LeviathanBuildReconciler{}).addJobPolicy(lvBuild, job)).To(Succeed())
+		Expect(job.Spec.CompletionMode).To(Equal(ptr.To(batchv1.IndexedCompletion)))
+		Expect(job.Spec.Completions).To(Equal(ptr.To[int32](4)))
+		Expect(job.Spec.Parallelism).To(Equal(ptr.To[int32](4)))
+		Expect(job.Spec.SuccessPolicy).To(BeNil())
+	})
+
+	It("sets the successPolicy on clusters serving it", func() {
+		lvBuild.Spec.JobPolicy.Parallelism = ptr.To[int32](2)
+		lvBuild.Spec.JobPolicy.SuccessPolicy = &batchv1.SuccessPolicy{Rules: []batchv1.SuccessPolicyRule{{SucceededIndexes: ptr.To("0,1")}}}
+
+		Expect((&LeviathanBuildReconciler{}).addJobPolicy(lvBuild, job)).To(MatchError(errSuccessPolicyUnsupported))
+
+		Expect((&LeviathanBuildReconciler{SuccessPolicies: true}).addJobPolicy(lvBuild, job)).To(Succeed())
+		Expect(job.Spec.Parallelism).To(Equal(ptr.To[int32](2)))
+		Expect(job.Spec.SuccessPolicy).To(Equal(lvBuild.Spec.JobPolicy.SuccessPolicy))
+		Expect(job.Spec.SuccessPolicy).NotTo(BeIdenticalTo(lvBuild.Spec.JobPolicy.SuccessPolicy))
+	})
+})
@@ -0,0 +1,369 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Every run of a build creates a Job, which is kept once finished for its logs and
+status. Deleting the Jobs of past runs from the reconciles of builds holds the
+reconciles back, and serializes the deletions of thousands of namespaces behind
+the workers of the controller. With the ParallelJobPruner gate, the JobPruner
+deletes them instead, from the leader only:
+
+  - the Jobs of the runs of Verify builds before their latest run;
+  - the finished Jobs of the runs of other builds past the historyLimit latest
+    runs, when a historyLimit is set.
+
+Each sweep lists the Jobs of builds from the API server page by page, and hands
+the builds found to a pool of workers as the pages arrive. Every worker takes the
+builds from a deque of its own, and steals from the others once it is empty, so
+that a worker left with a namespace of thousands of Jobs doesn't hold the sweep
+back. The deletions of all the workers share a rate limit, so that a sweep after
+an outage doesn't flood the API server.
+*/
+
+var (
+	jobPrunerDeletedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "jobrunner_job_pruner_deleted_total",
+			Help: "Number of Jobs of past runs of builds deleted by the Job pruner",
+		},
+	)
+	jobPrunerErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "jobrunner_job_pruner_errors_total",
+			Help: "Number of builds whose Jobs the Job pruner failed to prune",
+		},
+	)
+	jobPrunerStolenTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "jobrunner_job_pruner_stolen_total",
+			Help: "Number of builds pruned by a worker of the Job pruner that stole them from another worker",
+		},
+	)
+	jobPrunerSweepDurationSeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "jobrunner_job_pruner_sweep_duration_seconds",
+			Help:    "Duration of the sweeps of the Job pruner",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 14),
+		},
+	)
+	jobPrunerLastSweep = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "jobrunner_job_pruner_last_sweep_timestamp_seconds",
+			Help: "Time of the latest complete sweep of the Job pruner",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(jobPrunerDeletedTotal, jobPrunerErrorsTotal, jobPrunerStolenTotal,
+		jobPrunerSweepDurationSeconds, jobPrunerLastSweep)
+}
+
+// JobPruner deletes the Jobs of the past runs of builds, on an interval.
+type JobPruner struct {
+	// Client reads the builds and their Jobs, from the cache, and deletes Jobs.
+	Client client.Client
+
+	// APIReader lists the Jobs of builds page by page from the API server.
+	APIReader client.Reader
+
+	// HistoryLimit is the number of latest runs whose finished Jobs are kept per
+	// build. Only the Jobs of Verify builds are pruned when 0.
+	HistoryLimit int
+
+	// Workers is the number of builds pruned in parallel.
+	Workers int
+
+	// PageSize is the number of Jobs listed per request.
+	PageSize int64
+
+	// Interval is the time between the starts of two sweeps.
+	Interval time.Duration
+
+	// Limiter paces the deletions of all the workers. They aren't paced when nil.
+	Limiter *rate.Limiter
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only one
+// replica deletes Jobs.
+func (p *JobPruner) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (p *JobPruner) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("job-pruner")
+	ctx = logf.IntoContext(ctx, log)
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Sweep(ctx); err != nil && ctx.Err() == nil {
+			log.Error(err, "Failed to prune the Jobs of builds")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep prunes the Jobs of every build once. It returns the error of the listing
+// of the Jobs, or of the builds whose Jobs couldn't be pruned.
+func (p *JobPruner) Sweep(ctx context.Context) error {
+	log := logf.FromContext(ctx)
+	start := time.Now()
+	workers := max(p.Workers, 1)
+	queue := newStealQueue(workers)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	pruned := 0
+	for worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				key, stolen, ok := queue.pop(worker)
+				if !ok {
+					return
+				}
+				if stolen {
+					jobPrunerStolenTotal.Inc()
+				}
+				deleted, err := p.prune(ctx, key)
+				jobPrunerDeletedTotal.Add(float64(deleted))
+				mu.Lock()
+				pruned += deleted
+				if err != nil {
+					jobPrunerErrorsTotal.Inc()
+					errs = append(errs, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := p.listBuilds(ctx, queue.push)
+	queue.close()
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	jobPrunerSweepDurationSeconds.Observe(time.Since(start).Seconds())
+	jobPrunerLastSweep.SetToCurrentTime()
+	if pruned > 0 {
+		log.Info("Pruned the Jobs of past runs", "deleted", pruned, "duration", time.Since(start))
+	}
+	return nil
+}
+
+// listBuilds lists the Jobs of builds page by page, and calls found once for
+// each build they belong to.
+func (p *JobPruner) listBuilds(ctx context.Context, found func(types.NamespacedName)) error {
+	seen := map[types.NamespacedName]bool{}
+	jobs := &metav1.PartialObjectMetadataList{}
+	jobs.SetGroupVersionKind(batchv1.SchemeGroupVersion.WithKind("JobList"))
+	opts := []client.ListOption{client.HasLabels{buildLabel}, client.Limit(p.PageSize)}
+	for {
+		if err := p.APIReader.List(ctx, jobs, opts...); err != nil {
+			return err
+		}
+		for i := range jobs.Items {
+			owner := metav1.GetControllerOf(&jobs.Items[i])
+			if owner == nil || owner.Kind != "LeviathanBuild" || owner.APIVersion != jcrsv1.GroupVersion.String() {
+				continue
+			}
+			key := types.NamespacedName{Namespace: jobs.Items[i].Namespace, Name: owner.Name}
+			if !seen[key] {
+				seen[key] = true
+				found(key)
+			}
+		}
+		if jobs.Continue == "" {
+			return nil
+		}
+		opts = []client.ListOption{client.HasLabels{buildLabel}, client.Limit(p.PageSize), client.Continue(jobs.Continue)}
+	}
+}
+
+// prune deletes the Jobs of the past runs of the build key the policy doesn't
+// keep, and returns how many it deleted.
+func (p *JobPruner) prune(ctx context.Context, key types.NamespacedName) (int, error) {
+	lvBuild := &jcrsv1.LeviathanBuild{}
+	if err := p.Client.Get(ctx, key, lvBuild); err != nil {
+		// The Jobs of deleted builds are garbage collected along with them
+		return 0, client.IgnoreNotFound(err)
+	}
+	if !verifies(lvBuild) && p.HistoryLimit <= 0 {
+		return 0, nil
+	}
+	var jobs batchv1.JobList
+	if err := p.Client.List(ctx, &jobs, client.InNamespace(key.Namespace), client.MatchingLabels{buildLabel: labelValue(key.Name)}); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, job := range prunableJobs(lvBuild, jobs.Items, p.HistoryLimit) {
+		if p.Limiter != nil {
+			if err := p.Limiter.Wait(ctx); err != nil {
+				return deleted, err
+			}
+		}
+		err := p.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// prunableJobs returns the Jobs of lvBuild among jobs that aren't kept: the Jobs
+// of the runs of Verify builds before their latest run, and the finished Jobs of
+// other builds past the historyLimit latest runs.
+func prunableJobs(lvBuild *jcrsv1.LeviathanBuild, jobs []batchv1.Job, historyLimit int) []*batchv1.Job {
+	type run struct {
+		index int64
+		job   *batchv1.Job
+	}
+	var runs []run
+	latest := lvBuild.Status.RunIndex
+	for i := range jobs {
+		job := &jobs[i]
+		index, err := strconv.ParseInt(job.Labels[runIndexLabel], 10, 64)
+		if err != nil || !metav1.IsControlledBy(job, lvBuild) {
+			continue
+		}
+		runs = append(runs, run{index: index, job: job})
+		latest = max(latest, index)
+	}
+	slices.SortFunc(runs, func(a, b run) int { return cmp.Compare(b.index, a.index) })
+
+	var prunable []*batchv1.Job
+	kept := 1
+	for _, run := range runs {
+		if run.index >= latest {
+			continue
+		}
+		if verifies(lvBuild) {
+			prunable = append(prunable, run.job)
+			continue
+		}
+		if finished, _ := isJobFinished(run.job); !finished {
+			continue
+		}
+		if kept < historyLimit {
+			kept++
+			continue
+		}
+		prunable = append(prunable, run.job)
+	}
+	return prunable
+}
+
+// stealQueue holds the builds of a sweep, in a deque per worker. Builds are
+// pushed to the deques in turn; a worker pops from the back of its deque, and
+// steals from the front of the others once its deque is empty.
+type stealQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	deques [][]types.NamespacedName
+	next   int
+	closed bool
+}
+
+// newStealQueue returns an empty stealQueue for the given number of workers.
+func newStealQueue(workers int) *stealQueue {
+	q := &stealQueue{deques: make([][]types.NamespacedName, workers)}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds key to the deque of the next worker.
+func (q *stealQueue) push(key types.NamespacedName) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deques[q.next] = append(q.deques[q.next], key)
+	q.next = (q.next + 1) % len(q.deques)
+	q.cond.Signal()
+}
+
+// close tells the workers no more builds are pushed.
+func (q *stealQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// pop returns the next build for worker, and whether it was stolen from another
+// worker. It waits for a build until the queue is closed, and returns false once
+// it is closed and empty.
+func (q *stealQueue) pop(worker int) (types.NamespacedName, bool, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if own := q.deques[worker]; len(own) > 0 {
+			key := own[len(own)-1]
+			q.deques[worker] = own[:len(own)-1]
+			return key, false, true
+		}
+		for i := 1; i < len(q.deques); i++ {
+			victim := (worker + i) % len(q.deques)
+			if other := q.deques[victim]; len(other) > 0 {
+				key := other[0]
+				q.deques[victim] = other[1:]
+				return key, true, true
+			}
+		}
+		if q.closed {
+			return types.NamespacedName{}, false, false
+		}
+		q.cond.Wait()
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Job pruner", func() {
+	var (
+		ctx context.Context
+		c   client.Client
+	)
+
+	newBuild := func(namespace, name string, buildType jcrsv1.BuildType, runIndex int64) *jcrsv1.LeviathanBuild {
+		return &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: types.UID(namespace + "-" + name)},
+			Spec:       jcrsv1.LeviathanBuildSpec{BuildType: buildType},
+			Status:     jcrsv1.LeviathanBuildStatus{RunIndex: runIndex},
+		}
+	}
+
+	// newJob returns the Job of the run runIndex of lvBuild, finished unless running.
+	newJob := func(lvBuild *jcrsv1.LeviathanBuild, runIndex int64, running bool) *batchv1.Job {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Namespace: lvBuild.Namespace,
+			Name:      fmt.Sprintf("%s-%d", lvBuild.Name, runIndex),
+			Labels:    map[string]string{buildLabel: lvBuild.Name, runIndexLabel: strconv.FormatInt(runIndex, 10)},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: jcrsv1.GroupVersion.String(), Kind: "LeviathanBuild", Name: lvBuild.Name, UID: lvBuild.UID, Controller: ptr.To(true),
+			}},
+		}}
+		if !running {
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		}
+		return job
+	}
+
+	jobNames := func() []string {
+		var jobs batchv1.JobList
+		Expect(c.List(ctx, &jobs)).To(Succeed())
+		var names []string
+		for _, job := range jobs.Items {
+			names = append(names, job.Namespace+"/"+job.Name)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = newFakeClient()
+	})
+
+	It("selects the Jobs past the history limit, and the earlier runs of Verify builds", func() {
+		lvBuild := newBuild("ci", "web", jcrsv1.Build, 5)
+		jobs := []batchv1.Job{
+			*newJob(lvBuild, 1, false), *newJob(lvBuild, 2, true), *newJob(lvBuild, 3, false),
+			*newJob(lvBuild, 4, false), *newJob(lvBuild, 5, false),
+		}
+		names := func(jobs []*batchv1.Job) []string {
+			var names []string
+			for _, job := range jobs {
+				names = append(names, job.Name)
+			}
+			return names
+		}
+		Expect(names(prunableJobs(lvBuild, jobs, 2))).To(Equal([]string{"web-3", "web-1"}))
+		Expect(names(prunableJobs(lvBuild, jobs, 5))).To(BeEmpty())
+
+		By("keeping the Job of the latest run, even past the latest recorded one")
+		lvBuild.Status.RunIndex = 4
+		Expect(names(prunableJobs(lvBuild, jobs, 1))).To(Equal([]string{"web-4", "web-3", "web-1"}))
+
+		By("deleting every earlier run of Verify builds")
+		lvBuild.Spec.BuildType = jcrsv1.Verify
+		Expect(names(prunableJobs(lvBuild, jobs, 0))).To(Equal([]string{"web-4", "web-3", "web-2", "web-1"}))
+	})
+
+	It("prunes the Jobs of every build with a pool of workers", func() {
+		var builds []*jcrsv1.LeviathanBuild
+		for i := range 6 {
+			buildType := jcrsv1.Build
+			if i%3 == 0 {
+				buildType = jcrsv1.Verify
+			}
+			lvBuild := newBuild(fmt.Sprintf("team-%d", i%2), fmt.Sprintf("app-%d", i), buildType, 3)
+			builds = append(builds, lvBuild)
+			Expect(c.Create(ctx, lvBuild)).To(Succeed())
+			for run := int64(1); run <= 3; run++ {
+				Expect(c.Create(ctx, newJob(lvBuild, run, false))).To(Succeed())
+			}
+		}
+		// The Jobs of deleted builds are left to the garbage collector
+		Expect(c.Create(ctx, newJob(newBuild("team-0", "gone", jcrsv1.Verify, 3), 1, false))).To(Succeed())
+
+		pruner := &JobPruner{Client: c, APIReader: c, HistoryLimit: 2, Workers: 3, PageSize: 4, Limiter: rate.NewLimiter(rate.Inf, 1)}
+		Expect(pruner.Sweep(ctx)).To(Succeed())
+
+		var expected []string
+		for _, lvBuild := range builds {
+			first := int64(2)
+			if lvBuild.Spec.BuildType == jcrsv1.Verify {
+				first = 3
+			}
+			for run := first; run <= 3; run++ {
+				expected = append(expected, fmt.Sprintf("%s/%s-%d", lvBuild.Namespace, lvBuild.Name, run))
+			}
+		}
+		expected = append(expected, "team-0/gone-1")
+		Expect(jobNames()).To(ConsistOf(expected))
+
+		By("only pruning Verify builds without a history limit")
+		pruner.HistoryLimit = 0
+		Expect(c.Create(ctx, newJob(builds[1], 1, false))).To(Succeed())
+		Expect(pruner.Sweep(ctx)).To(Succeed())
+		Expect(jobNames()).To(ContainElement("team-1/app-1-1"))
+	})
+
+	It("lets idle workers steal the builds of the others", func() {
+		queue := newStealQueue(2)
+		for i := range 4 {
+			queue.push(types.NamespacedName{Namespace: "ci", Name: strconv.Itoa(i)})
+		}
+		queue.close()
+
+		var popped []string
+		var stolen []bool
+		for {
+			key, steal, ok := queue.pop(1)
+			if !ok {
+				break
+			}
+			popped = append(popped, key.Name)
+			stolen = append(stolen, steal)
+		}
+		Expect(popped).To(Equal([]string{"3", "1", "0", "2"}))
+		Expect(stolen).To(Equal([]bool{false, false, true, true}))
+	})
+})
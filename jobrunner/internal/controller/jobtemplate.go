@@ -0,0 +1,84 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A Job the API server refuses, because of an invalid field, a quota, the Pod
+Security admission of the namespace or a name taken by another Job, can't be
+fixed by retrying. Before the real Job is created, it is sent as a server-side
+dry run, and a rejection is recorded with the exact message of the API server as
+the InvalidJobTemplate condition instead of failing every reconcile. Rejections caused by the state of the
+namespace, like an exhausted quota, may go away on their own, so the build is
+still checked again from time to time.
+*/
+
+const (
+	// invalidJobTemplateRetryInterval is how often a rejected Job is tried again
+	invalidJobTemplateRetryInterval = 5 * time.Minute
+)
+
+// setInvalidJobTemplate records why the Job of lvBuild can't be created, or that
+// it can when err is nil.
+func setInvalidJobTemplate(lvBuild *jcrsv1.LeviathanBuild, reason string, err error) {
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionInvalidJobTemplate,
+		Status:             metav1.ConditionFalse,
+		Reason:             jcrsv1.ReasonAccepted,
+		Message:            "The Job of the build is accepted by the API server",
+		ObservedGeneration: lvBuild.Generation,
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = reason
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, condition)
+}
+
+// isJobRejected reports whether err is the API server refusing the Job itself.
+func isJobRejected(err error) bool {
+	if isNamespaceTerminatingError(err) {
+		return false
+	}
+	return apierrors.IsInvalid(err) || apierrors.IsForbidden(err) || apierrors.IsBadRequest(err) ||
+		apierrors.IsRequestEntityTooLargeError(err) || apierrors.IsAlreadyExists(err)
+}
+
+// dryRunJob creates job as a dry run. A rejection is recorded as the
+// InvalidJobTemplate condition of lvBuild and reported as false; other errors are
+// returned.
+func (r *LeviathanBuildReconciler) dryRunJob(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) (bool, error) {
+	err := r.Create(ctx, job.DeepCopy(), client.DryRunAll)
+	if err != nil && !isJobRejected(err) {
+		return false, err
+	}
+	setInvalidJobTemplate(lvBuild, string(apierrors.ReasonForError(err)), err)
+	return err == nil, nil
+}
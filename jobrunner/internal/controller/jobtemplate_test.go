@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Job dry runs", func() {
+	var (
+		lvBuild   *jcrsv1.LeviathanBuild
+		job       *batchv1.Job
+		createErr error
+		dryRun    bool
+		r         *LeviathanBuildReconciler
+	)
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 2}}
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{GenerateName: "web-1-", Namespace: "default"}}
+		createErr, dryRun = nil, false
+		c := newFakeClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(_ context.Context, _ client.WithWatch, _ client.Object, opts ...client.CreateOption) error {
+				createOpts := &client.CreateOptions{}
+				createOpts.ApplyOptions(opts)
+				dryRun = slices.Contains(createOpts.DryRun, metav1.DryRunAll)
+				return createErr
+			},
+		}).Build()
+		r = &LeviathanBuildReconciler{Client: c}
+	})
+
+	It("records an accepted Job", func() {
+		Expect(r.dryRunJob(context.Background(), lvBuild, job)).To(BeTrue())
+		Expect(dryRun).To(BeTrue())
+		condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionInvalidJobTemplate)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.ObservedGeneration).To(Equal(int64(2)))
+	})
+
+	It("records the message of the API server when the Job is rejected", func() {
+		createErr = apierrors.NewForbidden(schema.GroupResource{Group: "batch", Resource: "jobs"}, "web-1-abcde",
+			errors.New(`exceeded quota: compute, requested: limits.cpu=8, limited: limits.cpu=4`))
+		Expect(r.dryRunJob(context.Background(), lvBuild, job)).To(BeFalse())
+		condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionInvalidJobTemplate)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("Forbidden"))
+		Expect(condition.Message).To(Equal(createErr.Error()))
+	})
+
+	It("returns errors that are not about the Job", func() {
+		createErr = apierrors.NewServiceUnavailable("etcd is down")
+		_, err := r.dryRunJob(context.Background(), lvBuild, job)
+		Expect(err).To(MatchError(createErr))
+		Expect(lvBuild.Status.Conditions).To(BeEmpty())
+	})
+})
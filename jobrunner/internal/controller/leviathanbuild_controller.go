@@ -18,50 +18,354 @@ package controller
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
-	"k8s.io/apimachinery/pkg/api/equality"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/apihealth"
+	"test.jcrs.dev/jobrunner/internal/archive"
+	"test.jcrs.dev/jobrunner/internal/cloudevents"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	"test.jcrs.dev/jobrunner/internal/history"
+	"test.jcrs.dev/jobrunner/internal/imagepoll"
+	"test.jcrs.dev/jobrunner/internal/interop"
+	"test.jcrs.dev/jobrunner/internal/progress"
+	"test.jcrs.dev/jobrunner/internal/registry"
+	"test.jcrs.dev/jobrunner/internal/retention"
+	"test.jcrs.dev/jobrunner/internal/runlogs"
+	"test.jcrs.dev/jobrunner/internal/signing"
+	"test.jcrs.dev/jobrunner/internal/sourcepoll"
 )
 
 // LeviathanBuildReconciler reconciles a LeviathanBuild object
 type LeviathanBuildReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Registry is used to check whether a version is already published before
+	// a publishing build starts. The check is skipped when nil.
+	Registry registry.Checker
+
+	// FetcherImage is the image running the fetch init container of builds whose
+	// source is downloaded by the controller, and the heartbeat sidecar of builds
+	// with a heartbeat.
+	FetcherImage string
+
+	// GitFetcherImage is the image running the fetch init container of builds
+	// whose Git source is cloned by the controller. It holds the fetcher, git and
+	// git-lfs.
+	GitFetcherImage string
+
+	// Backoff decides when failing reconciles are retried. Errors are returned
+	// to the workqueue unchanged when nil.
+	Backoff *Backoff
+
+	// Stats keeps how often and how long each build is reconciled, served on
+	// /debug/controller. Reconciles aren't tracked when nil.
+	Stats *ReconcileStats
+
+	// JobCache keeps the Jobs constructed for builds that haven't changed since.
+	// Jobs are constructed on every reconcile when nil.
+	JobCache *JobCache
+
+	// Network is the proxy and trust bundle configuration injected into every
+	// build Job, unless overridden by the build.
+	Network NetworkConfig
+
+	// ServiceAccountClient returns the client used to apply the onSuccess patch
+	// targets of a build. Patch targets fail when nil.
+	ServiceAccountClient ServiceAccountClientFunc
+
+	// Pruners delete the versions of builds rolled back after their onSuccess
+	// hooks failed, from each kind of storage. Rollbacks fail without a Pruner.
+	Pruners map[jcrsv1.ArtifactStore]retention.Pruner
+
+	// SlowReconcileThreshold is the duration above which a reconcile is logged.
+	// Slow reconciles aren't logged when zero.
+	SlowReconcileThreshold time.Duration
+
+	// APIReader reads objects straight from the API server, bypassing the cache,
+	// when the status of a build has to be merged after a conflict, or a Job whose
+	// pod template isn't cached has to be compared. The Client is used when nil.
+	APIReader client.Reader
+
+	// NativeSidecars runs the sidecars of builds as native sidecars, which requires
+	// Kubernetes 1.29 or later. Sidecars are wrapped to be stopped otherwise.
+	NativeSidecars bool
+
+	// SuccessPolicies sets the successPolicy of sharded build Jobs, which requires
+	// Kubernetes 1.31 or later. Jobs of builds with a successPolicy can't be
+	// constructed otherwise.
+	SuccessPolicies bool
+
+	// Archive stores the records of builds once they have run and before they are
+	// deleted. Builds aren't archived when nil.
+	Archive archive.Store
+
+	// RunLogs reads the logs of finished runs, which are written to the Archive.
+	// The logs of runs aren't archived when nil.
+	RunLogs runlogs.Source
+
+	// Pricing provides the prices the cost of finished runs is estimated with.
+	// Costs aren't estimated when nil.
+	Pricing Pricing
+
+	// Spot describes the spot nodes builds are scheduled on or kept away from,
+	// according to their spotPolicy. The spotPolicy is ignored without a node label.
+	Spot SpotConfig
+
+	// Recorder records the Events of builds. Events aren't recorded when nil.
+	Recorder record.EventRecorder
+
+	// PullSecret is the registry pull Secret copied into the namespaces of builds
+	// and referenced by their pods.
+	PullSecret PullSecretConfig
+
+	// CloudEvents receives the CloudEvents of the runs of builds. Runs aren't
+	// exported when nil.
+	CloudEvents cloudevents.Sink
+
+	// History is the external store the runs of builds are recorded in. Runs are
+	// only kept as the Jobs of builds when nil.
+	History history.Store
+
+	// ProtectedPriorityClassName is the priority class of the pods of builds
+	// protected from eviction that don't set one. They keep their priority when empty.
+	ProtectedPriorityClassName string
+
+	// Verify describes how Verify builds are run.
+	Verify VerifyConfig
+
+	// OperatorVersion is the version of the controller, recorded in the build
+	// environment of every run.
+	OperatorVersion string
+
+	// Poller polls the sources of builds with a pollInterval. Sources aren't
+	// polled when nil.
+	Poller *sourcepoll.Poller
+
+	// ImagePoller checks the builder images of builds for a new digest. Builder
+	// images aren't checked when nil.
+	ImagePoller *imagepoll.Poller
+
+	// Progress follows the logs of running builds for their progress markers.
+	// The progress of builds isn't reported when nil.
+	Progress *progress.Tracker
+
+	// Backends convert the Jobs of builds for the engine of their executionBackend.
+	// Builds on an engine without a Converter aren't run.
+	Backends map[jcrsv1.ExecutionBackend]interop.Converter
+
+	// Capacity records the recoveries of the nodes that builds whose run failed
+	// for lack of capacity wait for. Such runs fail like any other when nil.
+	Capacity *CapacityMonitor
+
+	// Signing configures the Jobs that sign the artifacts of builds with
+	// spec.signing. Signing is disabled when its cosign image is empty.
+	Signing signing.Config
+
+	// Clusters connects to the remote clusters the Jobs of builds with a
+	// clusterSelector are dispatched to. Such builds aren't run when nil.
+	Clusters *ClusterRegistry
+
+	// SourceMirror configures the bucket fetched sources are mirrored to. Sources
+	// aren't mirrored when its bucket is empty.
+	SourceMirror SourceMirrorConfig
+
+	// Fetch tunes the downloads of the fetch init containers.
+	Fetch FetchConfig
+
+	// FetchSlots limits the builds of the cluster fetching their source at once.
+	// Fetches aren't limited when nil.
+	FetchSlots *FetchSlots
+
+	// SpecHistoryLimit is the number of generations of the spec of every build
+	// kept for rollbacks. The specs of builds aren't kept when zero.
+	SpecHistoryLimit int
 }
 
-func (r *LeviathanBuildReconciler) jobSpecsEqual(existing *batchv1.Job, desired *batchv1.JobSpec) bool {
-	// Compare the specs using Kubernetes semantic equality
-	return equality.Semantic.DeepEqual(existing.Spec, *desired)
+// event records an Event on lvBuild, if the reconciler has a Recorder.
+func (r *LeviathanBuildReconciler) event(lvBuild *jcrsv1.LeviathanBuild, eventType, reason, messageFmt string, args ...any) {
+	if r.Recorder != nil {
+		r.Recorder.Eventf(lvBuild, eventType, reason, messageFmt, args...)
+	}
+}
+
+// isJobFinished reports whether the job has finished, and if so how.
+func isJobFinished(job *batchv1.Job) (bool, batchv1.JobConditionType) {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true, c.Type
+		}
+	}
+
+	return false, ""
 }
 
-// +kubebuilder:docs-gen:collapse=jobSpecsEqual
+// +kubebuilder:docs-gen:collapse=isJobFinished
+
+// constructJob constructs the Job of a run of lvBuild from its jobTemplate, with
+// the parameters of the build. The pods reference the pull Secret when pullSecret
+// is set.
+func (r *LeviathanBuildReconciler) constructJob(lvBuild *jcrsv1.LeviathanBuild, params []corev1.EnvVar, pullSecret bool) (*batchv1.Job, error) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      make(map[string]string),
+			Annotations: make(map[string]string),
+			Namespace:   lvBuild.Namespace,
+		},
+		Spec: *lvBuild.Spec.JobTemplate.Spec.DeepCopy(),
+	}
+	for k, v := range lvBuild.Spec.JobTemplate.Annotations {
+		job.Annotations[k] = v
+	}
+	for k, v := range lvBuild.Spec.JobTemplate.Labels {
+		job.Labels[k] = v
+	}
+	propagateMetadata(lvBuild, job.Labels, job.Annotations)
+
+	podMeta := &job.Spec.Template.ObjectMeta
+	if podMeta.Labels == nil {
+		podMeta.Labels = make(map[string]string)
+	}
+	if podMeta.Annotations == nil {
+		podMeta.Annotations = make(map[string]string)
+	}
+	propagateMetadata(lvBuild, podMeta.Labels, podMeta.Annotations)
+	addPolledRevision(lvBuild, job)
+
+	addPipeline(lvBuild, job)
+	setBuilderImage(lvBuild, job)
+	addBuildVolumes(lvBuild, job)
+	addBuildDNS(lvBuild, job)
+	if err := r.addJobPolicy(lvBuild, job); err != nil {
+		return nil, err
+	}
+	addParameters(job, params)
+	addInlineScript(lvBuild, job)
+	addCheckpoint(lvBuild, job)
+	addHeartbeat(lvBuild, job)
+	if err := addLifecycleHooks(lvBuild, job); err != nil {
+		return nil, err
+	}
+	r.addFetchInitContainer(lvBuild, job)
+	addPipelineWorkspace(lvBuild, job)
+	if err := r.addSidecars(lvBuild, job); err != nil {
+		return nil, err
+	}
+	r.addSpotPolicy(lvBuild, job)
+	r.addCapacityFailurePolicy(job)
+	addSpreadPolicy(lvBuild, job)
+	r.addNetworkConfig(lvBuild, job)
+	addNetworkIsolation(lvBuild, job)
+	r.addVerifyDefaults(lvBuild, job)
+	addPublishHold(lvBuild, job)
+	addPublishPath(lvBuild, job)
+	addConflictPolicy(lvBuild, job)
+	r.addEvictionProtection(lvBuild, job)
+	finishPipeline(lvBuild, job)
+	addPhaseCredentials(lvBuild, job)
+	if pullSecret {
+		addPullSecret(job, r.PullSecret.Source.Name)
+	}
+	if err := ApplyJobPatches(job, lvBuild.Spec.JobPatches); err != nil {
+		return nil, err
+	}
+
+	if err := ctrl.SetControllerReference(lvBuild, job, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// +kubebuilder:docs-gen:collapse=constructJob
 
 // +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds/finalizers,verbs=update
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=builderimagemappings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=maintenancewindows,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=packageownerships,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=publishapprovals,verbs=get;list;watch
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the LeviathanBuild object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// Reconcile runs the LeviathanBuild named by req. Once the build passes its
+// preflight checks, each run is a new Job, in the cluster of the controller or
+// the one selected by its clusterSelector, or the object of its
+// executionBackend. The status of the build follows its latest run, and what
+// follows a finished run, such as publishing, signing and archiving, is done
+// once the run has finished. Failed reconciles are retried after a per-build
+// backoff rather than by the workqueue.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
 func (r *LeviathanBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	/*
+		Errors are not handed back to the workqueue directly. Instead, a per-object
+		backoff decides when to retry, so a persistently failing build doesn't get
+		hot-retried every sync. The backoff is reset by a successful reconcile or a
+		change to the spec.
+	*/
+	var lvBuild jcrsv1.LeviathanBuild
+	start := time.Now()
+	result, err := r.reconcile(ctx, req, &lvBuild)
+	if err == nil {
+		result = requeueBeforeExpiry(&lvBuild, result, time.Now())
+	}
+	duration := time.Since(start)
+	r.observeReconcile(ctx, &lvBuild, duration)
+	// Deleted builds aren't tracked again once forgotten
+	if lvBuild.Name != "" {
+		r.Stats.Observe(req.NamespacedName, duration, err)
+	}
+	if r.Backoff == nil {
+		return result, err
+	}
+	// Builds aren't backed off for a degraded API server, they wait for it to recover
+	if errors.Is(err, apihealth.ErrCircuitOpen) {
+		logf.FromContext(ctx).Info("API server is degraded, waiting for it to recover")
+		return ctrl.Result{RequeueAfter: degradedAPIRetryInterval}, nil
+	}
+	if err != nil {
+		delay := r.Backoff.Failure(req.NamespacedName, lvBuild.Generation)
+		logf.FromContext(ctx).Info("Reconcile failed, backing off", "error", err.Error(), "backoff", delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+	r.Backoff.Reset(req.NamespacedName)
+	return result, nil
+}
+
+// reconcile does the actual work of Reconcile: it loads the LeviathanBuild, checks
+// it can run, see preflight, and reconciles its run on the engine or cluster it
+// runs on. lvBuild is filled in once the LeviathanBuild has been fetched.
+func (r *LeviathanBuildReconciler) reconcile(ctx context.Context, req ctrl.Request, lvBuild *jcrsv1.LeviathanBuild) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
 	/*
@@ -76,121 +380,785 @@ func (r *LeviathanBuildReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 		Many client methods also take variadic options at the end.
 	*/
-	var lvBuild jcrsv1.LeviathanBuild
-	if err := r.Get(ctx, req.NamespacedName, &lvBuild); err != nil {
+	if err := r.Get(ctx, req.NamespacedName, lvBuild); err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Info("LeviathanBuild resource not found. Ignoring since it must be deleted")
+			deferredByMaintenanceWindow.DeleteLabelValues(req.Namespace, req.Name)
+			r.JobCache.Forget(req.NamespacedName)
+			r.Stats.Forget(req.NamespacedName)
+			if r.Poller != nil {
+				r.Poller.Forget(req.NamespacedName)
+			}
+			if r.ImagePoller != nil {
+				r.ImagePoller.Forget(req.NamespacedName)
+			}
+			if r.Progress != nil {
+				r.Progress.Forget(req.NamespacedName)
+			}
 			return ctrl.Result{}, nil
 		}
 		log.Error(err, "Unable to fetch LeviathanBuild")
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	ctx, log = withLogLevel(ctx, lvBuild)
+	log.V(1).Info("Reconciling LeviathanBuild", "generation", lvBuild.Generation, "resourceVersion", lvBuild.ResourceVersion)
+	// The status as read, to tell apart the changes made by this reconcile
+	observed := lvBuild.Status.DeepCopy()
+	// Only the gate that holds back this reconcile records itself as the blocking reason
+	lvBuild.Status.BlockingReason = nil
+
+	desiredJob, window, result, err := r.preflight(ctx, lvBuild, observed)
+	if desiredJob == nil {
+		return result, err
+	}
+
+	// Builds on another engine run the Job converted for it instead
+	if usesBackend(lvBuild) {
+		result, err := r.reconcileBackendRun(ctx, lvBuild, desiredJob, observed, window)
+		if err != nil {
+			log.Error(err, "Failed to reconcile the run of the execution backend", "executionBackend", lvBuild.Spec.ExecutionBackend)
+		}
+		return result, err
+	}
+
+	// Builds with a clusterSelector run their Job in a remote cluster instead
+	if lvBuild.Spec.ClusterSelector != nil {
+		result, err := r.reconcileRemoteRun(ctx, lvBuild, desiredJob, observed, window)
+		if err != nil {
+			log.Error(err, "Failed to reconcile the run of the remote cluster", "cluster", lvBuild.Status.Cluster)
+		}
+		return result, err
+	}
+
+	return r.reconcileJobRun(ctx, lvBuild, desiredJob, observed, window)
+}
+
+// preflight checks that lvBuild can run, and returns the Job of its next run and
+// the MaintenanceWindow open for it, if any. Until lvBuild can run, its status
+// records what holds it back and no Job is returned, only the result of the
+// reconcile.
+func (r *LeviathanBuildReconciler) preflight(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, observed *jcrsv1.LeviathanBuildStatus) (*batchv1.Job, *openMaintenanceWindow, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	// Builds being deleted are only archived, once their remote Jobs are deleted
+	if err := r.reconcileRemoteJobsFinalizer(ctx, lvBuild); err != nil {
+		log.Error(err, "Failed to reconcile remote Jobs finalizer")
+		return nil, nil, ctrl.Result{}, err
+	}
+	if deleting, err := r.reconcileArchiveFinalizer(ctx, lvBuild); err != nil {
+		log.Error(err, "Failed to reconcile archive finalizer")
+		return nil, nil, ctrl.Result{}, err
+	} else if deleting {
+		return nil, nil, ctrl.Result{}, nil
+	}
+
+	// The pods of builds reference the pull Secret in the namespaces it is copied to
+	pullSecret, err := r.pullSecretSelected(ctx, lvBuild.Namespace)
+	if err != nil {
+		log.Error(err, "Failed to get Namespace")
+		return nil, nil, ctrl.Result{}, err
+	}
+
+	/*
+		The reconciler finds the job owned by the leviathanBuild for the status.
+
+		Status should be able to be reconstituted from the state of the world,
+		so it's generally not a good idea to read from the status of the root object.
+		Instead, you should reconstruct it every run.
+
+		We can check if a job is "finished" and whether it succeeded or failed using status
+		conditions. We'll put that logic in a helper to make our code cleaner.
+	*/
+
+	/*
+		Nothing can be created in a namespace that is being deleted. Rather than
+		failing (and retrying) every attempt, we record that the namespace is going
+		away and drop the request.
+	*/
+	if terminating, err := r.namespaceTerminating(ctx, lvBuild.Namespace); err != nil {
+		log.Error(err, "Failed to get Namespace")
+		return nil, nil, ctrl.Result{}, err
+	} else if terminating {
+		r.markNamespaceTerminating(ctx, lvBuild, observed)
+		return nil, nil, ctrl.Result{}, nil
+	}
+
+	// Builds that didn't start within their expiresAfter never get a Job
+	if expired, err := r.expireQueuedBuild(ctx, lvBuild, time.Now()); err != nil {
+		log.Error(err, "Failed to expire build")
+		return nil, nil, ctrl.Result{}, err
+	} else if expired {
+		log.Info("Build didn't start within its expiresAfter, not creating a Job", "expiresAfter", lvBuild.Spec.ExpiresAfter.Duration)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			log.Error(err, "unable to update LeviathanBuild status")
+			return nil, nil, ctrl.Result{}, err
+		}
+		return nil, nil, ctrl.Result{}, nil
+	}
+
+	// The latest specs of the build are kept so it can be rolled back to one
+	if err := r.recordSpecHistory(ctx, lvBuild); err != nil {
+		log.Error(err, "Failed to record spec history")
+	}
+
+	// Builds polling their source run again when its revision changes
+	r.reconcilePolledRevision(ctx, lvBuild)
+
+	// Inline scripts are stored in a ConfigMap that has to exist before the Job can start
+	if err := r.reconcileInlineScript(ctx, lvBuild, lvBuild); err != nil {
+		log.Error(err, "Failed to reconcile inline script ConfigMap")
+		return nil, nil, ctrl.Result{}, err
+	}
+	if err := r.reconcileCheckpointClaim(ctx, lvBuild); err != nil {
+		log.Error(err, "Failed to reconcile checkpoint PersistentVolumeClaim")
+		return nil, nil, ctrl.Result{}, err
+	}
+
+	// Builds without containers run the steps of the BuildTypeDefinition of their buildType
+	if resolved, err := r.reconcilePipeline(ctx, lvBuild); err != nil {
+		log.Error(err, "Failed to resolve pipeline")
+		return nil, nil, ctrl.Result{}, err
+	} else if !resolved {
+		log.Info("No BuildTypeDefinition provides the steps of the build, not creating a Job", "buildType", buildTypeOf(lvBuild))
+		setBlocked(lvBuild, jcrsv1.BlockedByInvalidJobTemplate, jcrsv1.ConditionInvalidJobTemplate)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			log.Error(err, "unable to update LeviathanBuild status")
+			return nil, nil, ctrl.Result{}, err
+		}
+		return nil, nil, ctrl.Result{}, nil
+	}
+
+	/*
+		Containers that don't set an image run the builder image selected by the
+		BuilderImageMappings. Until a mapping selects one there's nothing to run; the
+		build is reconciled again when a mapping changes.
+	*/
+	resolved, err := r.reconcileBuilderImage(ctx, lvBuild)
+	if err != nil {
+		log.Error(err, "Failed to resolve builder image")
+		return nil, nil, ctrl.Result{}, err
+	}
+	// Builds run again when their builder image changes with a rebuildOnImageChange
+	r.reconcileBuilderImageDigest(ctx, lvBuild, observed.BuilderImage)
+	if !resolved {
+		log.Info("No BuilderImageMapping selects a builder image, not creating a Job")
+		setBlocked(lvBuild, jcrsv1.BlockedByBuilderImage, jcrsv1.ConditionBuilderImageResolved)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			log.Error(err, "unable to update LeviathanBuild status")
+			return nil, nil, ctrl.Result{}, err
+		}
+		return nil, nil, ctrl.Result{}, nil
+	}
+
+	/*
+		The path the build publishes at is rendered from its pathTemplate and
+		reported in status before any run publishes, so it can be confirmed.
+	*/
+	if err := setPublishPath(lvBuild); err != nil {
+		log.Info("The pathTemplate of the publishTarget doesn't render a valid path, not creating a Job", "error", err.Error())
+		setInvalidJobTemplate(lvBuild, jcrsv1.ReasonInvalidPublishPath, err)
+		setBlocked(lvBuild, jcrsv1.BlockedByInvalidJobTemplate, jcrsv1.ConditionInvalidJobTemplate)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			log.Error(err, "unable to update LeviathanBuild status")
+			return nil, nil, ctrl.Result{}, err
+		}
+		return nil, nil, ctrl.Result{}, nil
+	}
+
+	/*
+		The keys of the parameters of the build are read from their sources now, a
+		run can't start until the sources that aren't optional exist. The sources
+		are watched, so there's no need to requeue.
+	*/
+	params, err := r.resolveParameters(ctx, lvBuild)
+	if perr := (*parametersError)(nil); errors.As(err, &perr) {
+		log.Info("Parameters of the build can't be resolved, not creating a Job", "missing", perr.missing, "undeclared", perr.undeclared)
+		setInvalidJobTemplate(lvBuild, jcrsv1.ReasonParametersUnresolved, err)
+		setBlocked(lvBuild, jcrsv1.BlockedByParameters, jcrsv1.ConditionInvalidJobTemplate)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			log.Error(err, "unable to update LeviathanBuild status")
+			return nil, nil, ctrl.Result{}, err
+		}
+		return nil, nil, ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to resolve parameters")
+		return nil, nil, ctrl.Result{}, err
+	}
 
 	/*
 		We need to construct a job based on our LeviathanBuild's template. We'll copy over the spec
-		from the template and copy some basic object meta.
+		from the template and copy some basic object meta. The LeviathanBuild's own labels and
+		annotations are only copied onto the Job and its pod template when allowed by
+		`spec.propagation`.
 
 		Then, we'll set the "job time" annotation so that we can reconstitute our
-		`LastJobTime` field each reconcile.
+		`LastJobTime` field each reconcile. The name of the Job is only chosen when it is
+		created, as every new Job is a new run of the build.
 
 		Finally, we'll need to set an owner reference. This allows the Kubernetes garbage collector
 		to clean up jobs when we delete the LeviathanBuild, and allows controller-runtime to figure out
 		which leviathanBuild needs to be reconciled when a given job changes (is added, deleted, completes, etc).
 	*/
-	constructJobForLeviathanBuild := func(lvBuild *jcrsv1.LeviathanBuild) (*batchv1.Job, error) {
-		// We want job names for a given nominal start time to have a deterministic name to avoid the same job being created twice
-		name := fmt.Sprintf("%s-%d", lvBuild.Name, time.Time{}.Unix())
-
-		job := &batchv1.Job{
-			ObjectMeta: metav1.ObjectMeta{
-				Labels:      make(map[string]string),
-				Annotations: make(map[string]string),
-				Name:        name,
-				Namespace:   lvBuild.Namespace,
-			},
-			Spec: *lvBuild.Spec.JobTemplate.Spec.DeepCopy(),
+	desiredJob, err := r.JobCache.Get(lvBuild, params, pullSecret, r.constructJob)
+	if err != nil {
+		log.Error(err, "unable to construct job from template")
+		// don't bother requeuing until we get a change to the spec
+		setInvalidJobTemplate(lvBuild, jcrsv1.ReasonConstructionFailed, err)
+		setBlocked(lvBuild, jcrsv1.BlockedByInvalidJobTemplate, jcrsv1.ConditionInvalidJobTemplate)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			log.Error(err, "unable to update LeviathanBuild status")
+			return nil, nil, ctrl.Result{}, err
 		}
-		for k, v := range lvBuild.Spec.JobTemplate.Annotations {
-			job.Annotations[k] = v
+		return nil, nil, ctrl.Result{}, nil
+	}
+
+	/*
+		While a MaintenanceWindow is open no new run is started, whatever the reason
+		for it. Running Jobs aren't touched, and deferred builds are reconciled again
+		when the window closes.
+	*/
+	window, err := r.activeMaintenanceWindow(ctx, lvBuild, time.Now())
+	if err != nil {
+		log.Error(err, "Failed to list MaintenanceWindows")
+		return nil, nil, ctrl.Result{}, err
+	}
+	if window == nil {
+		setDeferredByMaintenanceWindow(lvBuild, nil, nil)
+	}
+
+	return desiredJob, window, ctrl.Result{}, nil
+}
+
+// reconcileJobRun runs desiredJob as the Job of lvBuild. A new run is started
+// when lvBuild has none yet, when the Job of its latest run is outdated, or when
+// that run is to be run again; otherwise the latest run is observed.
+func (r *LeviathanBuildReconciler) reconcileJobRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, desiredJob *batchv1.Job, observed *jcrsv1.LeviathanBuildStatus, window *openMaintenanceWindow) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	// Find the Job of the latest run, if any
+	existingJob, latestRunIndex, err := r.latestJob(ctx, lvBuild)
+	if err != nil {
+		log.Error(err, "Failed to list Jobs")
+		return ctrl.Result{}, err
+	}
+
+	// Check if the Job already exists, if not create a new one
+	if existingJob == nil {
+		return r.startJobRun(ctx, lvBuild, desiredJob, latestRunIndex, observed, window)
+	}
+
+	// Ensure the Job spec matches the desired state
+	preferNode(desiredJob, existingJob.Annotations[cacheNodeAnnotation])
+	keepSecretChecksums(desiredJob, existingJob)
+	changes, err := r.jobChanges(ctx, existingJob, desiredJob)
+	if err != nil {
+		log.Error(err, "Failed to compare Job with desired state", "Job.Namespace", existingJob.Namespace, "Job.Name", existingJob.Name)
+		return ctrl.Result{}, err
+	}
+	if len(changes) > 0 {
+		// The outdated Job is left to finish until the MaintenanceWindow closes
+		if window != nil {
+			return r.deferForMaintenance(ctx, lvBuild, observed, window, changes)
 		}
-		for k, v := range lvBuild.Spec.JobTemplate.Labels {
-			job.Labels[k] = v
+		if lvBuild.Spec.Suspend {
+			return r.holdSuspended(ctx, lvBuild, observed)
 		}
-		if err := ctrl.SetControllerReference(lvBuild, job, r.Scheme); err != nil {
-			return nil, err
+		log.Info("Job Spec doesn't match desired state. Deleting existing job.", "Job.Namespace", existingJob.Namespace, "Job.Name", existingJob.Name,
+			"changes", summarizeChanges(changes))
+		r.event(lvBuild, corev1.EventTypeNormal, jobOutOfDateReason, "Replacing Job %s, changed: %s", existingJob.Name, summarizeChanges(changes))
+		// Specs don't match, need to recreate
+		if err := r.Delete(ctx, existingJob); err != nil {
+			return ctrl.Result{}, err
 		}
+		lvBuild.Status.SpotInterruptions = 0
+		lvBuild.Status.CheckpointResumes = 0
+		lvBuild.Status.StallRestarts = 0
+		setResumeFromCheckpoint(lvBuild, desiredJob)
+		return r.startJobRun(ctx, lvBuild, desiredJob, latestRunIndex, observed, window)
+	}
 
-		return job, nil
+	// The existing Job matches the template, so the template is known to be accepted
+	log.V(1).Info("Job Spec matches desired state", "Job.Namespace", existingJob.Namespace, "Job.Name", existingJob.Name)
+	setInvalidJobTemplate(lvBuild, "", nil)
+
+	/*
+		A run interrupted by the reclaim of its spot node is run again, a failed run
+		is resumed from its checkpoint. Neither is decided while a MaintenanceWindow
+		is open or the build is suspended, failed runs are looked at again once it
+		closes or the build is resumed.
+	*/
+	if finished, finishedType := isJobFinished(existingJob); finished && window == nil && !lvBuild.Spec.Suspend {
+		rerun, err := r.retrySpotInterruption(ctx, lvBuild, existingJob, finishedType)
+		if err != nil {
+			log.Error(err, "Failed to check for spot interruption")
+			return ctrl.Result{}, err
+		}
+		if rerun {
+			log.Info("Job interrupted on a spot node, running it again", "Job.Namespace", existingJob.Namespace, "Job.Name", existingJob.Name)
+			return r.startJobRun(ctx, lvBuild, desiredJob, latestRunIndex, observed, window)
+		}
+		waiting, rerun, err := r.waitForCapacity(ctx, lvBuild, existingJob, finishedType)
+		if err != nil {
+			log.Error(err, "Failed to check for insufficient capacity")
+			return ctrl.Result{}, err
+		}
+		if rerun {
+			log.Info("Capacity recovered, running the build again", "Job.Namespace", existingJob.Namespace, "Job.Name", existingJob.Name)
+			return r.startJobRun(ctx, lvBuild, desiredJob, latestRunIndex, observed, window)
+		}
+		if waiting {
+			return r.holdForCapacity(ctx, lvBuild, observed)
+		}
+		if r.resumeFromCheckpoint(lvBuild, existingJob, desiredJob, finishedType) {
+			log.Info("Job failed, resuming it from its checkpoint", "Job.Namespace", existingJob.Namespace, "Job.Name", existingJob.Name)
+			return r.startJobRun(ctx, lvBuild, desiredJob, latestRunIndex, observed, window)
+		}
 	}
-	// +kubebuilder:docs-gen:collapse=constructJobForLeviathanBuild
 
 	/*
-		The reconciler finds the job owned by the leviathanBuild for the status.
+		A running run whose heartbeat has stopped is stalled. It's deleted and run
+		again up to maxRestarts times, unless a MaintenanceWindow is open or
+		the build is suspended.
+	*/
+	if finished, _ := isJobFinished(existingJob); !finished && lvBuild.Spec.Heartbeat != nil {
+		stalled, err := r.checkHeartbeat(ctx, lvBuild, existingJob)
+		if err != nil {
+			log.Error(err, "Failed to check heartbeat")
+			return ctrl.Result{}, err
+		}
+		if stalled && window == nil && !lvBuild.Spec.Suspend && r.restartStalled(lvBuild, existingJob) {
+			log.Info("Job stalled, running it again", "Job.Namespace", existingJob.Namespace, "Job.Name", existingJob.Name)
+			if err := r.Delete(ctx, existingJob, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, err
+			}
+			return r.startJobRun(ctx, lvBuild, desiredJob, latestRunIndex, observed, window)
+		}
+	} else {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionStalled)
+	}
 
-		Status should be able to be reconstituted from the state of the world,
-		so it's generally not a good idea to read from the status of the root object.
-		Instead, you should reconstruct it every run.
+	return r.observeJobRun(ctx, lvBuild, existingJob, desiredJob, latestRunIndex, observed, window)
+}
 
-		We can check if a job is "finished" and whether it succeeded or failed using status
-		conditions. We'll put that logic in a helper to make our code cleaner.
+// holdForCapacity records that lvBuild waits for capacity to run again. The
+// failed run of a build waiting for capacity doesn't hold its mutex.
+func (r *LeviathanBuildReconciler) holdForCapacity(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, observed *jcrsv1.LeviathanBuildStatus) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForCapacity)
+	log.Info("Waiting for capacity, not starting a Job", "reason", condition.Message)
+	if err := r.releaseMutex(ctx, lvBuild); err != nil {
+		log.Error(err, "Failed to release mutex Lease")
+		return ctrl.Result{}, err
+	}
+	jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonInsufficientCapacity, condition.Message)
+	setBlocked(lvBuild, jcrsv1.BlockedByCapacity, jcrsv1.ConditionWaitingForCapacity)
+	if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+		log.Error(err, "unable to update LeviathanBuild status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// startJobRun starts the run following latestRunIndex as a new Job from
+// desiredJob, once the run has passed the checks of new runs and holds the fetch
+// slot and mutex it needs.
+func (r *LeviathanBuildReconciler) startJobRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, desiredJob *batchv1.Job, latestRunIndex int64, observed *jcrsv1.LeviathanBuildStatus, window *openMaintenanceWindow) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	/*
+		The run index is also recorded on the Job itself, so a Job whose creation
+		didn't make it into the status is still found by the next reconcile.
 	*/
+	runIndex := max(lvBuild.Status.RunIndex, latestRunIndex) + 1
+	desiredJob.GenerateName = jobGenerateName(lvBuild, runIndex)
+	if name, err := TemplateName(lvBuild, runIndex); err != nil {
+		log.Info("Naming template can't name the Job, not creating it", "reason", err.Error())
+		setInvalidJobTemplate(lvBuild, jcrsv1.ReasonInvalidNamingTemplate, err)
+		setBlocked(lvBuild, jcrsv1.BlockedByInvalidJobTemplate, jcrsv1.ConditionInvalidJobTemplate)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			log.Error(err, "unable to update LeviathanBuild status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	} else if name != "" {
+		desiredJob.GenerateName, desiredJob.Name = "", name
+	}
+	setRunLabels(lvBuild, desiredJob, runIndex)
 
-	// Check if the Job already exists, if not create a new one
-	existingJob := &batchv1.Job{}
-	err := r.Get(ctx, req.NamespacedName, existingJob)
-	if err != nil && apierrors.IsNotFound(err) {
-		// Define a new Job
-		job, err := constructJobForLeviathanBuild(&lvBuild)
-		if err != nil {
-			log.Error(err, "unable to construct job from template")
-			// don't bother requeuing until we get a change to the spec
+	// The pods prefer the node the ReadWriteOnce volumes of the build are still attached to
+	node, err := r.attachedNode(ctx, lvBuild, desiredJob)
+	if err != nil {
+		log.Error(err, "Failed to find the node volumes are attached to")
+		return ctrl.Result{}, err
+	}
+	preferNode(desiredJob, node)
+
+	// A Job the API server refuses is recorded rather than retried on every reconcile
+	accepted, err := r.dryRunJob(ctx, lvBuild, desiredJob)
+	if err != nil {
+		if isNamespaceTerminatingError(err) {
+			r.markNamespaceTerminating(ctx, lvBuild, observed)
 			return ctrl.Result{}, nil
 		}
-		log.Info("Creating a new Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
-		if err := r.Create(ctx, job); err != nil {
-			log.Error(err, "Failed to create new Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+		log.Error(err, "Failed to dry run new Job", "Job.Namespace", desiredJob.Namespace, "Job.GenerateName", desiredJob.GenerateName)
+		return ctrl.Result{}, err
+	}
+	if !accepted {
+		log.Info("Job rejected by the API server, not creating it", "reason", meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionInvalidJobTemplate).Message)
+		setBlocked(lvBuild, jcrsv1.BlockedByInvalidJobTemplate, jcrsv1.ConditionInvalidJobTemplate)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			log.Error(err, "unable to update LeviathanBuild status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: invalidJobTemplateRetryInterval}, nil
+	}
+
+	if proceed, result, err := r.checkNewRun(ctx, lvBuild, observed, window); !proceed {
+		return result, err
+	}
+
+	// Only so many builds of the cluster fetch their source at once
+	acquired, err := r.acquireFetchSlot(ctx, lvBuild)
+	if err != nil {
+		log.Error(err, "Failed to acquire fetch slot")
+		return ctrl.Result{}, err
+	}
+	if !acquired {
+		log.Info("Waiting for a fetch slot", "maxConcurrentFetches", r.FetchSlots.Max)
+		setBlocked(lvBuild, jcrsv1.BlockedByFetchSlot, jcrsv1.ConditionWaitingForFetchSlot)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			log.Error(err, "unable to update LeviathanBuild status")
 			return ctrl.Result{}, err
 		}
-		// Requeue the request to ensure the Job is created
-		return ctrl.Result{RequeueAfter: time.Minute}, nil
-	} else if err != nil {
-		log.Error(err, "Failed to get Job")
+		return ctrl.Result{RequeueAfter: fetchSlotPollInterval}, nil
+	}
+
+	// The build then holds its mutex, a build waiting for it doesn't keep its fetch slot
+	proceed, result, err := r.acquireRunMutex(ctx, lvBuild, observed)
+	if !proceed {
+		if r.FetchSlots != nil {
+			r.FetchSlots.release(lvBuild)
+		}
+		return result, err
+	}
+
+	if err := r.startRun(ctx, lvBuild, desiredJob, runIndex); err != nil {
+		log.Error(err, "Failed to resolve source revision")
+		r.abandonRun(ctx, lvBuild)
 		return ctrl.Result{}, err
 	}
 
-	// Ensure the Job spec matches the desired state
-	if !r.jobSpecsEqual(existingJob, &lvBuild.Spec.JobTemplate.Spec) {
-		log.Info("Job Spec doesn't match desired state. Deleting existing job.", "Job.Namespace", existingJob.Namespace, "Job.Name", existingJob.Name)
-		// Specs don't match, need to recreate
-		if err := r.Delete(ctx, existingJob); err != nil {
+	// The pods of isolated builds must not start before their NetworkPolicy exists
+	if err := r.reconcileNetworkPolicy(ctx, lvBuild, true); err != nil {
+		log.Error(err, "Failed to reconcile NetworkPolicy")
+		r.abandonRun(ctx, lvBuild)
+		return ctrl.Result{}, err
+	}
+	if err := r.reconcilePodDisruptionBudget(ctx, lvBuild, desiredJob); err != nil {
+		log.Error(err, "Failed to reconcile PodDisruptionBudget")
+		r.abandonRun(ctx, lvBuild)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.annotateSecretChecksums(ctx, lvBuild, desiredJob); err != nil {
+		log.Error(err, "Failed to checksum Secrets")
+		r.abandonRun(ctx, lvBuild)
+		return ctrl.Result{}, err
+	}
+	if err := setManaged(desiredJob); err != nil {
+		r.abandonRun(ctx, lvBuild)
+		return ctrl.Result{}, err
+	}
+	log.Info("Creating a new Job", "Job.Namespace", desiredJob.Namespace, "Job.GenerateName", desiredJob.GenerateName)
+	if err := r.Create(ctx, desiredJob); err != nil {
+		r.abandonRun(ctx, lvBuild)
+		if isNamespaceTerminatingError(err) {
+			r.markNamespaceTerminating(ctx, lvBuild, observed)
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to create new Job", "Job.Namespace", desiredJob.Namespace, "Job.GenerateName", desiredJob.GenerateName)
+		return ctrl.Result{}, err
+	}
+	if r.FetchSlots != nil && needsFetch(lvBuild) {
+		r.FetchSlots.started(lvBuild, desiredJob)
+	}
+	r.exportStarted(ctx, lvBuild, desiredJob, runIndex)
+	r.endCapacityWait(lvBuild)
+	jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRunning, "Job "+desiredJob.Name+" is running")
+	if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+		log.Error(err, "unable to update LeviathanBuild status")
+		return ctrl.Result{}, err
+	}
+	// Requeue the request to ensure the Job is created
+	return ctrl.Result{RequeueAfter: time.Minute}, nil
+}
+
+// observeJobRun sets the status of lvBuild from existingJob, the Job of its
+// latest run latestRunIndex, and follows up on the run once it has finished.
+func (r *LeviathanBuildReconciler) observeJobRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, existingJob, desiredJob *batchv1.Job, latestRunIndex int64, observed *jcrsv1.LeviathanBuildStatus, window *openMaintenanceWindow) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	// The mutex Lease is held for as long as the Job runs
+	result := ctrl.Result{}
+	finished, finishedType := isJobFinished(existingJob)
+	if finished {
+		if err := r.releaseMutex(ctx, lvBuild); err != nil {
+			log.Error(err, "Failed to release mutex Lease")
 			return ctrl.Result{}, err
 		}
-		job, err := constructJobForLeviathanBuild(&lvBuild)
+	} else if held, err := r.renewMutex(ctx, lvBuild); err != nil {
+		log.Error(err, "Failed to renew mutex Lease")
+		return ctrl.Result{}, err
+	} else if held {
+		result.RequeueAfter = mutexRenewInterval
+	}
+	if err := r.reconcileNetworkPolicy(ctx, lvBuild, !finished); err != nil {
+		log.Error(err, "Failed to reconcile NetworkPolicy")
+		return ctrl.Result{}, err
+	}
+	if err := r.reconcileProgress(ctx, lvBuild, existingJob, finished); err != nil {
+		log.Error(err, "Failed to follow build progress")
+		return ctrl.Result{}, err
+	}
+	runningJob := existingJob
+	if finished {
+		runningJob = nil
+	}
+	if err := r.reconcilePodDisruptionBudget(ctx, lvBuild, runningJob); err != nil {
+		log.Error(err, "Failed to reconcile PodDisruptionBudget")
+		return ctrl.Result{}, err
+	}
+	// The JobPruner deletes the Jobs of earlier runs when it runs
+	if !featuregates.Enabled(featuregates.ParallelJobPruner) {
+		if err := r.pruneVerifyHistory(ctx, lvBuild, latestRunIndex); err != nil {
+			log.Error(err, "Failed to delete the Jobs of earlier runs")
+			return ctrl.Result{}, err
+		}
+	}
+	if finished && finishedType == batchv1.JobFailed && window != nil {
+		result.RequeueAfter = time.Until(window.end)
+	}
+	if !finished && lvBuild.Spec.Heartbeat != nil && (result.RequeueAfter == 0 || result.RequeueAfter > heartbeatRecheckInterval) {
+		result.RequeueAfter = heartbeatRecheckInterval
+	}
+
+	if err := r.checkPodCreation(ctx, lvBuild, existingJob); err != nil {
+		log.Error(err, "Failed to check the creation of the pods of the Job")
+		return ctrl.Result{}, err
+	}
+
+	/*
+		The succeeded runs of builds requiring a publishApproval publish with a Job
+		of their own once approved. Until that Job has finished the run isn't over,
+		and what follows a finished run waits for it.
+	*/
+	publishJob := existingJob
+	awaitingApproval := false
+	if !publishApprovalRequired(lvBuild) {
+		resetPublishApproval(lvBuild)
+	} else if finished && finishedType == batchv1.JobComplete {
+		job, waitFor, err := r.reconcilePublishApproval(ctx, lvBuild, desiredJob, latestRunIndex)
 		if err != nil {
-			log.Error(err, "unable to construct job from template")
-			// don't bother requeuing until we get a change to the spec
-			return ctrl.Result{}, nil
+			log.Error(err, "Failed to reconcile publish approval")
+			return ctrl.Result{}, err
+		}
+		switch {
+		case job != nil:
+			publishJob = job
+			finished, finishedType = isJobFinished(job)
+		case approvalTimedOut(lvBuild):
+			finishedType = batchv1.JobFailed
+		default:
+			awaitingApproval = true
+			finished = false
+		}
+		if waitFor > 0 && (result.RequeueAfter == 0 || waitFor < result.RequeueAfter) {
+			result.RequeueAfter = waitFor
 		}
-		log.Info("Creating a new Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
-		if err := r.Create(ctx, job); err != nil {
-			log.Error(err, "Failed to create new Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+	}
+
+	// Ready and Succeeded follow the Job of the latest run, or the Job publishing it
+	switch {
+	case awaitingApproval:
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonAwaitingApproval,
+			"Job "+existingJob.Name+" completed, its publish waits for a PublishApproval")
+	case !finished && podCreationBlocked(lvBuild) != nil:
+		message := podCreationBlocked(lvBuild).Message
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonPodCreationBlocked, message)
+		jcrsv1.SetReady(&lvBuild.Status.Conditions, metav1.ConditionFalse, lvBuild.Generation, jcrsv1.ReasonPodCreationBlocked, message)
+	case !finished:
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRunning, "Job "+publishJob.Name+" is running")
+	case approvalTimedOut(lvBuild):
+		jcrsv1.MarkFailed(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonApprovalTimedOut,
+			"Job "+existingJob.Name+" completed, but its publish wasn't approved in time")
+	case finishedType == batchv1.JobComplete && lvBuild.Status.RolledBack:
+		markRolledBack(lvBuild, publishJob)
+	case finishedType == batchv1.JobComplete:
+		jcrsv1.MarkSucceeded(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonJobComplete, "Job "+publishJob.Name+" completed")
+	default:
+		jcrsv1.MarkFailed(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonJobFailed, "Job "+publishJob.Name+" failed")
+	}
+
+	source, err := r.resolveSource(ctx, lvBuild, existingJob)
+	if err != nil {
+		log.Error(err, "Failed to resolve source revision")
+		return ctrl.Result{}, err
+	}
+	if source.Revision != "" {
+		lvBuild.Status.SourceRevision = source.Revision
+	}
+	if source.Mirror != "" {
+		lvBuild.Status.SourceMirror = source.Mirror
+	}
+	if err := r.reconcileReportedConditions(ctx, lvBuild, existingJob); err != nil {
+		log.Error(err, "Failed to read the conditions reported by the build")
+		return ctrl.Result{}, err
+	}
+	r.setImageUpdated(lvBuild, existingJob)
+	if jobFinished, _ := isJobFinished(existingJob); !jobFinished {
+		if err := r.checkSecretRotation(ctx, lvBuild, existingJob); err != nil {
+			log.Error(err, "Failed to check Secret rotation")
 			return ctrl.Result{}, err
 		}
-		// Requeue the request to ensure the Job is created
-		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+	if err := r.recordBuildEnvironment(ctx, lvBuild, existingJob, latestRunIndex); err != nil {
+		log.Error(err, "Failed to record build environment")
+		return ctrl.Result{}, err
+	}
+
+	// What follows a finished run waits for the Job publishing it, see above
+	if finished {
+		requeueAfter, err := r.finishJobRun(ctx, lvBuild, existingJob, publishJob, desiredJob, latestRunIndex, finishedType)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if requeueAfter > 0 {
+			result.RequeueAfter = requeueAfter
+		}
 	}
 
 	/*
 		Using the data we've gathered, we'll update the status of our CRD.
 		The status subresource ignores changes to spec, so it's less likely to conflict
-		with any other updates, and can have separate permissions.
+		with any other updates, and can have separate permissions. Conflicts that do
+		happen are merged by updateStatus.
 	*/
-	if err := r.Status().Update(ctx, &lvBuild); err != nil {
+	if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
 		log.Error(err, "unable to update LeviathanBuild status")
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// Finished Verify builds are deleted once their TTL has passed
+	if expiresIn, ok := r.verifyExpiresIn(lvBuild, time.Now()); finished && ok {
+		if expiresIn <= 0 {
+			log.Info("Deleting expired Verify build")
+			if err := r.Delete(ctx, lvBuild, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				log.Error(err, "Failed to delete expired Verify build")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+		if result.RequeueAfter == 0 || expiresIn < result.RequeueAfter {
+			result.RequeueAfter = expiresIn
+		}
+	}
+
+	return result, nil
+}
+
+// finishJobRun follows up on the finished run latestRunIndex of lvBuild, run by
+// existingJob and published by publishJob. Follow-ups that failed are tried again
+// after the returned duration, if any.
+func (r *LeviathanBuildReconciler) finishJobRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, existingJob, publishJob, desiredJob *batchv1.Job, latestRunIndex int64, finishedType batchv1.JobConditionType) (time.Duration, error) {
+	log := logf.FromContext(ctx)
+	var requeueAfter time.Duration
+
+	// Once a publishing build has succeeded, the downstream resources are rolled to what it published
+	if finishedType == batchv1.JobComplete && publishes(lvBuild.Spec.BuildType) && !lvBuild.Status.RolledBack {
+		digest, err := r.publishedDigest(ctx, publishJob)
+		if err != nil {
+			log.Error(err, "Failed to read published digest")
+			return 0, err
+		}
+		lvBuild.Status.PublishedDigest = digest
+		// The downstream resources only roll to signed artifacts
+		signed, err := r.reconcileSigning(ctx, lvBuild, publishJob, latestRunIndex)
+		if err != nil {
+			log.Error(err, "Failed to sign published artifact")
+			return 0, err
+		}
+		retry := false
+		if signed {
+			retry = r.applyPatchTargets(ctx, lvBuild)
+		}
+		if retry {
+			log.Info("Failed to apply patch targets", "reason", meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPatchTargetsApplied).Message)
+		}
+		if retry && lvBuild.Spec.OnHookFailure == jcrsv1.RollbackOnHookFailure {
+			if err := r.rollbackPublish(ctx, lvBuild, publishJob); err != nil {
+				log.Error(err, "Failed to roll back published version")
+			} else {
+				log.Info("Rolled back published version", "version", lvBuild.Spec.PublishTarget.Version)
+				retry = false
+			}
+		}
+		if retry {
+			requeueAfter = patchTargetsRetryInterval
+		}
+	}
+	// Succeeded runs are run again by builds verifying their reproducibility
+	if finishedType == batchv1.JobComplete && !lvBuild.Status.RolledBack {
+		if err := r.reconcileReproducibility(ctx, lvBuild, existingJob, desiredJob, latestRunIndex); err != nil {
+			log.Error(err, "Failed to reproduce build")
+			return 0, err
+		}
+	}
+	// Runs whose published version was rolled back are reported as failed
+	if lvBuild.Status.RolledBack {
+		finishedType = batchv1.JobFailed
+	}
+
+	// Finished runs are summarized in the status, newest first
+	recordRecentRun(lvBuild, existingJob, latestRunIndex, finishedType)
+
+	// The versions published by the build are recorded for its retention policy, unless rolled back
+	if !lvBuild.Status.RolledBack {
+		recordPublishedArtifact(lvBuild, publishJob, latestRunIndex, finishedType == batchv1.JobComplete)
+	}
+
+	// A missing estimate doesn't hold up the status, it's tried again on the next reconcile
+	if err := r.estimateCost(ctx, lvBuild, existingJob, latestRunIndex); err != nil {
+		log.Error(err, "Failed to estimate build cost")
+	}
+
+	// The outcome of finished runs is exported once the sink has accepted it
+	if err := r.exportFinished(ctx, lvBuild, existingJob, latestRunIndex, finishedType); err != nil {
+		log.Error(err, "Failed to export build events")
+		requeueAfter = cloudEventsRetryInterval
+	}
+
+	// Finished runs are recorded in the history store once it has accepted them
+	if err := r.recordRun(ctx, lvBuild, existingJob, latestRunIndex, finishedType); err != nil {
+		log.Error(err, "Failed to record build run")
+		requeueAfter = historyRetryInterval
+	}
+
+	// Finished runs are archived once; the final state is archived again before deletion
+	if r.Archive != nil && lvBuild.Status.ArchiveURL == "" {
+		url, err := r.archiveBuild(ctx, lvBuild)
+		if err != nil {
+			log.Error(err, "Failed to archive LeviathanBuild")
+			requeueAfter = archiveRetryInterval
+		}
+		lvBuild.Status.ArchiveURL = url
+	}
+	// The logs of every finished run are archived, its pods don't outlive its Job
+	if err := r.archiveRunLogs(ctx, lvBuild, existingJob, latestRunIndex); err != nil {
+		log.Error(err, "Failed to archive run logs")
+		requeueAfter = archiveRetryInterval
+	}
+
+	return requeueAfter, nil
 }
 
 /*
@@ -205,12 +1173,38 @@ so we just have to extract the owner name if the Job has a LeviathanBuild owner.
 Additionally, we'll inform the manager that this controller owns some Jobs, so that it
 will automatically call Reconcile on the underlying LeviathanBuild when a Job changes, is
 deleted, etc.
+
+BuilderImageMappings aren't owned by any build, so a second index maps them back to
+the builds whose builder image they may select. Likewise, a third index finds the
+builds deferred by MaintenanceWindows.
 */
 var (
 	jobOwnerKey = ".metadata.controller"
 	apiGVStr    = jcrsv1.GroupVersion.String()
 )
 
+// startRun resets the status of lvBuild for the run runIndex, run by job,
+// whichever cluster or engine it runs on.
+func (r *LeviathanBuildReconciler) startRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64) error {
+	// The revision of a source that is fetched by the new Job is only known once the fetch has completed
+	source, err := r.resolveSource(ctx, lvBuild, nil)
+	if err != nil {
+		return err
+	}
+	lvBuild.Status.SourceRevision, lvBuild.Status.SourceMirror = source.Revision, source.Mirror
+
+	lvBuild.Status.RunIndex = runIndex
+	lvBuild.Status.RolledBack = false
+	r.resetProgress(lvBuild)
+	resetReportedConditions(lvBuild)
+	resetSigning(lvBuild)
+	resetPatchTargets(lvBuild)
+	resetReproducibility(lvBuild)
+	resetPublishApproval(lvBuild)
+	lvBuild.Status.BuildEnvironment = newBuildEnvironment(lvBuild, job, runIndex, r.OperatorVersion)
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *LeviathanBuildReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
@@ -232,9 +1226,102 @@ func (r *LeviathanBuildReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := registerCacheCollector(mgr.GetCache()); err != nil {
+		return err
+	}
+
+	// Builds are reconciled again when the sources of their parameters change
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &jcrsv1.LeviathanBuild{}, parametersSourceKey, indexParametersSources); err != nil {
+		return err
+	}
+
+	bldr := ctrl.NewControllerManagedBy(mgr).
 		For(&jcrsv1.LeviathanBuild{}).
 		Owns(&batchv1.Job{}).
+		Owns(&corev1.ConfigMap{}, builder.OnlyMetadata).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.buildsForParametersSource(configMapKind)),
+			builder.OnlyMetadata).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.buildsForSecret),
+			builder.OnlyMetadata)
+
+	// BuildTypeDefinitions are only watched when they are used
+	if featuregates.Enabled(featuregates.BuildTypeDefinitions) {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &jcrsv1.LeviathanBuild{}, pipelineKey, indexPipeline); err != nil {
+			return err
+		}
+		bldr = bldr.Watches(&jcrsv1.BuildTypeDefinition{}, handler.EnqueueRequestsFromMapFunc(r.buildsForBuildTypeDefinition))
+	}
+
+	// BuilderImageMappings are only watched when they are used
+	if featuregates.Enabled(featuregates.BuilderImageMappings) {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &jcrsv1.LeviathanBuild{}, builderImageMappingKey, indexBuilderImageMapping); err != nil {
+			return err
+		}
+		bldr = bldr.Watches(&jcrsv1.BuilderImageMapping{}, handler.EnqueueRequestsFromMapFunc(r.buildsForBuilderImageMapping),
+			builder.WithPredicates(builderImageSelectionChanged))
+	}
+
+	// Deferred builds are started as soon as the MaintenanceWindow holding them back changes
+	if featuregates.Enabled(featuregates.MaintenanceWindows) {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &jcrsv1.LeviathanBuild{}, deferredByMaintenanceKey, indexDeferredByMaintenance); err != nil {
+			return err
+		}
+		bldr = bldr.Watches(&jcrsv1.MaintenanceWindow{}, handler.EnqueueRequestsFromMapFunc(r.buildsForMaintenanceWindow))
+	}
+
+	// Running builds are reconciled when a Secret they recorded the checksum of is
+	// rotated, through the watch of Secrets of buildsForSecret
+	if featuregates.Enabled(featuregates.SecretChecksums) {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &batchv1.Job{}, secretChecksumsKey, indexSecretChecksums); err != nil {
+			return err
+		}
+	}
+
+	// Builds whose Job can't create its pods are reconciled on every refusal
+	if featuregates.Enabled(featuregates.PodCreationChecks) {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Event{}, jobEventsKey, indexJobEvents); err != nil {
+			return err
+		}
+		bldr = bldr.Watches(&corev1.Event{}, handler.EnqueueRequestsFromMapFunc(r.buildForJobEvent),
+			builder.WithPredicates(failedCreateEvents))
+	}
+
+	// Builds waiting for the approval of their latest run publish once it is approved
+	if featuregates.Enabled(featuregates.PublishApprovals) {
+		bldr = bldr.Watches(&jcrsv1.PublishApproval{}, handler.EnqueueRequestsFromMapFunc(r.buildForPublishApproval))
+	}
+
+	// Builds held back for their publisher may publish once the ownership of their package changes
+	if featuregates.Enabled(featuregates.PackageOwnership) {
+		bldr = bldr.Watches(&jcrsv1.PackageOwnership{}, handler.EnqueueRequestsFromMapFunc(r.buildsForPackageOwnership))
+	}
+
+	// Builds polling their source are reconciled when the Poller finds a new revision
+	if r.Poller != nil {
+		bldr = bldr.WatchesRawSource(source.Channel(r.Poller.Events(), &handler.EnqueueRequestForObject{}))
+	}
+	// Builds running a builder image are reconciled when the ImagePoller finds a new digest
+	if r.ImagePoller != nil {
+		bldr = bldr.WatchesRawSource(source.Channel(r.ImagePoller.Events(), &handler.EnqueueRequestForObject{}))
+	}
+
+	// Builds waiting for capacity are run again when a node recovers
+	if r.Capacity != nil {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &jcrsv1.LeviathanBuild{}, waitingForCapacityKey, indexWaitingForCapacity); err != nil {
+			return err
+		}
+		bldr = bldr.Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.buildsForRecoveredNode),
+			builder.WithPredicates(r.Capacity.predicate()))
+	}
+
+	// Running builds are reconciled when they log progress markers
+	if r.Progress != nil {
+		bldr = bldr.WatchesRawSource(source.Channel(r.Progress.Events(), &handler.EnqueueRequestForObject{}))
+	}
+
+	return bldr.
 		Named("leviathanbuild").
 		Complete(r)
 }
@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/batch"
+)
+
+/*
+A LeviathanBuildBatchOperation is applied once, to the builds its selector
+selects when it is reconciled, and kept as the record of what was done to each
+of them. Kubernetes can't change several objects in one transaction, so the
+builds are operated on one by one, and the outcome is written once every build
+was handled. Should the controller stop halfway, the operation is applied again:
+suspending, resuming and cancelling twice change nothing, and the builds created
+by a Trigger are named after the operation, so they aren't created twice.
+*/
+
+// LeviathanBuildBatchOperationReconciler applies LeviathanBuildBatchOperations
+type LeviathanBuildBatchOperationReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuildbatchoperations,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuildbatchoperations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=get;list;watch;create;patch;delete
+
+// Reconcile applies a LeviathanBuildBatchOperation that hasn't been applied yet.
+func (r *LeviathanBuildBatchOperationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var operation jcrsv1.LeviathanBuildBatchOperation
+	if err := r.Get(ctx, req.NamespacedName, &operation); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if operation.Status.CompletionTime != nil {
+		return ctrl.Result{}, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionComplete,
+		Status:             metav1.ConditionTrue,
+		Reason:             jcrsv1.ReasonApplied,
+		ObservedGeneration: operation.Generation,
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&operation.Spec.Selector)
+	if err != nil {
+		condition.Reason = jcrsv1.ReasonInvalidSelector
+		condition.Message = err.Error()
+	} else {
+		results, err := batch.Run(ctx, r.Client, operation.Namespace, selector, operation.Spec.Operation, batch.Options{Name: operation.Name, Parameters: operation.Spec.Parameters})
+		if err != nil {
+			log.Error(err, "Failed to list LeviathanBuilds")
+			return ctrl.Result{}, err
+		}
+		failed := 0
+		for _, result := range results {
+			if !result.Succeeded {
+				failed++
+			}
+		}
+		operation.Status.Builds = results
+		condition.Message = fmt.Sprintf("%s applied to %d build(s)", operation.Spec.Operation, len(results)-failed)
+		if failed > 0 {
+			condition.Reason = jcrsv1.ReasonBuildsFailed
+			condition.Message += fmt.Sprintf(", failed for %d", failed)
+		}
+	}
+	log.Info("Applied batch operation", "operation", operation.Spec.Operation, "reason", condition.Reason, "message", condition.Message)
+
+	operation.Status.CompletionTime = ptr.To(metav1.Now())
+	meta.SetStatusCondition(&operation.Status.Conditions, condition)
+	if err := r.Status().Update(ctx, &operation); err != nil {
+		log.Error(err, "Failed to update LeviathanBuildBatchOperation status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LeviathanBuildBatchOperationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jcrsv1.LeviathanBuildBatchOperation{}).
+		Named("leviathanbuildbatchoperation").
+		Complete(r)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("LeviathanBuildBatchOperation Controller", func() {
+	var (
+		ctx context.Context
+		r   *LeviathanBuildBatchOperationReconciler
+	)
+
+	newOperation := func(name string, selector metav1.LabelSelector) *jcrsv1.LeviathanBuildBatchOperation {
+		return &jcrsv1.LeviathanBuildBatchOperation{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: name},
+			Spec:       jcrsv1.LeviathanBuildBatchOperationSpec{Operation: jcrsv1.SuspendOperation, Selector: selector},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c := newFakeClientBuilder().WithObjects(
+			&jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "ledger", Labels: map[string]string{"team": "payments"}}},
+			&jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "web", Labels: map[string]string{"team": "frontend"}}},
+			newOperation("freeze-payments", metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}),
+			newOperation("invalid", metav1.LabelSelector{MatchLabels: map[string]string{"team": "pay ments"}}),
+		).Build()
+		r = &LeviathanBuildBatchOperationReconciler{Client: c, Scheme: c.Scheme()}
+	})
+
+	reconcile := func(name string) *jcrsv1.LeviathanBuildBatchOperation {
+		key := client.ObjectKey{Namespace: "ci", Name: name}
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		operation := &jcrsv1.LeviathanBuildBatchOperation{}
+		Expect(r.Get(ctx, key, operation)).To(Succeed())
+		return operation
+	}
+
+	suspended := func(name string) bool {
+		lvBuild := &jcrsv1.LeviathanBuild{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "ci", Name: name}, lvBuild)).To(Succeed())
+		return lvBuild.Spec.Suspend
+	}
+
+	It("applies the operation once to the selected builds", func() {
+		operation := reconcile("freeze-payments")
+		Expect(operation.Status.CompletionTime).NotTo(BeNil())
+		Expect(operation.Status.Builds).To(Equal([]jcrsv1.BatchOperationResult{{Name: "ledger", Succeeded: true, Message: "Suspended"}}))
+		complete := meta.FindStatusCondition(operation.Status.Conditions, jcrsv1.ConditionComplete)
+		Expect(complete.Status).To(Equal(metav1.ConditionTrue))
+		Expect(complete.Reason).To(Equal(jcrsv1.ReasonApplied))
+		Expect(suspended("ledger")).To(BeTrue())
+		Expect(suspended("web")).To(BeFalse())
+
+		By("not applying it again")
+		lvBuild := &jcrsv1.LeviathanBuild{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "ci", Name: "ledger"}, lvBuild)).To(Succeed())
+		lvBuild.Spec.Suspend = false
+		Expect(r.Update(ctx, lvBuild)).To(Succeed())
+		Expect(reconcile("freeze-payments").Status.Builds).To(Equal(operation.Status.Builds))
+		Expect(suspended("ledger")).To(BeFalse())
+	})
+
+	It("completes operations with an invalid selector without applying them", func() {
+		operation := reconcile("invalid")
+		Expect(operation.Status.Builds).To(BeEmpty())
+		complete := meta.FindStatusCondition(operation.Status.Conditions, jcrsv1.ConditionComplete)
+		Expect(complete.Reason).To(Equal(jcrsv1.ReasonInvalidSelector))
+		Expect(suspended("ledger")).To(BeFalse())
+	})
+})
@@ -0,0 +1,183 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The summary of a namespace is recomputed from the cached builds whenever one of
+them changes, and exported as metrics at the same time, so dashboards get the
+counts per phase without aggregating the series of every build.
+
+The phase of a build is the status of its Succeeded condition: Pending before
+the first run, Running while the latest run hasn't finished, then Succeeded or
+Failed. The duration of a run goes from the creation of its Job to the last
+transition of the condition.
+*/
+
+const (
+	// summaryWindow is the period failed24h counts failures over
+	summaryWindow = 24 * time.Hour
+
+	// Phases of LeviathanBuilds, as used for the summary metrics
+	phasePending   = "Pending"
+	phaseRunning   = "Running"
+	phaseSucceeded = "Succeeded"
+	phaseFailed    = "Failed"
+)
+
+// LeviathanBuildSummaryReconciler maintains the LeviathanBuildSummary of every namespace holding LeviathanBuilds
+type LeviathanBuildSummaryReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuildsummaries,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuildsummaries/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=get;list;watch
+
+// Reconcile recomputes the summary of the builds of a namespace. The summary is
+// created along with the first build of the namespace and deleted with the last one.
+func (r *LeviathanBuildSummaryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var builds jcrsv1.LeviathanBuildList
+	if err := r.List(ctx, &builds, client.InNamespace(req.Namespace)); err != nil {
+		log.Error(err, "Failed to list LeviathanBuilds")
+		return ctrl.Result{}, err
+	}
+
+	summary := &jcrsv1.LeviathanBuildSummary{}
+	if err := r.Get(ctx, req.NamespacedName, summary); apierrors.IsNotFound(err) {
+		summary = nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if len(builds.Items) == 0 {
+		deleteSummaryMetrics(req.Namespace)
+		if summary == nil {
+			return ctrl.Result{}, nil
+		}
+		log.Info("Deleting LeviathanBuildSummary")
+		return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, summary))
+	}
+
+	status, requeueAfter := summarize(builds.Items, time.Now())
+	recordSummaryMetrics(req.Namespace, status)
+
+	if summary == nil {
+		summary = &jcrsv1.LeviathanBuildSummary{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}
+		if err := r.Create(ctx, summary); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if equality.Semantic.DeepEqual(summary.Status, status) {
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+	summary.Status = status
+	if err := r.Status().Update(ctx, summary); err != nil {
+		log.Error(err, "unable to update LeviathanBuildSummary status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// buildPhase returns the phase of lvBuild.
+func buildPhase(lvBuild *jcrsv1.LeviathanBuild) string {
+	switch condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded); {
+	case condition == nil:
+		return phasePending
+	case condition.Status == metav1.ConditionTrue:
+		return phaseSucceeded
+	case condition.Status == metav1.ConditionFalse:
+		return phaseFailed
+	default:
+		return phaseRunning
+	}
+}
+
+// summarize counts builds at now. It also returns when the count of failures in
+// the last 24 hours drops next, or 0 when it can't without a change to the builds.
+func summarize(builds []jcrsv1.LeviathanBuild, now time.Time) (jcrsv1.LeviathanBuildSummaryStatus, time.Duration) {
+	status := jcrsv1.LeviathanBuildSummaryStatus{Total: int32(len(builds))}
+	var requeueAfter, total time.Duration
+	finished := 0
+	for i := range builds {
+		lvBuild := &builds[i]
+		switch buildPhase(lvBuild) {
+		case phasePending:
+			status.Pending++
+			continue
+		case phaseRunning:
+			status.Running++
+			continue
+		case phaseSucceeded:
+			status.Succeeded++
+		case phaseFailed:
+			status.Failed++
+		}
+
+		finishedAt := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded).LastTransitionTime.Time
+		if jcrsv1.IsFailed(lvBuild.Status.Conditions) {
+			if expiresIn := finishedAt.Add(summaryWindow).Sub(now); expiresIn > 0 {
+				status.Failed24h++
+				if requeueAfter == 0 || expiresIn < requeueAfter {
+					requeueAfter = expiresIn
+				}
+			}
+		}
+		if lvBuild.Status.LastJobTime != nil && finishedAt.After(lvBuild.Status.LastJobTime.Time) {
+			total += finishedAt.Sub(lvBuild.Status.LastJobTime.Time)
+			finished++
+		}
+	}
+	if finished > 0 {
+		status.AverageDuration = &metav1.Duration{Duration: (total / time.Duration(finished)).Round(time.Second)}
+	}
+	return status, requeueAfter
+}
+
+// summaryFor maps an object to the summary of its namespace.
+func summaryFor(_ context.Context, obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: jcrsv1.LeviathanBuildSummaryName}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LeviathanBuildSummaryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jcrsv1.LeviathanBuildSummary{}).
+		Watches(&jcrsv1.LeviathanBuild{}, handler.EnqueueRequestsFromMapFunc(summaryFor)).
+		Named("leviathanbuildsummary").
+		Complete(r)
+}
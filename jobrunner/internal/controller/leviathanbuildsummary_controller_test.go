@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("LeviathanBuildSummary", func() {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// build returns a build whose latest run started at start and, unless status is
+	// Unknown, finished after duration
+	build := func(name string, status metav1.ConditionStatus, start time.Time, duration time.Duration) *jcrsv1.LeviathanBuild {
+		lvBuild := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "summary-test"},
+			Status:     jcrsv1.LeviathanBuildStatus{LastJobTime: &metav1.Time{Time: start}},
+		}
+		lvBuild.Status.Conditions = []metav1.Condition{{
+			Type:               jcrsv1.ConditionSucceeded,
+			Status:             status,
+			Reason:             jcrsv1.ReasonRunning,
+			LastTransitionTime: metav1.Time{Time: start.Add(duration)},
+		}}
+		return lvBuild
+	}
+
+	It("counts builds by phase and averages the duration of finished runs", func() {
+		builds := []jcrsv1.LeviathanBuild{
+			{ObjectMeta: metav1.ObjectMeta{Name: "new"}},
+			*build("running", metav1.ConditionUnknown, now.Add(-time.Minute), 0),
+			*build("succeeded", metav1.ConditionTrue, now.Add(-time.Hour), 10*time.Minute),
+			*build("failed-recently", metav1.ConditionFalse, now.Add(-2*time.Hour), 20*time.Minute),
+			*build("failed-long-ago", metav1.ConditionFalse, now.Add(-48*time.Hour), 30*time.Minute),
+		}
+		status, requeueAfter := summarize(builds, now)
+		Expect(status).To(Equal(jcrsv1.LeviathanBuildSummaryStatus{
+			Total:           5,
+			Pending:         1,
+			Running:         1,
+			Succeeded:       1,
+			Failed:          2,
+			Failed24h:       1,
+			AverageDuration: &metav1.Duration{Duration: 20 * time.Minute},
+		}))
+		By("recounting once the recent failure leaves the window")
+		Expect(requeueAfter).To(Equal(22*time.Hour + 20*time.Minute))
+
+		status, requeueAfter = summarize(builds[:2], now)
+		Expect(status.AverageDuration).To(BeNil())
+		Expect(requeueAfter).To(BeZero())
+	})
+
+	It("maintains the summary and metrics of a namespace while it holds builds", func() {
+		lvBuild := build("web", metav1.ConditionTrue, time.Now().Add(-time.Hour), 90*time.Second)
+		c := newFakeClient(lvBuild)
+		r := &LeviathanBuildSummaryReconciler{Client: c, Scheme: c.Scheme()}
+
+		key := types.NamespacedName{Namespace: "summary-test", Name: jcrsv1.LeviathanBuildSummaryName}
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		summary := &jcrsv1.LeviathanBuildSummary{}
+		Expect(c.Get(context.Background(), key, summary)).To(Succeed())
+		Expect(summary.Status.Total).To(BeEquivalentTo(1))
+		Expect(summary.Status.Succeeded).To(BeEquivalentTo(1))
+		Expect(summary.Status.AverageDuration.Duration).To(Equal(90 * time.Second))
+		Expect(testutil.ToFloat64(namespaceBuilds.WithLabelValues("summary-test", phaseSucceeded))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(namespaceAverageDurationSeconds.WithLabelValues("summary-test"))).To(Equal(90.0))
+
+		By("deleting the summary with the last build")
+		Expect(c.Delete(context.Background(), lvBuild)).To(Succeed())
+		_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(apierrors.IsNotFound(c.Get(context.Background(), key, summary))).To(BeTrue())
+		Expect(testutil.CollectAndCompare(namespaceFailed24h, strings.NewReader(""))).To(Succeed())
+	})
+})
@@ -0,0 +1,205 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A LeviathanClusterBuild doesn't belong to a namespace, but its Job has to run in
+one: the cluster build namespace the controller is configured with. The Job is
+constructed from the LeviathanBuild standing for the cluster build in that
+namespace, by the same code as the Jobs of LeviathanBuilds, so the two kinds of
+build run alike. That LeviathanBuild has the name and UID of the cluster build,
+and the Job is controlled by the cluster build itself, so it's garbage collected
+with it.
+
+Only the lifecycle of the Job is managed: a Job is created for every run, and
+replaced when the spec of the build changes. The gates, hooks and records of
+LeviathanBuilds work on objects of the namespace of the build, and don't apply
+to cluster builds.
+*/
+
+// LeviathanClusterBuildReconciler reconciles a LeviathanClusterBuild object
+type LeviathanClusterBuildReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Builds constructs the Jobs of cluster builds like those of LeviathanBuilds.
+	Builds *LeviathanBuildReconciler
+
+	// BuildNamespace is the namespace the Jobs of cluster builds run in.
+	BuildNamespace string
+}
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanclusterbuilds,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanclusterbuilds/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanclusterbuilds/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile creates the Job of the latest run of a LeviathanClusterBuild, and
+// reports it in the status of the build.
+func (r *LeviathanClusterBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var clusterBuild jcrsv1.LeviathanClusterBuild
+	if err := r.Get(ctx, req.NamespacedName, &clusterBuild); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	observed := clusterBuild.Status.DeepCopy()
+	lvBuild := clusterBuild.NamespacedBuild(r.BuildNamespace)
+	lvBuild.Status.BlockingReason = nil
+
+	result, err := r.reconcileJob(ctx, &clusterBuild, lvBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	clusterBuild.Status = lvBuild.Status
+	if !equality.Semantic.DeepEqual(*observed, clusterBuild.Status) {
+		if err := r.Status().Update(ctx, &clusterBuild); err != nil {
+			log.Error(err, "unable to update LeviathanClusterBuild status")
+			return ctrl.Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// reconcileJob creates or replaces the Job of lvBuild, standing for clusterBuild,
+// and records it in the status of lvBuild.
+func (r *LeviathanClusterBuildReconciler) reconcileJob(ctx context.Context, clusterBuild *jcrsv1.LeviathanClusterBuild, lvBuild *jcrsv1.LeviathanBuild) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	if err := r.Builds.reconcileInlineScript(ctx, lvBuild, clusterBuild); err != nil {
+		log.Error(err, "Failed to reconcile inline script ConfigMap")
+		return ctrl.Result{}, err
+	}
+	params, err := r.Builds.resolveParameters(ctx, lvBuild)
+	if perr := (*parametersError)(nil); errors.As(err, &perr) {
+		setInvalidJobTemplate(lvBuild, jcrsv1.ReasonParametersUnresolved, err)
+		setBlocked(lvBuild, jcrsv1.BlockedByParameters, jcrsv1.ConditionInvalidJobTemplate)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	desiredJob, err := r.Builds.constructJob(lvBuild, params, false)
+	if err == nil {
+		// The Job belongs to the cluster build, not to the LeviathanBuild standing for it
+		desiredJob.OwnerReferences = nil
+		err = ctrl.SetControllerReference(clusterBuild, desiredJob, r.Scheme)
+	}
+	if err != nil {
+		setInvalidJobTemplate(lvBuild, jcrsv1.ReasonConstructionFailed, err)
+		setBlocked(lvBuild, jcrsv1.BlockedByInvalidJobTemplate, jcrsv1.ConditionInvalidJobTemplate)
+		return ctrl.Result{}, nil
+	}
+
+	existingJob, latestRunIndex, err := r.Builds.latestJob(ctx, lvBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if existingJob != nil {
+		changes, err := r.Builds.jobChanges(ctx, existingJob, desiredJob)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if len(changes) > 0 && !lvBuild.Spec.Suspend {
+			log.Info("Job Spec doesn't match desired state. Deleting existing job.", "Job.Namespace", existingJob.Namespace, "Job.Name", existingJob.Name,
+				"changes", summarizeChanges(changes))
+			if err := r.Delete(ctx, existingJob, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, err
+			}
+			existingJob = nil
+		}
+	}
+
+	if existingJob == nil {
+		if lvBuild.Spec.Suspend {
+			lvBuild.Status.BlockingReason = &jcrsv1.BlockingReason{Reason: jcrsv1.BlockedBySuspend, Details: "spec.suspend is set"}
+			return ctrl.Result{}, nil
+		}
+		runIndex := max(lvBuild.Status.RunIndex, latestRunIndex) + 1
+		desiredJob.GenerateName = jobGenerateName(lvBuild, runIndex)
+		if name, err := TemplateName(lvBuild, runIndex); err != nil {
+			setInvalidJobTemplate(lvBuild, jcrsv1.ReasonInvalidNamingTemplate, err)
+			setBlocked(lvBuild, jcrsv1.BlockedByInvalidJobTemplate, jcrsv1.ConditionInvalidJobTemplate)
+			return ctrl.Result{}, nil
+		} else if name != "" {
+			desiredJob.GenerateName, desiredJob.Name = "", name
+		}
+		setRunLabels(lvBuild, desiredJob, runIndex)
+
+		accepted, err := r.Builds.dryRunJob(ctx, lvBuild, desiredJob)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !accepted {
+			setBlocked(lvBuild, jcrsv1.BlockedByInvalidJobTemplate, jcrsv1.ConditionInvalidJobTemplate)
+			return ctrl.Result{RequeueAfter: invalidJobTemplateRetryInterval}, nil
+		}
+
+		if err := setManaged(desiredJob); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Info("Creating a new Job", "Job.Namespace", desiredJob.Namespace, "Job.GenerateName", desiredJob.GenerateName)
+		if err := r.Create(ctx, desiredJob); err != nil {
+			return ctrl.Result{}, err
+		}
+		lvBuild.Status.RunIndex = runIndex
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRunning, "Job "+desiredJob.Name+" is running")
+		return ctrl.Result{}, nil
+	}
+	setInvalidJobTemplate(lvBuild, "", nil)
+
+	// Ready and Succeeded follow the Job of the latest run
+	lvBuild.Status.RunIndex = max(lvBuild.Status.RunIndex, latestRunIndex)
+	switch finished, finishedType := isJobFinished(existingJob); {
+	case !finished:
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRunning, "Job "+existingJob.Name+" is running")
+	case finishedType == batchv1.JobComplete:
+		jcrsv1.MarkSucceeded(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonJobComplete, "Job "+existingJob.Name+" completed")
+	default:
+		jcrsv1.MarkFailed(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonJobFailed, "Job "+existingJob.Name+" failed")
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LeviathanClusterBuildReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jcrsv1.LeviathanClusterBuild{}).
+		Owns(&batchv1.Job{}).
+		Owns(&corev1.ConfigMap{}, builder.OnlyMetadata).
+		Named("leviathanclusterbuild").
+		Complete(r)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("LeviathanClusterBuild Controller", func() {
+	var (
+		ctx context.Context
+		r   *LeviathanClusterBuildReconciler
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterBuild := &jcrsv1.LeviathanClusterBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "base-image", UID: types.UID("base-image-uid"), Generation: 1},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName: ptr.To("base-image"),
+				SourceType:  jcrsv1.InlineSource,
+				Source:      &jcrsv1.SourceSpec{Inline: &jcrsv1.InlineSourceSpec{Script: "make base-image"}},
+				JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					Containers:    []corev1.Container{{Name: "build", Image: "builder"}},
+					RestartPolicy: corev1.RestartPolicyNever,
+				}}}},
+			},
+		}
+		c := newFakeClient(clusterBuild)
+		r = &LeviathanClusterBuildReconciler{
+			Client:         c,
+			Scheme:         c.Scheme(),
+			Builds:         &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()},
+			BuildNamespace: "platform-builds",
+		}
+	})
+
+	reconcile := func() *jcrsv1.LeviathanClusterBuild {
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "base-image"}})
+		Expect(err).NotTo(HaveOccurred())
+		clusterBuild := &jcrsv1.LeviathanClusterBuild{}
+		Expect(r.Get(ctx, client.ObjectKey{Name: "base-image"}, clusterBuild)).To(Succeed())
+		return clusterBuild
+	}
+
+	It("runs the Job of the cluster build in the build namespace", func() {
+		clusterBuild := reconcile()
+		Expect(clusterBuild.Status.RunIndex).To(Equal(int64(1)))
+		Expect(meta.IsStatusConditionTrue(clusterBuild.Status.Conditions, jcrsv1.ConditionReady)).To(BeFalse())
+		Expect(meta.FindStatusCondition(clusterBuild.Status.Conditions, jcrsv1.ConditionReady).Reason).To(Equal(jcrsv1.ReasonRunning))
+
+		var jobs batchv1.JobList
+		Expect(r.List(ctx, &jobs, client.InNamespace("platform-builds"))).To(Succeed())
+		Expect(jobs.Items).To(HaveLen(1))
+		job := &jobs.Items[0]
+		Expect(metav1.IsControlledBy(job, clusterBuild)).To(BeTrue())
+		Expect(metav1.GetControllerOf(job).Kind).To(Equal("LeviathanClusterBuild"))
+		Expect(job.Labels).To(HaveKeyWithValue(runIndexLabel, "1"))
+
+		script := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "platform-builds", Name: "base-image-script"}, script)).To(Succeed())
+		Expect(metav1.IsControlledBy(script, clusterBuild)).To(BeTrue())
+
+		By("reporting the outcome of the Job")
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		Expect(r.Status().Update(ctx, job)).To(Succeed())
+		clusterBuild = reconcile()
+		Expect(jcrsv1.IsSucceeded(clusterBuild.Status.Conditions)).To(BeTrue())
+		Expect(r.List(ctx, &jobs, client.InNamespace("platform-builds"))).To(Succeed())
+		Expect(jobs.Items).To(HaveLen(1))
+	})
+
+	It("holds suspended cluster builds back", func() {
+		clusterBuild := &jcrsv1.LeviathanClusterBuild{}
+		Expect(r.Get(ctx, client.ObjectKey{Name: "base-image"}, clusterBuild)).To(Succeed())
+		clusterBuild.Spec.Suspend = true
+		Expect(r.Update(ctx, clusterBuild)).To(Succeed())
+
+		clusterBuild = reconcile()
+		Expect(clusterBuild.Status.BlockingReason.Reason).To(Equal(jcrsv1.BlockedBySuspend))
+		var jobs batchv1.JobList
+		Expect(r.List(ctx, &jobs, client.InNamespace("platform-builds"))).To(Succeed())
+		Expect(jobs.Items).To(BeEmpty())
+	})
+})
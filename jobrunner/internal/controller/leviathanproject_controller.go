@@ -0,0 +1,353 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/rerun"
+)
+
+/*
+A LeviathanProject groups the builds of its namespace labeled with its name. A
+package of the project is a packageName of its builds, and its state is the
+phase of its latest build: builds run again, by `leviathan rerun` or a batch
+Trigger, keep the labels of the build they rerun, so the latest build is the
+latest run of the package. The roll-up counts the packages whose latest run
+succeeded at the revision of the project.
+
+A rebuild runs the latest build of every package again, as a rerun named after
+the project and the spec.rebuild that triggered it, so a rebuild started again
+after a restart of the controller doesn't create its builds twice. A package is
+only rerun once the packages it depends on have been rebuilt, and not at all
+when one of them failed. Dependencies on packages the project doesn't have are
+ignored, and dependencies forming a cycle fail the rebuild before it starts.
+*/
+
+// ProjectRebuildAnnotation records the spec.rebuild of the LeviathanProject
+// whose rebuild created a build
+const ProjectRebuildAnnotation = "jcrs.jcrs.dev/project-rebuild"
+
+// LeviathanProjectReconciler rolls up the state of the builds of LeviathanProjects, and rebuilds them
+type LeviathanProjectReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanprojects,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanprojects/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=get;list;watch;create
+
+// Reconcile recomputes the state of the packages of a LeviathanProject, and
+// advances its rebuild.
+func (r *LeviathanProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var project jcrsv1.LeviathanProject
+	if err := r.Get(ctx, req.NamespacedName, &project); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	var builds jcrsv1.LeviathanBuildList
+	if err := r.List(ctx, &builds, client.InNamespace(project.Namespace), client.MatchingLabels{jcrsv1.ProjectLabel: project.Name}); err != nil {
+		log.Error(err, "Failed to list LeviathanBuilds")
+		return ctrl.Result{}, err
+	}
+
+	byName := make(map[string]*jcrsv1.LeviathanBuild, len(builds.Items))
+	for i := range builds.Items {
+		byName[builds.Items[i].Name] = &builds.Items[i]
+	}
+	status := project.Status.DeepCopy()
+	latest := latestPackageBuilds(builds.Items)
+	rollUpProject(&project, latest, status)
+	if err := r.reconcileRebuild(ctx, &project, byName, latest, status); err != nil {
+		log.Error(err, "Failed to rebuild LeviathanProject")
+		return ctrl.Result{}, err
+	}
+
+	if equality.Semantic.DeepEqual(project.Status, *status) {
+		return ctrl.Result{}, nil
+	}
+	project.Status = *status
+	if err := r.Status().Update(ctx, &project); err != nil {
+		log.Error(err, "unable to update LeviathanProject status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// projectPackage returns the package of lvBuild in its project.
+func projectPackage(lvBuild *jcrsv1.LeviathanBuild) string {
+	return ptr.Deref(lvBuild.Spec.PackageName, lvBuild.Name)
+}
+
+// latestPackageBuilds returns the latest build of every package of builds, by
+// package. Builds being deleted aren't the latest build of their package.
+func latestPackageBuilds(builds []jcrsv1.LeviathanBuild) map[string]*jcrsv1.LeviathanBuild {
+	latest := make(map[string]*jcrsv1.LeviathanBuild)
+	for i := range builds {
+		lvBuild := &builds[i]
+		if !lvBuild.DeletionTimestamp.IsZero() {
+			continue
+		}
+		current, ok := latest[projectPackage(lvBuild)]
+		if !ok || current.CreationTimestamp.Before(&lvBuild.CreationTimestamp) ||
+			(current.CreationTimestamp.Equal(&lvBuild.CreationTimestamp) && current.Name < lvBuild.Name) {
+			latest[projectPackage(lvBuild)] = lvBuild
+		}
+	}
+	return latest
+}
+
+// packageStatus returns the state of the package built by lvBuild.
+func packageStatus(pkg string, lvBuild *jcrsv1.LeviathanBuild) jcrsv1.ProjectPackageStatus {
+	return jcrsv1.ProjectPackageStatus{
+		Package:  pkg,
+		Build:    lvBuild.Name,
+		State:    jcrsv1.ProjectPackageState(buildPhase(lvBuild)),
+		Revision: lvBuild.Status.SourceRevision,
+	}
+}
+
+// rollUpProject sets the state of the packages of project, whose latest builds
+// are latest, in status.
+func rollUpProject(project *jcrsv1.LeviathanProject, latest map[string]*jcrsv1.LeviathanBuild, status *jcrsv1.LeviathanProjectStatus) {
+	status.PackageStatuses = nil
+	revisions := make(map[string]int)
+	for _, pkg := range slices.Sorted(maps.Keys(latest)) {
+		packageStatus := packageStatus(pkg, latest[pkg])
+		status.PackageStatuses = append(status.PackageStatuses, packageStatus)
+		if packageStatus.State == jcrsv1.PackageSucceeded && packageStatus.Revision != "" {
+			revisions[packageStatus.Revision]++
+		}
+	}
+
+	// Without an expected revision, the packages are counted at the revision most of them built
+	status.Revision = project.Spec.Revision
+	if status.Revision == "" {
+		for _, revision := range slices.Sorted(maps.Keys(revisions)) {
+			if revisions[revision] > revisions[status.Revision] {
+				status.Revision = revision
+			}
+		}
+	}
+	status.Packages = int32(len(status.PackageStatuses))
+	status.Built = 0
+	for _, packageStatus := range status.PackageStatuses {
+		if packageStatus.State == jcrsv1.PackageSucceeded && (status.Revision == "" || packageStatus.Revision == status.Revision) {
+			status.Built++
+		}
+	}
+	status.Summary = fmt.Sprintf("%d/%d packages built", status.Built, status.Packages)
+	if status.Revision != "" {
+		status.Summary += " at " + status.Revision
+	}
+}
+
+// dependencyOrder returns packages sorted so that every package comes after the
+// packages it depends on: first the packages without dependencies, then the
+// packages depending on them only, and so on, each by name. It also returns the
+// dependencies of every package among packages. It fails when the dependencies
+// form a cycle.
+func dependencyOrder(packages []string, dependencies []jcrsv1.PackageDependencies) ([]string, map[string][]string, error) {
+	deps := make(map[string][]string)
+	for _, dependency := range dependencies {
+		if !slices.Contains(packages, dependency.Package) {
+			continue
+		}
+		for _, pkg := range dependency.DependsOn {
+			if slices.Contains(packages, pkg) {
+				deps[dependency.Package] = append(deps[dependency.Package], pkg)
+			}
+		}
+	}
+
+	packages = slices.Sorted(slices.Values(packages))
+	order := make([]string, 0, len(packages))
+	done := make(map[string]bool)
+	for len(order) < len(packages) {
+		progressed := false
+		for _, pkg := range packages {
+			if !done[pkg] && !slices.ContainsFunc(deps[pkg], func(dep string) bool { return !done[dep] }) {
+				done[pkg] = true
+				order = append(order, pkg)
+				progressed = true
+			}
+		}
+		if !progressed {
+			var cycle []string
+			for _, pkg := range packages {
+				if !done[pkg] {
+					cycle = append(cycle, pkg)
+				}
+			}
+			return nil, nil, fmt.Errorf("the dependencies of packages %s form a cycle", strings.Join(cycle, ", "))
+		}
+	}
+	return order, deps, nil
+}
+
+// projectRebuildName returns the name of the build rebuilding lvBuild for the
+// rebuild of project.
+func projectRebuildName(project *jcrsv1.LeviathanProject, lvBuild *jcrsv1.LeviathanBuild) string {
+	suffix := "-" + nameHash(string(project.UID)+"/"+project.Spec.Rebuild)
+	return shortenName(lvBuild.Name, validation.DNS1123LabelMaxLength-len(suffix)) + suffix
+}
+
+// reconcileRebuild starts the rebuild of project when its spec.rebuild changed,
+// and reruns the packages of the rebuild whose dependencies have been rebuilt.
+// builds are the builds of the project by name, and latest the latest build of
+// every package.
+func (r *LeviathanProjectReconciler) reconcileRebuild(ctx context.Context, project *jcrsv1.LeviathanProject, builds, latest map[string]*jcrsv1.LeviathanBuild, status *jcrsv1.LeviathanProjectStatus) error {
+	if project.Spec.Rebuild == "" {
+		return nil
+	}
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionRebuilt,
+		Status:             metav1.ConditionUnknown,
+		Reason:             jcrsv1.ReasonRebuilding,
+		ObservedGeneration: project.Generation,
+	}
+	rebuild := status.Rebuild
+	if rebuild != nil && rebuild.Rebuild == project.Spec.Rebuild && rebuild.CompletionTime != nil {
+		return nil
+	}
+
+	order, deps, err := dependencyOrder(slices.Collect(maps.Keys(latest)), project.Spec.Dependencies)
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = jcrsv1.ReasonDependencyCycle
+		condition.Message = err.Error()
+		meta.SetStatusCondition(&status.Conditions, condition)
+		return nil
+	}
+	if rebuild == nil || rebuild.Rebuild != project.Spec.Rebuild {
+		rebuild = &jcrsv1.ProjectRebuildStatus{Rebuild: project.Spec.Rebuild, StartTime: metav1.Now()}
+		for _, pkg := range order {
+			rebuild.Packages = append(rebuild.Packages, jcrsv1.ProjectPackageStatus{Package: pkg, State: jcrsv1.PackagePending})
+		}
+		status.Rebuild = rebuild
+		logf.FromContext(ctx).Info("Rebuilding LeviathanProject", "rebuild", project.Spec.Rebuild, "packages", len(order))
+	}
+
+	states := make(map[string]jcrsv1.ProjectPackageState)
+	for i := range rebuild.Packages {
+		pkgStatus := &rebuild.Packages[i]
+		lvBuild, ok := latest[pkgStatus.Package]
+		switch {
+		case pkgStatus.State == jcrsv1.PackageSucceeded || pkgStatus.State == jcrsv1.PackageFailed || pkgStatus.State == jcrsv1.PackageBlocked:
+			// The outcome of a package is final
+		case slices.ContainsFunc(deps[pkgStatus.Package], func(dep string) bool {
+			return states[dep] == jcrsv1.PackageFailed || states[dep] == jcrsv1.PackageBlocked
+		}):
+			pkgStatus.State = jcrsv1.PackageBlocked
+		case slices.ContainsFunc(deps[pkgStatus.Package], func(dep string) bool { return states[dep] != jcrsv1.PackageSucceeded }):
+			// Waiting for the packages it depends on
+		case pkgStatus.Build == "" && !ok:
+			// The builds of the package were deleted during the rebuild
+			pkgStatus.State = jcrsv1.PackageFailed
+		case pkgStatus.Build == "":
+			newBuild, err := r.createRebuild(ctx, project, lvBuild)
+			if err != nil {
+				return err
+			}
+			pkgStatus.Build = newBuild.Name
+		default:
+			// The build created for the package may not be in the cache yet
+			if rebuilt := builds[pkgStatus.Build]; rebuilt != nil {
+				*pkgStatus = packageStatus(pkgStatus.Package, rebuilt)
+			}
+		}
+		states[pkgStatus.Package] = pkgStatus.State
+	}
+
+	failed := 0
+	for _, pkgStatus := range rebuild.Packages {
+		switch pkgStatus.State {
+		case jcrsv1.PackageSucceeded:
+		case jcrsv1.PackageFailed, jcrsv1.PackageBlocked:
+			failed++
+		default:
+			condition.Message = fmt.Sprintf("Rebuilding %d package(s)", len(rebuild.Packages))
+			meta.SetStatusCondition(&status.Conditions, condition)
+			return nil
+		}
+	}
+	rebuild.CompletionTime = ptr.To(metav1.Now())
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = jcrsv1.ReasonPackagesRebuilt
+	condition.Message = fmt.Sprintf("Rebuilt %d package(s)", len(rebuild.Packages))
+	if failed > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = jcrsv1.ReasonPackagesFailed
+		condition.Message = fmt.Sprintf("%d of %d package(s) failed to rebuild", failed, len(rebuild.Packages))
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+	return nil
+}
+
+// createRebuild creates the build rerunning lvBuild for the rebuild of project.
+// A build created by an earlier attempt is kept.
+func (r *LeviathanProjectReconciler) createRebuild(ctx context.Context, project *jcrsv1.LeviathanProject, lvBuild *jcrsv1.LeviathanBuild) (*jcrsv1.LeviathanBuild, error) {
+	newBuild, _, err := rerun.New(lvBuild, rerun.Options{Name: projectRebuildName(project, lvBuild)})
+	if err != nil {
+		return nil, err
+	}
+	newBuild.Annotations[ProjectRebuildAnnotation] = project.Spec.Rebuild
+	if err := r.Create(ctx, newBuild); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	logf.FromContext(ctx).Info("Rebuilding package", "package", projectPackage(lvBuild), "build", newBuild.Name)
+	return newBuild, nil
+}
+
+// projectFor maps a build to the project it belongs to, if any.
+func projectFor(_ context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()[jcrsv1.ProjectLabel]
+	if name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LeviathanProjectReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jcrsv1.LeviathanProject{}).
+		Watches(&jcrsv1.LeviathanBuild{}, handler.EnqueueRequestsFromMapFunc(projectFor)).
+		Named("leviathanproject").
+		Complete(r)
+}
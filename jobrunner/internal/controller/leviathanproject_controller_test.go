@@ -0,0 +1,201 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("LeviathanProject", func() {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// build returns a build of package pkg of the storefront project, created
+	// after age, whose latest run ended with status at revision
+	build := func(name, pkg string, age time.Duration, status metav1.ConditionStatus, revision string) *jcrsv1.LeviathanBuild {
+		lvBuild := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "team",
+				Labels:            map[string]string{jcrsv1.ProjectLabel: "storefront"},
+				CreationTimestamp: metav1.NewTime(now.Add(age)),
+			},
+			Spec:   jcrsv1.LeviathanBuildSpec{PackageName: ptr.To(pkg)},
+			Status: jcrsv1.LeviathanBuildStatus{SourceRevision: revision},
+		}
+		if status != "" {
+			lvBuild.Status.Conditions = []metav1.Condition{{Type: jcrsv1.ConditionSucceeded, Status: status, Reason: jcrsv1.ReasonRunning}}
+		}
+		return lvBuild
+	}
+
+	It("rolls up the latest build of every package at the revision most of them built", func() {
+		project := &jcrsv1.LeviathanProject{}
+		latest := latestPackageBuilds([]jcrsv1.LeviathanBuild{
+			*build("api-old", "api", 0, metav1.ConditionFalse, "v1"),
+			*build("api", "api", time.Minute, metav1.ConditionTrue, "v2"),
+			*build("models", "models", 0, metav1.ConditionTrue, "v2"),
+			*build("web", "web", 0, metav1.ConditionTrue, "v1"),
+			*build("ui-kit", "ui-kit", 0, metav1.ConditionUnknown, ""),
+		})
+		var status jcrsv1.LeviathanProjectStatus
+		rollUpProject(project, latest, &status)
+		Expect(status.Summary).To(Equal("2/4 packages built at v2"))
+		Expect(status.PackageStatuses).To(Equal([]jcrsv1.ProjectPackageStatus{
+			{Package: "api", Build: "api", State: jcrsv1.PackageSucceeded, Revision: "v2"},
+			{Package: "models", Build: "models", State: jcrsv1.PackageSucceeded, Revision: "v2"},
+			{Package: "ui-kit", Build: "ui-kit", State: jcrsv1.PackageRunning},
+			{Package: "web", Build: "web", State: jcrsv1.PackageSucceeded, Revision: "v1"},
+		}))
+
+		By("counting the packages at the revision of the project when it has one")
+		project.Spec.Revision = "v1"
+		rollUpProject(project, latest, &status)
+		Expect(status.Summary).To(Equal("1/4 packages built at v1"))
+	})
+
+	It("orders the packages after the packages they depend on", func() {
+		order, deps, err := dependencyOrder([]string{"web", "models", "api", "ui-kit"}, []jcrsv1.PackageDependencies{
+			{Package: "web", DependsOn: []string{"api", "ui-kit", "gone"}},
+			{Package: "api", DependsOn: []string{"models"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(order).To(Equal([]string{"models", "ui-kit", "api", "web"}))
+		Expect(deps).To(HaveKeyWithValue("web", []string{"api", "ui-kit"}))
+
+		_, _, err = dependencyOrder([]string{"web", "api", "models"}, []jcrsv1.PackageDependencies{
+			{Package: "web", DependsOn: []string{"api"}},
+			{Package: "api", DependsOn: []string{"web"}},
+		})
+		Expect(err).To(MatchError("the dependencies of packages api, web form a cycle"))
+	})
+
+	It("rebuilds the packages of the project in dependency order", func() {
+		project := &jcrsv1.LeviathanProject{
+			ObjectMeta: metav1.ObjectMeta{Name: "storefront", Namespace: "team", UID: "project-uid"},
+			Spec: jcrsv1.LeviathanProjectSpec{
+				Dependencies: []jcrsv1.PackageDependencies{{Package: "api", DependsOn: []string{"models"}}},
+				Rebuild:      "release-1",
+			},
+		}
+		c := newFakeClient(project, build("api", "api", 0, metav1.ConditionTrue, "v1"), build("models", "models", 0, metav1.ConditionTrue, "v1"))
+		r := &LeviathanProjectReconciler{Client: c, Scheme: c.Scheme()}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "storefront"}}
+
+		// rebuilt returns the builds created by the rebuild
+		rebuilt := func() []jcrsv1.LeviathanBuild {
+			var builds jcrsv1.LeviathanBuildList
+			Expect(c.List(ctx, &builds, client.InNamespace("team"))).To(Succeed())
+			var created []jcrsv1.LeviathanBuild
+			for _, lvBuild := range builds.Items {
+				if lvBuild.Annotations[ProjectRebuildAnnotation] == "release-1" {
+					created = append(created, lvBuild)
+				}
+			}
+			return created
+		}
+		// finish ends the run of the build named name with status
+		finish := func(name string, status metav1.ConditionStatus) {
+			lvBuild := &jcrsv1.LeviathanBuild{}
+			Expect(c.Get(ctx, types.NamespacedName{Namespace: "team", Name: name}, lvBuild)).To(Succeed())
+			meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{Type: jcrsv1.ConditionSucceeded, Status: status, Reason: jcrsv1.ReasonRunning})
+			Expect(c.Status().Update(ctx, lvBuild)).To(Succeed())
+		}
+
+		By("rebuilding the packages without dependencies first")
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		created := rebuilt()
+		Expect(created).To(HaveLen(1))
+		Expect(created[0].Spec.PackageName).To(HaveValue(Equal("models")))
+		Expect(created[0].Labels).To(HaveKeyWithValue(jcrsv1.ProjectLabel, "storefront"))
+		modelsBuild := created[0].Name
+		Expect(modelsBuild).To(Equal(projectRebuildName(project, build("models", "models", 0, "", ""))))
+
+		By("not rebuilding a package twice")
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rebuilt()).To(HaveLen(1))
+		Expect(c.Get(ctx, req.NamespacedName, project)).To(Succeed())
+		Expect(meta.FindStatusCondition(project.Status.Conditions, jcrsv1.ConditionRebuilt)).To(HaveField("Reason", jcrsv1.ReasonRebuilding))
+
+		By("rebuilding a package once its dependencies have been rebuilt")
+		finish(modelsBuild, metav1.ConditionTrue)
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rebuilt()).To(HaveLen(2))
+
+		By("completing the rebuild once every package has been rebuilt")
+		finish(projectRebuildName(project, build("api", "api", 0, "", "")), metav1.ConditionFalse)
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, req.NamespacedName, project)).To(Succeed())
+		Expect(project.Status.Rebuild.CompletionTime).NotTo(BeNil())
+		Expect(project.Status.Rebuild.Packages).To(HaveEach(HaveField("Build", Not(BeEmpty()))))
+		rebuiltCondition := meta.FindStatusCondition(project.Status.Conditions, jcrsv1.ConditionRebuilt)
+		Expect(rebuiltCondition).NotTo(BeNil())
+		Expect(rebuiltCondition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(rebuiltCondition.Reason).To(Equal(jcrsv1.ReasonPackagesFailed))
+	})
+
+	It("blocks the packages depending on a package that failed to rebuild", func() {
+		project := &jcrsv1.LeviathanProject{
+			ObjectMeta: metav1.ObjectMeta{Name: "storefront", Namespace: "team"},
+			Spec: jcrsv1.LeviathanProjectSpec{
+				Dependencies: []jcrsv1.PackageDependencies{{Package: "api", DependsOn: []string{"models"}}},
+				Rebuild:      "release-1",
+			},
+			Status: jcrsv1.LeviathanProjectStatus{Rebuild: &jcrsv1.ProjectRebuildStatus{
+				Rebuild: "release-1",
+				Packages: []jcrsv1.ProjectPackageStatus{
+					{Package: "models", Build: "models-rebuilt", State: jcrsv1.PackageRunning},
+					{Package: "api", State: jcrsv1.PackagePending},
+				},
+			}},
+		}
+		c := newFakeClientBuilder().
+			WithObjects(project, build("api", "api", 0, metav1.ConditionTrue, "v1"),
+				build("models", "models", 0, metav1.ConditionTrue, "v1"),
+				build("models-rebuilt", "models", time.Minute, metav1.ConditionFalse, "v2")).Build()
+		r := &LeviathanProjectReconciler{Client: c, Scheme: c.Scheme()}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "storefront"}}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, req.NamespacedName, project)).To(Succeed())
+		Expect(project.Status.Rebuild.Packages).To(ConsistOf(
+			HaveField("State", jcrsv1.PackageFailed),
+			HaveField("State", jcrsv1.PackageBlocked),
+		))
+		Expect(meta.FindStatusCondition(project.Status.Conditions, jcrsv1.ConditionRebuilt)).To(HaveField("Reason", jcrsv1.ReasonPackagesFailed))
+		var builds jcrsv1.LeviathanBuildList
+		Expect(c.List(ctx, &builds)).To(Succeed())
+		Expect(builds.Items).To(HaveLen(3))
+	})
+})
@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The lifecycle hooks of a build run in its build containers: the containers of the
+jobTemplate, or the last step of its build type, before the sidecars are added.
+
+In the Wrapper mode, the command of a build container is run by a shell script
+that runs preBuild first, then the command in the background so that the
+signals sent to the container reach it, and postBuild once it has exited. The
+script is passed as the command of the container, with the original command and
+args as its arguments, so the kubelet still expands the $(VAR_NAME) references of
+both. The controller doesn't know the entrypoint of the image, so containers
+that don't set a command can't be wrapped, and the Job isn't constructed.
+
+In the ContainerLifecycle mode the hooks are the postStart and preStop exec hooks
+of the build containers, which mustn't set them already.
+*/
+
+const (
+	// hooksScriptName is $0 of the script wrapping the command of the build containers
+	hooksScriptName = "leviathan-hooks"
+)
+
+// addLifecycleHooks runs the lifecycle hooks of lvBuild in the build containers of job.
+func addLifecycleHooks(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) error {
+	hooks := lvBuild.Spec.LifecycleHooks
+	if hooks == nil {
+		return nil
+	}
+	containers := job.Spec.Template.Spec.Containers
+	for i := range containers {
+		c := &containers[i]
+		if hooks.Mode == jcrsv1.ContainerLifecycleHooks {
+			if err := setContainerLifecycle(c, hooks); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(c.Command) == 0 {
+			return fmt.Errorf("lifecycleHooks require container %s to set its command", c.Name)
+		}
+		c.Command = append([]string{"/bin/sh", "-c", hooksScript(hooks), hooksScriptName}, append(c.Command, c.Args...)...)
+		c.Args = nil
+	}
+	return nil
+}
+
+// setContainerLifecycle sets hooks as the postStart and preStop hooks of c.
+func setContainerLifecycle(c *corev1.Container, hooks *jcrsv1.LifecycleHooks) error {
+	if c.Lifecycle == nil {
+		c.Lifecycle = &corev1.Lifecycle{}
+	}
+	if hooks.PreBuild != nil {
+		if c.Lifecycle.PostStart != nil {
+			return fmt.Errorf("lifecycleHooks.preBuild can't replace the postStart hook of container %s", c.Name)
+		}
+		c.Lifecycle.PostStart = &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: hooks.PreBuild.Command}}
+	}
+	if hooks.PostBuild != nil {
+		if c.Lifecycle.PreStop != nil {
+			return fmt.Errorf("lifecycleHooks.postBuild can't replace the preStop hook of container %s", c.Name)
+		}
+		c.Lifecycle.PreStop = &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: hooks.PostBuild.Command}}
+	}
+	return nil
+}
+
+// hooksScript returns the shell script running hooks around the command it's given
+// as arguments, and exiting with its exit code.
+func hooksScript(hooks *jcrsv1.LifecycleHooks) string {
+	var b strings.Builder
+	if hooks.PreBuild != nil {
+		fmt.Fprintf(&b, "%s || { status=$?; echo \"preBuild hook failed with exit code $status\" >&2; exit $status; }\n", shellQuote(hooks.PreBuild.Command))
+	}
+	b.WriteString(`"$@" &
+child=$!
+trap 'kill -TERM "$child" 2>/dev/null' TERM INT
+wait "$child"
+status=$?
+while kill -0 "$child" 2>/dev/null; do wait "$child"; status=$?; done
+`)
+	if hooks.PostBuild != nil {
+		fmt.Fprintf(&b, "%s || echo \"postBuild hook failed with exit code $?\" >&2\n", shellQuote(hooks.PostBuild.Command))
+	}
+	b.WriteString("exit $status\n")
+	return b.String()
+}
+
+// shellQuote quotes the words of command for a POSIX shell.
+func shellQuote(command []string) string {
+	words := make([]string, len(command))
+	for i, word := range command {
+		words[i] = "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Lifecycle hooks", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	// run runs the command of c with /bin/sh, and returns its output and exit code
+	run := func(c corev1.Container) (string, int) {
+		out, err := exec.Command(c.Command[0], c.Command[1:]...).CombinedOutput()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return string(out), exitErr.ExitCode()
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return string(out), 0
+	}
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName: ptr.To("web"),
+				LifecycleHooks: &jcrsv1.LifecycleHooks{
+					Mode:      jcrsv1.WrapperHooks,
+					PreBuild:  &jcrsv1.LifecycleHook{Command: []string{"echo", "warming 'cache'"}},
+					PostBuild: &jcrsv1.LifecycleHook{Command: []string{"echo", "flushing coverage"}},
+				},
+			},
+		}
+		job = &batchv1.Job{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "build", Image: "builder", Command: []string{"echo"}, Args: []string{"building $(PACKAGE)"}}},
+		}}}}
+	})
+
+	It("wraps the command of the build containers", func() {
+		Expect(addLifecycleHooks(lvBuild, job)).To(Succeed())
+		build := job.Spec.Template.Spec.Containers[0]
+		Expect(build.Command[:2]).To(Equal([]string{"/bin/sh", "-c"}))
+		Expect(build.Command[3:]).To(Equal([]string{hooksScriptName, "echo", "building $(PACKAGE)"}))
+		Expect(build.Args).To(BeNil())
+
+		out, code := run(build)
+		Expect(code).To(Equal(0))
+		Expect(out).To(Equal("warming 'cache'\nbuilding $(PACKAGE)\nflushing coverage\n"))
+	})
+
+	It("runs postBuild after failed builds and keeps their exit code", func() {
+		job.Spec.Template.Spec.Containers[0].Command = []string{"sh", "-c", "echo failing; exit 3"}
+		job.Spec.Template.Spec.Containers[0].Args = nil
+		lvBuild.Spec.LifecycleHooks.PostBuild.Command = []string{"false"}
+		Expect(addLifecycleHooks(lvBuild, job)).To(Succeed())
+
+		out, code := run(job.Spec.Template.Spec.Containers[0])
+		Expect(code).To(Equal(3))
+		Expect(out).To(Equal("warming 'cache'\nfailing\npostBuild hook failed with exit code 1\n"))
+	})
+
+	It("doesn't run the build when preBuild fails", func() {
+		lvBuild.Spec.LifecycleHooks.PreBuild.Command = []string{"sh", "-c", "exit 4"}
+		Expect(addLifecycleHooks(lvBuild, job)).To(Succeed())
+
+		out, code := run(job.Spec.Template.Spec.Containers[0])
+		Expect(code).To(Equal(4))
+		Expect(out).To(Equal("preBuild hook failed with exit code 4\n"))
+	})
+
+	It("can't wrap containers running the entrypoint of their image", func() {
+		job.Spec.Template.Spec.Containers[0].Command = nil
+		Expect(addLifecycleHooks(lvBuild, job)).To(MatchError(ContainSubstring("container build to set its command")))
+	})
+
+	It("sets the hooks as the container lifecycle hooks", func() {
+		lvBuild.Spec.LifecycleHooks.Mode = jcrsv1.ContainerLifecycleHooks
+		Expect(addLifecycleHooks(lvBuild, job)).To(Succeed())
+		build := job.Spec.Template.Spec.Containers[0]
+		Expect(build.Command).To(Equal([]string{"echo"}))
+		Expect(build.Lifecycle.PostStart.Exec.Command).To(Equal([]string{"echo", "warming 'cache'"}))
+		Expect(build.Lifecycle.PreStop.Exec.Command).To(Equal([]string{"echo", "flushing coverage"}))
+
+		job.Spec.Template.Spec.Containers[0].Lifecycle.PreStop = nil
+		Expect(addLifecycleHooks(lvBuild, job)).To(MatchError(ContainSubstring("can't replace the postStart hook of container build")))
+	})
+})
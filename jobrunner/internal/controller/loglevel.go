@@ -0,0 +1,56 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/logging"
+)
+
+/*
+Debug logging can be turned on for a single build, rather than for the whole
+controller, with the log level annotation. Its reconciles then log at that level
+through the logger of their context, whatever the level of the controller. A level
+lower than the one of the controller doesn't silence anything.
+
+The webhook rejects invalid levels. Builds admitted with one anyway log at the
+level of the controller.
+*/
+
+// LogLevelAnnotation sets the log level of the reconciles of a build: info, debug,
+// trace or a verbosity.
+const LogLevelAnnotation = "jcrs.jcrs.dev/log-level"
+
+// withLogLevel returns ctx and its logger raised to the log level of lvBuild.
+func withLogLevel(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (context.Context, logr.Logger) {
+	log := logf.FromContext(ctx)
+	level, ok := lvBuild.Annotations[LogLevelAnnotation]
+	if !ok {
+		return ctx, log
+	}
+	verbosity, err := logging.ParseLevel(level)
+	if err != nil {
+		return ctx, log
+	}
+	log = logging.WithVerbosity(log, verbosity)
+	return logf.IntoContext(ctx, log), log
+}
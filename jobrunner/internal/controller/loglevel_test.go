@@ -0,0 +1,58 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/logging"
+)
+
+var _ = Describe("Log level", func() {
+	var (
+		ctx     context.Context
+		lvBuild *jcrsv1.LeviathanBuild
+	)
+
+	BeforeEach(func() {
+		verbose := funcr.New(func(string, string) {}, funcr.Options{Verbosity: logging.MaxVerbosity})
+		ctx = logf.IntoContext(context.Background(), logging.New(verbose, 0))
+		lvBuild = &jcrsv1.LeviathanBuild{}
+	})
+
+	It("raises the logger of the reconciles of annotated builds", func() {
+		lvBuild.Annotations = map[string]string{LogLevelAnnotation: "debug"}
+		ctx, log := withLogLevel(ctx, lvBuild)
+
+		Expect(log.V(1).Enabled()).To(BeTrue())
+		Expect(log.V(2).Enabled()).To(BeFalse())
+		Expect(logf.FromContext(ctx).V(1).Enabled()).To(BeTrue())
+	})
+
+	It("ignores invalid log levels", func() {
+		lvBuild.Annotations = map[string]string{LogLevelAnnotation: "verbose"}
+		_, log := withLogLevel(ctx, lvBuild)
+
+		Expect(log.V(1).Enabled()).To(BeFalse())
+	})
+})
@@ -0,0 +1,160 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	"test.jcrs.dev/jobrunner/internal/schedule"
+)
+
+/*
+While a MaintenanceWindow is open, no new Job is started for the builds of the
+namespaces it applies to: not for new builds, not for changed specs and not to
+rerun an interrupted or failed run. Jobs that are already running aren't touched
+and finish on their own. Deferred builds are reconciled again when the window
+closes, or when a MaintenanceWindow changes.
+
+A build annotated with the maintenance override annotation is started anyway, for
+the fix that can't wait.
+*/
+
+const (
+	// maintenanceOverrideAnnotation starts the Jobs of a build even during a MaintenanceWindow when "true"
+	maintenanceOverrideAnnotation = "jcrs.jcrs.dev/maintenance-override"
+
+	// deferredByMaintenanceKey indexes the LeviathanBuilds deferred by a MaintenanceWindow
+	deferredByMaintenanceKey = ".status.conditions.deferredByMaintenanceWindow"
+)
+
+// openMaintenanceWindow is the MaintenanceWindow holding back a build.
+type openMaintenanceWindow struct {
+	name string
+	end  time.Time
+}
+
+// maintenanceWindowAppliesTo reports whether window holds back the builds of namespace.
+func maintenanceWindowAppliesTo(window *jcrsv1.MaintenanceWindow, namespace string) bool {
+	return len(window.Spec.Namespaces) == 0 || slices.Contains(window.Spec.Namespaces, namespace)
+}
+
+// maintenanceWindowEnd reports whether window is open at now, and if so when it closes.
+func maintenanceWindowEnd(window *jcrsv1.MaintenanceWindow, now time.Time) (time.Time, bool, error) {
+	sched, err := schedule.Parse(window.Spec.Schedule)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	location := time.UTC
+	if window.Spec.TimeZone != "" {
+		if location, err = time.LoadLocation(window.Spec.TimeZone); err != nil {
+			return time.Time{}, false, err
+		}
+	}
+	end, open := sched.Active(now.In(location), window.Spec.Duration.Duration)
+	return end, open, nil
+}
+
+// activeMaintenanceWindow returns the open MaintenanceWindow holding back the new
+// Jobs of lvBuild, or nil. When several windows are open, the one closing last is
+// returned. Windows that can't be parsed are logged and ignored.
+func (r *LeviathanBuildReconciler) activeMaintenanceWindow(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, now time.Time) (*openMaintenanceWindow, error) {
+	if !featuregates.Enabled(featuregates.MaintenanceWindows) || lvBuild.Annotations[maintenanceOverrideAnnotation] == "true" {
+		return nil, nil
+	}
+
+	var windows jcrsv1.MaintenanceWindowList
+	if err := r.List(ctx, &windows); err != nil {
+		return nil, err
+	}
+	var active *openMaintenanceWindow
+	for i := range windows.Items {
+		window := &windows.Items[i]
+		if !maintenanceWindowAppliesTo(window, lvBuild.Namespace) {
+			continue
+		}
+		end, open, err := maintenanceWindowEnd(window, now)
+		if err != nil {
+			logf.FromContext(ctx).Error(err, "Ignoring invalid MaintenanceWindow", "MaintenanceWindow", window.Name)
+			continue
+		}
+		if open && (active == nil || end.After(active.end)) {
+			active = &openMaintenanceWindow{name: window.Name, end: end}
+		}
+	}
+	return active, nil
+}
+
+// setDeferredByMaintenanceWindow records whether lvBuild is held back by window,
+// and the changes to the Job that is kept running, if any.
+func setDeferredByMaintenanceWindow(lvBuild *jcrsv1.LeviathanBuild, window *openMaintenanceWindow, changes []string) {
+	if window == nil {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionDeferredByMaintenanceWindow)
+		deferredByMaintenanceWindow.DeleteLabelValues(lvBuild.Namespace, lvBuild.Name)
+		return
+	}
+	message := "MaintenanceWindow " + window.name + " is open until " + window.end.UTC().Format(time.RFC3339) +
+		`, annotate the build with ` + maintenanceOverrideAnnotation + `: "true" to start it anyway`
+	if len(changes) > 0 {
+		message += ". The running Job is out of date, changed: " + summarizeChanges(changes)
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionDeferredByMaintenanceWindow,
+		Status:             metav1.ConditionTrue,
+		Reason:             jcrsv1.ReasonMaintenanceWindowOpen,
+		Message:            message,
+		ObservedGeneration: lvBuild.Generation,
+	})
+	deferredByMaintenanceWindow.WithLabelValues(lvBuild.Namespace, lvBuild.Name).Set(1)
+}
+
+// indexDeferredByMaintenance is the index function for deferredByMaintenanceKey.
+func indexDeferredByMaintenance(rawObj client.Object) []string {
+	lvBuild := rawObj.(*jcrsv1.LeviathanBuild)
+	if !meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionDeferredByMaintenanceWindow) {
+		return nil
+	}
+	return []string{"true"}
+}
+
+// buildsForMaintenanceWindow maps a changed MaintenanceWindow to the deferred
+// builds, which may be started now that the window changed.
+func (r *LeviathanBuildReconciler) buildsForMaintenanceWindow(ctx context.Context, _ client.Object) []reconcile.Request {
+	var builds jcrsv1.LeviathanBuildList
+	if err := r.List(ctx, &builds, client.MatchingFields{deferredByMaintenanceKey: "true"}); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list deferred LeviathanBuilds")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(builds.Items))
+	for _, lvBuild := range builds.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: lvBuild.Namespace,
+			Name:      lvBuild.Name,
+		}})
+	}
+	return requests
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Maintenance windows", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		r       *LeviathanBuildReconciler
+		// 2025-06-02 is a Monday
+		monday = time.Date(2025, time.June, 2, 21, 30, 0, 0, time.UTC)
+	)
+
+	newWindow := func(name, schedule string, duration time.Duration, namespaces ...string) *jcrsv1.MaintenanceWindow {
+		return &jcrsv1.MaintenanceWindow{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: jcrsv1.MaintenanceWindowSpec{
+				Schedule:   schedule,
+				Duration:   metav1.Duration{Duration: duration},
+				Namespaces: namespaces,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.MaintenanceWindows: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.MaintenanceWindows: false})).To(Succeed())
+		})
+
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "default").Obj()
+		c := newFakeClient(
+			newWindow("nightly", "0 21 * * mon-fri", 2*time.Hour),
+			newWindow("freeze", "0 20 * * mon", 4*time.Hour, "default"),
+			newWindow("other-team", "0 0 * * *", 24*time.Hour, "other"),
+			newWindow("broken", "0 25 * * *", time.Hour),
+		)
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+	})
+
+	It("opens windows in their time zone", func() {
+		window := newWindow("paris", "0 22 * * *", time.Hour)
+		window.Spec.TimeZone = "Europe/Paris"
+		// 22:00 in Paris is 20:00 UTC in June
+		end, open, err := maintenanceWindowEnd(window, time.Date(2025, time.June, 2, 20, 30, 0, 0, time.UTC))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeTrue())
+		Expect(end.UTC()).To(Equal(time.Date(2025, time.June, 2, 21, 0, 0, 0, time.UTC)))
+
+		window.Spec.TimeZone = "Mars/Olympus_Mons"
+		_, _, err = maintenanceWindowEnd(window, monday)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("finds the open window of the namespace closing last", func() {
+		window, err := r.activeMaintenanceWindow(context.Background(), lvBuild, monday)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(window).To(Equal(&openMaintenanceWindow{name: "freeze", end: time.Date(2025, time.June, 3, 0, 0, 0, 0, time.UTC)}))
+
+		lvBuild.Namespace = "staging"
+		window, err = r.activeMaintenanceWindow(context.Background(), lvBuild, monday)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(window.name).To(Equal("nightly"))
+
+		By("starting builds outside the windows")
+		window, err = r.activeMaintenanceWindow(context.Background(), lvBuild, monday.Add(-2*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(window).To(BeNil())
+	})
+
+	It("starts builds with the override annotation or without the feature", func() {
+		lvBuild.Annotations = map[string]string{maintenanceOverrideAnnotation: "true"}
+		Expect(r.activeMaintenanceWindow(context.Background(), lvBuild, monday)).To(BeNil())
+
+		lvBuild.Annotations = nil
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.MaintenanceWindows: false})).To(Succeed())
+		Expect(r.activeMaintenanceWindow(context.Background(), lvBuild, monday)).To(BeNil())
+	})
+
+	It("reports deferred builds", func() {
+		setDeferredByMaintenanceWindow(lvBuild, &openMaintenanceWindow{name: "nightly", end: monday}, nil)
+		Expect(meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionDeferredByMaintenanceWindow)).To(BeTrue())
+		Expect(testutil.ToFloat64(deferredByMaintenanceWindow.WithLabelValues("default", "web"))).To(Equal(1.0))
+		Expect(indexDeferredByMaintenance(lvBuild)).To(Equal([]string{"true"}))
+
+		By("naming the changes the running Job is missing")
+		setDeferredByMaintenanceWindow(lvBuild, &openMaintenanceWindow{name: "nightly", end: monday}, []string{"template.spec.containers[build].image"})
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionDeferredByMaintenanceWindow).Message).To(
+			HaveSuffix("The running Job is out of date, changed: template.spec.containers[build].image"))
+
+		setDeferredByMaintenanceWindow(lvBuild, nil, nil)
+		Expect(lvBuild.Status.Conditions).To(BeEmpty())
+		Expect(testutil.CollectAndCount(deferredByMaintenanceWindow)).To(BeZero())
+		Expect(indexDeferredByMaintenance(lvBuild)).To(BeEmpty())
+	})
+})
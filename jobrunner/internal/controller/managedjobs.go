@@ -0,0 +1,189 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+/*
+A cluster can hold far more Jobs than the controller manages, and the pod template
+is most of the size of a Job. With ManagedJobCache, the cache of the controller only
+holds the Jobs labeled as managed by it, and drops the pod template of the Jobs
+that record its hash. A few fields read from the template of existing Jobs, such as
+their ServiceAccount, are kept.
+
+The pod template of a Job is immutable, so the hash recorded when the Job is
+created tells whether the Job still matches its build. Only when the hashes differ
+is the Job read in full from the API server, to tell what changed.
+
+Jobs created before their label was introduced are labeled by LabelManagedJobs at
+startup, listing them a page at a time so the controller never holds them all.
+*/
+
+const (
+	// ManagedLabel marks the Jobs created by the controller
+	ManagedLabel = "jcrs.jcrs.dev/managed"
+
+	// podTemplateHashAnnotation records the hash of the pod template of a Job when it was created
+	podTemplateHashAnnotation = "jcrs.jcrs.dev/pod-template-hash"
+
+	// listPageSize is the number of objects listed at once from the API server
+	listPageSize = 500
+)
+
+// ManagedJobCache returns how Jobs are cached: only those managed by the
+// controller, without their pod template.
+func ManagedJobCache() cache.ByObject {
+	selector := labels.SelectorFromSet(labels.Set{ManagedLabel: "true"})
+	return cache.ByObject{Label: selector, Transform: StripJobTemplate}
+}
+
+// StripJobTemplate drops the managed fields of a Job, and its pod template when
+// the Job records its hash.
+func StripJobTemplate(obj any) (any, error) {
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return obj, nil
+	}
+	job.ManagedFields = nil
+	if _, ok := job.Annotations[podTemplateHashAnnotation]; !ok {
+		return job, nil
+	}
+	podSpec := job.Spec.Template.Spec
+	job.Spec.Template.Spec = corev1.PodSpec{
+		ServiceAccountName: podSpec.ServiceAccountName,
+		RestartPolicy:      podSpec.RestartPolicy,
+	}
+	return job, nil
+}
+
+// templateStripped reports whether the pod template of job was dropped from the cache.
+func templateStripped(job *batchv1.Job) bool {
+	_, ok := job.Annotations[podTemplateHashAnnotation]
+	return ok && len(job.Spec.Template.Spec.Containers) == 0
+}
+
+// podTemplateHash returns the hash of the pod template of job.
+func podTemplateHash(job *batchv1.Job) (string, error) {
+	data, err := json.Marshal(job.Spec.Template)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// setManaged labels job as managed by the controller and records the hash of its
+// pod template. It is called once the Job is final, right before its creation.
+func setManaged(job *batchv1.Job) error {
+	hash, err := podTemplateHash(job)
+	if err != nil {
+		return err
+	}
+	if job.Labels == nil {
+		job.Labels = make(map[string]string)
+	}
+	if job.Annotations == nil {
+		job.Annotations = make(map[string]string)
+	}
+	job.Labels[ManagedLabel] = "true"
+	job.Annotations[podTemplateHashAnnotation] = hash
+	return nil
+}
+
+// jobChanges returns the paths of the fields that differ between the spec of the
+// existing Job and the desired one, like diffJobSpecs. Jobs whose pod template
+// isn't cached are read from the API server when their template changed.
+func (r *LeviathanBuildReconciler) jobChanges(ctx context.Context, existing, desired *batchv1.Job) ([]string, error) {
+	if !templateStripped(existing) {
+		return diffJobSpecs(&existing.Spec, &desired.Spec), nil
+	}
+	hash, err := podTemplateHash(desired)
+	if err != nil {
+		return nil, err
+	}
+	if hash == existing.Annotations[podTemplateHashAnnotation] {
+		spec := desired.Spec.DeepCopy()
+		spec.Template = existing.Spec.Template
+		return diffJobSpecs(&existing.Spec, spec), nil
+	}
+
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	full := &batchv1.Job{}
+	if err := reader.Get(ctx, client.ObjectKeyFromObject(existing), full); err != nil {
+		return nil, err
+	}
+	return diffJobSpecs(&full.Spec, &desired.Spec), nil
+}
+
+// LabelManagedJobs labels the Jobs of builds created before ManagedLabel as
+// managed, so that a cache of the managed Jobs holds them. It returns the number
+// of Jobs labeled.
+func LabelManagedJobs(ctx context.Context, c client.Client) (int, error) {
+	log := logf.FromContext(ctx)
+	unmanaged, err := labels.NewRequirement(ManagedLabel, selection.DoesNotExist, nil)
+	if err != nil {
+		return 0, err
+	}
+	labeled := 0
+	for _, key := range []string{runIndexLabel, signingLabel} {
+		owned, err := labels.NewRequirement(key, selection.Exists, nil)
+		if err != nil {
+			return labeled, err
+		}
+		selector := labels.NewSelector().Add(*owned, *unmanaged)
+
+		var jobs batchv1.JobList
+		for {
+			if err := c.List(ctx, &jobs, client.MatchingLabelsSelector{Selector: selector},
+				client.Limit(listPageSize), client.Continue(jobs.Continue)); err != nil {
+				return labeled, err
+			}
+			for i := range jobs.Items {
+				job := &jobs.Items[i]
+				patch := client.MergeFrom(job.DeepCopy())
+				job.Labels[ManagedLabel] = "true"
+				if err := c.Patch(ctx, job, patch); client.IgnoreNotFound(err) != nil {
+					return labeled, err
+				}
+				labeled++
+			}
+			if jobs.Continue == "" {
+				break
+			}
+		}
+	}
+	if labeled > 0 {
+		log.Info("Labeled Jobs created before the managed label", "count", labeled)
+	}
+	return labeled, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Managed Job cache", func() {
+	var (
+		ctx context.Context
+		job *batchv1.Job
+	)
+
+	cached := func(job *batchv1.Job) *batchv1.Job {
+		obj, err := StripJobTemplate(job.DeepCopy())
+		Expect(err).NotTo(HaveOccurred())
+		return obj.(*batchv1.Job)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1-abcde", Namespace: "default"}}
+		job.Spec.Parallelism = ptr.To[int32](1)
+		job.Spec.Template.Spec = corev1.PodSpec{
+			ServiceAccountName: "builder",
+			RestartPolicy:      corev1.RestartPolicyNever,
+			Containers:         []corev1.Container{{Name: "build", Image: "builder:1"}},
+		}
+	})
+
+	It("drops the pod template of the Jobs recording its hash", func() {
+		Expect(cached(job).Spec.Template.Spec.Containers).To(HaveLen(1))
+
+		Expect(setManaged(job)).To(Succeed())
+		Expect(job.Labels).To(HaveKeyWithValue(ManagedLabel, "true"))
+		stripped := cached(job)
+		Expect(stripped.Spec.Template.Spec).To(Equal(corev1.PodSpec{ServiceAccountName: "builder", RestartPolicy: corev1.RestartPolicyNever}))
+		Expect(stripped.Spec.Parallelism).To(Equal(ptr.To[int32](1)))
+		Expect(templateStripped(stripped)).To(BeTrue())
+	})
+
+	It("compares Jobs whose pod template isn't cached by its hash", func() {
+		Expect(setManaged(job)).To(Succeed())
+		reader := &countingReader{Reader: newFakeClient(job.DeepCopy())}
+		r := &LeviathanBuildReconciler{APIReader: reader}
+		existing := cached(job)
+
+		desired := job.DeepCopy()
+		Expect(r.jobChanges(ctx, existing, desired)).To(BeEmpty())
+
+		desired.Spec.Parallelism = ptr.To[int32](2)
+		Expect(r.jobChanges(ctx, existing, desired)).To(Equal([]string{"parallelism"}))
+		Expect(reader.gets).To(BeZero())
+
+		desired = job.DeepCopy()
+		desired.Spec.Template.Spec.Containers[0].Image = "builder:2"
+		Expect(r.jobChanges(ctx, existing, desired)).To(Equal([]string{"template.spec.containers[build].image"}))
+		Expect(reader.gets).To(Equal(1))
+	})
+
+	It("labels the Jobs of builds created before the managed label", func() {
+		unlabeled := func(name string, labels map[string]string) *batchv1.Job {
+			return &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+		}
+		c := newFakeClientBuilder().WithObjects(
+			unlabeled("web-1-abcde", map[string]string{runIndexLabel: "1"}),
+			unlabeled("web-1-sign", map[string]string{signingLabel: "web"}),
+			unlabeled("web-2-abcde", map[string]string{runIndexLabel: "2", ManagedLabel: "true"}),
+			unlabeled("other", map[string]string{"app": "other"}),
+		).Build()
+
+		Expect(LabelManagedJobs(ctx, c)).To(Equal(2))
+		var jobs batchv1.JobList
+		Expect(c.List(ctx, &jobs, client.MatchingLabels{ManagedLabel: "true"})).To(Succeed())
+		Expect(jobs.Items).To(HaveLen(3))
+		Expect(LabelManagedJobs(ctx, c)).To(BeZero())
+	})
+})
+
+// countingReader counts the objects read with Get.
+type countingReader struct {
+	client.Reader
+	gets int
+}
+
+func (r *countingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	r.gets++
+	return r.Reader.Get(ctx, key, obj, opts...)
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+The workqueue and reconcile totals of controller-runtime are served on the same
+endpoint, but they're per controller. The metrics below break the reconcile
+latency down by package, so a handful of slow builds stand out at scale, and report
+how many objects the informer cache holds.
+*/
+
+var (
+	reconcileBackoffSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "leviathanbuild_reconcile_backoff_seconds",
+			Help: "Current requeue delay of a LeviathanBuild whose reconciles are failing",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	reconcileDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "leviathanbuild_reconcile_duration_seconds",
+			Help:    "Duration of LeviathanBuild reconciles per package",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"package"},
+	)
+
+	buildCostTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "leviathanbuild_estimated_cost_total",
+			Help: "Estimated cost of the finished runs of LeviathanBuilds per namespace",
+		},
+		[]string{"namespace", "currency"},
+	)
+
+	deferredByMaintenanceWindow = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "leviathanbuild_deferred_by_maintenance_window",
+			Help: "Whether a LeviathanBuild waits for a MaintenanceWindow to close before starting a Job",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	buildsExpiredTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "leviathanbuild_expired_total",
+			Help: "LeviathanBuilds per namespace that didn't start within their expiresAfter",
+		},
+		[]string{"namespace"},
+	)
+
+	capacityWaitSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "leviathanbuild_capacity_wait_seconds",
+			Help:    "Time LeviathanBuilds per namespace waited for the nodes to recover capacity before running again",
+			Buckets: prometheus.ExponentialBuckets(30, 2, 10),
+		},
+		[]string{"namespace"},
+	)
+
+	statusWritesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "leviathanbuild_status_writes_total",
+			Help: "Status writes of LeviathanBuilds by result: written, skipped as unchanged, or conflict",
+		},
+		[]string{"result"},
+	)
+
+	namespaceBuilds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "leviathanbuild_namespace_builds",
+			Help: "Number of LeviathanBuilds per namespace and phase of their latest run",
+		},
+		[]string{"namespace", "phase"},
+	)
+
+	namespaceFailed24h = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "leviathanbuild_namespace_failed_24h",
+			Help: "Number of LeviathanBuilds per namespace whose latest run failed in the last 24 hours",
+		},
+		[]string{"namespace"},
+	)
+
+	namespaceAverageDurationSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "leviathanbuild_namespace_average_duration_seconds",
+			Help: "Average duration of the latest finished run of the LeviathanBuilds per namespace",
+		},
+		[]string{"namespace"},
+	)
+
+	cacheObjectsDesc = prometheus.NewDesc(
+		"leviathanbuild_cache_objects",
+		"Number of objects of each kind held in the informer cache of the controller",
+		[]string{"kind"}, nil,
+	)
+)
+
+func init() {
+	// Register custom metrics with the global prometheus registry
+	metrics.Registry.MustRegister(reconcileBackoffSeconds, reconcileDurationSeconds, buildCostTotal, deferredByMaintenanceWindow, buildsExpiredTotal, statusWritesTotal,
+		capacityWaitSeconds, namespaceBuilds, namespaceFailed24h, namespaceAverageDurationSeconds)
+}
+
+// observeReconcile records the duration of a reconcile of lvBuild, and logs it
+// when it is slower than the SlowReconcileThreshold.
+func (r *LeviathanBuildReconciler) observeReconcile(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, duration time.Duration) {
+	packageName := ""
+	if lvBuild.Spec.PackageName != nil {
+		packageName = *lvBuild.Spec.PackageName
+	}
+	reconcileDurationSeconds.WithLabelValues(packageName).Observe(duration.Seconds())
+
+	if r.SlowReconcileThreshold > 0 && duration > r.SlowReconcileThreshold {
+		logf.FromContext(ctx).Info("Slow reconcile", "package", packageName, "duration", duration, "threshold", r.SlowReconcileThreshold)
+	}
+}
+
+// recordSummaryMetrics exports the summary of the builds of namespace.
+func recordSummaryMetrics(namespace string, status jcrsv1.LeviathanBuildSummaryStatus) {
+	namespaceBuilds.WithLabelValues(namespace, phasePending).Set(float64(status.Pending))
+	namespaceBuilds.WithLabelValues(namespace, phaseRunning).Set(float64(status.Running))
+	namespaceBuilds.WithLabelValues(namespace, phaseSucceeded).Set(float64(status.Succeeded))
+	namespaceBuilds.WithLabelValues(namespace, phaseFailed).Set(float64(status.Failed))
+	namespaceFailed24h.WithLabelValues(namespace).Set(float64(status.Failed24h))
+	if status.AverageDuration != nil {
+		namespaceAverageDurationSeconds.WithLabelValues(namespace).Set(status.AverageDuration.Seconds())
+	} else {
+		namespaceAverageDurationSeconds.DeleteLabelValues(namespace)
+	}
+}
+
+// deleteSummaryMetrics deletes the summary series of a namespace without builds.
+func deleteSummaryMetrics(namespace string) {
+	namespaceBuilds.DeletePartialMatch(prometheus.Labels{"namespace": namespace})
+	namespaceFailed24h.DeleteLabelValues(namespace)
+	namespaceAverageDurationSeconds.DeleteLabelValues(namespace)
+}
+
+// cacheCollector reports the number of cached objects when metrics are scraped,
+// rather than keeping gauges in sync with the informers.
+type cacheCollector struct {
+	reader client.Reader
+}
+
+// cacheCollectorTimeout bounds the time a scrape waits for the cache
+const cacheCollectorTimeout = 5 * time.Second
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheObjectsDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheCollectorTimeout)
+	defer cancel()
+
+	// Only the kinds the controller watches are listed, listing any other kind
+	// would start an informer for it. ConfigMaps are only watched by their metadata.
+	configMaps := &metav1.PartialObjectMetadataList{}
+	configMaps.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMapList"))
+	lists := map[string]client.ObjectList{
+		"LeviathanBuild": &jcrsv1.LeviathanBuildList{},
+		"Job":            &batchv1.JobList{},
+		"ConfigMap":      configMaps,
+	}
+	if featuregates.Enabled(featuregates.BuilderImageMappings) {
+		lists["BuilderImageMapping"] = &jcrsv1.BuilderImageMappingList{}
+	}
+	if featuregates.Enabled(featuregates.MaintenanceWindows) {
+		lists["MaintenanceWindow"] = &jcrsv1.MaintenanceWindowList{}
+	}
+	if featuregates.Enabled(featuregates.PackageOwnership) {
+		lists["PackageOwnership"] = &jcrsv1.PackageOwnershipList{}
+	}
+	if featuregates.Enabled(featuregates.BuildDefaults) {
+		lists["LeviathanBuildDefaults"] = &jcrsv1.LeviathanBuildDefaultsList{}
+	}
+	if featuregates.Enabled(featuregates.CredentialGrants) {
+		lists["CredentialGrant"] = &jcrsv1.CredentialGrantList{}
+	}
+	if featuregates.Enabled(featuregates.BatchOperations) {
+		lists["LeviathanBuildBatchOperation"] = &jcrsv1.LeviathanBuildBatchOperationList{}
+	}
+	if featuregates.Enabled(featuregates.ClusterBuilds) {
+		lists["LeviathanClusterBuild"] = &jcrsv1.LeviathanClusterBuildList{}
+	}
+	if featuregates.Enabled(featuregates.BuildSummaries) {
+		lists["LeviathanBuildSummary"] = &jcrsv1.LeviathanBuildSummaryList{}
+	}
+	if featuregates.Enabled(featuregates.BuildTypeDefinitions) {
+		lists["BuildTypeDefinition"] = &jcrsv1.BuildTypeDefinitionList{}
+	}
+	if featuregates.Enabled(featuregates.RemoteClusters) {
+		lists["ClusterTarget"] = &jcrsv1.ClusterTargetList{}
+	}
+	if featuregates.Enabled(featuregates.CapacityWaits) {
+		lists["Node"] = &corev1.NodeList{}
+	}
+	for kind, list := range lists {
+		if err := c.reader.List(ctx, list); err != nil {
+			ch <- prometheus.NewInvalidMetric(cacheObjectsDesc, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(cacheObjectsDesc, prometheus.GaugeValue, float64(meta.LenList(list)), kind)
+	}
+}
+
+// registerCacheCollector registers the cache metrics of the objects read through reader.
+func registerCacheCollector(reader client.Reader) error {
+	err := metrics.Registry.Register(&cacheCollector{reader: reader})
+	if _, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		return nil
+	}
+	return err
+}
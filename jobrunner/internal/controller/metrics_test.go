@@ -0,0 +1,62 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Metrics", func() {
+	It("records reconcile durations per package", func() {
+		lvBuild := &jcrsv1.LeviathanBuild{Spec: jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("metrics-web")}}
+		before := testutil.CollectAndCount(reconcileDurationSeconds)
+
+		r := &LeviathanBuildReconciler{SlowReconcileThreshold: time.Second}
+		r.observeReconcile(context.Background(), lvBuild, 2*time.Second)
+		Expect(testutil.CollectAndCount(reconcileDurationSeconds)).To(Equal(before + 1))
+	})
+
+	It("counts the objects in the cache", func() {
+		c := newFakeClient(
+			&jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+			&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1-abcde", Namespace: "default"}},
+			&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-2-abcde", Namespace: "default"}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web-script", Namespace: "default"}},
+		)
+
+		Expect(testutil.CollectAndCompare(&cacheCollector{reader: c}, strings.NewReader(`
+# HELP leviathanbuild_cache_objects Number of objects of each kind held in the informer cache of the controller
+# TYPE leviathanbuild_cache_objects gauge
+leviathanbuild_cache_objects{kind="BuilderImageMapping"} 0
+leviathanbuild_cache_objects{kind="ConfigMap"} 1
+leviathanbuild_cache_objects{kind="Job"} 2
+leviathanbuild_cache_objects{kind="LeviathanBuild"} 1
+`))).To(Succeed())
+	})
+})
@@ -0,0 +1,242 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Builds sharing a `mutexKey` are serialized through a coordination.k8s.io Lease in
+their namespace. The Lease's holderIdentity is the name of the LeviathanBuild
+currently running, and an annotation keeps the names of the builds waiting for it
+in arrival order, so that the lock is handed out first come, first served.
+
+The holder renews the Lease while its Job runs and releases it once the Job has
+finished. A Lease is considered stuck, and handed to the next waiter, when it
+hasn't been renewed within its duration or when its holder no longer exists or no
+longer uses the key.
+*/
+
+const (
+	mutexLeasePrefix = "leviathan-mutex-"
+	// mutexKeyAnnotation records the (unhashed) mutexKey on the Lease
+	mutexKeyAnnotation = "jcrs.jcrs.dev/mutex-key"
+	// mutexQueueAnnotation holds the JSON encoded list of waiting builds
+	mutexQueueAnnotation = "jcrs.jcrs.dev/mutex-queue"
+
+	mutexLeaseDuration = 5 * time.Minute
+	// mutexRenewInterval is how often the holder renews the Lease
+	mutexRenewInterval = mutexLeaseDuration / 3
+	// mutexPollInterval is how often waiting builds check the Lease again
+	mutexPollInterval = 15 * time.Second
+)
+
+// mutexLeaseName returns the name of the Lease guarding the given key.
+func mutexLeaseName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return mutexLeasePrefix + hex.EncodeToString(sum[:8])
+}
+
+func mutexQueue(lease *coordinationv1.Lease) []string {
+	var queue []string
+	if raw := lease.Annotations[mutexQueueAnnotation]; raw != "" {
+		_ = json.Unmarshal([]byte(raw), &queue)
+	}
+	return queue
+}
+
+func setMutexQueue(lease *coordinationv1.Lease, queue []string) {
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string)
+	}
+	raw, _ := json.Marshal(queue)
+	lease.Annotations[mutexQueueAnnotation] = string(raw)
+}
+
+// mutexClaimActive reports whether the named LeviathanBuild still has a valid claim
+// on key: it exists and still uses that mutexKey. Waiters must in addition still
+// be waiting for the Lease.
+func (r *LeviathanBuildReconciler) mutexClaimActive(ctx context.Context, namespace, name, key string, waiter bool) (bool, error) {
+	var other jcrsv1.LeviathanBuild
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &other); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if other.Spec.MutexKey == nil || *other.Spec.MutexKey != key {
+		return false, nil
+	}
+	return !waiter || meta.IsStatusConditionTrue(other.Status.Conditions, jcrsv1.ConditionWaitingForMutex), nil
+}
+
+// acquireMutex tries to acquire (or renew) the Lease guarding the mutexKey of
+// lvBuild. When the Lease is held by another build, lvBuild is added to the queue
+// of waiters. It always returns true for builds without a mutexKey.
+func (r *LeviathanBuildReconciler) acquireMutex(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (bool, error) {
+	if lvBuild.Spec.MutexKey == nil {
+		return true, nil
+	}
+	key := *lvBuild.Spec.MutexKey
+	now := metav1.NewMicroTime(time.Now())
+
+	lease := &coordinationv1.Lease{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: lvBuild.Namespace, Name: mutexLeaseName(key)}, lease)
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        mutexLeaseName(key),
+				Namespace:   lvBuild.Namespace,
+				Annotations: map[string]string{mutexKeyAnnotation: key},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.To(lvBuild.Name),
+				LeaseDurationSeconds: ptr.To(int32(mutexLeaseDuration.Seconds())),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		return true, r.Create(ctx, lease)
+	} else if err != nil {
+		return false, err
+	}
+
+	holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+	queue := mutexQueue(lease)
+
+	if holder == lvBuild.Name {
+		lease.Spec.RenewTime = &now
+		return true, r.Update(ctx, lease)
+	}
+
+	free := holder == ""
+	if !free {
+		expired := lease.Spec.RenewTime == nil ||
+			lease.Spec.RenewTime.Add(time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, 0))*time.Second).Before(now.Time)
+		active, err := r.mutexClaimActive(ctx, lvBuild.Namespace, holder, key, false)
+		if err != nil {
+			return false, err
+		}
+		free = expired || !active
+	}
+
+	if free {
+		// Skip over waiters that have gone away, then only the head of the queue may take the Lease
+		for len(queue) > 0 && queue[0] != lvBuild.Name {
+			active, err := r.mutexClaimActive(ctx, lvBuild.Namespace, queue[0], key, true)
+			if err != nil {
+				return false, err
+			}
+			if active {
+				break
+			}
+			queue = queue[1:]
+		}
+		if len(queue) == 0 || queue[0] == lvBuild.Name {
+			queue = slices.DeleteFunc(queue, func(name string) bool { return name == lvBuild.Name })
+			setMutexQueue(lease, queue)
+			lease.Spec.HolderIdentity = ptr.To(lvBuild.Name)
+			lease.Spec.LeaseDurationSeconds = ptr.To(int32(mutexLeaseDuration.Seconds()))
+			lease.Spec.AcquireTime = &now
+			lease.Spec.RenewTime = &now
+			return true, r.Update(ctx, lease)
+		}
+	}
+
+	if !slices.Contains(queue, lvBuild.Name) {
+		setMutexQueue(lease, append(queue, lvBuild.Name))
+		return false, r.Update(ctx, lease)
+	}
+	return false, nil
+}
+
+// renewMutex renews the Lease guarding the mutexKey of lvBuild if lvBuild holds it.
+// It reports whether lvBuild holds the Lease.
+func (r *LeviathanBuildReconciler) renewMutex(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (bool, error) {
+	if lvBuild.Spec.MutexKey == nil {
+		return false, nil
+	}
+	lease := &coordinationv1.Lease{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: lvBuild.Namespace, Name: mutexLeaseName(*lvBuild.Spec.MutexKey)}, lease); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != lvBuild.Name {
+		return false, nil
+	}
+	lease.Spec.RenewTime = ptr.To(metav1.NewMicroTime(time.Now()))
+	return true, r.Update(ctx, lease)
+}
+
+// releaseMutex releases the Lease guarding the mutexKey of lvBuild if lvBuild holds
+// it, and removes lvBuild from the queue of waiters.
+func (r *LeviathanBuildReconciler) releaseMutex(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) error {
+	if lvBuild.Spec.MutexKey == nil {
+		return nil
+	}
+	lease := &coordinationv1.Lease{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: lvBuild.Namespace, Name: mutexLeaseName(*lvBuild.Spec.MutexKey)}, lease); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	queue := mutexQueue(lease)
+	holder := ptr.Deref(lease.Spec.HolderIdentity, "") == lvBuild.Name
+	if !holder && !slices.Contains(queue, lvBuild.Name) {
+		return nil
+	}
+	setMutexQueue(lease, slices.DeleteFunc(queue, func(name string) bool { return name == lvBuild.Name }))
+	if holder {
+		lease.Spec.HolderIdentity = nil
+		lease.Spec.AcquireTime = nil
+		lease.Spec.RenewTime = nil
+	}
+	return r.Update(ctx, lease)
+}
+
+// setWaitingForMutex records whether lvBuild is waiting for its mutexKey.
+func setWaitingForMutex(lvBuild *jcrsv1.LeviathanBuild, waiting bool) {
+	if lvBuild.Spec.MutexKey == nil {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForMutex)
+		return
+	}
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionWaitingForMutex,
+		Status:             metav1.ConditionFalse,
+		Reason:             jcrsv1.ReasonAcquired,
+		Message:            "The lock for mutexKey " + *lvBuild.Spec.MutexKey + " is held by this build",
+		ObservedGeneration: lvBuild.Generation,
+	}
+	if waiting {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = jcrsv1.ReasonWaiting
+		condition.Message = "Waiting for another build holding mutexKey " + *lvBuild.Spec.MutexKey
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, condition)
+}
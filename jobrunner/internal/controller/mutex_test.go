@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Mutex Leases", func() {
+	var (
+		ctx context.Context
+		r   *LeviathanBuildReconciler
+	)
+
+	newBuild := func(name string) *jcrsv1.LeviathanBuild {
+		return &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName: ptr.To(name),
+				MutexKey:    ptr.To("publisher"),
+			},
+		}
+	}
+
+	// wait records that the build is waiting, like the reconciler does after a failed acquire
+	wait := func(lvBuild *jcrsv1.LeviathanBuild) {
+		setWaitingForMutex(lvBuild, true)
+		Expect(r.Status().Update(ctx, lvBuild)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var objs []client.Object
+		for _, name := range []string{"a", "b", "c"} {
+			objs = append(objs, newBuild(name))
+		}
+		c := newFakeClient(objs...)
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+	})
+
+	get := func(name string) *jcrsv1.LeviathanBuild {
+		lvBuild := &jcrsv1.LeviathanBuild{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, lvBuild)).To(Succeed())
+		return lvBuild
+	}
+
+	It("hands the Lease out in arrival order", func() {
+		a, b, c := get("a"), get("b"), get("c")
+
+		Expect(r.acquireMutex(ctx, a)).To(BeTrue())
+		Expect(r.acquireMutex(ctx, c)).To(BeFalse())
+		wait(c)
+		Expect(r.acquireMutex(ctx, b)).To(BeFalse())
+		wait(b)
+
+		Expect(r.releaseMutex(ctx, a)).To(Succeed())
+		Expect(r.acquireMutex(ctx, get("b"))).To(BeFalse())
+		Expect(r.acquireMutex(ctx, get("c"))).To(BeTrue())
+	})
+
+	It("recovers a Lease whose holder is gone", func() {
+		a, b := get("a"), get("b")
+		Expect(r.acquireMutex(ctx, a)).To(BeTrue())
+		Expect(r.acquireMutex(ctx, b)).To(BeFalse())
+		wait(b)
+
+		Expect(r.Delete(ctx, a)).To(Succeed())
+		Expect(r.acquireMutex(ctx, get("b"))).To(BeTrue())
+
+		lease := &coordinationv1.Lease{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "default", Name: mutexLeaseName("publisher")}, lease)).To(Succeed())
+		Expect(lease.Spec.HolderIdentity).To(Equal(ptr.To("b")))
+		Expect(mutexQueue(lease)).To(BeEmpty())
+	})
+	It("gives the Lease back when a run fails to start", func() {
+		a, b := get("a"), get("b")
+		Expect(r.acquireMutex(ctx, a)).To(BeTrue())
+		Expect(r.acquireMutex(ctx, b)).To(BeFalse())
+		wait(b)
+
+		r.abandonRun(ctx, a)
+		Expect(r.acquireMutex(ctx, get("b"))).To(BeTrue())
+	})
+})
@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// NamespaceCache returns the cache options of Namespaces. Namespaces are cached
+// cluster-wide, so the fields no reconciler reads are dropped from the cache.
+func NamespaceCache() cache.ByObject {
+	return cache.ByObject{Transform: StripNamespace}
+}
+
+// StripNamespace drops the managed fields of a Namespace, and the last applied
+// configuration recorded by kubectl.
+func StripNamespace(obj any) (any, error) {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return obj, nil
+	}
+	ns.ManagedFields = nil
+	delete(ns.Annotations, corev1.LastAppliedConfigAnnotation)
+	return ns, nil
+}
+
+// namespaceTerminating reports whether the given namespace is being deleted.
+func (r *LeviathanBuildReconciler) namespaceTerminating(ctx context.Context, namespace string) (bool, error) {
+	var ns corev1.Namespace
+	if err := r.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return ns.DeletionTimestamp != nil || ns.Status.Phase == corev1.NamespaceTerminating, nil
+}
+
+// isNamespaceTerminatingError reports whether err was caused by creating an object
+// in a namespace that is being deleted.
+func isNamespaceTerminatingError(err error) bool {
+	return apierrors.HasStatusCause(err, corev1.NamespaceTerminatingCause)
+}
+
+// markNamespaceTerminating records that the namespace is being deleted. Nothing
+// else is done for the build: it is about to be garbage collected along with its
+// namespace, so failing to write the status is only logged.
+func (r *LeviathanBuildReconciler) markNamespaceTerminating(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, observed *jcrsv1.LeviathanBuildStatus) {
+	log := logf.FromContext(ctx)
+	log.Info("Namespace is terminating, skipping reconcile")
+
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionNamespaceTerminating,
+		Status:             metav1.ConditionTrue,
+		Reason:             jcrsv1.ReasonNamespaceTerminating,
+		Message:            "The namespace is being deleted, no new Jobs are created",
+		ObservedGeneration: lvBuild.Generation,
+	})
+	setBlocked(lvBuild, jcrsv1.BlockedByNamespaceTerminating, jcrsv1.ConditionNamespaceTerminating)
+	if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+		log.V(1).Info("Unable to record NamespaceTerminating condition", "error", err.Error())
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+// namespaceTerminatingError is the error of the API server creating an object in
+// the given namespace while it is being deleted.
+func namespaceTerminatingError(namespace string) error {
+	err := apierrors.NewForbidden(batchv1.Resource("jobs"), "web-1",
+		apierrors.NewBadRequest("unable to create new content in namespace "+namespace+" because it is being terminated"))
+	err.ErrStatus.Details.Causes = []metav1.StatusCause{{
+		Type:    corev1.NamespaceTerminatingCause,
+		Message: "namespace " + namespace + " is being terminated",
+		Field:   "metadata.namespace",
+	}}
+	return err
+}
+
+var _ = Describe("Namespace termination", func() {
+	var (
+		ctx context.Context
+		c   client.Client
+		r   *LeviathanBuildReconciler
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = newFakeClient(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "active"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:              "deleted",
+				DeletionTimestamp: ptr.To(metav1.Now()),
+				Finalizers:        []string{"kubernetes"},
+			}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "terminating"}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating}},
+		)
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+	})
+
+	It("drops the fields nobody reads from the cache", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "team",
+			Annotations: map[string]string{
+				corev1.LastAppliedConfigAnnotation: `{"apiVersion":"v1","kind":"Namespace"}`,
+				"jcrs.jcrs.dev/max-builds":         "10",
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		}}
+		stripped, err := StripNamespace(ns)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stripped.(*corev1.Namespace).ManagedFields).To(BeNil())
+		Expect(stripped.(*corev1.Namespace).Annotations).To(Equal(map[string]string{"jcrs.jcrs.dev/max-builds": "10"}))
+	})
+
+	It("reports the namespaces being deleted", func() {
+		for namespace, terminating := range map[string]bool{"active": false, "deleted": true, "terminating": true, "missing": true} {
+			Expect(r.namespaceTerminating(ctx, namespace)).To(Equal(terminating), namespace)
+		}
+	})
+
+	It("recognizes the errors of creating objects in a namespace being deleted", func() {
+		c = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+			Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+				return namespaceTerminatingError(obj.GetNamespace())
+			},
+		})
+		err := c.Create(ctx, &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "deleted"}})
+		Expect(isNamespaceTerminatingError(err)).To(BeTrue())
+
+		By("not mistaking other errors for it")
+		Expect(isNamespaceTerminatingError(apierrors.NewForbidden(batchv1.Resource("jobs"), "web-1", nil))).To(BeFalse())
+		Expect(isNamespaceTerminatingError(apierrors.NewAlreadyExists(batchv1.Resource("jobs"), "web-1"))).To(BeFalse())
+		Expect(isNamespaceTerminatingError(nil)).To(BeFalse())
+	})
+
+	It("records the condition and blocking reason of the build", func() {
+		lvBuild := utiltesting.MakeLeviathanBuild("web", "deleted").Obj()
+		Expect(c.Create(ctx, lvBuild)).To(Succeed())
+		observed := lvBuild.Status.DeepCopy()
+
+		r.markNamespaceTerminating(ctx, lvBuild, observed)
+
+		var latest jcrsv1.LeviathanBuild
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(lvBuild), &latest)).To(Succeed())
+		condition := meta.FindStatusCondition(latest.Status.Conditions, jcrsv1.ConditionNamespaceTerminating)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(jcrsv1.ReasonNamespaceTerminating))
+		Expect(condition.ObservedGeneration).To(Equal(lvBuild.Generation))
+		Expect(latest.Status.BlockingReason).To(Equal(&jcrsv1.BlockingReason{
+			Reason:  jcrsv1.BlockedByNamespaceTerminating,
+			Details: condition.Message,
+		}))
+	})
+})
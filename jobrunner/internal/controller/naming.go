@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Every Job created for a build is a new run. Runs are numbered, and the Job of a run
+is created with the generateName "<build>-<run index>-", so that the API server picks
+a name that can't collide with a Job left over from an earlier run.
+
+The name of a Job ends up in the labels of its pods, which are limited to 63
+characters. Names, and package names used as label values, that would exceed that
+limit are truncated and suffixed with a hash of the full value, so that distinct
+long names stay distinct.
+*/
+
+const (
+	// buildLabel identifies the Jobs created for a build
+	buildLabel = "jcrs.jcrs.dev/build"
+	// runIndexLabel records the run index of a Job
+	runIndexLabel = "jcrs.jcrs.dev/run-index"
+	// packageLabel records the package built by a Job
+	packageLabel = "jcrs.jcrs.dev/package"
+
+	// generateNameSuffixLength is the length of the random suffix added by generateName
+	generateNameSuffixLength = 5
+	// nameHashLength is the length of the hash suffix of shortened names
+	nameHashLength = 8
+)
+
+// nameHash returns a short hash of value.
+func nameHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:nameHashLength]
+}
+
+// shortenName returns name when it is at most maxLength characters long. Longer
+// names are truncated and suffixed with a hash of the full name.
+func shortenName(name string, maxLength int) string {
+	if len(name) <= maxLength {
+		return name
+	}
+	truncated := strings.TrimRight(name[:maxLength-nameHashLength-1], "-.")
+	return truncated + "-" + nameHash(name)
+}
+
+// labelValue returns value when it is a valid label value. Otherwise, invalid
+// characters are replaced and the result is shortened and suffixed with a hash of
+// the original value.
+func labelValue(value string) string {
+	if len(validation.IsValidLabelValue(value)) == 0 {
+		return value
+	}
+	sanitized := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '-'
+	}, value)
+	sanitized = strings.Trim(sanitized, "-_.")
+
+	maxPrefix := validation.LabelValueMaxLength - nameHashLength - 1
+	if len(sanitized) > maxPrefix {
+		sanitized = strings.TrimRight(sanitized[:maxPrefix], "-_.")
+	}
+	if sanitized == "" {
+		return nameHash(value)
+	}
+	return sanitized + "-" + nameHash(value)
+}
+
+// jobGenerateName returns the generateName of the Job for the given run of lvBuild.
+func jobGenerateName(lvBuild *jcrsv1.LeviathanBuild, runIndex int64) string {
+	suffix := fmt.Sprintf("-%d-", runIndex)
+	maxLength := validation.LabelValueMaxLength - generateNameSuffixLength - len(suffix)
+	return shortenName(lvBuild.Name, maxLength) + suffix
+}
+
+// namingValues are the values a naming template is rendered with.
+type namingValues struct {
+	Name        string
+	PackageName string
+	Revision    int64
+	RunIndex    int64
+}
+
+// TemplateName returns the name the naming template of lvBuild renders for the
+// given run, or "" when lvBuild has no naming template. The name must be a
+// DNS-1123 label.
+func TemplateName(lvBuild *jcrsv1.LeviathanBuild, runIndex int64) (string, error) {
+	if lvBuild.Spec.Naming == nil {
+		return "", nil
+	}
+	tmpl, err := template.New("naming").Option("missingkey=error").Parse(lvBuild.Spec.Naming.Template)
+	if err != nil {
+		return "", err
+	}
+	var name strings.Builder
+	if err := tmpl.Execute(&name, namingValues{
+		Name:        lvBuild.Name,
+		PackageName: ptr.Deref(lvBuild.Spec.PackageName, ""),
+		Revision:    lvBuild.Generation,
+		RunIndex:    runIndex,
+	}); err != nil {
+		return "", err
+	}
+	if errs := validation.IsDNS1123Label(name.String()); len(errs) > 0 {
+		return "", fmt.Errorf("name %q rendered by the naming template is invalid: %s", name.String(), strings.Join(errs, ", "))
+	}
+	return name.String(), nil
+}
+
+// setRunLabels labels job as the given run of lvBuild.
+func setRunLabels(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64) {
+	job.Labels[buildLabel] = labelValue(lvBuild.Name)
+	job.Labels[runIndexLabel] = strconv.FormatInt(runIndex, 10)
+	job.Labels[packageLabel] = labelValue(ptr.Deref(lvBuild.Spec.PackageName, ""))
+}
+
+// RunLabels returns the labels of the Jobs of the given run of lvBuild. As
+// shortened names may collide, Jobs listed by them should be checked to be
+// controlled by lvBuild.
+func RunLabels(lvBuild *jcrsv1.LeviathanBuild, runIndex int64) map[string]string {
+	return map[string]string{
+		buildLabel:    labelValue(lvBuild.Name),
+		runIndexLabel: strconv.FormatInt(runIndex, 10),
+	}
+}
+
+// latestJob returns the Job of the latest run of lvBuild, if any, and its run index.
+func (r *LeviathanBuildReconciler) latestJob(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (*batchv1.Job, int64, error) {
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(lvBuild.Namespace), client.MatchingLabels{buildLabel: labelValue(lvBuild.Name)}); err != nil {
+		return nil, 0, err
+	}
+
+	var latest *batchv1.Job
+	var latestIndex int64
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		// Shortened names may collide, the owner tells the builds apart
+		if !metav1.IsControlledBy(job, lvBuild) {
+			continue
+		}
+		index, err := strconv.ParseInt(job.Labels[runIndexLabel], 10, 64)
+		if err != nil {
+			continue
+		}
+		if latest == nil || index > latestIndex {
+			latest, latestIndex = job, index
+		}
+	}
+	return latest, latestIndex, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Run naming", func() {
+	longName := strings.Repeat("a-very-long-package-name.", 6)
+
+	It("keeps short values and hashes long or invalid ones", func() {
+		Expect(labelValue("web")).To(Equal("web"))
+		Expect(shortenName("web", 10)).To(Equal("web"))
+
+		for _, value := range []string{longName, "@scope/pkg", longName + "x"} {
+			Expect(validation.IsValidLabelValue(labelValue(value))).To(BeEmpty(), value)
+		}
+		Expect(labelValue("@scope/pkg")).To(HavePrefix("scope-pkg-"))
+		Expect(labelValue(longName)).NotTo(Equal(labelValue(longName + "x")))
+		Expect(labelValue("@@@")).To(HaveLen(nameHashLength))
+	})
+
+	It("leaves room for the generated suffix in Job names", func() {
+		lvBuild := &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: longName}}
+		generateName := jobGenerateName(lvBuild, 12)
+		Expect(generateName).To(HaveSuffix("-12-"))
+		Expect(len(generateName) + generateNameSuffixLength).To(BeNumerically("<=", validation.LabelValueMaxLength))
+
+		lvBuild.Name = "web"
+		Expect(jobGenerateName(lvBuild, 1)).To(Equal("web-1-"))
+	})
+
+	It("names Jobs after the naming template of the build", func() {
+		lvBuild := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Generation: 4},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("web-ui")},
+		}
+		Expect(TemplateName(lvBuild, 2)).To(BeEmpty())
+
+		lvBuild.Spec.Naming = &jcrsv1.NamingSpec{Template: "ci-{{ .PackageName }}-r{{ .Revision }}-{{ .RunIndex }}"}
+		Expect(TemplateName(lvBuild, 2)).To(Equal("ci-web-ui-r4-2"))
+		Expect(inlineScriptConfigMapName(lvBuild)).To(Equal("ci-web-ui-r4-0"))
+
+		By("rejecting names that aren't DNS-1123 labels")
+		lvBuild.Spec.Naming.Template = "CI_{{ .Name }}"
+		_, err := TemplateName(lvBuild, 2)
+		Expect(err).To(MatchError(ContainSubstring(`name "CI_web" rendered by the naming template is invalid`)))
+		Expect(inlineScriptConfigMapName(lvBuild)).To(Equal("web-script"))
+
+		By("rejecting unknown values")
+		lvBuild.Spec.Naming.Template = "{{ .Team }}-{{ .RunIndex }}"
+		_, err = TemplateName(lvBuild, 2)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("finds the Job of the latest run", func() {
+		lvBuild := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: types.UID("web-uid")},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("web")},
+		}
+		other := lvBuild.DeepCopy()
+		other.UID = types.UID("other-uid")
+
+		newJob := func(owner *jcrsv1.LeviathanBuild, name string, runIndex int64) *batchv1.Job {
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{}}}
+			setRunLabels(owner, job, runIndex)
+			job.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(owner, jcrsv1.GroupVersion.WithKind("LeviathanBuild"))}
+			return job
+		}
+		c := newFakeClient(
+			newJob(lvBuild, "web-1-abcde", 1),
+			newJob(lvBuild, "web-3-abcde", 3),
+			newJob(other, "web-7-abcde", 7),
+		)
+		r := &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+
+		job, runIndex, err := r.latestJob(context.Background(), lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Name).To(Equal("web-3-abcde"))
+		Expect(runIndex).To(Equal(int64(3)))
+	})
+})
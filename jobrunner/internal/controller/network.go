@@ -0,0 +1,134 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"path"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const (
+	trustBundleVolumeName = "trust-bundle"
+	trustBundleMountPath  = "/etc/leviathan/trust"
+	trustBundleFile       = "ca-bundle.crt"
+)
+
+// trustBundleEnv lists the variables pointing common toolchains at the trust bundle
+var trustBundleEnv = []string{"SSL_CERT_FILE", "REQUESTS_CA_BUNDLE", "NODE_EXTRA_CA_CERTS"}
+
+// NetworkConfig is the operator-level network configuration injected into every
+// build Job, so builds work behind a corporate proxy. Builds can override it
+// through spec.network.
+type NetworkConfig struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+
+	// TrustBundleName names a ConfigMap holding a PEM encoded CA bundle. It is
+	// expected to exist in the namespace of every build, e.g. distributed by
+	// trust-manager. No trust bundle is mounted when empty.
+	TrustBundleName string
+	// TrustBundleKey is the key of the bundle in the ConfigMap
+	TrustBundleKey string
+
+	// SourceCIDRs are the networks isolated builds may reach to fetch their source
+	SourceCIDRs []string
+	// PublishCIDRs are the networks isolated builds may reach to publish
+	PublishCIDRs []string
+}
+
+// networkConfig returns the network configuration of lvBuild: the operator-level
+// configuration with the overrides of spec.network applied.
+func (r *LeviathanBuildReconciler) networkConfig(lvBuild *jcrsv1.LeviathanBuild) NetworkConfig {
+	config := r.Network
+	network := lvBuild.Spec.Network
+	if network == nil {
+		return config
+	}
+	if network.HTTPProxy != nil {
+		config.HTTPProxy = *network.HTTPProxy
+	}
+	if network.HTTPSProxy != nil {
+		config.HTTPSProxy = *network.HTTPSProxy
+	}
+	if network.NoProxy != nil {
+		config.NoProxy = *network.NoProxy
+	}
+	if network.TrustBundle != nil {
+		config.TrustBundleName = network.TrustBundle.Name
+		config.TrustBundleKey = network.TrustBundle.Key
+	}
+	return config
+}
+
+// addNetworkConfig injects the proxy environment and the trust bundle into every
+// container of the build Job, including init containers since they may download
+// the source.
+func (r *LeviathanBuildReconciler) addNetworkConfig(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	config := r.networkConfig(lvBuild)
+	podSpec := &job.Spec.Template.Spec
+
+	var env []corev1.EnvVar
+	for _, proxy := range []struct{ name, value string }{
+		{"HTTP_PROXY", config.HTTPProxy},
+		{"HTTPS_PROXY", config.HTTPSProxy},
+		{"NO_PROXY", config.NoProxy},
+	} {
+		if proxy.value == "" {
+			continue
+		}
+		// Not every tool reads the upper case variables, so both are set
+		env = append(env,
+			corev1.EnvVar{Name: proxy.name, Value: proxy.value},
+			corev1.EnvVar{Name: strings.ToLower(proxy.name), Value: proxy.value})
+	}
+
+	var mount *corev1.VolumeMount
+	if config.TrustBundleName != "" {
+		key := config.TrustBundleKey
+		if key == "" {
+			key = trustBundleFile
+		}
+		addVolume(podSpec, corev1.Volume{
+			Name: trustBundleVolumeName,
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: config.TrustBundleName},
+				Items:                []corev1.KeyToPath{{Key: key, Path: trustBundleFile}},
+			}},
+		})
+		mount = &corev1.VolumeMount{Name: trustBundleVolumeName, MountPath: trustBundleMountPath, ReadOnly: true}
+		for _, name := range trustBundleEnv {
+			env = append(env, corev1.EnvVar{Name: name, Value: path.Join(trustBundleMountPath, trustBundleFile)})
+		}
+	}
+
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			for _, e := range env {
+				setContainerEnv(&containers[i], e)
+			}
+			if mount != nil {
+				addContainerVolumeMount(&containers[i], *mount)
+			}
+		}
+	}
+}
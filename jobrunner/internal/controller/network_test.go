@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Network configuration", func() {
+	var (
+		r   *LeviathanBuildReconciler
+		job *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		r = &LeviathanBuildReconciler{Network: NetworkConfig{
+			HTTPSProxy:      "http://proxy.corp:3128",
+			NoProxy:         ".svc,.cluster.local",
+			TrustBundleName: "corp-ca",
+		}}
+		job = &batchv1.Job{}
+		job.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: fetchContainerName}}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+	})
+
+	It("injects the operator configuration into every container", func() {
+		r.addNetworkConfig(&jcrsv1.LeviathanBuild{}, job)
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.Volumes).To(ConsistOf(HaveField("ConfigMap.Items", ConsistOf(
+			corev1.KeyToPath{Key: "ca-bundle.crt", Path: trustBundleFile}))))
+		for _, c := range append(podSpec.InitContainers, podSpec.Containers...) {
+			Expect(c.Env).To(ContainElements(
+				corev1.EnvVar{Name: "HTTPS_PROXY", Value: "http://proxy.corp:3128"},
+				corev1.EnvVar{Name: "https_proxy", Value: "http://proxy.corp:3128"},
+				corev1.EnvVar{Name: "SSL_CERT_FILE", Value: "/etc/leviathan/trust/ca-bundle.crt"},
+			))
+			Expect(c.Env).NotTo(ContainElement(HaveField("Name", "HTTP_PROXY")))
+			Expect(c.VolumeMounts).To(ConsistOf(HaveField("Name", trustBundleVolumeName)))
+		}
+	})
+
+	It("applies the overrides of the build", func() {
+		lvBuild := &jcrsv1.LeviathanBuild{Spec: jcrsv1.LeviathanBuildSpec{Network: &jcrsv1.NetworkSpec{
+			HTTPSProxy:  ptr.To(""),
+			HTTPProxy:   ptr.To("http://other:8080"),
+			TrustBundle: &jcrsv1.TrustBundleRef{Name: ""},
+		}}}
+		r.addNetworkConfig(lvBuild, job)
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.Volumes).To(BeEmpty())
+		Expect(podSpec.Containers[0].Env).To(ConsistOf(
+			corev1.EnvVar{Name: "HTTP_PROXY", Value: "http://other:8080"},
+			corev1.EnvVar{Name: "http_proxy", Value: "http://other:8080"},
+			corev1.EnvVar{Name: "NO_PROXY", Value: ".svc,.cluster.local"},
+			corev1.EnvVar{Name: "no_proxy", Value: ".svc,.cluster.local"},
+		))
+	})
+
+	It("sets the DNS configuration of the build", func() {
+		job.Spec.Template.Spec.DNSPolicy = corev1.DNSClusterFirst
+		job.Spec.Template.Spec.HostAliases = []corev1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"git.corp"}}}
+		lvBuild := &jcrsv1.LeviathanBuild{Spec: jcrsv1.LeviathanBuildSpec{
+			DNSPolicy:   corev1.DNSNone,
+			DNSConfig:   &corev1.PodDNSConfig{Nameservers: []string{"10.0.0.53"}, Searches: []string{"mirrors.corp"}},
+			HostAliases: []corev1.HostAlias{{IP: "10.0.0.2", Hostnames: []string{"pypi.corp"}}},
+		}}
+		addBuildDNS(lvBuild, job)
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.DNSPolicy).To(Equal(corev1.DNSNone))
+		Expect(podSpec.DNSConfig).To(Equal(lvBuild.Spec.DNSConfig))
+		Expect(podSpec.HostAliases).To(Equal([]corev1.HostAlias{
+			{IP: "10.0.0.1", Hostnames: []string{"git.corp"}},
+			{IP: "10.0.0.2", Hostnames: []string{"pypi.corp"}},
+		}))
+
+		By("keeping the DNS configuration of the jobTemplate when the build has none")
+		job.Spec.Template.Spec = corev1.PodSpec{DNSPolicy: corev1.DNSDefault}
+		addBuildDNS(&jcrsv1.LeviathanBuild{}, job)
+		Expect(job.Spec.Template.Spec).To(Equal(corev1.PodSpec{DNSPolicy: corev1.DNSDefault}))
+	})
+})
@@ -0,0 +1,194 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Builds with a "Strict" network isolation run behind a NetworkPolicy of their own.
+The policy selects the pods of the build through the build label, which is only
+set on the pod template of isolated builds, and denies all ingress. Egress is
+allowed to the cluster DNS and the nameservers of the dnsConfig of the build, and
+to the networks of the source and the publish target when the build needs them.
+
+NetworkPolicies only match addresses, so the source and publish networks are CIDRs
+configured for the operator; a registryURL whose host is an IP address is allowed
+as is. Builds going through a proxy only reach it if it is part of those networks.
+
+The policy is created before the Job of a run, so its pods never start without
+it, and deleted once the Job has finished.
+*/
+
+const (
+	networkPolicySuffix = "-isolation"
+
+	// dnsNamespace and dnsLabel select the cluster DNS pods
+	dnsNamespace = "kube-system"
+	dnsLabel     = "k8s-app"
+	dnsLabelName = "kube-dns"
+)
+
+// ParseCIDRs parses a comma separated list of CIDRs, as given to the isolation flags.
+func ParseCIDRs(value string) ([]string, error) {
+	var cidrs []string
+	for _, cidr := range strings.Split(value, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		cidrs = append(cidrs, prefix.Masked().String())
+	}
+	return cidrs, nil
+}
+
+// isolated reports whether lvBuild runs with a strict network isolation.
+func isolated(lvBuild *jcrsv1.LeviathanBuild) bool {
+	return lvBuild.Spec.Network != nil && lvBuild.Spec.Network.Isolation == jcrsv1.StrictIsolation
+}
+
+// networkPolicyName returns the name of the NetworkPolicy isolating lvBuild.
+func networkPolicyName(lvBuild *jcrsv1.LeviathanBuild) string {
+	return lvBuild.Name + networkPolicySuffix
+}
+
+// addNetworkIsolation labels the pods of isolated builds, so that their
+// NetworkPolicy selects them.
+func addNetworkIsolation(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if !isolated(lvBuild) {
+		return
+	}
+	job.Spec.Template.Labels[buildLabel] = labelValue(lvBuild.Name)
+}
+
+// fetchesSource reports whether the build downloads its source over the network.
+func fetchesSource(lvBuild *jcrsv1.LeviathanBuild) bool {
+	switch lvBuild.Spec.SourceType {
+	case jcrsv1.GitSource, jcrsv1.S3Source, jcrsv1.HTTPSource:
+		return true
+	}
+	return false
+}
+
+// registryCIDR returns the CIDR of the registry host when it is an IP address.
+func registryCIDR(registryURL string) (string, bool) {
+	if !strings.Contains(registryURL, "://") {
+		registryURL = "https://" + registryURL
+	}
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return "", false
+	}
+	addr, err := netip.ParseAddr(u.Hostname())
+	if err != nil {
+		return "", false
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()).String(), true
+}
+
+// networkPolicySpec returns the spec of the NetworkPolicy isolating lvBuild.
+func (r *LeviathanBuildReconciler) networkPolicySpec(lvBuild *jcrsv1.LeviathanBuild) networkingv1.NetworkPolicySpec {
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	dnsPort := intstr.FromInt32(53)
+	egress := []networkingv1.NetworkPolicyEgressRule{{
+		To: []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: dnsNamespace}},
+			PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{dnsLabel: dnsLabelName}},
+		}},
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &dnsPort}, {Protocol: &tcp, Port: &dnsPort}},
+	}}
+	if lvBuild.Spec.DNSConfig != nil {
+		for _, nameserver := range lvBuild.Spec.DNSConfig.Nameservers {
+			if addr, err := netip.ParseAddr(nameserver); err == nil {
+				cidr := netip.PrefixFrom(addr, addr.BitLen()).String()
+				egress[0].To = append(egress[0].To, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+			}
+		}
+	}
+
+	var cidrs []string
+	if fetchesSource(lvBuild) {
+		cidrs = append(cidrs, r.Network.SourceCIDRs...)
+	}
+	if publishes(lvBuild.Spec.BuildType) {
+		cidrs = append(cidrs, r.Network.PublishCIDRs...)
+		if lvBuild.Spec.PublishTarget != nil {
+			if cidr, ok := registryCIDR(lvBuild.Spec.PublishTarget.RegistryURL); ok {
+				cidrs = append(cidrs, cidr)
+			}
+		}
+	}
+	if len(cidrs) > 0 {
+		rule := networkingv1.NetworkPolicyEgressRule{}
+		for _, cidr := range cidrs {
+			rule.To = append(rule.To, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+		}
+		egress = append(egress, rule)
+	}
+
+	return networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{buildLabel: labelValue(lvBuild.Name)}},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		Egress:      egress,
+	}
+}
+
+// reconcileNetworkPolicy creates or updates the NetworkPolicy of an isolated
+// build while its Job runs, and deletes it otherwise.
+func (r *LeviathanBuildReconciler) reconcileNetworkPolicy(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, running bool) error {
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      networkPolicyName(lvBuild),
+			Namespace: lvBuild.Namespace,
+		},
+	}
+	if !running || !isolated(lvBuild) {
+		// The cached policy spares a delete call for every build that isn't isolated
+		if err := r.Get(ctx, client.ObjectKeyFromObject(policy), policy); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !metav1.IsControlledBy(policy, lvBuild) {
+			return nil
+		}
+		return client.IgnoreNotFound(r.Delete(ctx, policy))
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, policy, func() error {
+		policy.Spec = r.networkPolicySpec(lvBuild)
+		return ctrl.SetControllerReference(lvBuild, policy, r.Scheme)
+	})
+	return err
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Network isolation", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		r       *LeviathanBuildReconciler
+		c       client.Client
+	)
+
+	BeforeEach(func() {
+		c = newFakeClient()
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme(), Network: NetworkConfig{
+			SourceCIDRs:  []string{"140.82.112.0/20"},
+			PublishCIDRs: []string{"10.20.0.0/16"},
+		}}
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				SourceType:    jcrsv1.GitSource,
+				BuildType:     jcrsv1.BuildPublish,
+				PublishTarget: &jcrsv1.PublishTarget{RegistryURL: "http://192.168.1.10:5000", Version: "1.0.0"},
+				Network:       &jcrsv1.NetworkSpec{Isolation: jcrsv1.StrictIsolation},
+			},
+		}
+	})
+
+	It("parses the configured CIDRs", func() {
+		cidrs, err := ParseCIDRs(" 10.0.0.1/8, ,fd00::/64")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cidrs).To(Equal([]string{"10.0.0.0/8", "fd00::/64"}))
+
+		_, err = ParseCIDRs("github.com")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("only labels the pods of isolated builds", func() {
+		job := &batchv1.Job{}
+		job.Spec.Template.Labels = map[string]string{}
+		addNetworkIsolation(lvBuild, job)
+		Expect(job.Spec.Template.Labels).To(HaveKeyWithValue(buildLabel, "web"))
+
+		job.Spec.Template.Labels = map[string]string{}
+		lvBuild.Spec.Network.Isolation = jcrsv1.DefaultIsolation
+		addNetworkIsolation(lvBuild, job)
+		Expect(job.Spec.Template.Labels).To(BeEmpty())
+	})
+
+	It("allows DNS, the source and the publish target", func() {
+		spec := r.networkPolicySpec(lvBuild)
+		Expect(spec.PodSelector.MatchLabels).To(Equal(map[string]string{buildLabel: "web"}))
+		Expect(spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress))
+		Expect(spec.Ingress).To(BeEmpty())
+		Expect(spec.Egress).To(HaveLen(2))
+		Expect(spec.Egress[0].Ports).To(HaveLen(2))
+		Expect(spec.Egress[1].To).To(ConsistOf(
+			HaveField("IPBlock.CIDR", "140.82.112.0/20"),
+			HaveField("IPBlock.CIDR", "10.20.0.0/16"),
+			HaveField("IPBlock.CIDR", "192.168.1.10/32"),
+		))
+
+		By("allowing only DNS to builds that neither fetch nor publish")
+		lvBuild.Spec.SourceType = jcrsv1.InlineSource
+		lvBuild.Spec.BuildType = jcrsv1.Build
+		Expect(r.networkPolicySpec(lvBuild).Egress).To(HaveLen(1))
+	})
+
+	It("allows the nameservers of the build", func() {
+		lvBuild.Spec.DNSConfig = &corev1.PodDNSConfig{Nameservers: []string{"10.0.0.53", "fd00::53"}}
+		spec := r.networkPolicySpec(lvBuild)
+		Expect(spec.Egress[0].To).To(HaveLen(3))
+		Expect(spec.Egress[0].To[1:]).To(ConsistOf(
+			HaveField("IPBlock.CIDR", "10.0.0.53/32"),
+			HaveField("IPBlock.CIDR", "fd00::53/128"),
+		))
+	})
+
+	It("keeps the NetworkPolicy while the Job runs", func() {
+		ctx := context.Background()
+		key := client.ObjectKey{Namespace: "default", Name: "web-isolation"}
+		policy := &networkingv1.NetworkPolicy{}
+
+		Expect(r.reconcileNetworkPolicy(ctx, lvBuild, true)).To(Succeed())
+		Expect(c.Get(ctx, key, policy)).To(Succeed())
+		Expect(policy.OwnerReferences).To(ConsistOf(HaveField("UID", lvBuild.UID)))
+
+		Expect(r.reconcileNetworkPolicy(ctx, lvBuild, false)).To(Succeed())
+		Expect(c.Get(ctx, key, policy)).NotTo(Succeed())
+
+		By("deleting it when the isolation is turned off")
+		Expect(r.reconcileNetworkPolicy(ctx, lvBuild, true)).To(Succeed())
+		lvBuild.Spec.Network.Isolation = jcrsv1.DefaultIsolation
+		Expect(r.reconcileNetworkPolicy(ctx, lvBuild, true)).To(Succeed())
+		Expect(c.Get(ctx, key, policy)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,257 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+Once a publishing build has succeeded, the patch targets of `spec.onSuccess` are
+applied with server-side apply. The controller doesn't patch them with its own
+permissions but impersonates the ServiceAccount named by the build, so a build can
+only modify what its ServiceAccount is allowed to. The controller itself is only
+allowed to impersonate the ServiceAccounts of the namespaces where the
+serviceaccount-impersonator-role is bound to it.
+
+Every patch is first sent as a dry run, and nothing is applied unless all of them
+pass, so a typo in one target doesn't leave the others half rolled out.
+
+Patches that fail are retried, unless the build asks for the version to be rolled
+back with `spec.onHookFailure`. The version is then deleted from the store of its
+artifactRetention policy, the run is marked as failed and the patches are not
+applied again. A rollback that fails is retried along with the patches.
+*/
+
+const (
+	// patchTargetsRetryInterval is how often failed patch targets are retried
+	patchTargetsRetryInterval = 5 * time.Minute
+
+	// rolledBackReason is the reason of the Event recorded when a version is rolled back
+	rolledBackReason = "RolledBack"
+)
+
+// ServiceAccountClientFunc returns a client acting as the named ServiceAccount.
+type ServiceAccountClientFunc func(namespace, name string) (client.Client, error)
+
+// buildResult is the termination message written by the build container.
+type buildResult struct {
+	Digest string `json:"digest"`
+	// Outputs are the digests of the files the build produced, by path. They
+	// are optional, and only used to summarize what differs between two runs.
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// Conditions are custom conditions reflected into the status of the build.
+	Conditions []reportedCondition `json:"conditions,omitempty"`
+}
+
+// publishedDigest returns the digest reported by the build containers of job
+// through their termination message, or an empty string.
+func (r *LeviathanBuildReconciler) publishedDigest(ctx context.Context, job *batchv1.Job) (string, error) {
+	result, err := r.reportedResult(ctx, job)
+	return result.Digest, err
+}
+
+// reportedResult returns the first result reporting a digest written by the
+// build containers of job, or an empty result.
+func (r *LeviathanBuildReconciler) reportedResult(ctx context.Context, job *batchv1.Job) (buildResult, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return buildResult{}, err
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated == nil || status.State.Terminated.ExitCode != 0 {
+				continue
+			}
+			var result buildResult
+			if err := json.Unmarshal([]byte(status.State.Terminated.Message), &result); err == nil && result.Digest != "" {
+				return result, nil
+			}
+		}
+	}
+	return buildResult{}, nil
+}
+
+// expandPatch replaces the references to the published values in patch. It fails
+// when the patch refers to a digest that isn't known.
+func expandPatch(lvBuild *jcrsv1.LeviathanBuild, patch []byte) ([]byte, error) {
+	raw := string(patch)
+	if strings.Contains(raw, "$(DIGEST)") && lvBuild.Status.PublishedDigest == "" {
+		return nil, fmt.Errorf("the patch refers to $(DIGEST) but the build didn't report a digest")
+	}
+	version := ""
+	if lvBuild.Spec.PublishTarget != nil {
+		version = lvBuild.Spec.PublishTarget.Version
+	}
+
+	// The values are substituted inside JSON strings, so they're escaped as such
+	escape := func(value string) string {
+		quoted, _ := json.Marshal(value)
+		return string(quoted[1 : len(quoted)-1])
+	}
+	return []byte(strings.NewReplacer(
+		"$(DIGEST)", escape(lvBuild.Status.PublishedDigest),
+		"$(VERSION)", escape(version),
+		"$(PACKAGE_NAME)", escape(ptr.Deref(lvBuild.Spec.PackageName, "")),
+	).Replace(raw)), nil
+}
+
+// patchTargetObjects returns the objects applied for the patch targets of lvBuild.
+func patchTargetObjects(lvBuild *jcrsv1.LeviathanBuild) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	for _, target := range lvBuild.Spec.OnSuccess.PatchTargets {
+		patch, err := expandPatch(lvBuild, target.Patch.Raw)
+		if err != nil {
+			return nil, err
+		}
+		obj := &unstructured.Unstructured{}
+		if err := json.Unmarshal(patch, &obj.Object); err != nil {
+			return nil, fmt.Errorf("invalid patch for %s %s: %w", target.Kind, target.Name, err)
+		}
+		if obj.Object == nil {
+			obj.Object = make(map[string]any)
+		}
+		obj.SetAPIVersion(target.APIVersion)
+		obj.SetKind(target.Kind)
+		obj.SetName(target.Name)
+		obj.SetNamespace(lvBuild.Namespace)
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// resetPatchTargets forgets the patch targets applied for the previous run of
+// lvBuild, so that a rerun of the same generation moves them again.
+func resetPatchTargets(lvBuild *jcrsv1.LeviathanBuild) {
+	meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionPatchTargetsApplied)
+}
+
+// applyPatchTargets applies the patch targets of a succeeded build and records the
+// outcome as a condition. It reports whether the patches should be retried later.
+func (r *LeviathanBuildReconciler) applyPatchTargets(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) bool {
+	onSuccess := lvBuild.Spec.OnSuccess
+	if onSuccess == nil || len(onSuccess.PatchTargets) == 0 || !publishes(lvBuild.Spec.BuildType) {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionPatchTargetsApplied)
+		return false
+	}
+	if !featuregates.Enabled(featuregates.OnSuccessPatchTargets) {
+		meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+			Type:               jcrsv1.ConditionPatchTargetsApplied,
+			Status:             metav1.ConditionFalse,
+			Reason:             jcrsv1.ReasonFeatureDisabled,
+			Message:            "The " + string(featuregates.OnSuccessPatchTargets) + " feature gate is disabled",
+			ObservedGeneration: lvBuild.Generation,
+		})
+		return false
+	}
+	if applied := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPatchTargetsApplied); applied != nil &&
+		applied.Status == metav1.ConditionTrue && applied.ObservedGeneration == lvBuild.Generation {
+		return false
+	}
+
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionPatchTargetsApplied,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: lvBuild.Generation,
+	}
+	err := r.patchTargets(ctx, lvBuild, onSuccess.ServiceAccountName, &condition)
+	if err == nil {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = jcrsv1.ReasonApplied
+		condition.Message = fmt.Sprintf("Patched %d target(s)", len(onSuccess.PatchTargets))
+	} else {
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, condition)
+	return err != nil
+}
+
+// patchTargets dry runs, then applies, the patch targets of lvBuild as the given
+// ServiceAccount. On failure, the reason of condition is set.
+func (r *LeviathanBuildReconciler) patchTargets(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, serviceAccount string, condition *metav1.Condition) error {
+	condition.Reason = jcrsv1.ReasonInvalidPatch
+	objs, err := patchTargetObjects(lvBuild)
+	if err != nil {
+		return err
+	}
+
+	condition.Reason = jcrsv1.ReasonImpersonationFailed
+	if r.ServiceAccountClient == nil {
+		return fmt.Errorf("patch targets are not supported by this controller")
+	}
+	c, err := r.ServiceAccountClient(lvBuild.Namespace, serviceAccount)
+	if err != nil {
+		return err
+	}
+
+	owner := client.FieldOwner("leviathanbuild-" + lvBuild.Name)
+	condition.Reason = jcrsv1.ReasonDryRunFailed
+	for _, obj := range objs {
+		if err := c.Patch(ctx, obj.DeepCopy(), client.Apply, owner, client.ForceOwnership, client.DryRunAll); err != nil {
+			return fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	condition.Reason = jcrsv1.ReasonApplyFailed
+	for _, obj := range objs {
+		if err := c.Patch(ctx, obj, client.Apply, owner, client.ForceOwnership); err != nil {
+			return fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// rollbackPublish deletes the version published by job, the Job of the current run
+// of lvBuild, from the store of its artifactRetention, and marks the run as failed.
+func (r *LeviathanBuildReconciler) rollbackPublish(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) error {
+	target := lvBuild.Spec.PublishTarget
+	if target == nil || lvBuild.Spec.ArtifactRetention == nil {
+		return fmt.Errorf("rolling back needs a publishTarget and an artifactRetention store")
+	}
+	store := lvBuild.Spec.ArtifactRetention.Store
+	pruner := r.Pruners[store]
+	if pruner == nil {
+		return fmt.Errorf("versions can't be deleted from %s storage by this controller", store)
+	}
+	if err := pruner.Delete(ctx, target.RegistryURL, publishPathOf(lvBuild), target.Version, lvBuild.Status.PublishedDigest); err != nil {
+		return fmt.Errorf("deleting version %s: %w", target.Version, err)
+	}
+	lvBuild.Status.RolledBack = true
+	markRolledBack(lvBuild, job)
+	r.event(lvBuild, corev1.EventTypeWarning, rolledBackReason, "Deleted version %s published by Job %s after its patch targets failed", target.Version, job.Name)
+	return nil
+}
+
+// markRolledBack records that the run of job failed, as its published version was rolled back.
+func markRolledBack(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	jcrsv1.MarkFailed(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRolledBack,
+		"Job "+job.Name+" completed, but its published version was rolled back as its patch targets failed")
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	"test.jcrs.dev/jobrunner/internal/retention"
+)
+
+var _ = Describe("onSuccess patch targets", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		patches []string
+		failOn  string
+		r       *LeviathanBuildReconciler
+	)
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.OnSuccessPatchTargets: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.OnSuccessPatchTargets: false})).To(Succeed())
+		})
+
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 1},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName:   ptr.To("web"),
+				BuildType:     jcrsv1.BuildPublish,
+				PublishTarget: &jcrsv1.PublishTarget{RegistryURL: "https://registry.example.com", Version: "1.2.3"},
+				OnSuccess: &jcrsv1.OnSuccessSpec{
+					ServiceAccountName: "deployer",
+					PatchTargets: []jcrsv1.PatchTarget{
+						{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Patch: runtime.RawExtension{
+							Raw: []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"web","image":"registry.example.com/web@$(DIGEST)"}]}}}}`),
+						}},
+						{APIVersion: "v1", Kind: "ConfigMap", Name: "web-version", Patch: runtime.RawExtension{
+							Raw: []byte(`{"data":{"version":"$(VERSION)"}}`),
+						}},
+					},
+				},
+			},
+			Status: jcrsv1.LeviathanBuildStatus{PublishedDigest: "sha256:0123"},
+		}
+
+		patches, failOn = nil, ""
+		c := newFakeClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, _ client.Patch, opts ...client.PatchOption) error {
+				patchOpts := &client.PatchOptions{}
+				patchOpts.ApplyOptions(opts)
+				call := obj.GetObjectKind().GroupVersionKind().Kind
+				if slices.Contains(patchOpts.DryRun, metav1.DryRunAll) {
+					call = "dry-run " + call
+				}
+				patches = append(patches, call)
+				if call == failOn {
+					return errors.New("forbidden")
+				}
+				return nil
+			},
+		}).Build()
+		r = &LeviathanBuildReconciler{ServiceAccountClient: func(namespace, name string) (client.Client, error) {
+			Expect(namespace).To(Equal("default"))
+			Expect(name).To(Equal("deployer"))
+			return c, nil
+		}}
+	})
+
+	It("substitutes the published values into the patches", func() {
+		objs, err := patchTargetObjects(lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(2))
+		Expect(objs[0].GetNamespace()).To(Equal("default"))
+		Expect(objs[0].Object).To(HaveKeyWithValue("spec", HaveKeyWithValue("template", HaveKeyWithValue("spec",
+			HaveKeyWithValue("containers", ContainElement(HaveKeyWithValue("image", "registry.example.com/web@sha256:0123")))))))
+		Expect(objs[1].Object).To(HaveKeyWithValue("data", HaveKeyWithValue("version", "1.2.3")))
+
+		lvBuild.Status.PublishedDigest = ""
+		_, err = patchTargetObjects(lvBuild)
+		Expect(err).To(MatchError(ContainSubstring("$(DIGEST)")))
+	})
+
+	It("applies the patches once every dry run has passed", func() {
+		Expect(r.applyPatchTargets(context.Background(), lvBuild)).To(BeFalse())
+		Expect(patches).To(Equal([]string{"dry-run Deployment", "dry-run ConfigMap", "Deployment", "ConfigMap"}))
+		Expect(meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionPatchTargetsApplied)).To(BeTrue())
+
+		By("not patching again for the same generation")
+		patches = nil
+		Expect(r.applyPatchTargets(context.Background(), lvBuild)).To(BeFalse())
+		Expect(patches).To(BeEmpty())
+	})
+
+	It("applies the patches again for a rerun of the same generation", func() {
+		Expect(r.applyPatchTargets(context.Background(), lvBuild)).To(BeFalse())
+
+		By("starting a new run, e.g. after a source poll, with another digest")
+		resetPatchTargets(lvBuild)
+		lvBuild.Status.PublishedDigest = "sha256:4567"
+		patches = nil
+		Expect(r.applyPatchTargets(context.Background(), lvBuild)).To(BeFalse())
+		Expect(patches).To(Equal([]string{"dry-run Deployment", "dry-run ConfigMap", "Deployment", "ConfigMap"}))
+		Expect(meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionPatchTargetsApplied)).To(BeTrue())
+	})
+
+	It("applies nothing when a dry run fails", func() {
+		failOn = "dry-run ConfigMap"
+		Expect(r.applyPatchTargets(context.Background(), lvBuild)).To(BeTrue())
+		Expect(patches).To(Equal([]string{"dry-run Deployment", "dry-run ConfigMap"}))
+		condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPatchTargetsApplied)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("DryRunFailed"))
+	})
+
+	It("rolls back the published version and fails the run", func() {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}}
+		lvBuild.Spec.OnHookFailure = jcrsv1.RollbackOnHookFailure
+		lvBuild.Spec.ArtifactRetention = &jcrsv1.ArtifactRetention{Store: jcrsv1.OCIArtifactStore}
+
+		By("failing without a Pruner for the store")
+		Expect(r.rollbackPublish(context.Background(), lvBuild, job)).To(MatchError(ContainSubstring("OCI")))
+		Expect(lvBuild.Status.RolledBack).To(BeFalse())
+
+		By("failing when the version can't be deleted")
+		pruner := &recordingPruner{failOn: map[string]bool{"1.2.3": true}}
+		r.Pruners = map[jcrsv1.ArtifactStore]retention.Pruner{jcrsv1.OCIArtifactStore: pruner}
+		Expect(r.rollbackPublish(context.Background(), lvBuild, job)).To(MatchError(ContainSubstring("denied")))
+		Expect(lvBuild.Status.RolledBack).To(BeFalse())
+
+		pruner.failOn = nil
+		Expect(r.rollbackPublish(context.Background(), lvBuild, job)).To(Succeed())
+		Expect(pruner.deleted).To(Equal([]string{"web:1.2.3"}))
+		Expect(lvBuild.Status.RolledBack).To(BeTrue())
+		succeeded := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded)
+		Expect(succeeded.Status).To(Equal(metav1.ConditionFalse))
+		Expect(succeeded.Reason).To(Equal(jcrsv1.ReasonRolledBack))
+	})
+})
@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+The admission webhook records who created a build, and who last triggered it by
+changing its spec, in annotations that users can't set themselves. Packages with a
+PackageOwnership are only published by builds last triggered by one of their
+publishers: the check runs before the Job of a publishing run is created, next to
+the publish preflight, so a build that may not publish doesn't run at all.
+
+Packages without any PackageOwnership may be published by anyone, as before.
+*/
+
+const (
+	// CreatedByAnnotation records the user who created a build
+	CreatedByAnnotation = "jcrs.jcrs.dev/created-by"
+	// TriggeredByAnnotation records the user who last changed the spec of a build
+	TriggeredByAnnotation = "jcrs.jcrs.dev/triggered-by"
+)
+
+// publishers returns the publishers of packageName, and whether any
+// PackageOwnership restricts who may publish it.
+func (r *LeviathanBuildReconciler) publishers(ctx context.Context, packageName string) ([]string, bool, error) {
+	var ownerships jcrsv1.PackageOwnershipList
+	if err := r.List(ctx, &ownerships); err != nil {
+		return nil, false, err
+	}
+	var publishers []string
+	owned := false
+	for _, ownership := range ownerships.Items {
+		if ownership.Spec.PackageName == packageName {
+			owned = true
+			publishers = append(publishers, ownership.Spec.Publishers...)
+		}
+	}
+	return publishers, owned, nil
+}
+
+// publishAuthorized checks that the user who triggered a publishing build may
+// publish its package. The outcome is recorded as a condition on lvBuild; it
+// returns false when no Job should be created.
+func (r *LeviathanBuildReconciler) publishAuthorized(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (bool, error) {
+	if !featuregates.Enabled(featuregates.PackageOwnership) || !publishes(lvBuild.Spec.BuildType) {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionPublishAuthorized)
+		return true, nil
+	}
+
+	packageName := ptr.Deref(lvBuild.Spec.PackageName, "")
+	publishers, owned, err := r.publishers(ctx, packageName)
+	if err != nil {
+		return false, fmt.Errorf("listing PackageOwnerships: %w", err)
+	}
+	if !owned {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionPublishAuthorized)
+		return true, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionPublishAuthorized,
+		Status:             metav1.ConditionTrue,
+		Reason:             jcrsv1.ReasonPublisher,
+		ObservedGeneration: lvBuild.Generation,
+	}
+	user, known := lvBuild.Annotations[TriggeredByAnnotation]
+	switch {
+	case !known:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = jcrsv1.ReasonNotAPublisher
+		condition.Message = fmt.Sprintf("Package %s may only be published by its publishers, but the user who triggered the build isn't known", packageName)
+	case !slices.Contains(publishers, user):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = jcrsv1.ReasonNotAPublisher
+		condition.Message = fmt.Sprintf("%s isn't a publisher of package %s", user, packageName)
+	default:
+		condition.Message = fmt.Sprintf("%s is a publisher of package %s", user, packageName)
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, condition)
+	return condition.Status == metav1.ConditionTrue, nil
+}
+
+// buildsForPackageOwnership maps a changed PackageOwnership to the builds
+// publishing its package, which may be allowed to publish now.
+func (r *LeviathanBuildReconciler) buildsForPackageOwnership(ctx context.Context, obj client.Object) []reconcile.Request {
+	ownership, ok := obj.(*jcrsv1.PackageOwnership)
+	if !ok {
+		return nil
+	}
+	var builds jcrsv1.LeviathanBuildList
+	if err := r.List(ctx, &builds); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list LeviathanBuilds")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, lvBuild := range builds.Items {
+		if ptr.Deref(lvBuild.Spec.PackageName, "") == ownership.Spec.PackageName && publishes(lvBuild.Spec.BuildType) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: lvBuild.Namespace,
+				Name:      lvBuild.Name,
+			}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Package ownership", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		c       client.Client
+		r       *LeviathanBuildReconciler
+	)
+
+	ownership := func(name, packageName string, publishers ...string) *jcrsv1.PackageOwnership {
+		return &jcrsv1.PackageOwnership{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       jcrsv1.PackageOwnershipSpec{PackageName: packageName, Publishers: publishers},
+		}
+	}
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PackageOwnership: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PackageOwnership: false})).To(Succeed())
+		})
+
+		c = newFakeClientBuilder().WithObjects(
+			ownership("web-team", "web", "jane"),
+			ownership("web-release", "web", "system:serviceaccount:ci:deployer"),
+			ownership("api", "api", "john"),
+		).Build()
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "default").
+			Annotation(TriggeredByAnnotation, "system:serviceaccount:ci:deployer").
+			BuildType(jcrsv1.BuildPublish).
+			Obj()
+	})
+
+	It("lets the publishers of any ownership of the package publish it", func() {
+		Expect(r.publishAuthorized(context.Background(), lvBuild)).To(BeTrue())
+		condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPublishAuthorized)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(jcrsv1.ReasonPublisher))
+	})
+
+	It("holds back builds triggered by other users, or by unknown ones", func() {
+		lvBuild.Annotations[TriggeredByAnnotation] = "john"
+		Expect(r.publishAuthorized(context.Background(), lvBuild)).To(BeFalse())
+		condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPublishAuthorized)
+		Expect(condition.Reason).To(Equal(jcrsv1.ReasonNotAPublisher))
+		Expect(condition.Message).To(Equal("john isn't a publisher of package web"))
+
+		delete(lvBuild.Annotations, TriggeredByAnnotation)
+		Expect(r.publishAuthorized(context.Background(), lvBuild)).To(BeFalse())
+		Expect(meta.IsStatusConditionFalse(lvBuild.Status.Conditions, jcrsv1.ConditionPublishAuthorized)).To(BeTrue())
+	})
+
+	It("leaves builds that don't publish an owned package alone", func() {
+		lvBuild.Annotations[TriggeredByAnnotation] = "john"
+		lvBuild.Spec.BuildType = jcrsv1.Build
+		Expect(r.publishAuthorized(context.Background(), lvBuild)).To(BeTrue())
+
+		lvBuild.Spec.BuildType = jcrsv1.BuildPublish
+		lvBuild.Spec.PackageName = ptr.To("docs")
+		Expect(r.publishAuthorized(context.Background(), lvBuild)).To(BeTrue())
+		Expect(lvBuild.Status.Conditions).To(BeEmpty())
+	})
+
+	It("enqueues the builds publishing a package when its ownership changes", func() {
+		other := lvBuild.DeepCopy()
+		other.Name, other.Spec.PackageName = "api", ptr.To("api")
+		Expect(c.Create(context.Background(), lvBuild)).To(Succeed())
+		Expect(c.Create(context.Background(), other)).To(Succeed())
+
+		requests := r.buildsForPackageOwnership(context.Background(), ownership("web-team", "web"))
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Name).To(Equal("web"))
+	})
+})
@@ -0,0 +1,306 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/promotion"
+)
+
+const (
+	// promotionRetryInterval is how often promotions that failed to copy their
+	// artifact are retried
+	promotionRetryInterval = 5 * time.Minute
+
+	// artifactPromotedReason is the reason of the Event recorded when an
+	// artifact is promoted
+	artifactPromotedReason = "ArtifactPromoted"
+)
+
+/*
+A PackagePromotion copies the artifact of a version published by a LeviathanBuild,
+usually of a dev namespace, to the target registry path, usually the prod one.
+The version is promoted once the latest run of the build succeeded and published
+it, and enough distinct users approved the promotion.
+
+The digest of the version is pinned in the status of the promotion as soon as it
+is seen, so approvers know which artifact they approve, and only that artifact is
+promoted: a version published again with another digest before the promotion
+fails it for good, as it would promote an artifact nobody approved. The
+promoters fetch the source by digest for the same reason.
+
+Approvals are only written through the status subresource. Users can't approve
+promotions unless they're bound to the packagepromotion-approver-role, and the
+webhook of PackagePromotions stamps every new approval with the name of the user
+adding it, so approvals can't be forged nor removed. The spec is immutable, so
+approvals can't be carried over to another artifact either.
+
+A promotion is promoted once: it keeps its Promoted condition afterwards, even
+when the target is changed or deleted outside of the controller. Promoting again
+takes a new promotion, with new approvals.
+*/
+
+// PackagePromotionReconciler promotes the artifacts of PackagePromotions
+type PackagePromotionReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Promoters copy artifacts in each kind of storage. Promotions in a kind of
+	// storage without a Promoter fail.
+	Promoters map[jcrsv1.ArtifactStore]promotion.Promoter
+}
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=packagepromotions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=packagepromotions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile pins the artifact of a PackagePromotion, counts its approvals and
+// promotes the artifact once they are all given.
+func (r *PackagePromotionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var promo jcrsv1.PackagePromotion
+	if err := r.Get(ctx, req.NamespacedName, &promo); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if promotionFinished(&promo) {
+		return ctrl.Result{}, nil
+	}
+	status := promo.Status.DeepCopy()
+	approved := setApproved(&promo, status)
+
+	result := ctrl.Result{}
+	ready, err := r.pinSource(ctx, &promo, status)
+	if err != nil {
+		log.Error(err, "Failed to get the source of PackagePromotion")
+		return ctrl.Result{}, err
+	}
+	switch {
+	case !ready:
+	case !approved:
+		setPromoted(&promo, status, metav1.ConditionUnknown, jcrsv1.ReasonPromoting,
+			fmt.Sprintf("Waiting for approvals to promote %s:%s@%s", status.PackageName, status.Version, status.Digest))
+	default:
+		if err := r.promote(ctx, &promo, status); err != nil {
+			log.Error(err, "Failed to promote artifact", "version", status.Version)
+			result.RequeueAfter = promotionRetryInterval
+		}
+	}
+
+	if equality.Semantic.DeepEqual(promo.Status, *status) {
+		return result, nil
+	}
+	promo.Status = *status
+	if err := r.Status().Update(ctx, &promo); err != nil {
+		log.Error(err, "unable to update PackagePromotion status")
+		return ctrl.Result{}, err
+	}
+	if meta.IsStatusConditionTrue(status.Conditions, jcrsv1.ConditionPromoted) && r.Recorder != nil {
+		r.Recorder.Eventf(&promo, corev1.EventTypeNormal, artifactPromotedReason, "Promoted %s:%s@%s to %s",
+			status.PackageName, status.Version, status.Digest, promo.Spec.Target.RegistryURL)
+	}
+	return result, nil
+}
+
+// promotionFinished reports whether promo was promoted, or failed for good.
+func promotionFinished(promo *jcrsv1.PackagePromotion) bool {
+	condition := meta.FindStatusCondition(promo.Status.Conditions, jcrsv1.ConditionPromoted)
+	return condition != nil && (condition.Status == metav1.ConditionTrue || condition.Reason == jcrsv1.ReasonSourceRepublished)
+}
+
+// setApproved sets the Approved condition of promo in status, and reports
+// whether promo has its required approvals.
+func setApproved(promo *jcrsv1.PackagePromotion, status *jcrsv1.PackagePromotionStatus) bool {
+	required := max(promo.Spec.RequiredApprovals, 1)
+	approvers := make(map[string]bool)
+	for _, approval := range status.Approvals {
+		if approval.ApprovedBy != "" {
+			approvers[approval.ApprovedBy] = true
+		}
+	}
+	approvals := int32(len(approvers))
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionApproved,
+		Status:             metav1.ConditionTrue,
+		Reason:             jcrsv1.ReasonApprovalsReceived,
+		Message:            fmt.Sprintf("Approved by %d of %d required users", approvals, required),
+		ObservedGeneration: promo.Generation,
+	}
+	if approvals < required {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = jcrsv1.ReasonAwaitingApproval
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+	return approvals >= required
+}
+
+// setPromoted sets the Promoted condition of promo in status.
+func setPromoted(promo *jcrsv1.PackagePromotion, status *jcrsv1.PackagePromotionStatus, conditionStatus metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionPromoted,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: promo.Generation,
+	})
+}
+
+// pinSource pins the artifact of the source version of promo in status, and
+// reports whether it is ready to be promoted.
+func (r *PackagePromotionReconciler) pinSource(ctx context.Context, promo *jcrsv1.PackagePromotion, status *jcrsv1.PackagePromotionStatus) (bool, error) {
+	source := promo.Spec.Source
+	key := types.NamespacedName{Namespace: source.Namespace, Name: source.Build}
+	if key.Namespace == "" {
+		key.Namespace = promo.Namespace
+	}
+	notReady := func(format string, args ...any) (bool, error) {
+		setPromoted(promo, status, metav1.ConditionUnknown, jcrsv1.ReasonSourceNotReady, fmt.Sprintf(format, args...))
+		return false, nil
+	}
+
+	var lvBuild jcrsv1.LeviathanBuild
+	if err := r.Get(ctx, key, &lvBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return notReady("LeviathanBuild %s not found", key)
+		}
+		return false, err
+	}
+	version := source.Version
+	if version == "" && lvBuild.Spec.PublishTarget != nil {
+		version = lvBuild.Spec.PublishTarget.Version
+	}
+	if version == "" {
+		return notReady("LeviathanBuild %s has no publishTarget, set the version of the source", key)
+	}
+	if !jcrsv1.IsSucceeded(lvBuild.Status.Conditions) {
+		return notReady("The latest run of LeviathanBuild %s hasn't succeeded", key)
+	}
+	var published *jcrsv1.PublishedArtifact
+	for i := range lvBuild.Status.PublishedArtifacts {
+		artifact := &lvBuild.Status.PublishedArtifacts[i]
+		if artifact.Version == version && artifact.Succeeded && (published == nil || published.RunIndex < artifact.RunIndex) {
+			published = artifact
+		}
+	}
+	if published == nil {
+		return notReady("LeviathanBuild %s didn't publish version %s", key, version)
+	}
+
+	if status.Version != "" && (status.Version != version || status.Digest != published.Digest) {
+		setPromoted(promo, status, metav1.ConditionFalse, jcrsv1.ReasonSourceRepublished,
+			fmt.Sprintf("LeviathanBuild %s published %s@%s since the promotion was created, create another promotion to promote it",
+				key, version, published.Digest))
+		return false, nil
+	}
+	status.PackageName = artifactPath(&lvBuild, *published)
+	status.SourceRegistryURL = published.RegistryURL
+	status.Version = version
+	status.Digest = published.Digest
+	return true, nil
+}
+
+// promote copies the pinned artifact of promo to its target, and sets the
+// Promoted condition of promo in status.
+func (r *PackagePromotionReconciler) promote(ctx context.Context, promo *jcrsv1.PackagePromotion, status *jcrsv1.PackagePromotionStatus) error {
+	promoter := r.Promoters[promo.Spec.Store]
+	if promoter == nil {
+		err := fmt.Errorf("artifacts stored in %s aren't supported by this controller", promo.Spec.Store)
+		setPromoted(promo, status, metav1.ConditionFalse, jcrsv1.ReasonPromotionFailed, err.Error())
+		return err
+	}
+	targetVersion := promo.Spec.Target.Version
+	if targetVersion == "" {
+		targetVersion = status.Version
+	}
+	source := promotion.Artifact{
+		RegistryURL: status.SourceRegistryURL,
+		PackageName: status.PackageName,
+		Version:     status.Version,
+		Digest:      status.Digest,
+	}
+	target := promotion.Artifact{RegistryURL: promo.Spec.Target.RegistryURL, PackageName: status.PackageName, Version: targetVersion}
+
+	digest, err := promoter.Promote(ctx, source, target)
+	switch {
+	case errors.Is(err, promotion.ErrDigestMismatch):
+		setPromoted(promo, status, metav1.ConditionFalse, jcrsv1.ReasonSourceRepublished,
+			fmt.Sprintf("The artifact of %s:%s isn't the approved one anymore: %v", status.PackageName, status.Version, err))
+		return nil
+	case err != nil:
+		setPromoted(promo, status, metav1.ConditionFalse, jcrsv1.ReasonPromotionFailed,
+			fmt.Sprintf("Failed to promote %s:%s: %v", status.PackageName, status.Version, err))
+		return err
+	}
+	status.PromotedDigest = digest
+	status.PromotedAt = ptr.To(metav1.Now())
+	setPromoted(promo, status, metav1.ConditionTrue, jcrsv1.ReasonArtifactPromoted,
+		fmt.Sprintf("Promoted %s:%s to %s:%s", status.PackageName, status.Version, promo.Spec.Target.RegistryURL, targetVersion))
+	return nil
+}
+
+// promotionsForBuild maps a LeviathanBuild to the unfinished PackagePromotions
+// of its artifacts.
+func (r *PackagePromotionReconciler) promotionsForBuild(ctx context.Context, obj client.Object) []reconcile.Request {
+	var promotions jcrsv1.PackagePromotionList
+	if err := r.List(ctx, &promotions); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list PackagePromotions")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range promotions.Items {
+		promo := &promotions.Items[i]
+		namespace := promo.Spec.Source.Namespace
+		if namespace == "" {
+			namespace = promo.Namespace
+		}
+		if namespace == obj.GetNamespace() && promo.Spec.Source.Build == obj.GetName() && !promotionFinished(promo) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(promo)})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PackagePromotionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jcrsv1.PackagePromotion{}).
+		Watches(&jcrsv1.LeviathanBuild{}, handler.EnqueueRequestsFromMapFunc(r.promotionsForBuild)).
+		Named("packagepromotion").
+		Complete(r)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/promotion"
+)
+
+// fakePromoter records the artifacts it promotes.
+type fakePromoter struct {
+	promoted []string
+	err      error
+}
+
+func (p *fakePromoter) Promote(_ context.Context, source, target promotion.Artifact) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	p.promoted = append(p.promoted, source.RegistryURL+"/"+source.PackageName+":"+source.Version+"@"+source.Digest+
+		" -> "+target.RegistryURL+"/"+target.PackageName+":"+target.Version)
+	return source.Digest, nil
+}
+
+var _ = Describe("PackagePromotion Controller", func() {
+	var (
+		ctx      context.Context
+		c        client.Client
+		promoter *fakePromoter
+		r        *PackagePromotionReconciler
+		lvBuild  *jcrsv1.LeviathanBuild
+		promo    *jcrsv1.PackagePromotion
+	)
+
+	// reconcile reconciles promo and returns it
+	reconcile := func() *jcrsv1.PackagePromotion {
+		GinkgoHelper()
+		_, _ = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(promo)})
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(promo), promo)).To(Succeed())
+		return promo
+	}
+	// condition returns the reason of the condition of promo of type conditionType
+	condition := func(conditionType string) string {
+		GinkgoHelper()
+		condition := meta.FindStatusCondition(promo.Status.Conditions, conditionType)
+		Expect(condition).NotTo(BeNil())
+		return string(condition.Status) + "/" + condition.Reason
+	}
+	// approve adds the approvals of users to promo
+	approve := func(users ...string) {
+		GinkgoHelper()
+		for _, user := range users {
+			promo.Status.Approvals = append(promo.Status.Approvals, jcrsv1.PromotionApproval{ApprovedBy: user, ApprovedAt: metav1.Now()})
+		}
+		Expect(c.Status().Update(ctx, promo)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev"},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName:   ptr.To("web"),
+				PublishTarget: &jcrsv1.PublishTarget{RegistryURL: "registry.example.com/dev", Version: "1.2.3"},
+			},
+			Status: jcrsv1.LeviathanBuildStatus{
+				Conditions: []metav1.Condition{{Type: jcrsv1.ConditionSucceeded, Status: metav1.ConditionTrue, Reason: jcrsv1.ReasonJobComplete}},
+				PublishedArtifacts: []jcrsv1.PublishedArtifact{
+					{RegistryURL: "registry.example.com/dev", Version: "1.2.3", Digest: "sha256:old", RunIndex: 1, Succeeded: false},
+					{RegistryURL: "registry.example.com/dev", Version: "1.2.3", Digest: "sha256:0123", RunIndex: 2, Succeeded: true},
+				},
+			},
+		}
+		promo = &jcrsv1.PackagePromotion{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1.2.3", Namespace: "prod"},
+			Spec: jcrsv1.PackagePromotionSpec{
+				Source:            jcrsv1.PromotionSource{Namespace: "dev", Build: "web"},
+				Target:            jcrsv1.PromotionTarget{RegistryURL: "registry.example.com/prod"},
+				Store:             jcrsv1.OCIArtifactStore,
+				RequiredApprovals: 2,
+			},
+		}
+		c = newFakeClient(lvBuild, promo)
+		promoter = &fakePromoter{}
+		r = &PackagePromotionReconciler{
+			Client: c, Scheme: c.Scheme(), Recorder: record.NewFakeRecorder(10),
+			Promoters: map[jcrsv1.ArtifactStore]promotion.Promoter{jcrsv1.OCIArtifactStore: promoter},
+		}
+	})
+
+	It("promotes the pinned artifact once it has its approvals", func() {
+		reconcile()
+		Expect(condition(jcrsv1.ConditionApproved)).To(Equal("False/AwaitingApproval"))
+		Expect(condition(jcrsv1.ConditionPromoted)).To(Equal("Unknown/Promoting"))
+		Expect(promo.Status.Version).To(Equal("1.2.3"))
+		Expect(promo.Status.Digest).To(Equal("sha256:0123"))
+
+		By("waiting for distinct users to approve")
+		approve("alice")
+		reconcile()
+		Expect(condition(jcrsv1.ConditionApproved)).To(Equal("False/AwaitingApproval"))
+		Expect(promoter.promoted).To(BeEmpty())
+
+		approve("bob")
+		reconcile()
+		Expect(condition(jcrsv1.ConditionApproved)).To(Equal("True/ApprovalsReceived"))
+		Expect(condition(jcrsv1.ConditionPromoted)).To(Equal("True/ArtifactPromoted"))
+		Expect(promoter.promoted).To(Equal([]string{
+			"registry.example.com/dev/web:1.2.3@sha256:0123 -> registry.example.com/prod/web:1.2.3",
+		}))
+		Expect(promo.Status.PromotedDigest).To(Equal("sha256:0123"))
+		Expect(promo.Status.PromotedAt).NotTo(BeNil())
+
+		By("promoting the artifact only once")
+		reconcile()
+		Expect(promoter.promoted).To(HaveLen(1))
+		Expect(r.promotionsForBuild(ctx, lvBuild)).To(BeEmpty())
+	})
+
+	It("waits for the source build to succeed", func() {
+		lvBuild.Status.Conditions[0].Status = metav1.ConditionUnknown
+		Expect(c.Status().Update(ctx, lvBuild)).To(Succeed())
+		reconcile()
+		Expect(condition(jcrsv1.ConditionPromoted)).To(Equal("Unknown/SourceNotReady"))
+		Expect(promo.Status.Digest).To(BeEmpty())
+		Expect(r.promotionsForBuild(ctx, lvBuild)).To(HaveLen(1))
+	})
+
+	It("fails for good when the version is published again before it is promoted", func() {
+		reconcile()
+		lvBuild.Status.PublishedArtifacts = append(lvBuild.Status.PublishedArtifacts,
+			jcrsv1.PublishedArtifact{RegistryURL: "registry.example.com/dev", Version: "1.2.3", Digest: "sha256:4567", RunIndex: 3, Succeeded: true})
+		Expect(c.Status().Update(ctx, lvBuild)).To(Succeed())
+		approve("alice", "bob")
+		reconcile()
+		Expect(condition(jcrsv1.ConditionPromoted)).To(Equal("False/SourceRepublished"))
+		Expect(promoter.promoted).To(BeEmpty())
+		Expect(promo.Status.Digest).To(Equal("sha256:0123"))
+	})
+
+	It("reports the failures to promote the artifact", func() {
+		promoter.err = promotion.ErrDigestMismatch
+		approve("alice", "bob")
+		reconcile()
+		Expect(condition(jcrsv1.ConditionPromoted)).To(Equal("False/SourceRepublished"))
+
+		By("failing promotions in storage without a promoter")
+		r.Promoters = nil
+		promo.Status = jcrsv1.PackagePromotionStatus{}
+		Expect(c.Status().Update(ctx, promo)).To(Succeed())
+		approve("alice", "bob")
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(promo)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(promotionRetryInterval))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(promo), promo)).To(Succeed())
+		Expect(condition(jcrsv1.ConditionPromoted)).To(Equal("False/PromotionFailed"))
+	})
+})
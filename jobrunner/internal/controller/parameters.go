@@ -0,0 +1,224 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/rerun"
+)
+
+/*
+The parameters of a build are the keys of the ConfigMaps and Secrets named by
+spec.parametersFrom. They are bound late: the keys are read when the Job of a run
+is constructed, and set on the build containers as environment variables referring
+to their source, so the kubelet reads the values when the pods start. Parameter
+values never go through the controller, and are never written to the Job, the
+logs or the status of the build; the build environment only records literal
+values.
+
+A run isn't started while a source that isn't optional is missing, which is
+recorded as the InvalidJobTemplate condition. The sources are watched, so the
+build is reconciled again as soon as they are created or change. Adding or
+removing keys changes the Job of the build, which replaces a running Job like
+any other change to the build; new values of existing keys are picked up by the
+next run.
+
+A manual run may override the values of some parameters, recorded on the build
+created for it (see the rerun package). Only declared parameters, keys of the
+sources, can be overridden: an override of any other name is reported like a
+missing source, so a typo doesn't silently build with the default value. The
+overrides are literal values set on the Job, and recorded in the build
+environment like any literal value, so they must not be secrets.
+*/
+
+const (
+	// parametersSourceKey indexes LeviathanBuilds by the ConfigMaps and Secrets of their parameters
+	parametersSourceKey = ".spec.parametersFrom"
+
+	configMapKind = "ConfigMap"
+	secretKind    = "Secret"
+)
+
+// parametersSourceIndexValue is the value of parametersSourceKey for the object of kind named name.
+func parametersSourceIndexValue(kind, name string) string {
+	return kind + "/" + name
+}
+
+// indexParametersSources is the index function for parametersSourceKey.
+func indexParametersSources(rawObj client.Object) []string {
+	lvBuild := rawObj.(*jcrsv1.LeviathanBuild)
+	var values []string
+	for _, source := range lvBuild.Spec.ParametersFrom {
+		switch {
+		case source.ConfigMapRef != nil:
+			values = append(values, parametersSourceIndexValue(configMapKind, source.ConfigMapRef.Name))
+		case source.SecretRef != nil:
+			values = append(values, parametersSourceIndexValue(secretKind, source.SecretRef.Name))
+		}
+	}
+	return values
+}
+
+// parametersError reports sources of parameters that are missing, and overrides
+// of parameters that aren't declared.
+type parametersError struct {
+	missing    []string
+	undeclared []string
+}
+
+func (e *parametersError) Error() string {
+	var msgs []string
+	if len(e.missing) > 0 {
+		msgs = append(msgs, "parameters sources not found: "+strings.Join(e.missing, ", "))
+	}
+	if len(e.undeclared) > 0 {
+		msgs = append(msgs, "overridden parameters not declared: "+strings.Join(e.undeclared, ", "))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// resolveParameters returns the environment variables of the parameters of lvBuild,
+// sorted by name, with the values of its overrides. Missing sources that aren't
+// optional and overrides of undeclared parameters are reported as a
+// *parametersError.
+func (r *LeviathanBuildReconciler) resolveParameters(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) ([]corev1.EnvVar, error) {
+	overrides, err := rerun.ParameterOverrides(lvBuild)
+	if err != nil {
+		return nil, err
+	}
+	params := make(map[string]corev1.EnvVar)
+	var missing []string
+	for _, source := range lvBuild.Spec.ParametersFrom {
+		var (
+			keys []string
+			ref  func(key string) *corev1.EnvVarSource
+			err  error
+			name string
+		)
+		switch {
+		case source.ConfigMapRef != nil:
+			name = parametersSourceIndexValue(configMapKind, source.ConfigMapRef.Name)
+			var configMap corev1.ConfigMap
+			err = r.Get(ctx, types.NamespacedName{Namespace: lvBuild.Namespace, Name: source.ConfigMapRef.Name}, &configMap)
+			for key := range configMap.Data {
+				keys = append(keys, key)
+			}
+			ref = func(key string) *corev1.EnvVarSource {
+				return &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: *source.ConfigMapRef, Key: key, Optional: ptr.To(source.Optional),
+				}}
+			}
+		case source.SecretRef != nil:
+			name = parametersSourceIndexValue(secretKind, source.SecretRef.Name)
+			var secret corev1.Secret
+			err = r.Get(ctx, types.NamespacedName{Namespace: lvBuild.Namespace, Name: source.SecretRef.Name}, &secret)
+			for key := range secret.Data {
+				keys = append(keys, key)
+			}
+			ref = func(key string) *corev1.EnvVarSource {
+				return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: *source.SecretRef, Key: key, Optional: ptr.To(source.Optional),
+				}}
+			}
+		default:
+			continue
+		}
+		if apierrors.IsNotFound(err) {
+			if !source.Optional {
+				missing = append(missing, name)
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			if len(validation.IsEnvVarName(key)) == 0 {
+				params[key] = corev1.EnvVar{Name: key, ValueFrom: ref(key)}
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &parametersError{missing: missing}
+	}
+	var undeclared []string
+	for name, value := range overrides {
+		if _, ok := params[name]; !ok {
+			undeclared = append(undeclared, name)
+			continue
+		}
+		params[name] = corev1.EnvVar{Name: name, Value: value}
+	}
+	if len(undeclared) > 0 {
+		slices.Sort(undeclared)
+		return nil, &parametersError{undeclared: undeclared}
+	}
+
+	env := make([]corev1.EnvVar, 0, len(params))
+	for _, param := range params {
+		env = append(env, param)
+	}
+	slices.SortFunc(env, func(a, b corev1.EnvVar) int { return strings.Compare(a.Name, b.Name) })
+	return env, nil
+}
+
+// addParameters sets the parameters of a build on the build containers of its
+// Job. They come before the env of the containers, which may refer to them and
+// takes precedence over them.
+func addParameters(job *batchv1.Job, params []corev1.EnvVar) {
+	if len(params) == 0 {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		c.Env = append(slices.Clone(params), c.Env...)
+	}
+}
+
+// buildsForParametersSource maps a ConfigMap or a Secret to the LeviathanBuilds
+// of its namespace whose parameters it holds.
+func (r *LeviathanBuildReconciler) buildsForParametersSource(kind string) func(context.Context, client.Object) []reconcile.Request {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		var builds jcrsv1.LeviathanBuildList
+		if err := r.List(ctx, &builds, client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{parametersSourceKey: parametersSourceIndexValue(kind, obj.GetName())}); err != nil {
+			logf.FromContext(ctx).Error(err, "Unable to list LeviathanBuilds", "source", fmt.Sprintf("%s/%s", kind, obj.GetName()))
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(builds.Items))
+		for _, lvBuild := range builds.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&lvBuild)})
+		}
+		return requests
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/rerun"
+)
+
+var _ = Describe("Build parameters", func() {
+	var (
+		ctx     context.Context
+		lvBuild *jcrsv1.LeviathanBuild
+		r       *LeviathanBuildReconciler
+		c       client.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: jcrsv1.LeviathanBuildSpec{ParametersFrom: []jcrsv1.ParametersSource{
+				{ConfigMapRef: &corev1.LocalObjectReference{Name: "build-config"}},
+				{SecretRef: &corev1.LocalObjectReference{Name: "build-secrets"}},
+				{ConfigMapRef: &corev1.LocalObjectReference{Name: "overrides"}, Optional: true},
+			}},
+		}
+		c = newFakeClientBuilder().
+			WithIndex(&jcrsv1.LeviathanBuild{}, parametersSourceKey, indexParametersSources).
+			WithObjects(
+				lvBuild,
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "build-config", Namespace: "default"},
+					Data:       map[string]string{"GOFLAGS": "-mod=mod", "REGISTRY": "registry.corp", "not a name": "x"},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "build-secrets", Namespace: "default"},
+					Data:       map[string][]byte{"REGISTRY": []byte("registry.internal"), "TOKEN": []byte("s3cr3t")},
+				},
+			).Build()
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+	})
+
+	It("refers to the keys of the sources, later sources taking precedence", func() {
+		env, err := r.resolveParameters(ctx, lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		configMapRef := corev1.LocalObjectReference{Name: "build-config"}
+		secretRef := corev1.LocalObjectReference{Name: "build-secrets"}
+		Expect(env).To(Equal([]corev1.EnvVar{
+			{Name: "GOFLAGS", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: configMapRef, Key: "GOFLAGS", Optional: ptr.To(false)}}},
+			{Name: "REGISTRY", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: secretRef, Key: "REGISTRY", Optional: ptr.To(false)}}},
+			{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: secretRef, Key: "TOKEN", Optional: ptr.To(false)}}},
+		}))
+
+		By("setting them before the env of the build containers")
+		job := &batchv1.Job{}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{
+			Name: "build",
+			Env:  []corev1.EnvVar{{Name: "IMAGE", Value: "$(REGISTRY)/web"}},
+		}}
+		addParameters(job, env)
+		Expect(job.Spec.Template.Spec.Containers[0].Env).To(HaveLen(4))
+		Expect(job.Spec.Template.Spec.Containers[0].Env[3]).To(Equal(corev1.EnvVar{Name: "IMAGE", Value: "$(REGISTRY)/web"}))
+
+		By("keeping their values out of the build environment")
+		Expect(newBuildEnvironment(lvBuild, job, 1, "").Containers[0].Parameters).To(Equal(map[string]string{"IMAGE": "$(REGISTRY)/web"}))
+	})
+
+	It("reports the missing sources that aren't optional", func() {
+		Expect(c.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "build-secrets", Namespace: "default"}})).To(Succeed())
+		_, err := r.resolveParameters(ctx, lvBuild)
+		Expect(err).To(MatchError("parameters sources not found: Secret/build-secrets"))
+	})
+
+	It("overrides the values of declared parameters for the run", func() {
+		lvBuild.Annotations = map[string]string{rerun.ParameterOverridesAnnotation: `{"REGISTRY":"registry.hotfix"}`}
+		env, err := r.resolveParameters(ctx, lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(env).To(HaveLen(3))
+		Expect(env[1]).To(Equal(corev1.EnvVar{Name: "REGISTRY", Value: "registry.hotfix"}))
+
+		By("reporting overrides of parameters that aren't declared")
+		lvBuild.Annotations[rerun.ParameterOverridesAnnotation] = `{"REGISTRY":"registry.hotfix","VERSION":"1.2.3","CHANNEL":"beta"}`
+		_, err = r.resolveParameters(ctx, lvBuild)
+		Expect(err).To(MatchError("overridden parameters not declared: CHANNEL, VERSION"))
+		Expect(errors.As(err, new(*parametersError))).To(BeTrue())
+	})
+
+	It("reconciles the builds using a source when it changes", func() {
+		mapFunc := r.buildsForParametersSource(secretKind)
+		Expect(mapFunc(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "build-secrets", Namespace: "default"}})).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "web"}}))
+		Expect(r.buildsForParametersSource(configMapKind)(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "overrides", Namespace: "default"}})).To(HaveLen(1))
+
+		By("ignoring objects of the same name in other namespaces, or of another kind")
+		Expect(mapFunc(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "build-secrets", Namespace: "other"}})).To(BeEmpty())
+		Expect(mapFunc(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "build-config", Namespace: "default"}})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"slices"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+Credentials set through parametersFrom or the jobTemplate are seen by every
+container of the Job: the fetcher could push to the registry, and the build
+tools of any step could read the token of the source. The credentials of a
+build are instead given to the containers of the phase needing them only:
+
+  - the source credentials to the fetch init container and the steps of the
+    Fetch phase, or to the containers of the jobTemplate when the source isn't
+    fetched by the controller;
+  - the registry credentials to the steps of the Publish phase, or to the
+    containers of the jobTemplate of builds that publish.
+
+The steps of a pipeline are separate containers, which only share the files of
+the workspace volume; it is added to every pipeline, whether or not the source
+is fetched by the controller. The credentials are added once the steps have
+been given the environment of the build container, so they aren't copied to
+the steps of other phases.
+*/
+
+// addPipelineWorkspace shares the workspace volume between the steps of the
+// pipeline of lvBuild.
+func addPipelineWorkspace(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if !featuregates.Enabled(featuregates.PhaseCredentials) || len(pipelineStepNames(lvBuild)) == 0 {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	addVolume(podSpec, corev1.Volume{
+		Name:         workspaceVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	addVolumeMount(podSpec, corev1.VolumeMount{Name: workspaceVolumeName, MountPath: workspaceMountPath})
+	setEnv(podSpec, corev1.EnvVar{Name: workspaceEnv, Value: workspaceMountPath})
+}
+
+// credentialContainers returns the names of the containers of the Job of
+// lvBuild given its source and its registry credentials.
+func credentialContainers(lvBuild *jcrsv1.LeviathanBuild) (source, registry []string) {
+	if needsFetch(lvBuild) {
+		source = append(source, fetchContainerName)
+	}
+	if len(pipelineStepNames(lvBuild)) > 0 {
+		for _, step := range lvBuild.Status.Pipeline.Steps {
+			switch step.Phase {
+			case jcrsv1.FetchPhase:
+				source = append(source, step.Name)
+			case jcrsv1.PublishPhase:
+				registry = append(registry, step.Name)
+			}
+		}
+		return source, registry
+	}
+	for _, c := range lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers {
+		if !needsFetch(lvBuild) {
+			source = append(source, c.Name)
+		}
+		registry = append(registry, c.Name)
+	}
+	return source, registry
+}
+
+// addPhaseCredentials gives the credentials of lvBuild to the containers of the
+// phases needing them.
+func addPhaseCredentials(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	credentials := lvBuild.Spec.Credentials
+	if !featuregates.Enabled(featuregates.PhaseCredentials) || credentials == nil {
+		return
+	}
+	source, registry := credentialContainers(lvBuild)
+	podSpec := &job.Spec.Template.Spec
+	if credentials.Source != nil {
+		addCredentials(podSpec, *credentials.Source, source)
+	}
+	if credentials.Registry != nil && publishes(lvBuild.Spec.BuildType) {
+		addCredentials(podSpec, *credentials.Registry, registry)
+	}
+}
+
+// addCredentials sets the keys of the Secret secret as environment variables of
+// the containers of podSpec named names.
+func addCredentials(podSpec *corev1.PodSpec, secret corev1.LocalObjectReference, names []string) {
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			c := &containers[i]
+			if !slices.Contains(names, c.Name) || slices.ContainsFunc(c.EnvFrom, func(envFrom corev1.EnvFromSource) bool {
+				return envFrom.SecretRef != nil && envFrom.SecretRef.Name == secret.Name
+			}) {
+				continue
+			}
+			c.EnvFrom = append(c.EnvFrom, corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: secret}})
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+var _ = Describe("Phase credentials", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		r       *LeviathanBuildReconciler
+	)
+
+	sourceSecret := corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "git-token"}}}
+	registrySecret := corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "registry"}}}
+
+	// containers returns the containers of podSpec by name.
+	containers := func(podSpec corev1.PodSpec) map[string]corev1.Container {
+		byName := make(map[string]corev1.Container)
+		for _, c := range append(podSpec.InitContainers, podSpec.Containers...) {
+			byName[c.Name] = c
+		}
+		return byName
+	}
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{
+			featuregates.PhaseCredentials: true, featuregates.BuildTypeDefinitions: true,
+		})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{
+				featuregates.PhaseCredentials: false, featuregates.BuildTypeDefinitions: false,
+			})).To(Succeed())
+		})
+
+		lvBuild, r = benchmarkBuild()
+		lvBuild.Spec.BuildType = jcrsv1.BuildPublish
+		lvBuild.Spec.Credentials = &jcrsv1.BuildCredentials{
+			Source:   &corev1.LocalObjectReference{Name: "git-token"},
+			Registry: &corev1.LocalObjectReference{Name: "registry"},
+		}
+	})
+
+	It("gives each phase of a pipeline its own credentials and a shared workspace", func() {
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = nil
+		lvBuild.Status.Pipeline = &jcrsv1.ResolvedPipeline{Definition: "build-publish", Steps: []jcrsv1.BuildStep{
+			{Name: "clone", Phase: jcrsv1.FetchPhase, Image: "git"},
+			{Name: "compile", Phase: jcrsv1.BuildPhase},
+			{Name: "push", Phase: jcrsv1.PublishPhase, Image: "pusher"},
+			{Name: "notify", Phase: jcrsv1.PostPhase, Image: "notifier"},
+		}}
+
+		job, err := r.constructJob(lvBuild, []corev1.EnvVar{{Name: "TARGET", Value: "all"}}, false)
+		Expect(err).NotTo(HaveOccurred())
+		byName := containers(job.Spec.Template.Spec)
+		Expect(byName["clone"].EnvFrom).To(ConsistOf(sourceSecret))
+		Expect(byName["compile"].EnvFrom).To(BeEmpty())
+		Expect(byName["push"].EnvFrom).To(ConsistOf(registrySecret))
+		Expect(byName["notify"].EnvFrom).To(BeEmpty())
+		Expect(byName["docker"].EnvFrom).To(BeEmpty())
+
+		for _, name := range []string{"clone", "compile", "push", "notify"} {
+			Expect(byName[name].VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: workspaceVolumeName, MountPath: workspaceMountPath}), name)
+			Expect(byName[name].Env).To(ContainElement(corev1.EnvVar{Name: "TARGET", Value: "all"}), name)
+		}
+		Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("Name", workspaceVolumeName)))
+	})
+
+	It("keeps the source credentials of a build fetched by the controller in the fetch init container", func() {
+		lvBuild.Spec.Source = &jcrsv1.SourceSpec{Git: &jcrsv1.GitSourceSpec{}}
+
+		job, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		byName := containers(job.Spec.Template.Spec)
+		Expect(byName[fetchContainerName].EnvFrom).To(ConsistOf(sourceSecret))
+		Expect(byName["build"].EnvFrom).To(ConsistOf(registrySecret))
+		Expect(byName["docker"].EnvFrom).To(BeEmpty())
+
+		By("not giving the registry credentials to builds that don't publish")
+		lvBuild.Spec.BuildType = jcrsv1.Build
+		job, err = r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(containers(job.Spec.Template.Spec)["build"].EnvFrom).To(BeEmpty())
+	})
+
+	It("leaves the Job alone with the feature disabled", func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PhaseCredentials: false})).To(Succeed())
+		job, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		for _, c := range containers(job.Spec.Template.Spec) {
+			Expect(c.EnvFrom).To(BeEmpty())
+		}
+	})
+})
@@ -0,0 +1,287 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+Builds whose jobTemplate doesn't set containers run the steps of the
+BuildTypeDefinition of their buildType. The definition is resolved on every
+reconcile, and its steps are ordered and rendered into status.pipeline, which
+the Job is constructed from: as with builder images, a change to the definition
+changes the desired Job, which is re-rolled.
+
+The last step runs as the container of the Job, and the steps before it as init
+containers, in order. The steps run with the environment, mounts and working
+directory of the build container, so they share the workspace and parameters
+added for the build whatever their phase. The credentials of the build are the
+exception: with PhaseCredentials, each is only given to the steps of its phase.
+
+To know which builds to reconcile when a definition changes, builds running a
+pipeline are indexed by their buildType, and by the definition they last
+resolved.
+*/
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=buildtypedefinitions,verbs=get;list;watch
+
+const (
+	// pipelineKey indexes the LeviathanBuilds running a pipeline by their buildType and definition
+	pipelineKey = ".spec.buildType.pipeline"
+	// pipelineDefinitionPrefix prefixes the index values of the definitions of pipelines
+	pipelineDefinitionPrefix = "definition/"
+)
+
+// stepPhases are the phases of the steps of a pipeline, in the order they run.
+var stepPhases = []jcrsv1.BuildStepPhase{
+	jcrsv1.FetchPhase,
+	jcrsv1.PrePhase,
+	jcrsv1.BuildPhase,
+	jcrsv1.TestPhase,
+	jcrsv1.PublishPhase,
+	jcrsv1.PostPhase,
+}
+
+// buildTypeOf returns the buildType of lvBuild, Build when unset.
+func buildTypeOf(lvBuild *jcrsv1.LeviathanBuild) jcrsv1.BuildType {
+	if lvBuild.Spec.BuildType == "" {
+		return jcrsv1.Build
+	}
+	return lvBuild.Spec.BuildType
+}
+
+// usesPipeline reports whether lvBuild runs the steps of a BuildTypeDefinition.
+func usesPipeline(lvBuild *jcrsv1.LeviathanBuild) bool {
+	return featuregates.Enabled(featuregates.BuildTypeDefinitions) && len(lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers) == 0
+}
+
+// stepValues are the values the commands and args of steps are rendered with.
+type stepValues struct {
+	Name        string
+	Namespace   string
+	PackageName string
+	Language    string
+	BuildType   jcrsv1.BuildType
+	SourceURL   string
+	Version     string
+}
+
+// renderStepValues renders every item of items with values.
+func renderStepValues(items []string, values stepValues) ([]string, error) {
+	if items == nil {
+		return nil, nil
+	}
+	rendered := make([]string, 0, len(items))
+	for _, item := range items {
+		tmpl, err := template.New("step").Option("missingkey=error").Parse(item)
+		if err != nil {
+			return nil, err
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, values); err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, out.String())
+	}
+	return rendered, nil
+}
+
+// resolvePipeline returns the pipeline of lvBuild from the first of definitions,
+// by name, declaring its buildType, nil when there is none.
+func resolvePipeline(definitions []jcrsv1.BuildTypeDefinition, lvBuild *jcrsv1.LeviathanBuild) (*jcrsv1.ResolvedPipeline, error) {
+	buildType := buildTypeOf(lvBuild)
+	var definition *jcrsv1.BuildTypeDefinition
+	for i := range definitions {
+		if definitions[i].Spec.BuildType == buildType && (definition == nil || definitions[i].Name < definition.Name) {
+			definition = &definitions[i]
+		}
+	}
+	if definition == nil {
+		return nil, nil
+	}
+
+	values := stepValues{
+		Name:        lvBuild.Name,
+		Namespace:   lvBuild.Namespace,
+		PackageName: ptr.Deref(lvBuild.Spec.PackageName, ""),
+		Language:    ptr.Deref(lvBuild.Spec.Language, ""),
+		BuildType:   buildType,
+		SourceURL:   ptr.Deref(lvBuild.Spec.SourceURL, ""),
+	}
+	if lvBuild.Spec.PublishTarget != nil {
+		values.Version = lvBuild.Spec.PublishTarget.Version
+	}
+	steps := slices.Clone(definition.Spec.Steps)
+	slices.SortStableFunc(steps, func(a, b jcrsv1.BuildStep) int {
+		return slices.Index(stepPhases, a.Phase) - slices.Index(stepPhases, b.Phase)
+	})
+	for i := range steps {
+		step := &steps[i]
+		var err error
+		if step.Command, err = renderStepValues(step.Command, values); err != nil {
+			return nil, fmt.Errorf("command of step %s of BuildTypeDefinition %s: %w", step.Name, definition.Name, err)
+		}
+		if step.Args, err = renderStepValues(step.Args, values); err != nil {
+			return nil, fmt.Errorf("args of step %s of BuildTypeDefinition %s: %w", step.Name, definition.Name, err)
+		}
+	}
+	return &jcrsv1.ResolvedPipeline{Definition: definition.Name, Steps: steps}, nil
+}
+
+// reconcilePipeline resolves the pipeline of lvBuild and records it in the
+// status. It reports whether the Job can be constructed: builds without
+// containers wait until a BuildTypeDefinition provides their steps.
+func (r *LeviathanBuildReconciler) reconcilePipeline(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (bool, error) {
+	if !usesPipeline(lvBuild) {
+		lvBuild.Status.Pipeline = nil
+		return true, nil
+	}
+
+	var definitions jcrsv1.BuildTypeDefinitionList
+	if err := r.List(ctx, &definitions); err != nil {
+		return false, err
+	}
+	pipeline, err := resolvePipeline(definitions.Items, lvBuild)
+	if err == nil && pipeline == nil {
+		err = fmt.Errorf("no BuildTypeDefinition declares the steps of buildType %s", buildTypeOf(lvBuild))
+	}
+	if err != nil {
+		lvBuild.Status.Pipeline = nil
+		setInvalidJobTemplate(lvBuild, jcrsv1.ReasonPipelineUnresolved, err)
+		return false, nil
+	}
+	lvBuild.Status.Pipeline = pipeline
+	return true, nil
+}
+
+// pipelineStepNames returns the names of the steps of the pipeline of lvBuild.
+func pipelineStepNames(lvBuild *jcrsv1.LeviathanBuild) []string {
+	if lvBuild.Status.Pipeline == nil {
+		return nil
+	}
+	names := make([]string, 0, len(lvBuild.Status.Pipeline.Steps))
+	for _, step := range lvBuild.Status.Pipeline.Steps {
+		names = append(names, step.Name)
+	}
+	return names
+}
+
+// addPipeline adds the steps of the pipeline of lvBuild to job: the last as its
+// container, the others as init containers after those of the jobTemplate.
+func addPipeline(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if lvBuild.Status.Pipeline == nil || len(lvBuild.Status.Pipeline.Steps) == 0 {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	steps := lvBuild.Status.Pipeline.Steps
+	for i, step := range steps {
+		c := corev1.Container{
+			Name:    step.Name,
+			Image:   step.Image,
+			Command: slices.Clone(step.Command),
+			Args:    slices.Clone(step.Args),
+		}
+		if i == len(steps)-1 {
+			podSpec.Containers = []corev1.Container{c}
+		} else {
+			podSpec.InitContainers = append(podSpec.InitContainers, c)
+		}
+	}
+}
+
+// finishPipeline gives the init containers of the steps of the pipeline of lvBuild
+// the environment, mounts and working directory of the build container, the last
+// step, once everything added to the build has been. The steps are moved after
+// the other init containers, so they run once the sources are fetched and with
+// the native sidecars started.
+func finishPipeline(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	names := pipelineStepNames(lvBuild)
+	if len(names) < 2 {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	i := slices.IndexFunc(podSpec.Containers, func(c corev1.Container) bool { return c.Name == names[len(names)-1] })
+	if i < 0 {
+		return
+	}
+	build := podSpec.Containers[i]
+	initContainers := make([]corev1.Container, 0, len(podSpec.InitContainers))
+	var steps []corev1.Container
+	for _, c := range podSpec.InitContainers {
+		if !slices.Contains(names[:len(names)-1], c.Name) {
+			initContainers = append(initContainers, c)
+			continue
+		}
+		c.Env = slices.Clone(build.Env)
+		c.EnvFrom = slices.Clone(build.EnvFrom)
+		c.VolumeMounts = slices.Clone(build.VolumeMounts)
+		c.WorkingDir = build.WorkingDir
+		steps = append(steps, c)
+	}
+	podSpec.InitContainers = append(initContainers, steps...)
+}
+
+// indexPipeline is the index function for pipelineKey.
+func indexPipeline(rawObj client.Object) []string {
+	lvBuild := rawObj.(*jcrsv1.LeviathanBuild)
+	var values []string
+	if len(lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers) == 0 {
+		values = append(values, string(buildTypeOf(lvBuild)))
+	}
+	if lvBuild.Status.Pipeline != nil {
+		values = append(values, pipelineDefinitionPrefix+lvBuild.Status.Pipeline.Definition)
+	}
+	return values
+}
+
+// buildsForBuildTypeDefinition maps a changed BuildTypeDefinition to the builds
+// whose pipeline may change: the builds of its buildType running a pipeline, and
+// the builds whose pipeline was resolved from it.
+func (r *LeviathanBuildReconciler) buildsForBuildTypeDefinition(ctx context.Context, obj client.Object) []reconcile.Request {
+	definition := obj.(*jcrsv1.BuildTypeDefinition)
+	var requests []reconcile.Request
+	for _, key := range []string{string(definition.Spec.BuildType), pipelineDefinitionPrefix + definition.Name} {
+		var builds jcrsv1.LeviathanBuildList
+		if err := r.List(ctx, &builds, client.MatchingFields{pipelineKey: key}); err != nil {
+			logf.FromContext(ctx).Error(err, "Unable to list LeviathanBuilds", "buildType", key)
+			continue
+		}
+		for _, lvBuild := range builds.Items {
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: lvBuild.Namespace, Name: lvBuild.Name}}
+			if !slices.Contains(requests, request) {
+				requests = append(requests, request)
+			}
+		}
+	}
+	return requests
+}
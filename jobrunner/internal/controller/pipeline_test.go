@@ -0,0 +1,169 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+var _ = Describe("BuildTypeDefinition pipelines", func() {
+	var (
+		definitions []jcrsv1.BuildTypeDefinition
+	)
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.BuildTypeDefinitions: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.BuildTypeDefinitions: false})).To(Succeed())
+		})
+
+		definitions = []jcrsv1.BuildTypeDefinition{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "lint-b"},
+				Spec: jcrsv1.BuildTypeDefinitionSpec{BuildType: "Lint", Steps: []jcrsv1.BuildStep{
+					{Name: "other", Phase: jcrsv1.TestPhase, Image: "other"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "lint-a"},
+				Spec: jcrsv1.BuildTypeDefinitionSpec{BuildType: "Lint", Steps: []jcrsv1.BuildStep{
+					{Name: "report", Phase: jcrsv1.PostPhase, Image: "reporter"},
+					{Name: "lint", Phase: jcrsv1.TestPhase, Args: []string{"lint", "{{ .PackageName }}@{{ .Namespace }}"}},
+					{Name: "deps", Phase: jcrsv1.PrePhase, Image: "deps", Command: []string{"install"}},
+				}},
+			},
+		}
+	})
+
+	newBuild := func(name string, buildType jcrsv1.BuildType) *jcrsv1.LeviathanBuild {
+		return &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To(name), BuildType: buildType},
+		}
+	}
+
+	It("resolves the steps of the first definition by name in phase order", func() {
+		pipeline, err := resolvePipeline(definitions, newBuild("web", "Lint"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pipeline).To(Equal(&jcrsv1.ResolvedPipeline{Definition: "lint-a", Steps: []jcrsv1.BuildStep{
+			{Name: "deps", Phase: jcrsv1.PrePhase, Image: "deps", Command: []string{"install"}},
+			{Name: "lint", Phase: jcrsv1.TestPhase, Args: []string{"lint", "web@default"}},
+			{Name: "report", Phase: jcrsv1.PostPhase, Image: "reporter"},
+		}}))
+		Expect(definitions[1].Spec.Steps[1].Args[1]).To(Equal("{{ .PackageName }}@{{ .Namespace }}"))
+
+		pipeline, err = resolvePipeline(definitions, newBuild("web", ""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pipeline).To(BeNil())
+	})
+
+	It("blocks builds whose steps can't be resolved", func() {
+		c := newFakeClient()
+		r := &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+
+		lvBuild := newBuild("web", "Lint")
+		resolved, err := r.reconcilePipeline(context.Background(), lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved).To(BeFalse())
+		Expect(lvBuild.Status.Conditions).To(ContainElement(And(
+			HaveField("Type", jcrsv1.ConditionInvalidJobTemplate),
+			HaveField("Reason", jcrsv1.ReasonPipelineUnresolved),
+		)))
+
+		definitions[1].Spec.Steps[1].Args = []string{"{{ .Revision }}"}
+		r.Client = newFakeClient(&definitions[1])
+		lvBuild = newBuild("web", "Lint")
+		resolved, err = r.reconcilePipeline(context.Background(), lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved).To(BeFalse())
+		Expect(lvBuild.Status.Pipeline).To(BeNil())
+
+		By("leaving builds with containers alone")
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build", Image: "busybox"}}
+		resolved, err = r.reconcilePipeline(context.Background(), lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved).To(BeTrue())
+	})
+
+	It("runs the steps as init containers sharing the environment of the last", func() {
+		lvBuild, r := benchmarkBuild()
+		lvBuild.Spec.BuildType = "Lint"
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = nil
+		lvBuild.Spec.JobPatches = nil
+		pipeline, err := resolvePipeline(definitions, lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		lvBuild.Status.Pipeline = pipeline
+
+		job, err := r.constructJob(lvBuild, []corev1.EnvVar{{Name: "TARGET", Value: "all"}}, false)
+		Expect(err).NotTo(HaveOccurred())
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.Containers).To(HaveLen(1))
+		report := podSpec.Containers[0]
+		Expect(report.Name).To(Equal("report"))
+		Expect(report.Image).To(Equal("reporter"))
+
+		var names []string
+		for _, c := range podSpec.InitContainers {
+			names = append(names, c.Name)
+		}
+		Expect(names).To(Equal([]string{"docker", "deps", "lint"}))
+		lint := podSpec.InitContainers[2]
+		Expect(lint.Image).To(Equal("builder:1.0"))
+		Expect(lint.Args).To(Equal([]string{"lint", "web@default"}))
+		Expect(lint.Env).To(Equal(report.Env))
+		Expect(lint.Env).To(ContainElement(corev1.EnvVar{Name: "TARGET", Value: "all"}))
+		Expect(lint.VolumeMounts).To(Equal(report.VolumeMounts))
+	})
+
+	It("enqueues the builds a changed definition may affect", func() {
+		resolvedBy := func(lvBuild *jcrsv1.LeviathanBuild, definition string) *jcrsv1.LeviathanBuild {
+			lvBuild.Status.Pipeline = &jcrsv1.ResolvedPipeline{Definition: definition}
+			return lvBuild
+		}
+		withContainers := newBuild("containers", "Lint")
+		withContainers.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build", Image: "busybox"}}
+		c := newFakeClientBuilder().
+			WithObjects(
+				newBuild("lint", "Lint"),
+				resolvedBy(newBuild("moved", "Test"), "lint-a"),
+				newBuild("build", ""),
+				withContainers,
+			).
+			WithIndex(&jcrsv1.LeviathanBuild{}, pipelineKey, indexPipeline).
+			Build()
+		r := &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+
+		request := func(name string) reconcile.Request {
+			return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+		}
+		Expect(r.buildsForBuildTypeDefinition(context.Background(), &definitions[1])).To(ConsistOf(
+			request("lint"), request("moved")))
+		Expect(r.buildsForBuildTypeDefinition(context.Background(), &definitions[0])).To(ConsistOf(
+			request("lint")))
+	})
+})
@@ -0,0 +1,212 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+The pods of a Job are created by the Job controller, long after the Job itself
+was admitted. When the namespace refuses them, because they violate its
+PodSecurity level, exceed a ResourceQuota or fall out of the bounds of a
+LimitRange, the Job never gets a pod: it doesn't fail, and the build would look
+like it runs forever. The only trace is the FailedCreate Events the Job
+controller records on the Job, with the message of the API server.
+
+Those Events are watched, and cached on their own, so a build whose Job has
+never created a pod reports the latest refusal in its PodCreationBlocked
+condition, and is not Ready. Once a pod of the Job is created, the condition is
+set to False. Refusals after the first pod, e.g. when a pod is replaced, are
+left to the backoffLimit of the Job.
+*/
+
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch
+
+const (
+	// jobEventsKey indexes the Events of Jobs by the UID of their Job
+	jobEventsKey = ".involvedObject.uid"
+
+	// failedCreateReason is the reason of the Events the Job controller records
+	// when it can't create the pods of a Job
+	failedCreateReason = "FailedCreate"
+
+	// podCreationBlockedReason is the reason of the Event recorded when the pods
+	// of the latest run of a build are refused
+	podCreationBlockedReason = "PodCreationBlocked"
+)
+
+// JobEventCache returns how Events are cached: only the FailedCreate Events of
+// Jobs, or every Warning Event when they are mirrored on builds.
+func JobEventCache() cache.ByObject {
+	if featuregates.Enabled(featuregates.JobEventMirroring) {
+		return cache.ByObject{Field: fields.OneTermEqualSelector("type", corev1.EventTypeWarning)}
+	}
+	return cache.ByObject{Field: fields.SelectorFromSet(fields.Set{
+		"involvedObject.kind": "Job",
+		"reason":              failedCreateReason,
+	})}
+}
+
+// failedCreateEvents selects the FailedCreate Events of Jobs, should the cache hold other Events.
+var failedCreateEvents = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	event, ok := obj.(*corev1.Event)
+	return ok && event.InvolvedObject.Kind == "Job" && event.Reason == failedCreateReason
+})
+
+// indexJobEvents is the index function for jobEventsKey.
+func indexJobEvents(rawObj client.Object) []string {
+	event := rawObj.(*corev1.Event)
+	if event.InvolvedObject.Kind != "Job" || event.Reason != failedCreateReason {
+		return nil
+	}
+	return []string{string(event.InvolvedObject.UID)}
+}
+
+// createdPods reports whether job has ever had a pod.
+func createdPods(job *batchv1.Job) bool {
+	status := job.Status
+	if status.Active > 0 || status.Succeeded > 0 || status.Failed > 0 || ptr.Deref(status.Ready, 0) > 0 || ptr.Deref(status.Terminating, 0) > 0 {
+		return true
+	}
+	uncounted := status.UncountedTerminatedPods
+	return uncounted != nil && (len(uncounted.Succeeded) > 0 || len(uncounted.Failed) > 0)
+}
+
+// eventTime returns when event was last seen.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// podCreationRefusal returns the reason of PodCreationBlocked for the message of
+// a FailedCreate Event, and the message without its prefix.
+func podCreationRefusal(message string) (string, string) {
+	message = strings.TrimPrefix(message, "Error creating: ")
+	switch {
+	case strings.Contains(message, "violates PodSecurity"):
+		return jcrsv1.ReasonPodSecurity, message
+	case strings.Contains(message, "exceeded quota") || strings.Contains(message, "failed quota"):
+		return jcrsv1.ReasonResourceQuota, message
+	case strings.Contains(message, "usage per Container") || strings.Contains(message, "usage per Pod") ||
+		strings.Contains(message, "limit to request ratio per"):
+		return jcrsv1.ReasonLimitRange, message
+	}
+	return jcrsv1.ReasonAdmissionRejected, message
+}
+
+// checkPodCreation reports in the PodCreationBlocked condition of lvBuild whether
+// the pods of job, the Job of its latest run, are refused.
+func (r *LeviathanBuildReconciler) checkPodCreation(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) error {
+	if !featuregates.Enabled(featuregates.PodCreationChecks) {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionPodCreationBlocked)
+		return nil
+	}
+	blocked := meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionPodCreationBlocked)
+	if createdPods(job) {
+		if blocked {
+			meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+				Type:               jcrsv1.ConditionPodCreationBlocked,
+				Status:             metav1.ConditionFalse,
+				Reason:             jcrsv1.ReasonPodsCreated,
+				Message:            "Job " + job.Name + " created its pods",
+				ObservedGeneration: lvBuild.Generation,
+			})
+		}
+		return nil
+	}
+	if finished, _ := isJobFinished(job); finished {
+		return nil
+	}
+
+	var events corev1.EventList
+	if err := r.List(ctx, &events, client.InNamespace(job.Namespace), client.MatchingFields{jobEventsKey: string(job.UID)}); err != nil {
+		return err
+	}
+	var latest *corev1.Event
+	for i := range events.Items {
+		if latest == nil || eventTime(&events.Items[i]).After(eventTime(latest)) {
+			latest = &events.Items[i]
+		}
+	}
+	if latest == nil {
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionPodCreationBlocked)
+		return nil
+	}
+	reason, message := podCreationRefusal(latest.Message)
+	message = "Job " + job.Name + " can't create its pods: " + message
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionPodCreationBlocked,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: lvBuild.Generation,
+	})
+	if !blocked {
+		r.event(lvBuild, corev1.EventTypeWarning, podCreationBlockedReason, "%s", message)
+	}
+	return nil
+}
+
+// podCreationBlocked returns the PodCreationBlocked condition of lvBuild while it is True.
+func podCreationBlocked(lvBuild *jcrsv1.LeviathanBuild) *metav1.Condition {
+	condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPodCreationBlocked)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return nil
+	}
+	return condition
+}
+
+// buildForJobEvent maps a FailedCreate Event to the build owning its Job.
+func (r *LeviathanBuildReconciler) buildForJobEvent(ctx context.Context, obj client.Object) []reconcile.Request {
+	event, ok := obj.(*corev1.Event)
+	if !ok || event.InvolvedObject.Kind != "Job" {
+		return nil
+	}
+	var job batchv1.Job
+	if err := r.Get(ctx, client.ObjectKey{Namespace: event.InvolvedObject.Namespace, Name: event.InvolvedObject.Name}, &job); err != nil {
+		return nil
+	}
+	owner := metav1.GetControllerOf(&job)
+	if owner == nil || owner.APIVersion != apiGVStr || owner.Kind != "LeviathanBuild" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: job.Namespace, Name: owner.Name}}}
+}
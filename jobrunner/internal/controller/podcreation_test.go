@@ -0,0 +1,130 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Pod creation checks", func() {
+	var (
+		ctx      context.Context
+		recorder *record.FakeRecorder
+		lvBuild  *jcrsv1.LeviathanBuild
+		job      *batchv1.Job
+	)
+
+	failedCreate := func(name, message string, seen time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "team"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Job", Namespace: "team", Name: "web-1", UID: "job-uid"},
+			Reason:         failedCreateReason,
+			Type:           corev1.EventTypeWarning,
+			Message:        message,
+			LastTimestamp:  metav1.NewTime(seen),
+		}
+	}
+
+	reconciler := func(objs ...client.Object) *LeviathanBuildReconciler {
+		c := newFakeClientBuilder().WithObjects(objs...).
+			WithIndex(&corev1.Event{}, jobEventsKey, indexJobEvents).Build()
+		return &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme(), Recorder: recorder}
+	}
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PodCreationChecks: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PodCreationChecks: false})).To(Succeed())
+		})
+
+		ctx = context.Background()
+		recorder = record.NewFakeRecorder(10)
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "team").Obj()
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "team", UID: "job-uid"}}
+	})
+
+	DescribeTable("tells why the pods are refused",
+		func(message, reason string) {
+			got, _ := podCreationRefusal(message)
+			Expect(got).To(Equal(reason))
+		},
+		Entry("PodSecurity", `Error creating: pods "web-1-x" is forbidden: violates PodSecurity "restricted:latest": privileged (container "build" must not set securityContext.privileged=true)`, jcrsv1.ReasonPodSecurity),
+		Entry("exceeded ResourceQuota", `Error creating: pods "web-1-x" is forbidden: exceeded quota: compute, requested: cpu=4, used: cpu=6, limited: cpu=8`, jcrsv1.ReasonResourceQuota),
+		Entry("ResourceQuota without limits", `Error creating: pods "web-1-x" is forbidden: failed quota: compute: must specify limits.cpu for: build`, jcrsv1.ReasonResourceQuota),
+		Entry("LimitRange", `Error creating: pods "web-1-x" is forbidden: maximum cpu usage per Container is 2, but limit is 4`, jcrsv1.ReasonLimitRange),
+		Entry("webhook", `Error creating: admission webhook "policy.example.com" denied the request: images must be signed`, jcrsv1.ReasonAdmissionRejected),
+	)
+
+	It("reports the latest refusal until the Job creates its pods", func() {
+		now := time.Now()
+		r := reconciler(
+			failedCreate("old", `Error creating: pods "web-1-a" is forbidden: exceeded quota: compute, requested: cpu=4`, now.Add(-time.Minute)),
+			failedCreate("new", `Error creating: pods "web-1-b" is forbidden: violates PodSecurity "restricted:latest": privileged`, now),
+		)
+		Expect(r.checkPodCreation(ctx, lvBuild, job)).To(Succeed())
+		condition := podCreationBlocked(lvBuild)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(jcrsv1.ReasonPodSecurity))
+		Expect(condition.Message).To(Equal(`Job web-1 can't create its pods: pods "web-1-b" is forbidden: violates PodSecurity "restricted:latest": privileged`))
+		Expect(recorder.Events).To(Receive(ContainSubstring(podCreationBlockedReason)))
+
+		By("recording the Event once")
+		Expect(r.checkPodCreation(ctx, lvBuild, job)).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive())
+
+		By("clearing it once a pod is created")
+		job.Status.Active = 1
+		Expect(r.checkPodCreation(ctx, lvBuild, job)).To(Succeed())
+		Expect(podCreationBlocked(lvBuild)).To(BeNil())
+		condition = meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPodCreationBlocked)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(jcrsv1.ReasonPodsCreated))
+	})
+
+	It("ignores the Events of other Jobs", func() {
+		other := failedCreate("other", "Error creating: exceeded quota: compute", time.Now())
+		other.InvolvedObject.UID = "other-uid"
+		r := reconciler(other)
+		Expect(r.checkPodCreation(ctx, lvBuild, job)).To(Succeed())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPodCreationBlocked)).To(BeNil())
+	})
+
+	It("maps the Events of a Job to the build owning it", func() {
+		job.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: jcrsv1.GroupVersion.String(), Kind: "LeviathanBuild", Name: "web", UID: "web-uid", Controller: ptr.To(true),
+		}}
+		r := reconciler(job)
+		requests := r.buildForJobEvent(ctx, failedCreate("new", "Error creating: exceeded quota", time.Now()))
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].NamespacedName).To(Equal(client.ObjectKey{Namespace: "team", Name: "web"}))
+	})
+})
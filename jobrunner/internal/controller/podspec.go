@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// The helpers below inject controller-managed configuration into the pod spec of a
+// build Job. Injected values are applied to every (non-init) container, since the
+// controller doesn't know which one runs the build.
+
+// ReservedVolumes maps the volumes the controller may inject into a build Job to
+// their mount path. Volumes and mounts declared by a build must not collide with them.
+var ReservedVolumes = map[string]string{
+	inlineScriptVolumeName:  inlineScriptMountPath,
+	workspaceVolumeName:     workspaceMountPath,
+	fetchCacheVolumeName:    fetchCacheMountPath,
+	trustBundleVolumeName:   trustBundleMountPath,
+	sidecarSignalVolumeName: sidecarSignalMountPath,
+	checkpointVolumeName:    checkpointMountPath,
+}
+
+// ReservedContainers are the names of the containers the controller may inject
+// into a build Job.
+var ReservedContainers = map[string]bool{
+	fetchContainerName: true,
+}
+
+// addBuildVolumes adds the volumes and volume mounts declared by lvBuild to the build Job.
+func addBuildVolumes(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	podSpec := &job.Spec.Template.Spec
+	for _, vol := range lvBuild.Spec.Volumes {
+		addVolume(podSpec, vol)
+	}
+	// A volume may be mounted more than once, so mounts are appended rather than
+	// replaced by name. The webhook rejects mount paths that are already in use.
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		c.VolumeMounts = append(c.VolumeMounts, lvBuild.Spec.VolumeMounts...)
+	}
+}
+
+// addBuildDNS sets the DNS policy and configuration declared by lvBuild on the
+// build Job, and adds its host aliases.
+func addBuildDNS(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	podSpec := &job.Spec.Template.Spec
+	if lvBuild.Spec.DNSPolicy != "" {
+		podSpec.DNSPolicy = lvBuild.Spec.DNSPolicy
+	}
+	if lvBuild.Spec.DNSConfig != nil {
+		podSpec.DNSConfig = lvBuild.Spec.DNSConfig.DeepCopy()
+	}
+	podSpec.HostAliases = append(podSpec.HostAliases, lvBuild.Spec.HostAliases...)
+}
+
+// addVolume adds vol to the pod spec, replacing any volume with the same name.
+func addVolume(spec *corev1.PodSpec, vol corev1.Volume) {
+	for i := range spec.Volumes {
+		if spec.Volumes[i].Name == vol.Name {
+			spec.Volumes[i] = vol
+			return
+		}
+	}
+	spec.Volumes = append(spec.Volumes, vol)
+}
+
+// addVolumeMount mounts a volume into every container of the pod spec.
+func addVolumeMount(spec *corev1.PodSpec, mount corev1.VolumeMount) {
+	for i := range spec.Containers {
+		addContainerVolumeMount(&spec.Containers[i], mount)
+	}
+}
+
+// addContainerVolumeMount mounts a volume into c, replacing any mount of the same volume.
+func addContainerVolumeMount(c *corev1.Container, mount corev1.VolumeMount) {
+	for j := range c.VolumeMounts {
+		if c.VolumeMounts[j].Name == mount.Name {
+			c.VolumeMounts[j] = mount
+			return
+		}
+	}
+	c.VolumeMounts = append(c.VolumeMounts, mount)
+}
+
+// setEnv sets an environment variable on every container of the pod spec,
+// overriding a variable of the same name.
+func setEnv(spec *corev1.PodSpec, env corev1.EnvVar) {
+	for i := range spec.Containers {
+		setContainerEnv(&spec.Containers[i], env)
+	}
+}
+
+// setContainerEnv sets an environment variable on c, overriding a variable of the same name.
+func setContainerEnv(c *corev1.Container, env corev1.EnvVar) {
+	for j := range c.Env {
+		if c.Env[j].Name == env.Name {
+			c.Env[j] = env
+			return
+		}
+	}
+	c.Env = append(c.Env, env)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/progress"
+)
+
+/*
+The build containers of a running build report their progress by logging
+progress markers, "::progress <step>::<message>". The Tracker follows the logs
+of the running build containers of the latest run, the containers of the
+jobTemplate rather than the init containers and sidecars, and the build is
+reconciled when it has read new markers. They are kept in status.progress,
+bounded to the latest 20, so `kubectl get -w` shows what a build is doing
+without its log archive.
+
+The progress is cleared when a new run starts. The logs of the containers are
+followed from the latest entry of the status, so entries aren't reported twice
+when the controller restarts.
+*/
+
+// +kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+
+// reconcileProgress follows the running build containers of job, and adds the
+// markers they logged since the last reconcile to the progress of lvBuild.
+func (r *LeviathanBuildReconciler) reconcileProgress(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, finished bool) error {
+	if r.Progress == nil {
+		return nil
+	}
+	var containers []progress.Container
+	if !finished {
+		var pods corev1.PodList
+		if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+			return err
+		}
+		containers = runningBuildContainers(lvBuild, pods.Items)
+	}
+	var since metav1.MicroTime
+	if n := len(lvBuild.Status.Progress); n > 0 {
+		since = lvBuild.Status.Progress[n-1].Time
+	}
+	key := client.ObjectKeyFromObject(lvBuild)
+	r.Progress.Follow(key, containers, since.Time)
+	lvBuild.Status.Progress = appendProgress(lvBuild.Status.Progress, r.Progress.Drain(key))
+	return nil
+}
+
+// resetProgress clears the progress of lvBuild for a new run.
+func (r *LeviathanBuildReconciler) resetProgress(lvBuild *jcrsv1.LeviathanBuild) {
+	lvBuild.Status.Progress = nil
+	if r.Progress != nil {
+		r.Progress.Forget(client.ObjectKeyFromObject(lvBuild))
+	}
+}
+
+// runningBuildContainers returns the build containers of lvBuild running in pods.
+func runningBuildContainers(lvBuild *jcrsv1.LeviathanBuild, pods []corev1.Pod) []progress.Container {
+	names := sets.New[string]()
+	for _, c := range lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers {
+		names.Insert(c.Name)
+	}
+	var containers []progress.Container
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Running == nil || !names.Has(status.Name) {
+				continue
+			}
+			containers = append(containers, progress.Container{
+				Namespace: pod.Namespace, Pod: pod.Name, Name: status.Name, RestartCount: status.RestartCount,
+			})
+		}
+	}
+	return containers
+}
+
+// appendProgress returns entries with markers appended in the order they were
+// logged, keeping the latest progress.MaxEntries.
+func appendProgress(entries []jcrsv1.ProgressEntry, markers []progress.Marker) []jcrsv1.ProgressEntry {
+	if len(markers) == 0 {
+		return entries
+	}
+	for _, marker := range markers {
+		entries = append(entries, jcrsv1.ProgressEntry{
+			Time:    metav1.NewMicroTime(marker.Time),
+			Step:    marker.Step,
+			Message: marker.Message,
+		})
+	}
+	// Markers of different containers are read concurrently
+	slices.SortStableFunc(entries, func(a, b jcrsv1.ProgressEntry) int { return a.Time.Compare(b.Time.Time) })
+	if n := len(entries); n > progress.MaxEntries {
+		entries = slices.Clone(entries[n-progress.MaxEntries:])
+	}
+	return entries
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/progress"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+// containerLogs serves the logs of containers by pod/container, and records the
+// containers followed.
+type containerLogs struct {
+	mu       sync.Mutex
+	logs     map[string]string
+	followed []string
+}
+
+func (l *containerLogs) Stream(_ context.Context, _, pod, container string, since time.Time) (io.ReadCloser, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.followed = append(l.followed, fmt.Sprintf("%s/%s since %s", pod, container, since.Format(time.RFC3339)))
+	return io.NopCloser(strings.NewReader(l.logs[pod+"/"+container])), nil
+}
+
+func (l *containerLogs) Followed() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.followed...)
+}
+
+var _ = Describe("Build progress", func() {
+	var (
+		ctx     context.Context
+		logs    *containerLogs
+		r       *LeviathanBuildReconciler
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+		started = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	)
+
+	pod := func(name string, phase corev1.PodPhase, statuses ...corev1.ContainerStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ci", Labels: map[string]string{batchv1.JobNameLabel: "web-1"}},
+			Status:     corev1.PodStatus{Phase: phase, ContainerStatuses: statuses},
+		}
+	}
+	running := func(name string, restarts int32) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name: name, RestartCount: restarts,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		}
+	}
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		logs = &containerLogs{logs: map[string]string{
+			"web-1-abcde/build": strings.Join([]string{
+				"2025-03-01T10:00:01Z ::progress fetch::downloading sources",
+				"2025-03-01T10:00:03Z ::progress compile::building ./cmd/server",
+			}, "\n"),
+			"web-1-abcde/lint":  "2025-03-01T10:00:02Z ::progress lint::golangci-lint run",
+			"web-1-abcde/cache": "2025-03-01T10:00:02Z ::progress cache::not a build container",
+		}}
+		tracker := progress.NewTracker(logs)
+		go func() { _ = tracker.Start(ctx) }()
+		c := newFakeClient(
+			pod("web-1-abcde", corev1.PodRunning, running("build", 0), running("lint", 1), running("cache", 0)),
+			pod("web-1-fghij", corev1.PodFailed),
+		)
+		r = &LeviathanBuildReconciler{
+			Client:   c,
+			Scheme:   c.Scheme(),
+			Progress: tracker,
+		}
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "ci").Obj()
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}, {Name: "lint"}}
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "ci"}}
+		// The tracker only follows containers once it has started
+		Eventually(func() []string {
+			Expect(r.reconcileProgress(ctx, lvBuild, job, false)).To(Succeed())
+			return logs.Followed()
+		}).ShouldNot(BeEmpty())
+	})
+
+	It("reports the markers of the running build containers in the order they were logged", func() {
+		Eventually(func() []jcrsv1.ProgressEntry {
+			Expect(r.reconcileProgress(ctx, lvBuild, job, false)).To(Succeed())
+			return lvBuild.Status.Progress
+		}).Should(HaveLen(3))
+		Expect(lvBuild.Status.Progress).To(Equal([]jcrsv1.ProgressEntry{
+			{Time: metav1.NewMicroTime(started.Add(time.Second)), Step: "fetch", Message: "downloading sources"},
+			{Time: metav1.NewMicroTime(started.Add(2 * time.Second)), Step: "lint", Message: "golangci-lint run"},
+			{Time: metav1.NewMicroTime(started.Add(3 * time.Second)), Step: "compile", Message: "building ./cmd/server"},
+		}))
+		Expect(logs.Followed()).To(ConsistOf(
+			"web-1-abcde/build since 0001-01-01T00:00:00Z",
+			"web-1-abcde/lint since 0001-01-01T00:00:00Z",
+		))
+
+		By("following the containers again from the latest entry after a restart of the controller")
+		r.Progress.Forget(client.ObjectKeyFromObject(lvBuild))
+		Expect(r.reconcileProgress(ctx, lvBuild, job, false)).To(Succeed())
+		Eventually(logs.Followed).Should(ContainElement("web-1-abcde/build since 2025-03-01T10:00:03Z"))
+		Consistently(func() []jcrsv1.ProgressEntry {
+			Expect(r.reconcileProgress(ctx, lvBuild, job, false)).To(Succeed())
+			return lvBuild.Status.Progress
+		}, 100*time.Millisecond).Should(HaveLen(3))
+
+		By("clearing the progress when a new run starts")
+		r.resetProgress(lvBuild)
+		Expect(lvBuild.Status.Progress).To(BeNil())
+	})
+
+	It("keeps the latest entries", func() {
+		var markers []progress.Marker
+		for i := range progress.MaxEntries + 5 {
+			markers = append(markers, progress.Marker{Time: started.Add(time.Duration(i) * time.Second), Step: fmt.Sprintf("step-%d", i)})
+		}
+		entries := appendProgress(nil, markers[:10])
+		entries = appendProgress(entries, markers[10:])
+		Expect(entries).To(HaveLen(progress.MaxEntries))
+		Expect(entries[0].Step).To(Equal("step-5"))
+		Expect(entries[progress.MaxEntries-1].Step).To(Equal("step-24"))
+	})
+})
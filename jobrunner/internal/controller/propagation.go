@@ -0,0 +1,84 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// lastAppliedAnnotation is written by `kubectl apply` and never makes sense on a child object.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// propagatedMetadata returns the subset of src allowed by the given rule.
+// A nil rule behaves like the "None" policy.
+func propagatedMetadata(src map[string]string, rule *jcrsv1.PropagationRule) map[string]string {
+	out := make(map[string]string)
+	if rule == nil {
+		return out
+	}
+	for k, v := range src {
+		if k == lastAppliedAnnotation {
+			continue
+		}
+		switch rule.Policy {
+		case jcrsv1.PropagateAll:
+			out[k] = v
+		case jcrsv1.PropagateSelector:
+			if keySelected(k, rule.Keys) {
+				out[k] = v
+			}
+		}
+	}
+	return out
+}
+
+// keySelected reports whether key matches one of the selector entries.
+// Entries ending in "/" match by prefix, everything else must match exactly.
+func keySelected(key string, selectors []string) bool {
+	for _, s := range selectors {
+		if strings.HasSuffix(s, "/") {
+			if strings.HasPrefix(key, s) {
+				return true
+			}
+		} else if key == s {
+			return true
+		}
+	}
+	return false
+}
+
+// propagateMetadata copies the labels and annotations of lvBuild selected by its
+// propagation policy into the given label and annotation maps. Keys already present
+// in the destination (set explicitly by the jobTemplate) are left untouched.
+func propagateMetadata(lvBuild *jcrsv1.LeviathanBuild, labels, annotations map[string]string) {
+	var labelRule, annotationRule *jcrsv1.PropagationRule
+	if p := lvBuild.Spec.Propagation; p != nil {
+		labelRule, annotationRule = p.Labels, p.Annotations
+	}
+	for k, v := range propagatedMetadata(lvBuild.Labels, labelRule) {
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+	for k, v := range propagatedMetadata(lvBuild.Annotations, annotationRule) {
+		if _, ok := annotations[k]; !ok {
+			annotations[k] = v
+		}
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Metadata propagation", func() {
+	src := map[string]string{
+		"team":                    "payments",
+		"cost.example.com/center": "42",
+		"cost.example.com/owner":  "alice",
+		lastAppliedAnnotation:     "{}",
+	}
+
+	It("propagates nothing without a rule", func() {
+		Expect(propagatedMetadata(src, nil)).To(BeEmpty())
+		Expect(propagatedMetadata(src, &jcrsv1.PropagationRule{Policy: jcrsv1.PropagateNone})).To(BeEmpty())
+	})
+
+	It("propagates everything but the last-applied annotation for All", func() {
+		out := propagatedMetadata(src, &jcrsv1.PropagationRule{Policy: jcrsv1.PropagateAll})
+		Expect(out).To(HaveLen(3))
+		Expect(out).NotTo(HaveKey(lastAppliedAnnotation))
+	})
+
+	It("matches exact keys and prefixes for Selector", func() {
+		out := propagatedMetadata(src, &jcrsv1.PropagationRule{
+			Policy: jcrsv1.PropagateSelector,
+			Keys:   []string{"cost.example.com/", "missing"},
+		})
+		Expect(out).To(Equal(map[string]string{
+			"cost.example.com/center": "42",
+			"cost.example.com/owner":  "alice",
+		}))
+	})
+
+	It("does not override metadata set by the jobTemplate", func() {
+		lvBuild := &jcrsv1.LeviathanBuild{}
+		lvBuild.Labels = map[string]string{"team": "payments"}
+		lvBuild.Spec.Propagation = &jcrsv1.PropagationSpec{
+			Labels: &jcrsv1.PropagationRule{Policy: jcrsv1.PropagateAll},
+		}
+		labels := map[string]string{"team": "from-template"}
+		annotations := map[string]string{}
+		propagateMetadata(lvBuild, labels, annotations)
+		Expect(labels).To(HaveKeyWithValue("team", "from-template"))
+		Expect(annotations).To(BeEmpty())
+	})
+})
@@ -0,0 +1,111 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The pods of builds protected from eviction are kept from being killed mid-way by
+the two voluntary disruptions of a cluster: the cluster-autoscaler scaling down
+their node, which it doesn't do while a pod is annotated as not safe to evict, and
+node drains, which go through the eviction API and are held back by a
+PodDisruptionBudget. They also run with the priority class configured for the
+operator, if any, so they aren't the first to be preempted.
+
+The PodDisruptionBudget selects the pods of the build through the build label and
+requires all of them to be available. Jobs have no scale subresource, so it can
+only count pods with an absolute minAvailable: the parallelism of the Job. Like the
+NetworkPolicy of isolated builds, it's created before the Job of a run and deleted
+once the Job has finished, so it never holds back a drain for a finished build.
+*/
+
+const (
+	podDisruptionBudgetSuffix = "-protection"
+
+	// safeToEvictAnnotation keeps the cluster-autoscaler from removing the node of a pod when "false"
+	safeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+)
+
+// protectedFromEviction reports whether the pods of lvBuild are protected from eviction.
+func protectedFromEviction(lvBuild *jcrsv1.LeviathanBuild) bool {
+	return lvBuild.Spec.ProtectionPolicy == jcrsv1.ProtectFromEviction
+}
+
+// podDisruptionBudgetName returns the name of the PodDisruptionBudget protecting lvBuild.
+func podDisruptionBudgetName(lvBuild *jcrsv1.LeviathanBuild) string {
+	return lvBuild.Name + podDisruptionBudgetSuffix
+}
+
+// addEvictionProtection labels the pods of protected builds, so that their
+// PodDisruptionBudget selects them, marks them as not safe to evict and sets
+// their priority class.
+func (r *LeviathanBuildReconciler) addEvictionProtection(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if !protectedFromEviction(lvBuild) {
+		return
+	}
+	template := &job.Spec.Template
+	template.Labels[buildLabel] = labelValue(lvBuild.Name)
+	template.Annotations[safeToEvictAnnotation] = "false"
+	if template.Spec.PriorityClassName == "" {
+		template.Spec.PriorityClassName = r.ProtectedPriorityClassName
+	}
+}
+
+// reconcilePodDisruptionBudget creates or updates the PodDisruptionBudget of a
+// protected build while job runs, and deletes it otherwise. job is nil when no
+// run is running.
+func (r *LeviathanBuildReconciler) reconcilePodDisruptionBudget(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) error {
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podDisruptionBudgetName(lvBuild),
+			Namespace: lvBuild.Namespace,
+		},
+	}
+	if job == nil || !protectedFromEviction(lvBuild) {
+		// The cached budget spares a delete call for every build that isn't protected
+		if err := r.Get(ctx, client.ObjectKeyFromObject(pdb), pdb); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !metav1.IsControlledBy(pdb, lvBuild) {
+			return nil
+		}
+		return client.IgnoreNotFound(r.Delete(ctx, pdb))
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, pdb, func() error {
+		minAvailable := intstr.FromInt32(ptr.Deref(job.Spec.Parallelism, 1))
+		pdb.Spec = policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{buildLabel: labelValue(lvBuild.Name)}},
+		}
+		return ctrl.SetControllerReference(lvBuild, pdb, r.Scheme)
+	})
+	return err
+}
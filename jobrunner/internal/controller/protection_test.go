@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Eviction protection", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		r       *LeviathanBuildReconciler
+		c       client.Client
+		job     *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		c = newFakeClient()
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme(), ProtectedPriorityClassName: "release-builds"}
+		lvBuild = utiltesting.MakeLeviathanBuild("release", "default").Obj()
+		lvBuild.Spec.ProtectionPolicy = jcrsv1.ProtectFromEviction
+		job = &batchv1.Job{}
+		job.Spec.Template.Labels = map[string]string{}
+		job.Spec.Template.Annotations = map[string]string{}
+	})
+
+	It("only marks the pods of protected builds", func() {
+		r.addEvictionProtection(lvBuild, job)
+		Expect(job.Spec.Template.Labels).To(HaveKeyWithValue(buildLabel, "release"))
+		Expect(job.Spec.Template.Annotations).To(HaveKeyWithValue(safeToEvictAnnotation, "false"))
+		Expect(job.Spec.Template.Spec.PriorityClassName).To(Equal("release-builds"))
+
+		By("keeping the priority class of the jobTemplate")
+		job.Spec.Template.Spec.PriorityClassName = "critical"
+		r.addEvictionProtection(lvBuild, job)
+		Expect(job.Spec.Template.Spec.PriorityClassName).To(Equal("critical"))
+
+		job = &batchv1.Job{}
+		job.Spec.Template.Labels = map[string]string{}
+		job.Spec.Template.Annotations = map[string]string{}
+		lvBuild.Spec.ProtectionPolicy = jcrsv1.NoProtection
+		r.addEvictionProtection(lvBuild, job)
+		Expect(job.Spec.Template.Labels).To(BeEmpty())
+		Expect(job.Spec.Template.Annotations).To(BeEmpty())
+		Expect(job.Spec.Template.Spec.PriorityClassName).To(BeEmpty())
+	})
+
+	It("keeps the PodDisruptionBudget while the Job runs", func() {
+		ctx := context.Background()
+		key := client.ObjectKey{Namespace: "default", Name: "release-protection"}
+		pdb := &policyv1.PodDisruptionBudget{}
+		job.Spec.Parallelism = ptr.To[int32](3)
+
+		Expect(r.reconcilePodDisruptionBudget(ctx, lvBuild, job)).To(Succeed())
+		Expect(c.Get(ctx, key, pdb)).To(Succeed())
+		Expect(pdb.OwnerReferences).To(ConsistOf(HaveField("UID", lvBuild.UID)))
+		Expect(pdb.Spec.MinAvailable).To(Equal(ptr.To(intstr.FromInt32(3))))
+		Expect(pdb.Spec.Selector.MatchLabels).To(Equal(map[string]string{buildLabel: "release"}))
+
+		Expect(r.reconcilePodDisruptionBudget(ctx, lvBuild, nil)).To(Succeed())
+		Expect(c.Get(ctx, key, pdb)).NotTo(Succeed())
+
+		By("deleting it when the protection is turned off")
+		Expect(r.reconcilePodDisruptionBudget(ctx, lvBuild, job)).To(Succeed())
+		lvBuild.Spec.ProtectionPolicy = jcrsv1.NoProtection
+		Expect(r.reconcilePodDisruptionBudget(ctx, lvBuild, job)).To(Succeed())
+		Expect(c.Get(ctx, key, pdb)).NotTo(Succeed())
+	})
+})
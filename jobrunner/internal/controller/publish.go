@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// replaceVersionEnv tells the build containers to overwrite a version that is already published
+const replaceVersionEnv = "LEVIATHAN_REPLACE_VERSION"
+
+// publishes reports whether the build type publishes the package.
+func publishes(buildType jcrsv1.BuildType) bool {
+	return buildType == jcrsv1.Publish || buildType == jcrsv1.BuildPublish
+}
+
+// publishPreflight checks whether the version being published already exists in the target
+// registry and applies the conflict policy. The outcome is recorded as a condition on
+// lvBuild; it returns false when no Job should be created. A build whose version
+// conflicts is marked as failed, unless its policy is to skip it.
+func (r *LeviathanBuildReconciler) publishPreflight(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (bool, error) {
+	target := lvBuild.Spec.PublishTarget
+	if r.Registry == nil || target == nil || !publishes(lvBuild.Spec.BuildType) {
+		return true, nil
+	}
+
+	exists, err := r.Registry.Exists(ctx, target.RegistryURL, publishPathOf(lvBuild), target.Version)
+	if err != nil {
+		return false, fmt.Errorf("checking publish target: %w", err)
+	}
+
+	condition := metav1.Condition{
+		Type:               jcrsv1.ConditionPublishPreflight,
+		Status:             metav1.ConditionTrue,
+		Reason:             jcrsv1.ReasonVersionAvailable,
+		Message:            fmt.Sprintf("Version %s is not yet published", target.Version),
+		ObservedGeneration: lvBuild.Generation,
+	}
+	proceed := true
+	if exists {
+		switch target.ConflictPolicy {
+		case jcrsv1.SkipOnConflict:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = jcrsv1.ReasonSkipped
+			condition.Message = fmt.Sprintf("Version %s is already published, skipping build", target.Version)
+			proceed = false
+		case jcrsv1.ReplaceOnConflict:
+			condition.Reason = jcrsv1.ReasonReplacing
+			condition.Message = fmt.Sprintf("Version %s is already published and will be replaced", target.Version)
+		default:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = jcrsv1.ReasonVersionConflict
+			condition.Message = fmt.Sprintf("Version %s is already published", target.Version)
+			proceed = false
+			jcrsv1.MarkFailed(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonVersionConflict, condition.Message)
+		}
+	}
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, condition)
+	return proceed, nil
+}
+
+// addConflictPolicy tells the build containers of lvBuild to overwrite the version
+// they publish when it already exists, as its conflictPolicy is Replace.
+func addConflictPolicy(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	target := lvBuild.Spec.PublishTarget
+	if target == nil || target.ConflictPolicy != jcrsv1.ReplaceOnConflict || !publishes(lvBuild.Spec.BuildType) {
+		return
+	}
+	setEnv(&job.Spec.Template.Spec, corev1.EnvVar{Name: replaceVersionEnv, Value: "true"})
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Publish conflict policy", func() {
+	var (
+		ctx     context.Context
+		r       *LeviathanBuildReconciler
+		lvBuild *jcrsv1.LeviathanBuild
+	)
+
+	preflight := func() *metav1.Condition {
+		return meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionPublishPreflight)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		registry := utiltesting.NewPublishTarget()
+		registry.Publish("web", "1.2.3", "sha256:0123")
+		r = &LeviathanBuildReconciler{Registry: registry}
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "ci").Generation(2).Publish("https://registry.example.com", "1.2.3").Obj()
+	})
+
+	It("fails the build when its version is already published", func() {
+		proceed, err := r.publishPreflight(ctx, lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proceed).To(BeFalse())
+		Expect(preflight().Reason).To(Equal(jcrsv1.ReasonVersionConflict))
+		Expect(jcrsv1.IsFailed(lvBuild.Status.Conditions)).To(BeTrue())
+		succeeded := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded)
+		Expect(succeeded.Reason).To(Equal(jcrsv1.ReasonVersionConflict))
+		Expect(succeeded.ObservedGeneration).To(BeEquivalentTo(2))
+	})
+
+	It("skips the build without failing it", func() {
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "ci").Publish("https://registry.example.com", "1.2.3").
+			ConflictPolicy(jcrsv1.SkipOnConflict).Obj()
+		proceed, err := r.publishPreflight(ctx, lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proceed).To(BeFalse())
+		Expect(preflight().Reason).To(Equal(jcrsv1.ReasonSkipped))
+		Expect(jcrsv1.IsFailed(lvBuild.Status.Conditions)).To(BeFalse())
+	})
+
+	It("runs the build when its version isn't published yet", func() {
+		lvBuild.Spec.PublishTarget.Version = "1.2.4"
+		proceed, err := r.publishPreflight(ctx, lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proceed).To(BeTrue())
+		Expect(preflight().Reason).To(Equal(jcrsv1.ReasonVersionAvailable))
+	})
+
+	It("tells the build containers to overwrite a version being replaced", func() {
+		job := &batchv1.Job{Spec: batchv1.JobSpec{Template: *lvBuild.Spec.JobTemplate.Spec.Template.DeepCopy()}}
+		addConflictPolicy(lvBuild, job)
+		Expect(job.Spec.Template.Spec.Containers[0].Env).To(BeEmpty())
+
+		lvBuild.Spec.PublishTarget.ConflictPolicy = jcrsv1.ReplaceOnConflict
+		proceed, err := r.publishPreflight(ctx, lvBuild)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proceed).To(BeTrue())
+		Expect(preflight().Reason).To(Equal(jcrsv1.ReasonReplacing))
+
+		addConflictPolicy(lvBuild, job)
+		Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: replaceVersionEnv, Value: "true"}))
+	})
+})
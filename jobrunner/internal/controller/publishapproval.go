@@ -0,0 +1,247 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+A publishing build with spec.publishApproval.required builds every run without
+publishing it: its containers are given LEVIATHAN_PUBLISH=false. Once the run
+succeeded, it waits for a PublishApproval of the run, reported by the
+WaitingForApproval condition, and the build stays Running meanwhile. Approving
+it runs the build again as a Job of its own with LEVIATHAN_PUBLISH=true, named
+after the run like reproduction Jobs and without the labels of the Jobs of runs.
+The run is over once that Job is: the digest it reports is the published one,
+and the signing, patch targets, retention and history of the run follow it.
+
+PublishApprovals are stamped with the user creating them by the admission
+webhook, which also admits only the approvers the build names, if any. Who may
+approve at all is up to who may create PublishApprovals, which the
+publishapproval-approver-role grants. A run whose timeout passes without an
+approval fails, or is published anyway with onTimeout: Publish.
+*/
+
+const (
+	// publishEnv tells the containers of builds requiring a publishApproval whether to publish
+	publishEnv = "LEVIATHAN_PUBLISH"
+
+	// publishesLabel identifies the publish Jobs of a build
+	publishesLabel = "jcrs.jcrs.dev/publishes"
+	// publishesRunIndexAnnotation records the run a publish Job publishes
+	publishesRunIndexAnnotation = "jcrs.jcrs.dev/publishes-run-index"
+
+	// publishApprovedReason is the reason of the Event recorded when a run is approved
+	publishApprovedReason = "PublishApproved"
+	// approvalTimedOutReason is the reason of the Event recorded when a run isn't approved in time
+	approvalTimedOutReason = "ApprovalTimedOut"
+)
+
+// publishApprovalRequired reports whether the runs of lvBuild wait for an approval to publish.
+func publishApprovalRequired(lvBuild *jcrsv1.LeviathanBuild) bool {
+	approval := lvBuild.Spec.PublishApproval
+	return featuregates.Enabled(featuregates.PublishApprovals) && publishes(lvBuild.Spec.BuildType) &&
+		approval != nil && approval.Required
+}
+
+// publishJobName returns the name of the publish Job of the run runIndex of lvBuild.
+func publishJobName(lvBuild *jcrsv1.LeviathanBuild, runIndex int64) string {
+	return shortenName(fmt.Sprintf("%s-%d-publish", lvBuild.Name, runIndex), validation.DNS1123LabelMaxLength)
+}
+
+// addPublishHold keeps the containers of the runs of builds requiring a
+// publishApproval from publishing.
+func addPublishHold(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if !publishApprovalRequired(lvBuild) {
+		return
+	}
+	setEnv(&job.Spec.Template.Spec, corev1.EnvVar{Name: publishEnv, Value: "false"})
+}
+
+// setWaitingForApproval records the approval of the publish of the latest run of lvBuild.
+func setWaitingForApproval(lvBuild *jcrsv1.LeviathanBuild, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionWaitingForApproval,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: lvBuild.Generation,
+	})
+}
+
+// resetPublishApproval forgets the approval of the previous run of lvBuild.
+func resetPublishApproval(lvBuild *jcrsv1.LeviathanBuild) {
+	lvBuild.Status.PublishApproval = nil
+	meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForApproval)
+}
+
+// approvalTimedOut reports whether the latest run of lvBuild failed for want of an approval.
+func approvalTimedOut(lvBuild *jcrsv1.LeviathanBuild) bool {
+	status := lvBuild.Status.PublishApproval
+	return status != nil && status.DecidedAt != nil && status.Approval == "" && status.Job == ""
+}
+
+// reconcilePublishApproval holds the publish of the run runIndex of lvBuild,
+// which succeeded, until it is approved or times out. It returns the Job
+// publishing the run with the spec of desired once it is created; while the
+// run waits, it returns when its timeout passes, if it has one.
+func (r *LeviathanBuildReconciler) reconcilePublishApproval(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, desired *batchv1.Job, runIndex int64) (*batchv1.Job, time.Duration, error) {
+	status := lvBuild.Status.PublishApproval
+	if status == nil || status.RunIndex != runIndex {
+		status = &jcrsv1.PublishApprovalStatus{RunIndex: runIndex, RequestedAt: metav1.Now()}
+		lvBuild.Status.PublishApproval = status
+	}
+	if status.Job != "" {
+		return r.ensurePublishJob(ctx, lvBuild, desired, runIndex)
+	}
+	if status.DecidedAt != nil {
+		return nil, 0, nil
+	}
+
+	approval, err := r.approvalOf(ctx, lvBuild, runIndex)
+	if err != nil {
+		return nil, 0, err
+	}
+	policy := lvBuild.Spec.PublishApproval
+	now := metav1.Now()
+	waited := now.Sub(status.RequestedAt.Time)
+	switch {
+	case approval != nil:
+		status.Approval = approval.Name
+		status.ApprovedBy = approval.Spec.ApprovedBy
+		status.DecidedAt = &now
+		setWaitingForApproval(lvBuild, metav1.ConditionFalse, jcrsv1.ReasonPublishApproved,
+			fmt.Sprintf("Run %d was approved by %s with PublishApproval %s", runIndex, approval.Spec.ApprovedBy, approval.Name))
+		r.event(lvBuild, corev1.EventTypeNormal, publishApprovedReason, "Run %d was approved by %s", runIndex, approval.Spec.ApprovedBy)
+	case policy.Timeout == nil || waited < policy.Timeout.Duration:
+		setWaitingForApproval(lvBuild, metav1.ConditionTrue, jcrsv1.ReasonAwaitingApproval,
+			fmt.Sprintf("Run %d waits for a PublishApproval to publish", runIndex))
+		if policy.Timeout == nil {
+			return nil, 0, nil
+		}
+		return nil, policy.Timeout.Duration - waited, nil
+	case policy.OnTimeout == jcrsv1.PublishOnApprovalTimeout:
+		status.DecidedAt = &now
+		setWaitingForApproval(lvBuild, metav1.ConditionFalse, jcrsv1.ReasonApprovalTimedOut,
+			fmt.Sprintf("Run %d wasn't approved within %s, and is published anyway", runIndex, policy.Timeout.Duration))
+		r.event(lvBuild, corev1.EventTypeWarning, approvalTimedOutReason, "Run %d wasn't approved within %s, publishing it anyway", runIndex, policy.Timeout.Duration)
+	default:
+		status.DecidedAt = &now
+		setWaitingForApproval(lvBuild, metav1.ConditionFalse, jcrsv1.ReasonApprovalTimedOut,
+			fmt.Sprintf("Run %d wasn't approved within %s", runIndex, policy.Timeout.Duration))
+		r.event(lvBuild, corev1.EventTypeWarning, approvalTimedOutReason, "Run %d wasn't approved within %s", runIndex, policy.Timeout.Duration)
+		return nil, 0, nil
+	}
+	status.Job = publishJobName(lvBuild, runIndex)
+	return r.ensurePublishJob(ctx, lvBuild, desired, runIndex)
+}
+
+// approvalOf returns the earliest PublishApproval of the run runIndex of lvBuild, if any.
+func (r *LeviathanBuildReconciler) approvalOf(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, runIndex int64) (*jcrsv1.PublishApproval, error) {
+	var approvals jcrsv1.PublishApprovalList
+	if err := r.List(ctx, &approvals, client.InNamespace(lvBuild.Namespace)); err != nil {
+		return nil, err
+	}
+	var earliest *jcrsv1.PublishApproval
+	for i := range approvals.Items {
+		approval := &approvals.Items[i]
+		if approval.Spec.Build != lvBuild.Name || approval.Spec.RunIndex != runIndex {
+			continue
+		}
+		if earliest == nil || approval.CreationTimestamp.Before(&earliest.CreationTimestamp) {
+			earliest = approval
+		}
+	}
+	return earliest, nil
+}
+
+// ensurePublishJob returns the Job publishing the run runIndex of lvBuild,
+// creating it with the spec of desired if it doesn't exist, and deletes the
+// publish Jobs of the earlier runs of lvBuild.
+func (r *LeviathanBuildReconciler) ensurePublishJob(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, desired *batchv1.Job, runIndex int64) (*batchv1.Job, time.Duration, error) {
+	name := lvBuild.Status.PublishApproval.Job
+	publish := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: lvBuild.Namespace, Name: name}, publish)
+	if err == nil {
+		return publish, 0, nil
+	} else if !apierrors.IsNotFound(err) {
+		return nil, 0, err
+	}
+
+	publish = &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   lvBuild.Namespace,
+			Labels:      map[string]string{publishesLabel: labelValue(lvBuild.Name), ManagedLabel: "true"},
+			Annotations: map[string]string{publishesRunIndexAnnotation: strconv.FormatInt(runIndex, 10)},
+		},
+		Spec: *desired.Spec.DeepCopy(),
+	}
+	// The Job is kept for its outcome to be read, and goes away with the next run
+	publish.Spec.TTLSecondsAfterFinished = nil
+	setEnv(&publish.Spec.Template.Spec, corev1.EnvVar{Name: publishEnv, Value: "true"})
+	if err := ctrl.SetControllerReference(lvBuild, publish, r.Scheme); err != nil {
+		return nil, 0, err
+	}
+	logf.FromContext(ctx).Info("Creating publish Job", "Job.Namespace", publish.Namespace, "Job.Name", publish.Name)
+	if err := r.Create(ctx, publish); err != nil {
+		return nil, 0, err
+	}
+
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(lvBuild.Namespace), client.MatchingLabels{publishesLabel: labelValue(lvBuild.Name)}); err != nil {
+		return nil, 0, err
+	}
+	for i := range jobs.Items {
+		earlier := &jobs.Items[i]
+		if earlier.Name == publish.Name || !metav1.IsControlledBy(earlier, lvBuild) {
+			continue
+		}
+		if err := r.Delete(ctx, earlier, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return nil, 0, err
+		}
+	}
+	return publish, 0, nil
+}
+
+// buildForPublishApproval maps a PublishApproval to the build whose run it approves.
+func (r *LeviathanBuildReconciler) buildForPublishApproval(_ context.Context, obj client.Object) []reconcile.Request {
+	approval, ok := obj.(*jcrsv1.PublishApproval)
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: approval.Namespace, Name: approval.Spec.Build}}}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Publish approval", func() {
+	var (
+		ctx     context.Context
+		lvBuild *jcrsv1.LeviathanBuild
+		desired *batchv1.Job
+		r       *LeviathanBuildReconciler
+	)
+
+	// approve creates the PublishApproval of the run runIndex of lvBuild by user
+	approve := func(name, user string, runIndex int64) {
+		Expect(r.Create(ctx, &jcrsv1.PublishApproval{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       jcrsv1.PublishApprovalSpec{Build: lvBuild.Name, RunIndex: runIndex, ApprovedBy: user},
+		})).To(Succeed())
+	}
+	condition := func() string {
+		condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForApproval)
+		Expect(condition).NotTo(BeNil())
+		return string(condition.Status) + "/" + condition.Reason
+	}
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PublishApprovals: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PublishApprovals: false})).To(Succeed())
+		})
+		ctx = context.Background()
+		c := newFakeClient()
+		r = &LeviathanBuildReconciler{
+			Client:   c,
+			Scheme:   c.Scheme(),
+			Recorder: record.NewFakeRecorder(10),
+		}
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "default").BuildType(jcrsv1.BuildPublish).RunIndex(2).Obj()
+		lvBuild.Spec.PublishApproval = &jcrsv1.PublishApprovalPolicy{Required: true}
+		desired = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+			Spec: batchv1.JobSpec{
+				TTLSecondsAfterFinished: ptr.To[int32](60),
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    []corev1.Container{{Name: "build", Image: "builder"}},
+				}},
+			},
+		}
+		addPublishHold(lvBuild, desired)
+	})
+
+	It("holds the publish of the run until it is approved", func() {
+		Expect(desired.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: publishEnv, Value: "false"}))
+
+		publish, waitFor, err := r.reconcilePublishApproval(ctx, lvBuild, desired, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(publish).To(BeNil())
+		Expect(waitFor).To(BeZero())
+		Expect(condition()).To(Equal("True/AwaitingApproval"))
+		Expect(lvBuild.Status.PublishApproval.RunIndex).To(Equal(int64(2)))
+
+		By("ignoring the approvals of other runs")
+		approve("web-1", "alice", 1)
+		Expect(r.reconcilePublishApproval(ctx, lvBuild, desired, 2)).To(BeNil())
+		Expect(condition()).To(Equal("True/AwaitingApproval"))
+
+		By("publishing with a Job of its own once approved")
+		approve("web-2", "bob", 2)
+		publish, _, err = r.reconcilePublishApproval(ctx, lvBuild, desired, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(publish.Name).To(Equal("web-2-publish"))
+		Expect(publish.Labels).To(HaveKeyWithValue(publishesLabel, "web"))
+		Expect(publish.Labels).NotTo(HaveKey(buildLabel))
+		Expect(metav1.IsControlledBy(publish, lvBuild)).To(BeTrue())
+		Expect(publish.Spec.TTLSecondsAfterFinished).To(BeNil())
+		Expect(publish.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: publishEnv, Value: "true"}))
+		Expect(condition()).To(Equal("False/PublishApproved"))
+		Expect(lvBuild.Status.PublishApproval).To(HaveField("Approval", "web-2"))
+		Expect(lvBuild.Status.PublishApproval).To(HaveField("ApprovedBy", "bob"))
+		Expect(lvBuild.Status.PublishApproval).To(HaveField("Job", "web-2-publish"))
+
+		By("following the same Job afterwards")
+		again, _, err := r.reconcilePublishApproval(ctx, lvBuild, desired, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again.UID).To(Equal(publish.UID))
+
+		By("deleting the publish Job of the previous run once the next one publishes")
+		approve("web-3", "bob", 3)
+		Expect(r.reconcilePublishApproval(ctx, lvBuild, desired, 3)).NotTo(BeNil())
+		var jobs batchv1.JobList
+		Expect(r.List(ctx, &jobs, client.MatchingLabels{publishesLabel: "web"})).To(Succeed())
+		Expect(jobs.Items).To(ConsistOf(HaveField("Name", "web-3-publish")))
+	})
+
+	It("fails or publishes the runs not approved in time", func() {
+		lvBuild.Spec.PublishApproval.Timeout = &metav1.Duration{Duration: time.Hour}
+		publish, waitFor, err := r.reconcilePublishApproval(ctx, lvBuild, desired, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(publish).To(BeNil())
+		Expect(waitFor).To(BeNumerically("~", time.Hour, time.Minute))
+
+		lvBuild.Status.PublishApproval.RequestedAt = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+		Expect(r.reconcilePublishApproval(ctx, lvBuild, desired, 2)).To(BeNil())
+		Expect(condition()).To(Equal("False/ApprovalTimedOut"))
+		Expect(approvalTimedOut(lvBuild)).To(BeTrue())
+
+		By("not publishing a timed out run approved afterwards")
+		approve("web-2", "bob", 2)
+		Expect(r.reconcilePublishApproval(ctx, lvBuild, desired, 2)).To(BeNil())
+
+		By("publishing anyway with onTimeout: Publish")
+		lvBuild.Spec.PublishApproval.OnTimeout = jcrsv1.PublishOnApprovalTimeout
+		lvBuild.Status.PublishApproval = &jcrsv1.PublishApprovalStatus{RunIndex: 3, RequestedAt: metav1.NewTime(time.Now().Add(-2 * time.Hour))}
+		publish, _, err = r.reconcilePublishApproval(ctx, lvBuild, desired, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(publish.Name).To(Equal("web-3-publish"))
+		Expect(condition()).To(Equal("False/ApprovalTimedOut"))
+		Expect(approvalTimedOut(lvBuild)).To(BeFalse())
+		Expect(lvBuild.Status.PublishApproval.ApprovedBy).To(BeEmpty())
+	})
+
+	It("doesn't hold builds that don't require an approval", func() {
+		lvBuild.Spec.PublishApproval.Required = false
+		job := &batchv1.Job{Spec: *desired.Spec.DeepCopy()}
+		job.Spec.Template.Spec.Containers[0].Env = nil
+		addPublishHold(lvBuild, job)
+		Expect(job.Spec.Template.Spec.Containers[0].Env).To(BeEmpty())
+		Expect(publishApprovalRequired(lvBuild)).To(BeFalse())
+	})
+})
@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Artifacts are published under <registryURL>/<packageName> by default, so where
+the artifacts of a team land depends on the package names its builds pick. The
+pathTemplate of a publishTarget renders that path instead, from the package,
+the namespace and the buildType of the build, so a cluster can standardize it.
+
+The path is rendered on every reconcile and reported in status.publishPath
+before any run publishes, for the owner of the build to confirm. Runs are given
+it in LEVIATHAN_PUBLISH_PATH. The controller looks up, signs, rolls back and
+prunes versions under it, and each published version records the path it was
+published at, so a later change to the template doesn't lose track of it.
+*/
+
+// publishPathEnv tells the build containers the path they publish the package at
+const publishPathEnv = "LEVIATHAN_PUBLISH_PATH"
+
+// publishPathSegment matches a segment of a publish path
+var publishPathSegment = regexp.MustCompile(`^[A-Za-z0-9_@+][A-Za-z0-9._@+-]*$`)
+
+// publishPathFuncs are the functions a pathTemplate may use
+var publishPathFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// publishPathValues are the values a pathTemplate is rendered with.
+type publishPathValues struct {
+	PackageName string
+	Revision    int64
+	BuildType   string
+	Namespace   string
+	Version     string
+}
+
+// PublishPath returns the path of the package of lvBuild under the registryURL
+// of its publishTarget: its packageName, or what its pathTemplate renders.
+func PublishPath(lvBuild *jcrsv1.LeviathanBuild) (string, error) {
+	packageName := ptr.Deref(lvBuild.Spec.PackageName, "")
+	target := lvBuild.Spec.PublishTarget
+	if target == nil || target.PathTemplate == "" {
+		return packageName, nil
+	}
+	tmpl, err := template.New("path").Funcs(publishPathFuncs).Option("missingkey=error").Parse(target.PathTemplate)
+	if err != nil {
+		return "", err
+	}
+	var path strings.Builder
+	if err := tmpl.Execute(&path, publishPathValues{
+		PackageName: packageName,
+		Revision:    lvBuild.Generation,
+		BuildType:   string(buildTypeOf(lvBuild)),
+		Namespace:   lvBuild.Namespace,
+		Version:     target.Version,
+	}); err != nil {
+		return "", err
+	}
+	if err := validatePublishPath(path.String()); err != nil {
+		return "", err
+	}
+	return path.String(), nil
+}
+
+// validatePublishPath checks that path is a relative path of valid segments.
+func validatePublishPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("the path rendered by the pathTemplate is empty")
+	}
+	for segment := range strings.SplitSeq(path, "/") {
+		if !publishPathSegment.MatchString(segment) {
+			return fmt.Errorf("path %q rendered by the pathTemplate is invalid: segments must be made of letters, digits and \"._+@-\", and not start with \".\" or \"-\"", path)
+		}
+	}
+	return nil
+}
+
+// setPublishPath reports the publish path of lvBuild in its status, when it publishes.
+func setPublishPath(lvBuild *jcrsv1.LeviathanBuild) error {
+	lvBuild.Status.PublishPath = ""
+	if lvBuild.Spec.PublishTarget == nil || !publishes(lvBuild.Spec.BuildType) {
+		return nil
+	}
+	path, err := PublishPath(lvBuild)
+	if err != nil {
+		return err
+	}
+	lvBuild.Status.PublishPath = path
+	return nil
+}
+
+// publishPathOf returns the path lvBuild publishes at, as reported in its status.
+func publishPathOf(lvBuild *jcrsv1.LeviathanBuild) string {
+	if lvBuild.Status.PublishPath != "" {
+		return lvBuild.Status.PublishPath
+	}
+	return ptr.Deref(lvBuild.Spec.PackageName, "")
+}
+
+// artifactPath returns the path artifact was published at by lvBuild.
+func artifactPath(lvBuild *jcrsv1.LeviathanBuild, artifact jcrsv1.PublishedArtifact) string {
+	if artifact.Path != "" {
+		return artifact.Path
+	}
+	return ptr.Deref(lvBuild.Spec.PackageName, "")
+}
+
+// addPublishPath tells the build containers of lvBuild the path they publish at.
+func addPublishPath(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if lvBuild.Status.PublishPath == "" {
+		return
+	}
+	setEnv(&job.Spec.Template.Spec, corev1.EnvVar{Name: publishPathEnv, Value: lvBuild.Status.PublishPath})
+}
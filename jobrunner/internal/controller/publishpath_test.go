@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Publish path", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		r       *LeviathanBuildReconciler
+	)
+
+	BeforeEach(func() {
+		lvBuild, r = benchmarkBuild()
+		lvBuild.Generation = 3
+		lvBuild.Spec.BuildType = jcrsv1.BuildPublish
+		lvBuild.Spec.PublishTarget = &jcrsv1.PublishTarget{RegistryURL: "registry.example.com", Version: "1.4.0",
+			PathTemplate: "{{ .Namespace }}/{{ .BuildType | lower }}/{{ .PackageName }}"}
+	})
+
+	It("publishes at the packageName without a pathTemplate", func() {
+		lvBuild.Spec.PublishTarget.PathTemplate = ""
+		Expect(PublishPath(lvBuild)).To(Equal("web"))
+	})
+
+	It("renders the pathTemplate of the publishTarget", func() {
+		Expect(PublishPath(lvBuild)).To(Equal("default/buildpublish/web"))
+
+		lvBuild.Spec.PublishTarget.PathTemplate = "{{ .PackageName }}/r{{ .Revision }}/{{ .Version }}"
+		Expect(PublishPath(lvBuild)).To(Equal("web/r3/1.4.0"))
+	})
+
+	DescribeTable("refuses templates that don't render a valid path",
+		func(pathTemplate string) {
+			lvBuild.Spec.PublishTarget.PathTemplate = pathTemplate
+			Expect(PublishPath(lvBuild)).Error().To(HaveOccurred())
+		},
+		Entry("unparsable", "{{ .PackageName"),
+		Entry("unknown field", "{{ .Team }}/{{ .PackageName }}"),
+		Entry("empty", "{{ if false }}x{{ end }}"),
+		Entry("absolute", "/{{ .PackageName }}"),
+		Entry("trailing slash", "{{ .PackageName }}/"),
+		Entry("empty segment", "{{ .Namespace }}//{{ .PackageName }}"),
+		Entry("parent segment", "../{{ .PackageName }}"),
+		Entry("invalid character", "{{ .Namespace }}:{{ .PackageName }}"),
+	)
+
+	It("reports the path in status and gives it to the build containers", func() {
+		Expect(setPublishPath(lvBuild)).To(Succeed())
+		Expect(lvBuild.Status.PublishPath).To(Equal("default/buildpublish/web"))
+
+		job, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: publishPathEnv, Value: "default/buildpublish/web"}))
+
+		By("clearing it once the build doesn't publish anymore")
+		lvBuild.Spec.BuildType = jcrsv1.Build
+		Expect(setPublishPath(lvBuild)).To(Succeed())
+		Expect(lvBuild.Status.PublishPath).To(BeEmpty())
+	})
+
+	It("records the path of versions published off the packageName", func() {
+		lvBuild.Spec.ArtifactRetention = &jcrsv1.ArtifactRetention{Store: jcrsv1.OCIArtifactStore, KeepLast: ptr.To[int32](1)}
+		Expect(setPublishPath(lvBuild)).To(Succeed())
+		recordPublishedArtifact(lvBuild, &batchv1.Job{}, 1, true)
+		Expect(lvBuild.Status.PublishedArtifacts).To(HaveLen(1))
+		Expect(lvBuild.Status.PublishedArtifacts[0].Path).To(Equal("default/buildpublish/web"))
+		Expect(artifactPath(lvBuild, lvBuild.Status.PublishedArtifacts[0])).To(Equal("default/buildpublish/web"))
+
+		By("not recording the packageName")
+		Expect(artifactPath(lvBuild, jcrsv1.PublishedArtifact{Version: "1.0.0"})).To(Equal("web"))
+	})
+})
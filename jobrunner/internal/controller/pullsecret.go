@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+A registry pull Secret managed in one namespace is copied by the PullSecret
+controller into every selected namespace holding builds, and kept in sync with the
+source so rotating it is a single update. Build pods reference the copy in their
+imagePullSecrets, so teams don't have to distribute the credentials themselves.
+
+The copies are labeled as managed by the controller. A Secret of the same name
+that isn't labeled is left alone, and not replaced.
+*/
+
+const (
+	// pullSecretLabel marks the copies of the pull Secret managed by the controller
+	pullSecretLabel = "jcrs.jcrs.dev/pull-secret"
+	// pullSecretSourceAnnotation records the namespace/name of the Secret a copy is synced from
+	pullSecretSourceAnnotation = "jcrs.jcrs.dev/pull-secret-source"
+)
+
+// PullSecretConfig is the registry pull Secret distributed to the namespaces of builds.
+type PullSecretConfig struct {
+	// Source is the Secret that is copied. No Secret is distributed when its name is empty.
+	Source types.NamespacedName
+	// NamespaceSelector selects the namespaces the Secret is copied to, every
+	// namespace holding builds when nil.
+	NamespaceSelector labels.Selector
+}
+
+// ParsePullSecretConfig returns the PullSecretConfig of a Secret, formatted as
+// namespace/name, and an optional label selector of namespaces.
+func ParsePullSecretConfig(source, namespaceSelector string) (PullSecretConfig, error) {
+	var config PullSecretConfig
+	if source == "" {
+		return config, nil
+	}
+	namespace, name, ok := strings.Cut(source, "/")
+	if !ok || namespace == "" || name == "" {
+		return PullSecretConfig{}, fmt.Errorf("invalid pull Secret %q, expected namespace/name", source)
+	}
+	config.Source = types.NamespacedName{Namespace: namespace, Name: name}
+	if namespaceSelector != "" {
+		selector, err := labels.Parse(namespaceSelector)
+		if err != nil {
+			return PullSecretConfig{}, fmt.Errorf("invalid pull Secret namespace selector: %w", err)
+		}
+		config.NamespaceSelector = selector
+	}
+	return config, nil
+}
+
+// enabled reports whether a pull Secret is distributed.
+func (c PullSecretConfig) enabled() bool {
+	return c.Source.Name != "" && featuregates.Enabled(featuregates.PullSecretDistribution)
+}
+
+// selects reports whether the pull Secret is copied to ns, provided it holds builds.
+// The namespace of the source already holds the Secret.
+func (c PullSecretConfig) selects(ns *corev1.Namespace) bool {
+	switch {
+	case !c.enabled():
+		return false
+	case ns.Name == c.Source.Namespace:
+		return true
+	default:
+		return c.NamespaceSelector == nil || c.NamespaceSelector.Matches(labels.Set(ns.Labels))
+	}
+}
+
+// pullSecretSelected reports whether the builds of namespace get the pull Secret.
+func (r *LeviathanBuildReconciler) pullSecretSelected(ctx context.Context, namespace string) (bool, error) {
+	if !r.PullSecret.enabled() {
+		return false, nil
+	}
+	var ns corev1.Namespace
+	if err := r.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return r.PullSecret.selects(&ns), nil
+}
+
+// addPullSecret adds the pull Secret to the imagePullSecrets of the build Job.
+func addPullSecret(job *batchv1.Job, name string) {
+	podSpec := &job.Spec.Template.Spec
+	ref := corev1.LocalObjectReference{Name: name}
+	if !slices.Contains(podSpec.ImagePullSecrets, ref) {
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, ref)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// PullSecretReconciler copies the registry pull Secret into the namespaces of LeviathanBuild objects
+type PullSecretReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// APIReader reads Secrets from the API server. Secrets aren't cached, to keep
+	// the credentials of the whole cluster out of the memory of the controller.
+	APIReader client.Reader
+
+	Config PullSecretConfig
+}
+
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=get;list;watch
+
+// Reconcile syncs the copy of the pull Secret in a namespace: it is created or
+// updated while the namespace is selected and holds builds, and deleted otherwise.
+func (r *PullSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var ns corev1.Namespace
+	if err := r.Get(ctx, req.NamespacedName, &ns); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if ns.Name == r.Config.Source.Namespace || ns.DeletionTimestamp != nil || ns.Status.Phase == corev1.NamespaceTerminating {
+		return ctrl.Result{}, nil
+	}
+
+	var builds jcrsv1.LeviathanBuildList
+	if err := r.List(ctx, &builds, client.InNamespace(ns.Name)); err != nil {
+		log.Error(err, "Failed to list LeviathanBuilds")
+		return ctrl.Result{}, err
+	}
+	wanted := len(builds.Items) > 0 && r.Config.selects(&ns)
+
+	key := client.ObjectKey{Namespace: ns.Name, Name: r.Config.Source.Name}
+	existing := &corev1.Secret{}
+	if err := r.APIReader.Get(ctx, key, existing); apierrors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		log.Error(err, "Failed to get pull Secret copy")
+		return ctrl.Result{}, err
+	} else if existing.Labels[pullSecretLabel] != "true" {
+		log.Info("Not replacing a Secret that isn't managed by the controller", "Secret", key.Name)
+		return ctrl.Result{}, nil
+	}
+
+	if !wanted {
+		if existing == nil {
+			return ctrl.Result{}, nil
+		}
+		log.Info("Deleting pull Secret copy", "Secret", key.Name)
+		return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, existing))
+	}
+
+	var source corev1.Secret
+	if err := r.APIReader.Get(ctx, r.Config.Source, &source); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The source is watched, so the copies are synced once it is created
+			log.Info("Pull Secret not found", "Secret", r.Config.Source)
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get pull Secret", "Secret", r.Config.Source)
+		return ctrl.Result{}, err
+	}
+
+	// The type of a Secret is immutable, a copy of another type is replaced
+	if existing != nil && existing.Type != source.Type {
+		if err := r.Delete(ctx, existing); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		existing = nil
+	}
+	if existing == nil {
+		log.Info("Creating pull Secret copy", "Secret", key.Name)
+		return ctrl.Result{}, r.Create(ctx, pullSecretCopy(&source, ns.Name))
+	}
+	if equality.Semantic.DeepEqual(existing.Data, source.Data) {
+		return ctrl.Result{}, nil
+	}
+	log.Info("Updating pull Secret copy", "Secret", key.Name)
+	existing.Data = source.Data
+	return ctrl.Result{}, r.Update(ctx, existing)
+}
+
+// pullSecretCopy returns the copy of source in namespace.
+func pullSecretCopy(source *corev1.Secret, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        source.Name,
+			Namespace:   namespace,
+			Labels:      map[string]string{pullSecretLabel: "true"},
+			Annotations: map[string]string{pullSecretSourceAnnotation: source.Namespace + "/" + source.Name},
+		},
+		Type: source.Type,
+		Data: source.Data,
+	}
+}
+
+// namespacesWithBuilds maps the source pull Secret to every namespace holding builds.
+func (r *PullSecretReconciler) namespacesWithBuilds(ctx context.Context, _ client.Object) []reconcile.Request {
+	var builds jcrsv1.LeviathanBuildList
+	if err := r.List(ctx, &builds); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list LeviathanBuilds")
+		return nil
+	}
+	seen := make(map[string]bool)
+	var requests []reconcile.Request
+	for _, lvBuild := range builds.Items {
+		if !seen[lvBuild.Namespace] {
+			seen[lvBuild.Namespace] = true
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: lvBuild.Namespace}})
+		}
+	}
+	return requests
+}
+
+// namespaceOf maps an object to its namespace.
+func namespaceOf(_ context.Context, obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PullSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Only the creation and deletion of builds change whether a namespace holds any
+	buildsAddedOrRemoved := predicate.Funcs{
+		UpdateFunc: func(event.UpdateEvent) bool { return false },
+	}
+	// Secrets are only watched through their metadata, and only the source and its copies matter
+	isSource := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == r.Config.Source.Namespace && obj.GetName() == r.Config.Source.Name
+	})
+	isCopy := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[pullSecretLabel] == "true"
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}).
+		Watches(&jcrsv1.LeviathanBuild{}, handler.EnqueueRequestsFromMapFunc(namespaceOf),
+			builder.WithPredicates(buildsAddedOrRemoved)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.namespacesWithBuilds),
+			builder.OnlyMetadata, builder.WithPredicates(isSource)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(namespaceOf),
+			builder.OnlyMetadata, builder.WithPredicates(isCopy)).
+		Named("pullsecret").
+		Complete(r)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Pull Secret distribution", func() {
+	var (
+		c      client.Client
+		r      *PullSecretReconciler
+		source *corev1.Secret
+	)
+
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	build := func(ns string) *jcrsv1.LeviathanBuild {
+		return utiltesting.MakeLeviathanBuild("web", ns).Obj()
+	}
+	reconcileNamespace := func(name string) {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+	getCopy := func(ns string) (*corev1.Secret, error) {
+		secret := &corev1.Secret{}
+		return secret, c.Get(context.Background(), client.ObjectKey{Namespace: ns, Name: "registry"}, secret)
+	}
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PullSecretDistribution: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PullSecretDistribution: false})).To(Succeed())
+		})
+
+		source = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "jobrunner-system"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+		}
+		c = newFakeClientBuilder().WithObjects(
+			source,
+			namespace("jobrunner-system", nil),
+			namespace("team-a", map[string]string{"builds": "true"}),
+			namespace("team-b", map[string]string{"builds": "true"}),
+			namespace("team-c", nil),
+			build("team-a"), build("team-c"),
+		).Build()
+
+		config, err := ParsePullSecretConfig("jobrunner-system/registry", "builds=true")
+		Expect(err).NotTo(HaveOccurred())
+		r = &PullSecretReconciler{Client: c, Scheme: c.Scheme(), APIReader: c, Config: config}
+	})
+
+	It("copies the Secret into the selected namespaces holding builds", func() {
+		for _, ns := range []string{"jobrunner-system", "team-a", "team-b", "team-c"} {
+			reconcileNamespace(ns)
+		}
+		secret, err := getCopy("team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Type).To(Equal(corev1.SecretTypeDockerConfigJson))
+		Expect(secret.Data).To(Equal(source.Data))
+		Expect(secret.Labels).To(HaveKeyWithValue(pullSecretLabel, "true"))
+
+		for _, ns := range []string{"team-b", "team-c"} {
+			_, err := getCopy(ns)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue(), ns)
+		}
+	})
+
+	It("rotates the copies and deletes them once the namespace has no builds", func() {
+		reconcileNamespace("team-a")
+		source.Data[corev1.DockerConfigJsonKey] = []byte(`{"auths":{"registry.example.com":{}}}`)
+		Expect(c.Update(context.Background(), source)).To(Succeed())
+		reconcileNamespace("team-a")
+		secret, err := getCopy("team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Data).To(Equal(source.Data))
+
+		Expect(c.Delete(context.Background(), build("team-a"))).To(Succeed())
+		reconcileNamespace("team-a")
+		_, err = getCopy("team-a")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("leaves Secrets it doesn't manage alone", func() {
+		own := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "team-a"}, StringData: map[string]string{"token": "team"}}
+		Expect(c.Create(context.Background(), own)).To(Succeed())
+		reconcileNamespace("team-a")
+		secret, err := getCopy("team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Labels).NotTo(HaveKey(pullSecretLabel))
+	})
+
+	It("attaches the Secret to the pods of the selected namespaces", func() {
+		lr := &LeviathanBuildReconciler{Client: c, PullSecret: r.Config}
+		selected, err := lr.pullSecretSelected(context.Background(), "team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(selected).To(BeTrue())
+		selected, err = lr.pullSecretSelected(context.Background(), "team-c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(selected).To(BeFalse())
+
+		job := &batchv1.Job{}
+		job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry"}}
+		addPullSecret(job, "registry")
+		Expect(job.Spec.Template.Spec.ImagePullSecrets).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+/*
+The reconcile latency histogram is broken down by package, which tells which
+kind of builds are slow but not which build. The ReconcileStats keep how often
+and how long each build was reconciled, since the controller started or since
+the build was last deleted, and serve them on /debug/controller, the builds that
+took the most reconcile time first. The endpoint answers which handful of builds
+out of thousands the controller spends its time on.
+*/
+
+// ReconcileStatsPath is the path the ReconcileStats are served on
+const ReconcileStatsPath = "/debug/controller"
+
+// defaultReconcileStatsLimit is how many builds are served unless the request asks for a limit
+const defaultReconcileStatsLimit = 100
+
+// ObjectReconcileStats are the reconcile stats of a build.
+type ObjectReconcileStats struct {
+	Namespace      string    `json:"namespace"`
+	Name           string    `json:"name"`
+	Reconciles     int64     `json:"reconciles"`
+	Errors         int64     `json:"errors"`
+	TotalSeconds   float64   `json:"totalSeconds"`
+	MaxSeconds     float64   `json:"maxSeconds"`
+	LastSeconds    float64   `json:"lastSeconds"`
+	LastReconciled time.Time `json:"lastReconciled"`
+	LastError      string    `json:"lastError,omitempty"`
+}
+
+// ReconcileStats keeps the reconcile stats of every build.
+type ReconcileStats struct {
+	mu      sync.Mutex
+	objects map[types.NamespacedName]*ObjectReconcileStats
+}
+
+// NewReconcileStats returns empty ReconcileStats.
+func NewReconcileStats() *ReconcileStats {
+	return &ReconcileStats{objects: make(map[types.NamespacedName]*ObjectReconcileStats)}
+}
+
+// Observe records a reconcile of the build key that took duration and failed
+// with err, if not nil. A nil ReconcileStats records nothing.
+func (s *ReconcileStats) Observe(key types.NamespacedName, duration time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, ok := s.objects[key]
+	if !ok {
+		stats = &ObjectReconcileStats{Namespace: key.Namespace, Name: key.Name}
+		s.objects[key] = stats
+	}
+	seconds := duration.Seconds()
+	stats.Reconciles++
+	stats.TotalSeconds += seconds
+	stats.MaxSeconds = max(stats.MaxSeconds, seconds)
+	stats.LastSeconds = seconds
+	stats.LastReconciled = time.Now()
+	stats.LastError = ""
+	if err != nil {
+		stats.Errors++
+		stats.LastError = err.Error()
+	}
+}
+
+// Forget drops the stats of the build key.
+func (s *ReconcileStats) Forget(key types.NamespacedName) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+}
+
+// reconcileStatsOrders are the orders the stats can be sorted in, by the sort parameter
+var reconcileStatsOrders = map[string]func(a, b *ObjectReconcileStats) bool{
+	"total":      func(a, b *ObjectReconcileStats) bool { return a.TotalSeconds > b.TotalSeconds },
+	"max":        func(a, b *ObjectReconcileStats) bool { return a.MaxSeconds > b.MaxSeconds },
+	"reconciles": func(a, b *ObjectReconcileStats) bool { return a.Reconciles > b.Reconciles },
+	"errors":     func(a, b *ObjectReconcileStats) bool { return a.Errors > b.Errors },
+}
+
+// reconcileStatsResponse is the body served on ReconcileStatsPath
+type reconcileStatsResponse struct {
+	// Tracked is how many builds have stats, of which Objects holds at most the limit
+	Tracked int                    `json:"tracked"`
+	Objects []ObjectReconcileStats `json:"objects"`
+}
+
+// ServeHTTP serves the stats as JSON. The sort parameter orders them by total,
+// max, reconciles or errors, total by default, and the limit parameter caps how
+// many builds are served, 0 serving all of them.
+func (s *ReconcileStats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	order := reconcileStatsOrders["total"]
+	if sort := r.URL.Query().Get("sort"); sort != "" {
+		var ok bool
+		if order, ok = reconcileStatsOrders[sort]; !ok {
+			http.Error(w, "sort must be one of total, max, reconciles or errors", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultReconcileStatsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	response := reconcileStatsResponse{Tracked: len(s.objects), Objects: make([]ObjectReconcileStats, 0, len(s.objects))}
+	for _, stats := range s.objects {
+		response.Objects = append(response.Objects, *stats)
+	}
+	s.mu.Unlock()
+
+	slices.SortFunc(response.Objects, func(a, b ObjectReconcileStats) int {
+		switch {
+		case order(&a, &b):
+			return -1
+		case order(&b, &a):
+			return 1
+		case a.Namespace != b.Namespace:
+			if a.Namespace < b.Namespace {
+				return -1
+			}
+			return 1
+		case a.Name < b.Name:
+			return -1
+		case a.Name > b.Name:
+			return 1
+		}
+		return 0
+	})
+	if limit > 0 && len(response.Objects) > limit {
+		response.Objects = response.Objects[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
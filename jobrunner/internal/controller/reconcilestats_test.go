@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Reconcile stats", func() {
+	var stats *ReconcileStats
+
+	web := types.NamespacedName{Namespace: "default", Name: "web"}
+	api := types.NamespacedName{Namespace: "default", Name: "api"}
+
+	serve := func(query string) (int, reconcileStatsResponse) {
+		recorder := httptest.NewRecorder()
+		stats.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ReconcileStatsPath+query, nil))
+		var response reconcileStatsResponse
+		if recorder.Code == http.StatusOK {
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+		}
+		return recorder.Code, response
+	}
+
+	BeforeEach(func() {
+		stats = NewReconcileStats()
+		stats.Observe(web, time.Second, nil)
+		stats.Observe(web, 3*time.Second, errors.New("registry unavailable"))
+		stats.Observe(api, 500*time.Millisecond, nil)
+		stats.Observe(api, 500*time.Millisecond, nil)
+		stats.Observe(api, 500*time.Millisecond, nil)
+	})
+
+	It("serves the builds that took the most reconcile time first", func() {
+		code, response := serve("")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(response.Tracked).To(Equal(2))
+		Expect(response.Objects).To(HaveLen(2))
+		first := response.Objects[0]
+		Expect(first.Name).To(Equal("web"))
+		Expect(first.Reconciles).To(BeEquivalentTo(2))
+		Expect(first.Errors).To(BeEquivalentTo(1))
+		Expect(first.TotalSeconds).To(BeNumerically("~", 4))
+		Expect(first.MaxSeconds).To(BeNumerically("~", 3))
+		Expect(first.LastSeconds).To(BeNumerically("~", 3))
+		Expect(first.LastError).To(Equal("registry unavailable"))
+		Expect(response.Objects[1].Name).To(Equal("api"))
+	})
+
+	It("sorts and limits the builds as asked", func() {
+		_, response := serve("?sort=reconciles&limit=1")
+		Expect(response.Tracked).To(Equal(2))
+		Expect(response.Objects).To(HaveLen(1))
+		Expect(response.Objects[0].Name).To(Equal("api"))
+	})
+
+	It("rejects an unknown order or limit", func() {
+		code, _ := serve("?sort=name")
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = serve("?limit=-1")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("forgets deleted builds", func() {
+		stats.Forget(web)
+		_, response := serve("")
+		Expect(response.Tracked).To(Equal(1))
+		Expect(response.Objects[0].Name).To(Equal("api"))
+	})
+})
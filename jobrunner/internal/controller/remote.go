@@ -0,0 +1,519 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Builds with a clusterSelector run their Jobs in a remote cluster, registered as a
+ClusterTarget whose kubeconfig is read from a Secret. The Job of a run is
+constructed as for any other build, and created in the cluster of the first
+Ready ClusterTarget selected, by name, unless the cluster of the latest run is
+still selected. The name of that ClusterTarget is recorded in status.cluster.
+
+Owner references can't cross clusters: remote Jobs are labeled with the UID of
+their build instead, and deleted through a finalizer when the build is. As for
+other engines, new runs pass the checks of checkNewRun and hold the mutex of
+their build, see acquireRunMutex, while they run, remote Jobs aren't watched,
+running builds are checked again every 30 seconds, and what acts on the Job once
+it is created only applies to Jobs of the cluster of the controller.
+
+The remote cluster must hold the ConfigMaps and Secrets the Job references. The
+webhook refuses builds whose Job references objects of the controller, such as
+the ConfigMap of an inline script, and the registry pull Secret distributed by
+the controller is copied to the namespace of the Job.
+*/
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=clustertargets,verbs=get;list;watch
+
+const (
+	// remoteJobsFinalizer holds the deletion of a build until its Jobs in remote
+	// clusters have been deleted
+	remoteJobsFinalizer = "jcrs.jcrs.dev/remote-jobs"
+
+	// originLabel is the UID of the build a Job of a remote cluster was created for
+	originLabel = "jcrs.jcrs.dev/origin-uid"
+
+	// originAnnotation is the namespace and name of the build a Job of a remote
+	// cluster was created for
+	originAnnotation = "jcrs.jcrs.dev/origin"
+
+	// kubeconfigKey is the key of the kubeconfig in the Secret of a ClusterTarget
+	kubeconfigKey = "kubeconfig"
+
+	// remoteClusterTimeout bounds the requests made to remote clusters
+	remoteClusterTimeout = 10 * time.Second
+)
+
+// Cluster is a remote cluster the Jobs of builds are dispatched to.
+type Cluster struct {
+	client.Client
+
+	// ServerVersion returns the version of the API server of the cluster.
+	ServerVersion func() (string, error)
+}
+
+// NewCluster connects to the cluster of config.
+func NewCluster(config *rest.Config) (*Cluster, error) {
+	config = rest.CopyConfig(config)
+	config.Timeout = remoteClusterTimeout
+	c, err := client.New(config, client.Options{Scheme: clientgoscheme.Scheme})
+	if err != nil {
+		return nil, err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Cluster{
+		Client: c,
+		ServerVersion: func() (string, error) {
+			info, err := dc.ServerVersion()
+			if err != nil {
+				return "", err
+			}
+			return info.GitVersion, nil
+		},
+	}, nil
+}
+
+// kubeconfigError reports a kubeconfig Secret that is missing or can't be used.
+type kubeconfigError struct {
+	err error
+}
+
+func (e *kubeconfigError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kubeconfigError) Unwrap() error {
+	return e.err
+}
+
+// isKubeconfigError reports whether err is a kubeconfig Secret that is missing or can't be used.
+func isKubeconfigError(err error) bool {
+	var kerr *kubeconfigError
+	return errors.As(err, &kerr)
+}
+
+// registeredCluster is a cluster connected with a version of a kubeconfig Secret.
+type registeredCluster struct {
+	secretVersion string
+	cluster       *Cluster
+}
+
+// ClusterRegistry connects to the clusters of ClusterTargets. Connections are kept
+// until the kubeconfig Secret of their ClusterTarget changes.
+type ClusterRegistry struct {
+	// Reader reads the kubeconfig Secrets of ClusterTargets. Secrets aren't cached,
+	// to keep the credentials of the whole cluster out of the memory of the controller.
+	Reader client.Reader
+
+	// Connect connects to the cluster of a kubeconfig. NewCluster is used when nil.
+	Connect func(*rest.Config) (*Cluster, error)
+
+	mu       sync.Mutex
+	clusters map[string]registeredCluster
+}
+
+// NewClusterRegistry returns a ClusterRegistry reading kubeconfig Secrets with reader.
+func NewClusterRegistry(reader client.Reader) *ClusterRegistry {
+	return &ClusterRegistry{Reader: reader}
+}
+
+// Cluster returns the cluster of target. A kubeconfig Secret that is missing or
+// invalid is reported as a *kubeconfigError.
+func (r *ClusterRegistry) Cluster(ctx context.Context, target *jcrsv1.ClusterTarget) (*Cluster, error) {
+	ref := target.Spec.KubeconfigSecretRef
+	var secret corev1.Secret
+	if err := r.Reader.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, &secret); apierrors.IsNotFound(err) {
+		return nil, &kubeconfigError{fmt.Errorf("kubeconfig Secret %s/%s not found", ref.Namespace, ref.Name)}
+	} else if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if registered, ok := r.clusters[target.Name]; ok && registered.secretVersion == secret.ResourceVersion {
+		return registered.cluster, nil
+	}
+	data, ok := secret.Data[kubeconfigKey]
+	if !ok {
+		return nil, &kubeconfigError{fmt.Errorf("kubeconfig Secret %s/%s has no %s key", ref.Namespace, ref.Name, kubeconfigKey)}
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return nil, &kubeconfigError{fmt.Errorf("invalid kubeconfig in Secret %s/%s: %w", ref.Namespace, ref.Name, err)}
+	}
+	connect := r.Connect
+	if connect == nil {
+		connect = NewCluster
+	}
+	cluster, err := connect(config)
+	if err != nil {
+		return nil, &kubeconfigError{fmt.Errorf("invalid kubeconfig in Secret %s/%s: %w", ref.Namespace, ref.Name, err)}
+	}
+	if r.clusters == nil {
+		r.clusters = map[string]registeredCluster{}
+	}
+	r.clusters[target.Name] = registeredCluster{secretVersion: secret.ResourceVersion, cluster: cluster}
+	return cluster, nil
+}
+
+// Forget drops the connection to the cluster of the ClusterTarget name.
+func (r *ClusterRegistry) Forget(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clusters, name)
+}
+
+// remoteNamespace returns the namespace of the cluster of target the Jobs of lvBuild are created in.
+func remoteNamespace(target *jcrsv1.ClusterTarget, lvBuild *jcrsv1.LeviathanBuild) string {
+	if target.Spec.Namespace != "" {
+		return target.Spec.Namespace
+	}
+	return lvBuild.Namespace
+}
+
+// setDispatched sets the Dispatched condition of lvBuild.
+func setDispatched(lvBuild *jcrsv1.LeviathanBuild, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionDispatched,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: lvBuild.Generation,
+	})
+}
+
+// reconcileRemoteJobsFinalizer adds the finalizer of remote Jobs to the builds
+// with a clusterSelector, and deletes the remote Jobs of lvBuild once it is being
+// deleted.
+func (r *LeviathanBuildReconciler) reconcileRemoteJobsFinalizer(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) error {
+	if lvBuild.DeletionTimestamp.IsZero() {
+		if lvBuild.Spec.ClusterSelector != nil && r.Clusters != nil && controllerutil.AddFinalizer(lvBuild, remoteJobsFinalizer) {
+			return r.Update(ctx, lvBuild)
+		}
+		return nil
+	}
+	if !controllerutil.ContainsFinalizer(lvBuild, remoteJobsFinalizer) {
+		return nil
+	}
+
+	// Builds are released even when remote clusters have been turned off since the finalizer was added
+	if r.Clusters != nil && lvBuild.Status.Cluster != "" {
+		if err := r.deleteRemoteJobs(ctx, lvBuild, lvBuild.Status.Cluster); err != nil {
+			return err
+		}
+	}
+	controllerutil.RemoveFinalizer(lvBuild, remoteJobsFinalizer)
+	return r.Update(ctx, lvBuild)
+}
+
+// deleteRemoteJobs deletes the Jobs of lvBuild from the cluster of the ClusterTarget
+// name. The Jobs of a ClusterTarget that no longer exists are left behind.
+func (r *LeviathanBuildReconciler) deleteRemoteJobs(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, name string) error {
+	var target jcrsv1.ClusterTarget
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, &target); apierrors.IsNotFound(err) {
+		logf.FromContext(ctx).Info("ClusterTarget not found, leaving its Jobs behind", "clusterTarget", name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	cluster, err := r.Clusters.Cluster(ctx, &target)
+	if err != nil {
+		return err
+	}
+	var jobs batchv1.JobList
+	if err := cluster.List(ctx, &jobs, client.InNamespace(remoteNamespace(&target, lvBuild)), client.MatchingLabels{originLabel: string(lvBuild.UID)}); err != nil {
+		return err
+	}
+	for i := range jobs.Items {
+		logf.FromContext(ctx).Info("Deleting Job of the build in a remote cluster", "clusterTarget", name, "name", jobs.Items[i].Name)
+		if err := cluster.Delete(ctx, &jobs.Items[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectClusterTarget returns the ClusterTarget the next run of lvBuild is
+// dispatched to, nil when no Ready ClusterTarget is selected.
+func (r *LeviathanBuildReconciler) selectClusterTarget(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (*jcrsv1.ClusterTarget, error) {
+	selector, err := metav1.LabelSelectorAsSelector(lvBuild.Spec.ClusterSelector)
+	if err != nil {
+		// Nothing is selected until the selector is fixed
+		logf.FromContext(ctx).Info("Invalid cluster selector", "error", err.Error())
+		return nil, nil
+	}
+	var targets jcrsv1.ClusterTargetList
+	if err := r.List(ctx, &targets, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	var selected *jcrsv1.ClusterTarget
+	for i := range targets.Items {
+		target := &targets.Items[i]
+		if target.Spec.Paused || !meta.IsStatusConditionTrue(target.Status.Conditions, jcrsv1.ConditionReady) {
+			continue
+		}
+		// The cluster of the latest run is kept while it is selected
+		if target.Name == lvBuild.Status.Cluster {
+			return target, nil
+		}
+		if selected == nil || target.Name < selected.Name {
+			selected = target
+		}
+	}
+	return selected, nil
+}
+
+// latestRemoteJob returns the Job of the latest run of lvBuild in namespace of
+// cluster, if any, and its run index.
+func latestRemoteJob(ctx context.Context, cluster *Cluster, lvBuild *jcrsv1.LeviathanBuild, namespace string) (*batchv1.Job, int64, error) {
+	var jobs batchv1.JobList
+	if err := cluster.List(ctx, &jobs, client.InNamespace(namespace), client.MatchingLabels{originLabel: string(lvBuild.UID)}); err != nil {
+		return nil, 0, err
+	}
+	var latest *batchv1.Job
+	var latestIndex int64
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		index, err := strconv.ParseInt(job.Labels[runIndexLabel], 10, 64)
+		if err != nil {
+			continue
+		}
+		if latest == nil || index > latestIndex {
+			latest, latestIndex = job, index
+		}
+	}
+	return latest, latestIndex, nil
+}
+
+// reconcileRemoteRun runs desiredJob in the remote cluster selected by the
+// clusterSelector of lvBuild, and sets the status of lvBuild from the remote Job.
+// No new run is started while window is open.
+func (r *LeviathanBuildReconciler) reconcileRemoteRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, desiredJob *batchv1.Job, observed *jcrsv1.LeviathanBuildStatus, window *openMaintenanceWindow) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	undispatched := func(reason, message string) (ctrl.Result, error) {
+		log.Info("Build can't be dispatched to a remote cluster", "reason", message)
+		setDispatched(lvBuild, metav1.ConditionFalse, reason, message)
+		setBlocked(lvBuild, jcrsv1.BlockedByClusterTarget, jcrsv1.ConditionDispatched)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: backendRecheckInterval}, nil
+	}
+
+	if r.Clusters == nil {
+		return undispatched(jcrsv1.ReasonFeatureDisabled, "remote clusters aren't enabled in the controller")
+	}
+	hash, err := jobSpecHash(desiredJob)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The latest run is followed in the cluster it was dispatched to
+	var target *jcrsv1.ClusterTarget
+	var cluster *Cluster
+	var latest *batchv1.Job
+	var latestRunIndex int64
+	if name := lvBuild.Status.Cluster; name != "" {
+		target = &jcrsv1.ClusterTarget{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, target); apierrors.IsNotFound(err) {
+			log.Info("ClusterTarget of the latest run not found, its Jobs are lost", "clusterTarget", name)
+			target = nil
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if target != nil {
+		if cluster, err = r.Clusters.Cluster(ctx, target); err != nil {
+			return ctrl.Result{}, err
+		}
+		if latest, latestRunIndex, err = latestRemoteJob(ctx, cluster, lvBuild, remoteNamespace(target, lvBuild)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// An outdated run is replaced, unless the build is suspended or a MaintenanceWindow is open
+	outdated := latest != nil && latest.Annotations[jobSpecHashAnnotation] != hash
+	if outdated && !lvBuild.Spec.Suspend && window == nil {
+		log.Info("Remote Job doesn't match desired state, deleting it", "clusterTarget", target.Name, "namespace", latest.Namespace, "name", latest.Name)
+		r.event(lvBuild, corev1.EventTypeNormal, jobOutOfDateReason, "Replacing Job %s in cluster %s", latest.Name, target.Name)
+		if err := cluster.Delete(ctx, latest, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		latest = nil
+	}
+
+	if latest == nil {
+		next, err := r.selectClusterTarget(ctx, lvBuild)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if next == nil {
+			return undispatched(jcrsv1.ReasonNoClusterTarget, "no Ready ClusterTarget is selected by spec.clusterSelector")
+		}
+		// A build moving to another cluster leaves no Jobs behind in the previous one
+		if target != nil && next.Name != target.Name {
+			if err := r.deleteRemoteJobs(ctx, lvBuild, target.Name); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		if target == nil || next.Name != target.Name {
+			if cluster, err = r.Clusters.Cluster(ctx, next); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		target = next
+
+		runIndex := max(lvBuild.Status.RunIndex, latestRunIndex) + 1
+		job := desiredJob.DeepCopy()
+		job.Namespace = remoteNamespace(target, lvBuild)
+		job.GenerateName = jobGenerateName(lvBuild, runIndex)
+		job.OwnerReferences = nil
+		setRunLabels(lvBuild, job, runIndex)
+		job.Labels[originLabel] = string(lvBuild.UID)
+		if job.Annotations == nil {
+			job.Annotations = map[string]string{}
+		}
+		job.Annotations[jobSpecHashAnnotation] = hash
+		job.Annotations[originAnnotation] = lvBuild.Namespace + "/" + lvBuild.Name
+		if proceed, result, err := r.checkNewRun(ctx, lvBuild, observed, window); !proceed {
+			return result, err
+		}
+		if proceed, result, err := r.acquireRunMutex(ctx, lvBuild, observed); !proceed {
+			return result, err
+		}
+		if err := r.copyPullSecret(ctx, cluster, job); err != nil {
+			r.abandonRun(ctx, lvBuild)
+			return ctrl.Result{}, err
+		}
+		if err := r.startRun(ctx, lvBuild, job, runIndex); err != nil {
+			r.abandonRun(ctx, lvBuild)
+			return ctrl.Result{}, err
+		}
+		log.Info("Creating a new Job in a remote cluster", "clusterTarget", target.Name, "namespace", job.Namespace, "generateName", job.GenerateName)
+		if err := cluster.Create(ctx, job); err != nil {
+			r.abandonRun(ctx, lvBuild)
+			return ctrl.Result{}, err
+		}
+		lvBuild.Status.Cluster = target.Name
+		message := fmt.Sprintf("Job %s/%s created in cluster %s", job.Namespace, job.Name, target.Name)
+		setDispatched(lvBuild, metav1.ConditionTrue, jcrsv1.ReasonDispatched, message)
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRunning, "Job "+job.Name+" is running in cluster "+target.Name)
+		if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: backendRecheckInterval}, nil
+	}
+
+	result := ctrl.Result{}
+	name := "Job " + latest.Name
+	finished, finishedType := isJobFinished(latest)
+	switch {
+	case !finished:
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRunning, name+" is running in cluster "+target.Name)
+		result.RequeueAfter = backendRecheckInterval
+	case finishedType == batchv1.JobComplete:
+		jcrsv1.MarkSucceeded(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonJobComplete, name+" completed in cluster "+target.Name)
+		recordRecentRun(lvBuild, latest, latestRunIndex, finishedType)
+	default:
+		jcrsv1.MarkFailed(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonJobFailed, name+" failed in cluster "+target.Name)
+		recordRecentRun(lvBuild, latest, latestRunIndex, finishedType)
+	}
+	// The mutex is held for as long as the remote Job runs
+	if finished {
+		if err := r.releaseMutex(ctx, lvBuild); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if _, err := r.renewMutex(ctx, lvBuild); err != nil {
+		return ctrl.Result{}, err
+	}
+	if outdated {
+		holdOutdatedRun(lvBuild, window, &result)
+	}
+	if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// copyPullSecret copies the registry pull Secret distributed by the controller to
+// the namespace of job in cluster, when job references it. As in the cluster of
+// the controller, a Secret of the same name that isn't a managed copy is left alone.
+func (r *LeviathanBuildReconciler) copyPullSecret(ctx context.Context, cluster *Cluster, job *batchv1.Job) error {
+	name := r.PullSecret.Source.Name
+	if !r.PullSecret.enabled() || !slices.Contains(job.Spec.Template.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: name}) {
+		return nil
+	}
+	var source corev1.Secret
+	if err := r.Get(ctx, r.PullSecret.Source, &source); err != nil {
+		return fmt.Errorf("reading pull Secret: %w", err)
+	}
+	desired := pullSecretCopy(&source, job.Namespace)
+	existing := &corev1.Secret{}
+	if err := cluster.Get(ctx, client.ObjectKeyFromObject(desired), existing); apierrors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		return err
+	} else if existing.Labels[pullSecretLabel] != "true" {
+		return nil
+	}
+
+	// The type of a Secret is immutable, a copy of another type is replaced
+	if existing != nil && existing.Type != desired.Type {
+		if err := cluster.Delete(ctx, existing); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		existing = nil
+	}
+	if existing == nil {
+		logf.FromContext(ctx).Info("Copying pull Secret to a remote cluster", "namespace", desired.Namespace, "Secret", desired.Name)
+		return cluster.Create(ctx, desired)
+	}
+	if equality.Semantic.DeepEqual(existing.Data, desired.Data) {
+		return nil
+	}
+	existing.Data = desired.Data
+	return cluster.Update(ctx, existing)
+}
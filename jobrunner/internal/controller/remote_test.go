@@ -0,0 +1,262 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+// kubeconfigFor returns a kubeconfig of the API server at server.
+func kubeconfigFor(server string) []byte {
+	return []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: remote
+  cluster:
+    server: ` + server + `
+contexts:
+- name: remote
+  context:
+    cluster: remote
+current-context: remote
+`)
+}
+
+// fakeClusters connects to fake clusters, by the address of their API server.
+type fakeClusters map[string]*Cluster
+
+func (c fakeClusters) connect(config *rest.Config) (*Cluster, error) {
+	cluster, ok := c[config.Host]
+	if !ok {
+		return nil, errors.New("no such cluster")
+	}
+	return cluster, nil
+}
+
+func newFakeCluster(version string) *Cluster {
+	return &Cluster{
+		Client: fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build(),
+		ServerVersion: func() (string, error) {
+			if version == "" {
+				return "", errors.New("connection refused")
+			}
+			return version, nil
+		},
+	}
+}
+
+var _ = Describe("Remote clusters", func() {
+	var (
+		ctx      context.Context
+		r        *LeviathanBuildReconciler
+		lvBuild  *jcrsv1.LeviathanBuild
+		job      *batchv1.Job
+		eu, us   *Cluster
+		clusters fakeClusters
+	)
+
+	target := func(name, server string, ready bool) []client.Object {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "jobrunner-system", Name: name},
+			Data:       map[string][]byte{kubeconfigKey: kubeconfigFor(server)},
+		}
+		t := &jcrsv1.ClusterTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pool": "builds"}},
+			Spec: jcrsv1.ClusterTargetSpec{
+				KubeconfigSecretRef: jcrsv1.SecretReference{Namespace: "jobrunner-system", Name: name},
+				Namespace:           "builds",
+			},
+		}
+		status := metav1.ConditionFalse
+		if ready {
+			status = metav1.ConditionTrue
+		}
+		jcrsv1.SetReady(&t.Status.Conditions, status, 0, jcrsv1.ReasonClusterReachable, "")
+		return []client.Object{secret, t}
+	}
+	remoteJobs := func(cluster *Cluster) []batchv1.Job {
+		var jobs batchv1.JobList
+		Expect(cluster.List(ctx, &jobs, client.InNamespace("builds"))).To(Succeed())
+		return jobs.Items
+	}
+	reconcile := func() {
+		observed := lvBuild.Status.DeepCopy()
+		_, err := r.reconcileRemoteRun(ctx, lvBuild, job, observed, nil)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	dispatched := func() *metav1.Condition {
+		return meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionDispatched)
+	}
+	setup := func(objs ...client.Object) {
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "ci").Obj()
+		lvBuild.Spec.ClusterSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "builds"}}
+		objs = append(objs, lvBuild)
+		c := newFakeClient(objs...)
+		r = &LeviathanBuildReconciler{
+			Client:   c,
+			Scheme:   c.Scheme(),
+			Clusters: &ClusterRegistry{Reader: c, Connect: clusters.connect},
+		}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(lvBuild), lvBuild)).To(Succeed())
+
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Labels: map[string]string{}, Annotations: map[string]string{}}}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build", Image: "golang:1.24", Command: []string{"make"}}}
+		Expect(controllerutil.SetControllerReference(lvBuild, job, r.Scheme)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		eu, us = newFakeCluster("v1.33.1"), newFakeCluster("v1.33.1")
+		clusters = fakeClusters{"https://eu.example.com": eu, "https://us.example.com": us}
+	})
+
+	It("creates the Job of the build in the first Ready cluster selected", func() {
+		objs := append(target("us", "https://us.example.com", true), target("eu", "https://eu.example.com", true)...)
+		setup(objs...)
+		reconcile()
+
+		Expect(remoteJobs(us)).To(BeEmpty())
+		jobs := remoteJobs(eu)
+		Expect(jobs).To(HaveLen(1))
+		Expect(jobs[0].OwnerReferences).To(BeEmpty())
+		Expect(jobs[0].Labels).To(HaveKeyWithValue(originLabel, "web-uid"))
+		Expect(jobs[0].Labels).To(HaveKeyWithValue(runIndexLabel, "1"))
+		Expect(jobs[0].Annotations).To(HaveKeyWithValue(originAnnotation, "ci/web"))
+		Expect(lvBuild.Status.Cluster).To(Equal("eu"))
+		Expect(lvBuild.Status.RunIndex).To(BeEquivalentTo(1))
+		Expect(dispatched().Status).To(Equal(metav1.ConditionTrue))
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded).Reason).To(Equal(jcrsv1.ReasonRunning))
+	})
+
+	It("sets the status of the build from the remote Job", func() {
+		setup(target("eu", "https://eu.example.com", true)...)
+		reconcile()
+		remote := &remoteJobs(eu)[0]
+		remote.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		Expect(eu.Status().Update(ctx, remote)).To(Succeed())
+
+		reconcile()
+		Expect(remoteJobs(eu)).To(HaveLen(1))
+		Expect(jcrsv1.IsSucceeded(lvBuild.Status.Conditions)).To(BeTrue())
+		Expect(lvBuild.Status.RecentRuns).To(HaveLen(1))
+	})
+
+	It("replaces the remote Job when the Job of the build changes", func() {
+		setup(target("eu", "https://eu.example.com", true)...)
+		reconcile()
+		first := remoteJobs(eu)[0].Name
+
+		job.Spec.Template.Spec.Containers[0].Image = "golang:1.25"
+		reconcile()
+		jobs := remoteJobs(eu)
+		Expect(jobs).To(HaveLen(1))
+		Expect(jobs[0].Name).NotTo(Equal(first))
+		Expect(jobs[0].Labels).To(HaveKeyWithValue(runIndexLabel, "2"))
+	})
+
+	It("refuses to publish a package the build wasn't triggered by a publisher of", func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PackageOwnership: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PackageOwnership: false})).To(Succeed())
+		})
+		setup(append(target("eu", "https://eu.example.com", true), &jcrsv1.PackageOwnership{
+			ObjectMeta: metav1.ObjectMeta{Name: "web"},
+			Spec:       jcrsv1.PackageOwnershipSpec{PackageName: "web", Publishers: []string{"jane"}},
+		})...)
+		lvBuild.Spec.PackageName = ptr.To("web")
+		lvBuild.Spec.BuildType = jcrsv1.BuildPublish
+		lvBuild.Annotations = map[string]string{TriggeredByAnnotation: "john"}
+		Expect(r.Update(ctx, lvBuild)).To(Succeed())
+		reconcile()
+
+		Expect(remoteJobs(eu)).To(BeEmpty())
+		Expect(lvBuild.Status.BlockingReason.Reason).To(Equal(jcrsv1.BlockedByPublishAuthorization))
+		Expect(lvBuild.Status.Cluster).To(BeEmpty())
+	})
+
+	It("copies the pull Secret the remote Job references to its namespace", func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PullSecretDistribution: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.PullSecretDistribution: false})).To(Succeed())
+		})
+		source := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "jobrunner-system", Name: "regcred"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+		}
+		setup(append(target("eu", "https://eu.example.com", true), source)...)
+		r.PullSecret = PullSecretConfig{Source: types.NamespacedName{Namespace: "jobrunner-system", Name: "regcred"}}
+		addPullSecret(job, "regcred")
+		reconcile()
+
+		copied := &corev1.Secret{}
+		Expect(eu.Get(ctx, client.ObjectKey{Namespace: "builds", Name: "regcred"}, copied)).To(Succeed())
+		Expect(copied.Labels).To(HaveKeyWithValue(pullSecretLabel, "true"))
+		Expect(copied.Data).To(Equal(source.Data))
+
+		By("syncing the copy when the next run starts")
+		source.Data = map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{}}}`)}
+		Expect(r.Update(ctx, source)).To(Succeed())
+		job.Spec.Template.Spec.Containers[0].Image = "golang:1.25"
+		reconcile()
+		Expect(eu.Get(ctx, client.ObjectKey{Namespace: "builds", Name: "regcred"}, copied)).To(Succeed())
+		Expect(copied.Data).To(Equal(source.Data))
+	})
+
+	It("holds back builds no Ready cluster is selected for", func() {
+		setup(target("eu", "https://eu.example.com", false)...)
+		reconcile()
+
+		Expect(remoteJobs(eu)).To(BeEmpty())
+		Expect(dispatched().Reason).To(Equal(jcrsv1.ReasonNoClusterTarget))
+		Expect(lvBuild.Status.BlockingReason.Reason).To(Equal(jcrsv1.BlockedByClusterTarget))
+	})
+
+	It("deletes the remote Jobs of deleted builds", func() {
+		setup(target("eu", "https://eu.example.com", true)...)
+		Expect(r.reconcileRemoteJobsFinalizer(ctx, lvBuild)).To(Succeed())
+		Expect(lvBuild.Finalizers).To(ContainElement(remoteJobsFinalizer))
+		reconcile()
+		Expect(remoteJobs(eu)).To(HaveLen(1))
+
+		Expect(r.Delete(ctx, lvBuild)).To(Succeed())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(lvBuild), lvBuild)).To(Succeed())
+		Expect(r.reconcileRemoteJobsFinalizer(ctx, lvBuild)).To(Succeed())
+		Expect(remoteJobs(eu)).To(BeEmpty())
+		Expect(lvBuild.Finalizers).NotTo(ContainElement(remoteJobsFinalizer))
+	})
+})
@@ -0,0 +1,224 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+Besides its digest, a build container may report conditions of its own in its
+termination message, such as whether its tests passed:
+
+	{"conditions": [{"type": "TestsPassed", "status": "True", "reason": "AllPassed", "message": "412 tests"}]}
+
+They are reflected into the status of the build with their type prefixed by
+build.jcrs.dev/, so they can't be mistaken for the conditions of the controller,
+and can be waited on like them, e.g. with
+`kubectl wait --for=condition=build.jcrs.dev/TestsPassed`.
+
+Reported conditions come from the build containers of the jobTemplate once they
+have terminated, whether they succeeded or not, a later pod of the run taking
+precedence over an earlier one. Types that aren't valid condition types and
+statuses other than True, False or Unknown are dropped, reasons are stripped of
+the characters a reason can't hold and messages are truncated. A run reports at
+most maxReportedConditions conditions, the ones beyond are dropped with a
+Warning Event. They are kept until the next run starts.
+*/
+
+const (
+	// maxReportedConditions bounds the conditions the build containers of a run may report
+	maxReportedConditions = 16
+	// maxReportedMessageLength bounds the message of a reported condition, in bytes
+	maxReportedMessageLength = 1024
+	// maxReportedReasonLength bounds the reason of a reported condition
+	maxReportedReasonLength = 128
+
+	// reportedConditionsDroppedReason is the reason of the Event recorded when
+	// reported conditions are dropped
+	reportedConditionsDroppedReason = "ReportedConditionsDropped"
+)
+
+var (
+	// reportedTypePattern matches the name part of condition types, which follows the prefix
+	reportedTypePattern = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	// reasonInvalidCharacters matches the characters condition reasons can't hold
+	reasonInvalidCharacters = regexp.MustCompile(`[^A-Za-z0-9_,:]`)
+)
+
+// reportedCondition is a condition written by a build container in its termination message.
+type reportedCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// sanitizeReportedCondition returns the condition of a build of the given
+// generation for reported, or false when it can't be reflected.
+func sanitizeReportedCondition(reported reportedCondition, generation int64) (metav1.Condition, bool) {
+	name := strings.TrimSpace(reported.Type)
+	// Condition types are at most 316 characters long, prefix included
+	if len(name) > 316-len(jcrsv1.ReportedConditionPrefix) || !reportedTypePattern.MatchString(name) {
+		return metav1.Condition{}, false
+	}
+	var status metav1.ConditionStatus
+	for _, s := range []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown} {
+		if strings.EqualFold(strings.TrimSpace(reported.Status), string(s)) {
+			status = s
+		}
+	}
+	if status == "" {
+		return metav1.Condition{}, false
+	}
+
+	// Reasons start with a letter and don't end with a separator
+	reason := reasonInvalidCharacters.ReplaceAllString(reported.Reason, "")
+	reason = strings.TrimLeft(reason, "0123456789_,:")
+	if len(reason) > maxReportedReasonLength {
+		reason = reason[:maxReportedReasonLength]
+	}
+	reason = strings.TrimRight(reason, ",:")
+	if reason == "" {
+		reason = jcrsv1.ReasonReported
+	}
+
+	message := strings.ToValidUTF8(reported.Message, "")
+	if len(message) > maxReportedMessageLength {
+		message = message[:maxReportedMessageLength]
+		for !utf8.ValidString(message) {
+			message = message[:len(message)-1]
+		}
+	}
+	return metav1.Condition{
+		Type:               jcrsv1.ReportedConditionPrefix + name,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	}, true
+}
+
+// isReportedCondition reports whether condition was reported by a build container.
+func isReportedCondition(condition metav1.Condition) bool {
+	return strings.HasPrefix(condition.Type, jcrsv1.ReportedConditionPrefix)
+}
+
+// reportedConditions returns the conditions reported by the terminated build
+// containers of the pods of job, in the order they were first reported, and
+// the number of conditions that couldn't be reflected.
+func (r *LeviathanBuildReconciler) reportedConditions(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) ([]metav1.Condition, int, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return nil, 0, err
+	}
+	// Later pods of the run take precedence
+	slices.SortStableFunc(pods.Items, func(a, b corev1.Pod) int {
+		return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+	})
+	names := sets.New[string]()
+	for _, c := range lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers {
+		names.Insert(c.Name)
+	}
+
+	var conditions []metav1.Condition
+	invalid := 0
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated == nil || !names.Has(status.Name) {
+				continue
+			}
+			var result buildResult
+			if err := json.Unmarshal([]byte(status.State.Terminated.Message), &result); err != nil {
+				continue
+			}
+			for _, reported := range result.Conditions {
+				condition, ok := sanitizeReportedCondition(reported, lvBuild.Generation)
+				if !ok {
+					invalid++
+					continue
+				}
+				if i := slices.IndexFunc(conditions, func(c metav1.Condition) bool { return c.Type == condition.Type }); i >= 0 {
+					conditions[i] = condition
+				} else {
+					conditions = append(conditions, condition)
+				}
+			}
+		}
+	}
+	return conditions, invalid, nil
+}
+
+// reconcileReportedConditions reflects the conditions reported by the build
+// containers of job into the status of lvBuild.
+func (r *LeviathanBuildReconciler) reconcileReportedConditions(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) error {
+	if !featuregates.Enabled(featuregates.ReportedConditions) {
+		return nil
+	}
+	conditions, dropped, err := r.reportedConditions(ctx, lvBuild, job)
+	if err != nil {
+		return err
+	}
+
+	// The conditions already reflected count against the limit, they are kept
+	// when the pods of the run are gone
+	reflected := 0
+	for _, condition := range lvBuild.Status.Conditions {
+		if isReportedCondition(condition) {
+			reflected++
+		}
+	}
+	changed := false
+	for _, condition := range conditions {
+		if meta.FindStatusCondition(lvBuild.Status.Conditions, condition.Type) == nil {
+			if reflected >= maxReportedConditions {
+				dropped++
+				continue
+			}
+			reflected++
+		}
+		if meta.SetStatusCondition(&lvBuild.Status.Conditions, condition) {
+			changed = true
+		}
+	}
+	// The same conditions are dropped on every reconcile, they are only reported along new ones
+	if changed && dropped > 0 {
+		r.event(lvBuild, corev1.EventTypeWarning, reportedConditionsDroppedReason,
+			"Dropped %d conditions reported by Job %s: invalid, or beyond the %d conditions a run may report", dropped, job.Name, maxReportedConditions)
+	}
+	return nil
+}
+
+// resetReportedConditions removes the conditions reported by the previous run of lvBuild.
+func resetReportedConditions(lvBuild *jcrsv1.LeviathanBuild) {
+	lvBuild.Status.Conditions = slices.DeleteFunc(lvBuild.Status.Conditions, isReportedCondition)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Reported conditions", func() {
+	var (
+		ctx      context.Context
+		c        client.Client
+		r        *LeviathanBuildReconciler
+		recorder *record.FakeRecorder
+		lvBuild  *jcrsv1.LeviathanBuild
+		job      *batchv1.Job
+		created  time.Time
+	)
+
+	// addPod adds a pod of job whose container reports conditions in its termination message.
+	addPod := func(container string, conditions ...reportedCondition) {
+		message, err := json.Marshal(buildResult{Conditions: conditions})
+		Expect(err).NotTo(HaveOccurred())
+		created = created.Add(time.Minute)
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "default",
+				Name:              fmt.Sprintf("web-1-%d", created.Unix()),
+				Labels:            map[string]string{batchv1.JobNameLabel: job.Name},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  container,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: string(message)}},
+			}}},
+		}
+		Expect(c.Create(ctx, pod)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.ReportedConditions: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.ReportedConditions: false})).To(Succeed())
+		})
+
+		ctx = context.Background()
+		c = newFakeClient()
+		recorder = record.NewFakeRecorder(10)
+		r = &LeviathanBuildReconciler{Client: c, Recorder: recorder}
+
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "default").Generation(3).Obj()
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-1"}}
+		created = time.Now().Add(-time.Hour)
+	})
+
+	It("reflects the conditions reported by the build containers under the prefix", func() {
+		addPod("build",
+			reportedCondition{Type: "TestsPassed", Status: "false", Reason: "3 tests failed!", Message: "3 of 412 tests failed"},
+			reportedCondition{Type: "CoverageThresholdMet", Status: "True"},
+		)
+		addPod("sidecar", reportedCondition{Type: "Sidecar", Status: "True"})
+		addPod("build", reportedCondition{Type: "TestsPassed", Status: "True", Reason: "AllPassed"})
+
+		Expect(r.reconcileReportedConditions(ctx, lvBuild, job)).To(Succeed())
+		Expect(lvBuild.Status.Conditions).To(HaveLen(2))
+		tests := meta.FindStatusCondition(lvBuild.Status.Conditions, "build.jcrs.dev/TestsPassed")
+		Expect(tests).NotTo(BeNil())
+		Expect(tests.Status).To(Equal(metav1.ConditionTrue))
+		Expect(tests.Reason).To(Equal("AllPassed"))
+		Expect(tests.ObservedGeneration).To(BeEquivalentTo(3))
+		coverage := meta.FindStatusCondition(lvBuild.Status.Conditions, "build.jcrs.dev/CoverageThresholdMet")
+		Expect(coverage).NotTo(BeNil())
+		Expect(coverage.Reason).To(Equal(jcrsv1.ReasonReported))
+		Expect(recorder.Events).To(BeEmpty())
+
+		By("keeping them once the pods are gone, until the next run")
+		Expect(c.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace("default"))).To(Succeed())
+		Expect(r.reconcileReportedConditions(ctx, lvBuild, job)).To(Succeed())
+		Expect(lvBuild.Status.Conditions).To(HaveLen(2))
+		jcrsv1.MarkRunning(&lvBuild.Status.Conditions, lvBuild.Generation, jcrsv1.ReasonRunning, "Job web-2 is running")
+		resetReportedConditions(lvBuild)
+		Expect(lvBuild.Status.Conditions).To(HaveLen(2))
+		Expect(lvBuild.Status.Conditions).NotTo(ContainElement(HaveField("Type", HavePrefix(jcrsv1.ReportedConditionPrefix))))
+	})
+
+	It("drops invalid conditions and the ones beyond the limit", func() {
+		var conditions []reportedCondition
+		for i := range maxReportedConditions + 2 {
+			conditions = append(conditions, reportedCondition{Type: fmt.Sprintf("Check%d", i), Status: "True"})
+		}
+		conditions = append(conditions,
+			reportedCondition{Type: "other.dev/Check", Status: "True"},
+			reportedCondition{Type: "Check", Status: "Maybe"},
+		)
+		addPod("build", conditions...)
+
+		Expect(r.reconcileReportedConditions(ctx, lvBuild, job)).To(Succeed())
+		Expect(lvBuild.Status.Conditions).To(HaveLen(maxReportedConditions))
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, fmt.Sprintf("build.jcrs.dev/Check%d", maxReportedConditions))).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Dropped 4 conditions reported by Job web-1")))
+
+		By("not recording the same drops again")
+		Expect(r.reconcileReportedConditions(ctx, lvBuild, job)).To(Succeed())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("sanitizes reasons and messages", func() {
+		condition, ok := sanitizeReportedCondition(reportedCondition{
+			Type:    " Lint ",
+			Status:  "UNKNOWN",
+			Reason:  "42 warnings: found,",
+			Message: strings.Repeat("é", maxReportedMessageLength),
+		}, 1)
+		Expect(ok).To(BeTrue())
+		Expect(condition.Type).To(Equal("build.jcrs.dev/Lint"))
+		Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+		Expect(condition.Reason).To(Equal("warnings:found"))
+		Expect(len(condition.Message)).To(BeNumerically("<=", maxReportedMessageLength))
+		Expect(condition.Message).To(HavePrefix("éé"))
+
+		_, ok = sanitizeReportedCondition(reportedCondition{Type: strings.Repeat("a", 400), Status: "True"}, 1)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("leaves the conditions out while the feature is disabled", func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.ReportedConditions: false})).To(Succeed())
+		addPod("build", reportedCondition{Type: "TestsPassed", Status: "True"})
+		Expect(r.reconcileReportedConditions(ctx, lvBuild, job)).To(Succeed())
+		Expect(lvBuild.Status.Conditions).To(BeEmpty())
+	})
+})
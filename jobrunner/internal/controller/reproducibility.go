@@ -0,0 +1,306 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A build with spec.verifyReproducibility runs every succeeded run a second time
+and compares the digests both runs report through the termination message of
+their build containers. The second run is a Job of its own, named after the run
+like signing Jobs and without the labels of the Jobs of runs, so it's never taken
+for one. Its spec is the spec the first run was created with, with
+LEVIATHAN_REPRODUCE set so that its containers don't publish, and a preference
+for any node but the one the first run ran on.
+
+When the digests differ, a ConfigMap owned by the second Job summarizes what
+differs: the digests, the source revisions, and the outputs both runs report
+digests of, if any. The reproduction Jobs of earlier runs are deleted with their
+ConfigMap once a new one is created. Neither a failed nor a mismatching second run
+fails the build, and the patchTargets of onSuccess don't wait for it: the outcome
+is reported by NonReproducible, for the attestation tooling to act on.
+*/
+
+const (
+	// reproduceEnv tells the containers of the second run of a build not to publish artifacts
+	reproduceEnv = "LEVIATHAN_REPRODUCE"
+
+	// reproducesLabel identifies the reproduction Jobs of a build
+	reproducesLabel = "jcrs.jcrs.dev/reproduces"
+	// reproducesRunIndexAnnotation records the run a reproduction Job runs again
+	reproducesRunIndexAnnotation = "jcrs.jcrs.dev/reproduces-run-index"
+
+	// reproducibilityDiffKey is the key of the diff summary in its ConfigMap
+	reproducibilityDiffKey = "diff.txt"
+
+	// reproductionStartedReason is the reason of the Event recorded when a reproduction Job is created
+	reproductionStartedReason = "ReproductionStarted"
+	// reproducedReason is the reason of the Event recorded when both runs reported the same digest
+	reproducedReason = "Reproduced"
+	// nonReproducibleReason is the reason of the Event recorded when both runs reported different digests
+	nonReproducibleReason = "NonReproducible"
+	// reproductionFailedReason is the reason of the Event recorded when a reproduction Job fails
+	reproductionFailedReason = "ReproductionFailed"
+)
+
+// reproductionJobName returns the name of the reproduction Job of the run runIndex of lvBuild.
+func reproductionJobName(lvBuild *jcrsv1.LeviathanBuild, runIndex int64) string {
+	return shortenName(fmt.Sprintf("%s-%d-repro", lvBuild.Name, runIndex), validation.DNS1123LabelMaxLength)
+}
+
+// setNonReproducible records the outcome of the reproduction of the latest run of lvBuild.
+func setNonReproducible(lvBuild *jcrsv1.LeviathanBuild, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionNonReproducible,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: lvBuild.Generation,
+	})
+}
+
+// resetReproducibility forgets the reproduction of the previous run of lvBuild.
+func resetReproducibility(lvBuild *jcrsv1.LeviathanBuild) {
+	lvBuild.Status.Reproducibility = nil
+	meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionNonReproducible)
+}
+
+// reproduced reports whether the reproduction of the run runIndex of lvBuild is over.
+func reproduced(lvBuild *jcrsv1.LeviathanBuild, runIndex int64) bool {
+	status := lvBuild.Status.Reproducibility
+	if status == nil || status.RunIndex != runIndex {
+		return false
+	}
+	condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionNonReproducible)
+	return condition != nil && slices.Contains([]string{jcrsv1.ReasonReproduced, jcrsv1.ReasonDigestMismatch, jcrsv1.ReasonReproductionFailed}, condition.Reason)
+}
+
+// reconcileReproducibility runs job, the succeeded Job of the run runIndex of
+// lvBuild, a second time with the spec of desired, and compares the digests both
+// runs report.
+func (r *LeviathanBuildReconciler) reconcileReproducibility(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job, desired *batchv1.Job, runIndex int64) error {
+	if !lvBuild.Spec.VerifyReproducibility {
+		resetReproducibility(lvBuild)
+		return nil
+	}
+	if reproduced(lvBuild, runIndex) {
+		return nil
+	}
+
+	first, err := r.reportedResult(ctx, job)
+	if err != nil {
+		return err
+	}
+	if first.Digest == "" {
+		setNonReproducible(lvBuild, metav1.ConditionUnknown, jcrsv1.ReasonNoDigest, "Job "+job.Name+" didn't report a digest")
+		return nil
+	}
+
+	name := reproductionJobName(lvBuild, runIndex)
+	reproduction := &batchv1.Job{}
+	err = r.Get(ctx, client.ObjectKey{Namespace: lvBuild.Namespace, Name: name}, reproduction)
+	if apierrors.IsNotFound(err) {
+		return r.createReproductionJob(ctx, lvBuild, job, desired, name, first.Digest, runIndex)
+	} else if err != nil {
+		return err
+	}
+	lvBuild.Status.Reproducibility = &jcrsv1.ReproducibilityStatus{RunIndex: runIndex, Job: name, Digest: first.Digest}
+
+	switch finished, finishedType := isJobFinished(reproduction); {
+	case !finished:
+		setNonReproducible(lvBuild, metav1.ConditionUnknown, jcrsv1.ReasonReproducing, "Job "+name+" is running the build again")
+		return nil
+	case finishedType == batchv1.JobFailed:
+		r.event(lvBuild, corev1.EventTypeWarning, reproductionFailedReason, "Job %s failed to run the build again", name)
+		setNonReproducible(lvBuild, metav1.ConditionUnknown, jcrsv1.ReasonReproductionFailed, "Job "+name+" failed to run the build again")
+		return nil
+	}
+
+	second, err := r.reportedResult(ctx, reproduction)
+	if err != nil {
+		return err
+	}
+	if second.Digest == "" {
+		setNonReproducible(lvBuild, metav1.ConditionUnknown, jcrsv1.ReasonNoDigest, "Job "+name+" didn't report a digest")
+		return nil
+	}
+	lvBuild.Status.Reproducibility.ReproducedDigest = second.Digest
+	if second.Digest == first.Digest {
+		setNonReproducible(lvBuild, metav1.ConditionFalse, jcrsv1.ReasonReproduced, "Job "+name+" reproduced "+first.Digest)
+		r.event(lvBuild, corev1.EventTypeNormal, reproducedReason, "Job %s reproduced %s", name, first.Digest)
+		return nil
+	}
+
+	source, err := r.resolveSource(ctx, lvBuild, reproduction)
+	if err != nil {
+		return err
+	}
+	diff := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: lvBuild.Namespace, Labels: map[string]string{reproducesLabel: labelValue(lvBuild.Name)}},
+		Data:       map[string]string{reproducibilityDiffKey: diffSummary(first, second, lvBuild.Status.SourceRevision, source.Revision)},
+	}
+	if err := ctrl.SetControllerReference(reproduction, diff, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, diff); client.IgnoreAlreadyExists(err) != nil {
+		return err
+	}
+	lvBuild.Status.Reproducibility.DiffConfigMap = diff.Name
+	setNonReproducible(lvBuild, metav1.ConditionTrue, jcrsv1.ReasonDigestMismatch,
+		fmt.Sprintf("Job %s reported %s rather than %s, see ConfigMap %s", name, second.Digest, first.Digest, diff.Name))
+	r.event(lvBuild, corev1.EventTypeWarning, nonReproducibleReason, "Job %s reported %s rather than %s", name, second.Digest, first.Digest)
+	return nil
+}
+
+// createReproductionJob creates the Job named name running job again with the
+// spec of desired, and deletes the reproduction Jobs of the earlier runs of lvBuild.
+func (r *LeviathanBuildReconciler) createReproductionJob(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job, desired *batchv1.Job, name, digest string, runIndex int64) error {
+	// The term preferring the node of the build cache is dropped with the annotation it's recorded by
+	template := desired.DeepCopy()
+	preferNode(template, "")
+	reproduction := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   lvBuild.Namespace,
+			Labels:      map[string]string{reproducesLabel: labelValue(lvBuild.Name), ManagedLabel: "true"},
+			Annotations: map[string]string{reproducesRunIndexAnnotation: strconv.FormatInt(runIndex, 10)},
+		},
+		Spec: template.Spec,
+	}
+	// The Job is kept for its outcome to be read, and goes away with the next run
+	reproduction.Spec.TTLSecondsAfterFinished = nil
+	setEnv(&reproduction.Spec.Template.Spec, corev1.EnvVar{Name: reproduceEnv, Value: "true"})
+	node, err := r.jobNode(ctx, job)
+	if err != nil {
+		return err
+	}
+	avoidNode(reproduction, node)
+	if err := ctrl.SetControllerReference(lvBuild, reproduction, r.Scheme); err != nil {
+		return err
+	}
+	logf.FromContext(ctx).Info("Creating reproduction Job", "Job.Namespace", reproduction.Namespace, "Job.Name", reproduction.Name, "avoidedNode", node)
+	if err := r.Create(ctx, reproduction); err != nil {
+		return err
+	}
+
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(lvBuild.Namespace), client.MatchingLabels{reproducesLabel: labelValue(lvBuild.Name)}); err != nil {
+		return err
+	}
+	for i := range jobs.Items {
+		earlier := &jobs.Items[i]
+		if earlier.Name == reproduction.Name || !metav1.IsControlledBy(earlier, lvBuild) {
+			continue
+		}
+		if err := r.Delete(ctx, earlier, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	lvBuild.Status.Reproducibility = &jcrsv1.ReproducibilityStatus{RunIndex: runIndex, Job: name, Digest: digest}
+	setNonReproducible(lvBuild, metav1.ConditionUnknown, jcrsv1.ReasonReproducing, "Job "+name+" is running the build again")
+	r.event(lvBuild, corev1.EventTypeNormal, reproductionStartedReason, "Running the build again with Job %s", name)
+	return nil
+}
+
+// jobNode returns the node the pods of job ran on, or "" if it isn't known.
+func (r *LeviathanBuildReconciler) jobNode(ctx context.Context, job *batchv1.Job) (string, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" {
+			return pod.Spec.NodeName, nil
+		}
+	}
+	return "", nil
+}
+
+// avoidNode makes the pods of job prefer any node but node, if set.
+func avoidNode(job *batchv1.Job, node string) {
+	if node == "" {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := podSpec.Affinity.NodeAffinity
+	nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		corev1.PreferredSchedulingTerm{Weight: 100, Preference: corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{{
+			Key:      nodeNameField,
+			Operator: corev1.NodeSelectorOpNotIn,
+			Values:   []string{node},
+		}}}})
+}
+
+// diffSummary describes what differs between the results reported by the first
+// and second runs of a build, built from the source revisions firstRevision and
+// secondRevision.
+func diffSummary(first, second buildResult, firstRevision, secondRevision string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digest: %s -> %s\n", first.Digest, second.Digest)
+	if firstRevision != secondRevision && firstRevision != "" && secondRevision != "" {
+		fmt.Fprintf(&b, "source revision: %s -> %s\n", firstRevision, secondRevision)
+	}
+
+	paths := make([]string, 0, len(first.Outputs)+len(second.Outputs))
+	for path := range first.Outputs {
+		paths = append(paths, path)
+	}
+	for path := range second.Outputs {
+		if _, ok := first.Outputs[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		before, inFirst := first.Outputs[path]
+		after, inSecond := second.Outputs[path]
+		switch {
+		case !inSecond:
+			fmt.Fprintf(&b, "- %s %s\n", path, before)
+		case !inFirst:
+			fmt.Fprintf(&b, "+ %s %s\n", path, after)
+		case before != after:
+			fmt.Fprintf(&b, "~ %s %s -> %s\n", path, before, after)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Reproducibility verification", func() {
+	const (
+		digest      = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+		otherDigest = "sha256:fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"
+	)
+
+	var (
+		ctx     context.Context
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+		desired *batchv1.Job
+		r       *LeviathanBuildReconciler
+	)
+
+	// report creates the pod of the Job named jobName, run on node, whose build container reported message
+	report := func(jobName, node, message string) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: jobName + "-pod", Namespace: "default", Labels: map[string]string{batchv1.JobNameLabel: jobName}},
+			Spec:       corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Name: "build", Image: "builder"}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "build",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: message}},
+			}}},
+		}
+		Expect(r.Create(ctx, pod)).To(Succeed())
+	}
+	reproduction := func(runIndex int64) *batchv1.Job {
+		reproducing := &batchv1.Job{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "default", Name: reproductionJobName(lvBuild, runIndex)}, reproducing)).To(Succeed())
+		return reproducing
+	}
+	finish := func(reproducing *batchv1.Job, conditionType batchv1.JobConditionType) {
+		reproducing.Status.Conditions = append(reproducing.Status.Conditions, batchv1.JobCondition{Type: conditionType, Status: corev1.ConditionTrue})
+		Expect(r.Status().Update(ctx, reproducing)).To(Succeed())
+	}
+	reason := func() string {
+		return meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionNonReproducible).Reason
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c := newFakeClient()
+		r = &LeviathanBuildReconciler{
+			Client: c,
+			Scheme: c.Scheme(),
+		}
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "default").RunIndex(2).Obj()
+		lvBuild.Spec.VerifyReproducibility = true
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-2-abcde", Namespace: "default", UID: "job-uid"}}
+		desired = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+			Spec: batchv1.JobSpec{
+				TTLSecondsAfterFinished: ptr.To[int32](60),
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    []corev1.Container{{Name: "build", Image: "builder"}},
+				}},
+			},
+		}
+		preferNode(desired, "cache-node")
+		report(job.Name, "node-a", `{"digest":"`+digest+`","outputs":{"bin/web":"sha256:aa","lib/web.so":"sha256:bb"}}`)
+	})
+
+	It("runs the build again on another node and reports matching digests", func() {
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		Expect(reason()).To(Equal(jcrsv1.ReasonReproducing))
+
+		created := reproduction(2)
+		Expect(created.Labels).To(HaveKeyWithValue(reproducesLabel, "web"))
+		Expect(created.Labels).NotTo(HaveKey(buildLabel))
+		Expect(metav1.IsControlledBy(created, lvBuild)).To(BeTrue())
+		Expect(created.Spec.TTLSecondsAfterFinished).To(BeNil())
+		Expect(created.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: reproduceEnv, Value: "true"}))
+		Expect(created.Spec.Template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(ConsistOf(
+			HaveField("Preference.MatchFields", ConsistOf(corev1.NodeSelectorRequirement{
+				Key: nodeNameField, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"node-a"},
+			})),
+		))
+
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		Expect(reason()).To(Equal(jcrsv1.ReasonReproducing))
+
+		report(created.Name, "node-b", `{"digest":"`+digest+`"}`)
+		finish(created, batchv1.JobComplete)
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		Expect(meta.IsStatusConditionFalse(lvBuild.Status.Conditions, jcrsv1.ConditionNonReproducible)).To(BeTrue())
+		Expect(reason()).To(Equal(jcrsv1.ReasonReproduced))
+		Expect(lvBuild.Status.Reproducibility).To(Equal(&jcrsv1.ReproducibilityStatus{
+			RunIndex: 2, Job: created.Name, Digest: digest, ReproducedDigest: digest,
+		}))
+	})
+
+	It("summarizes what differs when the digests don't match", func() {
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		created := reproduction(2)
+		report(created.Name, "node-b", `{"digest":"`+otherDigest+`","outputs":{"bin/web":"sha256:cc","lib/web.so":"sha256:bb","share/web":"sha256:dd"}}`)
+		finish(created, batchv1.JobComplete)
+
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionNonReproducible)).To(BeTrue())
+		Expect(reason()).To(Equal(jcrsv1.ReasonDigestMismatch))
+		Expect(lvBuild.Status.Reproducibility.ReproducedDigest).To(Equal(otherDigest))
+		Expect(lvBuild.Status.Reproducibility.DiffConfigMap).To(Equal(created.Name))
+
+		diff := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "default", Name: created.Name}, diff)).To(Succeed())
+		Expect(metav1.IsControlledBy(diff, created)).To(BeTrue())
+		Expect(diff.Data).To(HaveKeyWithValue(reproducibilityDiffKey,
+			"digest: "+digest+" -> "+otherDigest+"\n~ bin/web sha256:aa -> sha256:cc\n+ share/web sha256:dd\n"))
+	})
+
+	It("doesn't retry failed reproduction Jobs", func() {
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		finish(reproduction(2), batchv1.JobFailed)
+
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		Expect(reason()).To(Equal(jcrsv1.ReasonReproductionFailed))
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		Expect(reproduction(2).Status.Conditions).To(HaveLen(1))
+	})
+
+	It("deletes the reproduction Jobs of earlier runs", func() {
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+
+		resetReproducibility(lvBuild)
+		Expect(lvBuild.Status.Reproducibility).To(BeNil())
+		next := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-3-fghij", Namespace: "default"}}
+		report(next.Name, "node-a", `{"digest":"`+digest+`"}`)
+		Expect(r.reconcileReproducibility(ctx, lvBuild, next, desired, 3)).To(Succeed())
+
+		var jobs batchv1.JobList
+		Expect(r.List(ctx, &jobs, client.MatchingLabels{reproducesLabel: "web"})).To(Succeed())
+		Expect(jobs.Items).To(ConsistOf(HaveField("Name", reproductionJobName(lvBuild, 3))))
+	})
+
+	It("doesn't run the build again when it didn't report a digest", func() {
+		unreported := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-2-xyz", Namespace: "default"}}
+		Expect(r.reconcileReproducibility(ctx, lvBuild, unreported, desired, 2)).To(Succeed())
+		Expect(reason()).To(Equal(jcrsv1.ReasonNoDigest))
+
+		var jobs batchv1.JobList
+		Expect(r.List(ctx, &jobs, client.MatchingLabels{reproducesLabel: "web"})).To(Succeed())
+		Expect(jobs.Items).To(BeEmpty())
+	})
+
+	It("forgets the reproduction of builds without spec.verifyReproducibility", func() {
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		lvBuild.Spec.VerifyReproducibility = false
+		Expect(r.reconcileReproducibility(ctx, lvBuild, job, desired, 2)).To(Succeed())
+		Expect(lvBuild.Status.Reproducibility).To(BeNil())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionNonReproducible)).To(BeNil())
+	})
+})
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The versions published by a build are recorded in its status as its runs finish,
+while the build has an artifactRetention policy. The policy is enforced by the
+ArtifactRetentionReconciler, which deletes the versions the policy doesn't keep
+from the storage they were published to.
+
+Versions aren't deleted as soon as they're selected: they're first reported as
+pending in the status, and only the versions that were already pending are
+deleted by the next pass. A dry run stops at reporting them.
+*/
+
+// recordPublishedArtifact records the version published by the finished Job of
+// the given run of lvBuild.
+func recordPublishedArtifact(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64, succeeded bool) {
+	target := lvBuild.Spec.PublishTarget
+	if lvBuild.Spec.ArtifactRetention == nil || target == nil || !publishes(lvBuild.Spec.BuildType) {
+		return
+	}
+	artifacts := lvBuild.Status.PublishedArtifacts
+	if slices.ContainsFunc(artifacts, func(a jcrsv1.PublishedArtifact) bool { return a.RunIndex == runIndex }) {
+		return
+	}
+
+	// The path is only recorded when a pathTemplate moved it off the packageName
+	path := publishPathOf(lvBuild)
+	if path == ptr.Deref(lvBuild.Spec.PackageName, "") {
+		path = ""
+	}
+	// A version published again replaces the previous record of the version
+	artifacts = slices.DeleteFunc(artifacts, func(a jcrsv1.PublishedArtifact) bool {
+		return a.RegistryURL == target.RegistryURL && a.Path == path && a.Version == target.Version
+	})
+	artifact := jcrsv1.PublishedArtifact{
+		RegistryURL: target.RegistryURL,
+		Path:        path,
+		Version:     target.Version,
+		RunIndex:    runIndex,
+		Succeeded:   succeeded,
+		PublishedAt: metav1.Now(),
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed {
+			artifact.PublishedAt = c.LastTransitionTime
+		}
+	}
+	if succeeded {
+		artifact.Digest = lvBuild.Status.PublishedDigest
+	}
+	lvBuild.Status.PublishedArtifacts = append(artifacts, artifact)
+}
+
+// prunableArtifacts returns the published artifacts of lvBuild that its retention
+// policy doesn't keep at the given time.
+func prunableArtifacts(lvBuild *jcrsv1.LeviathanBuild, now time.Time) []jcrsv1.PublishedArtifact {
+	policy := lvBuild.Spec.ArtifactRetention
+	target := lvBuild.Spec.PublishTarget
+	if policy == nil || target == nil {
+		return nil
+	}
+
+	artifacts := slices.Clone(lvBuild.Status.PublishedArtifacts)
+	sort.SliceStable(artifacts, func(i, j int) bool { return artifacts[i].RunIndex > artifacts[j].RunIndex })
+
+	// Without any limit, every version of a succeeded run is kept
+	limited := policy.KeepLast != nil || policy.KeepDays != nil
+
+	var prunable []jcrsv1.PublishedArtifact
+	kept := int32(0)
+	for _, artifact := range artifacts {
+		current := artifact.RegistryURL == target.RegistryURL && artifact.Version == target.Version
+		if policy.KeepSuccessOnly && !artifact.Succeeded {
+			if !current {
+				prunable = append(prunable, artifact)
+			}
+			continue
+		}
+		kept++
+		if current || !limited {
+			continue
+		}
+		beyondLast := policy.KeepLast == nil || kept > *policy.KeepLast
+		expired := policy.KeepDays == nil || now.Sub(artifact.PublishedAt.Time) > time.Duration(*policy.KeepDays)*24*time.Hour
+		if beyondLast && expired {
+			prunable = append(prunable, artifact)
+		}
+	}
+	return prunable
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/retention"
+)
+
+// recordingPruner records the versions it deletes, failing for the versions in failOn.
+type recordingPruner struct {
+	deleted []string
+	failOn  map[string]bool
+}
+
+func (p *recordingPruner) Delete(_ context.Context, _, packageName, version, _ string) error {
+	if p.failOn[version] {
+		return errors.New("denied")
+	}
+	p.deleted = append(p.deleted, packageName+":"+version)
+	return nil
+}
+
+var _ = Describe("Artifact retention", func() {
+	const registryURL = "registry.example.com/team"
+	now := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) metav1.Time { return metav1.NewTime(now.Add(-time.Duration(days) * 24 * time.Hour)) }
+
+	var lvBuild *jcrsv1.LeviathanBuild
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName:       ptr.To("web"),
+				BuildType:         jcrsv1.BuildPublish,
+				PublishTarget:     &jcrsv1.PublishTarget{RegistryURL: registryURL, Version: "1.4.0"},
+				ArtifactRetention: &jcrsv1.ArtifactRetention{Store: jcrsv1.OCIArtifactStore},
+			},
+			Status: jcrsv1.LeviathanBuildStatus{PublishedArtifacts: []jcrsv1.PublishedArtifact{
+				{RegistryURL: registryURL, Version: "1.0.0", RunIndex: 1, Succeeded: true, PublishedAt: daysAgo(40)},
+				{RegistryURL: registryURL, Version: "1.1.0", RunIndex: 2, Succeeded: true, PublishedAt: daysAgo(20)},
+				{RegistryURL: registryURL, Version: "1.2.0", RunIndex: 3, Succeeded: false, PublishedAt: daysAgo(10)},
+				{RegistryURL: registryURL, Version: "1.3.0", RunIndex: 4, Succeeded: true, PublishedAt: daysAgo(5)},
+				{RegistryURL: registryURL, Version: "1.4.0", RunIndex: 5, Succeeded: true, PublishedAt: daysAgo(1)},
+			}},
+		}
+	})
+
+	versions := func(artifacts []jcrsv1.PublishedArtifact) []string {
+		var versions []string
+		for _, artifact := range artifacts {
+			versions = append(versions, artifact.Version)
+		}
+		return versions
+	}
+
+	It("selects the versions beyond keepLast and older than keepDays", func() {
+		policy := lvBuild.Spec.ArtifactRetention
+		Expect(prunableArtifacts(lvBuild, now)).To(BeEmpty())
+
+		policy.KeepLast = ptr.To[int32](2)
+		Expect(versions(prunableArtifacts(lvBuild, now))).To(Equal([]string{"1.2.0", "1.1.0", "1.0.0"}))
+
+		policy.KeepDays = ptr.To[int32](30)
+		Expect(versions(prunableArtifacts(lvBuild, now))).To(Equal([]string{"1.0.0"}))
+
+		By("pruning failed runs and not counting them with keepSuccessOnly")
+		policy.KeepLast, policy.KeepDays = ptr.To[int32](3), nil
+		policy.KeepSuccessOnly = true
+		Expect(versions(prunableArtifacts(lvBuild, now))).To(Equal([]string{"1.2.0", "1.0.0"}))
+
+		By("never pruning the version being published")
+		lvBuild.Spec.PublishTarget.Version = "1.0.0"
+		policy.KeepLast = ptr.To[int32](1)
+		Expect(versions(prunableArtifacts(lvBuild, now))).To(Equal([]string{"1.3.0", "1.2.0", "1.1.0"}))
+	})
+
+	It("records the version of each finished run once", func() {
+		lvBuild.Status.PublishedArtifacts = nil
+		lvBuild.Status.PublishedDigest = "sha256:0123"
+		job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: daysAgo(1)},
+		}}}
+		recordPublishedArtifact(lvBuild, job, 1, true)
+		recordPublishedArtifact(lvBuild, job, 1, true)
+		Expect(lvBuild.Status.PublishedArtifacts).To(Equal([]jcrsv1.PublishedArtifact{
+			{RegistryURL: registryURL, Version: "1.4.0", Digest: "sha256:0123", RunIndex: 1, Succeeded: true, PublishedAt: daysAgo(1)},
+		}))
+
+		By("replacing the record of a version published again")
+		recordPublishedArtifact(lvBuild, job, 2, false)
+		Expect(lvBuild.Status.PublishedArtifacts).To(HaveLen(1))
+		Expect(lvBuild.Status.PublishedArtifacts[0].RunIndex).To(Equal(int64(2)))
+		Expect(lvBuild.Status.PublishedArtifacts[0].Digest).To(BeEmpty())
+	})
+
+	It("deletes versions once they have been reported", func() {
+		lvBuild.Spec.ArtifactRetention.KeepLast = ptr.To[int32](2)
+		lvBuild.Spec.ArtifactRetention.DryRun = true
+		c := newFakeClient(lvBuild)
+		pruner := &recordingPruner{failOn: map[string]bool{"1.0.0": true}}
+		r := &ArtifactRetentionReconciler{Client: c, Scheme: c.Scheme(), Pruners: map[jcrsv1.ArtifactStore]retention.Pruner{
+			jcrsv1.OCIArtifactStore: pruner,
+		}}
+		key := types.NamespacedName{Name: "web", Namespace: "default"}
+		reconcile := func() *jcrsv1.LeviathanBuild {
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			updated := &jcrsv1.LeviathanBuild{}
+			Expect(c.Get(context.Background(), key, updated)).To(Succeed())
+			return updated
+		}
+
+		By("only reporting the versions on a dry run")
+		for range 2 {
+			updated := reconcile()
+			Expect(updated.Status.ArtifactRetention.Pending).To(Equal([]string{"1.2.0", "1.1.0", "1.0.0"}))
+			Expect(pruner.deleted).To(BeEmpty())
+		}
+
+		By("deleting the reported versions")
+		updated := reconcile()
+		updated.Spec.ArtifactRetention.DryRun = false
+		Expect(c.Update(context.Background(), updated)).To(Succeed())
+		updated = reconcile()
+		Expect(pruner.deleted).To(Equal([]string{"web:1.2.0", "web:1.1.0"}))
+		Expect(updated.Status.ArtifactRetention.LastPruned).To(Equal([]string{"1.2.0", "1.1.0"}))
+		Expect(updated.Status.ArtifactRetention.Pending).To(Equal([]string{"1.0.0"}))
+		Expect(updated.Status.ArtifactRetention.Message).To(ContainSubstring("Failed to delete version 1.0.0: denied"))
+		Expect(versions(updated.Status.PublishedArtifacts)).To(Equal([]string{"1.0.0", "1.3.0", "1.4.0"}))
+
+		By("clearing the status once the policy is removed")
+		updated.Spec.ArtifactRetention = nil
+		Expect(c.Update(context.Background(), updated)).To(Succeed())
+		Expect(reconcile().Status.ArtifactRetention).To(BeNil())
+	})
+})
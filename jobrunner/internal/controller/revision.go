@@ -0,0 +1,76 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/fetch"
+)
+
+// contentRevision returns the revision identifying content, its sha256 digest.
+func contentRevision(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+/*
+The source revision is resolved as early as possible:
+
+  - inline scripts are part of the spec, so their revision is known before the Job is created;
+  - fetched sources report the revision they actually fetched through the termination
+    message of the fetch init container, which finishes before any build container starts,
+    along with the mirror they were copied to or restored from.
+
+Source types that are neither inline nor fetched by the controller don't have a revision.
+*/
+
+// resolveSource returns the revision of the source built by job, and the URI of
+// its mirror, or an empty result when they aren't known (yet).
+func (r *LeviathanBuildReconciler) resolveSource(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) (fetch.Result, error) {
+	if script, ok := inlineScript(lvBuild); ok {
+		return fetch.Result{Revision: contentRevision(script)}, nil
+	}
+	if !needsFetch(lvBuild) || job == nil {
+		return fetch.Result{}, nil
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return fetch.Result{}, err
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name != fetchContainerName || status.State.Terminated == nil || status.State.Terminated.ExitCode != 0 {
+				continue
+			}
+			var result fetch.Result
+			if err := json.Unmarshal([]byte(status.State.Terminated.Message), &result); err == nil && result.Revision != "" {
+				return result, nil
+			}
+		}
+	}
+	return fetch.Result{}, nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A new run of a build is only started once the build passes the same checks,
+whatever it runs on: it isn't suspended, no MaintenanceWindow is open for it, it
+was triggered by a publisher of its package, the version it publishes passes the
+publish preflight, and it holds the Lease of its mutexKey. Jobs of the cluster
+of the controller, objects of other engines and Jobs of remote clusters are all
+checked with checkNewRun right before they are created, and builds on other
+engines or in remote clusters hold their mutex for as long as their run runs.
+
+The mutex is acquired last, with acquireRunMutex, once nothing else keeps the
+run from starting, in particular once the build holds a fetch slot. It is
+released again, along with the fetch slot, when the run then fails to start.
+*/
+
+// holdSuspended records that lvBuild starts no run until it is resumed, which is
+// a change of its spec.
+func (r *LeviathanBuildReconciler) holdSuspended(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, observed *jcrsv1.LeviathanBuildStatus) (ctrl.Result, error) {
+	logf.FromContext(ctx).Info("Build is suspended, not starting a run")
+	lvBuild.Status.BlockingReason = &jcrsv1.BlockingReason{Reason: jcrsv1.BlockedBySuspend, Details: "spec.suspend is set"}
+	return r.writeBlocked(ctx, lvBuild, observed, 0)
+}
+
+// deferForMaintenance records that lvBuild starts no run until window closes, and
+// the changes to the run that is kept running, if any.
+func (r *LeviathanBuildReconciler) deferForMaintenance(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, observed *jcrsv1.LeviathanBuildStatus, window *openMaintenanceWindow, changes []string) (ctrl.Result, error) {
+	logf.FromContext(ctx).Info("MaintenanceWindow is open, not starting a run", "MaintenanceWindow", window.name, "until", window.end)
+	setDeferredByMaintenanceWindow(lvBuild, window, changes)
+	setBlocked(lvBuild, jcrsv1.BlockedByMaintenanceWindow, jcrsv1.ConditionDeferredByMaintenanceWindow)
+	return r.writeBlocked(ctx, lvBuild, observed, time.Until(window.end))
+}
+
+// holdOutdatedRun records why the outdated run of lvBuild, on another engine or in
+// a remote cluster, is kept rather than replaced: the build is suspended or window
+// is open. The build is reconciled again when window closes.
+func holdOutdatedRun(lvBuild *jcrsv1.LeviathanBuild, window *openMaintenanceWindow, result *ctrl.Result) {
+	switch {
+	case lvBuild.Spec.Suspend:
+		lvBuild.Status.BlockingReason = &jcrsv1.BlockingReason{Reason: jcrsv1.BlockedBySuspend, Details: "spec.suspend is set"}
+	case window != nil:
+		setDeferredByMaintenanceWindow(lvBuild, window, nil)
+		setBlocked(lvBuild, jcrsv1.BlockedByMaintenanceWindow, jcrsv1.ConditionDeferredByMaintenanceWindow)
+		if result.RequeueAfter == 0 || time.Until(window.end) < result.RequeueAfter {
+			result.RequeueAfter = time.Until(window.end)
+		}
+	}
+}
+
+// writeBlocked writes the status of a blocked lvBuild, which is reconciled again
+// after requeueAfter, if set.
+func (r *LeviathanBuildReconciler) writeBlocked(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, observed *jcrsv1.LeviathanBuildStatus, requeueAfter time.Duration) (ctrl.Result, error) {
+	if err := r.updateStatus(ctx, lvBuild, observed); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to update LeviathanBuild status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// checkNewRun runs the checks a new run of lvBuild must pass, window being the
+// MaintenanceWindow open for it, if any. It reports whether the run can start;
+// when it can't, the status of lvBuild has been written and the result is the
+// one of the reconcile.
+func (r *LeviathanBuildReconciler) checkNewRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, observed *jcrsv1.LeviathanBuildStatus, window *openMaintenanceWindow) (bool, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	if lvBuild.Spec.Suspend {
+		result, err := r.holdSuspended(ctx, lvBuild, observed)
+		return false, result, err
+	}
+	if window != nil {
+		result, err := r.deferForMaintenance(ctx, lvBuild, observed, window, nil)
+		return false, result, err
+	}
+
+	authorized, err := r.publishAuthorized(ctx, lvBuild)
+	if err != nil {
+		log.Error(err, "Failed to check publish authorization")
+		return false, ctrl.Result{}, err
+	}
+	if !authorized {
+		log.Info("Build wasn't triggered by a publisher of its package, not starting a run")
+		setBlocked(lvBuild, jcrsv1.BlockedByPublishAuthorization, jcrsv1.ConditionPublishAuthorized)
+		result, err := r.writeBlocked(ctx, lvBuild, observed, 0)
+		return false, result, err
+	}
+
+	proceed, err := r.publishPreflight(ctx, lvBuild)
+	if err != nil {
+		log.Error(err, "Failed to check publish target")
+		return false, ctrl.Result{}, err
+	}
+	if !proceed {
+		log.Info("Publish preflight check did not pass, not starting a run")
+		// A conflicting version fails the build, a skipped build stays blocked
+		if lvBuild.Spec.PublishTarget.ConflictPolicy == jcrsv1.SkipOnConflict {
+			setBlocked(lvBuild, jcrsv1.BlockedByPublishedVersion, jcrsv1.ConditionPublishPreflight)
+		}
+		result, err := r.writeBlocked(ctx, lvBuild, observed, 0)
+		return false, result, err
+	}
+	return true, ctrl.Result{}, nil
+}
+
+// acquireRunMutex acquires the mutex of lvBuild for a new run, once it has passed
+// checkNewRun and holds everything else it waits for, so that the Lease isn't
+// held by a build that can't start. It reports whether the run can start; when
+// it can't, the status of lvBuild has been written and the result is the one of
+// the reconcile. A run that then fails to start must call abandonRun.
+func (r *LeviathanBuildReconciler) acquireRunMutex(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, observed *jcrsv1.LeviathanBuildStatus) (bool, ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	// Builds sharing a mutexKey run one at a time
+	acquired, err := r.acquireMutex(ctx, lvBuild)
+	if err != nil {
+		log.Error(err, "Failed to acquire mutex Lease")
+		return false, ctrl.Result{}, err
+	}
+	setWaitingForMutex(lvBuild, !acquired)
+	if !acquired {
+		log.Info("Waiting for mutex Lease", "mutexKey", *lvBuild.Spec.MutexKey)
+		setBlocked(lvBuild, jcrsv1.BlockedByMutex, jcrsv1.ConditionWaitingForMutex)
+		result, err := r.writeBlocked(ctx, lvBuild, observed, mutexPollInterval)
+		return false, result, err
+	}
+	return true, ctrl.Result{}, nil
+}
+
+// abandonRun releases the fetch slot and the mutex lvBuild acquired for a run
+// whose Job, or object of another engine, couldn't be created. Failing to release
+// the mutex is only logged: the Lease expires unless it is renewed.
+func (r *LeviathanBuildReconciler) abandonRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) {
+	if r.FetchSlots != nil {
+		r.FetchSlots.release(lvBuild)
+	}
+	if err := r.releaseMutex(ctx, lvBuild); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to release mutex Lease")
+	}
+}
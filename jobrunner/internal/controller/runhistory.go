@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/history"
+)
+
+/*
+When an external run history store is configured, the outcome of every run is
+recorded in it, so the history of a build outlives its Jobs. Like the export of
+CloudEvents, a run is recorded again until the store accepts it, which is
+tracked in the status; recording a run twice replaces it.
+
+Whether or not a store is configured, the latest finished runs are summarized in
+status.recentRuns, so the trend of their durations shows from kubectl alone.
+*/
+
+const (
+	// historyRetryInterval is how often failed recordings of runs are retried
+	historyRetryInterval = time.Minute
+
+	// maxRecentRuns is the number of runs summarized in status.recentRuns
+	maxRecentRuns = 10
+)
+
+// recordRun records the finished run of lvBuild by job in the history store, once.
+func (r *LeviathanBuildReconciler) recordRun(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64, finishedType batchv1.JobConditionType) error {
+	if r.History == nil || lvBuild.Status.RecordedRunIndex >= runIndex {
+		return nil
+	}
+	run := history.Run{
+		Namespace: lvBuild.Namespace,
+		Build:     lvBuild.Name,
+		Index:     runIndex,
+		Job:       job.Name,
+		Outcome:   history.Failed,
+		Revision:  lvBuild.Status.SourceRevision,
+	}
+	if finishedType == batchv1.JobComplete {
+		run.Outcome = history.Succeeded
+	}
+	if job.Status.StartTime != nil {
+		run.StartTime = job.Status.StartTime.UTC()
+	}
+	run.CompletionTime = completionTime(job, finishedType)
+	if lvBuild.Status.BuilderImage != nil {
+		run.BuilderImage = lvBuild.Status.BuilderImage.Image
+	}
+	if err := r.History.Record(ctx, run); err != nil {
+		return err
+	}
+	lvBuild.Status.RecordedRunIndex = runIndex
+	return nil
+}
+
+// completionTime returns the time job finished as finishedType, or now if it isn't known.
+func completionTime(job *batchv1.Job, finishedType batchv1.JobConditionType) time.Time {
+	completion := time.Now().UTC()
+	for _, c := range job.Status.Conditions {
+		if c.Type == finishedType {
+			completion = c.LastTransitionTime.UTC()
+		}
+	}
+	return completion
+}
+
+// recordRecentRun summarizes the finished run of lvBuild by job in its status.
+// A run summarized again, e.g. once its published version is rolled back,
+// replaces its previous summary.
+func recordRecentRun(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64, finishedType batchv1.JobConditionType) {
+	run := jcrsv1.RecentRun{
+		RunIndex: runIndex,
+		Result:   jcrsv1.RunFailed,
+		Revision: lvBuild.Status.SourceRevision,
+	}
+	if finishedType == batchv1.JobComplete {
+		run.Result = jcrsv1.RunSucceeded
+	}
+	if start := job.Status.StartTime; start != nil {
+		run.StartTime = start.DeepCopy()
+		run.Duration = metav1.Duration{Duration: completionTime(job, finishedType).Sub(start.Time).Round(time.Second)}
+	}
+
+	runs := slices.DeleteFunc(lvBuild.Status.RecentRuns, func(r jcrsv1.RecentRun) bool { return r.RunIndex == runIndex })
+	runs = append([]jcrsv1.RecentRun{run}, runs...)
+	slices.SortStableFunc(runs, func(a, b jcrsv1.RecentRun) int { return cmp.Compare(b.RunIndex, a.RunIndex) })
+	lvBuild.Status.RecentRuns = runs[:min(len(runs), maxRecentRuns)]
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/history"
+)
+
+// fakeHistory records the runs recorded in it, and fails while err is set.
+type fakeHistory struct {
+	runs []history.Run
+	err  error
+}
+
+func (h *fakeHistory) Record(_ context.Context, run history.Run) error {
+	if h.err != nil {
+		return h.err
+	}
+	h.runs = append(h.runs, run)
+	return nil
+}
+
+func (h *fakeHistory) List(context.Context, history.Query) ([]history.Run, error) {
+	return h.runs, nil
+}
+
+var _ = Describe("Run history", func() {
+	It("records the outcome of a run once the store accepts it", func() {
+		started := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC))
+		finished := metav1.NewTime(started.Add(5 * time.Minute))
+		lvBuild := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Status: jcrsv1.LeviathanBuildStatus{
+				SourceRevision: "sha256:abcd",
+				BuilderImage:   &jcrsv1.ResolvedBuilderImage{Image: "builder:1.0"},
+			},
+		}
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-2-abcde"}}
+		job.Status.StartTime = &started
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: finished}}
+		store := &fakeHistory{err: errors.New("unavailable")}
+		r := &LeviathanBuildReconciler{History: store}
+
+		Expect(r.recordRun(context.Background(), lvBuild, job, 2, batchv1.JobFailed)).NotTo(Succeed())
+		Expect(lvBuild.Status.RecordedRunIndex).To(BeZero())
+
+		store.err = nil
+		Expect(r.recordRun(context.Background(), lvBuild, job, 2, batchv1.JobFailed)).To(Succeed())
+		Expect(store.runs).To(Equal([]history.Run{{
+			Namespace: "default", Build: "web", Index: 2, Job: "web-2-abcde", Outcome: history.Failed,
+			StartTime: started.Time, CompletionTime: finished.Time, Revision: "sha256:abcd", BuilderImage: "builder:1.0",
+		}}))
+		Expect(lvBuild.Status.RecordedRunIndex).To(Equal(int64(2)))
+
+		By("not recording the run again")
+		Expect(r.recordRun(context.Background(), lvBuild, job, 2, batchv1.JobFailed)).To(Succeed())
+		Expect(store.runs).To(HaveLen(1))
+	})
+
+	It("summarizes the latest runs in the status, newest first", func() {
+		lvBuild := &jcrsv1.LeviathanBuild{Status: jcrsv1.LeviathanBuildStatus{SourceRevision: "0123abcd"}}
+		run := func(runIndex int64, minutes time.Duration, finishedType batchv1.JobConditionType) {
+			started := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC).Add(time.Duration(runIndex) * time.Hour))
+			job := &batchv1.Job{}
+			job.Status.StartTime = &started
+			job.Status.Conditions = []batchv1.JobCondition{{
+				Type: finishedType, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(started.Add(minutes * time.Minute)),
+			}}
+			recordRecentRun(lvBuild, job, runIndex, finishedType)
+		}
+
+		run(1, 3, batchv1.JobComplete)
+		run(2, 5, batchv1.JobFailed)
+		Expect(lvBuild.Status.RecentRuns).To(HaveExactElements(
+			jcrsv1.RecentRun{
+				RunIndex: 2, Result: jcrsv1.RunFailed, Revision: "0123abcd",
+				StartTime: ptr.To(metav1.NewTime(time.Date(2025, 1, 2, 5, 4, 0, 0, time.UTC))),
+				Duration:  metav1.Duration{Duration: 5 * time.Minute},
+			},
+			HaveField("RunIndex", int64(1)),
+		))
+
+		By("replacing the summary of a run summarized again")
+		run(2, 5, batchv1.JobComplete)
+		Expect(lvBuild.Status.RecentRuns).To(HaveLen(2))
+		Expect(lvBuild.Status.RecentRuns[0].Result).To(Equal(jcrsv1.RunSucceeded))
+
+		By("keeping the latest runs only")
+		for i := int64(3); i <= 12; i++ {
+			run(i, 1, batchv1.JobComplete)
+		}
+		Expect(lvBuild.Status.RecentRuns).To(HaveLen(10))
+		Expect(lvBuild.Status.RecentRuns[0].RunIndex).To(Equal(int64(12)))
+		Expect(lvBuild.Status.RecentRuns[9].RunIndex).To(Equal(int64(3)))
+	})
+})
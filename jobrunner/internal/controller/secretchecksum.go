@@ -0,0 +1,276 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+const (
+	// SecretChecksumsAnnotation records the checksums of the Secrets the pods of
+	// a run read from, as "name=checksum" pairs separated by commas
+	SecretChecksumsAnnotation = "jcrs.jcrs.dev/secret-checksums"
+
+	// secretChecksumsKey indexes Jobs by the Secrets of their SecretChecksumsAnnotation
+	secretChecksumsKey = ".metadata.annotations.secretChecksums"
+
+	// secretChecksumLength is the length of the checksums of Secrets
+	secretChecksumLength = 16
+
+	// credentialsRotatedReason is the reason of the Event recorded when a Secret
+	// of a running run is rotated
+	credentialsRotatedReason = "CredentialsRotated"
+)
+
+/*
+Long running builds mounting Secrets see them change under their feet: the
+kubelet updates mounted Secrets when they are rotated, and a publish step
+running after the rotation uses other credentials than the steps before it,
+while environment variables keep the old ones. A publish failing halfway through
+a run for that reason looks like a flaky registry.
+
+Builds with checksumSecrets record a checksum of the data of every Secret their
+pods read from when a run is created, on the Job and on its pod template. The
+pods read the Secrets of their volumes, projected volumes, env and envFrom; pull
+Secrets are only used to pull images as containers start, and aren't recorded.
+The checksums are also kept on the Job itself, as the cache of managed Jobs
+strips their pod template.
+
+Jobs are indexed by the Secrets they recorded, so the rotation of a Secret
+reconciles the builds whose running Job read it. A run whose Secret changed, or
+was deleted, is reported by the CredentialsRotatedDuringRun condition until the
+next run starts. Runs aren't replaced: the replacement would start from scratch
+and may fail the same way on the next rotation, the condition tells users why
+the run failed instead. Changes to the labels or annotations of a Secret aren't
+rotations.
+*/
+
+// podSecrets returns the names of the Secrets the pods of podSpec read
+// from, sorted.
+func podSecrets(podSpec *corev1.PodSpec) []string {
+	names := make(map[string]bool)
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret != nil {
+			names[volume.Secret.SecretName] = true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					names[source.Secret.Name] = true
+				}
+			}
+		}
+	}
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for _, c := range containers {
+			for _, env := range c.Env {
+				if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+					names[env.ValueFrom.SecretKeyRef.Name] = true
+				}
+			}
+			for _, envFrom := range c.EnvFrom {
+				if envFrom.SecretRef != nil {
+					names[envFrom.SecretRef.Name] = true
+				}
+			}
+		}
+	}
+	delete(names, "")
+	return slices.Sorted(maps.Keys(names))
+}
+
+// secretChecksum returns a checksum of the data of secret.
+func secretChecksum(secret *corev1.Secret) string {
+	hash := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(secret.Data)) {
+		fmt.Fprintf(hash, "%d:%s%d:", len(key), key, len(secret.Data[key]))
+		hash.Write(secret.Data[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:secretChecksumLength]
+}
+
+// secretChecksums returns the checksums of the Secrets of namespace named
+// names, by name. Missing Secrets have no checksum.
+func (r *LeviathanBuildReconciler) secretChecksums(ctx context.Context, namespace string, names []string) (map[string]string, error) {
+	checksums := make(map[string]string, len(names))
+	for _, name := range names {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		checksums[name] = secretChecksum(secret)
+	}
+	return checksums, nil
+}
+
+// formatSecretChecksums returns the value of SecretChecksumsAnnotation for checksums.
+func formatSecretChecksums(checksums map[string]string) string {
+	pairs := make([]string, 0, len(checksums))
+	for _, name := range slices.Sorted(maps.Keys(checksums)) {
+		pairs = append(pairs, name+"="+checksums[name])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// parseSecretChecksums returns the checksums of value, a value of
+// SecretChecksumsAnnotation, by Secret name.
+func parseSecretChecksums(value string) map[string]string {
+	checksums := make(map[string]string)
+	for pair := range strings.SplitSeq(value, ",") {
+		if name, checksum, ok := strings.Cut(pair, "="); ok && name != "" {
+			checksums[name] = checksum
+		}
+	}
+	return checksums
+}
+
+// annotateSecretChecksums records the checksums of the Secrets read by the
+// pods of job, the Job of a new run of lvBuild, when lvBuild has
+// checksumSecrets. The rotations of the previous run are forgotten.
+func (r *LeviathanBuildReconciler) annotateSecretChecksums(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) error {
+	meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionCredentialsRotatedDuringRun)
+	if !featuregates.Enabled(featuregates.SecretChecksums) || !lvBuild.Spec.ChecksumSecrets {
+		return nil
+	}
+	checksums, err := r.secretChecksums(ctx, job.Namespace, podSecrets(&job.Spec.Template.Spec))
+	if err != nil {
+		return err
+	}
+	value := formatSecretChecksums(checksums)
+	if job.Annotations == nil {
+		job.Annotations = make(map[string]string)
+	}
+	job.Annotations[SecretChecksumsAnnotation] = value
+	if job.Spec.Template.Annotations == nil {
+		job.Spec.Template.Annotations = make(map[string]string)
+	}
+	job.Spec.Template.Annotations[SecretChecksumsAnnotation] = value
+	return nil
+}
+
+// keepSecretChecksums copies the checksums recorded on existing, the Job of the
+// latest run, to desired, so that they don't make existing outdated.
+func keepSecretChecksums(desired, existing *batchv1.Job) {
+	value, ok := existing.Annotations[SecretChecksumsAnnotation]
+	if !ok {
+		return
+	}
+	if desired.Spec.Template.Annotations == nil {
+		desired.Spec.Template.Annotations = make(map[string]string)
+	}
+	desired.Spec.Template.Annotations[SecretChecksumsAnnotation] = value
+}
+
+// checkSecretRotation sets the CredentialsRotatedDuringRun condition of lvBuild
+// when a Secret recorded by job, the running Job of its latest run, changed
+// since job was created.
+func (r *LeviathanBuildReconciler) checkSecretRotation(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) error {
+	value, ok := job.Annotations[SecretChecksumsAnnotation]
+	if !ok || meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionCredentialsRotatedDuringRun) {
+		return nil
+	}
+	recorded := parseSecretChecksums(value)
+	current, err := r.secretChecksums(ctx, job.Namespace, slices.Sorted(maps.Keys(recorded)))
+	if err != nil {
+		return err
+	}
+	var rotated []string
+	for _, name := range slices.Sorted(maps.Keys(recorded)) {
+		if current[name] != recorded[name] {
+			rotated = append(rotated, name)
+		}
+	}
+	if len(rotated) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("Secret(s) %s changed while Job %s was running, its steps may have used different credentials",
+		strings.Join(rotated, ", "), job.Name)
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionCredentialsRotatedDuringRun,
+		Status:             metav1.ConditionTrue,
+		Reason:             jcrsv1.ReasonSecretsRotated,
+		Message:            message,
+		ObservedGeneration: lvBuild.Generation,
+	})
+	r.event(lvBuild, corev1.EventTypeWarning, credentialsRotatedReason, "%s", message)
+	return nil
+}
+
+// indexSecretChecksums is the index function for secretChecksumsKey.
+func indexSecretChecksums(rawObj client.Object) []string {
+	value, ok := rawObj.GetAnnotations()[SecretChecksumsAnnotation]
+	if !ok {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(parseSecretChecksums(value)))
+}
+
+// buildsForRotatedSecret maps a Secret to the builds whose running Job
+// recorded its checksum.
+func (r *LeviathanBuildReconciler) buildsForRotatedSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(obj.GetNamespace()), client.MatchingFields{secretChecksumsKey: obj.GetName()}); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		owner := metav1.GetControllerOf(job)
+		if finished, _ := isJobFinished(job); finished || owner == nil || owner.APIVersion != apiGVStr || owner.Kind != "LeviathanBuild" {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: job.Namespace, Name: owner.Name}})
+	}
+	return requests
+}
+
+// buildsForSecret maps a Secret to the builds reading their parameters from it
+// and, with the SecretChecksums gate, to the builds whose running Job recorded its
+// checksum, so that Secrets are watched once for both.
+func (r *LeviathanBuildReconciler) buildsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	requests := r.buildsForParametersSource(secretKind)(ctx, obj)
+	if !featuregates.Enabled(featuregates.SecretChecksums) {
+		return requests
+	}
+	for _, request := range r.buildsForRotatedSecret(ctx, obj) {
+		if !slices.Contains(requests, request) {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+var _ = Describe("Secret checksums", func() {
+	var (
+		ctx      context.Context
+		c        client.Client
+		recorder *record.FakeRecorder
+		r        *LeviathanBuildReconciler
+		lvBuild  *jcrsv1.LeviathanBuild
+		job      *batchv1.Job
+		token    *corev1.Secret
+	)
+
+	BeforeEach(func() {
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.SecretChecksums: true})).To(Succeed())
+		DeferCleanup(func() {
+			Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.SecretChecksums: false})).To(Succeed())
+		})
+
+		ctx = context.Background()
+		token = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "publish-token", Namespace: "team"},
+			Data:       map[string][]byte{"token": []byte("v1")},
+		}
+		signing := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: "team"},
+			Data:       map[string][]byte{"key": []byte("k1")},
+		}
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "team").Obj()
+		lvBuild.Spec.ChecksumSecrets = true
+		job = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "team"},
+			Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				Volumes: []corev1.Volume{
+					{Name: "signing", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "signing-key"}}},
+				},
+				Containers: []corev1.Container{{
+					Name: "build",
+					Env: []corev1.EnvVar{{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "publish-token"}, Key: "token"},
+					}}},
+					EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "missing"}}}},
+				}},
+			}}},
+		}
+		c = newFakeClient(token, signing)
+		recorder = record.NewFakeRecorder(10)
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme(), Recorder: recorder}
+	})
+
+	It("lists the Secrets the pods read, but not their pull Secrets", func() {
+		Expect(podSecrets(&job.Spec.Template.Spec)).To(Equal([]string{"missing", "publish-token", "signing-key"}))
+	})
+
+	It("records the checksums of the existing Secrets on the Job and its pod template", func() {
+		Expect(r.annotateSecretChecksums(ctx, lvBuild, job)).To(Succeed())
+		value := job.Annotations[SecretChecksumsAnnotation]
+		Expect(job.Spec.Template.Annotations).To(HaveKeyWithValue(SecretChecksumsAnnotation, value))
+		checksums := parseSecretChecksums(value)
+		Expect(checksums).To(HaveKeyWithValue("publish-token", secretChecksum(token)))
+		Expect(checksums).To(HaveKey("signing-key"))
+		Expect(checksums).NotTo(HaveKey("missing"))
+		Expect(formatSecretChecksums(checksums)).To(Equal(value))
+
+		By("not recording them for builds without checksumSecrets")
+		job.Annotations, job.Spec.Template.Annotations = nil, nil
+		lvBuild.Spec.ChecksumSecrets = false
+		Expect(r.annotateSecretChecksums(ctx, lvBuild, job)).To(Succeed())
+		Expect(job.Annotations).NotTo(HaveKey(SecretChecksumsAnnotation))
+	})
+
+	It("reports the rotation of a Secret while the Job is running", func() {
+		Expect(r.annotateSecretChecksums(ctx, lvBuild, job)).To(Succeed())
+
+		By("not reporting anything while the Secrets are unchanged")
+		token.Labels = map[string]string{"rotated-by": "vault"}
+		Expect(c.Update(ctx, token)).To(Succeed())
+		Expect(r.checkSecretRotation(ctx, lvBuild, job)).To(Succeed())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionCredentialsRotatedDuringRun)).To(BeNil())
+
+		By("reporting the Secrets whose data changed")
+		token.Data["token"] = []byte("v2")
+		Expect(c.Update(ctx, token)).To(Succeed())
+		Expect(r.checkSecretRotation(ctx, lvBuild, job)).To(Succeed())
+		condition := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionCredentialsRotatedDuringRun)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(jcrsv1.ReasonSecretsRotated))
+		Expect(condition.Message).To(ContainSubstring("publish-token changed while Job web-1 was running"))
+		Expect(recorder.Events).To(Receive(ContainSubstring(credentialsRotatedReason)))
+
+		By("forgetting the rotation once the next run is created")
+		Expect(r.annotateSecretChecksums(ctx, lvBuild, job)).To(Succeed())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionCredentialsRotatedDuringRun)).To(BeNil())
+	})
+
+	It("reconciles a build once for a Secret it reads parameters from and recorded the checksum of", func() {
+		lvBuild.Spec.ParametersFrom = []jcrsv1.ParametersSource{{SecretRef: &corev1.LocalObjectReference{Name: "publish-token"}}}
+		Expect(r.annotateSecretChecksums(ctx, lvBuild, job)).To(Succeed())
+		job.OwnerReferences = []metav1.OwnerReference{{APIVersion: apiGVStr, Kind: "LeviathanBuild", Name: "web", Controller: ptr.To(true)}}
+		c = newFakeClientBuilder().
+			WithIndex(&jcrsv1.LeviathanBuild{}, parametersSourceKey, indexParametersSources).
+			WithIndex(&batchv1.Job{}, secretChecksumsKey, indexSecretChecksums).
+			WithObjects(lvBuild, job, token).Build()
+		r.Client = c
+
+		Expect(r.buildsForSecret(ctx, token)).To(HaveExactElements(
+			reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "web"}}))
+
+		By("only mapping to the builds reading their parameters without the SecretChecksums gate")
+		Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.SecretChecksums: false})).To(Succeed())
+		lvBuild.Spec.ParametersFrom = nil
+		Expect(c.Update(ctx, lvBuild)).To(Succeed())
+		Expect(r.buildsForSecret(ctx, token)).To(BeEmpty())
+	})
+
+	It("keeps the recorded checksums on the desired Job", func() {
+		Expect(r.annotateSecretChecksums(ctx, lvBuild, job)).To(Succeed())
+		desired := &batchv1.Job{}
+		keepSecretChecksums(desired, job)
+		Expect(desired.Spec.Template.Annotations).To(Equal(job.Spec.Template.Annotations))
+		Expect(indexSecretChecksums(job)).To(Equal([]string{"publish-token", "signing-key"}))
+	})
+})
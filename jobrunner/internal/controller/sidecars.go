@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A Job only completes once every container of its pod has exited, so a service
+container like docker-in-docker running next to the build keeps it running forever.
+
+Since Kubernetes 1.29, such containers can run as native sidecars: init containers
+with a restartPolicy of Always, which the kubelet stops once the regular containers
+have exited. On older clusters, the sidecars run as regular containers and both
+sides are wrapped with a shell: every build container leaves a marker in a shared
+volume when it exits, and the sidecars are stopped once all markers are present.
+Wrapping a container requires knowing its command, so it must be set.
+
+The heartbeat sidecar runs like the sidecars of the build, but isn't wrapped: it
+watches the markers of the build containers itself.
+*/
+
+const (
+	// sidecarSignalVolumeName is the volume holding the markers of the exited build containers
+	sidecarSignalVolumeName = "sidecar-signal"
+	sidecarSignalMountPath  = "/var/run/leviathan/sidecars"
+)
+
+// nativeSidecarsVersion is the first Kubernetes version running native sidecars by default
+var nativeSidecarsVersion = version.MajorMinor(1, 29)
+
+// NativeSidecarsSupported reports whether the cluster behind cfg runs native sidecars.
+func NativeSidecarsSupported(cfg *rest.Config) (bool, error) {
+	return serverVersionAtLeast(cfg, nativeSidecarsVersion)
+}
+
+// serverVersionAtLeast reports whether the cluster behind cfg runs minVersion or later.
+func serverVersionAtLeast(cfg *rest.Config, minVersion *version.Version) (bool, error) {
+	client, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return false, err
+	}
+	info, err := client.ServerVersion()
+	if err != nil {
+		return false, err
+	}
+	serverVersion, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return false, err
+	}
+	return serverVersion.AtLeast(minVersion), nil
+}
+
+// addSidecars adds the sidecars of lvBuild and its heartbeat sidecar to the build
+// Job, either as native sidecars or wrapped so they're stopped once the build
+// containers have exited.
+func (r *LeviathanBuildReconciler) addSidecars(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) error {
+	heartbeat := r.heartbeatSidecar(lvBuild)
+	if len(lvBuild.Spec.Sidecars) == 0 && heartbeat == nil {
+		return nil
+	}
+	podSpec := &job.Spec.Template.Spec
+
+	if r.NativeSidecars {
+		for _, sidecar := range lvBuild.Spec.Sidecars {
+			sidecar = *sidecar.DeepCopy()
+			sidecar.RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
+			podSpec.InitContainers = append(podSpec.InitContainers, sidecar)
+		}
+		if heartbeat != nil {
+			heartbeat.RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
+			podSpec.InitContainers = append(podSpec.InitContainers, *heartbeat)
+		}
+		return nil
+	}
+
+	var markers []string
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if len(c.Command) == 0 {
+			return fmt.Errorf("container %s must set a command to run next to sidecars on this cluster", c.Name)
+		}
+		marker := path.Join(sidecarSignalMountPath, c.Name+".done")
+		markers = append(markers, marker)
+		wrapCommand(c, fmt.Sprintf(`"$@"; status=$?; touch %s; exit $status`, marker))
+		addContainerVolumeMount(c, corev1.VolumeMount{Name: sidecarSignalVolumeName, MountPath: sidecarSignalMountPath})
+	}
+
+	// The sidecar exits with its own status if it stops on its own, and
+	// successfully once it has been stopped, so it doesn't fail the pod
+	var waiting []string
+	for _, marker := range markers {
+		waiting = append(waiting, fmt.Sprintf("[ ! -f %s ]", marker))
+	}
+	script := fmt.Sprintf(`"$@" & pid=$!
+while %s; do
+  if ! kill -0 $pid 2>/dev/null; then wait $pid; exit $?; fi
+  sleep 1
+done
+kill $pid; wait $pid; exit 0`, strings.Join(waiting, " || "))
+	for _, sidecar := range lvBuild.Spec.Sidecars {
+		if len(sidecar.Command) == 0 {
+			return fmt.Errorf("sidecar %s must set a command to run on this cluster", sidecar.Name)
+		}
+		sidecar = *sidecar.DeepCopy()
+		wrapCommand(&sidecar, script)
+		addContainerVolumeMount(&sidecar, corev1.VolumeMount{Name: sidecarSignalVolumeName, MountPath: sidecarSignalMountPath})
+		podSpec.Containers = append(podSpec.Containers, sidecar)
+	}
+	if heartbeat != nil {
+		heartbeat.Env = append(heartbeat.Env, corev1.EnvVar{Name: "HEARTBEAT_DONE", Value: strings.Join(markers, ",")})
+		addContainerVolumeMount(heartbeat, corev1.VolumeMount{Name: sidecarSignalVolumeName, MountPath: sidecarSignalMountPath})
+		podSpec.Containers = append(podSpec.Containers, *heartbeat)
+	}
+	addVolume(podSpec, corev1.Volume{
+		Name:         sidecarSignalVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	return nil
+}
+
+// wrapCommand runs the command and args of c as the arguments of a shell script.
+func wrapCommand(c *corev1.Container, script string) {
+	c.Command = append([]string{"/bin/sh", "-c", script, c.Name}, append(c.Command, c.Args...)...)
+	c.Args = nil
+}
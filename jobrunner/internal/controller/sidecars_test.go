@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Sidecars", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{Spec: jcrsv1.LeviathanBuildSpec{
+			Sidecars: []corev1.Container{{Name: "dind", Image: "docker:dind", Command: []string{"dockerd-entrypoint.sh"}}},
+		}}
+		job = &batchv1.Job{}
+		job.Spec.Template.Spec.Containers = []corev1.Container{
+			{Name: "build", Image: "builder", Command: []string{"make"}, Args: []string{"image"}},
+		}
+	})
+
+	It("runs native sidecars as restartable init containers", func() {
+		r := &LeviathanBuildReconciler{NativeSidecars: true}
+		Expect(r.addSidecars(lvBuild, job)).To(Succeed())
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.Containers).To(HaveLen(1))
+		Expect(podSpec.Containers[0].Command).To(Equal([]string{"make"}))
+		Expect(podSpec.InitContainers).To(HaveLen(1))
+		Expect(podSpec.InitContainers[0].Name).To(Equal("dind"))
+		Expect(podSpec.InitContainers[0].RestartPolicy).To(Equal(ptr.To(corev1.ContainerRestartPolicyAlways)))
+		Expect(lvBuild.Spec.Sidecars[0].RestartPolicy).To(BeNil())
+	})
+
+	It("wraps the build containers and sidecars on older clusters", func() {
+		r := &LeviathanBuildReconciler{}
+		Expect(r.addSidecars(lvBuild, job)).To(Succeed())
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.InitContainers).To(BeEmpty())
+		Expect(podSpec.Containers).To(HaveLen(2))
+		build, dind := podSpec.Containers[0], podSpec.Containers[1]
+
+		Expect(build.Command[:2]).To(Equal([]string{"/bin/sh", "-c"}))
+		Expect(build.Command[2]).To(ContainSubstring("touch /var/run/leviathan/sidecars/build.done"))
+		Expect(build.Command[3:]).To(Equal([]string{"build", "make", "image"}))
+		Expect(build.Args).To(BeEmpty())
+
+		Expect(dind.Command[2]).To(ContainSubstring("[ ! -f /var/run/leviathan/sidecars/build.done ]"))
+		Expect(dind.Command[3:]).To(Equal([]string{"dind", "dockerd-entrypoint.sh"}))
+		for _, c := range podSpec.Containers {
+			Expect(c.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: sidecarSignalVolumeName, MountPath: sidecarSignalMountPath}))
+		}
+		Expect(podSpec.Volumes).To(ContainElement(HaveField("Name", sidecarSignalVolumeName)))
+	})
+
+	It("can't wrap containers without a command", func() {
+		r := &LeviathanBuildReconciler{}
+		lvBuild.Spec.Sidecars[0].Command = nil
+		Expect(r.addSidecars(lvBuild, job)).To(MatchError(ContainSubstring("sidecar dind must set a command")))
+
+		job.Spec.Template.Spec.Containers[0].Command = nil
+		Expect(r.addSidecars(lvBuild, job)).To(MatchError(ContainSubstring("container build must set a command")))
+	})
+})
@@ -0,0 +1,199 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/signing"
+)
+
+/*
+Once a publishing build with spec.signing has succeeded and reported the digest
+of its artifact, a signing Job is created for the run. It is named after the run,
+and doesn't carry the labels of the Jobs of runs, so it's never taken for one. Its
+provenance predicate is held by a ConfigMap owned by the Job, so both go away
+together; the signing Jobs of earlier runs are deleted once a new one is created.
+
+The patchTargets of onSuccess wait for the artifact to be signed, so that what
+they roll out passes the signature policies of the cluster. A failed signing Job
+isn't retried, the next run of the build signs its own artifact.
+*/
+
+const (
+	// signingLabel identifies the signing Jobs of a build
+	signingLabel = "jcrs.jcrs.dev/signs"
+	// signingRunIndexAnnotation records the run a signing Job signs the artifact of
+	signingRunIndexAnnotation = "jcrs.jcrs.dev/signs-run-index"
+
+	// signingStartedReason is the reason of the Event recorded when a signing Job is created
+	signingStartedReason = "SigningStarted"
+	// signedReason is the reason of the Event recorded when an artifact is signed
+	signedReason = "Signed"
+	// signingFailedReason is the reason of the Event recorded when a signing Job fails
+	signingFailedReason = "SigningFailed"
+)
+
+// signingJobName returns the name of the signing Job of the run runIndex of lvBuild.
+func signingJobName(lvBuild *jcrsv1.LeviathanBuild, runIndex int64) string {
+	return shortenName(fmt.Sprintf("%s-%d-sign", lvBuild.Name, runIndex), validation.DNS1123LabelMaxLength)
+}
+
+// setSigned records the signing of the artifact of the latest run of lvBuild.
+func setSigned(lvBuild *jcrsv1.LeviathanBuild, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{
+		Type:               jcrsv1.ConditionSigned,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: lvBuild.Generation,
+	})
+}
+
+// resetSigning forgets the signature of the artifact of the previous run of lvBuild.
+func resetSigning(lvBuild *jcrsv1.LeviathanBuild) {
+	lvBuild.Status.Signature = nil
+	meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionSigned)
+}
+
+// reconcileSigning signs the artifact published by job, the succeeded Job of the
+// run runIndex of lvBuild. It reports whether the artifact is signed, or doesn't
+// need to be.
+func (r *LeviathanBuildReconciler) reconcileSigning(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, runIndex int64) (bool, error) {
+	spec := lvBuild.Spec.Signing
+	if spec == nil || !spec.Enabled {
+		resetSigning(lvBuild)
+		return true, nil
+	}
+	if r.Signing.CosignImage == "" {
+		setSigned(lvBuild, metav1.ConditionFalse, jcrsv1.ReasonFeatureDisabled, "Signing is disabled in the controller")
+		return true, nil
+	}
+	if lvBuild.Status.PublishedDigest == "" {
+		setSigned(lvBuild, metav1.ConditionFalse, jcrsv1.ReasonSigningFailed, "The build didn't report the digest of its artifact")
+		return false, nil
+	}
+	image, err := signing.ImageReference(lvBuild.Spec.PublishTarget.RegistryURL, publishPathOf(lvBuild), lvBuild.Status.PublishedDigest)
+	if err != nil {
+		setSigned(lvBuild, metav1.ConditionFalse, jcrsv1.ReasonSigningFailed, err.Error())
+		return false, nil
+	}
+	if signature := lvBuild.Status.Signature; signature != nil && signature.Image == image {
+		return true, nil
+	}
+
+	req := signing.Request{
+		Name:               signingJobName(lvBuild, runIndex),
+		Namespace:          lvBuild.Namespace,
+		Image:              image,
+		Spec:               *spec,
+		ServiceAccountName: job.Spec.Template.Spec.ServiceAccountName,
+	}
+	signingJob := &batchv1.Job{}
+	err = r.Get(ctx, client.ObjectKey{Namespace: req.Namespace, Name: req.Name}, signingJob)
+	if apierrors.IsNotFound(err) {
+		return false, r.createSigningJob(ctx, lvBuild, job, req, runIndex)
+	} else if err != nil {
+		return false, err
+	}
+
+	switch finished, finishedType := isJobFinished(signingJob); {
+	case !finished:
+		setSigned(lvBuild, metav1.ConditionUnknown, jcrsv1.ReasonSigning, "Job "+signingJob.Name+" is signing "+image)
+		return false, nil
+	case finishedType == batchv1.JobFailed:
+		if !meta.IsStatusConditionPresentAndEqual(lvBuild.Status.Conditions, jcrsv1.ConditionSigned, metav1.ConditionFalse) {
+			r.event(lvBuild, corev1.EventTypeWarning, signingFailedReason, "Job %s failed to sign %s", signingJob.Name, image)
+		}
+		setSigned(lvBuild, metav1.ConditionFalse, jcrsv1.ReasonSigningFailed, "Job "+signingJob.Name+" failed to sign "+image)
+		return false, nil
+	}
+
+	signature := r.Signing.Verification(req)
+	signature.SignedAt = metav1.Now()
+	if signingJob.Status.CompletionTime != nil {
+		signature.SignedAt = *signingJob.Status.CompletionTime
+	}
+	lvBuild.Status.Signature = &signature
+	setSigned(lvBuild, metav1.ConditionTrue, jcrsv1.ReasonSigned, image+" is signed")
+	r.event(lvBuild, corev1.EventTypeNormal, signedReason, "Signed %s", image)
+	return true, nil
+}
+
+// createSigningJob creates the signing Job of req and its provenance predicate,
+// and deletes the signing Jobs of the earlier runs of lvBuild.
+func (r *LeviathanBuildReconciler) createSigningJob(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, req signing.Request, runIndex int64) error {
+	signingJob := r.Signing.Job(req)
+	signingJob.Labels = map[string]string{signingLabel: labelValue(lvBuild.Name), ManagedLabel: "true"}
+	signingJob.Annotations = map[string]string{signingRunIndexAnnotation: strconv.FormatInt(runIndex, 10)}
+	if err := ctrl.SetControllerReference(lvBuild, signingJob, r.Scheme); err != nil {
+		return err
+	}
+	logf.FromContext(ctx).Info("Creating signing Job", "Job.Namespace", signingJob.Namespace, "Job.Name", signingJob.Name, "image", req.Image)
+	if err := r.Create(ctx, signingJob); err != nil {
+		return err
+	}
+
+	// The pod of the Job waits for the ConfigMap of its predicate to be mounted
+	if signing.NeedsProvenance(req) {
+		predicate, err := signing.NewProvenance(lvBuild, job).Marshal()
+		if err != nil {
+			return err
+		}
+		predicates := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace, Labels: map[string]string{signingLabel: labelValue(lvBuild.Name)}},
+			Data:       map[string]string{signing.ProvenanceKey: predicate},
+		}
+		if err := ctrl.SetControllerReference(signingJob, predicates, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, predicates); client.IgnoreAlreadyExists(err) != nil {
+			return err
+		}
+	}
+
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(lvBuild.Namespace), client.MatchingLabels{signingLabel: labelValue(lvBuild.Name)}); err != nil {
+		return err
+	}
+	for i := range jobs.Items {
+		earlier := &jobs.Items[i]
+		if earlier.Name == signingJob.Name || !metav1.IsControlledBy(earlier, lvBuild) {
+			continue
+		}
+		if err := r.Delete(ctx, earlier, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	setSigned(lvBuild, metav1.ConditionUnknown, jcrsv1.ReasonSigning, "Job "+signingJob.Name+" is signing "+req.Image)
+	r.event(lvBuild, corev1.EventTypeNormal, signingStartedReason, "Signing %s with Job %s", req.Image, signingJob.Name)
+	return nil
+}
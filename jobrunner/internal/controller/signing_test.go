@@ -0,0 +1,164 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/signing"
+)
+
+var _ = Describe("Artifact signing", func() {
+	const digest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+	var (
+		ctx     context.Context
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+		r       *LeviathanBuildReconciler
+	)
+
+	signingJob := func(runIndex int64) *batchv1.Job {
+		signed := &batchv1.Job{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "default", Name: signingJobName(lvBuild, runIndex)}, signed)).To(Succeed())
+		return signed
+	}
+	finish := func(signed *batchv1.Job, conditionType batchv1.JobConditionType) {
+		signed.Status.Conditions = append(signed.Status.Conditions, batchv1.JobCondition{Type: conditionType, Status: corev1.ConditionTrue})
+		Expect(r.Status().Update(ctx, signed)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c := newFakeClient()
+		r = &LeviathanBuildReconciler{
+			Client:  c,
+			Scheme:  c.Scheme(),
+			Signing: signing.Config{CosignImage: "cosign:test", SyftImage: "syft:test"},
+		}
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName:   ptr.To("web"),
+				PublishTarget: &jcrsv1.PublishTarget{RegistryURL: "https://registry.example.com/team", Version: "1.0.0"},
+				Signing: &jcrsv1.SigningSpec{
+					Enabled:      true,
+					KeyRef:       &corev1.LocalObjectReference{Name: "cosign-key"},
+					Attestations: []jcrsv1.AttestationType{jcrsv1.ProvenanceAttestation},
+				},
+			},
+			Status: jcrsv1.LeviathanBuildStatus{RunIndex: 2, PublishedDigest: digest},
+		}
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-2-abcde", Namespace: "default", UID: "job-uid"}}
+	})
+
+	It("holds the patch targets until the signing Job has signed the artifact", func() {
+		signed, err := r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeFalse())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSigned)).To(HaveField("Reason", jcrsv1.ReasonSigning))
+
+		created := signingJob(2)
+		Expect(created.Labels).To(HaveKeyWithValue(signingLabel, "web"))
+		Expect(created.Labels).NotTo(HaveKey(buildLabel))
+		Expect(metav1.IsControlledBy(created, lvBuild)).To(BeTrue())
+		Expect(created.Spec.Template.Spec.Containers[0].Args).To(ContainElement("registry.example.com/team/web@" + digest))
+
+		predicates := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "default", Name: created.Name}, predicates)).To(Succeed())
+		Expect(metav1.IsControlledBy(predicates, created)).To(BeTrue())
+		Expect(predicates.Data).To(HaveKeyWithValue(signing.ProvenanceKey, ContainSubstring(`"invocationId": "job-uid"`)))
+
+		signed, err = r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeFalse())
+
+		finish(created, batchv1.JobComplete)
+		signed, err = r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionSigned)).To(BeTrue())
+		Expect(lvBuild.Status.Signature).NotTo(BeNil())
+		Expect(lvBuild.Status.Signature.Image).To(Equal("registry.example.com/team/web@" + digest))
+		Expect(lvBuild.Status.Signature.PublicKey).To(Equal("k8s://default/cosign-key"))
+		Expect(lvBuild.Status.Signature.Attestations).To(ConsistOf(jcrsv1.ProvenanceAttestation))
+	})
+
+	It("doesn't retry failed signing Jobs", func() {
+		_, err := r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+		finish(signingJob(2), batchv1.JobFailed)
+
+		signed, err := r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeFalse())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSigned)).To(HaveField("Reason", jcrsv1.ReasonSigningFailed))
+		Expect(signingJob(2).Status.Conditions).To(HaveLen(1))
+	})
+
+	It("deletes the signing Jobs of earlier runs", func() {
+		_, err := r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+		finish(signingJob(2), batchv1.JobComplete)
+		_, err = r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		resetSigning(lvBuild)
+		Expect(lvBuild.Status.Signature).To(BeNil())
+		lvBuild.Status.PublishedDigest = "sha256:fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"
+		_, err = r.reconcileSigning(ctx, lvBuild, job, 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		var jobs batchv1.JobList
+		Expect(r.List(ctx, &jobs, client.MatchingLabels{signingLabel: "web"})).To(Succeed())
+		Expect(jobs.Items).To(ConsistOf(HaveField("Name", signingJobName(lvBuild, 3))))
+	})
+
+	It("fails the signing of builds that didn't report a digest", func() {
+		lvBuild.Status.PublishedDigest = ""
+		signed, err := r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeFalse())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSigned)).To(HaveField("Reason", jcrsv1.ReasonSigningFailed))
+	})
+
+	It("doesn't hold builds when signing is disabled in the controller", func() {
+		r.Signing = signing.Config{}
+		signed, err := r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeTrue())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSigned)).To(HaveField("Reason", jcrsv1.ReasonFeatureDisabled))
+	})
+
+	It("doesn't sign the artifacts of builds without spec.signing", func() {
+		lvBuild.Spec.Signing = nil
+		signed, err := r.reconcileSigning(ctx, lvBuild, job, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeTrue())
+		Expect(meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSigned)).To(BeNil())
+	})
+})
@@ -0,0 +1,598 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Planning capacity asks how long a set of builds would queue before they run, and
+how much of the quotas of their namespaces they would take at their peak. The
+simulation answers from the current state of the cluster, through the gates a
+build goes through before its pods run:
+
+  - its creation is rejected once its namespace holds its maximum number of builds
+  - suspended builds never start
+  - no run starts while a MaintenanceWindow of its namespace is open, unless the
+    build has the maintenance override annotation
+  - builds sharing a mutexKey run one at a time, first come first served, after
+    the holder of the Lease and the builds already waiting for it
+  - the pods of a run are only created once their requests fit in the
+    ResourceQuotas of the namespace; the run holds its mutexKey meanwhile.
+
+The simulated builds are submitted together at the start of the simulation, in
+order. Their runs are expected to take the average duration of the recent runs
+of the build, of the build of the same name in the cluster, or else of the
+latest build of the same package, or else a default. The runs going on in the
+cluster are expected to take as long, and those past that to finish right away.
+
+The pods of a run are those of the Job the controller constructs for the build,
+without its parameters, which don't change what they request. ResourceQuotas are
+checked on requests and pod counts only, whatever their scopes, and nodes aren't
+simulated: admitted pods are expected to be scheduled.
+*/
+
+// +kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch
+
+// SimulationOptions configure the simulation of builds.
+type SimulationOptions struct {
+	// Now is the time the builds are submitted at
+	Now time.Time
+	// DefaultDuration is the expected duration of the runs of builds that haven't run yet
+	DefaultDuration time.Duration
+	// MaxBuilds returns the maximum number of builds of a namespace, 0 when
+	// unlimited. The builds of namespaces aren't capped when nil.
+	MaxBuilds func(ctx context.Context, namespace string) (int, error)
+}
+
+// SimulatedBuild is the expected schedule of a simulated build.
+type SimulatedBuild struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// NeverStarts is why the build is expected never to start, if it isn't
+	NeverStarts string `json:"neverStarts,omitempty"`
+	// Start and Finish are when its run is expected to start and finish
+	Start  time.Time `json:"start,omitzero"`
+	Finish time.Time `json:"finish,omitzero"`
+	// QueueTime is how long the build is expected to wait for its run to start
+	QueueTime time.Duration `json:"queueTime"`
+	// WaitedFor are the gates the build is expected to wait for, in order
+	WaitedFor []string `json:"waitedFor,omitempty"`
+	// Requests are the resources requested by the pods of its run
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+}
+
+// ResourcePeak is the peak of a resource requested by the runs of a namespace.
+type ResourcePeak struct {
+	Namespace string              `json:"namespace"`
+	Resource  corev1.ResourceName `json:"resource"`
+	// Peak is the most of the resource the runs request at once, first reached at At
+	Peak resource.Quantity `json:"peak"`
+	At   time.Time         `json:"at"`
+	// Hard is the lowest limit of the ResourceQuotas of the namespace on the resource, if any
+	Hard *resource.Quantity `json:"hard,omitempty"`
+}
+
+// Simulation is the outcome of the simulation of builds.
+type Simulation struct {
+	// Builds are the schedules of the simulated builds, in the order they were submitted
+	Builds []SimulatedBuild `json:"builds"`
+	// Peaks are the peaks of the resources requested in the namespaces of the
+	// simulated builds, by namespace and resource
+	Peaks []ResourcePeak `json:"peaks"`
+}
+
+// simulatedRun is a run of the simulation: the run of a simulated build, or of
+// a build of the cluster running or waiting for its mutexKey.
+type simulatedRun struct {
+	lvBuild  *jcrsv1.LeviathanBuild
+	requests corev1.ResourceList
+	duration time.Duration
+	// acquired is set once the run holds its mutexKey
+	acquired bool
+	finish   time.Time
+	// report is the schedule of a simulated build, nil for the builds of the cluster
+	report *SimulatedBuild
+	// blocked is the latest gate the run waited for
+	blocked string
+}
+
+// quotaRoom is the room left by a ResourceQuota for the requests of pods.
+type quotaRoom struct {
+	name string
+	free corev1.ResourceList
+	hard corev1.ResourceList
+}
+
+// simulatedNamespace is the state of a namespace during the simulation.
+type simulatedNamespace struct {
+	builds map[string]*jcrsv1.LeviathanBuild
+	count  int
+	limit  int
+	quotas []quotaRoom
+	// inUse are the resources requested by the running runs
+	inUse corev1.ResourceList
+	peaks map[corev1.ResourceName]*ResourcePeak
+}
+
+// simulator simulates the scheduling of builds.
+type simulator struct {
+	r          *LeviathanBuildReconciler
+	opts       SimulationOptions
+	windows    []jcrsv1.MaintenanceWindow
+	namespaces map[string]*simulatedNamespace
+	pending    []*simulatedRun
+	running    []*simulatedRun
+}
+
+// Simulate simulates the scheduling of builds, submitted at opts.Now, against
+// the builds, quotas, Leases and MaintenanceWindows of the cluster, which it
+// leaves untouched.
+func (r *LeviathanBuildReconciler) Simulate(ctx context.Context, builds []jcrsv1.LeviathanBuild, opts SimulationOptions) (*Simulation, error) {
+	var windows jcrsv1.MaintenanceWindowList
+	if err := r.List(ctx, &windows); err != nil {
+		return nil, err
+	}
+	s := &simulator{r: r, opts: opts, windows: windows.Items, namespaces: make(map[string]*simulatedNamespace)}
+	simulation := &Simulation{Builds: make([]SimulatedBuild, len(builds))}
+	for i := range builds {
+		simulation.Builds[i] = SimulatedBuild{Namespace: builds[i].Namespace, Name: builds[i].Name}
+		if err := s.submit(ctx, &builds[i], &simulation.Builds[i]); err != nil {
+			return nil, err
+		}
+	}
+	s.run()
+
+	for _, namespace := range slices.Sorted(maps.Keys(s.namespaces)) {
+		ns := s.namespaces[namespace]
+		for _, name := range slices.Sorted(maps.Keys(ns.peaks)) {
+			peak := ns.peaks[name]
+			peak.Namespace = namespace
+			for _, quota := range ns.quotas {
+				if hard, ok := quota.hard[name]; ok && (peak.Hard == nil || hard.Cmp(*peak.Hard) < 0) {
+					peak.Hard = &hard
+				}
+			}
+			simulation.Peaks = append(simulation.Peaks, *peak)
+		}
+	}
+	return simulation, nil
+}
+
+// submit submits lvBuild to the simulation, reporting its schedule in report.
+func (s *simulator) submit(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, report *SimulatedBuild) error {
+	ns, err := s.namespace(ctx, lvBuild.Namespace)
+	if err != nil {
+		return err
+	}
+	existing := ns.builds[lvBuild.Name]
+	if existing == nil {
+		if ns.limit > 0 && ns.count >= ns.limit {
+			report.NeverStarts = fmt.Sprintf("rejected, namespace %s would already hold %d LeviathanBuilds, its quota is %d",
+				lvBuild.Namespace, ns.count, ns.limit)
+			return nil
+		}
+		ns.count++
+	}
+	if lvBuild.Spec.Suspend {
+		report.NeverStarts = "the build is suspended"
+		return nil
+	}
+	requests, err := s.r.runRequests(lvBuild)
+	if err != nil {
+		report.NeverStarts = "invalid jobTemplate: " + err.Error()
+		return nil
+	}
+	report.Requests = requests
+
+	duration, ok := expectedDuration(lvBuild)
+	if !ok && existing != nil {
+		duration, ok = expectedDuration(existing)
+	}
+	if !ok {
+		duration, ok = ns.packageDuration(lvBuild)
+	}
+	if !ok {
+		duration = s.opts.DefaultDuration
+	}
+	s.pending = append(s.pending, &simulatedRun{lvBuild: lvBuild, requests: requests, duration: duration, report: report})
+	return nil
+}
+
+// namespace returns the state of namespace, reading it from the cluster the
+// first time. The runs of its builds join the simulation.
+func (s *simulator) namespace(ctx context.Context, namespace string) (*simulatedNamespace, error) {
+	if ns, ok := s.namespaces[namespace]; ok {
+		return ns, nil
+	}
+	ns := &simulatedNamespace{
+		builds: make(map[string]*jcrsv1.LeviathanBuild),
+		inUse:  corev1.ResourceList{},
+		peaks:  make(map[corev1.ResourceName]*ResourcePeak),
+	}
+	s.namespaces[namespace] = ns
+
+	if s.opts.MaxBuilds != nil {
+		limit, err := s.opts.MaxBuilds(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		ns.limit = limit
+	}
+
+	var quotas corev1.ResourceQuotaList
+	if err := s.r.List(ctx, &quotas, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for _, quota := range quotas.Items {
+		room := quotaRoom{name: quota.Name, free: corev1.ResourceList{}, hard: corev1.ResourceList{}}
+		for name, hard := range quota.Spec.Hard {
+			requested, ok := quotaResource(name)
+			if !ok {
+				continue
+			}
+			free := hard.DeepCopy()
+			free.Sub(quota.Status.Used[name])
+			if previous, ok := room.hard[requested]; !ok || hard.Cmp(previous) < 0 {
+				room.hard[requested] = hard.DeepCopy()
+			}
+			if previous, ok := room.free[requested]; !ok || free.Cmp(previous) < 0 {
+				room.free[requested] = free
+			}
+		}
+		ns.quotas = append(ns.quotas, room)
+	}
+
+	var builds jcrsv1.LeviathanBuildList
+	if err := s.r.List(ctx, &builds, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	ns.count = len(builds.Items)
+	var waiting []*simulatedRun
+	queues := make(map[string][]string)
+	for i := range builds.Items {
+		lvBuild := &builds.Items[i]
+		ns.builds[lvBuild.Name] = lvBuild
+		succeeded := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded)
+		runningNow := succeeded != nil && succeeded.Status == metav1.ConditionUnknown && succeeded.Reason == jcrsv1.ReasonRunning
+		waitingNow := meta.IsStatusConditionTrue(lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForMutex) && lvBuild.Spec.MutexKey != nil
+		if !runningNow && !waitingNow {
+			continue
+		}
+		duration, ok := expectedDuration(lvBuild)
+		if !ok {
+			duration = s.opts.DefaultDuration
+		}
+		// The requests of the pods already running are counted by the quotas
+		requests, _ := s.r.runRequests(lvBuild)
+		run := &simulatedRun{lvBuild: lvBuild, requests: requests, duration: duration, acquired: runningNow}
+		if runningNow {
+			run.finish = succeeded.LastTransitionTime.Add(duration)
+			if run.finish.Before(s.opts.Now) {
+				run.finish = s.opts.Now
+			}
+			addResources(ns.inUse, requests)
+			s.running = append(s.running, run)
+			continue
+		}
+		key := *lvBuild.Spec.MutexKey
+		if _, ok := queues[key]; !ok {
+			queue, err := s.r.simulatedMutexQueue(ctx, namespace, key)
+			if err != nil {
+				return nil, err
+			}
+			queues[key] = queue
+		}
+		waiting = append(waiting, run)
+	}
+	// The builds waiting for a mutexKey are queued in the order of its Lease
+	slices.SortStableFunc(waiting, func(a, b *simulatedRun) int {
+		queue := queues[*a.lvBuild.Spec.MutexKey]
+		return queuePosition(queue, a.lvBuild.Name) - queuePosition(queue, b.lvBuild.Name)
+	})
+	s.pending = append(s.pending, waiting...)
+	ns.recordPeaks(s.opts.Now)
+	return ns, nil
+}
+
+// run runs the simulation until every pending run started, or none can.
+func (s *simulator) run() {
+	now := s.opts.Now
+	for {
+		running := s.running[:0]
+		for _, run := range s.running {
+			if run.finish.After(now) {
+				running = append(running, run)
+				continue
+			}
+			ns := s.namespaces[run.lvBuild.Namespace]
+			subtractResources(ns.inUse, run.requests)
+			for _, quota := range ns.quotas {
+				quota.release(run.requests)
+			}
+		}
+		s.running = running
+
+		var next time.Time
+		earliest := func(t time.Time) {
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+		// Builds waiting for a mutexKey are handed it first come, first served
+		waitingForMutex := make(map[string]bool)
+		pending := s.pending[:0]
+		for _, run := range s.pending {
+			gate, until := s.gate(run, now, waitingForMutex)
+			if gate == "" {
+				s.start(run, now)
+				continue
+			}
+			run.blocked = gate
+			if run.report != nil && !slices.Contains(run.report.WaitedFor, gate) {
+				run.report.WaitedFor = append(run.report.WaitedFor, gate)
+			}
+			earliest(until)
+			pending = append(pending, run)
+		}
+		s.pending = pending
+		for _, ns := range s.namespaces {
+			ns.recordPeaks(now)
+		}
+		if len(s.pending) == 0 {
+			return
+		}
+
+		for _, run := range s.running {
+			// Runs expected to take no time finish before the pending runs are reconsidered
+			if !run.finish.After(now) {
+				next = now
+				break
+			}
+			earliest(run.finish)
+		}
+		if next.IsZero() {
+			for _, run := range s.pending {
+				if run.report != nil {
+					run.report.NeverStarts = "waits for " + run.blocked + " forever"
+				}
+			}
+			return
+		}
+		now = next
+	}
+}
+
+// gate returns the gate holding back run at now, and when it opens if known,
+// or "" when the pods of run can start. waitingForMutex holds the mutexKeys
+// the runs before run wait for.
+func (s *simulator) gate(run *simulatedRun, now time.Time, waitingForMutex map[string]bool) (string, time.Time) {
+	lvBuild := run.lvBuild
+	ns := s.namespaces[lvBuild.Namespace]
+	if !run.acquired && lvBuild.Annotations[maintenanceOverrideAnnotation] != "true" {
+		var gate string
+		var until time.Time
+		for i := range s.windows {
+			window := &s.windows[i]
+			if !maintenanceWindowAppliesTo(window, lvBuild.Namespace) {
+				continue
+			}
+			end, open, err := maintenanceWindowEnd(window, now)
+			if err == nil && open && end.After(until) {
+				gate, until = "MaintenanceWindow "+window.Name, end
+			}
+		}
+		if gate != "" {
+			return gate, until
+		}
+	}
+
+	if key := lvBuild.Spec.MutexKey; key != nil && !run.acquired {
+		gate := "mutexKey " + *key
+		queueKey := lvBuild.Namespace + "/" + *key
+		if waitingForMutex[queueKey] || s.mutexHeld(lvBuild.Namespace, *key) {
+			waitingForMutex[queueKey] = true
+			return gate, time.Time{}
+		}
+		run.acquired = true
+	}
+
+	for _, quota := range ns.quotas {
+		for name, free := range quota.free {
+			if requested, ok := run.requests[name]; ok && requested.Cmp(free) > 0 {
+				return "ResourceQuota " + quota.name, time.Time{}
+			}
+		}
+	}
+	return "", time.Time{}
+}
+
+// mutexHeld reports whether a run of namespace holds key.
+func (s *simulator) mutexHeld(namespace, key string) bool {
+	for _, runs := range [][]*simulatedRun{s.running, s.pending} {
+		for _, run := range runs {
+			if run.acquired && run.lvBuild.Namespace == namespace && run.lvBuild.Spec.MutexKey != nil && *run.lvBuild.Spec.MutexKey == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// start starts run at now.
+func (s *simulator) start(run *simulatedRun, now time.Time) {
+	ns := s.namespaces[run.lvBuild.Namespace]
+	run.finish = now.Add(run.duration)
+	addResources(ns.inUse, run.requests)
+	for _, quota := range ns.quotas {
+		quota.take(run.requests)
+	}
+	s.running = append(s.running, run)
+	if run.report != nil {
+		run.report.Start, run.report.Finish = now, run.finish
+		run.report.QueueTime = now.Sub(s.opts.Now)
+	}
+}
+
+// take takes the room of requests.
+func (q quotaRoom) take(requests corev1.ResourceList) {
+	for name, free := range q.free {
+		free.Sub(requests[name])
+		q.free[name] = free
+	}
+}
+
+// release gives back the room of requests.
+func (q quotaRoom) release(requests corev1.ResourceList) {
+	for name, free := range q.free {
+		free.Add(requests[name])
+		q.free[name] = free
+	}
+}
+
+// recordPeaks records the resources requested at now where they peak.
+func (ns *simulatedNamespace) recordPeaks(now time.Time) {
+	for name, quantity := range ns.inUse {
+		if peak, ok := ns.peaks[name]; !ok || quantity.Cmp(peak.Peak) > 0 {
+			ns.peaks[name] = &ResourcePeak{Resource: name, Peak: quantity.DeepCopy(), At: now}
+		}
+	}
+}
+
+// packageDuration returns the expected duration of the runs of the latest
+// build of the package of lvBuild that ran.
+func (ns *simulatedNamespace) packageDuration(lvBuild *jcrsv1.LeviathanBuild) (time.Duration, bool) {
+	if lvBuild.Spec.PackageName == nil {
+		return 0, false
+	}
+	var latest *jcrsv1.LeviathanBuild
+	var duration time.Duration
+	for _, other := range ns.builds {
+		if other.Spec.PackageName == nil || *other.Spec.PackageName != *lvBuild.Spec.PackageName {
+			continue
+		}
+		if d, ok := expectedDuration(other); ok && (latest == nil || latest.CreationTimestamp.Before(&other.CreationTimestamp) ||
+			(latest.CreationTimestamp.Equal(&other.CreationTimestamp) && other.Name > latest.Name)) {
+			latest, duration = other, d
+		}
+	}
+	return duration, latest != nil
+}
+
+// simulatedMutexQueue returns the builds waiting for key in namespace, in order.
+func (r *LeviathanBuildReconciler) simulatedMutexQueue(ctx context.Context, namespace, key string) ([]string, error) {
+	lease := &coordinationv1.Lease{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: mutexLeaseName(key)}, lease); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return mutexQueue(lease), nil
+}
+
+// runRequests returns the resources requested by the pods of a run of lvBuild,
+// with their number as the pods resource.
+func (r *LeviathanBuildReconciler) runRequests(lvBuild *jcrsv1.LeviathanBuild) (corev1.ResourceList, error) {
+	job, err := r.constructJob(lvBuild, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	pods := int32(1)
+	if job.Spec.Parallelism != nil {
+		pods = *job.Spec.Parallelism
+	}
+	requests := corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(int64(pods), resource.DecimalSI)}
+	for range pods {
+		addResources(requests, podRequests(&job.Spec.Template.Spec))
+	}
+	return requests, nil
+}
+
+// expectedDuration returns the average duration of the recent runs of lvBuild.
+func expectedDuration(lvBuild *jcrsv1.LeviathanBuild) (time.Duration, bool) {
+	var total time.Duration
+	var runs int
+	for _, run := range lvBuild.Status.RecentRuns {
+		if run.Duration.Duration > 0 {
+			total += run.Duration.Duration
+			runs++
+		}
+	}
+	if runs == 0 {
+		return 0, false
+	}
+	return total / time.Duration(runs), true
+}
+
+// quotaResource returns the resource requested by pods that the ResourceQuota
+// limit name caps, if any.
+func quotaResource(name corev1.ResourceName) (corev1.ResourceName, bool) {
+	switch name {
+	case corev1.ResourcePods, "count/pods":
+		return corev1.ResourcePods, true
+	case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+		return name, true
+	}
+	if requested, ok := strings.CutPrefix(string(name), corev1.DefaultResourceRequestsPrefix); ok {
+		return corev1.ResourceName(requested), true
+	}
+	return "", false
+}
+
+// queuePosition returns the position of name in queue, after the end of queue
+// when it isn't in it.
+func queuePosition(queue []string, name string) int {
+	if i := slices.Index(queue, name); i >= 0 {
+		return i
+	}
+	return len(queue)
+}
+
+// addResources adds resources to list.
+func addResources(list, resources corev1.ResourceList) {
+	for name, quantity := range resources {
+		total := list[name]
+		total.Add(quantity)
+		list[name] = total
+	}
+}
+
+// subtractResources subtracts resources from list.
+func subtractResources(list, resources corev1.ResourceList) {
+	for name, quantity := range resources {
+		total := list[name]
+		total.Sub(quantity)
+		list[name] = total
+	}
+}
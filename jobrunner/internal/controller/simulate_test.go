@@ -0,0 +1,155 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Scheduling simulation", func() {
+	var (
+		ctx  context.Context
+		now  time.Time
+		opts SimulationOptions
+	)
+
+	// build returns a build of pkg whose pods request cpu, with recent runs
+	// taking durations
+	build := func(name, pkg, cpu string, durations ...time.Duration) *jcrsv1.LeviathanBuild {
+		lvBuild := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To(pkg)},
+		}
+		container := corev1.Container{Name: "build", Image: "builder"}
+		if cpu != "" {
+			container.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+		}
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{container}
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		for i, duration := range durations {
+			lvBuild.Status.RecentRuns = append(lvBuild.Status.RecentRuns, jcrsv1.RecentRun{
+				RunIndex: int64(i + 1), Duration: metav1.Duration{Duration: duration}, Result: jcrsv1.RunSucceeded,
+			})
+		}
+		return lvBuild
+	}
+	// reconciler returns a LeviathanBuildReconciler reading objs
+	reconciler := func(objs ...client.Object) *LeviathanBuildReconciler {
+		c := newFakeClient(objs...)
+		return &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+		opts = SimulationOptions{Now: now, DefaultDuration: 30 * time.Minute}
+	})
+
+	It("queues builds behind their quotas and mutexKeys", func() {
+		deploy := build("deploy", "deploy", "2", 20*time.Minute)
+		deploy.Status.Conditions = []metav1.Condition{{
+			Type: jcrsv1.ConditionSucceeded, Status: metav1.ConditionUnknown, Reason: jcrsv1.ReasonRunning,
+			LastTransitionTime: metav1.NewTime(now.Add(-5 * time.Minute)),
+		}}
+		quota := &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "builds", Namespace: "team"},
+			Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("4"),
+				corev1.ResourcePods:        resource.MustParse("10"),
+			}},
+			Status: corev1.ResourceQuotaStatus{Used: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("2"),
+				corev1.ResourcePods:        resource.MustParse("1"),
+			}},
+		}
+		r := reconciler(deploy, quota, build("web", "web", "", 4*time.Minute, 6*time.Minute))
+		opts.MaxBuilds = func(context.Context, string) (int, error) { return 8, nil }
+
+		release := func(lvBuild *jcrsv1.LeviathanBuild) jcrsv1.LeviathanBuild {
+			lvBuild.Spec.MutexKey = ptr.To("release")
+			return *lvBuild
+		}
+		suspended := build("suspended", "docs", "")
+		suspended.Spec.Suspend = true
+		simulation, err := r.Simulate(ctx, []jcrsv1.LeviathanBuild{
+			*build("a", "a", "2", 10*time.Minute),
+			*build("b", "b", "2"),
+			release(build("c", "web", "")),
+			release(build("d", "d", "")),
+			*suspended,
+			*build("huge", "huge", "8"),
+			*build("rejected", "rejected", ""),
+		}, opts)
+		Expect(err).NotTo(HaveOccurred())
+
+		schedule := map[string]SimulatedBuild{}
+		for _, simulated := range simulation.Builds {
+			schedule[simulated.Name] = simulated
+		}
+		Expect(schedule["a"].QueueTime).To(BeZero())
+		Expect(schedule["a"].Finish).To(Equal(now.Add(10 * time.Minute)))
+
+		By("waiting for the room of the ResourceQuota")
+		Expect(schedule["b"].QueueTime).To(Equal(10 * time.Minute))
+		Expect(schedule["b"].WaitedFor).To(Equal([]string{"ResourceQuota builds"}))
+		Expect(schedule["b"].Finish).To(Equal(now.Add(40 * time.Minute)))
+
+		By("running builds sharing a mutexKey one at a time, as long as the builds of their package")
+		Expect(schedule["c"].Finish).To(Equal(now.Add(5 * time.Minute)))
+		Expect(schedule["d"].QueueTime).To(Equal(5 * time.Minute))
+		Expect(schedule["d"].WaitedFor).To(Equal([]string{"mutexKey release"}))
+
+		By("reporting the builds that never start")
+		Expect(schedule["suspended"].NeverStarts).To(Equal("the build is suspended"))
+		Expect(schedule["huge"].NeverStarts).To(Equal("waits for ResourceQuota builds forever"))
+		Expect(schedule["rejected"].NeverStarts).To(ContainSubstring("its quota is 8"))
+
+		By("reporting the peaks of the resources requested")
+		peaks := map[corev1.ResourceName]string{}
+		for _, peak := range simulation.Peaks {
+			Expect(peak.Namespace).To(Equal("team"))
+			Expect(peak.At).To(Equal(now))
+			peaks[peak.Resource] = peak.Peak.String() + "/" + peak.Hard.String()
+		}
+		Expect(peaks).To(Equal(map[corev1.ResourceName]string{corev1.ResourceCPU: "4/4", corev1.ResourcePods: "3/10"}))
+	})
+
+	It("holds builds back while a MaintenanceWindow is open", func() {
+		window := &jcrsv1.MaintenanceWindow{
+			ObjectMeta: metav1.ObjectMeta{Name: "upgrade"},
+			Spec:       jcrsv1.MaintenanceWindowSpec{Schedule: "0 12 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+		}
+		urgent := build("urgent", "urgent", "")
+		urgent.Annotations = map[string]string{maintenanceOverrideAnnotation: "true"}
+		simulation, err := reconciler(window).Simulate(ctx, []jcrsv1.LeviathanBuild{*build("web", "web", ""), *urgent}, opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(simulation.Builds[0].QueueTime).To(Equal(time.Hour))
+		Expect(simulation.Builds[0].WaitedFor).To(Equal([]string{"MaintenanceWindow upgrade"}))
+		Expect(simulation.Builds[1].QueueTime).To(BeZero())
+	})
+})
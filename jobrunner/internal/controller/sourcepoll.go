@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/sourcepoll"
+)
+
+const (
+	// polledRevisionAnnotation records the polled revision of the source on the
+	// pod template of the Job, so that a new revision makes the Job outdated
+	polledRevisionAnnotation = "jcrs.jcrs.dev/polled-revision"
+
+	// sourcePollFailedReason is the reason of the Event recorded when the source
+	// of a build can't be polled
+	sourcePollFailedReason = "SourcePollFailed"
+)
+
+/*
+Builds with a pollInterval watch their source through the Poller, which checks
+each remote once for all the builds polling it and sends the builds an event
+when its revision changes. The revision is recorded in status.polledRevision
+and stamped on the pod template of the Job: a new revision makes the Job
+outdated, and it's replaced like after a change to the spec.
+
+A source that can't be checked doesn't hold the build back, it keeps the last
+revision found. The revision is kept when polling stops too, so that turning
+polling off doesn't run the build again.
+*/
+
+// reconcilePolledRevision watches the source of lvBuild, or stops watching it,
+// and records the latest revision found in the status of lvBuild.
+func (r *LeviathanBuildReconciler) reconcilePolledRevision(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) {
+	if r.Poller == nil {
+		return
+	}
+	key := client.ObjectKeyFromObject(lvBuild)
+	if lvBuild.Spec.PollInterval == nil || lvBuild.Spec.SourceURL == nil {
+		r.Poller.Forget(key)
+		return
+	}
+
+	log := logf.FromContext(ctx)
+	remote, err := r.pollRemote(ctx, lvBuild)
+	if err == nil {
+		var revision string
+		revision, err = r.Poller.Watch(ctx, key, remote, lvBuild.Spec.PollInterval.Duration)
+		if revision != "" {
+			lvBuild.Status.PolledRevision = revision
+		}
+	}
+	if err != nil {
+		log.Error(err, "Failed to poll source", "sourceURL", *lvBuild.Spec.SourceURL)
+		r.event(lvBuild, corev1.EventTypeWarning, sourcePollFailedReason, "Failed to poll %s: %v", *lvBuild.Spec.SourceURL, err)
+	}
+}
+
+// pollRemote returns the remote polled for the source of lvBuild. The source
+// of an HTTP build is polled with the credentials of its secretRef.
+func (r *LeviathanBuildReconciler) pollRemote(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) (sourcepoll.Remote, error) {
+	remote := sourcepoll.Remote{Type: lvBuild.Spec.SourceType, URL: *lvBuild.Spec.SourceURL}
+	if lvBuild.Spec.SourceType != jcrsv1.HTTPSource || lvBuild.Spec.Source == nil ||
+		lvBuild.Spec.Source.HTTP == nil || lvBuild.Spec.Source.HTTP.SecretRef == nil {
+		return remote, nil
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Namespace: lvBuild.Namespace, Name: lvBuild.Spec.Source.HTTP.SecretRef.Name}, &secret); err != nil {
+		return remote, err
+	}
+	// The same keys as the fetch init container, see addFetchInitContainer
+	switch {
+	case len(secret.Data["token"]) > 0:
+		remote.Authorization = "Bearer " + string(secret.Data["token"])
+	case len(secret.Data["username"]) > 0:
+		credentials := string(secret.Data["username"]) + ":" + string(secret.Data["password"])
+		remote.Authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	return remote, nil
+}
+
+// addPolledRevision stamps the polled revision of the source of lvBuild on the
+// pod template of job.
+func addPolledRevision(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if lvBuild.Status.PolledRevision == "" {
+		return
+	}
+	job.Spec.Template.Annotations[polledRevisionAnnotation] = lvBuild.Status.PolledRevision
+}
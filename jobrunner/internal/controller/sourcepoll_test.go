@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/sourcepoll"
+	utiltesting "test.jcrs.dev/jobrunner/pkg/testing"
+)
+
+// recordingChecker returns revision, or err, and records the remotes it checks.
+type recordingChecker struct {
+	revision string
+	err      error
+	remotes  []sourcepoll.Remote
+}
+
+func (c *recordingChecker) Check(_ context.Context, remote sourcepoll.Remote, _ sourcepoll.Check) (sourcepoll.Check, error) {
+	c.remotes = append(c.remotes, remote)
+	return sourcepoll.Check{Revision: c.revision}, c.err
+}
+
+var _ = Describe("Source polling", func() {
+	var (
+		ctx      context.Context
+		checker  *recordingChecker
+		recorder *record.FakeRecorder
+		r        *LeviathanBuildReconciler
+		lvBuild  *jcrsv1.LeviathanBuild
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "download", Namespace: "polling"},
+			Data:       map[string][]byte{"username": []byte("ci"), "password": []byte("hunter2")},
+		}
+		checker = &recordingChecker{revision: `etag:"v1"`}
+		recorder = record.NewFakeRecorder(10)
+		c := newFakeClient(secret)
+		r = &LeviathanBuildReconciler{
+			Client:   c,
+			Scheme:   c.Scheme(),
+			Recorder: recorder,
+			Poller:   sourcepoll.NewPoller(map[jcrsv1.SourceType]sourcepoll.Checker{jcrsv1.HTTPSource: checker}),
+		}
+		lvBuild = utiltesting.MakeLeviathanBuild("web", "polling").SourceURL("https://example.com/web.tgz").Image("builder").Obj()
+		lvBuild.Spec.Source = &jcrsv1.SourceSpec{HTTP: &jcrsv1.HTTPSourceSpec{SecretRef: &corev1.LocalObjectReference{Name: "download"}}}
+		lvBuild.Spec.PollInterval = &metav1.Duration{Duration: 5 * time.Minute}
+	})
+
+	It("runs the build again when the polled revision changes", func() {
+		r.reconcilePolledRevision(ctx, lvBuild)
+		Expect(lvBuild.Status.PolledRevision).To(Equal(`etag:"v1"`))
+		Expect(checker.remotes).To(ConsistOf(sourcepoll.Remote{
+			Type: jcrsv1.HTTPSource, URL: "https://example.com/web.tgz", Authorization: "Basic Y2k6aHVudGVyMg==",
+		}))
+		job, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Spec.Template.Annotations).To(HaveKeyWithValue(polledRevisionAnnotation, `etag:"v1"`))
+
+		lvBuild.Status.PolledRevision = `etag:"v2"`
+		desired, err := r.constructJob(lvBuild, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diffJobSpecs(&job.Spec, &desired.Spec)).To(ConsistOf("template.annotations[jcrs.jcrs.dev/polled-revision]"))
+	})
+
+	It("keeps the last revision when the source can't be polled", func() {
+		lvBuild.Status.PolledRevision = `etag:"v1"`
+		checker.err = errors.New("connection refused")
+		r.reconcilePolledRevision(ctx, lvBuild)
+		Expect(lvBuild.Status.PolledRevision).To(Equal(`etag:"v1"`))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning SourcePollFailed Failed to poll https://example.com/web.tgz: connection refused")))
+
+		By("keeping it once polling stops")
+		lvBuild.Spec.PollInterval = nil
+		r.reconcilePolledRevision(ctx, lvBuild)
+		Expect(lvBuild.Status.PolledRevision).To(Equal(`etag:"v1"`))
+	})
+})
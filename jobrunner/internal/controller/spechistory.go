@@ -0,0 +1,64 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/spechistory"
+)
+
+/*
+The latest generations of the spec of every build are kept in a ConfigMap it
+controls, so that a change of its jobTemplate or parameters that breaks it can
+be rolled back with `leviathan rollback --to-generation`. The rollback applies
+the spec of the generation again, which makes a new generation of its own.
+
+Keeping the history is best effort: a build whose history can't be written, e.g.
+because a ConfigMap of the same name belongs to something else, still runs.
+*/
+
+// recordSpecHistory records the current generation of the spec of lvBuild in its history.
+func (r *LeviathanBuildReconciler) recordSpecHistory(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) error {
+	if r.SpecHistoryLimit <= 0 {
+		return nil
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      spechistory.ConfigMapName(lvBuild),
+			Namespace: lvBuild.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		if !cm.CreationTimestamp.IsZero() && !metav1.IsControlledBy(cm, lvBuild) {
+			return fmt.Errorf("ConfigMap %s already exists and doesn't belong to the build", cm.Name)
+		}
+		if _, err := spechistory.Record(cm, lvBuild, r.SpecHistoryLimit, time.Now()); err != nil {
+			return err
+		}
+		return ctrl.SetControllerReference(lvBuild, cm, r.Scheme)
+	})
+	return err
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/spechistory"
+)
+
+var _ = Describe("Spec history", func() {
+	var (
+		ctx     context.Context
+		c       client.Client
+		r       *LeviathanBuildReconciler
+		lvBuild *jcrsv1.LeviathanBuild
+	)
+
+	history := func() *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "nightly-spec-history"}, cm)).To(Succeed())
+		return cm
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = newFakeClient()
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme(), SpecHistoryLimit: 2}
+
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nightly", UID: "nightly-uid", Generation: 1},
+			Spec:       jcrsv1.LeviathanBuildSpec{MutexKey: ptr.To("v1")},
+		}
+	})
+
+	It("keeps the latest generations in a ConfigMap controlled by the build", func() {
+		for generation, mutexKey := range []string{"v1", "v2", "v3"} {
+			lvBuild.Generation = int64(generation + 1)
+			lvBuild.Spec.MutexKey = ptr.To(mutexKey)
+			Expect(r.recordSpecHistory(ctx, lvBuild)).To(Succeed())
+		}
+
+		cm := history()
+		Expect(metav1.IsControlledBy(cm, lvBuild)).To(BeTrue())
+		snapshots, err := spechistory.Snapshots(cm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots).To(HaveLen(2))
+		Expect(snapshots[0].Generation).To(BeEquivalentTo(3))
+		Expect(snapshots[1].Spec.MutexKey).To(Equal(ptr.To("v2")))
+	})
+
+	It("leaves a ConfigMap of another owner alone", func() {
+		Expect(c.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nightly-spec-history", CreationTimestamp: metav1.Now()},
+			Data:       map[string]string{"owner": "someone else"},
+		})).To(Succeed())
+		Expect(r.recordSpecHistory(ctx, lvBuild)).To(MatchError(ContainSubstring("doesn't belong to the build")))
+		Expect(history().Data).To(Equal(map[string]string{"owner": "someone else"}))
+	})
+
+	It("doesn't keep the specs without a limit", func() {
+		r.SpecHistoryLimit = 0
+		Expect(r.recordSpecHistory(ctx, lvBuild)).To(Succeed())
+		var cms corev1.ConfigMapList
+		Expect(c.List(ctx, &cms)).To(Succeed())
+		Expect(cms.Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,208 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Spot nodes are identified by a node label, and may carry a taint keeping other
+workloads away; both depend on the cloud provider, so they're configured on the
+controller. The spotPolicy of a build is turned into node affinity on that label,
+and a toleration of the taint for builds that may run on spot nodes.
+
+When a spot node is reclaimed, its pods get the DisruptionTarget condition. For
+builds that may run on spot nodes, a pod failure policy fails the Job right away
+in that case, instead of letting the pod count against the backoffLimit, and the
+controller starts a new run of the build. The number of consecutive interrupted
+runs is bounded by the controller, so a build can't loop forever on a pool that
+keeps being reclaimed.
+*/
+
+const (
+	// spotInterruptedReason is the reason of the Event recorded when a run is interrupted
+	spotInterruptedReason = "SpotInterrupted"
+)
+
+// SpotConfig describes the spot nodes of the cluster.
+type SpotConfig struct {
+	// NodeLabelKey and NodeLabelValue select the spot nodes
+	NodeLabelKey   string
+	NodeLabelValue string
+
+	// Taint is the taint of the spot nodes that builds running on them tolerate, if any
+	Taint *corev1.Taint
+
+	// MaxRetries is the number of consecutive interrupted runs that are run again
+	MaxRetries int32
+}
+
+// ParseSpotConfig returns the SpotConfig of a node label, formatted as key=value,
+// and an optional taint, formatted as key[=value]:effect. Without a node label,
+// builds are scheduled regardless of their spotPolicy.
+func ParseSpotConfig(nodeLabel, taint string, maxRetries int32) (SpotConfig, error) {
+	config := SpotConfig{MaxRetries: maxRetries}
+	if nodeLabel == "" {
+		return config, nil
+	}
+	var ok bool
+	if config.NodeLabelKey, config.NodeLabelValue, ok = strings.Cut(nodeLabel, "="); !ok || config.NodeLabelKey == "" {
+		return SpotConfig{}, fmt.Errorf("invalid spot node label %q, expected key=value", nodeLabel)
+	}
+	if taint == "" {
+		return config, nil
+	}
+	keyValue, effect, ok := strings.Cut(taint, ":")
+	if !ok || effect == "" {
+		return SpotConfig{}, fmt.Errorf("invalid spot node taint %q, expected key[=value]:effect", taint)
+	}
+	key, value, _ := strings.Cut(keyValue, "=")
+	config.Taint = &corev1.Taint{Key: key, Value: value, Effect: corev1.TaintEffect(effect)}
+	return config, nil
+}
+
+// addSpotPolicy schedules the build Job according to the spotPolicy of lvBuild.
+func (r *LeviathanBuildReconciler) addSpotPolicy(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	policy := lvBuild.Spec.SpotPolicy
+	if policy == "" || r.Spot.NodeLabelKey == "" {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := podSpec.Affinity.NodeAffinity
+	spotNode := corev1.NodeSelectorRequirement{
+		Key:      r.Spot.NodeLabelKey,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{r.Spot.NodeLabelValue},
+	}
+
+	switch policy {
+	case jcrsv1.SpotAvoid:
+		spotNode.Operator = corev1.NodeSelectorOpNotIn
+		requireNodes(nodeAffinity, spotNode)
+		return
+	case jcrsv1.SpotRequire:
+		requireNodes(nodeAffinity, spotNode)
+	case jcrsv1.SpotPrefer:
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			corev1.PreferredSchedulingTerm{Weight: 100, Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{spotNode}}})
+	}
+
+	if taint := r.Spot.Taint; taint != nil {
+		toleration := corev1.Toleration{Key: taint.Key, Operator: corev1.TolerationOpEqual, Value: taint.Value, Effect: taint.Effect}
+		if taint.Value == "" {
+			toleration.Operator = corev1.TolerationOpExists
+		}
+		podSpec.Tolerations = append(podSpec.Tolerations, toleration)
+	}
+
+	// Pod failure policies are only supported for pods that aren't restarted in place
+	if podSpec.RestartPolicy == corev1.RestartPolicyNever {
+		if job.Spec.PodFailurePolicy == nil {
+			job.Spec.PodFailurePolicy = &batchv1.PodFailurePolicy{}
+		}
+		job.Spec.PodFailurePolicy.Rules = append([]batchv1.PodFailurePolicyRule{{
+			Action: batchv1.PodFailurePolicyActionFailJob,
+			OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{
+				{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue},
+			},
+		}}, job.Spec.PodFailurePolicy.Rules...)
+	}
+}
+
+// requireNodes adds requirement to the required node affinity. The terms of the
+// node affinity are ORed, so the requirement is added to every one of them.
+func requireNodes(nodeAffinity *corev1.NodeAffinity, requirement corev1.NodeSelectorRequirement) {
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	selector := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+	for i := range selector.NodeSelectorTerms {
+		term := &selector.NodeSelectorTerms[i]
+		term.MatchExpressions = append(term.MatchExpressions, requirement)
+	}
+}
+
+// spotInterrupted reports whether the failed job was interrupted by the
+// disruption of one of its pods.
+func (r *LeviathanBuildReconciler) spotInterrupted(ctx context.Context, job *batchv1.Job) (bool, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return false, err
+	}
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.DisruptionTarget && condition.Status == corev1.ConditionTrue {
+				return true, nil
+			}
+		}
+	}
+
+	// The pods of a reclaimed node may already have been garbage collected
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Reason == batchv1.JobReasonPodFailurePolicy &&
+			strings.Contains(condition.Message, string(corev1.DisruptionTarget)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// retrySpotInterruption reports whether job, the finished Job of the latest run of
+// lvBuild, was interrupted on a spot node and should be run again.
+func (r *LeviathanBuildReconciler) retrySpotInterruption(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job, finishedType batchv1.JobConditionType) (bool, error) {
+	policy := lvBuild.Spec.SpotPolicy
+	if policy != jcrsv1.SpotPrefer && policy != jcrsv1.SpotRequire {
+		return false, nil
+	}
+	interrupted := false
+	if finishedType == batchv1.JobFailed {
+		var err error
+		if interrupted, err = r.spotInterrupted(ctx, job); err != nil {
+			return false, err
+		}
+	}
+	if !interrupted {
+		lvBuild.Status.SpotInterruptions = 0
+		return false, nil
+	}
+	if lvBuild.Status.SpotInterruptions >= r.Spot.MaxRetries {
+		return false, nil
+	}
+
+	lvBuild.Status.SpotInterruptions++
+	r.event(lvBuild, corev1.EventTypeWarning, spotInterruptedReason,
+		"Job %s was interrupted on a spot node, running the build again (%d/%d)", job.Name, lvBuild.Status.SpotInterruptions, r.Spot.MaxRetries)
+	return true, nil
+}
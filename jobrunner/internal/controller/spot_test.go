@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Spot policy", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+		r       *LeviathanBuildReconciler
+	)
+
+	BeforeEach(func() {
+		spot, err := ParseSpotConfig("karpenter.sh/capacity-type=spot", "spot=true:NoSchedule", 2)
+		Expect(err).NotTo(HaveOccurred())
+		lvBuild = &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+		job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-1-abcde", Namespace: "default"}}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		r = &LeviathanBuildReconciler{Spot: spot}
+	})
+
+	It("parses the spot node configuration", func() {
+		Expect(r.Spot.NodeLabelKey).To(Equal("karpenter.sh/capacity-type"))
+		Expect(r.Spot.NodeLabelValue).To(Equal("spot"))
+		Expect(r.Spot.Taint).To(Equal(&corev1.Taint{Key: "spot", Value: "true", Effect: corev1.TaintEffectNoSchedule}))
+
+		_, err := ParseSpotConfig("spot", "", 1)
+		Expect(err).To(HaveOccurred())
+		_, err = ParseSpotConfig("spot=true", "spot", 1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("keeps builds avoiding spot nodes off them in every node selector term", func() {
+		lvBuild.Spec.SpotPolicy = jcrsv1.SpotAvoid
+		job.Spec.Template.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}}},
+				{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}}}},
+			}},
+		}}
+		r.addSpotPolicy(lvBuild, job)
+
+		for _, term := range job.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			Expect(term.MatchExpressions).To(HaveLen(2))
+			Expect(term.MatchExpressions[1].Operator).To(Equal(corev1.NodeSelectorOpNotIn))
+		}
+		Expect(job.Spec.Template.Spec.Tolerations).To(BeEmpty())
+		Expect(job.Spec.PodFailurePolicy).To(BeNil())
+	})
+
+	It("tolerates spot nodes and fails disrupted pods right away for builds preferring them", func() {
+		lvBuild.Spec.SpotPolicy = jcrsv1.SpotPrefer
+		r.addSpotPolicy(lvBuild, job)
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(BeNil())
+		Expect(podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+		Expect(podSpec.Tolerations).To(ConsistOf(corev1.Toleration{
+			Key: "spot", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule,
+		}))
+		Expect(job.Spec.PodFailurePolicy.Rules).To(HaveLen(1))
+		Expect(job.Spec.PodFailurePolicy.Rules[0].Action).To(Equal(batchv1.PodFailurePolicyActionFailJob))
+	})
+
+	It("runs interrupted builds again up to the retry limit", func() {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1-abcde-xyz", Namespace: "default", Labels: map[string]string{batchv1.JobNameLabel: job.Name}},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue},
+			}},
+		}
+		r.Client = newFakeClient(pod)
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		lvBuild.Spec.SpotPolicy = jcrsv1.SpotRequire
+
+		for range 2 {
+			Expect(r.retrySpotInterruption(context.Background(), lvBuild, job, batchv1.JobFailed)).To(BeTrue())
+		}
+		Expect(lvBuild.Status.SpotInterruptions).To(Equal(int32(2)))
+		Expect(recorder.Events).To(HaveLen(2))
+		Expect(<-recorder.Events).To(HavePrefix("Warning SpotInterrupted"))
+
+		By("giving up once the limit is reached")
+		Expect(r.retrySpotInterruption(context.Background(), lvBuild, job, batchv1.JobFailed)).To(BeFalse())
+
+		By("resetting the count once a run isn't interrupted")
+		Expect(r.retrySpotInterruption(context.Background(), lvBuild, job, batchv1.JobComplete)).To(BeFalse())
+		Expect(lvBuild.Status.SpotInterruptions).To(BeZero())
+	})
+})
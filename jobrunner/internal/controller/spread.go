@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The spreadPolicy of a build is turned into the topology spread constraints and
+pod anti-affinity of its pods. Both select pods by label, so the pods of a build
+with a spreadPolicy carry the build and package labels of its Jobs:
+
+  - topology spread constraints without a labelSelector select the pods of the
+    build, which spreads the shards of an Indexed Job;
+  - the package anti-affinity selects the pods of the package in the namespace,
+    those of the build included, so that the builds of a package don't share a
+    node (or zone) with each other, nor with their own shards.
+
+The scheduler only counts running pods, so the pods of the finished runs of a
+build don't keep its new run away.
+*/
+
+// addSpreadPolicy spreads the pods of the build Job according to the
+// spreadPolicy of lvBuild.
+func addSpreadPolicy(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	policy := lvBuild.Spec.SpreadPolicy
+	if policy == nil {
+		return
+	}
+	template := &job.Spec.Template
+	buildPods := map[string]string{buildLabel: labelValue(lvBuild.Name)}
+	packagePods := map[string]string{packageLabel: labelValue(ptr.Deref(lvBuild.Spec.PackageName, ""))}
+	template.Labels[buildLabel] = buildPods[buildLabel]
+	template.Labels[packageLabel] = packagePods[packageLabel]
+
+	for _, constraint := range policy.TopologySpreadConstraints {
+		constraint := *constraint.DeepCopy()
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = &metav1.LabelSelector{MatchLabels: buildPods}
+		}
+		template.Spec.TopologySpreadConstraints = append(template.Spec.TopologySpreadConstraints, constraint)
+	}
+
+	if policy.PackageAntiAffinity == "" || policy.PackageAntiAffinity == jcrsv1.NoPackageAntiAffinity {
+		return
+	}
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: packagePods},
+		TopologyKey:   cmp.Or(policy.TopologyKey, corev1.LabelHostname),
+	}
+	if template.Spec.Affinity == nil {
+		template.Spec.Affinity = &corev1.Affinity{}
+	}
+	if template.Spec.Affinity.PodAntiAffinity == nil {
+		template.Spec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	antiAffinity := template.Spec.Affinity.PodAntiAffinity
+	switch policy.PackageAntiAffinity {
+	case jcrsv1.PreferredPackageAntiAffinity:
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			corev1.WeightedPodAffinityTerm{Weight: 100, PodAffinityTerm: term})
+	case jcrsv1.RequiredPackageAntiAffinity:
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Spread policy", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		job     *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-shards"},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("@acme/web")},
+		}
+		job = &batchv1.Job{}
+		job.Spec.Template.Labels = map[string]string{}
+	})
+
+	It("leaves builds without a spreadPolicy as they are", func() {
+		addSpreadPolicy(lvBuild, job)
+		Expect(job.Spec.Template.Labels).To(BeEmpty())
+		Expect(job.Spec.Template.Spec.Affinity).To(BeNil())
+	})
+
+	It("spreads the pods of the build", func() {
+		zones := corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelTopologyZone,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+		}
+		shared := *zones.DeepCopy()
+		shared.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}}
+		lvBuild.Spec.SpreadPolicy = &jcrsv1.SpreadPolicy{TopologySpreadConstraints: []corev1.TopologySpreadConstraint{zones, shared}}
+
+		addSpreadPolicy(lvBuild, job)
+		Expect(job.Spec.Template.Labels).To(Equal(map[string]string{buildLabel: "web-shards", packageLabel: labelValue("@acme/web")}))
+		constraints := job.Spec.Template.Spec.TopologySpreadConstraints
+		Expect(constraints).To(HaveLen(2))
+		Expect(constraints[0].LabelSelector.MatchLabels).To(Equal(map[string]string{buildLabel: "web-shards"}))
+		Expect(constraints[1].LabelSelector.MatchLabels).To(Equal(map[string]string{"team": "web"}))
+		Expect(lvBuild.Spec.SpreadPolicy.TopologySpreadConstraints[0].LabelSelector).To(BeNil())
+		Expect(job.Spec.Template.Spec.Affinity).To(BeNil())
+	})
+
+	It("keeps the pods of builds of the same package apart", func() {
+		lvBuild.Spec.SpreadPolicy = &jcrsv1.SpreadPolicy{PackageAntiAffinity: jcrsv1.PreferredPackageAntiAffinity}
+		addSpreadPolicy(lvBuild, job)
+		preferred := job.Spec.Template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+		Expect(preferred).To(HaveLen(1))
+		Expect(preferred[0].PodAffinityTerm.TopologyKey).To(Equal(corev1.LabelHostname))
+		Expect(preferred[0].PodAffinityTerm.LabelSelector.MatchLabels).To(Equal(map[string]string{packageLabel: labelValue("@acme/web")}))
+
+		job = &batchv1.Job{}
+		job.Spec.Template.Labels = map[string]string{}
+		lvBuild.Spec.SpreadPolicy = &jcrsv1.SpreadPolicy{PackageAntiAffinity: jcrsv1.RequiredPackageAntiAffinity, TopologyKey: corev1.LabelTopologyZone}
+		addSpreadPolicy(lvBuild, job)
+		required := job.Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		Expect(required).To(HaveLen(1))
+		Expect(required[0].TopologyKey).To(Equal(corev1.LabelTopologyZone))
+	})
+})
@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The status of a build isn't only written at the end of its own reconcile: another
+reconcile of the same build may have run in the meantime on a stale cache, and
+other tools record their own conditions. A plain update of the status then fails
+with a conflict, and the whole reconcile is retried only to conflict again under
+load.
+
+Instead, on a conflict the latest LeviathanBuild is read straight from the API
+server and only the parts of the status this reconcile changed are applied onto it.
+Conditions are merged by type, so conditions written by others are kept.
+
+Most reconciles don't change anything, e.g. those of a running Job or those caused
+by the write of the previous reconcile. Writing the status anyway would bump the
+resourceVersion and wake every watcher of the build, so the status is only written
+when it differs from what was read, and then as a patch of the changed fields.
+*/
+
+// updateStatus writes the status of lvBuild, unless it is unchanged. observed is
+// the status as it was read at the start of the reconcile; on a conflict, the
+// changes made since are applied onto the status of the latest version of the
+// object. Only the status and resourceVersion of lvBuild are updated: the rest of
+// the reconcile keeps working on the spec it read.
+func (r *LeviathanBuildReconciler) updateStatus(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, observed *jcrsv1.LeviathanBuildStatus) error {
+	desired := lvBuild.Status.DeepCopy()
+	// current is the status the patch is computed against
+	current := observed
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if equality.Semantic.DeepEqual(*current, lvBuild.Status) {
+			statusWritesTotal.WithLabelValues("skipped").Inc()
+			return nil
+		}
+
+		// The patch carries the resourceVersion it was computed against, so it
+		// conflicts like an update would rather than overwriting newer conditions
+		base := lvBuild.DeepCopy()
+		base.Status = *current
+		patched := lvBuild.DeepCopy()
+		err := r.Status().Patch(ctx, patched, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+		if !apierrors.IsConflict(err) {
+			if err == nil {
+				statusWritesTotal.WithLabelValues("written").Inc()
+				lvBuild.Status = patched.Status
+				lvBuild.ResourceVersion = patched.ResourceVersion
+			}
+			return err
+		}
+		statusWritesTotal.WithLabelValues("conflict").Inc()
+
+		var latest jcrsv1.LeviathanBuild
+		if err := r.apiReader().Get(ctx, client.ObjectKeyFromObject(lvBuild), &latest); err != nil {
+			return err
+		}
+		current = latest.Status.DeepCopy()
+		mergeStatus(&latest.Status, observed, desired)
+		lvBuild.Status = latest.Status
+		lvBuild.ResourceVersion = latest.ResourceVersion
+		return err
+	})
+}
+
+// apiReader returns the reader used to get the latest version of an object.
+func (r *LeviathanBuildReconciler) apiReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// mergeStatus applies onto latest the fields and conditions that differ between
+// observed and desired. Fields are compared by reflection so that new status
+// fields are merged without being listed here.
+func mergeStatus(latest, observed, desired *jcrsv1.LeviathanBuildStatus) {
+	latestValue := reflect.ValueOf(latest).Elem()
+	observedValue := reflect.ValueOf(observed).Elem()
+	desiredValue := reflect.ValueOf(desired).Elem()
+	for i := range latestValue.NumField() {
+		if latestValue.Type().Field(i).Name == "Conditions" {
+			continue
+		}
+		if !equality.Semantic.DeepEqual(observedValue.Field(i).Interface(), desiredValue.Field(i).Interface()) {
+			latestValue.Field(i).Set(desiredValue.Field(i))
+		}
+	}
+
+	for _, condition := range desired.Conditions {
+		if previous := meta.FindStatusCondition(observed.Conditions, condition.Type); previous == nil || !equality.Semantic.DeepEqual(*previous, condition) {
+			meta.SetStatusCondition(&latest.Conditions, condition)
+		}
+	}
+	for _, condition := range observed.Conditions {
+		if meta.FindStatusCondition(desired.Conditions, condition.Type) == nil {
+			meta.RemoveStatusCondition(&latest.Conditions, condition.Type)
+		}
+	}
+}
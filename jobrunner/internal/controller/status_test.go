@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Status updates", func() {
+	var (
+		ctx context.Context
+		c   client.Client
+		r   *LeviathanBuildReconciler
+		key client.ObjectKey
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		lvBuild := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 1},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("web")},
+			Status: jcrsv1.LeviathanBuildStatus{
+				RunIndex: 1,
+				Conditions: []metav1.Condition{
+					{Type: jcrsv1.ConditionWaitingForMutex, Status: metav1.ConditionTrue, Reason: "Waiting", LastTransitionTime: metav1.Now()},
+				},
+			},
+		}
+		c = newFakeClient(lvBuild)
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme()}
+		key = client.ObjectKeyFromObject(lvBuild)
+	})
+
+	// read gets the build and the status a reconcile starting now would observe
+	read := func() (*jcrsv1.LeviathanBuild, *jcrsv1.LeviathanBuildStatus) {
+		var lvBuild jcrsv1.LeviathanBuild
+		Expect(c.Get(ctx, key, &lvBuild)).To(Succeed())
+		return &lvBuild, lvBuild.Status.DeepCopy()
+	}
+
+	It("keeps the status written by others since the build was read", func() {
+		lvBuild, observed := read()
+
+		By("recording a condition from another writer")
+		external, _ := read()
+		external.Status.SourceRevision = "abc123"
+		meta.SetStatusCondition(&external.Status.Conditions, metav1.Condition{Type: "Approved", Status: metav1.ConditionTrue, Reason: "Approved"})
+		Expect(c.Status().Update(ctx, external)).To(Succeed())
+
+		lvBuild.Status.RunIndex = 2
+		meta.RemoveStatusCondition(&lvBuild.Status.Conditions, jcrsv1.ConditionWaitingForMutex)
+		Expect(r.updateStatus(ctx, lvBuild, observed)).To(Succeed())
+
+		latest, _ := read()
+		Expect(latest.Status.RunIndex).To(Equal(int64(2)))
+		Expect(latest.Status.SourceRevision).To(Equal("abc123"))
+		Expect(meta.IsStatusConditionTrue(latest.Status.Conditions, "Approved")).To(BeTrue())
+		Expect(meta.FindStatusCondition(latest.Status.Conditions, jcrsv1.ConditionWaitingForMutex)).To(BeNil())
+		Expect(lvBuild.ResourceVersion).To(Equal(latest.ResourceVersion))
+	})
+
+	It("keeps the spec the reconcile read on a conflict", func() {
+		lvBuild, observed := read()
+
+		By("changing the spec after the build was read")
+		external, _ := read()
+		external.Spec.PackageName = ptr.To("api")
+		Expect(c.Update(ctx, external)).To(Succeed())
+
+		lvBuild.Status.RunIndex = 2
+		Expect(r.updateStatus(ctx, lvBuild, observed)).To(Succeed())
+
+		latest, _ := read()
+		Expect(latest.Status.RunIndex).To(Equal(int64(2)))
+		Expect(lvBuild.Spec.PackageName).To(Equal(ptr.To("web")))
+		Expect(lvBuild.ResourceVersion).To(Equal(latest.ResourceVersion))
+	})
+
+	It("merges concurrent reconciles of the same build", func() {
+		first, firstObserved := read()
+		second, secondObserved := read()
+
+		first.Status.PublishedDigest = "sha256:0123"
+		Expect(r.updateStatus(ctx, first, firstObserved)).To(Succeed())
+
+		meta.SetStatusCondition(&second.Status.Conditions, metav1.Condition{Type: jcrsv1.ConditionPatchTargetsApplied, Status: metav1.ConditionTrue, Reason: "Applied"})
+		Expect(r.updateStatus(ctx, second, secondObserved)).To(Succeed())
+
+		latest, _ := read()
+		Expect(latest.Status.PublishedDigest).To(Equal("sha256:0123"))
+		Expect(meta.IsStatusConditionTrue(latest.Status.Conditions, jcrsv1.ConditionPatchTargetsApplied)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(latest.Status.Conditions, jcrsv1.ConditionWaitingForMutex)).To(BeTrue())
+	})
+
+	It("doesn't write an unchanged status", func() {
+		lvBuild, observed := read()
+		resourceVersion := lvBuild.ResourceVersion
+		skipped := testutil.ToFloat64(statusWritesTotal.WithLabelValues("skipped"))
+
+		// Conditions set again to the same value keep their transition time
+		meta.SetStatusCondition(&lvBuild.Status.Conditions, metav1.Condition{Type: jcrsv1.ConditionWaitingForMutex, Status: metav1.ConditionTrue, Reason: "Waiting"})
+		Expect(r.updateStatus(ctx, lvBuild, observed)).To(Succeed())
+
+		latest, _ := read()
+		Expect(latest.ResourceVersion).To(Equal(resourceVersion))
+		Expect(testutil.ToFloat64(statusWritesTotal.WithLabelValues("skipped"))).To(Equal(skipped + 1))
+	})
+
+	It("patches only the changed fields", func() {
+		var patches []string
+		c = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+			SubResourcePatch: func(ctx context.Context, cl client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				data, err := patch.Data(obj)
+				Expect(err).NotTo(HaveOccurred())
+				patches = append(patches, string(data))
+				return cl.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+			},
+		})
+		r.Client = c
+		lvBuild, observed := read()
+		resourceVersion := lvBuild.ResourceVersion
+
+		lvBuild.Status.SourceRevision = "abc123"
+		Expect(r.updateStatus(ctx, lvBuild, observed)).To(Succeed())
+		Expect(patches).To(HaveExactElements(MatchJSON(`{"metadata":{"resourceVersion":"` + resourceVersion + `"},"status":{"sourceRevision":"abc123"}}`)))
+	})
+})
@@ -25,9 +25,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -114,3 +116,34 @@ func getFirstFoundEnvTestBinaryDir() string {
 	}
 	return ""
 }
+
+// newTestScheme returns a scheme of the types of client-go and of the API.
+func newTestScheme() *runtime.Scheme {
+	testScheme := runtime.NewScheme()
+	Expect(scheme.AddToScheme(testScheme)).To(Succeed())
+	Expect(jcrsv1.AddToScheme(testScheme)).To(Succeed())
+	return testScheme
+}
+
+// newFakeClientBuilder returns a builder of fake clients of newTestScheme, the
+// status of the custom resources being served as a subresource as by the API
+// server.
+func newFakeClientBuilder() *fake.ClientBuilder {
+	return fake.NewClientBuilder().WithScheme(newTestScheme()).WithStatusSubresource(
+		&jcrsv1.BuilderImageMapping{},
+		&jcrsv1.ClusterTarget{},
+		&jcrsv1.CredentialGrant{},
+		&jcrsv1.LeviathanBuild{},
+		&jcrsv1.LeviathanBuildBatchOperation{},
+		&jcrsv1.LeviathanBuildSummary{},
+		&jcrsv1.LeviathanClusterBuild{},
+		&jcrsv1.LeviathanProject{},
+		&jcrsv1.PackagePromotion{},
+	)
+}
+
+// newFakeClient returns a fake client of newFakeClientBuilder, with objs in the
+// cluster.
+func newFakeClient(objs ...client.Object) client.WithWatch {
+	return newFakeClientBuilder().WithObjects(objs...).Build()
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Verify builds validate a change, such as a pull request, and are thrown away once
+they've said whether it builds. Their containers are told through an environment
+variable not to produce or publish artifacts, their pods run with the lower
+priority class configured for the operator unless the template sets one, and only
+the Job of their latest run is kept.
+
+A Job can't be given a TTL of its own: the controller would take the deleted Job
+of a finished run for a run that was never started, and run the build again.
+Instead the build itself is deleted once the TTL configured for the operator has
+passed since its latest run finished, which also archives it, if an archive is
+configured.
+*/
+
+const (
+	// verifyEnv tells the containers of Verify builds not to produce or publish artifacts
+	verifyEnv = "LEVIATHAN_VERIFY"
+)
+
+// VerifyConfig describes how Verify builds are run.
+type VerifyConfig struct {
+	// PriorityClassName is the priority class of the pods of Verify builds that
+	// don't set one. They keep their priority when empty.
+	PriorityClassName string
+
+	// TTL is how long Verify builds are kept once their latest run has finished.
+	// They are kept until deleted when 0.
+	TTL time.Duration
+}
+
+// verifies reports whether lvBuild is a Verify build.
+func verifies(lvBuild *jcrsv1.LeviathanBuild) bool {
+	return lvBuild.Spec.BuildType == jcrsv1.Verify
+}
+
+// addVerifyDefaults marks the containers of Verify builds and sets their priority class.
+func (r *LeviathanBuildReconciler) addVerifyDefaults(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) {
+	if !verifies(lvBuild) {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	setEnv(podSpec, corev1.EnvVar{Name: verifyEnv, Value: "true"})
+	if podSpec.PriorityClassName == "" {
+		podSpec.PriorityClassName = r.Verify.PriorityClassName
+	}
+}
+
+// pruneVerifyHistory deletes the Jobs of the runs of a Verify build before its latest run.
+func (r *LeviathanBuildReconciler) pruneVerifyHistory(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild, latestRunIndex int64) error {
+	if !verifies(lvBuild) {
+		return nil
+	}
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(lvBuild.Namespace), client.MatchingLabels{buildLabel: labelValue(lvBuild.Name)}); err != nil {
+		return err
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if !metav1.IsControlledBy(job, lvBuild) {
+			continue
+		}
+		index, err := strconv.ParseInt(job.Labels[runIndexLabel], 10, 64)
+		if err != nil || index >= latestRunIndex {
+			continue
+		}
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyExpiresIn returns how long the finished Verify build lvBuild is kept for,
+// or false if it's kept until deleted.
+func (r *LeviathanBuildReconciler) verifyExpiresIn(lvBuild *jcrsv1.LeviathanBuild, now time.Time) (time.Duration, bool) {
+	if !verifies(lvBuild) || r.Verify.TTL <= 0 {
+		return 0, false
+	}
+	succeeded := meta.FindStatusCondition(lvBuild.Status.Conditions, jcrsv1.ConditionSucceeded)
+	if succeeded == nil || succeeded.Status == metav1.ConditionUnknown {
+		return 0, false
+	}
+	return succeeded.LastTransitionTime.Add(r.Verify.TTL).Sub(now), true
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Verify builds", func() {
+	var (
+		lvBuild *jcrsv1.LeviathanBuild
+		r       *LeviathanBuildReconciler
+		c       client.Client
+	)
+
+	BeforeEach(func() {
+		c = newFakeClient()
+		r = &LeviathanBuildReconciler{Client: c, Scheme: c.Scheme(), Verify: VerifyConfig{PriorityClassName: "pr-builds", TTL: time.Hour}}
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-pr-42", Namespace: "default", UID: "web-pr-42-uid"},
+			Spec:       jcrsv1.LeviathanBuildSpec{BuildType: jcrsv1.Verify},
+		}
+	})
+
+	It("marks their containers and lowers their priority", func() {
+		job := &batchv1.Job{}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+		r.addVerifyDefaults(lvBuild, job)
+		Expect(job.Spec.Template.Spec.Containers[0].Env).To(ConsistOf(corev1.EnvVar{Name: verifyEnv, Value: "true"}))
+		Expect(job.Spec.Template.Spec.PriorityClassName).To(Equal("pr-builds"))
+
+		By("keeping the priority class of the jobTemplate")
+		job.Spec.Template.Spec.PriorityClassName = "critical"
+		r.addVerifyDefaults(lvBuild, job)
+		Expect(job.Spec.Template.Spec.PriorityClassName).To(Equal("critical"))
+
+		By("leaving other builds alone")
+		job = &batchv1.Job{}
+		job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build"}}
+		lvBuild.Spec.BuildType = jcrsv1.Build
+		r.addVerifyDefaults(lvBuild, job)
+		Expect(job.Spec.Template.Spec.Containers[0].Env).To(BeEmpty())
+		Expect(job.Spec.Template.Spec.PriorityClassName).To(BeEmpty())
+	})
+
+	It("keeps only the Job of the latest run", func() {
+		ctx := context.Background()
+		for _, name := range []string{"web-pr-42-1", "web-pr-42-2", "web-pr-42-3"} {
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{buildLabel: "web-pr-42", runIndexLabel: name[len(name)-1:]},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: jcrsv1.GroupVersion.String(), Kind: "LeviathanBuild", Name: "web-pr-42", UID: lvBuild.UID, Controller: ptr.To(true),
+				}},
+			}}
+			Expect(c.Create(ctx, job)).To(Succeed())
+		}
+
+		Expect(r.pruneVerifyHistory(ctx, lvBuild, 3)).To(Succeed())
+		var jobs batchv1.JobList
+		Expect(c.List(ctx, &jobs)).To(Succeed())
+		Expect(jobs.Items).To(ConsistOf(HaveField("Name", "web-pr-42-3")))
+	})
+
+	It("expires them once their latest run has finished", func() {
+		finishedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		_, ok := r.verifyExpiresIn(lvBuild, finishedAt)
+		Expect(ok).To(BeFalse())
+
+		jcrsv1.MarkFailed(&lvBuild.Status.Conditions, 1, jcrsv1.ReasonJobFailed, "Job web-pr-42-1 failed")
+		lvBuild.Status.Conditions[0].LastTransitionTime = metav1.NewTime(finishedAt)
+		expiresIn, ok := r.verifyExpiresIn(lvBuild, finishedAt.Add(20*time.Minute))
+		Expect(ok).To(BeTrue())
+		Expect(expiresIn).To(Equal(40 * time.Minute))
+
+		By("keeping them when no TTL is configured")
+		r.Verify.TTL = 0
+		_, ok = r.verifyExpiresIn(lvBuild, finishedAt.Add(20*time.Minute))
+		Expect(ok).To(BeFalse())
+	})
+})
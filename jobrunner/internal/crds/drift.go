@@ -0,0 +1,202 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crds detects drift between the CustomResourceDefinitions installed in
+// the cluster and the ones bundled with the operator, and applies the bundled
+// ones when the operator is asked to manage them.
+package crds
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/yaml"
+
+	crd "test.jcrs.dev/jobrunner/config/crd"
+)
+
+/*
+An installed CRD is told apart from the bundled one by the fields their schemas
+define, version by version. A CRD that lacks fields or versions of the bundled one
+was installed by an older release of the operator, and one that defines fields or
+versions the bundled one doesn't know was installed by a newer release. Schemas
+defining the same fields may still differ in their validation, which doesn't say
+which of the two is newer.
+*/
+
+// Drift describes how an installed CRD differs from the bundled one.
+type Drift string
+
+const (
+	// InSync is the drift of a CRD whose schema is the bundled one
+	InSync Drift = "InSync"
+
+	// NotInstalled is the drift of a bundled CRD missing from the cluster
+	NotInstalled Drift = "NotInstalled"
+
+	// Older is the drift of a CRD lacking fields or versions of the bundled one
+	Older Drift = "Older"
+
+	// Newer is the drift of a CRD defining fields or versions the bundled one doesn't know
+	Newer Drift = "Newer"
+
+	// Diverged is the drift of a CRD that both lacks fields of the bundled one and
+	// defines fields it doesn't know
+	Diverged Drift = "Diverged"
+
+	// Changed is the drift of a CRD defining the same fields as the bundled one,
+	// validated differently
+	Changed Drift = "Changed"
+)
+
+// Drifts lists every Drift.
+var Drifts = []Drift{InSync, NotInstalled, Older, Newer, Diverged, Changed}
+
+// Comparison is the outcome of the comparison of an installed CRD with the bundled one.
+type Comparison struct {
+	Drift Drift
+
+	// Missing are the versions and fields of the bundled CRD the installed one lacks
+	Missing []string
+
+	// Unknown are the versions and fields of the installed CRD the bundled one doesn't know
+	Unknown []string
+}
+
+// Bundled returns the CRDs bundled with the operator.
+func Bundled() ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	files, err := fs.Glob(crd.Bases, "bases/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var crds []*apiextensionsv1.CustomResourceDefinition
+	for _, file := range files {
+		raw, err := crd.Bases.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		obj := &apiextensionsv1.CustomResourceDefinition{}
+		if err := yaml.UnmarshalStrict(raw, obj); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		crds = append(crds, obj)
+	}
+	return crds, nil
+}
+
+// Compare compares the schemas of the installed CRD with those of the bundled one.
+func Compare(installed, bundled *apiextensionsv1.CustomResourceDefinition) Comparison {
+	installedFields, bundledFields := fieldsOf(installed), fieldsOf(bundled)
+	comparison := Comparison{Drift: InSync}
+	for _, f := range slices.Sorted(maps.Keys(bundledFields)) {
+		if !installedFields[f] {
+			comparison.Missing = append(comparison.Missing, f)
+		}
+	}
+	for _, f := range slices.Sorted(maps.Keys(installedFields)) {
+		if !bundledFields[f] {
+			comparison.Unknown = append(comparison.Unknown, f)
+		}
+	}
+
+	switch {
+	case len(comparison.Missing) > 0 && len(comparison.Unknown) > 0:
+		comparison.Drift = Diverged
+	case len(comparison.Missing) > 0:
+		comparison.Drift = Older
+	case len(comparison.Unknown) > 0:
+		comparison.Drift = Newer
+	case !equality.Semantic.DeepEqual(schemasOf(installed), schemasOf(bundled)):
+		comparison.Drift = Changed
+	}
+	return comparison
+}
+
+// String describes the comparison for humans, naming a handful of fields at most.
+func (c Comparison) String() string {
+	switch c.Drift {
+	case InSync:
+		return "in sync"
+	case NotInstalled:
+		return "not installed"
+	case Changed:
+		return "validated differently"
+	}
+	var parts []string
+	if len(c.Missing) > 0 {
+		parts = append(parts, "lacks "+summarize(c.Missing))
+	}
+	if len(c.Unknown) > 0 {
+		parts = append(parts, "defines unknown "+summarize(c.Unknown))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// maxReportedFields is the number of fields named by a Comparison
+const maxReportedFields = 5
+
+// summarize joins the first fields, and counts the others.
+func summarize(fields []string) string {
+	if len(fields) <= maxReportedFields {
+		return strings.Join(fields, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(fields[:maxReportedFields], ", "), len(fields)-maxReportedFields)
+}
+
+// fieldsOf returns the versions of crd and the fields of their schemas, prefixed
+// by their version. Items of lists are marked by [], and values of maps by {}.
+func fieldsOf(crd *apiextensionsv1.CustomResourceDefinition) map[string]bool {
+	fields := make(map[string]bool)
+	for _, version := range crd.Spec.Versions {
+		fields[version.Name] = true
+		if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+			addFields(fields, version.Name+":", version.Schema.OpenAPIV3Schema)
+		}
+	}
+	return fields
+}
+
+// addFields adds the fields of schema to fields, prefixed by prefix.
+func addFields(fields map[string]bool, prefix string, schema *apiextensionsv1.JSONSchemaProps) {
+	for name, property := range schema.Properties {
+		path := prefix + name
+		if !strings.HasSuffix(prefix, ":") {
+			path = prefix + "." + name
+		}
+		fields[path] = true
+		addFields(fields, path, &property)
+	}
+	if schema.Items != nil && schema.Items.Schema != nil {
+		addFields(fields, prefix+"[]", schema.Items.Schema)
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		addFields(fields, prefix+"{}", schema.AdditionalProperties.Schema)
+	}
+}
+
+// schemasOf returns the schemas of the versions of crd by name.
+func schemasOf(crd *apiextensionsv1.CustomResourceDefinition) map[string]*apiextensionsv1.CustomResourceValidation {
+	schemas := make(map[string]*apiextensionsv1.CustomResourceValidation)
+	for _, version := range crd.Spec.Versions {
+		schemas[version.Name] = version.Schema
+	}
+	return schemas
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crds
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// testCRD returns a CRD with a single version whose spec defines fields.
+func testCRD(fields ...string) *apiextensionsv1.CustomResourceDefinition {
+	spec := apiextensionsv1.JSONSchemaProps{Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{}}
+	for _, f := range fields {
+		spec.Properties[f] = apiextensionsv1.JSONSchemaProps{Type: "string"}
+	}
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "leviathanbuilds.jcrs.jcrs.dev"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name: "v1",
+				Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+					Type:       "object",
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{"spec": spec},
+				}},
+			}},
+		},
+	}
+}
+
+var _ = Describe("Compare", func() {
+	It("reads the bundled CRDs", func() {
+		bundled, err := Bundled()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundled).To(ContainElement(HaveField("Name", "leviathanbuilds.jcrs.jcrs.dev")))
+		for _, crd := range bundled {
+			Expect(Compare(crd, crd).Drift).To(Equal(InSync))
+		}
+	})
+
+	DescribeTable("tells which of the two CRDs is newer",
+		func(installed, bundled *apiextensionsv1.CustomResourceDefinition, expected Comparison) {
+			Expect(Compare(installed, bundled)).To(Equal(expected))
+		},
+		Entry("the same schema", testCRD("packageName"), testCRD("packageName"), Comparison{Drift: InSync}),
+		Entry("an installed CRD lacking fields", testCRD("packageName"), testCRD("packageName", "verify"),
+			Comparison{Drift: Older, Missing: []string{"v1:spec.verify"}}),
+		Entry("an installed CRD defining unknown fields", testCRD("packageName", "verify"), testCRD("packageName"),
+			Comparison{Drift: Newer, Unknown: []string{"v1:spec.verify"}}),
+		Entry("an installed CRD with fields of its own", testCRD("packageName", "legacy"), testCRD("packageName", "verify"),
+			Comparison{Drift: Diverged, Missing: []string{"v1:spec.verify"}, Unknown: []string{"v1:spec.legacy"}}),
+	)
+
+	It("tells versions and nested fields apart", func() {
+		bundled := testCRD("packageName")
+		v2 := *bundled.Spec.Versions[0].DeepCopy()
+		v2.Name = "v2"
+		v2.Schema.OpenAPIV3Schema.Properties["spec"].Properties["packageName"] = apiextensionsv1.JSONSchemaProps{
+			Type: "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{
+				Type:       "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{"name": {Type: "string"}},
+			}},
+		}
+		bundled.Spec.Versions = append(bundled.Spec.Versions, v2)
+
+		comparison := Compare(testCRD("packageName"), bundled)
+		Expect(comparison.Drift).To(Equal(Older))
+		Expect(comparison.Missing).To(Equal([]string{"v2", "v2:spec", "v2:spec.packageName", "v2:spec.packageName[].name"}))
+		Expect(comparison.String()).To(Equal("lacks v2, v2:spec, v2:spec.packageName, v2:spec.packageName[].name"))
+	})
+
+	It("reports schemas validating the same fields differently", func() {
+		installed := testCRD("packageName")
+		installed.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["packageName"] = apiextensionsv1.JSONSchemaProps{
+			Type:      "string",
+			MaxLength: ptr.To[int64](63),
+		}
+		Expect(Compare(installed, testCRD("packageName")).Drift).To(Equal(Changed))
+	})
+})
+
+var _ = Describe("Monitor", func() {
+	var (
+		scheme   *runtime.Scheme
+		applied  []string
+		recorder *record.FakeRecorder
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+		applied = nil
+		recorder = record.NewFakeRecorder(10)
+	})
+
+	newMonitor := func(manage bool, installed ...client.Object) *Monitor {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(installed...).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, patch client.Patch, _ ...client.PatchOption) error {
+				Expect(patch).To(Equal(client.Apply))
+				applied = append(applied, obj.GetName())
+				return nil
+			},
+		}).Build()
+		return &Monitor{Reader: c, Client: c, Recorder: recorder, CRDs: []*apiextensionsv1.CustomResourceDefinition{testCRD("packageName", "verify")}, Manage: manage}
+	}
+
+	It("marks the operator Degraded while a CRD drifted", func() {
+		ctx := context.Background()
+		m := newMonitor(false, testCRD("packageName"))
+		Expect(m.Condition().Status).To(Equal(metav1.ConditionUnknown))
+
+		Expect(m.Check(ctx)).To(Succeed())
+		Expect(applied).To(BeEmpty())
+		condition := m.Condition()
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(ReasonCRDSchemaDrift))
+		Expect(condition.Message).To(Equal("leviathanbuilds.jcrs.jcrs.dev is older: lacks v1:spec.verify"))
+		Expect(testutil.ToFloat64(crdSchemaDrift.WithLabelValues("leviathanbuilds.jcrs.jcrs.dev", string(Older)))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(crdSchemaDrift.WithLabelValues("leviathanbuilds.jcrs.jcrs.dev", string(InSync)))).To(Equal(0.0))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning CRDSchemaDrift leviathanbuilds.jcrs.jcrs.dev is older")))
+
+		By("recording the Event once per drift")
+		Expect(m.Check(ctx)).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("applies the bundled CRD over an older one when it manages them", func() {
+		m := newMonitor(true, testCRD("packageName"))
+		Expect(m.Check(context.Background())).To(Succeed())
+		Expect(applied).To(Equal([]string{"leviathanbuilds.jcrs.jcrs.dev"}))
+		Expect(m.Condition().Status).To(Equal(metav1.ConditionFalse))
+		Expect(testutil.ToFloat64(crdSchemaDrift.WithLabelValues("leviathanbuilds.jcrs.jcrs.dev", string(InSync)))).To(Equal(1.0))
+
+		By("installing a missing CRD")
+		applied = nil
+		m = newMonitor(true)
+		Expect(m.Check(context.Background())).To(Succeed())
+		Expect(applied).To(Equal([]string{"leviathanbuilds.jcrs.jcrs.dev"}))
+	})
+
+	It("never applies the bundled CRD over a newer one", func() {
+		m := newMonitor(true, testCRD("packageName", "verify", "priority"))
+		Expect(m.Check(context.Background())).To(Succeed())
+		Expect(applied).To(BeEmpty())
+		Expect(m.Condition().Message).To(Equal("leviathanbuilds.jcrs.jcrs.dev is newer: defines unknown v1:spec.priority"))
+	})
+})
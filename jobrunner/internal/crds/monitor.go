@@ -0,0 +1,237 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+/*
+The Monitor compares the installed CRDs with the bundled ones when the operator
+starts, and periodically after that, since CRDs are often installed by a separate
+pipeline that an upgrade of the operator may run ahead of or lag behind. A drift
+marks the operator Degraded, is exported as a metric and recorded as an Event on
+the CRD.
+
+When the operator manages its CRDs, the bundled ones are applied over those that
+aren't installed, are older or are validated differently. A newer CRD is never
+applied over: it may define fields a newer release of the operator relies on, and
+applying the bundled CRD would prune them from the stored objects.
+*/
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
+
+const (
+	// ConditionDegraded is the condition of the operator that reports drifted CRDs
+	ConditionDegraded = "Degraded"
+
+	// ReasonCRDSchemaDrift is the reason of the Degraded condition while CRDs drifted
+	ReasonCRDSchemaDrift = "CRDSchemaDrift"
+
+	// ReasonCRDsInSync is the reason of the Degraded condition once every CRD is in sync
+	ReasonCRDsInSync = "CRDsInSync"
+
+	// fieldOwner owns the fields of the CRDs applied by the operator
+	fieldOwner = "jobrunner"
+)
+
+var crdSchemaDrift = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "jobrunner_crd_schema_drift",
+		Help: "Whether a CustomResourceDefinition installed in the cluster drifted from the one bundled with the operator, by drift",
+	},
+	[]string{"crd", "drift"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(crdSchemaDrift)
+}
+
+// Monitor detects the drift of the installed CRDs from the bundled ones.
+type Monitor struct {
+	// Reader reads the installed CRDs. It isn't cached, since they are read once
+	// in a while.
+	Reader client.Reader
+
+	// Client applies the bundled CRDs.
+	Client client.Client
+
+	// Recorder records the Events of drifted CRDs. Events aren't recorded when nil.
+	Recorder record.EventRecorder
+
+	// CRDs are the bundled CRDs.
+	CRDs []*apiextensionsv1.CustomResourceDefinition
+
+	// Interval is the time between two comparisons. The CRDs are only compared
+	// at startup when 0.
+	Interval time.Duration
+
+	// Manage applies the bundled CRDs over those that are missing, older or
+	// validated differently.
+	Manage bool
+
+	mu        sync.Mutex
+	condition metav1.Condition
+	reported  map[string]string
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only one
+// replica compares, and applies, the CRDs.
+func (m *Monitor) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (m *Monitor) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("crds")
+	ctx = logf.IntoContext(ctx, log)
+	if err := m.Check(ctx); err != nil {
+		log.Error(err, "Failed to compare CustomResourceDefinitions")
+	}
+	if m.Interval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := m.Check(ctx); err != nil {
+				log.Error(err, "Failed to compare CustomResourceDefinitions")
+			}
+		}
+	}
+}
+
+// Condition returns the Degraded condition of the operator, whose status is
+// Unknown until the CRDs have been compared.
+func (m *Monitor) Condition() metav1.Condition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.condition.Type == "" {
+		return metav1.Condition{Type: ConditionDegraded, Status: metav1.ConditionUnknown, Reason: "NotChecked"}
+	}
+	return m.condition
+}
+
+// Check compares the installed CRDs with the bundled ones, and applies the bundled
+// ones if the operator manages them.
+func (m *Monitor) Check(ctx context.Context) error {
+	log := logf.FromContext(ctx)
+	var drifted []string
+	for _, bundled := range m.CRDs {
+		installed := &apiextensionsv1.CustomResourceDefinition{}
+		comparison := Comparison{Drift: NotInstalled}
+		if err := m.Reader.Get(ctx, client.ObjectKey{Name: bundled.Name}, installed); err == nil {
+			comparison = Compare(installed, bundled)
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		if m.Manage && applies(comparison.Drift) {
+			if err := m.apply(ctx, bundled); err != nil {
+				return fmt.Errorf("failed to apply %s: %w", bundled.Name, err)
+			}
+			log.Info("Applied the bundled CustomResourceDefinition", "CustomResourceDefinition", bundled.Name, "drift", comparison.Drift)
+			comparison = Comparison{Drift: InSync}
+		}
+
+		for _, drift := range Drifts {
+			value := 0.0
+			if drift == comparison.Drift {
+				value = 1
+			}
+			crdSchemaDrift.WithLabelValues(bundled.Name, string(drift)).Set(value)
+		}
+		if comparison.Drift == InSync {
+			m.report(bundled.Name, "")
+			continue
+		}
+		message := fmt.Sprintf("%s is %s: %s", bundled.Name, strings.ToLower(string(comparison.Drift)), comparison)
+		drifted = append(drifted, message)
+		if m.report(bundled.Name, message) {
+			log.Info("CustomResourceDefinition drifted from the bundled one", "CustomResourceDefinition", bundled.Name,
+				"drift", comparison.Drift, "missing", comparison.Missing, "unknown", comparison.Unknown)
+			if m.Recorder != nil && comparison.Drift != NotInstalled {
+				m.Recorder.Event(installed, corev1.EventTypeWarning, ReasonCRDSchemaDrift, message)
+			}
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    ConditionDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonCRDsInSync,
+		Message: "The installed CustomResourceDefinitions are the bundled ones",
+	}
+	if len(drifted) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonCRDSchemaDrift
+		condition.Message = strings.Join(drifted, "; ")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	conditions := []metav1.Condition{m.condition}
+	if m.condition.Type == "" {
+		conditions = nil
+	}
+	meta.SetStatusCondition(&conditions, condition)
+	m.condition = conditions[0]
+	return nil
+}
+
+// report records message as the drift of the CRD name, and reports whether it changed.
+func (m *Monitor) report(name, message string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reported == nil {
+		m.reported = make(map[string]string)
+	}
+	changed := m.reported[name] != message
+	m.reported[name] = message
+	return changed
+}
+
+// applies reports whether the bundled CRD is applied over an installed one with drift.
+func applies(drift Drift) bool {
+	return drift == NotInstalled || drift == Older || drift == Changed
+}
+
+// apply applies the bundled CRD, taking over the fields it sets.
+func (m *Monitor) apply(ctx context.Context, bundled *apiextensionsv1.CustomResourceDefinition) error {
+	obj := bundled.DeepCopy()
+	obj.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition"))
+	return m.Client.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package featuregates lets new controller subsystems ship disabled and be turned
+// on per cluster with the --feature-gates flag. It is modeled on the feature gates
+// of k8s.io/component-base.
+package featuregates
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature is the name of a feature gate.
+type Feature string
+
+// Stage is the maturity of a feature.
+type Stage string
+
+const (
+	// Alpha features are disabled by default and may change or go away
+	Alpha Stage = "ALPHA"
+	// Beta features are enabled by default
+	Beta Stage = "BETA"
+	// GA features are always enabled, the gate only remains for compatibility
+	GA Stage = ""
+)
+
+// FeatureSpec describes a feature gate.
+type FeatureSpec struct {
+	// Default is the state of the feature when it isn't set explicitly
+	Default bool
+	// Stage is the maturity of the feature
+	Stage Stage
+	// LockToDefault prevents the feature from being changed from its default
+	LockToDefault bool
+}
+
+// FeatureGate holds the state of a set of feature gates. It implements flag.Value.
+type FeatureGate struct {
+	mu      sync.RWMutex
+	known   map[Feature]FeatureSpec
+	enabled map[Feature]bool
+}
+
+// NewFeatureGate returns a FeatureGate without any known feature.
+func NewFeatureGate() *FeatureGate {
+	return &FeatureGate{
+		known:   make(map[Feature]FeatureSpec),
+		enabled: make(map[Feature]bool),
+	}
+}
+
+// Add registers features. Adding a feature twice with a different spec fails.
+func (f *FeatureGate) Add(features map[Feature]FeatureSpec) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for name, spec := range features {
+		if existing, ok := f.known[name]; ok && existing != spec {
+			return fmt.Errorf("feature gate %q with different spec already exists: %v", name, existing)
+		}
+		f.known[name] = spec
+	}
+	return nil
+}
+
+// Set parses a comma separated list of feature=bool pairs, e.g. "A=true,B=false",
+// and applies it on top of the current state.
+func (f *FeatureGate) Set(value string) error {
+	m := make(map[Feature]bool)
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("missing bool value for %s", k)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid value of %s=%s, err: %v", k, v, err)
+		}
+		m[Feature(strings.TrimSpace(k))] = enabled
+	}
+	return f.SetFromMap(m)
+}
+
+// SetFromMap sets the state of the given features. Unknown features and changes
+// to locked features are rejected, in which case nothing is changed.
+func (f *FeatureGate) SetFromMap(m map[Feature]bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for name, enabled := range m {
+		spec, ok := f.known[name]
+		if !ok {
+			return fmt.Errorf("unrecognized feature gate: %s", name)
+		}
+		if spec.LockToDefault && spec.Default != enabled {
+			return fmt.Errorf("cannot set feature gate %v to %v, feature is locked to %v", name, enabled, spec.Default)
+		}
+	}
+	maps.Copy(f.enabled, m)
+	return nil
+}
+
+// String returns the explicitly set features as "A=true,B=false", sorted by name.
+func (f *FeatureGate) String() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var pairs []string
+	for name, enabled := range f.enabled {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", name, enabled))
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Enabled reports whether the feature is enabled. It panics for unknown features,
+// as checking a feature that was never registered is a programming error.
+func (f *FeatureGate) Enabled(name Feature) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if enabled, ok := f.enabled[name]; ok {
+		return enabled
+	}
+	spec, ok := f.known[name]
+	if !ok {
+		panic(fmt.Sprintf("feature %q is not registered in FeatureGate", name))
+	}
+	return spec.Default
+}
+
+// KnownFeatures returns a description of every feature that can be set, for use
+// in the help text of the flag.
+func (f *FeatureGate) KnownFeatures() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var known []string
+	for name, spec := range f.known {
+		if spec.Stage == GA || spec.LockToDefault {
+			continue
+		}
+		known = append(known, fmt.Sprintf("%s=true|false (%s - default=%t)", name, spec.Stage, spec.Default))
+	}
+	slices.Sort(known)
+	return known
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package featuregates
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FeatureGate", func() {
+	var gate *FeatureGate
+
+	BeforeEach(func() {
+		gate = NewFeatureGate()
+		Expect(gate.Add(map[Feature]FeatureSpec{
+			"AlphaFeature":  {Default: false, Stage: Alpha},
+			"BetaFeature":   {Default: true, Stage: Beta},
+			"LockedFeature": {Default: true, Stage: GA, LockToDefault: true},
+		})).To(Succeed())
+	})
+
+	It("uses the defaults until features are set", func() {
+		Expect(gate.Enabled("AlphaFeature")).To(BeFalse())
+		Expect(gate.Enabled("BetaFeature")).To(BeTrue())
+
+		Expect(gate.Set("AlphaFeature=true, BetaFeature=false")).To(Succeed())
+		Expect(gate.Enabled("AlphaFeature")).To(BeTrue())
+		Expect(gate.Enabled("BetaFeature")).To(BeFalse())
+		Expect(gate.String()).To(Equal("AlphaFeature=true,BetaFeature=false"))
+	})
+
+	It("rejects invalid settings without changing anything", func() {
+		Expect(gate.Set("AlphaFeature=true,Unknown=true")).To(MatchError(ContainSubstring("unrecognized feature gate")))
+		Expect(gate.Set("AlphaFeature")).To(MatchError(ContainSubstring("missing bool value")))
+		Expect(gate.Set("AlphaFeature=maybe")).To(MatchError(ContainSubstring("invalid value")))
+		Expect(gate.Set("LockedFeature=false")).To(MatchError(ContainSubstring("locked")))
+		Expect(gate.Enabled("AlphaFeature")).To(BeFalse())
+	})
+
+	It("rejects conflicting registrations", func() {
+		Expect(gate.Add(map[Feature]FeatureSpec{"AlphaFeature": {Default: false, Stage: Alpha}})).To(Succeed())
+		Expect(gate.Add(map[Feature]FeatureSpec{"AlphaFeature": {Default: true, Stage: Beta}})).NotTo(Succeed())
+	})
+
+	It("lists the features that can be set", func() {
+		Expect(gate.KnownFeatures()).To(Equal([]string{
+			"AlphaFeature=true|false (ALPHA - default=false)",
+			"BetaFeature=true|false (BETA - default=true)",
+		}))
+	})
+
+	It("panics on unknown features", func() {
+		Expect(func() { gate.Enabled("Unknown") }).To(Panic())
+	})
+})
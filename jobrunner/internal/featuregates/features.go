@@ -0,0 +1,198 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package featuregates
+
+const (
+	// BuilderImageMappings resolves the image of jobTemplate containers without
+	// one from the BuilderImageMappings.
+	BuilderImageMappings Feature = "BuilderImageMappings"
+
+	// OnSuccessPatchTargets applies the spec.onSuccess patch targets of builds
+	// once they have published.
+	OnSuccessPatchTargets Feature = "OnSuccessPatchTargets"
+
+	// BuilderImageCanaries rolls out the canary images of BuilderImageMapping
+	// rules, and pauses rollouts whose builds fail more often.
+	BuilderImageCanaries Feature = "BuilderImageCanaries"
+
+	// ArtifactRetention prunes the versions published by builds according to
+	// their spec.artifactRetention policy.
+	ArtifactRetention Feature = "ArtifactRetention"
+
+	// MaintenanceWindows holds back the new Jobs of builds while a
+	// MaintenanceWindow is open.
+	MaintenanceWindows Feature = "MaintenanceWindows"
+
+	// PullSecretDistribution copies a central registry pull Secret into the
+	// namespaces of builds and attaches it to build pods.
+	PullSecretDistribution Feature = "PullSecretDistribution"
+
+	// BuildSummaries maintains a LeviathanBuildSummary and summary metrics per
+	// namespace holding builds.
+	BuildSummaries Feature = "BuildSummaries"
+
+	// PackageOwnership only publishes the packages of PackageOwnerships from
+	// builds last triggered by one of their publishers.
+	PackageOwnership Feature = "PackageOwnership"
+
+	// BuildDefaults fills the fields new builds leave unset from the
+	// LeviathanBuildDefaults of their namespace.
+	BuildDefaults Feature = "BuildDefaults"
+
+	// CredentialGrants copies the Secrets of CredentialGrants into the namespaces
+	// of the builds referencing them.
+	CredentialGrants Feature = "CredentialGrants"
+
+	// BatchOperations applies the LeviathanBuildBatchOperations triggering,
+	// suspending, resuming or cancelling groups of builds.
+	BatchOperations Feature = "BatchOperations"
+
+	// ClusterBuilds runs the Jobs of cluster-scoped LeviathanClusterBuilds in the
+	// cluster build namespace.
+	ClusterBuilds Feature = "ClusterBuilds"
+
+	// SourcePolling polls the sources of builds with a spec.pollInterval, and
+	// runs them again when the revision of their source changes.
+	SourcePolling Feature = "SourcePolling"
+
+	// BuildProgress follows the logs of running builds for the progress markers
+	// of their build containers, and reports them in status.progress.
+	BuildProgress Feature = "BuildProgress"
+
+	// ExecutionBackends runs builds with an executionBackend as Tekton
+	// PipelineRuns or Argo Workflows instead of Jobs.
+	ExecutionBackends Feature = "ExecutionBackends"
+
+	// CapacityWaits holds builds whose run failed for lack of node capacity until
+	// a node recovers, rather than failing them.
+	CapacityWaits Feature = "CapacityWaits"
+
+	// ArtifactSigning signs the artifacts of builds with spec.signing with cosign
+	// before their patchTargets are applied.
+	ArtifactSigning Feature = "ArtifactSigning"
+
+	// ManagedJobCache only caches the Jobs labeled as managed by the controller,
+	// without the pod template of their pods, to bound the memory of the
+	// controller in clusters with many other Jobs.
+	ManagedJobCache Feature = "ManagedJobCache"
+
+	// RemoteClusters serves ClusterTargets, and dispatches the Jobs of builds
+	// with a clusterSelector to the remote clusters they register.
+	RemoteClusters Feature = "RemoteClusters"
+
+	// BuildTypeDefinitions serves BuildTypeDefinitions, and runs their steps in
+	// the builds of their buildType whose jobTemplate doesn't set containers.
+	BuildTypeDefinitions Feature = "BuildTypeDefinitions"
+
+	// BuilderImagePolling checks the builder images resolved from the
+	// BuilderImageMappings for a new digest, and runs the builds with a
+	// rebuildOnImageChange again when their image changes.
+	BuilderImagePolling Feature = "BuilderImagePolling"
+
+	// Projects serves LeviathanProjects, rolling up the state of their packages
+	// and rebuilding them in dependency order, and defaults the builds of a
+	// project from its defaults.
+	Projects Feature = "Projects"
+
+	// SecretChecksums records checksums of the Secrets of the runs of builds
+	// with checksumSecrets, and reports the Secrets rotated while they run.
+	SecretChecksums Feature = "SecretChecksums"
+
+	// PackagePromotions serves PackagePromotions, promoting the artifacts of
+	// successful builds to another registry path once they are approved.
+	PackagePromotions Feature = "PackagePromotions"
+
+	// PublishApprovals serves PublishApprovals, and holds the publish of the runs
+	// of builds with a publishApproval until they are approved.
+	PublishApprovals Feature = "PublishApprovals"
+
+	// PhaseCredentials gives the credentials of builds only to the containers
+	// of the phase needing them, and a shared workspace to the steps of
+	// pipelines.
+	PhaseCredentials Feature = "PhaseCredentials"
+
+	// PodCreationChecks watches the Events of the Jobs of builds for pods the
+	// API server refuses to create, and reports them in the PodCreationBlocked
+	// condition of the builds.
+	PodCreationChecks Feature = "PodCreationChecks"
+
+	// ParallelJobPruner deletes the Jobs of past runs from a pruner of its own,
+	// with a pool of workers and a deletion rate limit, instead of from the
+	// reconciles of builds.
+	ParallelJobPruner Feature = "ParallelJobPruner"
+
+	// JobEventMirroring records the Warning Events of the Jobs of builds and
+	// their pods, such as FailedScheduling or BackOff, on the builds again.
+	JobEventMirroring Feature = "JobEventMirroring"
+
+	// ReportedConditions reflects the conditions reported by the build containers
+	// of builds in their termination message into the status of the builds.
+	ReportedConditions Feature = "ReportedConditions"
+
+	// SpecHistory keeps the latest generations of the spec of builds in a
+	// ConfigMap, which `leviathan rollback` applies again.
+	SpecHistory Feature = "SpecHistory"
+)
+
+// defaultFeatures lists every feature of the controller and its default state.
+var defaultFeatures = map[Feature]FeatureSpec{
+	BuilderImageMappings:   {Default: true, Stage: Beta},
+	OnSuccessPatchTargets:  {Default: false, Stage: Alpha},
+	BuilderImageCanaries:   {Default: false, Stage: Alpha},
+	ArtifactRetention:      {Default: false, Stage: Alpha},
+	MaintenanceWindows:     {Default: false, Stage: Alpha},
+	PullSecretDistribution: {Default: false, Stage: Alpha},
+	BuildSummaries:         {Default: false, Stage: Alpha},
+	PackageOwnership:       {Default: false, Stage: Alpha},
+	BuildDefaults:          {Default: false, Stage: Alpha},
+	CredentialGrants:       {Default: false, Stage: Alpha},
+	BatchOperations:        {Default: false, Stage: Alpha},
+	ClusterBuilds:          {Default: false, Stage: Alpha},
+	SourcePolling:          {Default: false, Stage: Alpha},
+	BuildProgress:          {Default: false, Stage: Alpha},
+	ExecutionBackends:      {Default: false, Stage: Alpha},
+	CapacityWaits:          {Default: false, Stage: Alpha},
+	ArtifactSigning:        {Default: false, Stage: Alpha},
+	ManagedJobCache:        {Default: false, Stage: Alpha},
+	RemoteClusters:         {Default: false, Stage: Alpha},
+	BuildTypeDefinitions:   {Default: false, Stage: Alpha},
+	BuilderImagePolling:    {Default: false, Stage: Alpha},
+	Projects:               {Default: false, Stage: Alpha},
+	SecretChecksums:        {Default: false, Stage: Alpha},
+	PackagePromotions:      {Default: false, Stage: Alpha},
+	PublishApprovals:       {Default: false, Stage: Alpha},
+	PhaseCredentials:       {Default: false, Stage: Alpha},
+	PodCreationChecks:      {Default: false, Stage: Alpha},
+	ParallelJobPruner:      {Default: false, Stage: Alpha},
+	JobEventMirroring:      {Default: false, Stage: Alpha},
+	ReportedConditions:     {Default: false, Stage: Alpha},
+	SpecHistory:            {Default: false, Stage: Alpha},
+}
+
+// DefaultFeatureGate is the feature gate of the controller, set through the --feature-gates flag.
+var DefaultFeatureGate = NewFeatureGate()
+
+func init() {
+	if err := DefaultFeatureGate.Add(defaultFeatures); err != nil {
+		panic(err)
+	}
+}
+
+// Enabled reports whether the feature is enabled in the DefaultFeatureGate.
+func Enabled(name Feature) bool {
+	return DefaultFeatureGate.Enabled(name)
+}
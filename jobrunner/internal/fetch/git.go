@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// GitOptions configures a Git fetch.
+type GitOptions struct {
+	// URL of the repository
+	URL string
+	// Dest is the directory the repository is cloned into
+	Dest string
+
+	// Submodules clones the submodules of the repository, recursively
+	Submodules bool
+	// LFS fetches the Git LFS objects of the checked out files
+	LFS bool
+	// SparsePaths limits the checkout to these directories
+	SparsePaths []string
+	// Parallelism is the number of submodules and LFS objects downloaded at
+	// once. Git's defaults apply when zero.
+	Parallelism int
+
+	// Git is the git binary, "git" from the PATH when empty
+	Git string
+}
+
+// Git clones the default branch of the repository at opts.URL into opts.Dest,
+// without its history. The revision of a Git source is the commit checked out.
+func Git(ctx context.Context, opts GitOptions) (*Result, error) {
+	for _, args := range gitCommands(opts) {
+		if _, err := runGit(ctx, opts, args...); err != nil {
+			return nil, err
+		}
+	}
+	revision, err := runGit(ctx, opts, "-C", opts.Dest, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Revision: revision}, nil
+}
+
+// gitCommands returns the arguments of the git commands cloning the repository.
+// Sparse clones leave the blobs outside the sparse paths on the server, and only
+// initialize the submodules under the sparse paths.
+func gitCommands(opts GitOptions) [][]string {
+	clone := []string{"clone", "--depth=1", "--single-branch"}
+	if len(opts.SparsePaths) > 0 {
+		clone = append(clone, "--sparse", "--filter=blob:none")
+	}
+	commands := [][]string{append(clone, "--", opts.URL, opts.Dest)}
+
+	if len(opts.SparsePaths) > 0 {
+		commands = append(commands, append([]string{"-C", opts.Dest, "sparse-checkout", "set", "--cone", "--"}, opts.SparsePaths...))
+	}
+	if opts.Submodules {
+		update := []string{"-C", opts.Dest, "submodule", "update", "--init", "--recursive", "--depth=1"}
+		if opts.Parallelism > 0 {
+			update = append(update, "--jobs="+strconv.Itoa(opts.Parallelism))
+		}
+		if len(opts.SparsePaths) > 0 {
+			update = append(append(update, "--"), opts.SparsePaths...)
+		}
+		commands = append(commands, update)
+	}
+	if opts.LFS {
+		// Configuration passed with -c is inherited by the git commands of submodule foreach
+		var lfsConfig []string
+		if opts.Parallelism > 0 {
+			lfsConfig = []string{"-c", "lfs.concurrenttransfers=" + strconv.Itoa(opts.Parallelism)}
+		}
+		pull := append(lfsConfig, "-C", opts.Dest, "lfs", "pull")
+		if len(opts.SparsePaths) > 0 {
+			pull = append(pull, "--include="+strings.Join(opts.SparsePaths, ","))
+		}
+		commands = append(commands, pull)
+		if opts.Submodules {
+			commands = append(commands, append(lfsConfig, "-C", opts.Dest, "submodule", "foreach", "--recursive", "git lfs pull"))
+		}
+	}
+	return commands
+}
+
+// runGit runs git with args and returns its trimmed output. LFS objects are only
+// downloaded by an explicit pull, so that repositories using LFS are cloned with
+// pointer files unless asked otherwise.
+func runGit(ctx context.Context, opts GitOptions, args ...string) (string, error) {
+	git := opts.Git
+	if git == "" {
+		git = "git"
+	}
+	cmd := exec.CommandContext(ctx, git, args...)
+	cmd.Env = append(os.Environ(), "GIT_LFS_SKIP_SMUDGE=1", "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
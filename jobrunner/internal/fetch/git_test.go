@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// gitRepo creates a repository holding files, with a single commit.
+func gitRepo(files map[string]string, submodules map[string]string) string {
+	dir := GinkgoT().TempDir()
+	run := func(args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(out))
+	}
+	run("init", "--quiet", "--initial-branch=main")
+	for name, content := range files {
+		Expect(os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)).To(Succeed())
+	}
+	for path, url := range submodules {
+		run("submodule", "--quiet", "add", url, path)
+	}
+	run("add", ".")
+	run("commit", "--quiet", "--message", "initial")
+	return dir
+}
+
+var _ = Describe("Git fetch", func() {
+	BeforeEach(func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git isn't installed")
+		}
+		for key, value := range map[string]string{
+			"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com",
+			"GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com",
+			// Submodules are cloned from local repositories
+			"GIT_CONFIG_COUNT": "1", "GIT_CONFIG_KEY_0": "protocol.file.allow", "GIT_CONFIG_VALUE_0": "always",
+		} {
+			GinkgoT().Setenv(key, value)
+		}
+	})
+
+	It("clones the repository and reports the commit", func() {
+		repo := gitRepo(map[string]string{"README": "hello", "web/main.go": "package main"}, nil)
+		head, err := exec.Command("git", "-C", repo, "rev-parse", "HEAD").Output()
+		Expect(err).NotTo(HaveOccurred())
+
+		dest := GinkgoT().TempDir()
+		result, err := Git(context.Background(), GitOptions{URL: "file://" + repo, Dest: dest})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Revision + "\n").To(Equal(string(head)))
+		Expect(os.ReadFile(filepath.Join(dest, "web", "main.go"))).To(BeEquivalentTo("package main"))
+	})
+
+	It("checks out the sparse paths and their submodules only", func() {
+		lib := gitRepo(map[string]string{"lib.go": "package lib"}, nil)
+		other := gitRepo(map[string]string{"other.go": "package other"}, nil)
+		repo := gitRepo(map[string]string{
+			"README":      "hello",
+			"web/main.go": "package main",
+			"api/main.go": "package main",
+		}, map[string]string{"web/lib": "file://" + lib, "api/other": "file://" + other})
+
+		dest := GinkgoT().TempDir()
+		_, err := Git(context.Background(), GitOptions{URL: "file://" + repo, Dest: dest, Submodules: true, SparsePaths: []string{"web"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(dest, "README")).To(BeAnExistingFile())
+		Expect(os.ReadFile(filepath.Join(dest, "web", "lib", "lib.go"))).To(BeEquivalentTo("package lib"))
+		Expect(filepath.Join(dest, "api")).NotTo(BeAnExistingFile())
+	})
+
+	It("leaves the submodules out unless asked", func() {
+		lib := gitRepo(map[string]string{"lib.go": "package lib"}, nil)
+		repo := gitRepo(map[string]string{"README": "hello"}, map[string]string{"lib": "file://" + lib})
+
+		dest := GinkgoT().TempDir()
+		_, err := Git(context.Background(), GitOptions{URL: "file://" + repo, Dest: dest})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(dest, "lib", "lib.go")).NotTo(BeAnExistingFile())
+	})
+
+	It("pulls the LFS objects of the checked out files", func() {
+		Expect(gitCommands(GitOptions{URL: "https://example.com/repo.git", Dest: "/workspace", Submodules: true, LFS: true, SparsePaths: []string{"web", "lib"}})).To(Equal([][]string{
+			{"clone", "--depth=1", "--single-branch", "--sparse", "--filter=blob:none", "--", "https://example.com/repo.git", "/workspace"},
+			{"-C", "/workspace", "sparse-checkout", "set", "--cone", "--", "web", "lib"},
+			{"-C", "/workspace", "submodule", "update", "--init", "--recursive", "--depth=1", "--", "web", "lib"},
+			{"-C", "/workspace", "lfs", "pull", "--include=web,lib"},
+			{"-C", "/workspace", "submodule", "foreach", "--recursive", "git lfs pull"},
+		}))
+	})
+
+	It("downloads the submodules and LFS objects in parallel", func() {
+		Expect(gitCommands(GitOptions{URL: "https://example.com/repo.git", Dest: "/workspace", Submodules: true, LFS: true, Parallelism: 8})).To(Equal([][]string{
+			{"clone", "--depth=1", "--single-branch", "--", "https://example.com/repo.git", "/workspace"},
+			{"-C", "/workspace", "submodule", "update", "--init", "--recursive", "--depth=1", "--jobs=8"},
+			{"-c", "lfs.concurrenttransfers=8", "-C", "/workspace", "lfs", "pull"},
+			{"-c", "lfs.concurrenttransfers=8", "-C", "/workspace", "submodule", "foreach", "--recursive", "git lfs pull"},
+		}))
+	})
+
+	It("reports the errors of git", func() {
+		_, err := Git(context.Background(), GitOptions{URL: "file:///nonexistent", Dest: GinkgoT().TempDir()})
+		Expect(err).To(MatchError(ContainSubstring("git clone")))
+	})
+})
@@ -0,0 +1,193 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fetch implements the source fetching performed by the fetch init
+// container of build Jobs.
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// HTTPOptions configures an HTTP fetch.
+type HTTPOptions struct {
+	// URL of the archive to download
+	URL string
+	// Dest is the directory the archive is extracted into
+	Dest string
+	// CacheDir, if set, keeps the downloaded archive between builds. Subsequent
+	// downloads are conditional on the cached ETag/Last-Modified values.
+	CacheDir string
+
+	// Username and Password enable basic authentication
+	Username string
+	Password string
+	// Token enables bearer authentication, it takes precedence over basic authentication
+	Token string
+
+	// BandwidthLimit caps the download rate, in bytes per second. Unlimited when zero.
+	BandwidthLimit int64
+
+	Client *http.Client
+}
+
+// cacheMeta is stored next to a cached archive.
+type cacheMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// Result describes a completed fetch.
+type Result struct {
+	// Cached is true when a previously downloaded archive was reused
+	Cached bool `json:"cached,omitempty"`
+	// Revision immutably identifies the fetched source
+	Revision string `json:"revision"`
+	// Mirror is the URI of the internal copy of the source, when sources are mirrored
+	Mirror string `json:"mirror,omitempty"`
+}
+
+// HTTP downloads the archive at opts.URL and extracts it into opts.Dest.
+// The revision of an HTTP source is the sha256 digest of the archive.
+func HTTP(ctx context.Context, opts HTTPOptions) (*Result, error) {
+	archive, metaPath, cleanup, err := archivePaths(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case opts.Token != "":
+		req.Header.Set("Authorization", "Bearer "+opts.Token)
+	case opts.Username != "":
+		req.SetBasicAuth(opts.Username, opts.Password)
+	}
+
+	var meta cacheMeta
+	if metaPath != "" {
+		if _, err := os.Stat(archive); err == nil {
+			if raw, err := os.ReadFile(metaPath); err == nil && json.Unmarshal(raw, &meta) == nil {
+				if meta.ETag != "" {
+					req.Header.Set("If-None-Match", meta.ETag)
+				}
+				if meta.LastModified != "" {
+					req.Header.Set("If-Modified-Since", meta.LastModified)
+				}
+			}
+		}
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	cached := false
+	switch {
+	case resp.StatusCode == http.StatusNotModified && metaPath != "":
+		cached = true
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		if err := writeFile(archive, throttle(ctx, resp.Body, opts.BandwidthLimit)); err != nil {
+			return nil, err
+		}
+		if metaPath != "" {
+			raw, _ := json.Marshal(cacheMeta{
+				ETag:         resp.Header.Get("ETag"),
+				LastModified: resp.Header.Get("Last-Modified"),
+			})
+			if err := os.WriteFile(metaPath, raw, 0o644); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("downloading %s: unexpected status %q", opts.URL, resp.Status)
+	}
+
+	revision, err := fileDigest(archive)
+	if err != nil {
+		return nil, err
+	}
+	if err := Extract(archive, opts.Dest); err != nil {
+		return nil, err
+	}
+	return &Result{Cached: cached, Revision: revision}, nil
+}
+
+// fileDigest returns the sha256 digest of the file at path.
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// archivePaths returns where the archive and its cache metadata are stored.
+// Without a cache directory the archive goes to a temporary file removed by cleanup.
+func archivePaths(opts HTTPOptions) (archive, meta string, cleanup func(), err error) {
+	if opts.CacheDir == "" {
+		dir, err := os.MkdirTemp("", "fetch")
+		if err != nil {
+			return "", "", nil, err
+		}
+		return filepath.Join(dir, "archive"), "", func() { _ = os.RemoveAll(dir) }, nil
+	}
+	if err := os.MkdirAll(opts.CacheDir, 0o755); err != nil {
+		return "", "", nil, err
+	}
+	sum := sha256.Sum256([]byte(opts.URL))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(opts.CacheDir, key+".archive"), filepath.Join(opts.CacheDir, key+".json"), func() {}, nil
+}
+
+// writeFile atomically replaces path with the content of r.
+func writeFile(path string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
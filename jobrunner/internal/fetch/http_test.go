@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func tarGz(files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+		_, err := tw.Write([]byte(content))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+	Expect(gz.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func zipArchive(files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = w.Write([]byte(content))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(zw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+var _ = Describe("HTTP fetch", func() {
+	var (
+		server    *httptest.Server
+		body      []byte
+		downloads int
+		lastAuth  string
+	)
+
+	BeforeEach(func() {
+		downloads = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lastAuth = r.Header.Get("Authorization")
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			downloads++
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write(body)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("extracts tarballs and reuses the cache when the ETag matches", func() {
+		body = tarGz(map[string]string{"src/main.go": "package main"})
+		cache := GinkgoT().TempDir()
+
+		for i := 0; i < 2; i++ {
+			dest := GinkgoT().TempDir()
+			result, err := HTTP(context.Background(), HTTPOptions{URL: server.URL, Dest: dest, CacheDir: cache, Token: "secret"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Cached).To(Equal(i == 1))
+			Expect(result.Revision).To(HavePrefix("sha256:"))
+			Expect(os.ReadFile(filepath.Join(dest, "src", "main.go"))).To(BeEquivalentTo("package main"))
+		}
+		Expect(downloads).To(Equal(1))
+		Expect(lastAuth).To(Equal("Bearer secret"))
+	})
+
+	It("extracts zip archives", func() {
+		body = zipArchive(map[string]string{"README": "hello"})
+		dest := GinkgoT().TempDir()
+		_, err := HTTP(context.Background(), HTTPOptions{URL: server.URL, Dest: dest, Username: "u", Password: "p"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(filepath.Join(dest, "README"))).To(BeEquivalentTo("hello"))
+		Expect(lastAuth).To(HavePrefix("Basic "))
+	})
+
+	It("refuses entries escaping the destination", func() {
+		body = tarGz(map[string]string{"../evil": "x"})
+		_, err := HTTP(context.Background(), HTTPOptions{URL: server.URL, Dest: GinkgoT().TempDir()})
+		Expect(err).To(MatchError(ContainSubstring("escapes the destination")))
+	})
+
+	It("rejects unknown formats", func() {
+		_, err := DetectFormat([]byte("just some text"))
+		Expect(err).To(HaveOccurred())
+	})
+})
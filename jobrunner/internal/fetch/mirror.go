@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"test.jcrs.dev/jobrunner/internal/archive"
+)
+
+// mirrorRevisionHeader is the metadata of mirrored sources recording their revision
+const mirrorRevisionHeader = "X-Amz-Meta-Revision"
+
+// MirrorOptions configures the mirror of fetched sources.
+type MirrorOptions struct {
+	// Store writes to the bucket sources are mirrored to
+	Store *archive.S3Store
+	// Key of the object the source is mirrored to
+	Key string
+	// BandwidthLimit caps the rate the source is restored at, in bytes per
+	// second. Unlimited when zero.
+	BandwidthLimit int64
+}
+
+// URI returns the s3 URI of the mirrored source.
+func (opts MirrorOptions) URI() string {
+	return "s3://" + opts.Store.Bucket + "/" + opts.Key
+}
+
+// FromMirror extracts the source mirrored at opts.Key into dest, and returns
+// the revision of the source it was mirrored from. It returns nil when the
+// source hasn't been mirrored yet.
+func FromMirror(ctx context.Context, opts MirrorOptions, dest string) (*Result, error) {
+	dir, err := os.MkdirTemp("", "mirror")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "archive")
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	header, err := opts.Store.Get(ctx, opts.Key, throttleWriter(ctx, f, opts.BandwidthLimit))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if errors.Is(err, archive.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := Extract(path, dest); err != nil {
+		return nil, err
+	}
+	return &Result{Revision: header.Get(mirrorRevisionHeader), Mirror: opts.URI()}, nil
+}
+
+// Mirror writes the source fetched into dest to opts.Key as a gzipped tar, and
+// records its URI in result.
+func Mirror(ctx context.Context, opts MirrorOptions, dest string, result *Result) error {
+	var buf bytes.Buffer
+	if err := writeTarGz(&buf, dest); err != nil {
+		return err
+	}
+	header := http.Header{
+		"Content-Type":       []string{"application/gzip"},
+		mirrorRevisionHeader: []string{result.Revision},
+	}
+	if _, err := opts.Store.PutObject(ctx, opts.Key, header, buf.Bytes()); err != nil {
+		return err
+	}
+	result.Mirror = opts.URI()
+	return nil
+}
+
+// writeTarGz writes the directories, regular files and symlinks under dir to w
+// as a gzipped tar, with paths relative to dir.
+func writeTarGz(w io.Writer, dir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		var link string
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case !info.IsDir() && !info.Mode().IsRegular():
+			return nil
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"test.jcrs.dev/jobrunner/internal/archive"
+)
+
+var _ = Describe("Source mirror", func() {
+	var (
+		mirror MirrorOptions
+		mu     sync.Mutex
+		bucket map[string][]byte
+		meta   map[string]string
+	)
+
+	BeforeEach(func() {
+		bucket, meta = map[string][]byte{}, map[string]string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch r.Method {
+			case http.MethodPut:
+				data, _ := io.ReadAll(r.Body)
+				bucket[r.URL.Path], meta[r.URL.Path] = data, r.Header.Get(mirrorRevisionHeader)
+			case http.MethodGet:
+				data, ok := bucket[r.URL.Path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set(mirrorRevisionHeader, meta[r.URL.Path])
+				_, _ = w.Write(data)
+			}
+		}))
+		DeferCleanup(server.Close)
+		mirror = MirrorOptions{
+			Store: archive.NewS3Store(server.URL, "sources", "auto", "key", "secret", time.Second),
+			Key:   "default/web/uid/0123456789abcdef.tar.gz",
+		}
+	})
+
+	It("restores the source it mirrored", func() {
+		result, err := FromMirror(context.Background(), mirror, GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(BeNil())
+
+		src := GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(src, "cmd"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(src, "cmd", "main.go"), []byte("package main"), 0o644)).To(Succeed())
+		Expect(os.Symlink("cmd/main.go", filepath.Join(src, "main.go"))).To(Succeed())
+		fetched := &Result{Revision: "sha256:abc"}
+		Expect(Mirror(context.Background(), mirror, src, fetched)).To(Succeed())
+		Expect(fetched.Mirror).To(Equal("s3://sources/default/web/uid/0123456789abcdef.tar.gz"))
+		Expect(bytes.HasPrefix(bucket["/sources/"+mirror.Key], []byte{0x1f, 0x8b})).To(BeTrue())
+
+		dest := GinkgoT().TempDir()
+		result, err = FromMirror(context.Background(), mirror, dest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(&Result{Revision: "sha256:abc", Mirror: fetched.Mirror}))
+		Expect(os.ReadFile(filepath.Join(dest, "cmd", "main.go"))).To(Equal([]byte("package main")))
+		Expect(os.Readlink(filepath.Join(dest, "main.go"))).To(Equal("cmd/main.go"))
+	})
+})
@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// maxThrottleBurst bounds the bytes read at once from a throttled reader, so
+// that low limits are spread over the second rather than spent at its start.
+const maxThrottleBurst = 64 << 10
+
+// throttledReader reads from r at the rate of limiter.
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// throttle returns a reader of r reading at most bytesPerSecond bytes per
+// second, or r itself when bytesPerSecond isn't positive.
+func throttle(ctx context.Context, r io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return r
+	}
+	burst := int(min(bytesPerSecond, maxThrottleBurst))
+	return &throttledReader{ctx: ctx, r: r, limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burst)}
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > t.limiter.Burst() {
+		p = p[:t.limiter.Burst()]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if waitErr := t.limiter.WaitN(t.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// throttledWriter writes to w at the rate of limiter.
+type throttledWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	limiter *rate.Limiter
+}
+
+// throttleWriter returns a writer to w writing at most bytesPerSecond bytes per
+// second, or w itself when bytesPerSecond isn't positive.
+func throttleWriter(ctx context.Context, w io.Writer, bytesPerSecond int64) io.Writer {
+	if bytesPerSecond <= 0 {
+		return w
+	}
+	burst := int(min(bytesPerSecond, maxThrottleBurst))
+	return &throttledWriter{ctx: ctx, w: w, limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burst)}
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), t.limiter.Burst())]
+		if err := t.limiter.WaitN(t.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := t.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
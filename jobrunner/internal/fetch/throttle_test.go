@@ -0,0 +1,63 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Throttling", func() {
+	const limit = 10000
+	content := bytes.Repeat([]byte("x"), 2*limit)
+
+	It("reads at the bandwidth limit", func() {
+		start := time.Now()
+		read, err := io.ReadAll(throttle(context.Background(), bytes.NewReader(content), limit))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read).To(Equal(content))
+		// The first second worth of bytes is read right away
+		Expect(time.Since(start)).To(BeNumerically(">=", 900*time.Millisecond))
+	})
+
+	It("writes at the bandwidth limit", func() {
+		var buf bytes.Buffer
+		start := time.Now()
+		n, err := throttleWriter(context.Background(), &buf, limit).Write(content)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(len(content)))
+		Expect(buf.Bytes()).To(Equal(content))
+		Expect(time.Since(start)).To(BeNumerically(">=", 900*time.Millisecond))
+	})
+
+	It("stops waiting once the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := io.ReadAll(throttle(ctx, bytes.NewReader(content), limit))
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	It("doesn't limit unset limits", func() {
+		r := bytes.NewReader(content)
+		Expect(throttle(context.Background(), r, 0)).To(BeIdenticalTo(r))
+	})
+})
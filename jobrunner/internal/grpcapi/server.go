@@ -0,0 +1,394 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	buildsv1 "test.jcrs.dev/jobrunner/api/proto/builds/v1"
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/controller"
+)
+
+/*
+The gRPC API lets CI systems submit builds and follow them without talking to the
+API server themselves. Builds are submitted, read and cancelled as the user of
+the token of the caller, impersonated by the controller, so RBAC decides what a
+token may do to builds, and the webhook records that user as the one who
+triggered the builds it submits, which PackageOwnerships can name as publishers.
+The Jobs, pods and logs of a build readable as that user are then read with the
+permissions of the controller.
+
+Builds are submitted as manifests rather than as protobuf messages mirroring their
+spec, so that the API follows the CRDs without being regenerated, and accepts any
+served version of LeviathanBuild.
+*/
+
+// +kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+
+// logChunkSize is the maximum size of the LogChunks streamed by StreamLogs.
+const logChunkSize = 32 << 10
+
+// shutdownGracePeriod is how long in-flight requests are given to finish once
+// the manager stops, before log streams following pods are cut.
+const shutdownGracePeriod = 10 * time.Second
+
+// Server serves the BuildService gRPC API.
+type Server struct {
+	buildsv1.UnimplementedBuildServiceServer
+
+	// Client lists the Jobs and pods of builds.
+	Client client.Client
+	// UserClient returns the client creating, reading and deleting builds as the
+	// user of a token.
+	UserClient controller.UserClientFunc
+	// Clientset streams the logs of build pods.
+	Clientset kubernetes.Interface
+	// Scheme decodes the manifests of submitted builds.
+	Scheme *runtime.Scheme
+	// Tokens are the tokens accepted from callers.
+	Tokens Tokens
+	// Addr is the address the server listens on.
+	Addr string
+	// TLSConfig secures the connections of callers. They are served in
+	// plaintext when nil.
+	TLSConfig *tls.Config
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: every replica of
+// the manager serves the API.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable, serving the API until ctx is done.
+func (s *Server) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("grpc")
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	server := s.newGRPCServer()
+	if s.TLSConfig == nil {
+		log.Info("serving the gRPC API in plaintext, bearer tokens may be intercepted", "addr", s.Addr)
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownGracePeriod):
+		server.Stop()
+	}
+	return nil
+}
+
+// newGRPCServer returns a gRPC server serving s.
+func (s *Server) newGRPCServer() *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.Tokens.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.Tokens.streamInterceptor),
+	}
+	if s.TLSConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.TLSConfig)))
+	}
+	server := grpc.NewServer(opts...)
+	buildsv1.RegisterBuildServiceServer(server, s)
+	return server
+}
+
+// SubmitBuild implements buildsv1.BuildServiceServer.
+func (s *Server) SubmitBuild(ctx context.Context, req *buildsv1.SubmitBuildRequest) (*buildsv1.SubmitBuildResponse, error) {
+	if err := authorize(ctx, req.GetNamespace()); err != nil {
+		return nil, err
+	}
+	obj, gvk, err := serializer.NewCodecFactory(s.Scheme).UniversalDeserializer().Decode(req.GetManifest(), nil, nil)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "decoding the manifest: %v", err)
+	}
+	lvBuild, ok := obj.(client.Object)
+	if !ok || gvk.GroupKind() != jcrsv1.GroupVersion.WithKind("LeviathanBuild").GroupKind() {
+		return nil, status.Errorf(codes.InvalidArgument, "the manifest must be a LeviathanBuild, not a %s", gvk.Kind)
+	}
+	if namespace := lvBuild.GetNamespace(); namespace != "" && namespace != req.GetNamespace() {
+		return nil, status.Errorf(codes.InvalidArgument, "the manifest is of namespace %s, not %s", namespace, req.GetNamespace())
+	}
+	lvBuild.SetNamespace(req.GetNamespace())
+
+	c, err := s.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Create(ctx, lvBuild); err != nil {
+		return nil, toStatus(err)
+	}
+	logf.FromContext(ctx).Info("build submitted", "token", tokenName(ctx), "build", client.ObjectKeyFromObject(lvBuild))
+	return &buildsv1.SubmitBuildResponse{Build: &buildsv1.BuildRef{
+		Namespace: lvBuild.GetNamespace(),
+		Name:      lvBuild.GetName(),
+		Uid:       string(lvBuild.GetUID()),
+	}}, nil
+}
+
+// GetStatus implements buildsv1.BuildServiceServer.
+func (s *Server) GetStatus(ctx context.Context, req *buildsv1.GetStatusRequest) (*buildsv1.BuildStatus, error) {
+	lvBuild, err := s.getBuild(ctx, req.GetNamespace(), req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	buildStatus := &buildsv1.BuildStatus{
+		Build:           &buildsv1.BuildRef{Namespace: lvBuild.Namespace, Name: lvBuild.Name, Uid: string(lvBuild.UID)},
+		RunIndex:        lvBuild.Status.RunIndex,
+		PublishedDigest: lvBuild.Status.PublishedDigest,
+		SourceRevision:  lvBuild.Status.SourceRevision,
+	}
+	for _, condition := range lvBuild.Status.Conditions {
+		buildStatus.Conditions = append(buildStatus.Conditions, &buildsv1.Condition{
+			Type:               condition.Type,
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: timestamppb.New(condition.LastTransitionTime.Time),
+			ObservedGeneration: condition.ObservedGeneration,
+		})
+	}
+	for _, job := range lvBuild.Status.Active {
+		buildStatus.ActiveJobs = append(buildStatus.ActiveJobs, job.Name)
+	}
+	return buildStatus, nil
+}
+
+// StreamLogs implements buildsv1.BuildServiceServer. The logs of the containers
+// of the pods of the latest run are streamed concurrently, each chunk tagged
+// with its pod and container.
+func (s *Server) StreamLogs(req *buildsv1.StreamLogsRequest, stream grpc.ServerStreamingServer[buildsv1.LogChunk]) error {
+	ctx := stream.Context()
+	lvBuild, err := s.getBuild(ctx, req.GetNamespace(), req.GetName())
+	if err != nil {
+		return err
+	}
+	pods, err := s.runPods(ctx, lvBuild)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		sendLock sync.Mutex
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if req.GetContainer() != "" && container.Name != req.GetContainer() {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				send := func(data []byte) error {
+					sendLock.Lock()
+					defer sendLock.Unlock()
+					return stream.Send(&buildsv1.LogChunk{Pod: pod.Name, Container: container.Name, Data: data})
+				}
+				if err := s.streamContainerLogs(ctx, &pod, container.Name, req.GetFollow(), send); err != nil {
+					fail(err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// streamContainerLogs passes the logs of container of pod to send, in chunks.
+func (s *Server) streamContainerLogs(ctx context.Context, pod *corev1.Pod, container string, follow bool, send func([]byte) error) error {
+	logs, err := s.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: container,
+		Follow:    follow,
+	}).Stream(ctx)
+	if err != nil {
+		return toStatus(err)
+	}
+	defer func() { _ = logs.Close() }()
+
+	buf := make([]byte, logChunkSize)
+	for {
+		n, err := logs.Read(buf)
+		if n > 0 {
+			if err := send(append([]byte(nil), buf[:n]...)); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return toStatus(err)
+		}
+	}
+}
+
+// runPods returns the pods of the latest run of lvBuild.
+func (s *Server) runPods(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) ([]corev1.Pod, error) {
+	if lvBuild.Status.RunIndex == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "build %s hasn't run yet", lvBuild.Name)
+	}
+	var jobs batchv1.JobList
+	if err := s.Client.List(ctx, &jobs, client.InNamespace(lvBuild.Namespace),
+		client.MatchingLabels(controller.RunLabels(lvBuild, lvBuild.Status.RunIndex))); err != nil {
+		return nil, toStatus(err)
+	}
+	var pods []corev1.Pod
+	for _, job := range jobs.Items {
+		if !metav1.IsControlledBy(&job, lvBuild) {
+			continue
+		}
+		var jobPods corev1.PodList
+		if err := s.Client.List(ctx, &jobPods, client.InNamespace(lvBuild.Namespace),
+			client.MatchingLabels{batchv1.ControllerUidLabel: string(job.UID)}); err != nil {
+			return nil, toStatus(err)
+		}
+		pods = append(pods, jobPods.Items...)
+	}
+	if len(pods) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "run %d of build %s has no pods", lvBuild.Status.RunIndex, lvBuild.Name)
+	}
+	return pods, nil
+}
+
+// CancelBuild implements buildsv1.BuildServiceServer.
+func (s *Server) CancelBuild(ctx context.Context, req *buildsv1.CancelBuildRequest) (*buildsv1.CancelBuildResponse, error) {
+	if err := authorize(ctx, req.GetNamespace()); err != nil {
+		return nil, err
+	}
+	c, err := s.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lvBuild := &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Namespace: req.GetNamespace(), Name: req.GetName()}}
+	if err := c.Delete(ctx, lvBuild, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+		return nil, toStatus(err)
+	}
+	logf.FromContext(ctx).Info("build cancelled", "token", tokenName(ctx), "build", client.ObjectKeyFromObject(lvBuild))
+	return &buildsv1.CancelBuildResponse{}, nil
+}
+
+// getBuild returns the build named name in namespace, if the Token of ctx
+// allows it and its user may read it.
+func (s *Server) getBuild(ctx context.Context, namespace, name string) (*jcrsv1.LeviathanBuild, error) {
+	if err := authorize(ctx, namespace); err != nil {
+		return nil, err
+	}
+	c, err := s.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var lvBuild jcrsv1.LeviathanBuild
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &lvBuild); err != nil {
+		return nil, toStatus(err)
+	}
+	return &lvBuild, nil
+}
+
+// clientFor returns the client acting as the user of the Token of ctx.
+func (s *Server) clientFor(ctx context.Context) (client.Client, error) {
+	token, ok := ctx.Value(tokenKey{}).(*Token)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	c, err := s.UserClient(token.User)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "impersonating %s: %v", token.User, err)
+	}
+	return c, nil
+}
+
+// tokenName returns the name of the Token of ctx.
+func tokenName(ctx context.Context) string {
+	if token, ok := ctx.Value(tokenKey{}).(*Token); ok {
+		return token.Name
+	}
+	return ""
+}
+
+// toStatus converts an error of the API server into a gRPC status error.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := codes.Internal
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case apierrors.IsNotFound(err):
+		code = codes.NotFound
+	case apierrors.IsAlreadyExists(err):
+		code = codes.AlreadyExists
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		code = codes.InvalidArgument
+	case apierrors.IsForbidden(err):
+		code = codes.PermissionDenied
+	case apierrors.IsConflict(err):
+		code = codes.Aborted
+	case apierrors.IsTooManyRequests(err):
+		code = codes.ResourceExhausted
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), apierrors.IsServiceUnavailable(err):
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
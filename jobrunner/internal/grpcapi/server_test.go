@@ -0,0 +1,311 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	buildsv1 "test.jcrs.dev/jobrunner/api/proto/builds/v1"
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	jcrsv2 "test.jcrs.dev/jobrunner/api/v2"
+	"test.jcrs.dev/jobrunner/internal/controller"
+)
+
+const testTokens = `
+# CI of the team namespace
+ci secret team
+admin root * jane@example.com
+`
+
+var _ = Describe("Server", func() {
+	var (
+		k8sClient client.Client
+		users     []string
+		buildsAPI buildsv1.BuildServiceClient
+		lvBuild   *jcrsv1.LeviathanBuild
+		limit     rate.Limit
+	)
+
+	BeforeEach(func() {
+		limit = rate.Inf
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team", UID: "build-uid"},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("web")},
+		}
+	})
+
+	// serve starts the API over an in-memory connection, with objs in the cluster
+	serve := func(objs ...client.Object) {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(jcrsv2.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(lvBuild).Build()
+		tokens, err := ParseTokens(strings.NewReader(testTokens), limit, 1)
+		Expect(err).NotTo(HaveOccurred())
+		users = nil
+		server := (&Server{
+			Client: k8sClient,
+			UserClient: func(user string) (client.Client, error) {
+				users = append(users, user)
+				return k8sClient, nil
+			},
+			Clientset: clientsetfake.NewClientset(),
+			Scheme:    scheme,
+			Tokens:    tokens,
+		}).newGRPCServer()
+
+		listener := bufconn.Listen(1 << 20)
+		go func() { _ = server.Serve(listener) }()
+		DeferCleanup(server.Stop)
+		conn, err := grpc.NewClient("passthrough:///bufconn",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(conn.Close)
+		buildsAPI = buildsv1.NewBuildServiceClient(conn)
+	}
+
+	// as returns a context authenticating with token
+	as := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	// code returns the gRPC code of err
+	code := func(err error) codes.Code {
+		return status.Code(err)
+	}
+
+	It("authenticates callers with their token", func() {
+		serve(lvBuild)
+		_, err := buildsAPI.GetStatus(context.Background(), &buildsv1.GetStatusRequest{Namespace: "team", Name: "web"})
+		Expect(code(err)).To(Equal(codes.Unauthenticated))
+		_, err = buildsAPI.GetStatus(as("guess"), &buildsv1.GetStatusRequest{Namespace: "team", Name: "web"})
+		Expect(code(err)).To(Equal(codes.Unauthenticated))
+		Expect(buildsAPI.GetStatus(as("secret"), &buildsv1.GetStatusRequest{Namespace: "team", Name: "web"})).Error().NotTo(HaveOccurred())
+	})
+
+	It("restricts tokens to their namespaces", func() {
+		other := &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "other"}}
+		serve(lvBuild, other)
+		_, err := buildsAPI.GetStatus(as("secret"), &buildsv1.GetStatusRequest{Namespace: "other", Name: "api"})
+		Expect(code(err)).To(Equal(codes.PermissionDenied))
+		_, err = buildsAPI.CancelBuild(as("secret"), &buildsv1.CancelBuildRequest{Namespace: "other", Name: "api"})
+		Expect(code(err)).To(Equal(codes.PermissionDenied))
+		Expect(buildsAPI.GetStatus(as("root"), &buildsv1.GetStatusRequest{Namespace: "other", Name: "api"})).Error().NotTo(HaveOccurred())
+	})
+
+	It("rate limits every token", func() {
+		limit = rate.Every(time.Hour)
+		serve(lvBuild)
+		Expect(buildsAPI.GetStatus(as("secret"), &buildsv1.GetStatusRequest{Namespace: "team", Name: "web"})).Error().NotTo(HaveOccurred())
+		_, err := buildsAPI.GetStatus(as("secret"), &buildsv1.GetStatusRequest{Namespace: "team", Name: "web"})
+		Expect(code(err)).To(Equal(codes.ResourceExhausted))
+		Expect(buildsAPI.GetStatus(as("root"), &buildsv1.GetStatusRequest{Namespace: "team", Name: "web"})).Error().NotTo(HaveOccurred())
+	})
+
+	It("submits builds from their manifest", func() {
+		serve()
+		manifest := `
+apiVersion: jcrs.jcrs.dev/v1
+kind: LeviathanBuild
+metadata:
+  name: web
+spec:
+  packageName: web
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: build
+            image: busybox
+`
+		response, err := buildsAPI.SubmitBuild(as("secret"), &buildsv1.SubmitBuildRequest{Namespace: "team", Manifest: []byte(manifest)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.GetBuild().GetNamespace()).To(Equal("team"))
+		Expect(response.GetBuild().GetName()).To(Equal("web"))
+
+		var created jcrsv1.LeviathanBuild
+		Expect(k8sClient.Get(context.Background(), client.ObjectKey{Namespace: "team", Name: "web"}, &created)).To(Succeed())
+		Expect(created.Spec.JobTemplate.Spec.Template.Spec.Containers).To(HaveLen(1))
+
+		By("refusing to submit it twice")
+		_, err = buildsAPI.SubmitBuild(as("secret"), &buildsv1.SubmitBuildRequest{Namespace: "team", Manifest: []byte(manifest)})
+		Expect(code(err)).To(Equal(codes.AlreadyExists))
+
+		By("accepting manifests of other versions, in JSON")
+		response, err = buildsAPI.SubmitBuild(as("secret"), &buildsv1.SubmitBuildRequest{Namespace: "team", Manifest: []byte(
+			`{"apiVersion": "jcrs.jcrs.dev/v2", "kind": "LeviathanBuild", "metadata": {"name": "api"}, "spec": {"packageName": "api"}}`)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.GetBuild().GetName()).To(Equal("api"))
+	})
+
+	It("rejects manifests of other kinds or namespaces", func() {
+		serve()
+		_, err := buildsAPI.SubmitBuild(as("secret"), &buildsv1.SubmitBuildRequest{Namespace: "team", Manifest: []byte(
+			`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "web"}}`)})
+		Expect(code(err)).To(Equal(codes.InvalidArgument))
+		Expect(err).To(MatchError(ContainSubstring("the manifest must be a LeviathanBuild, not a ConfigMap")))
+
+		_, err = buildsAPI.SubmitBuild(as("secret"), &buildsv1.SubmitBuildRequest{Namespace: "team", Manifest: []byte(
+			`{"apiVersion": "jcrs.jcrs.dev/v1", "kind": "LeviathanBuild", "metadata": {"name": "web", "namespace": "other"}}`)})
+		Expect(code(err)).To(Equal(codes.InvalidArgument))
+		Expect(err).To(MatchError(ContainSubstring("the manifest is of namespace other, not team")))
+
+		_, err = buildsAPI.SubmitBuild(as("secret"), &buildsv1.SubmitBuildRequest{Namespace: "team", Manifest: []byte("{")})
+		Expect(code(err)).To(Equal(codes.InvalidArgument))
+	})
+
+	It("acts as the user of the token of the caller", func() {
+		serve(lvBuild)
+		Expect(buildsAPI.SubmitBuild(as("secret"), &buildsv1.SubmitBuildRequest{Namespace: "team", Manifest: []byte(
+			`{"apiVersion": "jcrs.jcrs.dev/v1", "kind": "LeviathanBuild", "metadata": {"name": "api"}, "spec": {"packageName": "api"}}`)})).
+			Error().NotTo(HaveOccurred())
+		Expect(buildsAPI.GetStatus(as("root"), &buildsv1.GetStatusRequest{Namespace: "team", Name: "web"})).Error().NotTo(HaveOccurred())
+		Expect(buildsAPI.CancelBuild(as("root"), &buildsv1.CancelBuildRequest{Namespace: "team", Name: "web"})).Error().NotTo(HaveOccurred())
+		Expect(users).To(Equal([]string{"jobrunner-grpc:ci", "jane@example.com", "jane@example.com"}))
+
+		By("returning the errors of the API server to the user")
+		k8sClient = interceptor.NewClient(k8sClient.(client.WithWatch), interceptor.Funcs{
+			Delete: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.DeleteOption) error {
+				return apierrors.NewForbidden(jcrsv1.GroupVersion.WithResource("leviathanbuilds").GroupResource(), obj.GetName(), errors.New("RBAC: access denied"))
+			},
+		})
+		_, err := buildsAPI.CancelBuild(as("secret"), &buildsv1.CancelBuildRequest{Namespace: "team", Name: "api"})
+		Expect(code(err)).To(Equal(codes.PermissionDenied))
+	})
+
+	It("returns the status of builds", func() {
+		lvBuild.Status = jcrsv1.LeviathanBuildStatus{
+			RunIndex:        2,
+			PublishedDigest: "sha256:abc",
+			Active:          []corev1.ObjectReference{{Name: "web-2-x7k2p"}},
+			Conditions: []metav1.Condition{{
+				Type: "Succeeded", Status: metav1.ConditionFalse, Reason: "Running", Message: "run 2 is running",
+				LastTransitionTime: metav1.Now(),
+			}},
+		}
+		serve(lvBuild)
+		buildStatus, err := buildsAPI.GetStatus(as("secret"), &buildsv1.GetStatusRequest{Namespace: "team", Name: "web"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buildStatus.GetBuild().GetUid()).To(Equal("build-uid"))
+		Expect(buildStatus.GetRunIndex()).To(BeEquivalentTo(2))
+		Expect(buildStatus.GetPublishedDigest()).To(Equal("sha256:abc"))
+		Expect(buildStatus.GetActiveJobs()).To(ConsistOf("web-2-x7k2p"))
+		Expect(buildStatus.GetConditions()).To(HaveLen(1))
+		Expect(buildStatus.GetConditions()[0].GetReason()).To(Equal("Running"))
+		Expect(buildStatus.GetConditions()[0].GetLastTransitionTime().AsTime()).To(BeTemporally("~", lvBuild.Status.Conditions[0].LastTransitionTime.Time, 1e9))
+
+		_, err = buildsAPI.GetStatus(as("secret"), &buildsv1.GetStatusRequest{Namespace: "team", Name: "missing"})
+		Expect(code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("streams the logs of the pods of the latest run", func() {
+		lvBuild.Status.RunIndex = 2
+		owner := metav1.OwnerReference{
+			APIVersion: jcrsv1.GroupVersion.String(), Kind: "LeviathanBuild", Name: "web", UID: "build-uid", Controller: ptr.To(true),
+		}
+		job := func(name string, runIndex int64) *batchv1.Job {
+			return &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "team", UID: types.UID("uid-" + name),
+				Labels: controller.RunLabels(lvBuild, runIndex), OwnerReferences: []metav1.OwnerReference{owner},
+			}}
+		}
+		pod := func(name, jobName string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team", Labels: map[string]string{batchv1.ControllerUidLabel: "uid-" + jobName}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "build"}, {Name: "heartbeat"}}},
+			}
+		}
+		serve(lvBuild, job("web-1", 1), job("web-2", 2), pod("web-1-a", "web-1"), pod("web-2-a", "web-2"))
+
+		// read returns the pods and containers of the chunks streamed for req
+		read := func(req *buildsv1.StreamLogsRequest) ([]string, error) {
+			stream, err := buildsAPI.StreamLogs(as("secret"), req)
+			Expect(err).NotTo(HaveOccurred())
+			var sources []string
+			for {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					return sources, nil
+				}
+				if err != nil {
+					return sources, err
+				}
+				Expect(chunk.GetData()).NotTo(BeEmpty())
+				sources = append(sources, chunk.GetPod()+"/"+chunk.GetContainer())
+			}
+		}
+		Expect(read(&buildsv1.StreamLogsRequest{Namespace: "team", Name: "web"})).To(ConsistOf("web-2-a/build", "web-2-a/heartbeat"))
+		Expect(read(&buildsv1.StreamLogsRequest{Namespace: "team", Name: "web", Container: "build"})).To(ConsistOf("web-2-a/build"))
+	})
+
+	It("refuses to stream the logs of builds that haven't run", func() {
+		serve(lvBuild)
+		stream, err := buildsAPI.StreamLogs(as("secret"), &buildsv1.StreamLogsRequest{Namespace: "team", Name: "web"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = stream.Recv()
+		Expect(code(err)).To(Equal(codes.FailedPrecondition))
+	})
+
+	It("cancels builds by deleting them", func() {
+		serve(lvBuild)
+		Expect(buildsAPI.CancelBuild(as("secret"), &buildsv1.CancelBuildRequest{Namespace: "team", Name: "web"})).Error().NotTo(HaveOccurred())
+		err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(lvBuild), &jcrsv1.LeviathanBuild{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		_, err = buildsAPI.CancelBuild(as("secret"), &buildsv1.CancelBuildRequest{Namespace: "team", Name: "web"})
+		Expect(code(err)).To(Equal(codes.NotFound))
+	})
+})
+
+var _ = Describe("ParseTokens", func() {
+	It("rejects malformed lines and reused tokens", func() {
+		_, err := ParseTokens(strings.NewReader("ci secret"), rate.Inf, 1)
+		Expect(err).To(MatchError("line 1: expected <name> <token> <namespaces> [<user>], got 2 fields"))
+		_, err = ParseTokens(strings.NewReader("ci secret team\nother secret team"), rate.Inf, 1)
+		Expect(err).To(MatchError("line 2: the token of other is already used"))
+	})
+})
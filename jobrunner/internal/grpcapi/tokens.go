@@ -0,0 +1,193 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcapi
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+/*
+CI systems authenticate with bearer tokens rather than Kubernetes credentials, so
+they don't need a kubeconfig nor RBAC bindings of their own. The tokens are read
+from a file, typically mounted from a Secret, holding one token per line:
+
+	<name> <token> <namespace>[,<namespace>...] [<user>]
+
+The name identifies the CI system in the logs, and the namespaces are the ones
+whose builds it may submit, read and cancel; "*" allows every namespace. Builds
+are submitted, read and cancelled as the user, which RBAC and the webhook see as
+the requester, "jobrunner-grpc:<name>" unless set. Blank lines and lines starting
+with # are ignored.
+
+Every token is rate limited on its own, so that a runaway pipeline can't starve
+the others, nor flood the API server through the controller.
+*/
+
+const (
+	// allNamespaces allows a token every namespace.
+	allNamespaces = "*"
+
+	// defaultUserPrefix prefixes the name of tokens to get the user they act as
+	// when the user isn't set.
+	defaultUserPrefix = "jobrunner-grpc:"
+)
+
+// Token authorizes a CI system to the builds of namespaces.
+type Token struct {
+	// Name identifies the CI system presenting the token.
+	Name string
+	// Namespaces are the namespaces whose builds the token allows.
+	Namespaces []string
+	// User is the user impersonated for the requests of the token.
+	User string
+
+	limiter *rate.Limiter
+}
+
+// allows returns whether t allows the builds of namespace.
+func (t *Token) allows(namespace string) bool {
+	return slices.Contains(t.Namespaces, allNamespaces) || slices.Contains(t.Namespaces, namespace)
+}
+
+// Tokens are the tokens accepted by the Server, by the SHA-256 digest of their
+// value.
+type Tokens map[[sha256.Size]byte]*Token
+
+// LoadTokens reads the tokens of the file at path, each allowed limit requests
+// per second with bursts of burst.
+func LoadTokens(path string, limit rate.Limit, burst int) (Tokens, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return ParseTokens(f, limit, burst)
+}
+
+// ParseTokens reads tokens from r, each allowed limit requests per second with
+// bursts of burst.
+func ParseTokens(r io.Reader, limit rate.Limit, burst int) (Tokens, error) {
+	tokens := make(Tokens)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 && len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected <name> <token> <namespaces> [<user>], got %d fields", line, len(fields))
+		}
+		user := defaultUserPrefix + fields[0]
+		if len(fields) == 4 {
+			user = fields[3]
+		}
+		digest := sha256.Sum256([]byte(fields[1]))
+		if _, ok := tokens[digest]; ok {
+			return nil, fmt.Errorf("line %d: the token of %s is already used", line, fields[0])
+		}
+		tokens[digest] = &Token{
+			Name:       fields[0],
+			Namespaces: strings.Split(fields[2], ","),
+			User:       user,
+			limiter:    rate.NewLimiter(limit, burst),
+		}
+	}
+	return tokens, scanner.Err()
+}
+
+// tokenKey is the context key of the Token of a request.
+type tokenKey struct{}
+
+// authenticate returns the Token of the bearer token in the metadata of ctx, or
+// an Unauthenticated error. Tokens exceeding their rate are refused with a
+// ResourceExhausted error.
+func (t Tokens) authenticate(ctx context.Context) (*Token, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var value string
+	for _, authorization := range md.Get("authorization") {
+		if bearer, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+			value = bearer
+		}
+	}
+	if value == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	// Looking the digest up rather than the token doesn't leak the token through timing
+	token, ok := t[sha256.Sum256([]byte(value))]
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+	if !token.limiter.Allow() {
+		return nil, status.Errorf(codes.ResourceExhausted, "rate limit of %s exceeded", token.Name)
+	}
+	return token, nil
+}
+
+// unaryInterceptor authenticates unary requests.
+func (t Tokens) unaryInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	token, err := t.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, tokenKey{}, token), req)
+}
+
+// streamInterceptor authenticates streaming requests.
+func (t Tokens) streamInterceptor(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	token, err := t.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: context.WithValue(stream.Context(), tokenKey{}, token)})
+}
+
+// authenticatedStream is a stream whose context holds its Token.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize returns a PermissionDenied error unless the Token of ctx allows the
+// builds of namespace.
+func authorize(ctx context.Context, namespace string) error {
+	if namespace == "" {
+		return status.Error(codes.InvalidArgument, "namespace is required")
+	}
+	token, ok := ctx.Value(tokenKey{}).(*Token)
+	if !ok || !token.allows(namespace) {
+		return status.Errorf(codes.PermissionDenied, "the token isn't allowed the builds of namespace %s", namespace)
+	}
+	return nil
+}
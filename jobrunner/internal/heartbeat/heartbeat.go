@@ -0,0 +1,86 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package heartbeat implements the heartbeat sidecar of builds. The build
+// containers touch a heartbeat file in a volume shared with the sidecar, and the
+// readiness probe of the sidecar fails once the file is older than the timeout of
+// the build, which the controller reads from the status of the sidecar.
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Init creates file, so the build has until the timeout for its first heartbeat.
+// An existing file is left as is, so a restarted sidecar doesn't hide a stalled
+// build.
+func Init(file string) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o666)
+	if errors.Is(err, os.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// The build containers may run as another user, they must be able to touch the file
+	return os.Chmod(file, 0o666)
+}
+
+// Check returns an error when file was last touched more than timeout ago.
+func Check(file string, timeout time.Duration, now time.Time) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if age := now.Sub(info.ModTime()); age > timeout {
+		return fmt.Errorf("no heartbeat for %s, longer than the timeout of %s", age.Round(time.Second), timeout)
+	}
+	return nil
+}
+
+// Wait returns once every file of done exists, checking every interval, or when
+// ctx is done. It only returns with ctx when done is empty.
+func Wait(ctx context.Context, done []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if len(done) > 0 && allExist(done) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// allExist reports whether every file of files exists.
+func allExist(files []string) bool {
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package heartbeat
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Heartbeat", func() {
+	var file string
+
+	BeforeEach(func() {
+		file = filepath.Join(GinkgoT().TempDir(), "heartbeat")
+	})
+
+	It("creates a heartbeat file the build can touch", func() {
+		Expect(Init(file)).To(Succeed())
+		info, err := os.Stat(file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o666)))
+		Expect(Check(file, time.Minute, time.Now())).To(Succeed())
+	})
+
+	It("leaves an existing heartbeat file as is", func() {
+		Expect(Init(file)).To(Succeed())
+		lastHeartbeat := time.Now().Add(-time.Hour)
+		Expect(os.Chtimes(file, lastHeartbeat, lastHeartbeat)).To(Succeed())
+
+		Expect(Init(file)).To(Succeed())
+		Expect(Check(file, 15*time.Minute, time.Now())).To(MatchError(ContainSubstring("no heartbeat for 1h0m0s")))
+		Expect(Check(file, 2*time.Hour, time.Now())).To(Succeed())
+	})
+
+	It("fails the check without a heartbeat file", func() {
+		Expect(Check(file, time.Minute, time.Now())).To(MatchError(os.ErrNotExist))
+	})
+
+	It("waits for the markers of the build containers", func() {
+		done := []string{file + ".build", file + ".test"}
+		for _, marker := range done {
+			Expect(os.WriteFile(marker, nil, 0o644)).To(Succeed())
+		}
+		Wait(context.Background(), done, time.Millisecond)
+
+		By("stopping with its context without markers")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Wait(ctx, nil, time.Millisecond)
+	})
+})
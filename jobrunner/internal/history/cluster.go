@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const (
+	// runIndexLabel is the label the controller records the run index of a Job in
+	runIndexLabel = "jcrs.jcrs.dev/run-index"
+
+	// pageSize is the number of Jobs listed at once, so that only the runs of a
+	// page of Jobs are held along the runs listed
+	pageSize = 500
+)
+
+// ClusterStore lists the runs kept in the cluster, the finished Jobs of builds.
+// The Jobs are the record of their run, so there is nothing to record, and runs
+// are only listed as long as their Job is kept.
+type ClusterStore struct {
+	Reader client.Reader
+}
+
+// Record implements Store. The Job of run already records it.
+func (s *ClusterStore) Record(context.Context, Run) error {
+	return nil
+}
+
+// List implements Store.
+func (s *ClusterStore) List(ctx context.Context, query Query) ([]Run, error) {
+	if s.Reader == nil {
+		return nil, errors.New("the cluster history store has no client")
+	}
+	var jobs batchv1.JobList
+	opts := []client.ListOption{client.HasLabels{runIndexLabel}, client.Limit(pageSize)}
+	if query.Namespace != "" {
+		opts = append(opts, client.InNamespace(query.Namespace))
+	}
+
+	var runs []Run
+	for {
+		if err := s.Reader.List(ctx, &jobs, append(opts, client.Continue(jobs.Continue))...); err != nil {
+			return nil, err
+		}
+		for i := range jobs.Items {
+			run, ok := jobRun(&jobs.Items[i])
+			if ok && (query.Build == "" || run.Build == query.Build) {
+				runs = append(runs, run)
+			}
+		}
+		if jobs.Continue == "" {
+			break
+		}
+	}
+	slices.SortFunc(runs, func(a, b Run) int {
+		return cmp.Or(b.CompletionTime.Compare(a.CompletionTime), cmp.Compare(b.Index, a.Index))
+	})
+	if query.Limit > 0 && len(runs) > query.Limit {
+		runs = runs[:query.Limit]
+	}
+	return runs, nil
+}
+
+// jobRun returns the run of a finished Job of a build.
+func jobRun(job *batchv1.Job) (Run, bool) {
+	owner := metav1.GetControllerOf(job)
+	if owner == nil || owner.Kind != "LeviathanBuild" {
+		return Run{}, false
+	}
+	if gv, err := schema.ParseGroupVersion(owner.APIVersion); err != nil || gv.Group != jcrsv1.GroupVersion.Group {
+		return Run{}, false
+	}
+	index, err := strconv.ParseInt(job.Labels[runIndexLabel], 10, 64)
+	if err != nil {
+		return Run{}, false
+	}
+	run := Run{Namespace: job.Namespace, Build: owner.Name, Index: index, Job: job.Name}
+	for _, c := range job.Status.Conditions {
+		switch {
+		case c.Status != corev1.ConditionTrue:
+		case c.Type == batchv1.JobComplete:
+			run.Outcome = Succeeded
+			run.CompletionTime = c.LastTransitionTime.UTC()
+		case c.Type == batchv1.JobFailed:
+			run.Outcome = Failed
+			run.CompletionTime = c.LastTransitionTime.UTC()
+		}
+	}
+	if run.Outcome == "" {
+		return Run{}, false
+	}
+	if job.Status.StartTime != nil {
+		run.StartTime = job.Status.StartTime.UTC()
+	}
+	if containers := job.Spec.Template.Spec.Containers; len(containers) > 0 {
+		run.BuilderImage = containers[0].Image
+	}
+	return run, true
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package history stores the runs of LeviathanBuilds. The runs kept in the
+// cluster are the Jobs of the builds; clusters where keeping thousands of them
+// is too heavy record the runs in an external store instead, typically a SQLite
+// or Postgres database served by a sidecar, and let the Jobs go.
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Outcome is how a run finished.
+type Outcome string
+
+const (
+	// Succeeded is the outcome of a run whose Job completed
+	Succeeded Outcome = "Succeeded"
+	// Failed is the outcome of a run whose Job failed
+	Failed Outcome = "Failed"
+)
+
+// Run is a finished run of a build.
+type Run struct {
+	Namespace string `json:"namespace"`
+	// Build is the name of the LeviathanBuild
+	Build string `json:"build"`
+	// Index is the run index of the build
+	Index int64 `json:"index"`
+	// Job is the name of the Job of the run
+	Job            string    `json:"job"`
+	Outcome        Outcome   `json:"outcome"`
+	StartTime      time.Time `json:"startTime"`
+	CompletionTime time.Time `json:"completionTime"`
+	// Revision is the source revision built, when known
+	Revision string `json:"revision,omitempty"`
+	// BuilderImage is the image of the build container
+	BuilderImage string `json:"builderImage,omitempty"`
+}
+
+// Query selects runs.
+type Query struct {
+	// Namespace selects the runs of a namespace, every namespace when empty
+	Namespace string
+	// Build selects the runs of a build of Namespace, every build when empty
+	Build string
+	// Limit is the maximum number of runs listed, all of them when zero
+	Limit int
+}
+
+// Store records the runs of builds and lists them.
+type Store interface {
+	// Record stores run. Recording a run again replaces it.
+	Record(ctx context.Context, run Run) error
+	// List returns the runs selected by query, the latest first.
+	List(ctx context.Context, query Query) ([]Run, error)
+}
+
+// Compaction is the outcome of the compaction of a store.
+type Compaction struct {
+	// Pruned is the number of runs pruned
+	Pruned int `json:"pruned"`
+	// ReclaimedBytes is the storage space reclaimed, when the store reports it
+	ReclaimedBytes int64 `json:"reclaimedBytes"`
+}
+
+// Compactor is implemented by the stores that keep runs until told to drop them.
+type Compactor interface {
+	// Compact prunes the runs that finished before cutoff, every run is kept when
+	// cutoff is zero, and reclaims the space they and previously pruned runs took.
+	Compact(ctx context.Context, cutoff time.Time) (Compaction, error)
+}
+
+// New returns the Store of storeURL: the Jobs read with reader when empty, or
+// the history server at the HTTP(S) URL.
+func New(storeURL string, reader client.Reader, timeout time.Duration) (Store, error) {
+	if storeURL == "" {
+		return &ClusterStore{Reader: reader}, nil
+	}
+	u, err := url.Parse(storeURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid history store URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported history store URL scheme %q", u.Scheme)
+	}
+	return &HTTPStore{Client: &http.Client{Timeout: timeout}, URL: strings.TrimSuffix(storeURL, "/")}, nil
+}
+
+// HTTPStore stores runs in a history server, usually a sidecar keeping them in
+// SQLite or Postgres. Runs are recorded by POSTing them as JSON to <URL>/runs,
+// which replaces the run of the same namespace, build and index, and listed by
+// GETting <URL>/runs with the namespace, build and limit query parameters.
+// The store is compacted by POSTing {"before": <RFC 3339 time>} to <URL>/compact,
+// which answers with the Compaction, e.g. after a VACUUM of its database.
+type HTTPStore struct {
+	Client *http.Client
+	// URL is the base URL of the history server
+	URL string
+}
+
+// Record implements Store.
+func (s *HTTPStore) Record(ctx context.Context, run Run) error {
+	body, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL+"/runs", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// List implements Store.
+func (s *HTTPStore) List(ctx context.Context, query Query) ([]Run, error) {
+	params := url.Values{}
+	if query.Namespace != "" {
+		params.Set("namespace", query.Namespace)
+	}
+	if query.Build != "" {
+		params.Set("build", query.Build)
+	}
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+	target := s.URL + "/runs"
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var runs []Run
+	if err := json.NewDecoder(resp.Body).Decode(&runs); err != nil {
+		return nil, fmt.Errorf("invalid response from history server: %w", err)
+	}
+	return runs, nil
+}
+
+// Compact implements Compactor.
+func (s *HTTPStore) Compact(ctx context.Context, cutoff time.Time) (Compaction, error) {
+	request := struct {
+		Before *time.Time `json:"before,omitempty"`
+	}{}
+	if !cutoff.IsZero() {
+		request.Before = &cutoff
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return Compaction{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL+"/compact", bytes.NewReader(body))
+	if err != nil {
+		return Compaction{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.do(req)
+	if err != nil {
+		return Compaction{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var compaction Compaction
+	if err := json.NewDecoder(resp.Body).Decode(&compaction); err != nil {
+		return Compaction{}, fmt.Errorf("invalid response from history server: %w", err)
+	}
+	return compaction, nil
+}
+
+// do sends req, and returns an error unless the response is a success.
+func (s *HTTPStore) do(req *http.Request) (*http.Response, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %q for %s %s: %s", resp.Status, req.Method, req.URL, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
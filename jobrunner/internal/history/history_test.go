@@ -0,0 +1,171 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Store", func() {
+	It("is selected by its URL", func() {
+		store, err := New("", nil, time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store).To(BeAssignableToTypeOf(&ClusterStore{}))
+
+		store, err = New("http://localhost:8090/", nil, time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store).To(HaveField("URL", "http://localhost:8090"))
+
+		_, err = New("postgres://history", nil, time.Second)
+		Expect(err).To(MatchError(ContainSubstring("unsupported history store URL scheme")))
+	})
+})
+
+var _ = Describe("HTTPStore", func() {
+	var (
+		server   *httptest.Server
+		recorded []Run
+		query    string
+		compact  string
+	)
+
+	BeforeEach(func() {
+		recorded = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch {
+			case req.URL.Path == "/compact" && req.Method == http.MethodPost:
+				body, _ := io.ReadAll(req.Body)
+				compact = string(body)
+				_, _ = w.Write([]byte(`{"pruned":3,"reclaimedBytes":4096}`))
+			case req.URL.Path != "/runs":
+				http.NotFound(w, req)
+			case req.Method == http.MethodPost:
+				var run Run
+				if err := json.NewDecoder(req.Body).Decode(&run); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				recorded = append(recorded, run)
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				query = req.URL.RawQuery
+				_ = json.NewEncoder(w).Encode(recorded)
+			}
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("records runs and lists them", func() {
+		store := &HTTPStore{Client: server.Client(), URL: server.URL}
+		run := Run{
+			Namespace: "default", Build: "web", Index: 2, Job: "web-2-abcde", Outcome: Succeeded,
+			StartTime: time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC), CompletionTime: time.Date(2025, 1, 2, 3, 9, 0, 0, time.UTC),
+		}
+		Expect(store.Record(context.Background(), run)).To(Succeed())
+		Expect(recorded).To(Equal([]Run{run}))
+
+		runs, err := store.List(context.Background(), Query{Namespace: "default", Build: "web", Limit: 5})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runs).To(Equal([]Run{run}))
+		Expect(query).To(Equal("build=web&limit=5&namespace=default"))
+	})
+
+	It("compacts the store", func() {
+		store := &HTTPStore{Client: server.Client(), URL: server.URL}
+		compaction, err := store.Compact(context.Background(), time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compaction).To(Equal(Compaction{Pruned: 3, ReclaimedBytes: 4096}))
+		Expect(compact).To(MatchJSON(`{"before":"2025-01-02T03:04:00Z"}`))
+
+		_, err = store.Compact(context.Background(), time.Time{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compact).To(MatchJSON(`{}`))
+	})
+
+	It("reports the failures of the server", func() {
+		store := &HTTPStore{Client: server.Client(), URL: server.URL + "/missing"}
+		Expect(store.Record(context.Background(), Run{})).To(MatchError(ContainSubstring("404")))
+	})
+})
+
+var _ = Describe("ClusterStore", func() {
+	It("lists the finished Jobs of builds, the latest first", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		started := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC))
+		job := func(name, build, index string, outcome batchv1.JobConditionType, finished time.Duration) *batchv1.Job {
+			j := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "default",
+				Labels: map[string]string{runIndexLabel: index},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: jcrsv1.GroupVersion.String(), Kind: "LeviathanBuild", Name: build, UID: "uid", Controller: ptr.To(true),
+				}},
+			}}
+			j.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build", Image: "builder:1.0"}}
+			j.Status.StartTime = &started
+			if outcome != "" {
+				j.Status.Conditions = []batchv1.JobCondition{{
+					Type: outcome, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(started.Add(finished)),
+				}}
+			}
+			return j
+		}
+		unowned := job("other", "web", "1", batchv1.JobComplete, time.Minute)
+		unowned.OwnerReferences = nil
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			job("web-1", "web", "1", batchv1.JobFailed, time.Minute),
+			job("web-2", "web", "2", batchv1.JobComplete, 2*time.Minute),
+			job("web-3", "web", "3", "", 0),
+			job("api-1", "api", "1", batchv1.JobComplete, 3*time.Minute),
+			unowned,
+		).Build()
+		store := &ClusterStore{Reader: c}
+
+		runs, err := store.List(context.Background(), Query{Namespace: "default", Build: "web"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runs).To(HaveExactElements(
+			HaveField("Job", "web-2"),
+			HaveField("Job", "web-1"),
+		))
+		Expect(runs[0]).To(Equal(Run{
+			Namespace: "default", Build: "web", Index: 2, Job: "web-2", Outcome: Succeeded,
+			StartTime: started.Time, CompletionTime: started.Add(2 * time.Minute), BuilderImage: "builder:1.0",
+		}))
+		Expect(runs[1].Outcome).To(Equal(Failed))
+
+		runs, err = store.List(context.Background(), Query{Limit: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runs).To(HaveExactElements(HaveField("Job", "api-1"), HaveField("Job", "web-2")))
+	})
+})
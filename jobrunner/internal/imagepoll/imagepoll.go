@@ -0,0 +1,230 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package imagepoll checks the builder images of builds for a new digest, so
+// that the builds know when the tag they run has moved, e.g. to a rebuilt base
+// image. Builds running the same image share its checks, and the builds are
+// only reconciled when its digest changes.
+package imagepoll
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/registry"
+)
+
+// tick is how often the poller looks for images due for a check.
+const tick = time.Second
+
+var imageChecksTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "jobrunner_builder_image_checks_total",
+		Help: "Number of checks of builder images for a new digest, by result (unchanged, changed or error)",
+	},
+	[]string{"result"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(imageChecksTotal)
+}
+
+// Poller checks the images watched by builds every Interval, and sends an event
+// for every build watching an image whose digest has changed.
+type Poller struct {
+	// Resolver resolves images to their digest
+	Resolver registry.DigestResolver
+	// Interval is how often an image is checked
+	Interval time.Duration
+
+	events chan event.GenericEvent
+
+	mu     sync.Mutex
+	images map[string]*imageState
+	builds map[types.NamespacedName]string
+
+	// now returns the current time, for tests
+	now func() time.Time
+}
+
+// imageState is what the poller knows of an image.
+type imageState struct {
+	builds  map[types.NamespacedName]bool
+	digest  string
+	checked bool
+	// checkedAt is when the image was last checked, successfully or not
+	checkedAt time.Time
+}
+
+// NewPoller returns a Poller checking images with resolver every interval.
+func NewPoller(resolver registry.DigestResolver, interval time.Duration) *Poller {
+	return &Poller{
+		Resolver: resolver,
+		Interval: interval,
+		events:   make(chan event.GenericEvent, 1024),
+		images:   make(map[string]*imageState),
+		builds:   make(map[types.NamespacedName]string),
+		now:      time.Now,
+	}
+}
+
+// Events returns the channel the builds watching a changed image are sent to,
+// as LeviathanBuilds with only their namespace and name.
+func (p *Poller) Events() <-chan event.GenericEvent {
+	return p.events
+}
+
+// due reports whether state is due for a check at now.
+func (p *Poller) due(state *imageState, now time.Time) bool {
+	return !now.Before(state.checkedAt.Add(p.Interval))
+}
+
+// Watch subscribes build to image, in place of the image it watched before if
+// any, and returns the latest digest of image. An image watched for the first
+// time is checked right away. The digest is empty until a check of the image
+// succeeds.
+func (p *Poller) Watch(ctx context.Context, build types.NamespacedName, image string) (string, error) {
+	p.mu.Lock()
+	p.forgetLocked(build, image)
+	state, ok := p.images[image]
+	if !ok {
+		state = &imageState{builds: make(map[types.NamespacedName]bool)}
+		p.images[image] = state
+	}
+	state.builds[build] = true
+	p.builds[build] = image
+	// An image that just failed its check is left to the next interval
+	known := state.checked || !p.due(state, p.now())
+	digest := state.digest
+	p.mu.Unlock()
+
+	if known {
+		return digest, nil
+	}
+	if err := p.check(ctx, image, false); err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return state.digest, nil
+}
+
+// Forget unsubscribes build from the image it watches.
+func (p *Poller) Forget(build types.NamespacedName) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.forgetLocked(build, "")
+}
+
+// forgetLocked unsubscribes build from the image it watches unless it is image,
+// and drops images no build watches anymore.
+func (p *Poller) forgetLocked(build types.NamespacedName, image string) {
+	previous, ok := p.builds[build]
+	if !ok || previous == image {
+		return
+	}
+	delete(p.builds, build)
+	if state, ok := p.images[previous]; ok {
+		delete(state.builds, build)
+		if len(state.builds) == 0 {
+			delete(p.images, previous)
+		}
+	}
+}
+
+// Start checks the images due for a check until ctx is done. It implements
+// manager.Runnable.
+func (p *Poller) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, p.pollDue, tick)
+	return nil
+}
+
+// pollDue checks the images whose interval has elapsed since their last check,
+// one at a time.
+func (p *Poller) pollDue(ctx context.Context) {
+	p.mu.Lock()
+	now := p.now()
+	var due []string
+	for image, state := range p.images {
+		if p.due(state, now) {
+			due = append(due, image)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, image := range due {
+		if err := p.check(ctx, image, true); err != nil {
+			logf.FromContext(ctx).Error(err, "Failed to check builder image", "image", image)
+		}
+	}
+}
+
+// check resolves image and, with notify, sends an event for the builds watching
+// it when its digest has changed. A failed check is retried at the next interval.
+func (p *Poller) check(ctx context.Context, image string, notify bool) error {
+	p.mu.Lock()
+	state, ok := p.images[image]
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	digest, err := p.Resolver.Digest(ctx, image)
+
+	p.mu.Lock()
+	state.checkedAt = p.now()
+	if err != nil {
+		p.mu.Unlock()
+		imageChecksTotal.WithLabelValues("error").Inc()
+		return err
+	}
+	changed := notify && digest != state.digest
+	state.digest, state.checked = digest, true
+	var builds []types.NamespacedName
+	if changed {
+		for build := range state.builds {
+			builds = append(builds, build)
+		}
+	}
+	p.mu.Unlock()
+
+	if !changed {
+		imageChecksTotal.WithLabelValues("unchanged").Inc()
+		return nil
+	}
+	imageChecksTotal.WithLabelValues("changed").Inc()
+	logf.FromContext(ctx).Info("Builder image changed", "image", image, "digest", digest, "builds", len(builds))
+	for _, build := range builds {
+		select {
+		case p.events <- event.GenericEvent{Object: &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: build.Name},
+		}}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
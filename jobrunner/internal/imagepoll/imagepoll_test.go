@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagepoll
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// fakeResolver returns digest, or err, and counts its resolutions.
+type fakeResolver struct {
+	digest      string
+	err         error
+	resolutions int
+}
+
+func (r *fakeResolver) Digest(_ context.Context, _ string) (string, error) {
+	r.resolutions++
+	return r.digest, r.err
+}
+
+var _ = Describe("Poller", func() {
+	const image = "ghcr.io/team/builder:1.22"
+
+	var (
+		ctx      context.Context
+		resolver *fakeResolver
+		poller   *Poller
+		now      time.Time
+		web      = types.NamespacedName{Namespace: "default", Name: "web"}
+		api      = types.NamespacedName{Namespace: "default", Name: "api"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		resolver = &fakeResolver{digest: "sha256:a1"}
+		poller = NewPoller(resolver, 10*time.Minute)
+		now = time.Now()
+		poller.now = func() time.Time { return now }
+	})
+
+	pending := func() []event.GenericEvent {
+		var events []event.GenericEvent
+		for len(poller.Events()) > 0 {
+			events = append(events, <-poller.Events())
+		}
+		return events
+	}
+
+	It("checks an image once for all the builds running it", func() {
+		Expect(poller.Watch(ctx, web, image)).To(Equal("sha256:a1"))
+		Expect(poller.Watch(ctx, api, image)).To(Equal("sha256:a1"))
+		Expect(resolver.resolutions).To(Equal(1))
+
+		By("leaving the image alone within the interval")
+		now = now.Add(5 * time.Minute)
+		poller.pollDue(ctx)
+		Expect(resolver.resolutions).To(Equal(1))
+
+		By("notifying the builds once the digest changes")
+		now = now.Add(5 * time.Minute)
+		resolver.digest = "sha256:b2"
+		poller.pollDue(ctx)
+		Expect(resolver.resolutions).To(Equal(2))
+		Expect(pending()).To(ConsistOf(
+			HaveField("Object.GetName()", "web"),
+			HaveField("Object.GetName()", "api"),
+		))
+		Expect(poller.Watch(ctx, web, image)).To(Equal("sha256:b2"))
+
+		By("not notifying them while it doesn't")
+		now = now.Add(10 * time.Minute)
+		poller.pollDue(ctx)
+		Expect(pending()).To(BeEmpty())
+	})
+
+	It("stops checking images no build runs anymore", func() {
+		Expect(poller.Watch(ctx, web, image)).To(Equal("sha256:a1"))
+		Expect(poller.Watch(ctx, web, "ghcr.io/team/builder:1.23")).To(Equal("sha256:a1"))
+		poller.Forget(web)
+		Expect(poller.images).To(BeEmpty())
+		Expect(poller.builds).To(BeEmpty())
+	})
+
+	It("retries failed checks at the next interval", func() {
+		resolver.err = errors.New("registry unavailable")
+		_, err := poller.Watch(ctx, web, image)
+		Expect(err).To(MatchError("registry unavailable"))
+		Expect(poller.Watch(ctx, web, image)).To(BeEmpty())
+		Expect(resolver.resolutions).To(Equal(1))
+
+		resolver.err = nil
+		now = now.Add(10 * time.Minute)
+		poller.pollDue(ctx)
+		Expect(poller.Watch(ctx, web, image)).To(Equal("sha256:a1"))
+	})
+})
@@ -0,0 +1,190 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interop
+
+import (
+	"encoding/json"
+	"slices"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+/*
+A Job becomes a Workflow of a single template, "build", running the pod of the
+Job. A pod of a single container becomes a container template, with the init
+containers of the pod; a pod of several containers becomes a container set, in
+which the init containers run one after the other before the containers. Native
+sidecars become the sidecars of the template. The fields of the pod a Workflow
+doesn't have are set through its podSpecPatch, so nothing is dropped, and the
+backoffLimit of the Job becomes the retry limit of the template.
+*/
+
+// argoTemplateName is the name of the single template of the Workflow.
+const argoTemplateName = "build"
+
+var (
+	// argoWorkflowFields are the fields of a pod a Workflow has, by the name of their field in a Workflow
+	argoWorkflowFields = map[string]string{
+		"nodeSelector": "nodeSelector", "tolerations": "tolerations", "affinity": "affinity",
+		"securityContext": "securityContext", "imagePullSecrets": "imagePullSecrets", "hostAliases": "hostAliases",
+		"hostNetwork": "hostNetwork", "dnsPolicy": "dnsPolicy", "dnsConfig": "dnsConfig",
+		"priorityClassName": "podPriorityClassName", "schedulerName": "schedulerName",
+		"automountServiceAccountToken": "automountServiceAccountToken", "serviceAccountName": "serviceAccountName",
+		"volumes": "volumes",
+	}
+	// argoTemplateFields are the fields of a pod set on the template of the Workflow
+	argoTemplateFields = []string{"containers", "initContainers", "restartPolicy"}
+)
+
+// ArgoConverter converts Jobs into Argo Workflows.
+type ArgoConverter struct{}
+
+var _ Converter = ArgoConverter{}
+
+// GroupVersionKind implements Converter.
+func (ArgoConverter) GroupVersionKind() schema.GroupVersionKind {
+	return WorkflowGVK
+}
+
+// Convert implements Converter.
+func (c ArgoConverter) Convert(job *batchv1.Job) (*unstructured.Unstructured, []string, error) {
+	podSpec := job.Spec.Template.Spec
+	warnings := jobWarnings(job)
+
+	template := map[string]any{"name": argoTemplateName}
+	nativeSidecars, initContainers := sidecars(&podSpec)
+	if len(podSpec.Containers) == 1 {
+		container, err := toMap(&podSpec.Containers[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		template["container"] = container
+		if len(initContainers) > 0 {
+			if template["initContainers"], err = toSlice(initContainers); err != nil {
+				return nil, nil, err
+			}
+		}
+	} else {
+		containerSet, err := argoContainerSet(initContainers, podSpec.Containers)
+		if err != nil {
+			return nil, nil, err
+		}
+		template["containerSet"] = containerSet
+	}
+	if len(nativeSidecars) > 0 {
+		var err error
+		if template["sidecars"], err = toSlice(nativeSidecars); err != nil {
+			return nil, nil, err
+		}
+	}
+	if job.Spec.BackoffLimit != nil && *job.Spec.BackoffLimit > 0 {
+		template["retryStrategy"] = map[string]any{"limit": int64(*job.Spec.BackoffLimit)}
+	}
+
+	spec := map[string]any{"entrypoint": argoTemplateName, "templates": []any{template}}
+	fields, err := toMap(&podSpec)
+	if err != nil {
+		return nil, nil, err
+	}
+	patch := map[string]any{}
+	for field, value := range fields {
+		if name, ok := argoWorkflowFields[field]; ok {
+			spec[name] = value
+		} else if !slices.Contains(argoTemplateFields, field) {
+			patch[field] = value
+		}
+	}
+	if len(patch) > 0 {
+		data, err := json.Marshal(patch)
+		if err != nil {
+			return nil, nil, err
+		}
+		spec["podSpecPatch"] = string(data)
+	}
+	if job.Spec.ActiveDeadlineSeconds != nil {
+		spec["activeDeadlineSeconds"] = *job.Spec.ActiveDeadlineSeconds
+	}
+	podMetadata := map[string]any{}
+	if len(job.Spec.Template.Labels) > 0 {
+		podMetadata["labels"] = stringMap(job.Spec.Template.Labels)
+	}
+	if len(job.Spec.Template.Annotations) > 0 {
+		podMetadata["annotations"] = stringMap(job.Spec.Template.Annotations)
+	}
+	if len(podMetadata) > 0 {
+		spec["podMetadata"] = podMetadata
+	}
+
+	obj := newObject(WorkflowGVK, job)
+	obj.Object["spec"] = spec
+	return obj, warnings, nil
+}
+
+// Finished implements Converter. A Workflow has finished once its phase is
+// Succeeded, Failed or Error.
+func (ArgoConverter) Finished(obj *unstructured.Unstructured) (bool, bool, string) {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	message, _, _ := unstructured.NestedString(obj.Object, "status", "message")
+	switch phase {
+	case "Succeeded":
+		return true, true, message
+	case "Failed", "Error":
+		return true, false, message
+	}
+	return false, false, ""
+}
+
+// argoContainerSet returns the container set running initContainers one after
+// the other, then containers.
+func argoContainerSet(initContainers, containers []corev1.Container) (map[string]any, error) {
+	var nodes []any
+	var previous string
+	for _, c := range initContainers {
+		node, err := toMap(&c)
+		if err != nil {
+			return nil, err
+		}
+		if previous != "" {
+			node["dependencies"] = []any{previous}
+		}
+		nodes = append(nodes, node)
+		previous = c.Name
+	}
+	for _, c := range containers {
+		node, err := toMap(&c)
+		if err != nil {
+			return nil, err
+		}
+		if previous != "" {
+			node["dependencies"] = []any{previous}
+		}
+		nodes = append(nodes, node)
+	}
+	return map[string]any{"containers": nodes}, nil
+}
+
+// stringMap returns m as the map of an unstructured object.
+func stringMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
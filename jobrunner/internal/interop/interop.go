@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package interop converts the Jobs of builds into the runs of other workflow
+// engines, Tekton PipelineRuns and Argo Workflows. It backs `leviathan export`,
+// for teams moving builds to or from those engines, and the execution backends
+// of the controller.
+//
+// The pod of a Job runs as a single task or template, so the containers keep
+// sharing their volumes. Each engine has its own model of a pod, what it
+// can't express is dropped and reported as a warning.
+package interop
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Format is an engine builds are converted for.
+type Format string
+
+const (
+	// Tekton converts a build into a Tekton PipelineRun
+	Tekton Format = "tekton"
+	// Argo converts a build into an Argo Workflow
+	Argo Format = "argo"
+)
+
+var (
+	// PipelineRunGVK is the kind of Tekton PipelineRuns
+	PipelineRunGVK = schema.GroupVersionKind{Group: "tekton.dev", Version: "v1", Kind: "PipelineRun"}
+	// WorkflowGVK is the kind of Argo Workflows
+	WorkflowGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Workflow"}
+)
+
+// Converter converts Jobs for an engine, and reads the outcome of the runs it
+// created.
+type Converter interface {
+	// GroupVersionKind is the kind of the objects of the engine
+	GroupVersionKind() schema.GroupVersionKind
+	// Convert returns the object running the pod of job, with the warnings about
+	// what it can't express.
+	Convert(job *batchv1.Job) (*unstructured.Unstructured, []string, error)
+	// Finished reports whether the run of obj has finished, whether it
+	// succeeded, and why it failed.
+	Finished(obj *unstructured.Unstructured) (finished, succeeded bool, message string)
+}
+
+// ForFormat returns the Converter of format.
+func ForFormat(format Format) (Converter, error) {
+	switch format {
+	case Tekton:
+		return TektonConverter{}, nil
+	case Argo:
+		return ArgoConverter{}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, must be %s or %s", format, Tekton, Argo)
+}
+
+// newObject returns an object of gvk with the metadata of job. Its name is
+// generated when job has none.
+func newObject(gvk schema.GroupVersionKind, job *batchv1.Job) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]any{}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(job.Namespace)
+	obj.SetName(job.Name)
+	obj.SetGenerateName(job.GenerateName)
+	obj.SetLabels(job.Labels)
+	obj.SetAnnotations(job.Annotations)
+	obj.SetOwnerReferences(job.OwnerReferences)
+	return obj
+}
+
+// toMap returns the fields of v, as set in JSON.
+func toMap(v any) (map[string]any, error) {
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(v)
+}
+
+// toSlice returns the fields of every item of items.
+func toSlice[T any](items []T) ([]any, error) {
+	slice := make([]any, 0, len(items))
+	for i := range items {
+		m, err := toMap(&items[i])
+		if err != nil {
+			return nil, err
+		}
+		slice = append(slice, m)
+	}
+	return slice, nil
+}
+
+// keep returns the fields of m named in fields, and the names of the other ones.
+func keep(m map[string]any, fields ...string) (map[string]any, []string) {
+	kept := make(map[string]any, len(m))
+	var dropped []string
+	for k, v := range m {
+		if slices.Contains(fields, k) {
+			kept[k] = v
+		} else {
+			dropped = append(dropped, k)
+		}
+	}
+	slices.Sort(dropped)
+	return kept, dropped
+}
+
+// sidecars splits the init containers of podSpec into native sidecars and the
+// others.
+func sidecars(podSpec *corev1.PodSpec) (sidecars, initContainers []corev1.Container) {
+	for _, c := range podSpec.InitContainers {
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			c.RestartPolicy = nil
+			sidecars = append(sidecars, c)
+		} else {
+			initContainers = append(initContainers, c)
+		}
+	}
+	return sidecars, initContainers
+}
+
+// droppedWarning describes the fields dropped from what.
+func droppedWarning(what string, dropped []string) []string {
+	if len(dropped) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: %s can't be converted, dropped", what, strings.Join(dropped, ", "))}
+}
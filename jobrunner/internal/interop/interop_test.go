@@ -0,0 +1,242 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interop
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("Interop", func() {
+	var job *batchv1.Job
+
+	BeforeEach(func() {
+		job = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ci", GenerateName: "web-3-",
+				Labels: map[string]string{"jcrs.jcrs.dev/build": "web"},
+			},
+			Spec: batchv1.JobSpec{
+				BackoffLimit:          ptr.To[int32](2),
+				ActiveDeadlineSeconds: ptr.To[int64](3600),
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "web"}},
+					Spec: corev1.PodSpec{
+						RestartPolicy:                 corev1.RestartPolicyNever,
+						ServiceAccountName:            "builder",
+						NodeSelector:                  map[string]string{"pool": "builds"},
+						TerminationGracePeriodSeconds: ptr.To[int64](5),
+						InitContainers: []corev1.Container{
+							{Name: "fetch", Image: "fetcher", Args: []string{"https://example.com/web.tgz"}},
+							{Name: "cache", Image: "redis:7", RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways),
+								Ports: []corev1.ContainerPort{{ContainerPort: 6379}}},
+						},
+						Containers: []corev1.Container{{
+							Name: "build", Image: "golang:1.24", Command: []string{"make"},
+							Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}},
+							ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+								Exec: &corev1.ExecAction{Command: []string{"true"}},
+							}},
+						}},
+						Volumes: []corev1.Volume{{Name: "source", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+					},
+				},
+			},
+		}
+	})
+
+	// fromYAML returns the fields of a manifest.
+	fromYAML := func(manifest string) map[string]any {
+		var obj map[string]any
+		Expect(yaml.Unmarshal([]byte(manifest), &obj)).To(Succeed())
+		return obj
+	}
+
+	// fields returns obj as decoded from its JSON.
+	fields := func(obj *unstructured.Unstructured) map[string]any {
+		data, err := json.Marshal(obj.DeepCopy())
+		Expect(err).NotTo(HaveOccurred())
+		var decoded map[string]any
+		Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+		return decoded
+	}
+
+	It("converts a Job into a Tekton PipelineRun", func() {
+		obj, warnings, err := TektonConverter{}.Convert(job)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields(obj)).To(Equal(fromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  namespace: ci
+  generateName: web-3-
+  labels:
+    jcrs.jcrs.dev/build: web
+    team: web
+spec:
+  pipelineSpec:
+    tasks:
+    - name: build
+      retries: 2
+      taskSpec:
+        steps:
+        - name: fetch
+          image: fetcher
+          args: [https://example.com/web.tgz]
+          computeResources: {}
+        - name: build
+          image: golang:1.24
+          command: [make]
+          computeResources:
+            limits:
+              cpu: "2"
+        sidecars:
+        - name: cache
+          image: redis:7
+          ports:
+          - containerPort: 6379
+          computeResources: {}
+        volumes:
+        - name: source
+          emptyDir: {}
+  taskRunTemplate:
+    serviceAccountName: builder
+    podTemplate:
+      nodeSelector:
+        pool: builds
+  timeouts:
+    pipeline: 3600s
+`)))
+		Expect(warnings).To(ConsistOf(
+			`step "build": readinessProbe can't be converted, dropped`,
+			"pod: terminationGracePeriodSeconds can't be converted, dropped",
+		))
+	})
+
+	It("converts a Job into an Argo Workflow", func() {
+		obj, warnings, err := ArgoConverter{}.Convert(job)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+		Expect(fields(obj)).To(Equal(fromYAML(`
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  namespace: ci
+  generateName: web-3-
+  labels:
+    jcrs.jcrs.dev/build: web
+spec:
+  entrypoint: build
+  serviceAccountName: builder
+  nodeSelector:
+    pool: builds
+  volumes:
+  - name: source
+    emptyDir: {}
+  activeDeadlineSeconds: 3600
+  podSpecPatch: '{"terminationGracePeriodSeconds":5}'
+  podMetadata:
+    labels:
+      team: web
+  templates:
+  - name: build
+    retryStrategy:
+      limit: 2
+    initContainers:
+    - name: fetch
+      image: fetcher
+      args: [https://example.com/web.tgz]
+      resources: {}
+    sidecars:
+    - name: cache
+      image: redis:7
+      ports:
+      - containerPort: 6379
+      resources: {}
+    container:
+      name: build
+      image: golang:1.24
+      command: [make]
+      resources:
+        limits:
+          cpu: "2"
+      readinessProbe:
+        exec:
+          command: ["true"]
+`)))
+	})
+
+	It("runs the containers of a pod side by side in an Argo container set", func() {
+		job.Spec.Template.Spec.Containers = append(job.Spec.Template.Spec.Containers, corev1.Container{Name: "lint", Image: "golangci-lint"})
+		job.Spec.Template.Spec.InitContainers = append(job.Spec.Template.Spec.InitContainers, corev1.Container{Name: "deps", Image: "golang:1.24"})
+		obj, _, err := ArgoConverter{}.Convert(job)
+		Expect(err).NotTo(HaveOccurred())
+		nodes, _, err := unstructured.NestedSlice(obj.Object, "spec", "templates")
+		Expect(err).NotTo(HaveOccurred())
+		containers := nodes[0].(map[string]any)["containerSet"].(map[string]any)["containers"].([]any)
+		Expect(containers).To(HaveLen(4))
+		dependencies := map[string]any{}
+		for _, c := range containers {
+			dependencies[c.(map[string]any)["name"].(string)] = c.(map[string]any)["dependencies"]
+		}
+		Expect(dependencies).To(Equal(map[string]any{
+			"fetch": nil, "deps": []any{"fetch"}, "build": []any{"deps"}, "lint": []any{"deps"},
+		}))
+	})
+
+	It("warns about the Job fields no engine has", func() {
+		job.Spec.Completions = ptr.To[int32](3)
+		job.Spec.CompletionMode = ptr.To(batchv1.IndexedCompletion)
+		for _, format := range []Format{Tekton, Argo} {
+			converter, err := ForFormat(format)
+			Expect(err).NotTo(HaveOccurred())
+			_, warnings, err := converter.Convert(job)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElements(
+				"completions and parallelism can't be converted, a single pod runs",
+				"completionMode Indexed can't be converted, JOB_COMPLETION_INDEX isn't set",
+			))
+		}
+		_, err := ForFormat("jenkins")
+		Expect(err).To(MatchError(`unknown format "jenkins", must be tekton or argo`))
+	})
+
+	DescribeTable("reads the outcome of runs",
+		func(converter Converter, status string, finished, succeeded bool, message string) {
+			obj := &unstructured.Unstructured{Object: fromYAML("status: " + status)}
+			f, s, m := converter.Finished(obj)
+			Expect([]any{f, s, m}).To(Equal([]any{finished, succeeded, message}))
+		},
+		Entry("running PipelineRun", TektonConverter{}, `{conditions: [{type: Succeeded, status: Unknown, message: running}]}`, false, false, ""),
+		Entry("succeeded PipelineRun", TektonConverter{}, `{conditions: [{type: Succeeded, status: "True", message: done}]}`, true, true, "done"),
+		Entry("failed PipelineRun", TektonConverter{}, `{conditions: [{type: Succeeded, status: "False", message: step failed}]}`, true, false, "step failed"),
+		Entry("new PipelineRun", TektonConverter{}, `{}`, false, false, ""),
+		Entry("running Workflow", ArgoConverter{}, `{phase: Running}`, false, false, ""),
+		Entry("succeeded Workflow", ArgoConverter{}, `{phase: Succeeded}`, true, true, ""),
+		Entry("failed Workflow", ArgoConverter{}, `{phase: Failed, message: child failed}`, true, false, "child failed"),
+		Entry("errored Workflow", ArgoConverter{}, `{phase: Error, message: pod deleted}`, true, false, "pod deleted"),
+	)
+})
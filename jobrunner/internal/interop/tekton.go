@@ -0,0 +1,203 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interop
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+/*
+A Job becomes a PipelineRun of an inline pipeline of a single task, "build".
+The steps of the task are the init containers of the pod, then its containers:
+Tekton runs steps one after the other, so containers meant to run side by side
+should be sidecars. Native sidecars become the sidecars of the task. The fields
+of the pod Tekton sets on the pods of TaskRuns are set through the pod template
+of the task runs, and the backoffLimit of the Job becomes the retries of the task.
+*/
+
+// tektonTaskName is the name of the single task of the pipeline.
+const tektonTaskName = "build"
+
+var (
+	// stepFields are the fields of a container a Tekton step has
+	stepFields = []string{"name", "image", "command", "args", "workingDir", "env", "envFrom", "resources",
+		"volumeMounts", "volumeDevices", "imagePullPolicy", "securityContext"}
+	// sidecarFields are the fields of a container a Tekton sidecar has
+	sidecarFields = append(slices.Clone(stepFields), "ports", "livenessProbe", "readinessProbe", "startupProbe",
+		"lifecycle", "terminationMessagePath", "terminationMessagePolicy", "stdin", "stdinOnce", "tty")
+	// tektonPodTemplateFields are the fields of a pod the pod template of a TaskRun has
+	tektonPodTemplateFields = []string{"nodeSelector", "tolerations", "affinity", "securityContext", "imagePullSecrets",
+		"hostAliases", "hostNetwork", "dnsPolicy", "dnsConfig", "priorityClassName", "schedulerName",
+		"runtimeClassName", "automountServiceAccountToken", "enableServiceLinks", "topologySpreadConstraints"}
+	// tektonTaskFields are the fields of a pod set elsewhere on the PipelineRun
+	tektonTaskFields = []string{"containers", "initContainers", "volumes", "serviceAccountName", "restartPolicy"}
+)
+
+// TektonConverter converts Jobs into Tekton PipelineRuns.
+type TektonConverter struct{}
+
+var _ Converter = TektonConverter{}
+
+// GroupVersionKind implements Converter.
+func (TektonConverter) GroupVersionKind() schema.GroupVersionKind {
+	return PipelineRunGVK
+}
+
+// Convert implements Converter.
+func (c TektonConverter) Convert(job *batchv1.Job) (*unstructured.Unstructured, []string, error) {
+	podSpec := job.Spec.Template.Spec
+	warnings := jobWarnings(job)
+
+	nativeSidecars, initContainers := sidecars(&podSpec)
+	var steps []any
+	for _, container := range append(initContainers, podSpec.Containers...) {
+		step, dropped, err := tektonContainer(&container, stepFields)
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings = append(warnings, droppedWarning(fmt.Sprintf("step %q", container.Name), dropped)...)
+		steps = append(steps, step)
+	}
+	taskSpec := map[string]any{"steps": steps}
+	if len(nativeSidecars) > 0 {
+		var taskSidecars []any
+		for _, container := range nativeSidecars {
+			sidecar, dropped, err := tektonContainer(&container, sidecarFields)
+			if err != nil {
+				return nil, nil, err
+			}
+			warnings = append(warnings, droppedWarning(fmt.Sprintf("sidecar %q", container.Name), dropped)...)
+			taskSidecars = append(taskSidecars, sidecar)
+		}
+		taskSpec["sidecars"] = taskSidecars
+	}
+	if len(podSpec.Volumes) > 0 {
+		volumes, err := toSlice(podSpec.Volumes)
+		if err != nil {
+			return nil, nil, err
+		}
+		taskSpec["volumes"] = volumes
+	}
+	task := map[string]any{"name": tektonTaskName, "taskSpec": taskSpec}
+	if job.Spec.BackoffLimit != nil && *job.Spec.BackoffLimit > 0 {
+		task["retries"] = int64(*job.Spec.BackoffLimit)
+	}
+
+	spec := map[string]any{"pipelineSpec": map[string]any{"tasks": []any{task}}}
+	fields, err := toMap(&podSpec)
+	if err != nil {
+		return nil, nil, err
+	}
+	podTemplate, dropped := keep(fields, append(tektonPodTemplateFields, tektonTaskFields...)...)
+	warnings = append(warnings, droppedWarning("pod", dropped)...)
+	for _, field := range tektonTaskFields {
+		delete(podTemplate, field)
+	}
+	taskRunTemplate := map[string]any{}
+	if len(podTemplate) > 0 {
+		taskRunTemplate["podTemplate"] = podTemplate
+	}
+	if podSpec.ServiceAccountName != "" {
+		taskRunTemplate["serviceAccountName"] = podSpec.ServiceAccountName
+	}
+	if len(taskRunTemplate) > 0 {
+		spec["taskRunTemplate"] = taskRunTemplate
+	}
+	if job.Spec.ActiveDeadlineSeconds != nil {
+		spec["timeouts"] = map[string]any{"pipeline": fmt.Sprintf("%ds", *job.Spec.ActiveDeadlineSeconds)}
+	}
+
+	obj := newObject(PipelineRunGVK, job)
+	// Tekton propagates the labels and annotations of PipelineRuns to their pods
+	obj.SetLabels(merge(job.Labels, job.Spec.Template.Labels))
+	obj.SetAnnotations(merge(job.Annotations, job.Spec.Template.Annotations))
+	obj.Object["spec"] = spec
+	return obj, warnings, nil
+}
+
+// Finished implements Converter. A PipelineRun has finished once its Succeeded
+// condition is no longer Unknown.
+func (TektonConverter) Finished(obj *unstructured.Unstructured) (bool, bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok || condition["type"] != "Succeeded" {
+			continue
+		}
+		message, _ := condition["message"].(string)
+		switch condition["status"] {
+		case string(corev1.ConditionTrue):
+			return true, true, message
+		case string(corev1.ConditionFalse):
+			return true, false, message
+		}
+	}
+	return false, false, ""
+}
+
+// tektonContainer returns the fields of c a Tekton step or sidecar has, and the
+// names of the other ones. Tekton names the resources of containers computeResources.
+func tektonContainer(c *corev1.Container, fields []string) (map[string]any, []string, error) {
+	m, err := toMap(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	kept, dropped := keep(m, fields...)
+	if resources, ok := kept["resources"]; ok {
+		delete(kept, "resources")
+		kept["computeResources"] = resources
+	}
+	return kept, dropped, nil
+}
+
+// merge returns the entries of a and b, b taking precedence, or nil when both are empty.
+func merge(a, b map[string]string) map[string]string {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	merged := maps.Clone(a)
+	if merged == nil {
+		merged = make(map[string]string, len(b))
+	}
+	maps.Copy(merged, b)
+	return merged
+}
+
+// jobWarnings describes the fields of the spec of job no engine can express.
+func jobWarnings(job *batchv1.Job) []string {
+	var warnings []string
+	if job.Spec.Completions != nil && *job.Spec.Completions > 1 || job.Spec.Parallelism != nil && *job.Spec.Parallelism > 1 {
+		warnings = append(warnings, "completions and parallelism can't be converted, a single pod runs")
+	}
+	if job.Spec.CompletionMode != nil && *job.Spec.CompletionMode == batchv1.IndexedCompletion {
+		warnings = append(warnings, "completionMode Indexed can't be converted, JOB_COMPLETION_INDEX isn't set")
+	}
+	if job.Spec.PodFailurePolicy != nil {
+		warnings = append(warnings, "podFailurePolicy can't be converted, dropped")
+	}
+	if job.Spec.SuccessPolicy != nil {
+		warnings = append(warnings, "successPolicy can't be converted, dropped")
+	}
+	return warnings
+}
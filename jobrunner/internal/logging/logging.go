@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logging raises the verbosity of the logs of individual objects. The
+// sink of the controller writes every verbosity up to MaxVerbosity, and a filter
+// in front of it drops the messages more verbose than the configured verbosity,
+// unless the logger they're written to has been raised with WithVerbosity.
+package logging
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+)
+
+// MaxVerbosity is the highest verbosity the logs of an object can be raised to.
+const MaxVerbosity = 5
+
+// Levels are the named verbosities accepted by ParseLevel.
+var Levels = map[string]int{
+	"info":  0,
+	"debug": 1,
+	"trace": 2,
+}
+
+// ParseLevel returns the verbosity of level, either a name of Levels or a
+// verbosity from 0 to MaxVerbosity.
+func ParseLevel(level string) (int, error) {
+	if verbosity, ok := Levels[level]; ok {
+		return verbosity, nil
+	}
+	verbosity, err := strconv.Atoi(level)
+	if err != nil || verbosity < 0 || verbosity > MaxVerbosity {
+		return 0, fmt.Errorf("log level must be info, debug, trace or a verbosity from 0 to %d, not %q", MaxVerbosity, level)
+	}
+	return verbosity, nil
+}
+
+// Verbosity returns the logr verbosity enabled by the zap level, or -1 when it
+// only enables errors.
+func Verbosity(level zapcore.LevelEnabler) int {
+	verbosity := -1
+	for verbosity < MaxVerbosity && level.Enabled(zapcore.Level(-verbosity-1)) {
+		verbosity++
+	}
+	return verbosity
+}
+
+// New returns a logger writing to the sink of log, which must enable every
+// verbosity up to MaxVerbosity, that only writes messages up to verbosity.
+func New(log logr.Logger, verbosity int) logr.Logger {
+	inner := log.GetSink()
+	// The sink of log is already initialized, it only has to skip the frame of the filter
+	if withCallDepth, ok := inner.(logr.CallDepthLogSink); ok {
+		inner = withCallDepth.WithCallDepth(1)
+	}
+	return logr.New(&sink{sink: inner, verbosity: verbosity})
+}
+
+// WithVerbosity returns log writing messages up to verbosity, on top of the ones
+// it already writes. log is returned as is when it wasn't created by New.
+func WithVerbosity(log logr.Logger, verbosity int) logr.Logger {
+	s, ok := log.GetSink().(*sink)
+	if !ok || verbosity <= s.verbosity {
+		return log
+	}
+	raised := *s
+	raised.verbosity = verbosity
+	return log.WithSink(&raised)
+}
+
+// sink filters the messages of a verbose sink by verbosity.
+type sink struct {
+	sink      logr.LogSink
+	verbosity int
+}
+
+var _ logr.CallDepthLogSink = &sink{}
+
+func (s *sink) Init(logr.RuntimeInfo) {}
+
+func (s *sink) Enabled(level int) bool {
+	return level <= s.verbosity && s.sink.Enabled(level)
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...any) {
+	s.sink.Info(level, msg, keysAndValues...)
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...any) {
+	s.sink.Error(err, msg, keysAndValues...)
+}
+
+func (s *sink) WithValues(keysAndValues ...any) logr.LogSink {
+	return &sink{sink: s.sink.WithValues(keysAndValues...), verbosity: s.verbosity}
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	return &sink{sink: s.sink.WithName(name), verbosity: s.verbosity}
+}
+
+func (s *sink) WithCallDepth(depth int) logr.LogSink {
+	inner, ok := s.sink.(logr.CallDepthLogSink)
+	if !ok {
+		return s
+	}
+	return &sink{sink: inner.WithCallDepth(depth), verbosity: s.verbosity}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"errors"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zapcore"
+)
+
+var _ = Describe("Logging", func() {
+	var (
+		lines   []string
+		verbose logr.Logger
+		log     logr.Logger
+	)
+
+	BeforeEach(func() {
+		lines = nil
+		verbose = funcr.New(func(prefix, args string) {
+			lines = append(lines, args)
+		}, funcr.Options{Verbosity: MaxVerbosity})
+		log = New(verbose, 0)
+	})
+
+	It("only writes messages up to the verbosity", func() {
+		log.Info("info")
+		log.V(1).Info("debug")
+		log.Error(errors.New("failed"), "error")
+
+		Expect(lines).To(ConsistOf(ContainSubstring(`"info"`), ContainSubstring(`"error"`)))
+	})
+
+	It("raises the verbosity of a logger", func() {
+		raised := WithVerbosity(log.WithValues("name", "web"), 1)
+		raised.V(1).Info("debug")
+		raised.V(2).Info("trace")
+		log.V(1).Info("other")
+
+		Expect(lines).To(ConsistOf(And(ContainSubstring(`"debug"`), ContainSubstring(`"name"="web"`))))
+	})
+
+	It("doesn't lower the verbosity of a logger", func() {
+		Expect(WithVerbosity(New(verbose, 2), 1).V(2).Enabled()).To(BeTrue())
+		Expect(WithVerbosity(logr.Discard(), 1)).To(Equal(logr.Discard()))
+	})
+
+	DescribeTable("returns the verbosity of zap levels",
+		func(level zapcore.LevelEnabler, verbosity int) {
+			Expect(Verbosity(level)).To(Equal(verbosity))
+		},
+		Entry("info", zapcore.InfoLevel, 0),
+		Entry("debug", zapcore.DebugLevel, 1),
+		Entry("verbosity", zapcore.Level(-3), 3),
+		Entry("errors only", zapcore.ErrorLevel, -1),
+		Entry("beyond the highest verbosity", zapcore.Level(-10), MaxVerbosity),
+	)
+
+	DescribeTable("parses log levels",
+		func(level string, verbosity int, valid bool) {
+			v, err := ParseLevel(level)
+			if !valid {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).To(Equal(verbosity))
+		},
+		Entry("info", "info", 0, true),
+		Entry("debug", "debug", 1, true),
+		Entry("trace", "trace", 2, true),
+		Entry("verbosity", "4", 4, true),
+		Entry("verbosity beyond the highest", "6", 0, false),
+		Entry("negative verbosity", "-1", 0, false),
+		Entry("unknown level", "verbose", 0, false),
+	)
+})
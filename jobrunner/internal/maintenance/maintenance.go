@@ -0,0 +1,274 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package maintenance keeps the stores of the history of builds in check: on a
+// schedule, it compacts the run history store, and prunes the runs and archived
+// builds past their retention.
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"test.jcrs.dev/jobrunner/internal/archive"
+	"test.jcrs.dev/jobrunner/internal/history"
+	"test.jcrs.dev/jobrunner/internal/schedule"
+)
+
+/*
+The history of builds grows with every run: history servers keep the runs they
+were sent and the space of the rows they delete until their database is
+vacuumed, and the archive keeps a record of every build. The Runner maintains
+them at the minutes matched by its schedule, typically once a night, from the
+leader only.
+
+What was pruned and reclaimed is added to metrics, and the outcome of the latest
+maintenance is reported as the HistoryMaintained condition of the operator,
+served next to its other conditions.
+*/
+
+const (
+	// ConditionHistoryMaintained is the condition of the operator that reports the latest maintenance
+	ConditionHistoryMaintained = "HistoryMaintained"
+
+	// ReasonMaintained is the reason of the HistoryMaintained condition once a maintenance succeeded
+	ReasonMaintained = "Maintained"
+
+	// ReasonMaintenanceFailed is the reason of the HistoryMaintained condition once a maintenance failed
+	ReasonMaintenanceFailed = "MaintenanceFailed"
+
+	// historyStore and archiveStore label the metrics of the stores
+	historyStore = "history"
+	archiveStore = "archive"
+
+	// archiveRecordSuffix is the suffix of the keys of archived builds
+	archiveRecordSuffix = ".json"
+)
+
+var (
+	maintenancePruned = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobrunner_history_maintenance_pruned_total",
+			Help: "Number of runs and archived builds pruned past their retention, by store",
+		},
+		[]string{"store"},
+	)
+	maintenanceReclaimedBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobrunner_history_maintenance_reclaimed_bytes_total",
+			Help: "Storage space reclaimed by the maintenance of the history of builds, by store",
+		},
+		[]string{"store"},
+	)
+	maintenanceLastSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "jobrunner_history_maintenance_last_success_timestamp_seconds",
+			Help: "Time of the latest successful maintenance of the history of builds",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(maintenancePruned, maintenanceReclaimedBytes, maintenanceLastSuccess)
+}
+
+// Archive is the object storage builds are archived to.
+type Archive interface {
+	// List returns the objects whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]archive.Object, error)
+	// Delete deletes the object at key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Runner maintains the run history store and the archive on a schedule.
+type Runner struct {
+	// History is compacted if it is a history.Compactor.
+	History history.Store
+
+	// HistoryRetention is how long runs are kept in History. They are kept until
+	// the store drops them when 0, the store is only compacted.
+	HistoryRetention time.Duration
+
+	// Archive holds the archived builds. It isn't pruned when nil.
+	Archive Archive
+
+	// ArchiveRetention is how long archived builds are kept. They are kept forever when 0.
+	ArchiveRetention time.Duration
+
+	// Schedule selects the minutes the stores are maintained at, in UTC.
+	Schedule *schedule.Schedule
+
+	// now returns the current time, for tests
+	now func() time.Time
+
+	mu        sync.Mutex
+	condition metav1.Condition
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only one
+// replica maintains the stores.
+func (m *Runner) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (m *Runner) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("maintenance")
+	ctx = logf.IntoContext(ctx, log)
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	var last time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			minute := m.clock().UTC().Truncate(time.Minute)
+			if minute.Equal(last) || !m.Schedule.Matches(minute) {
+				continue
+			}
+			last = minute
+			if err := m.Run(ctx); err != nil {
+				log.Error(err, "Failed to maintain the history of builds")
+			}
+		}
+	}
+}
+
+// clock returns the current time.
+func (m *Runner) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// Run compacts the run history store and prunes the runs and archived builds past
+// their retention, then reports the outcome in the HistoryMaintained condition.
+func (m *Runner) Run(ctx context.Context) error {
+	log := logf.FromContext(ctx)
+	now := m.clock()
+	var reports []string
+	var errs []error
+
+	if compactor, ok := m.History.(history.Compactor); ok {
+		var cutoff time.Time
+		if m.HistoryRetention > 0 {
+			cutoff = now.Add(-m.HistoryRetention)
+		}
+		compaction, err := compactor.Compact(ctx, cutoff)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to compact the history store: %w", err))
+		} else {
+			maintenancePruned.WithLabelValues(historyStore).Add(float64(compaction.Pruned))
+			maintenanceReclaimedBytes.WithLabelValues(historyStore).Add(float64(compaction.ReclaimedBytes))
+			log.Info("Compacted the history store", "pruned", compaction.Pruned, "reclaimedBytes", compaction.ReclaimedBytes)
+			reports = append(reports, fmt.Sprintf("history store: pruned %d runs, reclaimed %s",
+				compaction.Pruned, formatBytes(compaction.ReclaimedBytes)))
+		}
+	}
+
+	if m.Archive != nil && m.ArchiveRetention > 0 {
+		pruned, reclaimed, err := m.pruneArchive(ctx, now.Add(-m.ArchiveRetention))
+		maintenancePruned.WithLabelValues(archiveStore).Add(float64(pruned))
+		maintenanceReclaimedBytes.WithLabelValues(archiveStore).Add(float64(reclaimed))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to prune the archive: %w", err))
+		} else {
+			log.Info("Pruned the archive", "pruned", pruned, "reclaimedBytes", reclaimed)
+			reports = append(reports, fmt.Sprintf("archive: pruned %d builds, reclaimed %s", pruned, formatBytes(reclaimed)))
+		}
+	}
+
+	condition := metav1.Condition{Type: ConditionHistoryMaintained, Status: metav1.ConditionTrue, Reason: ReasonMaintained}
+	if err := errors.Join(errs...); err != nil {
+		condition.Status, condition.Reason, condition.Message = metav1.ConditionFalse, ReasonMaintenanceFailed, err.Error()
+	} else {
+		maintenanceLastSuccess.Set(float64(now.Unix()))
+		condition.Message = "Nothing to maintain"
+		if len(reports) > 0 {
+			condition.Message = "Maintained the " + strings.Join(reports, "; ")
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	conditions := []metav1.Condition{m.condition}
+	if m.condition.Type == "" {
+		conditions = nil
+	}
+	meta.SetStatusCondition(&conditions, condition)
+	m.condition = conditions[0]
+	return errors.Join(errs...)
+}
+
+// pruneArchive deletes the archived builds last written before cutoff, and returns
+// how many were deleted and the space they took.
+func (m *Runner) pruneArchive(ctx context.Context, cutoff time.Time) (int, int64, error) {
+	objects, err := m.Archive.List(ctx, "")
+	if err != nil {
+		return 0, 0, err
+	}
+	pruned, reclaimed := 0, int64(0)
+	for _, object := range objects {
+		if !strings.HasSuffix(object.Key, archiveRecordSuffix) || !object.LastModified.Before(cutoff) {
+			continue
+		}
+		if err := m.Archive.Delete(ctx, object.Key); err != nil {
+			return pruned, reclaimed, err
+		}
+		pruned++
+		reclaimed += object.Size
+	}
+	return pruned, reclaimed, nil
+}
+
+// formatBytes formats a number of bytes as a binary quantity, e.g. 4Ki.
+func formatBytes(bytes int64) string {
+	return resource.NewQuantity(bytes, resource.BinarySI).String()
+}
+
+// Condition returns the HistoryMaintained condition of the operator, whose status
+// is Unknown until the stores have been maintained.
+func (m *Runner) Condition() metav1.Condition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.condition.Type == "" {
+		return metav1.Condition{Type: ConditionHistoryMaintained, Status: metav1.ConditionUnknown, Reason: "NotMaintained"}
+	}
+	return m.condition
+}
+
+// Handler serves the HistoryMaintained condition of runner as JSON.
+func Handler(runner *Runner) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(runner.Condition())
+	})
+}
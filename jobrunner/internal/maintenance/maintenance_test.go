@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"test.jcrs.dev/jobrunner/internal/archive"
+	"test.jcrs.dev/jobrunner/internal/history"
+)
+
+// fakeHistory is a history store recording its compactions.
+type fakeHistory struct {
+	history.HTTPStore
+	cutoffs []time.Time
+	err     error
+}
+
+func (h *fakeHistory) Compact(_ context.Context, cutoff time.Time) (history.Compaction, error) {
+	h.cutoffs = append(h.cutoffs, cutoff)
+	return history.Compaction{Pruned: 3, ReclaimedBytes: 4096}, h.err
+}
+
+// fakeArchive is an archive listing objects and recording their deletion.
+type fakeArchive struct {
+	objects []archive.Object
+	deleted []string
+}
+
+func (a *fakeArchive) List(context.Context, string) ([]archive.Object, error) {
+	return a.objects, nil
+}
+
+func (a *fakeArchive) Delete(_ context.Context, key string) error {
+	a.deleted = append(a.deleted, key)
+	return nil
+}
+
+var _ = Describe("Runner", func() {
+	now := time.Date(2025, 3, 1, 3, 0, 0, 0, time.UTC)
+
+	It("compacts the history and prunes the archive past their retention", func() {
+		store := &fakeHistory{}
+		builds := &fakeArchive{objects: []archive.Object{
+			{Key: "default/web/old.json", LastModified: now.Add(-100 * 24 * time.Hour), Size: 1024},
+			{Key: "default/web/new.json", LastModified: now.Add(-24 * time.Hour), Size: 2048},
+			{Key: "default/web/old.txt", LastModified: now.Add(-100 * 24 * time.Hour), Size: 512},
+		}}
+		runner := &Runner{
+			History: store, HistoryRetention: 30 * 24 * time.Hour,
+			Archive: builds, ArchiveRetention: 90 * 24 * time.Hour,
+			now: func() time.Time { return now },
+		}
+		Expect(runner.Condition().Status).To(Equal(metav1.ConditionUnknown))
+
+		Expect(runner.Run(context.Background())).To(Succeed())
+		Expect(store.cutoffs).To(Equal([]time.Time{now.Add(-30 * 24 * time.Hour)}))
+		Expect(builds.deleted).To(Equal([]string{"default/web/old.json"}))
+		condition := runner.Condition()
+		Expect(condition.Type).To(Equal(ConditionHistoryMaintained))
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(Equal("Maintained the history store: pruned 3 runs, reclaimed 4Ki; archive: pruned 1 builds, reclaimed 1Ki"))
+	})
+
+	It("only compacts the history without a retention, and reports failures", func() {
+		store := &fakeHistory{err: errors.New("database is locked")}
+		runner := &Runner{History: store, Archive: &fakeArchive{}, now: func() time.Time { return now }}
+
+		Expect(runner.Run(context.Background())).To(MatchError(ContainSubstring("database is locked")))
+		Expect(store.cutoffs).To(Equal([]time.Time{{}}))
+		condition := runner.Condition()
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ReasonMaintenanceFailed))
+	})
+})
@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migration moves the objects of custom resources to the storage version
+// of their CRD. Once every object has been rewritten, the versions they were
+// stored in before are dropped from the status of the CRD, after which they can
+// be removed from the CRD.
+package migration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+/*
+The API server stores an object in the storage version of its CRD whenever it is
+written, even when the write doesn't change it. So rewriting every object as is,
+the way kube-storage-version-migrator does, moves it to the storage version.
+
+An object that changed or was deleted since it was listed has been written in the
+storage version already, or doesn't need to be, so conflicts are skipped.
+*/
+
+// pageSize is the number of objects listed at once
+const pageSize = 500
+
+// Result is the outcome of the migration of a CRD.
+type Result struct {
+	// StorageVersion is the version the objects were migrated to
+	StorageVersion string
+	// Migrated is the number of objects rewritten
+	Migrated int
+	// Skipped is the number of objects that changed or were deleted during the migration
+	Skipped int
+}
+
+// StorageVersion migrates the objects of the CRD named crdName to its storage
+// version, then records the storage version as the only version the CRD is stored in.
+// A CRD that is only stored in its storage version is left untouched.
+func StorageVersion(ctx context.Context, c client.Client, crdName string) (Result, error) {
+	log := logf.FromContext(ctx).WithValues("CustomResourceDefinition", crdName)
+
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := c.Get(ctx, client.ObjectKey{Name: crdName}, &crd); err != nil {
+		return Result{}, err
+	}
+	var result Result
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			result.StorageVersion = version.Name
+		}
+	}
+	if result.StorageVersion == "" {
+		return result, fmt.Errorf("CustomResourceDefinition %s has no storage version", crdName)
+	}
+	if slices.Equal(crd.Status.StoredVersions, []string{result.StorageVersion}) {
+		log.Info("Already stored in its storage version", "storageVersion", result.StorageVersion)
+		return result, nil
+	}
+
+	gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: result.StorageVersion, Kind: crd.Spec.Names.ListKind}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk)
+	for {
+		if err := c.List(ctx, list, client.Limit(pageSize), client.Continue(list.GetContinue())); err != nil {
+			return result, err
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if err := c.Update(ctx, obj); err != nil {
+				if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+					result.Skipped++
+					continue
+				}
+				return result, fmt.Errorf("failed to rewrite %s %s: %w", crd.Spec.Names.Kind, client.ObjectKeyFromObject(obj), err)
+			}
+			result.Migrated++
+		}
+		if list.GetContinue() == "" {
+			break
+		}
+	}
+	log.Info("Rewrote objects in the storage version", "storageVersion", result.StorageVersion,
+		"migrated", result.Migrated, "skipped", result.Skipped)
+
+	patch := client.MergeFromWithOptions(crd.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	crd.Status.StoredVersions = []string{result.StorageVersion}
+	if err := c.Status().Patch(ctx, &crd, patch); err != nil {
+		return result, fmt.Errorf("failed to update the stored versions: %w", err)
+	}
+	return result, nil
+}
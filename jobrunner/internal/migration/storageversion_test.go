@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("StorageVersion", func() {
+	const crdName = "leviathanbuilds.jcrs.jcrs.dev"
+
+	var (
+		crd      *apiextensionsv1.CustomResourceDefinition
+		builder  *fake.ClientBuilder
+		updated  []string
+		conflict string
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+
+		crd = &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: crdName},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: jcrsv1.GroupVersion.Group,
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: "LeviathanBuild", ListKind: "LeviathanBuildList"},
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+					{Name: "v1", Served: true, Storage: true},
+					{Name: "v2", Served: true},
+				},
+			},
+			Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: []string{"v1", "v2"}},
+		}
+
+		updated, conflict = nil, ""
+		builder = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&apiextensionsv1.CustomResourceDefinition{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if obj.GetName() == conflict {
+						return apierrors.NewConflict(schema.GroupResource{}, obj.GetName(), fmt.Errorf("modified"))
+					}
+					updated = append(updated, obj.GetName())
+					return c.Update(ctx, obj, opts...)
+				},
+			})
+	})
+
+	It("rewrites every object and records the storage version", func() {
+		for _, name := range []string{"a", "b", "c"} {
+			builder.WithObjects(&jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}})
+		}
+		c := builder.WithObjects(crd).Build()
+		conflict = "b"
+
+		result, err := StorageVersion(context.Background(), c, crdName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(Result{StorageVersion: "v1", Migrated: 2, Skipped: 1}))
+		Expect(updated).To(ConsistOf("a", "c"))
+
+		Expect(c.Get(context.Background(), client.ObjectKey{Name: crdName}, crd)).To(Succeed())
+		Expect(crd.Status.StoredVersions).To(Equal([]string{"v1"}))
+	})
+
+	It("leaves CRDs already stored in their storage version alone", func() {
+		crd.Status.StoredVersions = []string{"v1"}
+		c := builder.WithObjects(crd, &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}}).Build()
+
+		result, err := StorageVersion(context.Background(), c, crdName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(Result{StorageVersion: "v1"}))
+		Expect(updated).To(BeEmpty())
+	})
+
+	It("fails for CRDs without a storage version", func() {
+		crd.Spec.Versions[0].Storage = false
+		c := builder.WithObjects(crd).Build()
+
+		_, err := StorageVersion(context.Background(), c, crdName)
+		Expect(err).To(MatchError(ContainSubstring("no storage version")))
+	})
+})
@@ -0,0 +1,41 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package profiling helps to debug the performance of the controller at fleet
+// scale. It provides the pprof handlers the metrics server serves behind its
+// authentication and authorization, and sizes the Go runtime to the limits of
+// the container of the controller, which the runtime doesn't see on its own.
+package profiling
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// PprofPath is the path the pprof handlers are served under
+const PprofPath = "/debug/pprof/"
+
+// Handlers returns the pprof handlers by path. The index serves the profiles
+// of runtime/pprof, such as heap and goroutine, by name.
+func Handlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		PprofPath:             http.HandlerFunc(pprof.Index),
+		PprofPath + "cmdline": http.HandlerFunc(pprof.Cmdline),
+		PprofPath + "profile": http.HandlerFunc(pprof.Profile),
+		PprofPath + "symbol":  http.HandlerFunc(pprof.Symbol),
+		PprofPath + "trace":   http.HandlerFunc(pprof.Trace),
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiling
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Runtime tuning", func() {
+	var root string
+
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+	})
+
+	DescribeTable("detects the limits of the container",
+		func(files map[string]string, expected Limits) {
+			for name, content := range files {
+				write(name, content)
+			}
+			Expect(DetectLimits(root)).To(Equal(expected))
+		},
+		Entry("cgroup v2", map[string]string{"cpu.max": "250000 100000\n", "memory.max": "1073741824\n"},
+			Limits{CPU: 2.5, Memory: 1 << 30}),
+		Entry("unlimited cgroup v2", map[string]string{"cpu.max": "max 100000\n", "memory.max": "max\n"}, Limits{}),
+		Entry("cgroup v1", map[string]string{
+			"cpu/cpu.cfs_quota_us":         "50000\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+			"memory/memory.limit_in_bytes": "536870912\n",
+		}, Limits{CPU: 0.5, Memory: 1 << 29}),
+		Entry("unlimited cgroup v1", map[string]string{
+			"cpu/cpu.cfs_quota_us":         "-1\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+			"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		}, Limits{}),
+		Entry("no cgroup", map[string]string{}, Limits{}),
+	)
+
+	It("fails on a malformed limit", func() {
+		write("cpu.max", "lots 100000\n")
+		_, err := DetectLimits(root)
+		Expect(err).To(MatchError(ContainSubstring("parsing cpu.max")))
+	})
+
+	Describe("Tuner", func() {
+		var (
+			tuner       *Tuner
+			maxProcs    int
+			memoryLimit int64
+		)
+
+		BeforeEach(func() {
+			maxProcs, memoryLimit = 0, 0
+			tuner = &Tuner{
+				Root:             root,
+				MaxProcs:         true,
+				MemoryLimitRatio: 0.9,
+				Log:              logr.Discard(),
+				numCPU:           16,
+				setMaxProcs: func(n int) int {
+					previous := maxProcs
+					maxProcs = n
+					return previous
+				},
+				setMemoryLimit: func(n int64) int64 {
+					previous := memoryLimit
+					memoryLimit = n
+					return previous
+				},
+			}
+			GinkgoT().Setenv("GOMAXPROCS", "")
+			GinkgoT().Setenv("GOMEMLIMIT", "")
+		})
+
+		It("sizes the runtime to the limits of the container", func() {
+			write("cpu.max", "150000 100000\n")
+			write("memory.max", "1000000000\n")
+			Expect(tuner.Tune()).To(Succeed())
+			Expect(maxProcs).To(Equal(2))
+			Expect(memoryLimit).To(Equal(int64(900000000)))
+		})
+
+		It("follows the limits when the container is resized", func() {
+			write("cpu.max", "400000 100000\n")
+			write("memory.max", "1000000000\n")
+			Expect(tuner.Tune()).To(Succeed())
+			Expect(maxProcs).To(Equal(4))
+
+			write("cpu.max", "max 100000\n")
+			write("memory.max", "max\n")
+			Expect(tuner.Tune()).To(Succeed())
+			Expect(maxProcs).To(Equal(16))
+			Expect(memoryLimit).To(Equal(int64(math.MaxInt64)))
+		})
+
+		It("caps GOMAXPROCS at the CPUs of the node", func() {
+			write("cpu.max", "6400000 100000\n")
+			Expect(tuner.Tune()).To(Succeed())
+			Expect(maxProcs).To(Equal(16))
+		})
+
+		It("leaves the runtime alone when set in the environment", func() {
+			GinkgoT().Setenv("GOMAXPROCS", "3")
+			GinkgoT().Setenv("GOMEMLIMIT", "512MiB")
+			write("cpu.max", "100000 100000\n")
+			write("memory.max", "1000000000\n")
+			Expect(tuner.Tune()).To(Succeed())
+			Expect(maxProcs).To(BeZero())
+			Expect(memoryLimit).To(BeZero())
+		})
+	})
+})
+
+var _ = Describe("Handlers", func() {
+	It("serves the pprof profiles under the pprof path", func() {
+		Expect(Handlers()).To(HaveKey(PprofPath))
+		for path := range Handlers() {
+			Expect(path).To(HavePrefix(PprofPath))
+		}
+	})
+})
@@ -0,0 +1,233 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+/*
+The Go runtime sizes itself to the node: GOMAXPROCS defaults to the number of
+CPUs of the node, and the garbage collector doesn't know the memory limit of
+the container. A controller limited to 2 CPUs on a 64 CPU node then runs 64
+threads that the CPU quota throttles, and one limited to 1Gi gets OOM killed
+rather than collecting harder as its heap grows.
+
+The Tuner reads the limits of the container from its cgroup, v2 or v1, and sets
+GOMAXPROCS to the CPU limit, rounded up, and the memory limit of the runtime to
+a ratio of the memory limit. As the limits of a pod can be resized in place, they
+are read again periodically. GOMAXPROCS and GOMEMLIMIT set in the environment
+are left alone.
+*/
+
+// unlimitedMemory is the memory limit of cgroup v1 above which the memory isn't limited
+const unlimitedMemory = 1 << 62
+
+// Limits are the resources the container of the controller is limited to.
+type Limits struct {
+	// CPU is the number of CPUs, unlimited when 0
+	CPU float64
+	// Memory is the memory in bytes, unlimited when 0
+	Memory int64
+}
+
+// DetectLimits reads the limits of the cgroup mounted at root, usually /sys/fs/cgroup.
+func DetectLimits(root string) (Limits, error) {
+	if cpuMax, err := os.ReadFile(filepath.Join(root, "cpu.max")); err == nil {
+		return detectV2(root, string(cpuMax))
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return Limits{}, err
+	}
+	return detectV1(root)
+}
+
+// detectV2 reads the limits of a cgroup v2, whose cpu.max is cpuMax.
+func detectV2(root, cpuMax string) (Limits, error) {
+	var limits Limits
+	quota, period, _ := strings.Cut(strings.TrimSpace(cpuMax), " ")
+	if quota != "max" {
+		cpu, err := ratio(quota, period)
+		if err != nil {
+			return Limits{}, fmt.Errorf("parsing cpu.max: %w", err)
+		}
+		limits.CPU = cpu
+	}
+	memoryMax, err := os.ReadFile(filepath.Join(root, "memory.max"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return limits, nil
+	} else if err != nil {
+		return Limits{}, err
+	}
+	if value := strings.TrimSpace(string(memoryMax)); value != "max" {
+		if limits.Memory, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return Limits{}, fmt.Errorf("parsing memory.max: %w", err)
+		}
+	}
+	return limits, nil
+}
+
+// detectV1 reads the limits of a cgroup v1, whose controllers are mounted under root.
+func detectV1(root string) (Limits, error) {
+	var limits Limits
+	quota, quotaErr := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"))
+	period, periodErr := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_period_us"))
+	if quotaErr == nil && periodErr == nil && strings.TrimSpace(string(quota)) != "-1" {
+		cpu, err := ratio(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+		if err != nil {
+			return Limits{}, fmt.Errorf("parsing cpu.cfs_quota_us: %w", err)
+		}
+		limits.CPU = cpu
+	}
+	memory, err := os.ReadFile(filepath.Join(root, "memory", "memory.limit_in_bytes"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return limits, nil
+	} else if err != nil {
+		return Limits{}, err
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(memory)), 10, 64)
+	if err != nil {
+		return Limits{}, fmt.Errorf("parsing memory.limit_in_bytes: %w", err)
+	}
+	if value < unlimitedMemory {
+		limits.Memory = value
+	}
+	return limits, nil
+}
+
+// ratio returns quota / period, both integers.
+func ratio(quota, period string) (float64, error) {
+	q, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	p, err := strconv.ParseInt(period, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if p <= 0 {
+		return 0, fmt.Errorf("invalid period %d", p)
+	}
+	return float64(q) / float64(p), nil
+}
+
+// Tuner sizes the Go runtime to the limits of the container of the controller.
+type Tuner struct {
+	// Root is where the cgroup of the container is mounted
+	Root string
+	// MaxProcs sets GOMAXPROCS to the CPU limit, unless set in the environment
+	MaxProcs bool
+	// MemoryLimitRatio is the ratio of the memory limit the memory limit of the
+	// runtime is set to, unless GOMEMLIMIT is set in the environment. It's left
+	// alone when 0.
+	MemoryLimitRatio float64
+	// Interval is how often the limits are read again. They are only read once when 0.
+	Interval time.Duration
+	// Log logs the changes to the runtime
+	Log logr.Logger
+
+	// applied is the last limits tuned for
+	applied *Limits
+
+	// setMaxProcs and setMemoryLimit change the runtime, they are replaced in tests
+	setMaxProcs    func(int) int
+	setMemoryLimit func(int64) int64
+	numCPU         int
+}
+
+// Tune reads the limits of the container, and sizes the runtime to them when they changed.
+func (t *Tuner) Tune() error {
+	limits, err := DetectLimits(t.Root)
+	if err != nil {
+		return err
+	}
+	if t.applied != nil && *t.applied == limits {
+		return nil
+	}
+	t.applied = &limits
+
+	if t.MaxProcs && os.Getenv("GOMAXPROCS") == "" {
+		numCPU := t.numCPU
+		if numCPU == 0 {
+			numCPU = runtime.NumCPU()
+		}
+		procs := numCPU
+		if limits.CPU > 0 {
+			procs = min(max(int(math.Ceil(limits.CPU)), 1), numCPU)
+		}
+		set := t.setMaxProcs
+		if set == nil {
+			set = runtime.GOMAXPROCS
+		}
+		if previous := set(procs); previous != procs {
+			t.Log.Info("Set GOMAXPROCS to the CPU limit", "GOMAXPROCS", procs, "previous", previous, "cpuLimit", limits.CPU)
+		}
+	}
+
+	if t.MemoryLimitRatio > 0 && os.Getenv("GOMEMLIMIT") == "" {
+		limit := int64(math.MaxInt64)
+		if limits.Memory > 0 {
+			limit = int64(float64(limits.Memory) * t.MemoryLimitRatio)
+		}
+		set := t.setMemoryLimit
+		if set == nil {
+			set = debug.SetMemoryLimit
+		}
+		if previous := set(limit); previous != limit {
+			t.Log.Info("Set the memory limit of the runtime", "GOMEMLIMIT", limit, "previous", previous, "memoryLimit", limits.Memory)
+		}
+	}
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: every replica of
+// the manager tunes its own runtime.
+func (t *Tuner) NeedLeaderElection() bool {
+	return false
+}
+
+// Start tunes the runtime again every Interval until ctx is done. It
+// implements manager.Runnable.
+func (t *Tuner) Start(ctx context.Context) error {
+	if t.Interval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := t.Tune(); err != nil {
+				t.Log.Error(err, "Failed to read the limits of the container")
+			}
+		}
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package progress follows the logs of running builds for the progress markers
+// of their build containers. A build container reports a step by logging a line
+//
+//	::progress <step>::<message>
+//
+// The logs of every followed container are streamed from the API server, and
+// the entries parsed from them are kept for their build until the controller
+// drains them. The build is sent as an event when entries are pending, so it's
+// reconciled without polling the logs.
+package progress
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const (
+	// MaxEntries is the number of entries kept for a build, the latest ones
+	MaxEntries = 20
+
+	markerPrefix     = "::progress "
+	markerSeparator  = "::"
+	maxStepLength    = 63
+	maxMessageLength = 256
+
+	// maxLineLength bounds the lines read from the logs; following stops at a longer line
+	maxLineLength = 1 << 20
+)
+
+// Marker is a step reported by a build container.
+type Marker struct {
+	// Time is when the marker was logged, as recorded by the kubelet
+	Time time.Time
+	Step string
+	// Message is truncated to 256 bytes
+	Message string
+}
+
+// ParseLine parses a line of the logs of a container, prefixed with its
+// timestamp, and reports whether it's a progress marker.
+func ParseLine(line string) (Marker, bool) {
+	timestamp, content, ok := strings.Cut(line, " ")
+	if !ok {
+		return Marker{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return Marker{}, false
+	}
+	rest, ok := strings.CutPrefix(strings.TrimRight(content, "\r"), markerPrefix)
+	if !ok {
+		return Marker{}, false
+	}
+	step, message, ok := strings.Cut(rest, markerSeparator)
+	step = strings.TrimSpace(step)
+	if !ok || step == "" || len(step) > maxStepLength || strings.ContainsAny(step, " \t") {
+		return Marker{}, false
+	}
+	return Marker{Time: t, Step: step, Message: truncate(strings.TrimSpace(message), maxMessageLength)}, true
+}
+
+// truncate returns s cut to at most n bytes, on a rune boundary.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Logs streams the logs of containers, each line prefixed with its timestamp.
+type Logs interface {
+	// Stream follows the logs of container of pod in namespace, from since
+	// when it isn't zero, until the container exits or ctx is done.
+	Stream(ctx context.Context, namespace, pod, container string, since time.Time) (io.ReadCloser, error)
+}
+
+// ClientsetLogs streams the logs of containers from the API server.
+type ClientsetLogs struct {
+	Clientset kubernetes.Interface
+}
+
+// Stream implements Logs.
+func (l ClientsetLogs) Stream(ctx context.Context, namespace, pod, container string, since time.Time) (io.ReadCloser, error) {
+	opts := &corev1.PodLogOptions{Container: container, Follow: true, Timestamps: true}
+	if !since.IsZero() {
+		opts.SinceTime = &metav1.Time{Time: since}
+	}
+	return l.Clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+}
+
+// Container is a running container followed for progress markers.
+type Container struct {
+	Namespace string
+	Pod       string
+	Name      string
+	// RestartCount tells the runs of a restarted container apart
+	RestartCount int32
+}
+
+// Tracker follows the containers of builds, and sends an event for a build
+// once entries are pending for it.
+type Tracker struct {
+	// Logs streams the logs of the followed containers
+	Logs Logs
+
+	events chan event.GenericEvent
+
+	mu     sync.Mutex
+	ctx    context.Context
+	builds map[types.NamespacedName]*buildState
+}
+
+// buildState is what the tracker knows of a build.
+type buildState struct {
+	follows map[Container]context.CancelFunc
+	pending []Marker
+}
+
+// NewTracker returns a Tracker streaming logs with logs.
+func NewTracker(logs Logs) *Tracker {
+	return &Tracker{
+		Logs:   logs,
+		events: make(chan event.GenericEvent, 1024),
+		builds: make(map[types.NamespacedName]*buildState),
+	}
+}
+
+// Events returns the channel the builds with pending entries are sent to, as
+// LeviathanBuilds with only their namespace and name.
+func (t *Tracker) Events() <-chan event.GenericEvent {
+	return t.events
+}
+
+// Start lets the tracker follow containers until ctx is done, when every
+// container stops being followed. It implements manager.Runnable.
+func (t *Tracker) Start(ctx context.Context) error {
+	t.mu.Lock()
+	t.ctx = ctx
+	t.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+// Follow follows containers for build, and stops following the containers of
+// build that aren't in containers. Markers logged until since are skipped.
+// Containers are only followed once the tracker has started.
+func (t *Tracker) Follow(build types.NamespacedName, containers []Container, since time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ctx == nil {
+		return
+	}
+	state := t.builds[build]
+	if state == nil {
+		if len(containers) == 0 {
+			return
+		}
+		state = &buildState{follows: make(map[Container]context.CancelFunc)}
+		t.builds[build] = state
+	}
+	wanted := make(map[Container]bool, len(containers))
+	for _, c := range containers {
+		wanted[c] = true
+		if _, ok := state.follows[c]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(t.ctx)
+		state.follows[c] = cancel
+		go t.follow(ctx, build, c, since)
+	}
+	for c, cancel := range state.follows {
+		if !wanted[c] {
+			cancel()
+			delete(state.follows, c)
+		}
+	}
+	if len(state.follows) == 0 && len(state.pending) == 0 {
+		delete(t.builds, build)
+	}
+}
+
+// Drain returns the entries pending for build, the oldest first, and forgets them.
+func (t *Tracker) Drain(build types.NamespacedName) []Marker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := t.builds[build]
+	if state == nil {
+		return nil
+	}
+	pending := state.pending
+	state.pending = nil
+	if len(state.follows) == 0 {
+		delete(t.builds, build)
+	}
+	return pending
+}
+
+// Forget stops following the containers of build and drops its pending entries.
+func (t *Tracker) Forget(build types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state := t.builds[build]; state != nil {
+		for _, cancel := range state.follows {
+			cancel()
+		}
+		delete(t.builds, build)
+	}
+}
+
+// follow reads the markers of c until its logs end or ctx is done.
+func (t *Tracker) follow(ctx context.Context, build types.NamespacedName, c Container, since time.Time) {
+	logs, err := t.Logs.Stream(ctx, c.Namespace, c.Pod, c.Name, since)
+	if err != nil {
+		if ctx.Err() == nil {
+			logf.FromContext(ctx).Error(err, "Failed to follow logs", "pod", c.Pod, "container", c.Name)
+		}
+		return
+	}
+	defer func() { _ = logs.Close() }()
+
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	for scanner.Scan() {
+		entry, ok := ParseLine(scanner.Text())
+		// Status times are kept to the microsecond
+		if !ok || !entry.Time.Truncate(time.Microsecond).After(since) {
+			continue
+		}
+		if !t.add(ctx, build, entry) {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		logf.FromContext(ctx).Error(err, "Failed to read logs", "pod", c.Pod, "container", c.Name)
+	}
+}
+
+// add keeps entry pending for build, and sends an event for build unless entries
+// were already pending. It reports whether build is still followed.
+func (t *Tracker) add(ctx context.Context, build types.NamespacedName, entry Marker) bool {
+	t.mu.Lock()
+	state := t.builds[build]
+	if state == nil {
+		t.mu.Unlock()
+		return false
+	}
+	notify := len(state.pending) == 0
+	state.pending = append(state.pending, entry)
+	if n := len(state.pending); n > MaxEntries {
+		state.pending = state.pending[n-MaxEntries:]
+	}
+	t.mu.Unlock()
+
+	if !notify {
+		return true
+	}
+	select {
+	case t.events <- event.GenericEvent{Object: &jcrsv1.LeviathanBuild{
+		ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: build.Name},
+	}}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package progress
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// fakeLogs serves the logs of containers by name. The streams of containers
+// without logs stay open until their context is done.
+type fakeLogs struct {
+	mu      sync.Mutex
+	logs    map[string]string
+	streams map[string]time.Time
+}
+
+func (l *fakeLogs) Stream(ctx context.Context, _, pod, container string, since time.Time) (io.ReadCloser, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.streams[pod+"/"+container] = since
+	logs, ok := l.logs[container]
+	if !ok {
+		r, w := io.Pipe()
+		go func() {
+			<-ctx.Done()
+			_ = w.CloseWithError(ctx.Err())
+		}()
+		return r, nil
+	}
+	if logs == "error" {
+		return nil, errors.New("container not found")
+	}
+	return io.NopCloser(strings.NewReader(logs)), nil
+}
+
+func (l *fakeLogs) followed() map[string]time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	followed := make(map[string]time.Time, len(l.streams))
+	for k, v := range l.streams {
+		followed[k] = v
+	}
+	return followed
+}
+
+var _ = Describe("Progress", func() {
+	DescribeTable("parses progress markers",
+		func(line string, expected Marker, ok bool) {
+			entry, parsed := ParseLine(line)
+			Expect(parsed).To(Equal(ok))
+			Expect(entry).To(Equal(expected))
+		},
+		Entry("marker", "2025-03-01T10:00:00.5Z ::progress compile::building ./cmd/server",
+			Marker{Time: time.Date(2025, 3, 1, 10, 0, 0, 5e8, time.UTC), Step: "compile", Message: "building ./cmd/server"}, true),
+		Entry("marker without message", "2025-03-01T10:00:00Z ::progress test::\r",
+			Marker{Time: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), Step: "test"}, true),
+		Entry("message with separator", "2025-03-01T10:00:00Z ::progress push:: image::latest",
+			Marker{Time: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), Step: "push", Message: "image::latest"}, true),
+		Entry("other line", "2025-03-01T10:00:00Z go build ./...", Marker{}, false),
+		Entry("marker without separator", "2025-03-01T10:00:00Z ::progress compile", Marker{}, false),
+		Entry("marker without step", "2025-03-01T10:00:00Z ::progress ::done", Marker{}, false),
+		Entry("step with spaces", "2025-03-01T10:00:00Z ::progress unit tests::done", Marker{}, false),
+		Entry("step too long", "2025-03-01T10:00:00Z ::progress "+strings.Repeat("s", 64)+"::done", Marker{}, false),
+		Entry("no timestamp", "::progress compile::building", Marker{}, false),
+	)
+
+	It("truncates long messages on a rune boundary", func() {
+		entry, ok := ParseLine("2025-03-01T10:00:00Z ::progress test::" + strings.Repeat("a", 255) + "é")
+		Expect(ok).To(BeTrue())
+		Expect(entry.Message).To(Equal(strings.Repeat("a", 255)))
+	})
+
+	Context("Tracker", func() {
+		var (
+			ctx     context.Context
+			cancel  context.CancelFunc
+			logs    *fakeLogs
+			tracker *Tracker
+			build   = types.NamespacedName{Namespace: "ci", Name: "web"}
+			since   = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+		)
+
+		BeforeEach(func() {
+			ctx, cancel = context.WithCancel(context.Background())
+			DeferCleanup(func() { cancel() })
+			logs = &fakeLogs{logs: map[string]string{}, streams: map[string]time.Time{}}
+			tracker = NewTracker(logs)
+			go func() { _ = tracker.Start(ctx) }()
+			Eventually(func() context.Context {
+				tracker.mu.Lock()
+				defer tracker.mu.Unlock()
+				return tracker.ctx
+			}).ShouldNot(BeNil())
+		})
+
+		It("keeps the markers logged after since pending for the build", func() {
+			logs.logs["build"] = strings.Join([]string{
+				"2025-03-01T10:00:00Z ::progress fetch::already reported",
+				"2025-03-01T10:00:01Z ::progress compile::building",
+				"2025-03-01T10:00:02Z compiling...",
+				"2025-03-01T10:00:03Z ::progress test::testing",
+			}, "\n")
+			tracker.Follow(build, []Container{{Namespace: "ci", Pod: "web-1-abcde", Name: "build"}}, since)
+
+			var ev event.GenericEvent
+			Eventually(tracker.Events()).Should(Receive(&ev))
+			Expect(client.ObjectKeyFromObject(ev.Object)).To(Equal(build))
+			Expect(logs.followed()).To(Equal(map[string]time.Time{"web-1-abcde/build": since}))
+
+			var entries []Marker
+			Eventually(func() []Marker {
+				entries = append(entries, tracker.Drain(build)...)
+				return entries
+			}).Should(HaveLen(2))
+			Expect(entries[0]).To(Equal(Marker{Time: since.Add(time.Second), Step: "compile", Message: "building"}))
+			Expect(entries[1].Step).To(Equal("test"))
+			Expect(tracker.Drain(build)).To(BeEmpty())
+		})
+
+		It("keeps the latest entries of a build", func() {
+			var lines []string
+			for i := range 2 * MaxEntries {
+				lines = append(lines, since.Add(time.Duration(i+1)*time.Second).Format(time.RFC3339)+" ::progress step::"+strings.Repeat("x", i))
+			}
+			logs.logs["build"] = strings.Join(lines, "\n")
+			tracker.Follow(build, []Container{{Namespace: "ci", Pod: "web-1-abcde", Name: "build"}}, time.Time{})
+
+			Eventually(tracker.Events()).Should(Receive())
+			Eventually(func() int {
+				tracker.mu.Lock()
+				defer tracker.mu.Unlock()
+				return len(tracker.builds[build].pending)
+			}).Should(Equal(MaxEntries))
+			Eventually(func() string {
+				tracker.mu.Lock()
+				defer tracker.mu.Unlock()
+				pending := tracker.builds[build].pending
+				return pending[len(pending)-1].Message
+			}).Should(HaveLen(2*MaxEntries - 1))
+		})
+
+		It("follows each run of a container once, until it isn't running", func() {
+			container := Container{Namespace: "ci", Pod: "web-1-abcde", Name: "build"}
+			tracker.Follow(build, []Container{container}, since)
+			tracker.Follow(build, []Container{container}, since)
+			Eventually(logs.followed).Should(HaveLen(1))
+
+			restarted := container
+			restarted.RestartCount = 1
+			tracker.Follow(build, []Container{restarted}, since)
+			tracker.mu.Lock()
+			Expect(tracker.builds[build].follows).To(HaveLen(1))
+			Expect(tracker.builds[build].follows).To(HaveKey(restarted))
+			tracker.mu.Unlock()
+
+			tracker.Follow(build, nil, since)
+			Expect(tracker.Drain(build)).To(BeEmpty())
+			tracker.mu.Lock()
+			Expect(tracker.builds).To(BeEmpty())
+			tracker.mu.Unlock()
+		})
+
+		It("stops following the containers of forgotten builds", func() {
+			logs.logs["build"] = "2025-03-01T10:00:01Z ::progress compile::building"
+			tracker.Follow(build, []Container{{Namespace: "ci", Pod: "web-1-abcde", Name: "build"}, {Namespace: "ci", Pod: "web-1-abcde", Name: "lint"}}, since)
+			Eventually(tracker.Events()).Should(Receive())
+			tracker.Forget(build)
+			Expect(tracker.Drain(build)).To(BeEmpty())
+		})
+
+		It("doesn't follow containers before it has started", func() {
+			idle := NewTracker(logs)
+			idle.Follow(build, []Container{{Namespace: "ci", Pod: "web-1-abcde", Name: "build"}}, since)
+			Expect(idle.builds).To(BeEmpty())
+		})
+	})
+})
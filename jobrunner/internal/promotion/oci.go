@@ -0,0 +1,430 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promotion
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// manifestMediaTypes are the manifests a tag is resolved to.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// maxManifestSize is the size of the largest manifest promoted
+const maxManifestSize = 4 << 20
+
+// OCIPromoter promotes versions published as tags of the <registryURL>/<packageName>
+// repository of an OCI registry, through the distribution API. The manifest of
+// the tag, the manifests of an index and every blob they reference are copied
+// to the target repository, then the manifest is tagged with the target
+// version. Blobs already in the target repository aren't copied again, and
+// blobs of the same registry are mounted from the source repository when the
+// registry supports it.
+//
+// Registries using token authentication are supported, the tokens being
+// requested with the Username and Password, if any.
+type OCIPromoter struct {
+	Client *http.Client
+
+	Username string
+	Password string
+}
+
+// NewOCIPromoter returns an OCIPromoter using a client with the given timeout.
+// The timeout bounds the copy of every blob, it should leave time for the
+// largest of them.
+func NewOCIPromoter(username, password string, timeout time.Duration) *OCIPromoter {
+	return &OCIPromoter{
+		Client:   &http.Client{Timeout: timeout},
+		Username: username,
+		Password: password,
+	}
+}
+
+// repository is a repository of an OCI registry.
+type repository struct {
+	// base is the URL of the registry, <scheme>://<host>
+	base string
+	name string
+}
+
+// url returns the URL of the distribution API for the path of the repository.
+func (r repository) url(path string) string {
+	return r.base + "/v2/" + r.name + "/" + path
+}
+
+// parseRepository returns the repository of packageName under registryURL.
+func parseRepository(registryURL, packageName string) (repository, error) {
+	if !strings.Contains(registryURL, "://") {
+		registryURL = "https://" + registryURL
+	}
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return repository{}, err
+	}
+	if u.Host == "" {
+		return repository{}, fmt.Errorf("registry URL %q has no host", registryURL)
+	}
+	return repository{
+		base: u.Scheme + "://" + u.Host,
+		name: strings.TrimPrefix(strings.TrimSuffix(u.Path, "/")+"/"+packageName, "/"),
+	}, nil
+}
+
+// manifest holds the references of an image manifest or index.
+type manifest struct {
+	Config    *descriptor  `json:"config,omitempty"`
+	Layers    []descriptor `json:"layers,omitempty"`
+	Manifests []descriptor `json:"manifests,omitempty"`
+}
+
+// descriptor references content by digest.
+type descriptor struct {
+	Digest string `json:"digest"`
+}
+
+// Promote implements Promoter.
+func (p *OCIPromoter) Promote(ctx context.Context, source, target Artifact) (string, error) {
+	from, err := parseRepository(source.RegistryURL, source.PackageName)
+	if err != nil {
+		return "", err
+	}
+	to, err := parseRepository(target.RegistryURL, target.PackageName)
+	if err != nil {
+		return "", err
+	}
+
+	s := &session{promoter: p, authorization: make(map[string]string)}
+	// Pinned artifacts are fetched by digest, so a tag overwritten since isn't promoted
+	reference := source.Version
+	if source.Digest != "" {
+		reference = source.Digest
+	}
+	body, mediaType, err := s.getManifest(ctx, from, reference)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if strings.HasPrefix(source.Digest, "sha256:") && source.Digest != digest {
+		return "", fmt.Errorf("%w: %s@%s is %s", ErrDigestMismatch, from.name, source.Digest, digest)
+	}
+	if err := s.copyContent(ctx, from, to, body); err != nil {
+		return "", err
+	}
+	if err := s.putManifest(ctx, to, target.Version, mediaType, body); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// session sends the requests of a promotion, keeping the authorization of
+// every repository.
+type session struct {
+	promoter *OCIPromoter
+	// authorization is the Authorization header of the requests, by repository
+	authorization map[string]string
+}
+
+// copyContent copies the content referenced by body, a manifest of from, to to.
+func (s *session) copyContent(ctx context.Context, from, to repository, body []byte) error {
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return fmt.Errorf("invalid manifest in %s: %w", from.name, err)
+	}
+	for _, child := range m.Manifests {
+		childBody, mediaType, err := s.getManifest(ctx, from, child.Digest)
+		if err != nil {
+			return err
+		}
+		if err := s.copyContent(ctx, from, to, childBody); err != nil {
+			return err
+		}
+		if err := s.putManifest(ctx, to, child.Digest, mediaType, childBody); err != nil {
+			return err
+		}
+	}
+	blobs := m.Layers
+	if m.Config != nil {
+		blobs = append([]descriptor{*m.Config}, blobs...)
+	}
+	for _, blob := range blobs {
+		if err := s.copyBlob(ctx, from, to, blob.Digest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getManifest returns the manifest of repo at reference and its media type.
+func (s *session) getManifest(ctx context.Context, repo repository, reference string) ([]byte, string, error) {
+	header := http.Header{"Accept": []string{strings.Join(manifestMediaTypes, ", ")}}
+	resp, err := s.do(ctx, repo, "pull", http.MethodGet, repo.url("manifests/"+reference), header, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %q getting %s:%s", resp.Status, repo.name, reference)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(body) > maxManifestSize {
+		return nil, "", fmt.Errorf("the manifest of %s:%s is larger than %d bytes", repo.name, reference, maxManifestSize)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return body, mediaType, nil
+}
+
+// putManifest writes body, a manifest of mediaType, to repo under reference.
+func (s *session) putManifest(ctx context.Context, repo repository, reference, mediaType string, body []byte) error {
+	header := http.Header{"Content-Type": []string{mediaType}}
+	resp, err := s.do(ctx, repo, "pull,push", http.MethodPut, repo.url("manifests/"+reference), header, body)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q putting %s:%s", resp.Status, repo.name, reference)
+	}
+	return nil
+}
+
+// copyBlob copies the blob of digest from from to to, unless to has it already.
+func (s *session) copyBlob(ctx context.Context, from, to repository, digest string) error {
+	resp, err := s.do(ctx, to, "pull,push", http.MethodHead, to.url("blobs/"+digest), nil, nil)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	uploads := to.url("blobs/uploads/")
+	location := ""
+	if from.base == to.base {
+		// A registry mounting the blob creates it without copying the data, one
+		// that doesn't starts an upload instead
+		query := url.Values{"mount": []string{digest}, "from": []string{from.name}}
+		resp, err := s.do(ctx, to, "pull,push", http.MethodPost, uploads+"?"+query.Encode(), nil, nil)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusCreated:
+			return nil
+		case http.StatusAccepted:
+			location = resp.Header.Get("Location")
+		}
+	}
+	if location == "" {
+		resp, err := s.do(ctx, to, "pull,push", http.MethodPost, uploads, nil, nil)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			return fmt.Errorf("unexpected status %q starting the upload of %s to %s", resp.Status, digest, to.name)
+		}
+		location = resp.Header.Get("Location")
+	}
+	upload, err := url.Parse(uploads)
+	if err != nil {
+		return err
+	}
+	if upload, err = upload.Parse(location); err != nil {
+		return fmt.Errorf("invalid upload location %q: %w", location, err)
+	}
+	query := upload.Query()
+	query.Set("digest", digest)
+	upload.RawQuery = query.Encode()
+
+	blob, err := s.do(ctx, from, "pull", http.MethodGet, from.url("blobs/"+digest), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = blob.Body.Close() }()
+	if blob.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q getting blob %s of %s", blob.Status, digest, from.name)
+	}
+	// The blob is streamed, so the upload can't be sent again once challenged:
+	// it reuses the authorization of the request that started it
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, upload.String(), blob.Body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = blob.ContentLength
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if authorization := s.authorization[to.base+"/"+to.name]; authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err = s.client().Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status %q uploading blob %s to %s", resp.Status, digest, to.name)
+	}
+	return nil
+}
+
+func (s *session) client() *http.Client {
+	if s.promoter.Client == nil {
+		return http.DefaultClient
+	}
+	return s.promoter.Client
+}
+
+// do sends a request for repo to the registry, authenticating for actions on
+// repo when challenged. The caller closes the body of the response.
+func (s *session) do(ctx context.Context, repo repository, actions, method, target string, header http.Header, body []byte) (*http.Response, error) {
+	key := repo.base + "/" + repo.name
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if authorization := s.authorization[key]; authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return s.client().Do(req)
+	}
+
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	switch {
+	case strings.EqualFold(scheme, "Bearer"):
+		if params["scope"] == "" {
+			params["scope"] = "repository:" + repo.name + ":" + actions
+		}
+		token, err := s.token(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		s.authorization[key] = "Bearer " + token
+	case strings.EqualFold(scheme, "Basic") && s.promoter.Username != "":
+		s.authorization[key] = "Basic " + base64.StdEncoding.EncodeToString([]byte(s.promoter.Username+":"+s.promoter.Password))
+	default:
+		return nil, fmt.Errorf("unexpected status %q for %s %s", resp.Status, method, target)
+	}
+	return send()
+}
+
+// token requests a token from the authorization service of a Bearer challenge.
+// See https://distribution.github.io/distribution/spec/auth/token/
+func (s *session) token(ctx context.Context, params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	for _, name := range []string{"service", "scope"} {
+		if value := params[name]; value != "" {
+			query.Set(name, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if s.promoter.Username != "" {
+		req.SetBasicAuth(s.promoter.Username, s.promoter.Password)
+	}
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %q requesting a token from %s", resp.Status, realm.Host)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("no token in the response of %s", realm.Host)
+}
+
+// parseChallenge parses a WWW-Authenticate header with a single challenge, e.g.
+// `Bearer realm="https://auth.example.com/token",scope="repository:a/b:pull,push"`.
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		name, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if strings.HasPrefix(value, `"`) {
+			// Quoted values may contain commas
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[name] = value[1:]
+				break
+			}
+			params[name] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			params[name], rest, _ = strings.Cut(value, ",")
+		}
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return scheme, params
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package promotion copies the package versions published by builds to another
+// path of the storage they were published to, e.g. from the dev path of a
+// registry to its prod path.
+package promotion
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"test.jcrs.dev/jobrunner/internal/archive"
+)
+
+// ErrDigestMismatch is returned when the source of a promotion isn't the
+// artifact of the expected digest anymore.
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+// Artifact is a published version of a package.
+type Artifact struct {
+	// RegistryURL is the base URL the version is published under
+	RegistryURL string
+	PackageName string
+	Version     string
+	// Digest of the artifact, if known
+	Digest string
+}
+
+// Promoter promotes published versions of packages.
+type Promoter interface {
+	// Promote copies the source artifact to the target, whose Digest is
+	// ignored, and returns the digest of the promoted artifact. When the Digest
+	// of source is set, only the artifact of that digest is promoted.
+	Promote(ctx context.Context, source, target Artifact) (string, error)
+}
+
+// S3Promoter promotes versions published to S3 compatible storage by copying
+// the object <registryURL>/<packageName>/<version>, along with its
+// Content-Type, to the target. The registryURLs are path-style URLs,
+// <endpoint>/<bucket>[/<prefix>]. Digests are only checked when they are
+// sha256 digests, against the content of the object.
+type S3Promoter struct {
+	Client *http.Client
+
+	// Region the requests are signed for
+	Region string
+
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// NewS3Promoter returns an S3Promoter using a client with the given timeout.
+func NewS3Promoter(region, accessKeyID, secretAccessKey string, timeout time.Duration) *S3Promoter {
+	return &S3Promoter{
+		Client:          &http.Client{Timeout: timeout},
+		Region:          region,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+	}
+}
+
+// Promote implements Promoter.
+func (p *S3Promoter) Promote(ctx context.Context, source, target Artifact) (string, error) {
+	from, fromKey, err := p.object(source)
+	if err != nil {
+		return "", err
+	}
+	to, toKey, err := p.object(target)
+	if err != nil {
+		return "", err
+	}
+
+	var data bytes.Buffer
+	header, err := from.Get(ctx, fromKey, &data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data.Bytes())
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if strings.HasPrefix(source.Digest, "sha256:") && source.Digest != digest {
+		return "", fmt.Errorf("%w: %s is %s, not %s", ErrDigestMismatch, fromKey, digest, source.Digest)
+	}
+	putHeader := http.Header{}
+	if contentType := header.Get("Content-Type"); contentType != "" {
+		putHeader.Set("Content-Type", contentType)
+	}
+	if _, err := to.PutObject(ctx, toKey, putHeader, data.Bytes()); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// object returns the store and key of the object of artifact.
+func (p *S3Promoter) object(artifact Artifact) (*archive.S3Store, string, error) {
+	u, err := url.Parse(artifact.RegistryURL)
+	if err != nil {
+		return nil, "", err
+	}
+	bucket, prefix, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if u.Scheme == "" || u.Host == "" || bucket == "" {
+		return nil, "", fmt.Errorf("registry URL %q isn't a path-style bucket URL", artifact.RegistryURL)
+	}
+	store := &archive.S3Store{
+		Client:          p.Client,
+		Endpoint:        u.Scheme + "://" + u.Host,
+		Bucket:          bucket,
+		Region:          p.Region,
+		AccessKeyID:     p.AccessKeyID,
+		SecretAccessKey: p.SecretAccessKey,
+	}
+	return store, path.Join(prefix, artifact.PackageName, artifact.Version), nil
+}
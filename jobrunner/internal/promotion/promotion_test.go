@@ -0,0 +1,223 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promotion
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// digestOf returns the sha256 digest of data.
+func digestOf(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+var _ = Describe("S3Promoter", func() {
+	It("copies the object of the version", func() {
+		objects := map[string]string{"/packages/dev/web/1.2.3": "tarball"}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=key/"))
+			switch r.Method {
+			case http.MethodGet:
+				data, ok := objects[r.URL.Path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", "application/gzip")
+				_, _ = io.WriteString(w, data)
+			case http.MethodPut:
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/gzip"))
+				data, _ := io.ReadAll(r.Body)
+				objects[r.URL.Path] = string(data)
+			}
+		}))
+		DeferCleanup(server.Close)
+
+		promoter := NewS3Promoter("auto", "key", "secret", time.Second)
+		source := Artifact{RegistryURL: server.URL + "/packages/dev", PackageName: "web", Version: "1.2.3", Digest: digestOf("tarball")}
+		target := Artifact{RegistryURL: server.URL + "/packages/prod", PackageName: "web", Version: "1.2.3"}
+		Expect(promoter.Promote(context.Background(), source, target)).To(Equal(digestOf("tarball")))
+		Expect(objects).To(HaveKeyWithValue("/packages/prod/web/1.2.3", "tarball"))
+
+		By("refusing to promote another artifact than the pinned one")
+		source.Digest = digestOf("other")
+		Expect(promoter.Promote(context.Background(), source, target)).Error().To(MatchError(ErrDigestMismatch))
+	})
+})
+
+// fakeRegistry is an OCI registry keeping its manifests and blobs in memory,
+// by repository and reference.
+type fakeRegistry struct {
+	server    *httptest.Server
+	manifests map[string]string
+	blobs     map[string]string
+	// mount is set when the registry mounts blobs across repositories
+	mount    bool
+	requests []string
+}
+
+func newFakeRegistry() *fakeRegistry {
+	registry := &fakeRegistry{manifests: map[string]string{}, blobs: map[string]string{}}
+	registry.server = httptest.NewServer(http.HandlerFunc(registry.serve))
+	DeferCleanup(registry.server.Close)
+	return registry
+}
+
+func (f *fakeRegistry) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		user, password, ok := r.BasicAuth()
+		Expect(ok).To(BeTrue())
+		Expect(user + ":" + password).To(Equal("robot:secret"))
+		Expect(r.URL.Query().Get("scope")).To(HavePrefix("repository:"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "t0k3n"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer t0k3n" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+f.server.URL+`/token",service="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	f.requests = append(f.requests, r.Method+" "+path)
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case strings.Contains(path, "/manifests/"):
+		repo, reference, _ := strings.Cut(path, "/manifests/")
+		switch r.Method {
+		case http.MethodGet:
+			manifest, ok := f.manifests[repo+"@"+reference]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			mediaType, data, _ := strings.Cut(manifest, " ")
+			w.Header().Set("Content-Type", mediaType)
+			_, _ = io.WriteString(w, data)
+		case http.MethodPut:
+			manifest := r.Header.Get("Content-Type") + " " + string(body)
+			f.manifests[repo+"@"+reference] = manifest
+			f.manifests[repo+"@"+digestOf(string(body))] = manifest
+			w.WriteHeader(http.StatusCreated)
+		}
+	case strings.Contains(path, "/blobs/uploads/"):
+		repo, _, _ := strings.Cut(path, "/blobs/uploads/")
+		digest := r.URL.Query().Get("digest")
+		switch r.Method {
+		case http.MethodPost:
+			if from := r.URL.Query().Get("from"); f.mount && from != "" {
+				if data, ok := f.blobs[from+"@"+r.URL.Query().Get("mount")]; ok {
+					f.blobs[repo+"@"+r.URL.Query().Get("mount")] = data
+					w.WriteHeader(http.StatusCreated)
+					return
+				}
+			}
+			w.Header().Set("Location", "/v2/"+repo+"/blobs/uploads/upload-1")
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodPut:
+			Expect(digestOf(string(body))).To(Equal(digest))
+			f.blobs[repo+"@"+digest] = string(body)
+			w.WriteHeader(http.StatusCreated)
+		}
+	case strings.Contains(path, "/blobs/"):
+		repo, digest, _ := strings.Cut(path, "/blobs/")
+		data, ok := f.blobs[repo+"@"+digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = io.WriteString(w, data)
+		}
+	}
+}
+
+var _ = Describe("OCIPromoter", func() {
+	const (
+		imageManifest = "application/vnd.oci.image.manifest.v1+json"
+		imageIndex    = "application/vnd.oci.image.index.v1+json"
+	)
+	var (
+		dev      *fakeRegistry
+		index    string
+		manifest string
+		promoter *OCIPromoter
+	)
+
+	BeforeEach(func() {
+		dev = newFakeRegistry()
+		dev.blobs["dev/web@"+digestOf("config")] = "config"
+		dev.blobs["dev/web@"+digestOf("layer")] = "layer"
+		manifest = `{"config":{"digest":"` + digestOf("config") + `"},"layers":[{"digest":"` + digestOf("layer") + `"}]}`
+		index = `{"manifests":[{"digest":"` + digestOf(manifest) + `"}]}`
+		dev.manifests["dev/web@"+digestOf(manifest)] = imageManifest + " " + manifest
+		dev.manifests["dev/web@1.2.3"] = imageIndex + " " + index
+		dev.manifests["dev/web@"+digestOf(index)] = imageIndex + " " + index
+		promoter = NewOCIPromoter("robot", "secret", time.Second)
+	})
+
+	It("copies the manifests and blobs of the tag to another registry", func() {
+		prod := newFakeRegistry()
+		source := Artifact{RegistryURL: dev.server.URL + "/dev", PackageName: "web", Version: "1.2.3"}
+		target := Artifact{RegistryURL: prod.server.URL + "/prod/", PackageName: "web", Version: "1.2"}
+		Expect(promoter.Promote(context.Background(), source, target)).To(Equal(digestOf(index)))
+
+		Expect(prod.manifests).To(HaveKeyWithValue("prod/web@1.2", imageIndex+" "+index))
+		Expect(prod.manifests).To(HaveKeyWithValue("prod/web@"+digestOf(manifest), imageManifest+" "+manifest))
+		Expect(prod.blobs).To(HaveKeyWithValue("prod/web@"+digestOf("config"), "config"))
+		Expect(prod.blobs).To(HaveKeyWithValue("prod/web@"+digestOf("layer"), "layer"))
+
+		By("not copying the blobs the target has already")
+		prod.requests = nil
+		Expect(promoter.Promote(context.Background(), source, target)).To(Equal(digestOf(index)))
+		Expect(prod.requests).NotTo(ContainElement(HavePrefix("POST")))
+	})
+
+	It("mounts the blobs of the same registry", func() {
+		dev.mount = true
+		source := Artifact{RegistryURL: dev.server.URL + "/dev", PackageName: "web", Version: "1.2.3", Digest: digestOf(index)}
+		target := Artifact{RegistryURL: dev.server.URL + "/prod", PackageName: "web", Version: "1.2.3"}
+		Expect(promoter.Promote(context.Background(), source, target)).To(Equal(digestOf(index)))
+		Expect(dev.manifests).To(HaveKeyWithValue("prod/web@1.2.3", imageIndex+" "+index))
+		Expect(dev.blobs).To(HaveKeyWithValue("prod/web@"+digestOf("layer"), "layer"))
+		Expect(dev.requests).NotTo(ContainElement(HavePrefix("GET dev/web/blobs/")))
+	})
+
+	It("promotes the pinned artifact, even when the tag moved", func() {
+		dev.manifests["dev/web@1.2.3"] = imageManifest + " " + manifest
+		prod := newFakeRegistry()
+		source := Artifact{RegistryURL: dev.server.URL + "/dev", PackageName: "web", Version: "1.2.3", Digest: digestOf(index)}
+		target := Artifact{RegistryURL: prod.server.URL + "/prod", PackageName: "web", Version: "1.2.3"}
+		Expect(promoter.Promote(context.Background(), source, target)).To(Equal(digestOf(index)))
+		Expect(prod.manifests).To(HaveKeyWithValue("prod/web@1.2.3", imageIndex+" "+index))
+
+		By("failing when the pinned artifact is gone")
+		source.Digest = digestOf("gone")
+		Expect(promoter.Promote(context.Background(), source, target)).Error().To(MatchError(ContainSubstring("404")))
+	})
+})
@@ -0,0 +1,406 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rbaccheck statically checks that the +kubebuilder:rbac markers of the
+// controller permit the requests its code makes. It finds the calls to the
+// controller-runtime client, the objects watched by controllers and the Events
+// recorded, resolves the Go types of their objects to resources through a
+// scheme, and reports the requests the markers, and so the generated Role,
+// don't permit.
+package rbaccheck
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	clientPkg   = "sigs.k8s.io/controller-runtime/pkg/client"
+	builderPkg  = "sigs.k8s.io/controller-runtime/pkg/builder"
+	recorderPkg = "k8s.io/client-go/tools/record"
+
+	rbacMarker = "+kubebuilder:rbac:"
+)
+
+// clientVerbs maps the methods of the client to the verbs of their requests.
+var clientVerbs = map[string]string{
+	"Get":         "get",
+	"List":        "list",
+	"Create":      "create",
+	"Update":      "update",
+	"Patch":       "patch",
+	"Delete":      "delete",
+	"DeleteAllOf": "deletecollection",
+}
+
+// Request is a request of the code to the API server.
+type Request struct {
+	Group    string
+	Resource string
+	Verb     string
+	// Position of the call making the request
+	Position token.Position
+}
+
+func (r Request) String() string {
+	group := r.Group
+	if group == "" {
+		group = "core"
+	}
+	return fmt.Sprintf("%s: %s %s/%s", r.Position, r.Verb, group, r.Resource)
+}
+
+// Rule is a rule of a +kubebuilder:rbac marker.
+type Rule struct {
+	Groups    []string
+	Resources []string
+	Verbs     []string
+}
+
+// Permits reports whether the rule permits req.
+func (r Rule) Permits(req Request) bool {
+	matches := func(values []string, value string) bool {
+		return slices.Contains(values, "*") || slices.Contains(values, value)
+	}
+	return matches(r.Groups, req.Group) && matches(r.Resources, req.Resource) && matches(r.Verbs, req.Verb)
+}
+
+// Violations returns the requests none of the rules permit, sorted by position.
+func Violations(requests []Request, rules []Rule) []Request {
+	var violations []Request
+	for _, req := range requests {
+		if !slices.ContainsFunc(rules, func(rule Rule) bool { return rule.Permits(req) }) {
+			violations = append(violations, req)
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].String() < violations[j].String() })
+	return violations
+}
+
+// ParseMarkers returns the rules of the +kubebuilder:rbac markers of the Go
+// files under dir, as controller-gen reads them.
+func ParseMarkers(dir string) ([]Rule, error) {
+	var rules []Rule
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != dir && (d.Name() == "bin" || d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if marker, ok := strings.CutPrefix(line, "// "+rbacMarker); ok {
+				rules = append(rules, parseMarker(marker))
+			}
+		}
+		return scanner.Err()
+	})
+	return rules, err
+}
+
+// ParseRole returns the rules of the hand-written ClusterRole at path, for the
+// permissions that can't be expressed by markers, such as rules restricted to
+// resourceNames. A rule restricted to resourceNames is taken to permit its
+// requests.
+func ParseRole(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var role rbacv1.ClusterRole
+	if err := yaml.Unmarshal(data, &role); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	rules := make([]Rule, 0, len(role.Rules))
+	for _, rule := range role.Rules {
+		rules = append(rules, Rule{Groups: rule.APIGroups, Resources: rule.Resources, Verbs: rule.Verbs})
+	}
+	return rules, nil
+}
+
+// parseMarker parses the arguments of a +kubebuilder:rbac marker, e.g.
+// groups=batch,resources=jobs,verbs=get;list. The core group is written "core"
+// or left empty.
+func parseMarker(marker string) Rule {
+	var rule Rule
+	for _, arg := range strings.Split(marker, ",") {
+		key, value, _ := strings.Cut(arg, "=")
+		values := strings.Split(value, ";")
+		switch key {
+		case "groups":
+			for i, group := range values {
+				if group == "core" {
+					values[i] = ""
+				}
+			}
+			rule.Groups = values
+		case "resources":
+			rule.Resources = values
+		case "verbs":
+			rule.Verbs = values
+		}
+	}
+	return rule
+}
+
+// Scanner finds the requests made by Go packages.
+type Scanner struct {
+	// kinds maps the <package path>.<type name> of the Go types of the scheme to
+	// their kinds
+	kinds map[string]schema.GroupVersionKind
+}
+
+// NewScanner returns a Scanner resolving the objects of requests to resources
+// with scheme. Objects of types the scheme doesn't know are skipped.
+func NewScanner(scheme *runtime.Scheme) *Scanner {
+	kinds := make(map[string]schema.GroupVersionKind)
+	for gvk, t := range scheme.AllKnownTypes() {
+		kinds[t.PkgPath()+"."+t.Name()] = gvk
+	}
+	return &Scanner{kinds: kinds}
+}
+
+// Scan type-checks the packages matching patterns, relative to dir, and returns
+// the requests they make. Test files aren't scanned.
+func (s *Scanner) Scan(dir string, patterns ...string) ([]Request, error) {
+	pkgs, exports, err := listPackages(dir, patterns)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	// The export data of the dependencies is read by the importer of the toolchain
+	imp := importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		export, ok := exports[path]
+		if !ok {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+		return os.Open(export)
+	})
+
+	var requests []Request
+	for _, pkg := range pkgs {
+		var files []*ast.File
+		for _, name := range pkg.GoFiles {
+			file, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, parser.SkipObjectResolution)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+		info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue), Uses: make(map[*ast.Ident]types.Object)}
+		if _, err := (&types.Config{Importer: imp}).Check(pkg.ImportPath, fset, files, info); err != nil {
+			return nil, fmt.Errorf("type-checking %s: %w", pkg.ImportPath, err)
+		}
+		for _, file := range files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					requests = append(requests, s.callRequests(fset, info, call)...)
+				}
+				return true
+			})
+		}
+	}
+	return requests, nil
+}
+
+// listedPackage is a package listed by go list.
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+	Export     string
+	DepOnly    bool
+}
+
+// listPackages returns the packages matching patterns, and the export data
+// files of their dependencies by import path, as built by go list.
+func listPackages(dir string, patterns []string) ([]listedPackage, map[string]string, error) {
+	args := append([]string{"list", "-deps", "-export", "-json=ImportPath,Dir,GoFiles,Export,DepOnly"}, patterns...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, nil, fmt.Errorf("go list: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var pkgs []listedPackage
+	exports := make(map[string]string)
+	decoder := json.NewDecoder(&stdout)
+	for {
+		var pkg listedPackage
+		if err := decoder.Decode(&pkg); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		exports[pkg.ImportPath] = pkg.Export
+		if !pkg.DepOnly {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs, exports, nil
+}
+
+/*
+The requests of a call are found from the method called:
+
+  - the methods of the client request their verb for the resource of their
+    object, or of the subresource named by Status() or SubResource();
+  - For, Owns and Watches of a controller builder list and watch their object;
+  - the methods of an EventRecorder create or patch Events.
+
+Objects whose static type is an interface, such as client.Object, can't be
+resolved and are skipped.
+*/
+
+// callRequests returns the requests made by call.
+func (s *Scanner) callRequests(fset *token.FileSet, info *types.Info, call *ast.CallExpr) []Request {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return nil
+	}
+	position := fset.Position(call.Pos())
+
+	switch fn.Pkg().Path() {
+	case clientPkg:
+		verb, ok := clientVerbs[fn.Name()]
+		if !ok || len(call.Args) < 2 {
+			return nil
+		}
+		req, ok := s.objectRequest(info, call.Args[1], verb, position)
+		if !ok {
+			return nil
+		}
+		if subresource, ok := subresourceOf(sel.X); ok {
+			req.Resource += "/" + subresource
+		}
+		return []Request{req}
+	case builderPkg:
+		if fn.Name() != "For" && fn.Name() != "Owns" && fn.Name() != "Watches" || len(call.Args) == 0 {
+			return nil
+		}
+		var requests []Request
+		for _, verb := range []string{"list", "watch"} {
+			if req, ok := s.objectRequest(info, call.Args[0], verb, position); ok {
+				requests = append(requests, req)
+			}
+		}
+		return requests
+	case recorderPkg:
+		if !strings.HasPrefix(fn.Name(), "Event") && !strings.HasPrefix(fn.Name(), "AnnotatedEventf") {
+			return nil
+		}
+		return []Request{
+			{Resource: "events", Verb: "create", Position: position},
+			{Resource: "events", Verb: "patch", Position: position},
+		}
+	}
+	return nil
+}
+
+// objectRequest returns the request with verb for the resource of the object
+// expr, if its type is known.
+func (s *Scanner) objectRequest(info *types.Info, expr ast.Expr, verb string, position token.Position) (Request, bool) {
+	t := info.TypeOf(expr)
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return Request{}, false
+	}
+	gvk, ok := s.kinds[named.Obj().Pkg().Path()+"."+named.Obj().Name()]
+	if !ok {
+		return Request{}, false
+	}
+	if kind, ok := strings.CutSuffix(gvk.Kind, "List"); ok && s.knows(gvk.GroupVersion().WithKind(kind)) {
+		gvk.Kind = kind
+	}
+	resource, _ := meta.UnsafeGuessKindToResource(gvk)
+	return Request{Group: gvk.Group, Resource: resource.Resource, Verb: verb, Position: position}, true
+}
+
+// knows reports whether the scheme of s has gvk.
+func (s *Scanner) knows(gvk schema.GroupVersionKind) bool {
+	for _, known := range s.kinds {
+		if known == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+// subresourceOf returns the subresource written by a writer expression, such
+// as r.Status() or r.SubResource("eviction").
+func subresourceOf(expr ast.Expr) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	switch sel.Sel.Name {
+	case "Status":
+		return "status", true
+	case "SubResource":
+		if len(call.Args) == 1 {
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				name, err := strconv.Unquote(lit.Value)
+				return name, err == nil
+			}
+		}
+	}
+	return "", false
+}
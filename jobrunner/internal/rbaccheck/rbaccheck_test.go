@@ -0,0 +1,90 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbaccheck
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// managerPackages are the packages run by the manager, with the permissions of
+// its Role. The CLI and the migration Job have their own.
+var managerPackages = []string{
+	"./cmd",
+	"./internal/batch",
+	"./internal/controller",
+	"./internal/crds",
+	"./internal/grpcapi",
+	"./internal/webhook/...",
+}
+
+// managerRoles are the hand-written ClusterRoles bound to the manager, besides
+// the Role generated from the markers.
+var managerRoles = []string{
+	"../../config/rbac/crd_manager_role.yaml",
+}
+
+var _ = Describe("RBAC check", func() {
+	var scanner *Scanner
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+		scanner = NewScanner(scheme)
+	})
+
+	It("permits every request of the manager", func() {
+		rules, err := ParseMarkers("../..")
+		Expect(err).NotTo(HaveOccurred())
+		for _, path := range managerRoles {
+			roleRules, err := ParseRole(path)
+			Expect(err).NotTo(HaveOccurred())
+			rules = append(rules, roleRules...)
+		}
+		requests, err := scanner.Scan("../..", managerPackages...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).NotTo(BeEmpty())
+
+		Expect(Violations(requests, rules)).To(BeEmpty(),
+			"add +kubebuilder:rbac markers for these requests and run make manifests")
+	})
+
+	It("reports the requests the markers don't permit", func() {
+		rules, err := ParseMarkers("testdata/violations")
+		Expect(err).NotTo(HaveOccurred())
+		requests, err := scanner.Scan(".", "./testdata/violations")
+		Expect(err).NotTo(HaveOccurred())
+
+		var reported []string
+		for _, req := range Violations(requests, rules) {
+			reported = append(reported, req.Verb+" "+req.Group+"/"+req.Resource)
+		}
+		Expect(reported).To(ConsistOf(
+			"delete /secrets",
+			"update batch/jobs/status",
+			"create /events",
+			"patch /events",
+		))
+	})
+})
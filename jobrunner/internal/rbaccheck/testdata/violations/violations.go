@@ -0,0 +1,62 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package violations makes requests its markers don't all permit.
+package violations
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=*
+
+type reconciler struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+func (r *reconciler) reconcile(ctx context.Context, obj client.Object) error {
+	var secrets corev1.SecretList
+	if err := r.List(ctx, &secrets); err != nil {
+		return err
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: "token"}, secret); err != nil {
+		return err
+	}
+	if err := r.Delete(ctx, secret); err != nil {
+		return err
+	}
+	job := &batchv1.Job{}
+	if err := r.Create(ctx, job); err != nil {
+		return err
+	}
+	if err := r.Status().Update(ctx, job); err != nil {
+		return err
+	}
+	// The type of obj isn't known statically
+	if err := r.Update(ctx, obj); err != nil {
+		return err
+	}
+	r.recorder.Event(job, corev1.EventTypeNormal, "Created", "Job created")
+	return nil
+}
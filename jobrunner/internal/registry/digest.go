@@ -0,0 +1,213 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DigestResolver returns the digest an image reference points to.
+type DigestResolver interface {
+	Digest(ctx context.Context, image string) (string, error)
+}
+
+// manifestMediaTypes are the manifests accepted for the digest of a tag, image
+// indexes first so that a multi-platform tag resolves to its index.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// HTTPDigestResolver resolves image references with a HEAD request for their
+// manifest, through the distribution API of their registry. Registries asking
+// for a bearer token are sent an anonymous one, so only public images resolve.
+// References already pinned by digest resolve to it without a request.
+type HTTPDigestResolver struct {
+	Client *http.Client
+	// Scheme of the registries, https when empty
+	Scheme string
+}
+
+// Digest implements DigestResolver.
+func (r *HTTPDigestResolver) Digest(ctx context.Context, image string) (string, error) {
+	host, repository, reference, err := ParseImage(image)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(reference, "sha256:") {
+		return reference, nil
+	}
+	scheme := r.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	target := scheme + "://" + host + "/v2/" + repository + "/manifests/" + reference
+
+	resp, err := r.head(ctx, target, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := r.token(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", fmt.Errorf("authenticating to %s: %w", host, err)
+		}
+		if resp, err = r.head(ctx, target, token); err != nil {
+			return "", err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %q resolving %s", resp.Status, image)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry %s didn't return the digest of %s", host, image)
+	}
+	return digest, nil
+}
+
+// head requests the manifest at target, with token if set.
+func (r *HTTPDigestResolver) head(ctx context.Context, target, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	return resp, nil
+}
+
+// token returns an anonymous token from the realm of the Bearer challenge.
+func (r *HTTPDigestResolver) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+	values := parseChallenge(params)
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return "", fmt.Errorf("invalid realm in authentication challenge %q", challenge)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %q from %s", resp.Status, realm.Host)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+func (r *HTTPDigestResolver) client() *http.Client {
+	if r.Client == nil {
+		return http.DefaultClient
+	}
+	return r.Client
+}
+
+// parseChallenge parses the comma separated key="value" parameters of an
+// authentication challenge.
+func parseChallenge(params string) map[string]string {
+	values := make(map[string]string)
+	for params != "" {
+		key, rest, ok := strings.Cut(strings.TrimLeft(params, ", "), "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = value
+		params = rest
+	}
+	return values
+}
+
+// ParseImage splits an image reference into the host of its registry, its
+// repository and its tag or digest, with the defaults of Docker Hub: images
+// without a registry are on docker.io, in the library namespace when they have
+// none, and tagged latest.
+func ParseImage(image string) (host, repository, reference string, err error) {
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, reference = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		if reference == "" {
+			reference = name[i+1:]
+		}
+		name = name[:i]
+	}
+	if reference == "" {
+		reference = "latest"
+	}
+	if name == "" {
+		return "", "", "", fmt.Errorf("invalid image reference %q", image)
+	}
+
+	host, repository = "docker.io", name
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		host, repository = first, rest
+	}
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	}
+	return host, repository, reference, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTPDigestResolver", func() {
+	const digest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+	var (
+		server   *httptest.Server
+		host     string
+		resolver *HTTPDigestResolver
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/token":
+				Expect(r.URL.Query().Get("service")).To(Equal("registry.test"))
+				Expect(r.URL.Query().Get("scope")).To(Equal("repository:team/builder:pull"))
+				_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+			case "/v2/team/builder/manifests/1.22":
+				Expect(r.Method).To(Equal(http.MethodHead))
+				Expect(r.Header.Get("Accept")).To(ContainSubstring("application/vnd.oci.image.index.v1+json"))
+				if r.Header.Get("Authorization") != "Bearer anonymous" {
+					w.Header().Set("WWW-Authenticate",
+						`Bearer realm="http://`+r.Host+`/token",service="registry.test",scope="repository:team/builder:pull"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Header().Set("Docker-Content-Digest", digest)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		host = strings.TrimPrefix(server.URL, "http://")
+		resolver = &HTTPDigestResolver{Client: server.Client(), Scheme: "http"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("resolves tags with an anonymous token", func() {
+		Expect(resolver.Digest(context.Background(), host+"/team/builder:1.22")).To(Equal(digest))
+	})
+
+	It("resolves images pinned by digest without a request", func() {
+		server.Close()
+		Expect(resolver.Digest(context.Background(), host+"/team/builder:1.22@"+digest)).To(Equal(digest))
+	})
+
+	It("returns an error for missing tags", func() {
+		_, err := resolver.Digest(context.Background(), host+"/team/builder:1.23")
+		Expect(err).To(MatchError(ContainSubstring("404")))
+	})
+
+	DescribeTable("parses image references",
+		func(image, host, repository, reference string) {
+			h, repo, ref, err := ParseImage(image)
+			Expect(err).NotTo(HaveOccurred())
+			Expect([]string{h, repo, ref}).To(Equal([]string{host, repository, reference}))
+		},
+		Entry(nil, "golang", "registry-1.docker.io", "library/golang", "latest"),
+		Entry(nil, "golang:1.22", "registry-1.docker.io", "library/golang", "1.22"),
+		Entry(nil, "team/builder:1.0", "registry-1.docker.io", "team/builder", "1.0"),
+		Entry(nil, "ghcr.io/team/builder", "ghcr.io", "team/builder", "latest"),
+		Entry(nil, "localhost:5000/builder:dev", "localhost:5000", "builder", "dev"),
+		Entry(nil, "ghcr.io/team/builder:1.0@sha256:abc", "ghcr.io", "team/builder", "sha256:abc"),
+	)
+})
@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry contains clients used to query package registries before
+// publishing, and image registries for the digests of tags.
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Checker reports whether a package version has already been published.
+type Checker interface {
+	Exists(ctx context.Context, registryURL, packageName, version string) (bool, error)
+}
+
+// HTTPChecker checks for a published version by issuing a HEAD request against
+// <registryURL>/<packageName>/<version>. A 2xx response means the version exists,
+// a 404 means it does not, anything else is reported as an error.
+type HTTPChecker struct {
+	Client *http.Client
+}
+
+// NewHTTPChecker returns an HTTPChecker using a client with the given timeout.
+func NewHTTPChecker(timeout time.Duration) *HTTPChecker {
+	return &HTTPChecker{Client: &http.Client{Timeout: timeout}}
+}
+
+// escapePath escapes each "/"-separated segment of a package path on its own so
+// that multi-segment names such as "team/app" keep their separators.
+func escapePath(packageName string) string {
+	segments := strings.Split(packageName, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Exists implements Checker.
+func (c *HTTPChecker) Exists(ctx context.Context, registryURL, packageName, version string) (bool, error) {
+	target := strings.TrimSuffix(registryURL, "/") + "/" + escapePath(packageName) + "/" + url.PathEscape(version)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		return false, err
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %q checking %s", resp.Status, target)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTPChecker", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodHead))
+			switch r.URL.EscapedPath() {
+			case "/pkg/1.0.0", "/team/web%20ui/1.0.0":
+				w.WriteHeader(http.StatusOK)
+			case "/pkg/2.0.0":
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reports existing and missing versions", func() {
+		checker := NewHTTPChecker(time.Second)
+
+		exists, err := checker.Exists(context.Background(), server.URL+"/", "pkg", "1.0.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		exists, err = checker.Exists(context.Background(), server.URL, "pkg", "2.0.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("keeps the separators of multi-segment package paths", func() {
+		exists, err := NewHTTPChecker(time.Second).Exists(context.Background(), server.URL, "team/web ui", "1.0.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+
+	It("returns an error on unexpected responses", func() {
+		_, err := NewHTTPChecker(time.Second).Exists(context.Background(), server.URL, "pkg", "3.0.0")
+		Expect(err).To(HaveOccurred())
+	})
+})
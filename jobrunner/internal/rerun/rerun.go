@@ -0,0 +1,247 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rerun creates a LeviathanBuild that runs a build again. An exact rerun
+// pins the run to the build environment recorded in the status of the build: the
+// digests of the images it ran and the values of its parameters.
+//
+// A rerun may override the values of parameters of the build for that run only.
+// The overrides are recorded on the new build, the spec of the build it reruns
+// is left as is.
+package rerun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const (
+	// RerunOfAnnotation records the namespace/name of the build a build reruns
+	RerunOfAnnotation = "jcrs.jcrs.dev/rerun-of"
+	// RerunIndexAnnotation records the index of the run an exact rerun replays
+	RerunIndexAnnotation = "jcrs.jcrs.dev/rerun-index"
+	// ExpectedSourceRevisionAnnotation records the source revision an exact rerun
+	// is expected to build
+	ExpectedSourceRevisionAnnotation = "jcrs.jcrs.dev/expected-source-revision"
+	// ParameterOverridesAnnotation records the values of parameters overridden for
+	// the run of a build, as a JSON object of strings
+	ParameterOverridesAnnotation = "jcrs.jcrs.dev/parameter-overrides"
+)
+
+// ErrNoBuildEnvironment is returned for an exact rerun of a build without a
+// recorded build environment.
+var ErrNoBuildEnvironment = errors.New("build has no recorded build environment")
+
+// Options configure a rerun.
+type Options struct {
+	// Exact pins the rerun to the build environment of the latest run of the build.
+	Exact bool
+	// Name of the new build. It's generated from the name of the build when empty.
+	Name string
+	// OperatorVersion is the version of the running controller, compared to the
+	// version that ran the build. It isn't compared when empty.
+	OperatorVersion string
+	// Parameters override the values of parameters of the build, by name, for the
+	// rerun only. They're validated against the parameters the build declares when
+	// its Job is constructed.
+	Parameters map[string]string
+}
+
+// New returns a LeviathanBuild that reruns lvBuild, with the warnings about what
+// an exact rerun can't pin.
+func New(lvBuild *jcrsv1.LeviathanBuild, opts Options) (*jcrsv1.LeviathanBuild, []string, error) {
+	rerun := &jcrsv1.LeviathanBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   lvBuild.Namespace,
+			Name:        opts.Name,
+			Labels:      maps.Clone(lvBuild.Labels),
+			Annotations: map[string]string{RerunOfAnnotation: lvBuild.Namespace + "/" + lvBuild.Name},
+		},
+		Spec: *lvBuild.Spec.DeepCopy(),
+	}
+	// A rerun is asked for, so it runs even if the build is suspended
+	rerun.Spec.Suspend = false
+	if rerun.Name == "" {
+		rerun.GenerateName = lvBuild.Name + "-rerun-"
+	}
+	if !opts.Exact {
+		return rerun, nil, setParameterOverrides(rerun, nil, opts.Parameters)
+	}
+
+	env := lvBuild.Status.BuildEnvironment
+	if env == nil {
+		return nil, nil, ErrNoBuildEnvironment
+	}
+	if env.Generation != lvBuild.Generation {
+		return nil, nil, fmt.Errorf("spec changed since run %d: recorded generation %d, current generation %d",
+			env.RunIndex, env.Generation, lvBuild.Generation)
+	}
+	rerun.Annotations[RerunIndexAnnotation] = strconv.FormatInt(env.RunIndex, 10)
+
+	var warnings []string
+	if opts.OperatorVersion != "" && env.OperatorVersion != opts.OperatorVersion {
+		warnings = append(warnings, fmt.Sprintf("run %d was created by controller version %q, not %q",
+			env.RunIndex, env.OperatorVersion, opts.OperatorVersion))
+	}
+	if env.SourceRevision != "" {
+		rerun.Annotations[ExpectedSourceRevisionAnnotation] = env.SourceRevision
+	}
+	// The overrides of the run replayed are part of its build environment
+	recorded, err := ParameterOverrides(lvBuild)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := setParameterOverrides(rerun, recorded, opts.Parameters); err != nil {
+		return nil, nil, err
+	}
+	switch lvBuild.Spec.SourceType {
+	case jcrsv1.GitSource, jcrsv1.S3Source, jcrsv1.HTTPSource:
+		warnings = append(warnings, fmt.Sprintf("%s sources are fetched again and can't be pinned to revision %q",
+			lvBuild.Spec.SourceType, env.SourceRevision))
+	}
+
+	containers := make(map[string]jcrsv1.BuildContainer, len(env.Containers))
+	for _, c := range env.Containers {
+		containers[c.Name] = c
+	}
+	podSpec := &rerun.Spec.JobTemplate.Spec.Template.Spec
+	for _, list := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers, rerun.Spec.Sidecars} {
+		for i := range list {
+			warnings = append(warnings, pin(&list[i], containers)...)
+		}
+	}
+	return rerun, warnings, nil
+}
+
+// ParameterOverrides returns the parameters overridden for the runs of lvBuild, by
+// name, or nil when none are.
+func ParameterOverrides(lvBuild *jcrsv1.LeviathanBuild) (map[string]string, error) {
+	value, ok := lvBuild.Annotations[ParameterOverridesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", ParameterOverridesAnnotation, err)
+	}
+	if err := validateParameterNames(overrides); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", ParameterOverridesAnnotation, err)
+	}
+	return overrides, nil
+}
+
+// ParseParameter parses a parameter override given as NAME=VALUE.
+func ParseParameter(s string) (name, value string, err error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", "", fmt.Errorf("parameter %q isn't NAME=VALUE", s)
+	}
+	if errs := validation.IsEnvVarName(name); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid parameter name %q: %s", name, strings.Join(errs, ", "))
+	}
+	return name, value, nil
+}
+
+// setParameterOverrides records recorded, then overrides, as the parameter
+// overrides of rerun.
+func setParameterOverrides(rerun *jcrsv1.LeviathanBuild, recorded, overrides map[string]string) error {
+	if err := validateParameterNames(overrides); err != nil {
+		return err
+	}
+	merged := maps.Clone(recorded)
+	if merged == nil {
+		merged = make(map[string]string, len(overrides))
+	}
+	maps.Copy(merged, overrides)
+	if len(merged) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	rerun.Annotations[ParameterOverridesAnnotation] = string(data)
+	return nil
+}
+
+// validateParameterNames checks that the names of overrides are names of
+// environment variables, like the parameters they override.
+func validateParameterNames(overrides map[string]string) error {
+	for name := range overrides {
+		if errs := validation.IsEnvVarName(name); len(errs) > 0 {
+			return fmt.Errorf("invalid parameter name %q: %s", name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// pin pins the image and the parameters of c to the ones recorded in containers.
+func pin(c *corev1.Container, containers map[string]jcrsv1.BuildContainer) []string {
+	recorded, ok := containers[c.Name]
+	if !ok {
+		return []string{fmt.Sprintf("container %q isn't in the build environment", c.Name)}
+	}
+	var warnings []string
+	if ref := digestRef(recorded.Image, recorded.ImageID); ref != "" {
+		c.Image = ref
+	} else {
+		warnings = append(warnings, fmt.Sprintf("image of container %q has no recorded digest", c.Name))
+		if recorded.Image != "" {
+			c.Image = recorded.Image
+		}
+	}
+	for i := range c.Env {
+		if value, ok := recorded.Parameters[c.Env[i].Name]; ok && c.Env[i].ValueFrom == nil {
+			c.Env[i].Value = value
+		}
+	}
+	return warnings
+}
+
+// digestRef returns the reference of image pinned to the digest of imageID, as
+// reported in the status of a container, or "" without a digest. Runtimes report
+// either a full reference, repo@sha256:..., or the bare digest.
+func digestRef(image, imageID string) string {
+	imageID = strings.TrimPrefix(imageID, "docker-pullable://")
+	switch {
+	case strings.Contains(imageID, "@"):
+		return imageID
+	case strings.HasPrefix(imageID, "sha256:") && image != "":
+		return repository(image) + "@" + imageID
+	}
+	return ""
+}
+
+// repository returns image without its tag or digest.
+func repository(image string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	return image
+}
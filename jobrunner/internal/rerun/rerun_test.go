@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rerun
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Rerun", func() {
+	var lvBuild *jcrsv1.LeviathanBuild
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ci", Name: "web", Generation: 2, Labels: map[string]string{"team": "web"},
+			},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				SourceType: jcrsv1.LocalSource,
+				Sidecars:   []corev1.Container{{Name: "cache", Image: "redis:7"}},
+			},
+			Status: jcrsv1.LeviathanBuildStatus{BuildEnvironment: &jcrsv1.BuildEnvironment{
+				RunIndex:        4,
+				Generation:      2,
+				OperatorVersion: "v1.4.0",
+				SourceRevision:  "0123abc",
+				Containers: []jcrsv1.BuildContainer{
+					{Name: "build", Image: "builders/go:1.24", ImageID: "sha256:bbb",
+						Parameters: map[string]string{"GOFLAGS": "-mod=vendor"}},
+					{Name: "cache", Image: "redis:7", ImageID: "docker-pullable://docker.io/library/redis@sha256:ccc"},
+				},
+			}},
+		}
+		lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{
+			Name: "build",
+			Env:  []corev1.EnvVar{{Name: "GOFLAGS", Value: "-mod=mod"}},
+		}}
+	})
+
+	It("copies the spec of the build", func() {
+		rerun, warnings, err := New(lvBuild, Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+
+		Expect(rerun.GenerateName).To(Equal("web-rerun-"))
+		Expect(rerun.Namespace).To(Equal("ci"))
+		Expect(rerun.Labels).To(Equal(lvBuild.Labels))
+		Expect(rerun.Annotations).To(Equal(map[string]string{RerunOfAnnotation: "ci/web"}))
+		Expect(rerun.Spec).To(Equal(lvBuild.Spec))
+	})
+
+	It("runs suspended builds again", func() {
+		lvBuild.Spec.Suspend = true
+		rerun, _, err := New(lvBuild, Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rerun.Spec.Suspend).To(BeFalse())
+	})
+
+	It("pins an exact rerun to the build environment", func() {
+		rerun, warnings, err := New(lvBuild, Options{Exact: true, Name: "web-replay", OperatorVersion: "v1.4.0"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+
+		Expect(rerun.Name).To(Equal("web-replay"))
+		Expect(rerun.Annotations).To(Equal(map[string]string{
+			RerunOfAnnotation:                "ci/web",
+			RerunIndexAnnotation:             "4",
+			ExpectedSourceRevisionAnnotation: "0123abc",
+		}))
+		build := rerun.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+		Expect(build.Image).To(Equal("builders/go@sha256:bbb"))
+		Expect(build.Env).To(ConsistOf(corev1.EnvVar{Name: "GOFLAGS", Value: "-mod=vendor"}))
+		Expect(rerun.Spec.Sidecars[0].Image).To(Equal("docker.io/library/redis@sha256:ccc"))
+		Expect(lvBuild.Spec.Sidecars[0].Image).To(Equal("redis:7"))
+	})
+
+	It("warns about what an exact rerun can't pin", func() {
+		lvBuild.Spec.SourceType = jcrsv1.GitSource
+		lvBuild.Status.BuildEnvironment.Containers[1].ImageID = ""
+
+		_, warnings, err := New(lvBuild, Options{Exact: true, OperatorVersion: "v1.5.0"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(ConsistOf(
+			ContainSubstring(`controller version "v1.4.0", not "v1.5.0"`),
+			ContainSubstring(`Git sources are fetched again`),
+			ContainSubstring(`image of container "cache" has no recorded digest`),
+		))
+	})
+
+	It("refuses an exact rerun without a matching build environment", func() {
+		lvBuild.Generation = 3
+		_, _, err := New(lvBuild, Options{Exact: true})
+		Expect(err).To(MatchError(ContainSubstring("spec changed since run 4")))
+
+		lvBuild.Status.BuildEnvironment = nil
+		_, _, err = New(lvBuild, Options{Exact: true})
+		Expect(err).To(MatchError(ErrNoBuildEnvironment))
+	})
+
+	It("records parameter overrides on the new build only", func() {
+		rerun, _, err := New(lvBuild, Options{Parameters: map[string]string{"VERSION": "1.2.3-hotfix"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rerun.Annotations).To(HaveKeyWithValue(ParameterOverridesAnnotation, `{"VERSION":"1.2.3-hotfix"}`))
+		Expect(ParameterOverrides(rerun)).To(Equal(map[string]string{"VERSION": "1.2.3-hotfix"}))
+		Expect(rerun.Spec).To(Equal(lvBuild.Spec))
+		Expect(lvBuild.Annotations).To(BeNil())
+
+		By("not carrying them over to a rerun of the new build")
+		again, _, err := New(rerun, Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again.Annotations).NotTo(HaveKey(ParameterOverridesAnnotation))
+
+		By("refusing names that aren't parameter names")
+		_, _, err = New(lvBuild, Options{Parameters: map[string]string{"1VERSION": "1.2.3"}})
+		Expect(err).To(MatchError(ContainSubstring(`invalid parameter name "1VERSION"`)))
+	})
+
+	It("replays the parameter overrides of the run in an exact rerun", func() {
+		lvBuild.Annotations = map[string]string{ParameterOverridesAnnotation: `{"CHANNEL":"beta","VERSION":"1.2.3-hotfix"}`}
+		rerun, _, err := New(lvBuild, Options{Exact: true, Parameters: map[string]string{"CHANNEL": "stable"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ParameterOverrides(rerun)).To(Equal(map[string]string{"CHANNEL": "stable", "VERSION": "1.2.3-hotfix"}))
+	})
+
+	DescribeTable("parses parameter overrides",
+		func(s, name, value, msg string) {
+			n, v, err := ParseParameter(s)
+			if msg != "" {
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect([]string{n, v}).To(Equal([]string{name, value}))
+		},
+		Entry("name and value", "version=1.2.3-hotfix", "version", "1.2.3-hotfix", ""),
+		Entry("value with =", "LDFLAGS=-X main.v=1", "LDFLAGS", "-X main.v=1", ""),
+		Entry("empty value", "TAG=", "TAG", "", ""),
+		Entry("no value", "TAG", "", "", "isn't NAME=VALUE"),
+		Entry("invalid name", "1TAG=x", "", "", "invalid parameter name"),
+	)
+
+	DescribeTable("pins images to their digest",
+		func(image, imageID, ref string) {
+			Expect(digestRef(image, imageID)).To(Equal(ref))
+		},
+		Entry("full reference", "redis:7", "docker.io/library/redis@sha256:ccc", "docker.io/library/redis@sha256:ccc"),
+		Entry("docker-pullable reference", "redis:7", "docker-pullable://redis@sha256:ccc", "redis@sha256:ccc"),
+		Entry("bare digest", "registry:5000/builders/go:1.24", "sha256:bbb", "registry:5000/builders/go@sha256:bbb"),
+		Entry("bare digest of a pinned image", "go@sha256:aaa", "sha256:bbb", "go@sha256:bbb"),
+		Entry("no digest", "redis:7", "", ""),
+	)
+})
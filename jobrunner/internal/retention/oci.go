@@ -0,0 +1,218 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// manifestMediaTypes are the manifests a tag is resolved to.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// OCIPruner deletes versions published as tags of the <registryURL>/<packageName>
+// repository of an OCI registry, through the distribution API. A tag is deleted
+// by deleting the manifest it points to, as not every registry supports deleting
+// tags, so the other tags of that manifest are deleted along with it.
+//
+// Registries using token authentication are supported, the token being requested
+// with the Username and Password, if any.
+type OCIPruner struct {
+	Client *http.Client
+
+	Username string
+	Password string
+}
+
+// NewOCIPruner returns an OCIPruner using a client with the given timeout.
+func NewOCIPruner(username, password string, timeout time.Duration) *OCIPruner {
+	return &OCIPruner{
+		Client:   &http.Client{Timeout: timeout},
+		Username: username,
+		Password: password,
+	}
+}
+
+// Delete implements Pruner.
+func (p *OCIPruner) Delete(ctx context.Context, registryURL, packageName, version, digest string) error {
+	if !strings.Contains(registryURL, "://") {
+		registryURL = "https://" + registryURL
+	}
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return err
+	}
+	repository := strings.TrimPrefix(strings.TrimSuffix(u.Path, "/")+"/"+packageName, "/")
+	manifests := u.Scheme + "://" + u.Host + "/v2/" + repository + "/manifests/"
+
+	// The tag is resolved first, so the digest of a tag that was overwritten since isn't deleted
+	resp, err := p.do(ctx, http.MethodHead, manifests+url.PathEscape(version))
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("unexpected status %q resolving %s:%s", resp.Status, repository, version)
+	}
+	if resolved := resp.Header.Get("Docker-Content-Digest"); resolved != "" {
+		digest = resolved
+	}
+	if digest == "" {
+		return fmt.Errorf("the registry didn't report the digest of %s:%s", repository, version)
+	}
+
+	resp, err = p.do(ctx, http.MethodDelete, manifests+digest)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNotFound && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		return fmt.Errorf("unexpected status %q deleting %s@%s", resp.Status, repository, digest)
+	}
+	return nil
+}
+
+// do sends a request to the registry, authenticating when challenged. The body of
+// the response is discarded.
+func (p *OCIPruner) do(ctx context.Context, method, target string) (*http.Response, error) {
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	send := func(authorization string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		_ = resp.Body.Close()
+		return resp, nil
+	}
+
+	resp, err := send("")
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	switch {
+	case strings.EqualFold(scheme, "Bearer"):
+		token, err := p.token(ctx, client, params)
+		if err != nil {
+			return nil, err
+		}
+		return send("Bearer " + token)
+	case strings.EqualFold(scheme, "Basic") && p.Username != "":
+		return send("Basic " + base64.StdEncoding.EncodeToString([]byte(p.Username+":"+p.Password)))
+	}
+	return resp, nil
+}
+
+// token requests a token from the authorization service of a Bearer challenge.
+// See https://distribution.github.io/distribution/spec/auth/token/
+func (p *OCIPruner) token(ctx context.Context, client *http.Client, params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	for _, name := range []string{"service", "scope"} {
+		if value := params[name]; value != "" {
+			query.Set(name, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if p.Username != "" {
+		req.SetBasicAuth(p.Username, p.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %q requesting a token from %s", resp.Status, realm.Host)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("no token in the response of %s", realm.Host)
+}
+
+// parseChallenge parses a WWW-Authenticate header with a single challenge, e.g.
+// `Bearer realm="https://auth.example.com/token",scope="repository:a/b:pull,delete"`.
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		name, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if strings.HasPrefix(value, `"`) {
+			// Quoted values may contain commas
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[name] = value[1:]
+				break
+			}
+			params[name] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			params[name], rest, _ = strings.Cut(value, ",")
+		}
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return scheme, params
+}
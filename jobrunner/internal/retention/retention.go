@@ -0,0 +1,84 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package retention deletes the package versions published by builds from the
+// storage they were published to, as decided by their artifact retention policy.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"test.jcrs.dev/jobrunner/internal/archive"
+)
+
+// Pruner deletes published versions of packages.
+type Pruner interface {
+	// Delete deletes a version of a package published under registryURL. The
+	// digest reported by the build is given when known. Deleting a version that
+	// doesn't exist succeeds.
+	Delete(ctx context.Context, registryURL, packageName, version, digest string) error
+}
+
+// S3Pruner deletes versions published to S3 compatible storage, as the objects
+// <registryURL>/<packageName>/<version>. The registryURL is a path-style URL,
+// <endpoint>/<bucket>[/<prefix>].
+type S3Pruner struct {
+	Client *http.Client
+
+	// Region the requests are signed for
+	Region string
+
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// NewS3Pruner returns an S3Pruner using a client with the given timeout.
+func NewS3Pruner(region, accessKeyID, secretAccessKey string, timeout time.Duration) *S3Pruner {
+	return &S3Pruner{
+		Client:          &http.Client{Timeout: timeout},
+		Region:          region,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+	}
+}
+
+// Delete implements Pruner.
+func (p *S3Pruner) Delete(ctx context.Context, registryURL, packageName, version, _ string) error {
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return err
+	}
+	bucket, prefix, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if u.Scheme == "" || u.Host == "" || bucket == "" {
+		return fmt.Errorf("registry URL %q isn't a path-style bucket URL", registryURL)
+	}
+
+	store := &archive.S3Store{
+		Client:          p.Client,
+		Endpoint:        u.Scheme + "://" + u.Host,
+		Bucket:          bucket,
+		Region:          p.Region,
+		AccessKeyID:     p.AccessKeyID,
+		SecretAccessKey: p.SecretAccessKey,
+	}
+	return store.Delete(ctx, path.Join(prefix, packageName, version))
+}
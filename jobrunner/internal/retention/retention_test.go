@@ -0,0 +1,111 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("S3Pruner", func() {
+	It("deletes the object of the version", func() {
+		var requests []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=key/"))
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		DeferCleanup(server.Close)
+
+		pruner := NewS3Pruner("auto", "key", "secret", time.Second)
+		Expect(pruner.Delete(context.Background(), server.URL+"/packages/team/", "web", "1.2.3", "")).To(Succeed())
+		Expect(pruner.Delete(context.Background(), server.URL+"/packages", "web", "1.2.4", "")).To(Succeed())
+		Expect(requests).To(Equal([]string{"DELETE /packages/team/web/1.2.3", "DELETE /packages/web/1.2.4"}))
+
+		Expect(pruner.Delete(context.Background(), server.URL, "web", "1.2.3", "")).To(MatchError(ContainSubstring("bucket")))
+	})
+})
+
+var _ = Describe("OCIPruner", func() {
+	var (
+		requests []string
+		server   *httptest.Server
+		tags     map[string]string
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		tags = map[string]string{"1.2.3": "sha256:0123"}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(user + ":" + password).To(Equal("robot:secret"))
+			Expect(r.URL.Query().Get("scope")).To(Equal("repository:team/web:pull,delete"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "t0k3n"})
+		})
+		mux.HandleFunc("/v2/team/web/manifests/{reference}", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer t0k3n" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry",scope="repository:team/web:pull,delete"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			requests = append(requests, r.Method+" "+r.PathValue("reference"))
+			switch r.Method {
+			case http.MethodHead:
+				digest, ok := tags[r.PathValue("reference")]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Docker-Content-Digest", digest)
+			case http.MethodDelete:
+				w.WriteHeader(http.StatusAccepted)
+			}
+		})
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+	})
+
+	It("deletes the manifest the tag points to", func() {
+		pruner := NewOCIPruner("robot", "secret", time.Second)
+		Expect(pruner.Delete(context.Background(), server.URL+"/team", "web", "1.2.3", "sha256:stale")).To(Succeed())
+		Expect(requests).To(Equal([]string{"HEAD 1.2.3", "DELETE sha256:0123"}))
+	})
+
+	It("succeeds when the tag is already gone", func() {
+		pruner := NewOCIPruner("robot", "secret", time.Second)
+		Expect(pruner.Delete(context.Background(), server.URL+"/team/", "web", "1.0.0", "")).To(Succeed())
+		Expect(requests).To(Equal([]string{"HEAD 1.0.0"}))
+	})
+
+	It("parses authentication challenges", func() {
+		scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service=registry, scope="repository:a/b:pull,delete"`)
+		Expect(scheme).To(Equal("Bearer"))
+		Expect(params).To(Equal(map[string]string{
+			"realm":   "https://auth.example.com/token",
+			"service": "registry",
+			"scope":   "repository:a/b:pull,delete",
+		}))
+	})
+})
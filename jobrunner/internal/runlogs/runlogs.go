@@ -0,0 +1,203 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package runlogs reads the logs of a run of a build: the logs of the init
+// containers and containers of the pods of its Job, merged into one stream
+// whose lines are prefixed with the step, or container, they come from.
+package runlogs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Source streams the logs of the containers of pods.
+type Source interface {
+	// Stream returns the logs of container of pod. With follow, the logs are
+	// followed until the container exits or ctx is done.
+	Stream(ctx context.Context, pod *corev1.Pod, container string, follow bool) (io.ReadCloser, error)
+}
+
+// ClientsetSource streams the logs of containers from the API server.
+type ClientsetSource struct {
+	Clientset kubernetes.Interface
+}
+
+// Stream implements Source.
+func (s ClientsetSource) Stream(ctx context.Context, pod *corev1.Pod, container string, follow bool) (io.ReadCloser, error) {
+	return s.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: container,
+		Follow:    follow,
+	}).Stream(ctx)
+}
+
+// Lister returns the pods of a run, and reports whether the run has finished.
+type Lister func(ctx context.Context) ([]corev1.Pod, bool, error)
+
+// ArchiveKey returns the key the logs of the run runIndex of a build are
+// archived under, next to the record of the build.
+func ArchiveKey(namespace, build string, uid types.UID, runIndex int64) string {
+	return path.Join(namespace, build, string(uid), fmt.Sprintf("run-%d.log", runIndex))
+}
+
+// Write writes the logs of the containers of pods that have started to w, one
+// container after the other in the order they ran.
+func Write(ctx context.Context, src Source, pods []corev1.Pod, w io.Writer) error {
+	out := &lineWriter{w: w}
+	pods = slices.Clone(pods)
+	slices.SortStableFunc(pods, func(a, b corev1.Pod) int {
+		return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+	})
+	for i := range pods {
+		for _, status := range startedContainers(&pods[i]) {
+			if err := copyLines(ctx, src, &pods[i], status.Name, false, prefix(pods, &pods[i], status.Name), out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Follow writes the logs of the containers of the pods listed by list to w as
+// they run, checking for new containers every interval, until list reports the
+// run has finished and the logs of every container have been written.
+// Containers that restart, and the pods the Job creates in place of failed
+// ones, are followed too. The lines of containers running at the same time are
+// interleaved.
+func Follow(ctx context.Context, src Source, list Lister, interval time.Duration, w io.Writer) error {
+	out := &lineWriter{w: w}
+	started := make(map[string]bool)
+	var wg sync.WaitGroup
+	var errsMu sync.Mutex
+	var errs []error
+
+	defer wg.Wait()
+	for {
+		pods, finished, err := list(ctx)
+		if err != nil {
+			return err
+		}
+		for i := range pods {
+			pod := &pods[i]
+			for _, status := range startedContainers(pod) {
+				// A container that restarts is followed again from its new start
+				key := fmt.Sprintf("%s/%s/%d", pod.UID, status.Name, status.RestartCount)
+				if started[key] {
+					continue
+				}
+				started[key] = true
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := copyLines(ctx, src, pod, status.Name, true, prefix(pods, pod, status.Name), out); err != nil {
+						errsMu.Lock()
+						errs = append(errs, err)
+						errsMu.Unlock()
+					}
+				}()
+			}
+		}
+		if finished {
+			wg.Wait()
+			return errors.Join(errs...)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// startedContainers returns the statuses of the init containers and containers
+// of pod that have started, in the order they run.
+func startedContainers(pod *corev1.Pod) []corev1.ContainerStatus {
+	var started []corev1.ContainerStatus
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			if status.State.Running != nil || status.State.Terminated != nil {
+				started = append(started, status)
+			}
+		}
+	}
+	return started
+}
+
+// prefix returns the prefix of the lines of container of pod: the name of the
+// container, which is the name of the step for the steps of a pipeline, and the
+// name of the pod when the run has several.
+func prefix(pods []corev1.Pod, pod *corev1.Pod, container string) string {
+	if len(pods) > 1 {
+		return "[" + pod.Name + "/" + container + "] "
+	}
+	return "[" + container + "] "
+}
+
+// copyLines writes the logs of container of pod to out, each line prefixed
+// with prefix.
+func copyLines(ctx context.Context, src Source, pod *corev1.Pod, container string, follow bool, prefix string, out *lineWriter) error {
+	logs, err := src.Stream(ctx, pod, container, follow)
+	if err != nil {
+		return fmt.Errorf("logs of container %s of pod %s: %w", container, pod.Name, err)
+	}
+	defer func() { _ = logs.Close() }()
+
+	reader := bufio.NewReader(logs)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if werr := out.writeLine(prefix, line); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("logs of container %s of pod %s: %w", container, pod.Name, err)
+		}
+	}
+}
+
+// lineWriter writes whole lines to w, one at a time.
+type lineWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// writeLine writes line to w with prefix, ending it with a newline if it
+// doesn't have one.
+func (l *lineWriter) writeLine(prefix, line string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if line[len(line)-1] != '\n' {
+		line += "\n"
+	}
+	_, err := io.WriteString(l.w, prefix+line)
+	return err
+}
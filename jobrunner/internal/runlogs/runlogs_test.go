@@ -0,0 +1,160 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runlogs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// fakeSource returns the logs of containers by "<pod>/<container>", and
+// records the containers it streams.
+type fakeSource struct {
+	mu       sync.Mutex
+	logs     map[string]string
+	streamed []string
+}
+
+func (s *fakeSource) Stream(_ context.Context, pod *corev1.Pod, container string, _ bool) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := pod.Name + "/" + container
+	s.streamed = append(s.streamed, key)
+	logs, ok := s.logs[key]
+	if !ok {
+		return nil, errors.New("container not found")
+	}
+	return io.NopCloser(strings.NewReader(logs)), nil
+}
+
+// runPod returns a pod whose init containers and containers have the given states.
+func runPod(name string, created time.Time, init, main map[string]corev1.ContainerState) corev1.Pod {
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              name,
+		UID:               types.UID(name + "-uid"),
+		CreationTimestamp: metav1.NewTime(created),
+	}}
+	for _, name := range []string{"fetch", "compile"} {
+		if state, ok := init[name]; ok {
+			pod.Status.InitContainerStatuses = append(pod.Status.InitContainerStatuses, corev1.ContainerStatus{Name: name, State: state})
+		}
+	}
+	for name, state := range main {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{Name: name, State: state})
+	}
+	return pod
+}
+
+var (
+	running    = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	terminated = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}
+	waiting    = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}
+)
+
+var _ = Describe("Run logs", func() {
+	var (
+		ctx context.Context
+		src *fakeSource
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		src = &fakeSource{logs: map[string]string{
+			"web-1/fetch":   "fetched\n",
+			"web-1/compile": "compiling\ndone",
+			"web-1/build":   "packaged\n",
+			"web-2/build":   "retried\n",
+		}}
+	})
+
+	It("writes the logs of the steps in the order they ran", func() {
+		pods := []corev1.Pod{runPod("web-1", now,
+			map[string]corev1.ContainerState{"fetch": terminated, "compile": terminated},
+			map[string]corev1.ContainerState{"build": terminated})}
+		var out bytes.Buffer
+		Expect(Write(ctx, src, pods, &out)).To(Succeed())
+		Expect(out.String()).To(Equal("[fetch] fetched\n[compile] compiling\n[compile] done\n[build] packaged\n"))
+	})
+
+	It("prefixes the lines with their pod when the run has several", func() {
+		pods := []corev1.Pod{
+			runPod("web-2", now.Add(time.Minute), nil, map[string]corev1.ContainerState{"build": terminated}),
+			runPod("web-1", now, nil, map[string]corev1.ContainerState{"build": terminated}),
+		}
+		var out bytes.Buffer
+		Expect(Write(ctx, src, pods, &out)).To(Succeed())
+		Expect(out.String()).To(Equal("[web-1/build] packaged\n[web-2/build] retried\n"))
+	})
+
+	It("skips the containers that haven't started", func() {
+		pods := []corev1.Pod{runPod("web-1", now,
+			map[string]corev1.ContainerState{"fetch": running},
+			map[string]corev1.ContainerState{"build": waiting})}
+		var out bytes.Buffer
+		Expect(Write(ctx, src, pods, &out)).To(Succeed())
+		Expect(out.String()).To(Equal("[fetch] fetched\n"))
+	})
+
+	It("follows the containers as they start and restart until the run finishes", func() {
+		first := runPod("web-1", now,
+			map[string]corev1.ContainerState{"fetch": running},
+			map[string]corev1.ContainerState{"build": waiting})
+		second := runPod("web-1", now,
+			map[string]corev1.ContainerState{"fetch": terminated, "compile": terminated},
+			map[string]corev1.ContainerState{"build": running})
+		restarted := second.DeepCopy()
+		restarted.Status.ContainerStatuses[0].RestartCount = 1
+		listings := [][]corev1.Pod{{first}, {second}, {second}, {*restarted}}
+
+		calls := 0
+		list := func(context.Context) ([]corev1.Pod, bool, error) {
+			pods := listings[calls]
+			calls++
+			return pods, calls == len(listings), nil
+		}
+		var out bytes.Buffer
+		Expect(Follow(ctx, src, list, time.Millisecond, &out)).To(Succeed())
+		Expect(calls).To(Equal(4))
+		Expect(src.streamed).To(ConsistOf("web-1/fetch", "web-1/compile", "web-1/build", "web-1/build"))
+		Expect(strings.Count(out.String(), "[build] packaged\n")).To(Equal(2))
+		Expect(out.String()).To(ContainSubstring("[fetch] fetched\n"))
+	})
+
+	It("reports the containers whose logs can't be read", func() {
+		pods := []corev1.Pod{runPod("web-3", now, nil, map[string]corev1.ContainerState{"build": terminated})}
+		list := func(context.Context) ([]corev1.Pod, bool, error) { return pods, true, nil }
+		err := Follow(ctx, src, list, time.Millisecond, io.Discard)
+		Expect(err).To(MatchError(ContainSubstring("logs of container build of pod web-3: container not found")))
+	})
+
+	It("archives the logs of a run next to the record of its build", func() {
+		Expect(ArchiveKey("team-a", "web", "web-uid", 3)).To(Equal("team-a/web/web-uid/run-3.log"))
+	})
+})
@@ -0,0 +1,155 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schedule parses the standard five field cron schedules, e.g.
+// "0 22 * * 1-5", and finds the times they match.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron schedule. It matches times at the minute.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// Cron matches either field when both the day of the month and the day of
+	// the week are restricted
+	domStar, dowStar bool
+}
+
+type field struct {
+	min, max int
+	names    []string
+}
+
+var (
+	minutes = field{min: 0, max: 59}
+	hours   = field{min: 0, max: 23}
+	doms    = field{min: 1, max: 31}
+	months  = field{min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// Both 0 and 7 are Sunday
+	dows = field{min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// Parse parses a cron schedule of five space separated fields: minute, hour, day
+// of the month, month and day of the week. Fields are "*", values, ranges
+// ("1-5"), steps ("*/15", "0-30/10") or comma separated lists of those. Months
+// and days of the week may also be given by their three letter English names.
+func Parse(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in %q, found %d", spec, len(fields))
+	}
+	s := &Schedule{
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}
+	var err error
+	for i, f := range []struct {
+		bits *uint64
+		field
+	}{{&s.minute, minutes}, {&s.hour, hours}, {&s.dom, doms}, {&s.month, months}, {&s.dow, dows}} {
+		if *f.bits, err = f.parse(fields[i]); err != nil {
+			return nil, err
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parse returns the values matched by a field as a bit set.
+func (f field) parse(value string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rng, stepValue, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepValue); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		low, high := f.min, f.max
+		if rng != "*" {
+			first, last, isRange := strings.Cut(rng, "-")
+			var err error
+			if low, err = f.value(first); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = f.value(last); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = f.max
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses a single value of the field.
+func (f field) value(value string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(value, name) {
+			return i + f.min, nil
+		}
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q, expected %d-%d", value, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Matches reports whether the schedule matches the minute of t, in the location of t.
+func (s *Schedule) Matches(t time.Time) bool {
+	if s.minute&(1<<t.Minute()) == 0 || s.hour&(1<<t.Hour()) == 0 || s.month&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	domMatch := s.dom&(1<<t.Day()) != 0
+	dowMatch := s.dow&(1<<int(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Active reports whether t falls in a window of the given duration starting at a
+// time matched by the schedule, and if so when the window ends. When windows
+// overlap, the end of the last one is returned.
+func (s *Schedule) Active(t time.Time, duration time.Duration) (time.Time, bool) {
+	for start := t.Truncate(time.Minute); start.After(t.Add(-duration)); start = start.Add(-time.Minute) {
+		if s.Matches(start) {
+			return start.Add(duration), true
+		}
+	}
+	return time.Time{}, false
+}
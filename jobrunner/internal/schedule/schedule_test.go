@@ -0,0 +1,71 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schedule", func() {
+	// 2025-06-02 is a Monday
+	at := func(value string) time.Time {
+		t, err := time.Parse(time.DateTime, value)
+		Expect(err).NotTo(HaveOccurred())
+		return t
+	}
+
+	DescribeTable("matching times",
+		func(spec, value string, matches bool) {
+			s, err := Parse(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Matches(at(value))).To(Equal(matches))
+		},
+		Entry("every minute", "* * * * *", "2025-06-02 13:37:00", true),
+		Entry("a step", "*/15 * * * *", "2025-06-02 13:45:00", true),
+		Entry("outside a step", "*/15 * * * *", "2025-06-02 13:46:00", false),
+		Entry("a range of week days", "0 22 * * mon-fri", "2025-06-02 22:00:00", true),
+		Entry("the weekend", "0 22 * * sat,sun", "2025-06-02 22:00:00", false),
+		Entry("Sunday as 7", "0 0 * * 7", "2025-06-01 00:00:00", true),
+		Entry("a stepped range", "0-30/10 * * * *", "2025-06-02 13:20:00", true),
+		Entry("a month", "0 0 1 jul *", "2025-06-01 00:00:00", false),
+		Entry("the day of the month or of the week", "0 0 15 * mon", "2025-06-02 00:00:00", true),
+	)
+
+	It("rejects invalid schedules", func() {
+		for _, spec := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+			_, err := Parse(spec)
+			Expect(err).To(HaveOccurred(), spec)
+		}
+	})
+
+	It("finds the window a time falls in", func() {
+		s, err := Parse("0 22 * * mon-fri")
+		Expect(err).NotTo(HaveOccurred())
+
+		end, active := s.Active(at("2025-06-02 23:30:00"), 4*time.Hour)
+		Expect(active).To(BeTrue())
+		Expect(end).To(Equal(at("2025-06-03 02:00:00")))
+
+		_, active = s.Active(at("2025-06-03 02:00:00"), 4*time.Hour)
+		Expect(active).To(BeFalse())
+		_, active = s.Active(at("2025-06-02 21:59:00"), 4*time.Hour)
+		Expect(active).To(BeFalse())
+	})
+})
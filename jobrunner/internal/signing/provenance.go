@@ -0,0 +1,164 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+The provenance of a run follows SLSA provenance v1. The build is defined by the
+package and version it publishes and by its source; the builder images and the
+revision of the source the run resolved are its dependencies. Everything is read
+from the status of the build and the Job of the run, so the provenance is
+recorded by the controller rather than by the build itself.
+*/
+
+const (
+	// provenanceBuildType identifies the builds described by the provenance
+	provenanceBuildType = "https://jcrs.jcrs.dev/leviathanbuild/v1"
+
+	// builderID identifies the controller running builds
+	builderID = "https://jcrs.jcrs.dev/jobrunner"
+)
+
+// Provenance is the SLSA provenance predicate of a run.
+type Provenance struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition describes what a run built.
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   ExternalParameters   `json:"externalParameters"`
+	InternalParameters   InternalParameters   `json:"internalParameters,omitzero"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// ExternalParameters are the parameters of a run set by the user.
+type ExternalParameters struct {
+	Build       string `json:"build"`
+	Generation  int64  `json:"generation"`
+	PackageName string `json:"packageName,omitempty"`
+	Version     string `json:"version,omitempty"`
+	SourceType  string `json:"sourceType,omitempty"`
+	SourceURL   string `json:"sourceURL,omitempty"`
+}
+
+// InternalParameters are the parameters of a run set by the controller.
+type InternalParameters struct {
+	RunIndex int64 `json:"runIndex"`
+}
+
+// ResourceDescriptor describes an artifact a run depended on.
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// RunDetails describes how a run was executed.
+type RunDetails struct {
+	Builder  Builder       `json:"builder"`
+	Metadata BuildMetadata `json:"metadata"`
+}
+
+// Builder identifies the controller running a build.
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// BuildMetadata describes the Job of a run.
+type BuildMetadata struct {
+	InvocationID string     `json:"invocationId,omitempty"`
+	StartedOn    *time.Time `json:"startedOn,omitempty"`
+	FinishedOn   *time.Time `json:"finishedOn,omitempty"`
+}
+
+// NewProvenance returns the provenance of job, the Job of the latest run of lvBuild.
+func NewProvenance(lvBuild *jcrsv1.LeviathanBuild, job *batchv1.Job) Provenance {
+	provenance := Provenance{
+		BuildDefinition: BuildDefinition{
+			BuildType: provenanceBuildType,
+			ExternalParameters: ExternalParameters{
+				Build:       lvBuild.Namespace + "/" + lvBuild.Name,
+				Generation:  lvBuild.Generation,
+				PackageName: ptr.Deref(lvBuild.Spec.PackageName, ""),
+				SourceType:  string(lvBuild.Spec.SourceType),
+				SourceURL:   ptr.Deref(lvBuild.Spec.SourceURL, ""),
+			},
+			InternalParameters: InternalParameters{RunIndex: lvBuild.Status.RunIndex},
+		},
+		RunDetails: RunDetails{
+			Builder:  Builder{ID: builderID},
+			Metadata: BuildMetadata{InvocationID: string(job.UID)},
+		},
+	}
+	if target := lvBuild.Spec.PublishTarget; target != nil {
+		provenance.BuildDefinition.ExternalParameters.Version = target.Version
+	}
+	if start := job.Status.StartTime; start != nil {
+		provenance.RunDetails.Metadata.StartedOn = ptr.To(start.UTC())
+	}
+	if completion := job.Status.CompletionTime; completion != nil {
+		provenance.RunDetails.Metadata.FinishedOn = ptr.To(completion.UTC())
+	}
+
+	dependencies := []ResourceDescriptor{}
+	if revision := lvBuild.Status.SourceRevision; revision != "" {
+		source := ResourceDescriptor{Name: "source", URI: provenance.BuildDefinition.ExternalParameters.SourceURL}
+		if algorithm, digest, ok := strings.Cut(revision, ":"); ok {
+			source.Digest = map[string]string{algorithm: digest}
+		} else {
+			source.Digest = map[string]string{"gitCommit": revision}
+		}
+		dependencies = append(dependencies, source)
+	}
+	if env := lvBuild.Status.BuildEnvironment; env != nil {
+		if env.OperatorVersion != "" {
+			provenance.RunDetails.Builder.Version = map[string]string{"jobrunner": env.OperatorVersion}
+		}
+		for _, container := range env.Containers {
+			dependency := ResourceDescriptor{Name: container.Name, URI: container.Image}
+			if _, digest, ok := strings.Cut(container.ImageID, "@sha256:"); ok {
+				dependency.Digest = map[string]string{"sha256": digest}
+			} else if digest, ok := strings.CutPrefix(container.ImageID, "sha256:"); ok {
+				dependency.Digest = map[string]string{"sha256": digest}
+			}
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	if len(dependencies) > 0 {
+		provenance.BuildDefinition.ResolvedDependencies = dependencies
+	}
+	return provenance
+}
+
+// Marshal returns the provenance as a cosign predicate.
+func (p Provenance) Marshal() (string, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	return string(data), err
+}
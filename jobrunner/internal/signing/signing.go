@@ -0,0 +1,245 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package signing signs the artifacts published by builds with cosign. An
+// artifact is signed, and its attestations attached, by a Job running cosign,
+// and syft to generate an SBOM, one step after the other. The SLSA provenance
+// of a run is recorded by the controller, and mounted into the Job from a
+// ConfigMap.
+package signing
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const (
+	// ProvenanceKey is the key of the provenance predicate in the ConfigMap of a signing Job
+	ProvenanceKey = "provenance.json"
+
+	// identityTokenAudience is the audience of the ServiceAccount tokens exchanged
+	// with Fulcio for keyless certificates
+	identityTokenAudience = "sigstore"
+
+	keyDir         = "/cosign"
+	predicatesDir  = "/predicates"
+	workDir        = "/work"
+	dockerDir      = "/docker"
+	tokenDir       = "/var/run/sigstore"
+	tokenPath      = tokenDir + "/token"
+	sbomPath       = workDir + "/sbom.spdx.json"
+	signingBackoff = 2
+)
+
+// repositoryPattern matches the OCI repositories packages are published to.
+var repositoryPattern = regexp.MustCompile(`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*(/[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*)*$`)
+
+// digestPattern matches the digests of OCI artifacts.
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// Config describes the images signing Jobs run.
+type Config struct {
+	// CosignImage is the image of cosign. Artifacts aren't signed when empty.
+	CosignImage string
+
+	// SyftImage is the image of syft, which generates SBOMs
+	SyftImage string
+
+	// OIDCIssuer is the issuer of the ServiceAccount tokens of the cluster, which
+	// verifies the identity of keyless signatures
+	OIDCIssuer string
+}
+
+// Request describes an artifact to sign.
+type Request struct {
+	// Name and Namespace of the signing Job, and of the ConfigMap of its predicates
+	Name      string
+	Namespace string
+
+	// Image is the artifact, by digest
+	Image string
+
+	// Spec describes how the artifact is signed
+	Spec jcrsv1.SigningSpec
+
+	// ServiceAccountName is the ServiceAccount the Job runs as, the identity of
+	// keyless signatures
+	ServiceAccountName string
+}
+
+// ImageReference returns the artifact a publishTarget registryURL holds for
+// packageName, by digest.
+func ImageReference(registryURL, packageName, digest string) (string, error) {
+	if !digestPattern.MatchString(digest) {
+		return "", fmt.Errorf("digest %q isn't a sha256 digest", digest)
+	}
+	registry := strings.TrimSuffix(registryURL, "/")
+	for _, scheme := range []string{"https://", "http://", "oci://"} {
+		registry = strings.TrimPrefix(registry, scheme)
+	}
+	host, repository, _ := strings.Cut(registry+"/"+packageName, "/")
+	if host == "" || !repositoryPattern.MatchString(repository) {
+		return "", fmt.Errorf("%s/%s isn't an OCI repository", registry, packageName)
+	}
+	return host + "/" + repository + "@" + digest, nil
+}
+
+// attests reports whether spec attaches the attestation of attestationType.
+func attests(spec jcrsv1.SigningSpec, attestationType jcrsv1.AttestationType) bool {
+	for _, attestation := range spec.Attestations {
+		if attestation == attestationType {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsProvenance reports whether the Job of req mounts a provenance predicate.
+func NeedsProvenance(req Request) bool {
+	return attests(req.Spec, jcrsv1.ProvenanceAttestation)
+}
+
+// Job returns the Job signing the artifact of req.
+func (c Config) Job(req Request) *batchv1.Job {
+	var steps []corev1.Container
+	keyArgs, keyEnv := c.keyArgs(req)
+	cosign := func(name string, args ...string) corev1.Container {
+		return corev1.Container{
+			Name:  name,
+			Image: c.CosignImage,
+			Args:  append(append(args[:1:1], keyArgs...), args[1:]...),
+			Env:   keyEnv,
+		}
+	}
+
+	steps = append(steps, cosign("sign", "sign", "--yes", req.Image))
+	if attests(req.Spec, jcrsv1.ProvenanceAttestation) {
+		steps = append(steps, cosign("attest-provenance", "attest", "--yes",
+			"--type", "slsaprovenance1", "--predicate", predicatesDir+"/"+ProvenanceKey, req.Image))
+	}
+	if attests(req.Spec, jcrsv1.SBOMAttestation) {
+		steps = append(steps, corev1.Container{
+			Name:  "sbom",
+			Image: c.SyftImage,
+			Args:  []string{"scan", "registry:" + req.Image, "--output", "spdx-json=" + sbomPath},
+		})
+		steps = append(steps, cosign("attest-sbom", "attest", "--yes",
+			"--type", "spdxjson", "--predicate", sbomPath, req.Image))
+	}
+
+	volumes := []corev1.Volume{{Name: "work", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	mounts := []corev1.VolumeMount{{Name: "work", MountPath: workDir}}
+	if attests(req.Spec, jcrsv1.ProvenanceAttestation) {
+		volumes = append(volumes, corev1.Volume{Name: "predicates", VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: req.Name}},
+		}})
+		mounts = append(mounts, corev1.VolumeMount{Name: "predicates", MountPath: predicatesDir, ReadOnly: true})
+	}
+	if ref := req.Spec.KeyRef; ref != nil {
+		volumes = append(volumes, corev1.Volume{Name: "key", VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: ref.Name, Items: []corev1.KeyToPath{{Key: "cosign.key", Path: "cosign.key"}}},
+		}})
+		mounts = append(mounts, corev1.VolumeMount{Name: "key", MountPath: keyDir, ReadOnly: true})
+	}
+	if req.Spec.Keyless != nil {
+		volumes = append(volumes, corev1.Volume{Name: "identity-token", VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{{
+				ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+					Audience: identityTokenAudience, ExpirationSeconds: ptr.To[int64](600), Path: "token",
+				},
+			}}},
+		}})
+		mounts = append(mounts, corev1.VolumeMount{Name: "identity-token", MountPath: tokenDir, ReadOnly: true})
+	}
+	var env []corev1.EnvVar
+	if ref := req.Spec.CredentialsSecretRef; ref != nil {
+		volumes = append(volumes, corev1.Volume{Name: "registry-credentials", VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: ref.Name, Items: []corev1.KeyToPath{{Key: corev1.DockerConfigJsonKey, Path: "config.json"}}},
+		}})
+		mounts = append(mounts, corev1.VolumeMount{Name: "registry-credentials", MountPath: dockerDir, ReadOnly: true})
+		env = append(env, corev1.EnvVar{Name: "DOCKER_CONFIG", Value: dockerDir})
+	}
+	for i := range steps {
+		steps[i].VolumeMounts = mounts
+		steps[i].Env = append(append([]corev1.EnvVar(nil), steps[i].Env...), env...)
+	}
+
+	// The steps run one after the other as init containers, but for the last one
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To[int32](signingBackoff),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: req.ServiceAccountName,
+					InitContainers:     steps[:len(steps)-1],
+					Containers:         steps[len(steps)-1:],
+					Volumes:            volumes,
+				},
+			},
+		},
+	}
+}
+
+// keyArgs returns the arguments and environment of the cosign commands signing with the key of req.
+func (c Config) keyArgs(req Request) ([]string, []corev1.EnvVar) {
+	if keyless := req.Spec.Keyless; keyless != nil {
+		args := []string{"--identity-token", tokenPath}
+		if keyless.FulcioURL != "" {
+			args = append(args, "--fulcio-url", keyless.FulcioURL)
+		}
+		if keyless.RekorURL != "" {
+			args = append(args, "--rekor-url", keyless.RekorURL)
+		}
+		return args, nil
+	}
+	if ref := req.Spec.KeyRef; ref != nil {
+		return []string{"--key", keyDir + "/cosign.key"}, []corev1.EnvVar{{
+			Name: "COSIGN_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: *ref, Key: "cosign.password", Optional: ptr.To(true),
+			}},
+		}}
+	}
+	return nil, nil
+}
+
+// Verification returns how the signature of the artifact of req is verified.
+func (c Config) Verification(req Request) jcrsv1.SignatureStatus {
+	status := jcrsv1.SignatureStatus{Image: req.Image, Attestations: req.Spec.Attestations}
+	if keyless := req.Spec.Keyless; keyless != nil {
+		serviceAccount := req.ServiceAccountName
+		if serviceAccount == "" {
+			serviceAccount = "default"
+		}
+		status.CertificateIdentity = "https://kubernetes.io/namespaces/" + req.Namespace + "/serviceaccounts/" + serviceAccount
+		status.CertificateOIDCIssuer = c.OIDCIssuer
+		status.RekorURL = keyless.RekorURL
+	}
+	if ref := req.Spec.KeyRef; ref != nil {
+		status.PublicKey = "k8s://" + req.Namespace + "/" + ref.Name
+	}
+	return status
+}
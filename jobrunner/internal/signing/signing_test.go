@@ -0,0 +1,173 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const testDigest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+var _ = Describe("ImageReference", func() {
+	DescribeTable("references the published image by digest",
+		func(registryURL, packageName, expected string) {
+			Expect(ImageReference(registryURL, packageName, testDigest)).To(Equal(expected + "@" + testDigest))
+		},
+		Entry("without a scheme", "registry.example.com/team", "app", "registry.example.com/team/app"),
+		Entry("with an https scheme", "https://registry.example.com/", "app", "registry.example.com/app"),
+		Entry("with an oci scheme", "oci://registry.example.com:5000/team", "app", "registry.example.com:5000/team/app"),
+	)
+
+	It("rejects digests that aren't sha256 digests", func() {
+		_, err := ImageReference("registry.example.com", "app", "1.2.3")
+		Expect(err).To(MatchError(ContainSubstring("isn't a sha256 digest")))
+	})
+
+	It("rejects packages that aren't OCI repositories", func() {
+		_, err := ImageReference("registry.example.com", "My App", testDigest)
+		Expect(err).To(MatchError(ContainSubstring("isn't an OCI repository")))
+	})
+})
+
+var _ = Describe("Config", func() {
+	config := Config{
+		CosignImage: "cosign:test",
+		SyftImage:   "syft:test",
+		OIDCIssuer:  "https://issuer.example.com",
+	}
+	image := "registry.example.com/app@" + testDigest
+
+	It("signs with a key from a Secret", func() {
+		req := Request{Name: "app-1-sign", Namespace: "builds", Image: image, Spec: jcrsv1.SigningSpec{
+			Enabled: true,
+			KeyRef:  &corev1.LocalObjectReference{Name: "cosign-key"},
+		}}
+		job := config.Job(req)
+		Expect(job.Name).To(Equal("app-1-sign"))
+		Expect(job.Namespace).To(Equal("builds"))
+		Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(job.Spec.Template.Spec.InitContainers).To(BeEmpty())
+		Expect(job.Spec.Template.Spec.Containers).To(HaveLen(1))
+		sign := job.Spec.Template.Spec.Containers[0]
+		Expect(sign.Image).To(Equal("cosign:test"))
+		Expect(sign.Args).To(Equal([]string{"sign", "--key", "/cosign/cosign.key", "--yes", image}))
+		Expect(sign.Env).To(ContainElement(HaveField("ValueFrom.SecretKeyRef.LocalObjectReference.Name", "cosign-key")))
+		Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("Secret.SecretName", "cosign-key")))
+
+		Expect(config.Verification(req)).To(Equal(jcrsv1.SignatureStatus{
+			Image:     image,
+			PublicKey: "k8s://builds/cosign-key",
+		}))
+	})
+
+	It("signs keyless with the identity of the ServiceAccount of the build", func() {
+		req := Request{Name: "app-1-sign", Namespace: "builds", Image: image, ServiceAccountName: "builder", Spec: jcrsv1.SigningSpec{
+			Enabled: true,
+			Keyless: &jcrsv1.KeylessSigning{FulcioURL: "https://fulcio.example.com", RekorURL: "https://rekor.example.com"},
+		}}
+		job := config.Job(req)
+		Expect(job.Spec.Template.Spec.ServiceAccountName).To(Equal("builder"))
+		sign := job.Spec.Template.Spec.Containers[0]
+		Expect(sign.Args).To(Equal([]string{"sign", "--identity-token", "/var/run/sigstore/token",
+			"--fulcio-url", "https://fulcio.example.com", "--rekor-url", "https://rekor.example.com", "--yes", image}))
+		Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(
+			HaveField("Projected.Sources", ContainElement(HaveField("ServiceAccountToken.Audience", "sigstore")))))
+
+		Expect(config.Verification(req)).To(Equal(jcrsv1.SignatureStatus{
+			Image:                 image,
+			CertificateIdentity:   "https://kubernetes.io/namespaces/builds/serviceaccounts/builder",
+			CertificateOIDCIssuer: "https://issuer.example.com",
+			RekorURL:              "https://rekor.example.com",
+		}))
+	})
+
+	It("attaches the attestations after signing", func() {
+		req := Request{Name: "app-1-sign", Namespace: "builds", Image: image, Spec: jcrsv1.SigningSpec{
+			Enabled:              true,
+			KeyRef:               &corev1.LocalObjectReference{Name: "cosign-key"},
+			Attestations:         []jcrsv1.AttestationType{jcrsv1.ProvenanceAttestation, jcrsv1.SBOMAttestation},
+			CredentialsSecretRef: &corev1.LocalObjectReference{Name: "registry"},
+		}}
+		Expect(NeedsProvenance(req)).To(BeTrue())
+		job := config.Job(req)
+		spec := job.Spec.Template.Spec
+		Expect(spec.InitContainers).To(HaveEach(HaveField("Env", ContainElement(corev1.EnvVar{Name: "DOCKER_CONFIG", Value: "/docker"}))))
+		steps := append(spec.InitContainers, spec.Containers...)
+		Expect(steps).To(HaveExactElements(
+			HaveField("Name", "sign"),
+			HaveField("Name", "attest-provenance"),
+			HaveField("Name", "sbom"),
+			HaveField("Name", "attest-sbom"),
+		))
+		Expect(steps[1].Args).To(ContainElements("--type", "slsaprovenance1", "/predicates/provenance.json"))
+		Expect(steps[2].Image).To(Equal("syft:test"))
+		Expect(steps[3].Args).To(ContainElements("--type", "spdxjson"))
+		Expect(spec.Volumes).To(ContainElement(HaveField("ConfigMap.Name", "app-1-sign")))
+		Expect(spec.Volumes).To(ContainElement(HaveField("Secret.SecretName", "registry")))
+	})
+})
+
+var _ = Describe("NewProvenance", func() {
+	It("records the source and build environment of the run", func() {
+		lvBuild := &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "builds", Generation: 3},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				PackageName:   ptr.To("app"),
+				SourceType:    jcrsv1.GitSource,
+				SourceURL:     ptr.To("https://git.example.com/app.git"),
+				PublishTarget: &jcrsv1.PublishTarget{RegistryURL: "registry.example.com", Version: "1.2.3"},
+			},
+			Status: jcrsv1.LeviathanBuildStatus{
+				RunIndex:       4,
+				SourceRevision: "0123456789abcdef",
+				BuildEnvironment: &jcrsv1.BuildEnvironment{
+					OperatorVersion: "v1.0.0",
+					Containers: []jcrsv1.BuildContainer{{
+						Name: "build", Image: "builder:1", ImageID: "docker.io/library/builder@sha256:abcd",
+					}},
+				},
+			},
+		}
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{UID: "job-uid"}}
+
+		provenance := NewProvenance(lvBuild, job)
+		Expect(provenance.BuildDefinition.ExternalParameters.Build).To(Equal("builds/app"))
+		Expect(provenance.BuildDefinition.ExternalParameters.Version).To(Equal("1.2.3"))
+		Expect(provenance.BuildDefinition.InternalParameters.RunIndex).To(Equal(int64(4)))
+		Expect(provenance.RunDetails.Metadata.InvocationID).To(Equal("job-uid"))
+		Expect(provenance.RunDetails.Builder.Version).To(HaveKeyWithValue("jobrunner", "v1.0.0"))
+		Expect(provenance.BuildDefinition.ResolvedDependencies).To(Equal([]ResourceDescriptor{
+			{Name: "source", URI: "https://git.example.com/app.git", Digest: map[string]string{"gitCommit": "0123456789abcdef"}},
+			{Name: "build", URI: "builder:1", Digest: map[string]string{"sha256": "abcd"}},
+		}))
+
+		predicate, err := provenance.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		var decoded map[string]any
+		Expect(json.Unmarshal([]byte(predicate), &decoded)).To(Succeed())
+		Expect(decoded).To(HaveKey("buildDefinition"))
+	})
+})
@@ -0,0 +1,220 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcepoll
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"test.jcrs.dev/jobrunner/internal/archive"
+)
+
+/*
+Each check transfers as little as possible:
+
+  - HTTP sources are checked with a conditional HEAD request, the server answers
+    304 Not Modified while the ETag or Last-Modified date of the archive hold;
+  - Git sources read the ref advertisement of the smart HTTP protocol, which
+    `git ls-remote` reads too, and stop at the HEAD of the repository, its first ref;
+  - S3 sources are checked with a HEAD request of the object, whose ETag changes
+    with its content.
+*/
+
+// HTTPChecker checks HTTP sources.
+type HTTPChecker struct {
+	Client *http.Client
+}
+
+// Check implements Checker. The revision of an HTTP source is its ETag, or its
+// Last-Modified date when the server doesn't return an ETag.
+func (c *HTTPChecker) Check(ctx context.Context, remote Remote, last Check) (Check, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, remote.URL, nil)
+	if err != nil {
+		return Check{}, err
+	}
+	if remote.Authorization != "" {
+		req.Header.Set("Authorization", remote.Authorization)
+	}
+	if last.ETag != "" {
+		req.Header.Set("If-None-Match", last.ETag)
+	}
+	if last.LastModified != "" {
+		req.Header.Set("If-Modified-Since", last.LastModified)
+	}
+	resp, err := client(c.Client).Do(req)
+	if err != nil {
+		return Check{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && last.Revision != "":
+		return last, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return Check{}, fmt.Errorf("unexpected status %q for HEAD %s", resp.Status, remote.URL)
+	}
+	check := Check{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	switch {
+	case check.ETag != "":
+		check.Revision = "etag:" + check.ETag
+	case check.LastModified != "":
+		check.Revision = "last-modified:" + check.LastModified
+	default:
+		return Check{}, fmt.Errorf("%s returns neither an ETag nor a Last-Modified date", remote.URL)
+	}
+	return check, nil
+}
+
+// GitChecker checks Git sources served over the smart HTTP protocol.
+type GitChecker struct {
+	Client *http.Client
+}
+
+// Check implements Checker. The revision of a Git source is the commit of the
+// default branch of the repository, its HEAD.
+func (c *GitChecker) Check(ctx context.Context, remote Remote, _ Check) (Check, error) {
+	target := strings.TrimSuffix(remote.URL, "/") + "/info/refs?service=git-upload-pack"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return Check{}, err
+	}
+	if remote.Authorization != "" {
+		req.Header.Set("Authorization", remote.Authorization)
+	}
+	resp, err := client(c.Client).Do(req)
+	if err != nil {
+		return Check{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return Check{}, fmt.Errorf("unexpected status %q for GET %s", resp.Status, target)
+	}
+
+	commit, err := advertisedHead(resp.Body)
+	if err != nil {
+		return Check{}, fmt.Errorf("reading the refs of %s: %w", remote.URL, err)
+	}
+	return Check{Revision: commit}, nil
+}
+
+// advertisedHead returns the commit of HEAD in the ref advertisement of the git
+// smart HTTP protocol: a "# service=git-upload-pack" packet and a flush packet,
+// followed by the refs, HEAD first. See
+// https://git-scm.com/docs/http-protocol#_smart_clients
+func advertisedHead(r io.Reader) (string, error) {
+	br := bufio.NewReader(r)
+	service, err := readPacket(br)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(service, "# service=git-upload-pack") {
+		return "", errors.New("not a git-upload-pack ref advertisement")
+	}
+	for {
+		line, err := readPacket(br)
+		if err != nil {
+			return "", err
+		}
+		if line == "" {
+			continue
+		}
+		// The first ref carries the capabilities after a NUL byte
+		ref, _, _ := strings.Cut(strings.TrimSuffix(line, "\n"), "\x00")
+		commit, name, ok := strings.Cut(ref, " ")
+		if !ok || name != "HEAD" {
+			return "", errors.New("the repository has no HEAD")
+		}
+		return commit, nil
+	}
+}
+
+// readPacket reads a pkt-line, returning an empty string for a flush packet.
+func readPacket(r *bufio.Reader) (string, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return "", err
+	}
+	n, err := strconv.ParseUint(string(size[:]), 16, 16)
+	if err != nil {
+		return "", fmt.Errorf("invalid pkt-line length %q", size)
+	}
+	if n == 0 {
+		return "", nil
+	}
+	if n < 4 {
+		return "", fmt.Errorf("invalid pkt-line length %q", size)
+	}
+	data := make([]byte, n-4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// S3Checker checks S3 sources, s3://<bucket>/<key> URLs of objects of an S3
+// compatible endpoint.
+type S3Checker struct {
+	Client *http.Client
+
+	// Endpoint is the URL of the storage service, e.g. https://s3.amazonaws.com
+	Endpoint string
+	// Region the requests are signed for
+	Region string
+
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Check implements Checker. The revision of an S3 source is the ETag of the object.
+func (c *S3Checker) Check(ctx context.Context, remote Remote, _ Check) (Check, error) {
+	u, err := url.Parse(remote.URL)
+	if err != nil {
+		return Check{}, err
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Scheme != "s3" || u.Host == "" || key == "" {
+		return Check{}, fmt.Errorf("source URL %q isn't an s3://<bucket>/<key> URL", remote.URL)
+	}
+
+	store := archive.NewS3Store(c.Endpoint, u.Host, c.Region, c.AccessKeyID, c.SecretAccessKey, 0)
+	store.Client = client(c.Client)
+	header, err := store.Head(ctx, key)
+	if err != nil {
+		return Check{}, err
+	}
+	etag := header.Get("ETag")
+	if etag == "" {
+		return Check{}, fmt.Errorf("%s has no ETag", remote.URL)
+	}
+	return Check{Revision: "etag:" + etag, ETag: etag}, nil
+}
+
+// client returns c, or a client with a default timeout when c is nil.
+func client(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcepoll
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// pktLine encodes data as a pkt-line of the git protocol.
+func pktLine(data string) string {
+	return fmt.Sprintf("%04x%s", len(data)+4, data)
+}
+
+var _ = Describe("HTTPChecker", func() {
+	var (
+		ctx      context.Context
+		server   *httptest.Server
+		etag     string
+		requests []*http.Request
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		etag = `"v1"`
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requests = append(requests, req)
+			if req.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("checks the source with conditional HEAD requests", func() {
+		checker := &HTTPChecker{}
+		remote := Remote{Type: jcrsv1.HTTPSource, URL: server.URL + "/src.tgz", Authorization: "Bearer token"}
+		check, err := checker.Check(ctx, remote, Check{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(check.Revision).To(Equal(`etag:"v1"`))
+		Expect(requests[0].Method).To(Equal(http.MethodHead))
+		Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer token"))
+
+		Expect(checker.Check(ctx, remote, check)).To(Equal(check))
+		Expect(requests[1].Header.Get("If-None-Match")).To(Equal(`"v1"`))
+
+		etag = `"v2"`
+		Expect(checker.Check(ctx, remote, check)).To(HaveField("Revision", `etag:"v2"`))
+	})
+})
+
+var _ = Describe("GitChecker", func() {
+	It("reads the HEAD of the repository from its ref advertisement", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/web.git/info/refs" || req.URL.Query().Get("service") != "git-upload-pack" {
+				http.NotFound(w, req)
+				return
+			}
+			_, _ = w.Write([]byte(pktLine("# service=git-upload-pack\n") + "0000" +
+				pktLine("3f2a0c1d HEAD\x00multi_ack symref=HEAD:refs/heads/main\n") +
+				pktLine("3f2a0c1d refs/heads/main\n") + "0000"))
+		}))
+		DeferCleanup(server.Close)
+
+		check, err := (&GitChecker{}).Check(context.Background(), Remote{Type: jcrsv1.GitSource, URL: server.URL + "/web.git/"}, Check{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(check.Revision).To(Equal("3f2a0c1d"))
+	})
+
+	It("fails for repositories without a HEAD", func() {
+		Expect(advertisedHead(strings.NewReader(pktLine("# service=git-upload-pack\n") + "0000" +
+			pktLine("0000000000000000000000000000000000000000 capabilities^{}\x00multi_ack\n") + "0000"))).
+			Error().To(MatchError("the repository has no HEAD"))
+	})
+})
+
+var _ = Describe("S3Checker", func() {
+	It("checks the ETag of the object", func() {
+		var requested string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requested = req.Method + " " + req.URL.Path
+			Expect(req.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=key/"))
+			w.Header().Set("ETag", `"abc"`)
+		}))
+		DeferCleanup(server.Close)
+
+		checker := &S3Checker{Endpoint: server.URL, Region: "us-east-1", AccessKeyID: "key", SecretAccessKey: "secret"}
+		check, err := checker.Check(context.Background(), Remote{Type: jcrsv1.S3Source, URL: "s3://sources/web/src.tgz"}, Check{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(check.Revision).To(Equal(`etag:"abc"`))
+		Expect(requested).To(Equal("HEAD /sources/web/src.tgz"))
+	})
+})
@@ -0,0 +1,280 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sourcepoll checks the sources of builds for new revisions, so that
+// builds run again when their source changes without a webhook or a timer per
+// build. Builds polling the same remote share its checks, which run at the
+// shortest interval of the builds, and the builds are only reconciled when the
+// revision of their remote changes.
+package sourcepoll
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// tick is how often the poller looks for remotes due for a check.
+const tick = time.Second
+
+var pollChecksTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "jobrunner_source_poll_checks_total",
+		Help: "Number of checks of polled sources for a new revision, by source type and result (unchanged, changed or error)",
+	},
+	[]string{"source_type", "result"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(pollChecksTotal)
+}
+
+// Remote is a polled source. Builds polling the same remote with the same
+// credentials share its checks.
+type Remote struct {
+	// Type is the sourceType of the builds
+	Type jcrsv1.SourceType
+	// URL of the source
+	URL string
+	// Authorization is the Authorization header of the requests, if any
+	Authorization string
+}
+
+// Check is the outcome of a check of a remote. Its validators make the next
+// check conditional, so an unchanged source isn't transferred again.
+type Check struct {
+	// Revision identifies the content of the remote
+	Revision string
+	// ETag and LastModified are the validators returned by the remote
+	ETag         string
+	LastModified string
+}
+
+// Checker checks a type of remote. It returns last when the remote reports that
+// it hasn't changed since last.
+type Checker interface {
+	Check(ctx context.Context, remote Remote, last Check) (Check, error)
+}
+
+// Poller checks the remotes watched by builds, and sends an event for every
+// build watching a remote whose revision has changed.
+type Poller struct {
+	// Checkers checks the remotes of each source type
+	Checkers map[jcrsv1.SourceType]Checker
+
+	events chan event.GenericEvent
+
+	mu      sync.Mutex
+	remotes map[Remote]*remoteState
+	builds  map[types.NamespacedName]Remote
+
+	// now returns the current time, for tests
+	now func() time.Time
+}
+
+// remoteState is what the poller knows of a remote.
+type remoteState struct {
+	// intervals holds the pollInterval of each build watching the remote
+	intervals map[types.NamespacedName]time.Duration
+	last      Check
+	checked   bool
+	// checkedAt is when the remote was last checked, successfully or not
+	checkedAt time.Time
+}
+
+// interval returns the shortest pollInterval of the builds watching the remote.
+func (s *remoteState) interval() time.Duration {
+	var shortest time.Duration
+	for _, interval := range s.intervals {
+		if shortest == 0 || interval < shortest {
+			shortest = interval
+		}
+	}
+	return shortest
+}
+
+// due reports whether the remote is due for a check at now.
+func (s *remoteState) due(now time.Time) bool {
+	return !now.Before(s.checkedAt.Add(s.interval()))
+}
+
+// NewPoller returns a Poller checking remotes with checkers.
+func NewPoller(checkers map[jcrsv1.SourceType]Checker) *Poller {
+	return &Poller{
+		Checkers: checkers,
+		events:   make(chan event.GenericEvent, 1024),
+		remotes:  make(map[Remote]*remoteState),
+		builds:   make(map[types.NamespacedName]Remote),
+		now:      time.Now,
+	}
+}
+
+// Events returns the channel the builds watching a changed remote are sent to,
+// as LeviathanBuilds with only their namespace and name.
+func (p *Poller) Events() <-chan event.GenericEvent {
+	return p.events
+}
+
+// Watch subscribes build to remote at interval, in place of the remote it
+// watched before if any, and returns the latest revision of remote. A remote
+// watched for the first time is checked right away, so that the first run of
+// a build already knows the revision it builds. The revision is empty until a
+// check of the remote succeeds.
+func (p *Poller) Watch(ctx context.Context, build types.NamespacedName, remote Remote, interval time.Duration) (string, error) {
+	p.mu.Lock()
+	p.forgetLocked(build, remote)
+	state, ok := p.remotes[remote]
+	if !ok {
+		state = &remoteState{intervals: make(map[types.NamespacedName]time.Duration)}
+		p.remotes[remote] = state
+	}
+	state.intervals[build] = interval
+	p.builds[build] = remote
+	// A remote that just failed its check is left to the next interval
+	known := state.checked || !state.due(p.now())
+	revision := state.last.Revision
+	p.mu.Unlock()
+
+	if known {
+		return revision, nil
+	}
+	if err := p.check(ctx, remote, false); err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return state.last.Revision, nil
+}
+
+// Forget unsubscribes build from the remote it watches.
+func (p *Poller) Forget(build types.NamespacedName) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.forgetLocked(build, Remote{})
+}
+
+// forgetLocked unsubscribes build from the remote it watches unless it is
+// remote, and drops remotes no build watches anymore.
+func (p *Poller) forgetLocked(build types.NamespacedName, remote Remote) {
+	previous, ok := p.builds[build]
+	if !ok || previous == remote {
+		return
+	}
+	delete(p.builds, build)
+	if state, ok := p.remotes[previous]; ok {
+		delete(state.intervals, build)
+		if len(state.intervals) == 0 {
+			delete(p.remotes, previous)
+		}
+	}
+}
+
+// Start checks the remotes due for a check until ctx is done. It implements
+// manager.Runnable.
+func (p *Poller) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, p.pollDue, tick)
+	return nil
+}
+
+// pollDue checks the remotes whose interval has elapsed since their last check.
+// Remotes are checked one at a time, their checks are bounded by the timeout
+// of the checkers.
+func (p *Poller) pollDue(ctx context.Context) {
+	p.mu.Lock()
+	now := p.now()
+	var due []Remote
+	for remote, state := range p.remotes {
+		if state.due(now) {
+			due = append(due, remote)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, remote := range due {
+		if err := p.check(ctx, remote, true); err != nil {
+			logf.FromContext(ctx).Error(err, "Failed to poll source", "sourceType", remote.Type, "url", remote.URL)
+		}
+	}
+}
+
+// check checks remote and, with notify, sends an event for the builds watching
+// it when its revision has changed. A failed check is retried at the next
+// interval.
+func (p *Poller) check(ctx context.Context, remote Remote, notify bool) error {
+	p.mu.Lock()
+	state, ok := p.remotes[remote]
+	if !ok {
+		p.mu.Unlock()
+		return nil
+	}
+	last := state.last
+	checker := p.Checkers[remote.Type]
+	p.mu.Unlock()
+
+	var (
+		next Check
+		err  = fmt.Errorf("sources of type %q can't be polled", remote.Type)
+	)
+	if checker != nil {
+		next, err = checker.Check(ctx, remote, last)
+	}
+
+	p.mu.Lock()
+	state.checkedAt = p.now()
+	if err != nil {
+		p.mu.Unlock()
+		pollChecksTotal.WithLabelValues(string(remote.Type), "error").Inc()
+		return err
+	}
+	changed := notify && next.Revision != state.last.Revision
+	state.last, state.checked = next, true
+	var builds []types.NamespacedName
+	if changed {
+		for build := range state.intervals {
+			builds = append(builds, build)
+		}
+	}
+	p.mu.Unlock()
+
+	if !changed {
+		pollChecksTotal.WithLabelValues(string(remote.Type), "unchanged").Inc()
+		return nil
+	}
+	pollChecksTotal.WithLabelValues(string(remote.Type), "changed").Inc()
+	logf.FromContext(ctx).Info("Polled source changed", "sourceType", remote.Type, "url", remote.URL,
+		"revision", next.Revision, "builds", len(builds))
+	for _, build := range builds {
+		select {
+		case p.events <- event.GenericEvent{Object: &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: build.Name},
+		}}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
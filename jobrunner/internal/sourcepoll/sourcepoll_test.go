@@ -0,0 +1,134 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcepoll
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// fakeChecker returns revision, or err, and counts its checks.
+type fakeChecker struct {
+	revision string
+	err      error
+	checks   int
+}
+
+func (c *fakeChecker) Check(_ context.Context, _ Remote, _ Check) (Check, error) {
+	c.checks++
+	if c.err != nil {
+		return Check{}, c.err
+	}
+	return Check{Revision: c.revision}, nil
+}
+
+var _ = Describe("Poller", func() {
+	var (
+		ctx     context.Context
+		checker *fakeChecker
+		poller  *Poller
+		now     time.Time
+		remote  = Remote{Type: jcrsv1.GitSource, URL: "https://example.com/web.git"}
+		web     = types.NamespacedName{Namespace: "default", Name: "web"}
+		api     = types.NamespacedName{Namespace: "default", Name: "api"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		checker = &fakeChecker{revision: "a1"}
+		poller = NewPoller(map[jcrsv1.SourceType]Checker{jcrsv1.GitSource: checker})
+		now = time.Now()
+		poller.now = func() time.Time { return now }
+	})
+
+	pending := func() []event.GenericEvent {
+		var events []event.GenericEvent
+		for len(poller.Events()) > 0 {
+			events = append(events, <-poller.Events())
+		}
+		return events
+	}
+
+	It("checks a remote once for all the builds watching it", func() {
+		Expect(poller.Watch(ctx, web, remote, 5*time.Minute)).To(Equal("a1"))
+		Expect(poller.Watch(ctx, api, remote, time.Minute)).To(Equal("a1"))
+		Expect(checker.checks).To(Equal(1))
+
+		By("checking at the shortest interval of the builds")
+		now = now.Add(30 * time.Second)
+		poller.pollDue(ctx)
+		Expect(checker.checks).To(Equal(1))
+		now = now.Add(31 * time.Second)
+		poller.pollDue(ctx)
+		Expect(checker.checks).To(Equal(2))
+		Expect(pending()).To(BeEmpty())
+	})
+
+	It("reconciles the builds watching a remote whose revision changed", func() {
+		Expect(poller.Watch(ctx, web, remote, time.Minute)).To(Equal("a1"))
+		Expect(poller.Watch(ctx, api, remote, time.Minute)).To(Equal("a1"))
+
+		checker.revision = "b2"
+		now = now.Add(time.Minute)
+		poller.pollDue(ctx)
+		events := pending()
+		Expect(events).To(HaveLen(2))
+		Expect([]string{events[0].Object.GetName(), events[1].Object.GetName()}).To(ConsistOf("web", "api"))
+		Expect(poller.Watch(ctx, web, remote, time.Minute)).To(Equal("b2"))
+	})
+
+	It("stops checking remotes no build watches", func() {
+		Expect(poller.Watch(ctx, web, remote, time.Minute)).To(Equal("a1"))
+		other := Remote{Type: jcrsv1.GitSource, URL: "https://example.com/other.git"}
+		Expect(poller.Watch(ctx, web, other, time.Minute)).To(Equal("a1"))
+		Expect(poller.remotes).To(HaveLen(1))
+
+		poller.Forget(web)
+		Expect(poller.remotes).To(BeEmpty())
+		now = now.Add(time.Minute)
+		poller.pollDue(ctx)
+		Expect(checker.checks).To(Equal(2))
+	})
+
+	It("retries a failed check at the next interval", func() {
+		checker.err = errors.New("connection refused")
+		_, err := poller.Watch(ctx, web, remote, time.Minute)
+		Expect(err).To(MatchError("connection refused"))
+		Expect(poller.Watch(ctx, web, remote, time.Minute)).To(BeEmpty())
+		Expect(checker.checks).To(Equal(1))
+
+		By("reconciling the build once the remote can be checked")
+		checker.err = nil
+		now = now.Add(time.Minute)
+		poller.pollDue(ctx)
+		Expect(pending()).To(HaveLen(1))
+		Expect(poller.Watch(ctx, web, remote, time.Minute)).To(Equal("a1"))
+	})
+
+	It("fails remotes of types it can't check", func() {
+		_, err := poller.Watch(ctx, web, Remote{Type: jcrsv1.LocalSource, URL: "/src"}, time.Minute)
+		Expect(err).To(MatchError(ContainSubstring(`sources of type "Local" can't be polled`)))
+	})
+})
@@ -0,0 +1,141 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spechistory keeps the latest spec generations of a LeviathanBuild in
+// a ConfigMap controlled by the build, so that a change of its jobTemplate or
+// parameters that breaks it can be rolled back.
+//
+// Every generation is stored under its number, as a JSON encoded Snapshot. The
+// oldest generations are dropped beyond the limit of the history, and while
+// the ConfigMap would exceed maxSize; the latest generation is always kept.
+package spechistory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+const (
+	// nameSuffix is appended to the name of a build to name its history
+	nameSuffix = "-spec-history"
+	// maxSize bounds the size of the data of a history, below the 1MiB limit of ConfigMaps
+	maxSize = 900 << 10
+)
+
+// Snapshot is a generation of the spec of a build.
+type Snapshot struct {
+	// Generation is the metadata.generation of the build with this spec
+	Generation int64 `json:"generation"`
+	// RecordedAt is when the controller first saw the generation
+	RecordedAt metav1.Time `json:"recordedAt"`
+	// Spec is the spec of the build at the generation
+	Spec jcrsv1.LeviathanBuildSpec `json:"spec"`
+}
+
+// ConfigMapName returns the name of the ConfigMap holding the history of lvBuild.
+// Names too long for a ConfigMap are shortened with a hash of the name of the build.
+func ConfigMapName(lvBuild *jcrsv1.LeviathanBuild) string {
+	name := lvBuild.Name + nameSuffix
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(lvBuild.Name))
+	hash := hex.EncodeToString(sum[:4])
+	return lvBuild.Name[:validation.DNS1123SubdomainMaxLength-len(nameSuffix)-len(hash)-1] + "-" + hash + nameSuffix
+}
+
+// Record adds the current generation of lvBuild to the history held by cm, and
+// drops the generations beyond the latest limit ones. It reports whether cm changed.
+func Record(cm *corev1.ConfigMap, lvBuild *jcrsv1.LeviathanBuild, limit int, now time.Time) (bool, error) {
+	key := strconv.FormatInt(lvBuild.Generation, 10)
+	changed := false
+	if _, ok := cm.Data[key]; !ok {
+		raw, err := json.Marshal(Snapshot{Generation: lvBuild.Generation, RecordedAt: metav1.NewTime(now), Spec: lvBuild.Spec})
+		if err != nil {
+			return false, err
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[key] = string(raw)
+		changed = true
+	}
+
+	generations := generations(cm)
+	size := 0
+	for _, data := range cm.Data {
+		size += len(data)
+	}
+	// The oldest generations come first
+	for len(generations) > 1 && (len(generations) > limit || size > maxSize) {
+		oldest := strconv.FormatInt(generations[0], 10)
+		size -= len(cm.Data[oldest])
+		delete(cm.Data, oldest)
+		generations = generations[1:]
+		changed = true
+	}
+	return changed, nil
+}
+
+// generations returns the generations in the history held by cm, the oldest first.
+func generations(cm *corev1.ConfigMap) []int64 {
+	var generations []int64
+	for key := range cm.Data {
+		if generation, err := strconv.ParseInt(key, 10, 64); err == nil {
+			generations = append(generations, generation)
+		}
+	}
+	slices.Sort(generations)
+	return generations
+}
+
+// Snapshots returns the generations in the history held by cm, the latest first.
+func Snapshots(cm *corev1.ConfigMap) ([]Snapshot, error) {
+	generations := generations(cm)
+	snapshots := make([]Snapshot, 0, len(generations))
+	for _, generation := range slices.Backward(generations) {
+		snapshot, err := Get(cm, generation)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	return snapshots, nil
+}
+
+// Get returns the given generation of the history held by cm.
+func Get(cm *corev1.ConfigMap, generation int64) (*Snapshot, error) {
+	raw, ok := cm.Data[strconv.FormatInt(generation, 10)]
+	if !ok {
+		return nil, fmt.Errorf("generation %d isn't in the history, which has generations %v", generation, generations(cm))
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal([]byte(raw), &snapshot); err != nil {
+		return nil, fmt.Errorf("reading generation %d: %w", generation, err)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spechistory
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("Spec history", func() {
+	var (
+		cm      *corev1.ConfigMap
+		lvBuild *jcrsv1.LeviathanBuild
+		now     time.Time
+	)
+
+	// record records the generation of lvBuild building the given version.
+	record := func(generation int64, version string, limit int) bool {
+		lvBuild.Generation = generation
+		lvBuild.Spec.PackageName = ptr.To("web")
+		lvBuild.Spec.PublishTarget = &jcrsv1.PublishTarget{Version: version}
+		now = now.Add(time.Hour)
+		changed, err := Record(cm, lvBuild, limit, now)
+		Expect(err).NotTo(HaveOccurred())
+		return changed
+	}
+
+	BeforeEach(func() {
+		cm = &corev1.ConfigMap{}
+		lvBuild = &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nightly"}}
+		now = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	It("keeps the latest generations of the spec", func() {
+		Expect(record(1, "1.0.0", 3)).To(BeTrue())
+		Expect(record(1, "1.0.0", 3)).To(BeFalse())
+		Expect(record(2, "1.1.0", 3)).To(BeTrue())
+		Expect(record(3, "1.2.0", 3)).To(BeTrue())
+		Expect(record(5, "2.0.0", 3)).To(BeTrue())
+
+		snapshots, err := Snapshots(cm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots).To(HaveLen(3))
+		Expect(snapshots[0].Generation).To(BeEquivalentTo(5))
+		Expect(snapshots[2].Generation).To(BeEquivalentTo(2))
+
+		snapshot, err := Get(cm, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.Spec.PublishTarget.Version).To(Equal("1.2.0"))
+		Expect(snapshot.RecordedAt.Time.IsZero()).To(BeFalse())
+
+		_, err = Get(cm, 1)
+		Expect(err).To(MatchError(ContainSubstring("generation 1 isn't in the history, which has generations [2 3 5]")))
+	})
+
+	It("drops the oldest generations beyond its size", func() {
+		lvBuild.Spec.PackageName = ptr.To("web")
+		big := strings.Repeat("x", maxSize/2)
+		for generation := range int64(3) {
+			lvBuild.Generation = generation + 1
+			lvBuild.Spec.MutexKey = ptr.To(big)
+			_, err := Record(cm, lvBuild, 10, now)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(generations(cm)).To(Equal([]int64{3}))
+
+		By("keeping the latest generation however large it is")
+		lvBuild.Generation = 4
+		lvBuild.Spec.MutexKey = ptr.To(big + big)
+		_, err := Record(cm, lvBuild, 10, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(generations(cm)).To(Equal([]int64{4}))
+	})
+
+	It("names the history after the build", func() {
+		Expect(ConfigMapName(lvBuild)).To(Equal("nightly-spec-history"))
+
+		lvBuild.Name = strings.Repeat("a", validation.DNS1123SubdomainMaxLength)
+		name := ConfigMapName(lvBuild)
+		Expect(len(name)).To(Equal(validation.DNS1123SubdomainMaxLength))
+		Expect(name).To(HaveSuffix(nameSuffix))
+		Expect(validation.IsDNS1123Subdomain(name)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+)
+
+/*
+Teams running many builds in a namespace want to set their retry and resource
+policy once rather than in every build. The LeviathanBuildDefaults named default
+of a namespace holds that policy, and the defaulting webhook fills the fields a
+new build leaves unset from it. Fields set by the build always win.
+
+Builds are only defaulted when created: defaulting updates too would change the
+spec of existing builds behind the back of their authors whenever the defaults
+change, and start new Jobs for them. Defaults are read from the cache of the
+manager, so a build created right after its defaults changed may miss the change.
+
+The builds of a LeviathanProject are defaulted from the defaults of the project
+first: a project groups related packages, whose policy is closer to the build
+than the policy of the whole namespace.
+*/
+
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanbuilddefaults,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jcrs.jcrs.dev,resources=leviathanprojects,verbs=get;list;watch
+
+// applyDefaults fills the fields of lvBuild left unset from the defaults of its
+// LeviathanProject, then from the LeviathanBuildDefaults of its namespace, if
+// any and when their features are enabled.
+func (d *LeviathanBuildCustomDefaulter) applyDefaults(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) error {
+	if d.Client == nil {
+		return nil
+	}
+	if featuregates.Enabled(featuregates.Projects) {
+		if err := d.applyProjectDefaults(ctx, lvBuild); err != nil {
+			return err
+		}
+	}
+	if !featuregates.Enabled(featuregates.BuildDefaults) {
+		return nil
+	}
+	var defaults jcrsv1.LeviathanBuildDefaults
+	key := client.ObjectKey{Namespace: lvBuild.Namespace, Name: jcrsv1.LeviathanBuildDefaultsName}
+	if err := d.Client.Get(ctx, key, &defaults); err != nil {
+		if err := client.IgnoreNotFound(err); err != nil {
+			return fmt.Errorf("reading the LeviathanBuildDefaults of namespace %s: %w", lvBuild.Namespace, err)
+		}
+		return nil
+	}
+	mergeDefaults(lvBuild, &defaults.Spec)
+	return nil
+}
+
+// applyProjectDefaults fills the fields of lvBuild left unset from the defaults
+// of the LeviathanProject it belongs to, if any.
+func (d *LeviathanBuildCustomDefaulter) applyProjectDefaults(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) error {
+	name := lvBuild.Labels[jcrsv1.ProjectLabel]
+	if name == "" {
+		return nil
+	}
+	var project jcrsv1.LeviathanProject
+	if err := d.Client.Get(ctx, client.ObjectKey{Namespace: lvBuild.Namespace, Name: name}, &project); err != nil {
+		if err := client.IgnoreNotFound(err); err != nil {
+			return fmt.Errorf("reading the LeviathanProject %s: %w", name, err)
+		}
+		return nil
+	}
+	if project.Spec.Defaults != nil {
+		mergeDefaults(lvBuild, project.Spec.Defaults)
+	}
+	return nil
+}
+
+// mergeDefaults fills the fields of lvBuild left unset from defaults.
+func mergeDefaults(lvBuild *jcrsv1.LeviathanBuild, defaults *jcrsv1.LeviathanBuildDefaultsSpec) {
+	jobSpec := &lvBuild.Spec.JobTemplate.Spec
+	if retry := defaults.RetryPolicy; retry != nil {
+		if jobSpec.BackoffLimit == nil && retry.BackoffLimit != nil {
+			jobSpec.BackoffLimit = ptr.To(*retry.BackoffLimit)
+		}
+		if jobSpec.ActiveDeadlineSeconds == nil && retry.ActiveDeadlineSeconds != nil {
+			jobSpec.ActiveDeadlineSeconds = ptr.To(*retry.ActiveDeadlineSeconds)
+		}
+	}
+	if defaults.Resources != nil {
+		for i := range jobSpec.Template.Spec.Containers {
+			resources := &jobSpec.Template.Spec.Containers[i].Resources
+			if len(resources.Requests) == 0 && len(resources.Limits) == 0 {
+				defaults.Resources.DeepCopyInto(resources)
+			}
+		}
+	}
+}
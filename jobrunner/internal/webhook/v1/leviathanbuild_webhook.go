@@ -0,0 +1,592 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"path"
+	"reflect"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/controller"
+	"test.jcrs.dev/jobrunner/internal/logging"
+	"test.jcrs.dev/jobrunner/internal/rerun"
+)
+
+// log is for logging in this package.
+var leviathanbuildlog = logf.Log.WithName("leviathanbuild-resource")
+
+// SetupLeviathanBuildWebhookWithManager registers the webhook for LeviathanBuild in the manager.
+// As v1 is the conversion hub, the conversion webhook between the versions of
+// LeviathanBuild registered in the scheme of the manager is served too.
+// Namespaces may hold maxBuildsPerNamespace builds, unless their annotation says
+// otherwise; they aren't capped when 0. The package names of the builds of a
+// namespace are unique when uniquePackageNames is set, unless its annotation
+// says otherwise. With strictFields, builds with fields their spec doesn't
+// declare are denied.
+func SetupLeviathanBuildWebhookWithManager(mgr ctrl.Manager, maxBuildsPerNamespace int, uniquePackageNames, strictFields bool) error {
+	// Namespaces may turn uniqueness on whatever the flag, so builds are always indexed
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &jcrsv1.LeviathanBuild{}, packageNameKey, indexActivePackageName); err != nil {
+		return err
+	}
+	return ctrl.NewWebhookManagedBy(mgr).For(&jcrsv1.LeviathanBuild{}).
+		WithValidator(&LeviathanBuildCustomValidator{Client: mgr.GetClient(), MaxBuildsPerNamespace: maxBuildsPerNamespace,
+			UniquePackageNames: uniquePackageNames, StrictFields: strictFields}).
+		WithDefaulter(&LeviathanBuildCustomDefaulter{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-jcrs-jcrs-dev-v1-leviathanbuild,mutating=true,failurePolicy=fail,sideEffects=None,groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=create;update,versions=v1,name=mleviathanbuild-v1.kb.io,admissionReviewVersions=v1
+
+// LeviathanBuildCustomDefaulter struct is responsible for setting default values on the custom resource of the
+// Kind LeviathanBuild when those are created or updated.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as it is used only for temporary operations and does not need to be deeply copied.
+type LeviathanBuildCustomDefaulter struct {
+	// Client reads the LeviathanBuildDefaults of namespaces. New builds aren't
+	// defaulted when nil.
+	Client client.Reader
+}
+
+var _ webhook.CustomDefaulter = &LeviathanBuildCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type LeviathanBuild.
+// It records the user who created the build, and the user who last changed its
+// spec, in annotations. Values set by users are overwritten, so that the
+// annotations can be trusted to authorize publishing.
+// New builds are defaulted from the LeviathanBuildDefaults of their namespace.
+func (d *LeviathanBuildCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	lvBuild, ok := obj.(*jcrsv1.LeviathanBuild)
+	if !ok {
+		return fmt.Errorf("expected a LeviathanBuild object but got %T", obj)
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var old *jcrsv1.LeviathanBuild
+	if req.Operation == admissionv1.Update {
+		old = &jcrsv1.LeviathanBuild{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return fmt.Errorf("decoding the LeviathanBuild being updated: %w", err)
+		}
+	}
+	recordIdentity(lvBuild, old, req.UserInfo.Username)
+	if old == nil {
+		return d.applyDefaults(ctx, lvBuild)
+	}
+	return nil
+}
+
+// recordIdentity sets the identity annotations of lvBuild, which is created by
+// user when old is nil, and updated from old otherwise.
+func recordIdentity(lvBuild, old *jcrsv1.LeviathanBuild, user string) {
+	if lvBuild.Annotations == nil {
+		lvBuild.Annotations = make(map[string]string)
+	}
+	if old == nil {
+		lvBuild.Annotations[controller.CreatedByAnnotation] = user
+		lvBuild.Annotations[controller.TriggeredByAnnotation] = user
+		return
+	}
+
+	keep := func(key string) {
+		if value, ok := old.Annotations[key]; ok {
+			lvBuild.Annotations[key] = value
+		} else {
+			delete(lvBuild.Annotations, key)
+		}
+	}
+	keep(controller.CreatedByAnnotation)
+	// Suspending or resuming a build doesn't trigger it
+	spec := lvBuild.Spec.DeepCopy()
+	spec.Suspend = old.Spec.Suspend
+	if equality.Semantic.DeepEqual(old.Spec, *spec) {
+		keep(controller.TriggeredByAnnotation)
+	} else {
+		lvBuild.Annotations[controller.TriggeredByAnnotation] = user
+	}
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-jcrs-jcrs-dev-v1-leviathanbuild,mutating=false,failurePolicy=fail,sideEffects=None,groups=jcrs.jcrs.dev,resources=leviathanbuilds,verbs=create;update,versions=v1,name=vleviathanbuild-v1.kb.io,admissionReviewVersions=v1
+
+// LeviathanBuildCustomValidator struct is responsible for validating the LeviathanBuild resource
+// when it is created, updated, or deleted.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type LeviathanBuildCustomValidator struct {
+	// Client counts the builds of namespaces against their quota, and finds the
+	// other builds of a package. Neither is checked when nil.
+	Client client.Reader
+	// MaxBuildsPerNamespace is the number of builds a namespace may hold, unless
+	// the MaxBuildsAnnotation of the namespace says otherwise. Unlimited when 0.
+	MaxBuildsPerNamespace int
+	// UniquePackageNames denies a build while another active build of its
+	// namespace builds the same package, unless the UniquePackageNamesAnnotation
+	// of the namespace says otherwise.
+	UniquePackageNames bool
+	// StrictFields denies builds with fields under spec that the spec doesn't
+	// declare, suggesting the nearest declared field.
+	StrictFields bool
+}
+
+var _ webhook.CustomValidator = &LeviathanBuildCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type LeviathanBuild.
+func (v *LeviathanBuildCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	lvBuild, ok := obj.(*jcrsv1.LeviathanBuild)
+	if !ok {
+		return nil, fmt.Errorf("expected a LeviathanBuild object but got %T", obj)
+	}
+	leviathanbuildlog.Info("Validation for LeviathanBuild upon creation", "name", lvBuild.GetName())
+
+	if err := v.validateLeviathanBuild(ctx, lvBuild); err != nil {
+		return nil, err
+	}
+	if err := v.checkQuota(ctx, lvBuild); err != nil {
+		return nil, err
+	}
+	return nil, v.checkPackageName(ctx, lvBuild, nil)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type LeviathanBuild.
+func (v *LeviathanBuildCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	lvBuild, ok := newObj.(*jcrsv1.LeviathanBuild)
+	if !ok {
+		return nil, fmt.Errorf("expected a LeviathanBuild object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*jcrsv1.LeviathanBuild)
+	if !ok {
+		return nil, fmt.Errorf("expected a LeviathanBuild object for the oldObj but got %T", oldObj)
+	}
+	leviathanbuildlog.Info("Validation for LeviathanBuild upon update", "name", lvBuild.GetName())
+
+	if err := v.validateLeviathanBuild(ctx, lvBuild); err != nil {
+		return nil, err
+	}
+	return nil, v.checkPackageName(ctx, lvBuild, old)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type LeviathanBuild.
+func (v *LeviathanBuildCustomValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateLeviathanBuild validates the fields of a LeviathanBuild object.
+func (v *LeviathanBuildCustomValidator) validateLeviathanBuild(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) error {
+	allErrs := validateBuild(lvBuild)
+	if v.StrictFields {
+		allErrs = append(allErrs, strictSpecFields(ctx, reflect.TypeFor[jcrsv1.LeviathanBuildSpec]())...)
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: jcrsv1.GroupVersion.Group, Kind: "LeviathanBuild"},
+		lvBuild.Name, allErrs)
+}
+
+// validateBuild validates the fields of lvBuild, a LeviathanBuild or the
+// LeviathanBuild standing for a LeviathanClusterBuild.
+func validateBuild(lvBuild *jcrsv1.LeviathanBuild) field.ErrorList {
+	allErrs := validateVolumes(&lvBuild.Spec, field.NewPath("spec"))
+	allErrs = append(allErrs, validateSidecars(&lvBuild.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateArtifactRetention(&lvBuild.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateVerify(&lvBuild.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateJobPatches(&lvBuild.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateDNS(&lvBuild.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateGitSource(&lvBuild.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateClusterSelector(&lvBuild.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateExecutionBackend(&lvBuild.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateNaming(lvBuild, field.NewPath("spec", "naming"))...)
+	allErrs = append(allErrs, validatePublishPath(lvBuild, field.NewPath("spec", "publishTarget"))...)
+	allErrs = append(allErrs, validateLogLevel(&lvBuild.ObjectMeta, field.NewPath("metadata"))...)
+	allErrs = append(allErrs, validateParameterOverrides(lvBuild, field.NewPath("metadata"))...)
+	return allErrs
+}
+
+// validateVolumes checks that the volumes and volume mounts of the build can be
+// merged into the pod spec of the jobTemplate: volume names and mount paths must
+// not collide with the jobTemplate or the volumes managed by the controller, and
+// mounts must refer to an existing volume.
+func validateVolumes(spec *jcrsv1.LeviathanBuildSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	podSpec := spec.JobTemplate.Spec.Template.Spec
+
+	reservedPaths := make(map[string]bool)
+	for _, mountPath := range controller.ReservedVolumes {
+		reservedPaths[mountPath] = true
+	}
+
+	volumes := make(map[string]bool)
+	for _, vol := range podSpec.Volumes {
+		volumes[vol.Name] = true
+	}
+	for i, vol := range spec.Volumes {
+		idxPath := fldPath.Child("volumes").Index(i).Child("name")
+		if _, reserved := controller.ReservedVolumes[vol.Name]; reserved {
+			allErrs = append(allErrs, field.Invalid(idxPath, vol.Name, "name is reserved for a volume managed by the controller"))
+		} else if volumes[vol.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath, vol.Name))
+		}
+		volumes[vol.Name] = true
+	}
+
+	mountPaths := make(map[string]bool)
+	for _, c := range podSpec.Containers {
+		for _, mount := range c.VolumeMounts {
+			mountPaths[mount.MountPath] = true
+		}
+	}
+	for i, mount := range spec.VolumeMounts {
+		idxPath := fldPath.Child("volumeMounts").Index(i)
+		if !volumes[mount.Name] {
+			allErrs = append(allErrs, field.NotFound(idxPath.Child("name"), mount.Name))
+		}
+		if reservedPaths[mount.MountPath] {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("mountPath"), mount.MountPath, "path is reserved for a volume managed by the controller"))
+		} else if mountPaths[mount.MountPath] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("mountPath"), mount.MountPath))
+		}
+	}
+
+	return allErrs
+}
+
+// validateSidecars checks that the sidecars of the build don't share a name with
+// the containers of the jobTemplate or those managed by the controller.
+func validateSidecars(spec *jcrsv1.LeviathanBuildSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	podSpec := spec.JobTemplate.Spec.Template.Spec
+
+	names := make(map[string]bool)
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for _, c := range containers {
+			names[c.Name] = true
+		}
+	}
+	for i, sidecar := range spec.Sidecars {
+		idxPath := fldPath.Child("sidecars").Index(i).Child("name")
+		if controller.ReservedContainers[sidecar.Name] {
+			allErrs = append(allErrs, field.Invalid(idxPath, sidecar.Name, "name is reserved for a container managed by the controller"))
+		} else if names[sidecar.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath, sidecar.Name))
+		}
+		names[sidecar.Name] = true
+	}
+
+	return allErrs
+}
+
+// validateArtifactRetention checks that a build with an artifact retention policy
+// publishes its package somewhere versions can be pruned from.
+func validateArtifactRetention(spec *jcrsv1.LeviathanBuildSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.ArtifactRetention == nil {
+		return allErrs
+	}
+
+	retentionPath := fldPath.Child("artifactRetention")
+	if spec.BuildType != jcrsv1.Publish && spec.BuildType != jcrsv1.BuildPublish {
+		allErrs = append(allErrs, field.Forbidden(retentionPath, "only builds that publish a package can prune its versions"))
+	}
+	if spec.PublishTarget == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("publishTarget"), "required by artifactRetention"))
+	}
+
+	return allErrs
+}
+
+// validateVerify checks that a Verify build doesn't set what's only used by builds
+// that publish a package.
+func validateVerify(spec *jcrsv1.LeviathanBuildSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.BuildType != jcrsv1.Verify {
+		return allErrs
+	}
+
+	if spec.PublishTarget != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("publishTarget"), "Verify builds don't publish a package"))
+	}
+	if spec.OnSuccess != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("onSuccess"), "Verify builds don't publish a package"))
+	}
+
+	return allErrs
+}
+
+// validateClusterSelector checks that the Job of a build dispatched to a remote
+// cluster only references objects the remote cluster may hold: the ConfigMap of
+// an inline script is created by the controller next to the build, and the keys
+// of parametersFrom are read from the namespace of the build.
+func validateClusterSelector(spec *jcrsv1.LeviathanBuildSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.ClusterSelector == nil {
+		return allErrs
+	}
+
+	if spec.SourceType == jcrsv1.InlineSource {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("sourceType"), "Inline sources can't be run in a remote cluster"))
+	}
+	if len(spec.ParametersFrom) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("parametersFrom"), "parameters can't be read by builds run in a remote cluster"))
+	}
+
+	return allErrs
+}
+
+// validateExecutionBackend checks that builds run on another engine than Jobs
+// don't use what the controller only sets up for Jobs: the NetworkPolicy of Strict
+// isolation and the PodDisruptionBudget of ProtectFromEviction select the pods of
+// Jobs, Secret checksums are recorded on Jobs, and fetch slots are released when
+// the fetch init container of the pod of a Job completes.
+func validateExecutionBackend(spec *jcrsv1.LeviathanBuildSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.ExecutionBackend == "" || spec.ExecutionBackend == jcrsv1.JobBackend {
+		return allErrs
+	}
+
+	if spec.Network != nil && spec.Network.Isolation == jcrsv1.StrictIsolation {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("network", "isolation"), "Strict isolation only applies to builds run by Jobs"))
+	}
+	if spec.ProtectionPolicy == jcrsv1.ProtectFromEviction {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("protectionPolicy"), "ProtectFromEviction only applies to builds run by Jobs"))
+	}
+	if spec.ChecksumSecrets {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("checksumSecrets"), "Secret checksums are only recorded for builds run by Jobs"))
+	}
+	if spec.SourceType == jcrsv1.HTTPSource || (spec.SourceType == jcrsv1.GitSource && spec.Source != nil && spec.Source.Git != nil) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("sourceType"), "sources fetched by the controller can only be built by Jobs, as they are limited by the fetch slots"))
+	}
+
+	return allErrs
+}
+
+// validateJobPatches renders the jobPatches of the build against the Job of its
+// jobTemplate. Patches are applied in order, so validation stops at the first
+// one that fails.
+func validateJobPatches(spec *jcrsv1.LeviathanBuildSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      spec.JobTemplate.Labels,
+			Annotations: spec.JobTemplate.Annotations,
+		},
+		Spec: *spec.JobTemplate.Spec.DeepCopy(),
+	}
+	for i, patch := range spec.JobPatches {
+		if err := controller.ApplyJobPatch(job, patch); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("jobPatches").Index(i).Child("patch"), field.OmitValueType{}, err.Error()))
+			break
+		}
+	}
+
+	return allErrs
+}
+
+// validateNaming checks that the naming template of the build renders valid Job
+// names, and that they differ between runs, so that a run doesn't collide with
+// the Job of the previous one.
+func validateNaming(lvBuild *jcrsv1.LeviathanBuild, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if lvBuild.Spec.Naming == nil {
+		return allErrs
+	}
+	templatePath := fldPath.Child("template")
+	first, err := controller.TemplateName(lvBuild, 1)
+	if err != nil {
+		return append(allErrs, field.Invalid(templatePath, lvBuild.Spec.Naming.Template, err.Error()))
+	}
+	second, err := controller.TemplateName(lvBuild, 2)
+	if err != nil {
+		return append(allErrs, field.Invalid(templatePath, lvBuild.Spec.Naming.Template, err.Error()))
+	}
+	if first == second {
+		allErrs = append(allErrs, field.Invalid(templatePath, lvBuild.Spec.Naming.Template,
+			fmt.Sprintf("every run would be named %q, the name must depend on .RunIndex", first)))
+	}
+
+	return allErrs
+}
+
+// validatePublishPath checks that the pathTemplate of the publishTarget of the
+// build parses, and renders a valid path for the build as it is admitted.
+func validatePublishPath(lvBuild *jcrsv1.LeviathanBuild, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	target := lvBuild.Spec.PublishTarget
+	if target == nil || target.PathTemplate == "" {
+		return allErrs
+	}
+	if _, err := controller.PublishPath(lvBuild); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("pathTemplate"), target.PathTemplate, err.Error()))
+	}
+	return allErrs
+}
+
+// validateGitSource checks that the git configuration of the build can be cloned
+// by the fetch init container: it needs the URL of a Git source, and sparse paths
+// are directories of the repository, not patterns.
+func validateGitSource(spec *jcrsv1.LeviathanBuildSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.Source == nil || spec.Source.Git == nil {
+		return allErrs
+	}
+
+	gitPath := fldPath.Child("source", "git")
+	if spec.SourceType != jcrsv1.GitSource {
+		allErrs = append(allErrs, field.Forbidden(gitPath, "only allowed when sourceType is Git"))
+	} else if spec.SourceURL == nil || *spec.SourceURL == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("sourceURL"), "required to clone the repository"))
+	}
+	for i, sparsePath := range spec.Source.Git.SparsePaths {
+		idxPath := gitPath.Child("sparsePaths").Index(i)
+		switch {
+		case path.IsAbs(sparsePath):
+			allErrs = append(allErrs, field.Invalid(idxPath, sparsePath, "must be relative to the root of the repository"))
+		case path.Clean(sparsePath) != strings.TrimSuffix(sparsePath, "/") || strings.HasPrefix(path.Clean(sparsePath), ".."):
+			allErrs = append(allErrs, field.Invalid(idxPath, sparsePath, "must be a clean path inside the repository"))
+		case strings.ContainsAny(sparsePath, `*?[\`):
+			allErrs = append(allErrs, field.Invalid(idxPath, sparsePath, "must be a directory, not a pattern"))
+		}
+	}
+
+	return allErrs
+}
+
+// maxDNSNameservers and maxDNSSearches are the limits the API server puts on the
+// dnsConfig of a pod.
+const (
+	maxDNSNameservers = 3
+	maxDNSSearches    = 32
+)
+
+// validateDNS checks that the DNS configuration of the build makes a valid pod:
+// a dnsPolicy of None needs nameservers, which must be IP addresses, and search
+// domains and host aliases must be valid names. The API server would only reject
+// them once the Job creates its pods, where the build would hang without a word.
+func validateDNS(spec *jcrsv1.LeviathanBuildSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	podSpec := spec.JobTemplate.Spec.Template.Spec
+
+	dnsConfig, configPath := spec.DNSConfig, fldPath.Child("dnsConfig")
+	if dnsConfig == nil {
+		dnsConfig = podSpec.DNSConfig
+	}
+	policy := spec.DNSPolicy
+	if policy == "" {
+		policy = podSpec.DNSPolicy
+	}
+	if policy == corev1.DNSNone && (dnsConfig == nil || len(dnsConfig.Nameservers) == 0) {
+		allErrs = append(allErrs, field.Required(configPath.Child("nameservers"), "required when dnsPolicy is None"))
+	}
+
+	if config := spec.DNSConfig; config != nil {
+		if len(config.Nameservers) > maxDNSNameservers {
+			allErrs = append(allErrs, field.TooMany(configPath.Child("nameservers"), len(config.Nameservers), maxDNSNameservers))
+		}
+		for i, nameserver := range config.Nameservers {
+			if _, err := netip.ParseAddr(nameserver); err != nil {
+				allErrs = append(allErrs, field.Invalid(configPath.Child("nameservers").Index(i), nameserver, "must be an IP address"))
+			}
+		}
+		if len(config.Searches) > maxDNSSearches {
+			allErrs = append(allErrs, field.TooMany(configPath.Child("searches"), len(config.Searches), maxDNSSearches))
+		}
+		for i, search := range config.Searches {
+			if search == "." {
+				continue
+			}
+			for _, msg := range validation.IsDNS1123Subdomain(strings.TrimSuffix(search, ".")) {
+				allErrs = append(allErrs, field.Invalid(configPath.Child("searches").Index(i), search, msg))
+			}
+		}
+		for i, option := range config.Options {
+			if option.Name == "" {
+				allErrs = append(allErrs, field.Required(configPath.Child("options").Index(i).Child("name"), ""))
+			}
+		}
+	}
+
+	for i, alias := range spec.HostAliases {
+		idxPath := fldPath.Child("hostAliases").Index(i)
+		if _, err := netip.ParseAddr(alias.IP); err != nil {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("ip"), alias.IP, "must be an IP address"))
+		}
+		if len(alias.Hostnames) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("hostnames"), ""))
+		}
+		for j, hostname := range alias.Hostnames {
+			for _, msg := range validation.IsDNS1123Subdomain(hostname) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("hostnames").Index(j), hostname, msg))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateLogLevel checks that the log level annotation of the build is a level
+// the controller can log at.
+func validateLogLevel(meta *metav1.ObjectMeta, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if level, ok := meta.Annotations[controller.LogLevelAnnotation]; ok {
+		if _, err := logging.ParseLevel(level); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("annotations").Key(controller.LogLevelAnnotation), level, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+// validateParameterOverrides checks that the parameter overrides annotation of
+// the build is a JSON object of strings keyed by parameter names. Whether the
+// parameters are declared is only known when the Job of the build is constructed.
+func validateParameterOverrides(lvBuild *jcrsv1.LeviathanBuild, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if _, err := rerun.ParameterOverrides(lvBuild); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("annotations").Key(rerun.ParameterOverridesAnnotation),
+			lvBuild.Annotations[rerun.ParameterOverridesAnnotation], err.Error()))
+	}
+
+	return allErrs
+}
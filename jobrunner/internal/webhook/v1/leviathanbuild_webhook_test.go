@@ -0,0 +1,609 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/controller"
+	"test.jcrs.dev/jobrunner/internal/featuregates"
+	"test.jcrs.dev/jobrunner/internal/rerun"
+)
+
+var _ = Describe("LeviathanBuild Webhook", func() {
+	var (
+		obj       *jcrsv1.LeviathanBuild
+		validator LeviathanBuildCustomValidator
+	)
+
+	BeforeEach(func() {
+		obj = &jcrsv1.LeviathanBuild{Spec: jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("pkg")}}
+		podSpec := &obj.Spec.JobTemplate.Spec.Template.Spec
+		podSpec.Volumes = []corev1.Volume{{Name: "cache"}}
+		podSpec.Containers = []corev1.Container{{
+			Name:         "build",
+			Image:        "busybox",
+			VolumeMounts: []corev1.VolumeMount{{Name: "cache", MountPath: "/cache-dir"}},
+		}}
+		validator = LeviathanBuildCustomValidator{}
+	})
+
+	Context("When creating or updating LeviathanBuild under Validating Webhook", func() {
+		It("Should admit volumes merging cleanly into the jobTemplate", func() {
+			obj.Spec.Volumes = []corev1.Volume{{Name: "secrets"}}
+			obj.Spec.VolumeMounts = []corev1.VolumeMount{
+				{Name: "secrets", MountPath: "/secrets"},
+				{Name: "cache", MountPath: "/other-cache", SubPath: "other"},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+			Expect(validator.ValidateUpdate(ctx, obj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny volumes colliding with the jobTemplate or the controller", func() {
+			obj.Spec.Volumes = []corev1.Volume{{Name: "cache"}, {Name: "workspace"}}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.volumes[0].name: Duplicate value"),
+				ContainSubstring("spec.volumes[1].name: Invalid value"),
+			)))
+		})
+
+		It("Should deny mounts of unknown volumes or at paths in use", func() {
+			obj.Spec.VolumeMounts = []corev1.VolumeMount{
+				{Name: "missing", MountPath: "/missing"},
+				{Name: "cache", MountPath: "/cache-dir"},
+				{Name: "cache", MountPath: "/workspace"},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.volumeMounts[0].name: Not found"),
+				ContainSubstring("spec.volumeMounts[1].mountPath: Duplicate value"),
+				ContainSubstring("spec.volumeMounts[2].mountPath: Invalid value"),
+			)))
+		})
+
+		It("Should deny sidecars named after other containers", func() {
+			obj.Spec.Sidecars = []corev1.Container{
+				{Name: "dind", Image: "docker:dind"},
+				{Name: "build", Image: "localstack/localstack"},
+				{Name: "fetch", Image: "busybox"},
+				{Name: "dind", Image: "docker:dind"},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.sidecars[1].name: Duplicate value"),
+				ContainSubstring("spec.sidecars[2].name: Invalid value"),
+				ContainSubstring("spec.sidecars[3].name: Duplicate value"),
+				Not(ContainSubstring("spec.sidecars[0]")),
+			)))
+		})
+
+		It("Should deny retention policies of builds that don't publish", func() {
+			obj.Spec.ArtifactRetention = &jcrsv1.ArtifactRetention{Store: jcrsv1.OCIArtifactStore, KeepLast: ptr.To[int32](5)}
+			obj.Spec.BuildType = jcrsv1.Build
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.artifactRetention: Forbidden"),
+				ContainSubstring("spec.publishTarget: Required value"),
+			)))
+
+			obj.Spec.BuildType = jcrsv1.BuildPublish
+			obj.Spec.PublishTarget = &jcrsv1.PublishTarget{RegistryURL: "registry.example.com/team", Version: "1.0.0"}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny Verify builds with a publish target or onSuccess actions", func() {
+			obj.Spec.BuildType = jcrsv1.Verify
+			obj.Spec.PublishTarget = &jcrsv1.PublishTarget{RegistryURL: "registry.example.com/team", Version: "1.0.0"}
+			obj.Spec.OnSuccess = &jcrsv1.OnSuccessSpec{}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.publishTarget: Forbidden"),
+				ContainSubstring("spec.onSuccess: Forbidden"),
+			)))
+
+			obj.Spec.PublishTarget = nil
+			obj.Spec.OnSuccess = nil
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny builds run in a remote cluster with objects of the controller", func() {
+			obj.Spec.ClusterSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "builds"}}
+			obj.Spec.SourceType = jcrsv1.InlineSource
+			obj.Spec.Source = &jcrsv1.SourceSpec{Inline: &jcrsv1.InlineSourceSpec{Script: "make"}}
+			obj.Spec.ParametersFrom = []jcrsv1.ParametersSource{{ConfigMapRef: &corev1.LocalObjectReference{Name: "params"}}}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.sourceType: Forbidden"),
+				ContainSubstring("spec.parametersFrom: Forbidden"),
+			)))
+
+			obj.Spec.ClusterSelector = nil
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny builds run on another engine with what only applies to Jobs", func() {
+			obj.Spec.ExecutionBackend = jcrsv1.TektonBackend
+			obj.Spec.Network = &jcrsv1.NetworkSpec{Isolation: jcrsv1.StrictIsolation}
+			obj.Spec.ProtectionPolicy = jcrsv1.ProtectFromEviction
+			obj.Spec.ChecksumSecrets = true
+			obj.Spec.SourceType = jcrsv1.HTTPSource
+			obj.Spec.SourceURL = ptr.To("https://example.com/src.tar.gz")
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.network.isolation: Forbidden"),
+				ContainSubstring("spec.protectionPolicy: Forbidden"),
+				ContainSubstring("spec.checksumSecrets: Forbidden"),
+				ContainSubstring("spec.sourceType: Forbidden"),
+			)))
+
+			obj.Spec.ExecutionBackend = jcrsv1.JobBackend
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny job patches that don't apply to the jobTemplate", func() {
+			obj.Spec.JobPatches = []jcrsv1.JobPatch{
+				{Type: jcrsv1.StrategicMergePatch, Patch: "spec:\n  backoffLimit: 0"},
+				{Type: jcrsv1.JSON6902Patch, Patch: `[{"op": "add", "path": "/spec/template/spec/containers/0/args", "value": ["--ci"]}]`},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			obj.Spec.JobPatches[1].Patch = `[{"op": "replace", "path": "/spec/template/spec/containers/1/image", "value": "node:22"}]`
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.jobPatches[1].patch: Invalid value")))
+		})
+
+		It("Should deny naming templates that don't name every run apart", func() {
+			obj.Spec.Naming = &jcrsv1.NamingSpec{Template: "ci-r{{ .Revision }}-{{ .RunIndex }}"}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			obj.Spec.Naming.Template = "ci-r{{ .Revision }}"
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("the name must depend on .RunIndex")))
+
+			obj.Spec.Naming.Template = "CI.{{ .RunIndex }}"
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.naming.template: Invalid value")))
+		})
+
+		It("Should deny pathTemplates that don't render a valid publish path", func() {
+			obj.Namespace = "default"
+			obj.Spec.PackageName = ptr.To("widget")
+			obj.Spec.PublishTarget = &jcrsv1.PublishTarget{RegistryURL: "registry.example.com", Version: "1.0.0",
+				PathTemplate: "{{ .Namespace }}/{{ .BuildType | lower }}/{{ .PackageName }}"}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			obj.Spec.PublishTarget.PathTemplate = "{{ .Namespace }/{{ .PackageName }}"
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.publishTarget.pathTemplate: Invalid value")))
+
+			obj.Spec.PublishTarget.PathTemplate = "{{ .Team }}/{{ .PackageName }}"
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.publishTarget.pathTemplate: Invalid value")))
+
+			obj.Spec.PublishTarget.PathTemplate = "../{{ .PackageName }}"
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.publishTarget.pathTemplate: Invalid value")))
+		})
+
+		It("Should admit the git configuration of Git sources", func() {
+			obj.Spec.SourceType = jcrsv1.GitSource
+			obj.Spec.SourceURL = ptr.To("https://github.com/example/monorepo.git")
+			obj.Spec.Source = &jcrsv1.SourceSpec{Git: &jcrsv1.GitSourceSpec{
+				Submodules:  jcrsv1.RecursiveSubmodules,
+				LFS:         true,
+				SparsePaths: []string{"services/web/", "libs"},
+			}}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny git configurations the fetch init container can't clone", func() {
+			obj.Spec.SourceType = jcrsv1.GitSource
+			obj.Spec.Source = &jcrsv1.SourceSpec{Git: &jcrsv1.GitSourceSpec{
+				SparsePaths: []string{"/services", "services/../..", "./libs", "services/*"},
+			}}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.sourceURL: Required value: required to clone the repository"),
+				ContainSubstring(`spec.source.git.sparsePaths[0]: Invalid value: "/services": must be relative to the root of the repository`),
+				ContainSubstring(`spec.source.git.sparsePaths[1]: Invalid value: "services/../..": must be a clean path inside the repository`),
+				ContainSubstring(`spec.source.git.sparsePaths[2]: Invalid value: "./libs": must be a clean path inside the repository`),
+				ContainSubstring(`spec.source.git.sparsePaths[3]: Invalid value: "services/*": must be a directory, not a pattern`),
+			)))
+
+			obj.Spec.SourceType = jcrsv1.HTTPSource
+			obj.Spec.Source.Git.SparsePaths = nil
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(
+				ContainSubstring("spec.source.git: Forbidden: only allowed when sourceType is Git")))
+		})
+
+		It("Should admit a valid DNS configuration", func() {
+			obj.Spec.DNSPolicy = corev1.DNSNone
+			obj.Spec.DNSConfig = &corev1.PodDNSConfig{
+				Nameservers: []string{"10.0.0.53", "fd00::53"},
+				Searches:    []string{"mirrors.corp.", "."},
+				Options:     []corev1.PodDNSConfigOption{{Name: "ndots", Value: ptr.To("2")}},
+			}
+			obj.Spec.HostAliases = []corev1.HostAlias{{IP: "10.0.0.2", Hostnames: []string{"pypi.corp"}}}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			By("taking the nameservers of the jobTemplate into account")
+			obj.Spec.JobTemplate.Spec.Template.Spec.DNSConfig, obj.Spec.DNSConfig = obj.Spec.DNSConfig, nil
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny DNS configurations the build pods couldn't run with", func() {
+			obj.Spec.JobTemplate.Spec.Template.Spec.DNSPolicy = corev1.DNSNone
+			obj.Spec.DNSConfig = &corev1.PodDNSConfig{
+				Searches: []string{"not_a_domain"},
+				Options:  []corev1.PodDNSConfigOption{{Value: ptr.To("2")}},
+			}
+			obj.Spec.HostAliases = []corev1.HostAlias{
+				{IP: "pypi", Hostnames: []string{"pypi.corp"}},
+				{IP: "10.0.0.3", Hostnames: []string{"Bad Host"}},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.dnsConfig.nameservers: Required value: required when dnsPolicy is None"),
+				ContainSubstring(`spec.dnsConfig.searches[0]: Invalid value: "not_a_domain"`),
+				ContainSubstring("spec.dnsConfig.options[0].name: Required value"),
+				ContainSubstring(`spec.hostAliases[0].ip: Invalid value: "pypi": must be an IP address`),
+				ContainSubstring(`spec.hostAliases[1].hostnames[0]: Invalid value: "Bad Host"`),
+			)))
+
+			obj.Spec.DNSConfig = &corev1.PodDNSConfig{Nameservers: []string{"1.1.1.1", "8.8.8.8", "9.9.9.9", "dns.corp"}}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.dnsConfig.nameservers: Too many: 4: must have at most 3 items"),
+				ContainSubstring(`spec.dnsConfig.nameservers[3]: Invalid value: "dns.corp": must be an IP address`),
+			)))
+		})
+
+		It("Should deny unknown log levels", func() {
+			obj.Annotations = map[string]string{controller.LogLevelAnnotation: "debug"}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			obj.Annotations[controller.LogLevelAnnotation] = "verbose"
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring(
+				"metadata.annotations[jcrs.jcrs.dev/log-level]: Invalid value")))
+		})
+
+		It("Should deny malformed parameter overrides", func() {
+			obj.Annotations = map[string]string{rerun.ParameterOverridesAnnotation: `{"VERSION":"1.2.3-hotfix"}`}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			obj.Annotations[rerun.ParameterOverridesAnnotation] = `{"VERSION":1}`
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring(
+				"metadata.annotations[jcrs.jcrs.dev/parameter-overrides]: Invalid value")))
+
+			obj.Annotations[rerun.ParameterOverridesAnnotation] = `{"1VERSION":"1.2.3"}`
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring(
+				`invalid parameter name "1VERSION"`)))
+		})
+
+		It("Should deny fields the spec doesn't declare in strict mode", func() {
+			raw := []byte(`{"spec": {
+				"soucePath": "/src",
+				"packageName": "pkg",
+				"publishTarget": {"registryURL": "https://registry.example.com", "verison": "1.0"},
+				"jobTemplate": {"spec": {"template": {"spec": {"containers": [{"name": "build", "imag": "busybox",
+					"resources": {"requests": {"cpu": "1"}}}]}}}},
+				"zzz": true
+			}}`)
+			strictCtx := admission.NewContextWithRequest(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			Expect(validator.ValidateCreate(strictCtx, obj)).Error().NotTo(HaveOccurred())
+
+			validator.StrictFields = true
+			_, err := validator.ValidateCreate(strictCtx, obj)
+			Expect(err).To(MatchError(And(
+				ContainSubstring(`spec.soucePath: Forbidden: unknown field, did you mean "sourcePath"?`),
+				ContainSubstring(`spec.publishTarget.verison: Forbidden: unknown field, did you mean "version"?`),
+				ContainSubstring(`spec.jobTemplate.spec.template.spec.containers[0].imag: Forbidden: unknown field, did you mean "image"?`),
+				ContainSubstring(`spec.zzz: Forbidden: unknown field`),
+			)))
+			Expect(err.Error()).NotTo(ContainSubstring("zzz: Forbidden: unknown field, did you mean"))
+			Expect(err.Error()).NotTo(ContainSubstring("packageName"))
+			Expect(err.Error()).NotTo(ContainSubstring("resources"))
+		})
+	})
+
+	Context("When creating LeviathanBuild beyond the quota of its namespace", func() {
+		var ns *corev1.Namespace
+
+		BeforeEach(func() {
+			obj.Name = "new"
+			obj.Namespace = "team"
+			ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+			validator.MaxBuildsPerNamespace = 2
+		})
+
+		withBuilds := func(n int) {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+			builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ns)
+			for i := range n {
+				builder.WithObjects(&jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("build-%d", i), Namespace: "team",
+				}})
+			}
+			builder.WithObjects(&jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"}})
+			validator.Client = builder.Build()
+		}
+
+		It("Should admit builds up to the quota", func() {
+			withBuilds(1)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny builds beyond the quota", func() {
+			withBuilds(2)
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("namespace team already holds 2 LeviathanBuilds, its quota is 2")))
+
+			By("leaving updates of the builds alone")
+			Expect(validator.ValidateUpdate(ctx, obj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should let the annotation of the namespace override the quota", func() {
+			ns.Annotations = map[string]string{MaxBuildsAnnotation: "3"}
+			withBuilds(2)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			ns.Annotations[MaxBuildsAnnotation] = "0"
+			validator.MaxBuildsPerNamespace = 0
+			withBuilds(5)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			ns.Annotations[MaxBuildsAnnotation] = "1"
+			validator.MaxBuildsPerNamespace = 0
+			withBuilds(1)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("its quota is 1")))
+		})
+
+		It("Should deny builds of namespaces with an invalid annotation", func() {
+			ns.Annotations = map[string]string{MaxBuildsAnnotation: "many"}
+			withBuilds(0)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(
+				ContainSubstring(`annotation jcrs.jcrs.dev/max-builds of namespace team must be a non-negative integer, not "many"`)))
+		})
+	})
+
+	Context("When creating or updating LeviathanBuild of a package built in its namespace", func() {
+		var ns *corev1.Namespace
+
+		BeforeEach(func() {
+			obj.Name = "new"
+			obj.Namespace = "team"
+			ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+			validator.UniquePackageNames = true
+		})
+
+		withBuilds := func(builds ...*jcrsv1.LeviathanBuild) {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+			builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ns).
+				WithIndex(&jcrsv1.LeviathanBuild{}, packageNameKey, indexActivePackageName)
+			for _, build := range builds {
+				builder.WithObjects(build)
+			}
+			builder.WithObjects(&jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+				Spec: jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("pkg")}})
+			validator.Client = builder.Build()
+		}
+		build := func(name, packageName string) *jcrsv1.LeviathanBuild {
+			return &jcrsv1.LeviathanBuild{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team"},
+				Spec: jcrsv1.LeviathanBuildSpec{PackageName: ptr.To(packageName)}}
+		}
+
+		It("Should deny a second active build of the package", func() {
+			withBuilds(build("web", "pkg"), build("api", "api"))
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("LeviathanBuild web already builds package pkg in namespace team")))
+
+			By("denying builds renamed to the package")
+			renamed := build("api", "pkg")
+			Expect(validator.ValidateUpdate(ctx, build("api", "api"), renamed)).Error().To(MatchError(ContainSubstring("already builds package pkg")))
+
+			By("leaving updates of builds of the package alone")
+			Expect(validator.ValidateUpdate(ctx, obj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should admit builds next to Verify builds and builds being deleted", func() {
+			verify := build("verify", "pkg")
+			verify.Spec.BuildType = jcrsv1.Verify
+			deleting := build("deleting", "pkg")
+			deleting.DeletionTimestamp = &metav1.Time{Time: metav1.Now().Time}
+			deleting.Finalizers = []string{"jcrs.jcrs.dev/finalizer"}
+			withBuilds(verify, deleting)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			obj.Spec.BuildType = jcrsv1.Verify
+			withBuilds(build("web", "pkg"))
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should let the annotation of the namespace turn uniqueness on or off", func() {
+			ns.Annotations = map[string]string{UniquePackageNamesAnnotation: "false"}
+			withBuilds(build("web", "pkg"))
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			ns.Annotations[UniquePackageNamesAnnotation] = "true"
+			validator.UniquePackageNames = false
+			withBuilds(build("web", "pkg"))
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("already builds package pkg")))
+
+			ns.Annotations[UniquePackageNamesAnnotation] = "sometimes"
+			withBuilds()
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(
+				ContainSubstring(`annotation jcrs.jcrs.dev/unique-package-names of namespace team must be true or false, not "sometimes"`)))
+		})
+	})
+
+	Context("When creating or updating LeviathanBuild under Defaulting Webhook", func() {
+		var defaulter LeviathanBuildCustomDefaulter
+
+		// admit runs the defaulter on obj as user, for an update of old unless it is nil
+		admit := func(obj, old *jcrsv1.LeviathanBuild, user string) error {
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  authenticationv1.UserInfo{Username: user},
+			}}
+			if old != nil {
+				raw, err := json.Marshal(old)
+				Expect(err).NotTo(HaveOccurred())
+				req.Operation = admissionv1.Update
+				req.OldObject = runtime.RawExtension{Raw: raw}
+			}
+			return defaulter.Default(admission.NewContextWithRequest(ctx, req), obj)
+		}
+
+		It("Should record the user who created the build, whatever the annotations say", func() {
+			obj.Annotations = map[string]string{controller.TriggeredByAnnotation: "mallory"}
+			Expect(admit(obj, nil, "jane")).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(controller.CreatedByAnnotation, "jane"))
+			Expect(obj.Annotations).To(HaveKeyWithValue(controller.TriggeredByAnnotation, "jane"))
+		})
+
+		It("Should record the user who last changed the spec", func() {
+			Expect(admit(obj, nil, "jane")).To(Succeed())
+			old := obj.DeepCopy()
+
+			By("keeping the annotations on updates that leave the spec alone")
+			obj.Labels = map[string]string{"team": "web"}
+			obj.Annotations[controller.CreatedByAnnotation] = "mallory"
+			delete(obj.Annotations, controller.TriggeredByAnnotation)
+			Expect(admit(obj, old, "mallory")).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(controller.CreatedByAnnotation, "jane"))
+			Expect(obj.Annotations).To(HaveKeyWithValue(controller.TriggeredByAnnotation, "jane"))
+
+			By("keeping the annotations when the build is only suspended")
+			old = obj.DeepCopy()
+			obj.Spec.Suspend = true
+			Expect(admit(obj, old, "mallory")).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(controller.TriggeredByAnnotation, "jane"))
+
+			By("recording who changed the spec")
+			old = obj.DeepCopy()
+			obj.Spec.BuildType = jcrsv1.BuildPublish
+			Expect(admit(obj, old, "john")).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(controller.CreatedByAnnotation, "jane"))
+			Expect(obj.Annotations).To(HaveKeyWithValue(controller.TriggeredByAnnotation, "john"))
+		})
+
+		It("Should not record annotations the build was created without", func() {
+			old := obj.DeepCopy()
+			obj.Annotations = map[string]string{controller.CreatedByAnnotation: "mallory"}
+			Expect(admit(obj, old, "mallory")).To(Succeed())
+			Expect(obj.Annotations).NotTo(HaveKey(controller.CreatedByAnnotation))
+			Expect(obj.Annotations).NotTo(HaveKey(controller.TriggeredByAnnotation))
+		})
+
+		Context("with the LeviathanBuildDefaults of the namespace", func() {
+			var defaults *jcrsv1.LeviathanBuildDefaults
+
+			BeforeEach(func() {
+				Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.BuildDefaults: true})).To(Succeed())
+				DeferCleanup(func() {
+					Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.BuildDefaults: false})).To(Succeed())
+				})
+				obj.Namespace = "team"
+				defaults = &jcrsv1.LeviathanBuildDefaults{
+					ObjectMeta: metav1.ObjectMeta{Name: jcrsv1.LeviathanBuildDefaultsName, Namespace: "team"},
+					Spec: jcrsv1.LeviathanBuildDefaultsSpec{
+						RetryPolicy: &jcrsv1.DefaultRetryPolicy{BackoffLimit: ptr.To[int32](2), ActiveDeadlineSeconds: ptr.To[int64](3600)},
+						Resources: &corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+						},
+					},
+				}
+				scheme := runtime.NewScheme()
+				Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+				Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+				defaulter.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(defaults).Build()
+				DeferCleanup(func() { defaulter.Client = nil })
+			})
+
+			It("Should fill the fields new builds leave unset", func() {
+				obj.Spec.JobTemplate.Spec.Template.Spec.Containers = append(obj.Spec.JobTemplate.Spec.Template.Spec.Containers,
+					corev1.Container{Name: "tuned", Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					}})
+				obj.Spec.JobTemplate.Spec.ActiveDeadlineSeconds = ptr.To[int64](60)
+				Expect(admit(obj, nil, "jane")).To(Succeed())
+
+				jobSpec := obj.Spec.JobTemplate.Spec
+				Expect(jobSpec.BackoffLimit).To(HaveValue(BeEquivalentTo(2)))
+				Expect(jobSpec.ActiveDeadlineSeconds).To(HaveValue(BeEquivalentTo(60)))
+				Expect(jobSpec.Template.Spec.Containers[0].Resources).To(Equal(*defaults.Spec.Resources))
+				Expect(jobSpec.Template.Spec.Containers[1].Resources.Requests).To(BeEmpty())
+			})
+
+			It("Should leave updates and other namespaces alone", func() {
+				old := obj.DeepCopy()
+				Expect(admit(obj, old, "jane")).To(Succeed())
+				Expect(obj.Spec.JobTemplate.Spec.BackoffLimit).To(BeNil())
+
+				obj.Namespace = "other"
+				Expect(admit(obj, nil, "jane")).To(Succeed())
+				Expect(obj.Spec.JobTemplate.Spec.BackoffLimit).To(BeNil())
+			})
+
+			It("Should not default builds while the feature is disabled", func() {
+				Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.BuildDefaults: false})).To(Succeed())
+				Expect(admit(obj, nil, "jane")).To(Succeed())
+				Expect(obj.Spec.JobTemplate.Spec.BackoffLimit).To(BeNil())
+			})
+
+			It("Should fill the fields from the defaults of the project of the build first", func() {
+				Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.Projects: true})).To(Succeed())
+				DeferCleanup(func() {
+					Expect(featuregates.DefaultFeatureGate.SetFromMap(map[featuregates.Feature]bool{featuregates.Projects: false})).To(Succeed())
+				})
+				project := &jcrsv1.LeviathanProject{
+					ObjectMeta: metav1.ObjectMeta{Name: "storefront", Namespace: "team"},
+					Spec: jcrsv1.LeviathanProjectSpec{Defaults: &jcrsv1.LeviathanBuildDefaultsSpec{
+						RetryPolicy: &jcrsv1.DefaultRetryPolicy{BackoffLimit: ptr.To[int32](5)},
+					}},
+				}
+				scheme := runtime.NewScheme()
+				Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+				Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+				defaulter.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(defaults, project).Build()
+				obj.Labels = map[string]string{jcrsv1.ProjectLabel: "storefront"}
+				Expect(admit(obj, nil, "jane")).To(Succeed())
+
+				jobSpec := obj.Spec.JobTemplate.Spec
+				Expect(jobSpec.BackoffLimit).To(HaveValue(BeEquivalentTo(5)))
+				Expect(jobSpec.ActiveDeadlineSeconds).To(HaveValue(BeEquivalentTo(3600)))
+
+				By("defaulting builds of projects that don't exist from the namespace only")
+				obj = obj.DeepCopy()
+				obj.Labels[jcrsv1.ProjectLabel] = "gone"
+				obj.Spec.JobTemplate.Spec.BackoffLimit = nil
+				Expect(admit(obj, nil, "jane")).To(Succeed())
+				Expect(obj.Spec.JobTemplate.Spec.BackoffLimit).To(HaveValue(BeEquivalentTo(2)))
+			})
+		})
+	})
+})
@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// log is for logging in this package.
+var leviathanclusterbuildlog = logf.Log.WithName("leviathanclusterbuild-resource")
+
+// SetupLeviathanClusterBuildWebhookWithManager registers the webhook for LeviathanClusterBuild in the manager.
+// With strictFields, cluster builds with fields their spec doesn't declare are denied.
+func SetupLeviathanClusterBuildWebhookWithManager(mgr ctrl.Manager, strictFields bool) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&jcrsv1.LeviathanClusterBuild{}).
+		WithValidator(&LeviathanClusterBuildCustomValidator{StrictFields: strictFields}).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-jcrs-jcrs-dev-v1-leviathanclusterbuild,mutating=false,failurePolicy=fail,sideEffects=None,groups=jcrs.jcrs.dev,resources=leviathanclusterbuilds,verbs=create;update,versions=v1,name=vleviathanclusterbuild-v1.kb.io,admissionReviewVersions=v1
+
+// LeviathanClusterBuildCustomValidator validates LeviathanClusterBuilds like
+// LeviathanBuilds, and rejects the fields that only work in the namespace of a
+// LeviathanBuild.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type LeviathanClusterBuildCustomValidator struct {
+	// StrictFields denies cluster builds with fields under spec that the spec
+	// doesn't declare, suggesting the nearest declared field.
+	StrictFields bool
+}
+
+var _ webhook.CustomValidator = &LeviathanClusterBuildCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type LeviathanClusterBuild.
+func (v *LeviathanClusterBuildCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	clusterBuild, ok := obj.(*jcrsv1.LeviathanClusterBuild)
+	if !ok {
+		return nil, fmt.Errorf("expected a LeviathanClusterBuild object but got %T", obj)
+	}
+	leviathanclusterbuildlog.Info("Validation for LeviathanClusterBuild upon creation", "name", clusterBuild.GetName())
+
+	return nil, v.validateLeviathanClusterBuild(ctx, clusterBuild)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type LeviathanClusterBuild.
+func (v *LeviathanClusterBuildCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	clusterBuild, ok := newObj.(*jcrsv1.LeviathanClusterBuild)
+	if !ok {
+		return nil, fmt.Errorf("expected a LeviathanClusterBuild object for the newObj but got %T", newObj)
+	}
+	leviathanclusterbuildlog.Info("Validation for LeviathanClusterBuild upon update", "name", clusterBuild.GetName())
+
+	return nil, v.validateLeviathanClusterBuild(ctx, clusterBuild)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type LeviathanClusterBuild.
+func (v *LeviathanClusterBuildCustomValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateLeviathanClusterBuild validates the fields of a LeviathanClusterBuild
+// object. The checkpoint claim of a build is only created, and its source only
+// polled, by the controller of LeviathanBuilds, so cluster builds can't
+// checkpoint nor poll their source.
+func (v *LeviathanClusterBuildCustomValidator) validateLeviathanClusterBuild(ctx context.Context, clusterBuild *jcrsv1.LeviathanClusterBuild) error {
+	allErrs := validateBuild(clusterBuild.NamespacedBuild(""))
+	if v.StrictFields {
+		allErrs = append(allErrs, strictSpecFields(ctx, reflect.TypeFor[jcrsv1.LeviathanBuildSpec]())...)
+	}
+	if clusterBuild.Spec.Checkpoint != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "checkpoint"), "cluster builds can't checkpoint"))
+	}
+	if clusterBuild.Spec.PollInterval != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "pollInterval"), "cluster builds can't poll their source"))
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: jcrsv1.GroupVersion.Group, Kind: "LeviathanClusterBuild"},
+		clusterBuild.Name, allErrs)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("LeviathanClusterBuild Webhook", func() {
+	var (
+		obj       *jcrsv1.LeviathanClusterBuild
+		validator LeviathanClusterBuildCustomValidator
+	)
+
+	BeforeEach(func() {
+		obj = &jcrsv1.LeviathanClusterBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "base-image"},
+			Spec:       jcrsv1.LeviathanBuildSpec{PackageName: ptr.To("base-image")},
+		}
+		obj.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "build", Image: "busybox"}}
+		validator = LeviathanClusterBuildCustomValidator{}
+	})
+
+	Context("When creating or updating LeviathanClusterBuild under Validating Webhook", func() {
+		It("Should admit builds valid in a namespace", func() {
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+			Expect(validator.ValidateUpdate(ctx, obj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should validate the spec like a LeviathanBuild", func() {
+			obj.Spec.Volumes = []corev1.Volume{{Name: "workspace"}}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(
+				ContainSubstring("spec.volumes[0].name: Invalid value")))
+		})
+
+		It("Should deny checkpoints", func() {
+			obj.Spec.Checkpoint = &jcrsv1.CheckpointSpec{Enabled: true}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(
+				ContainSubstring("spec.checkpoint: Forbidden: cluster builds can't checkpoint")))
+		})
+
+		It("Should deny polling the source", func() {
+			obj.Spec.PollInterval = &metav1.Duration{Duration: 5 * time.Minute}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(
+				ContainSubstring("spec.pollInterval: Forbidden: cluster builds can't poll their source")))
+		})
+	})
+})
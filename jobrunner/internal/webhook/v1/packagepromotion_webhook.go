@@ -0,0 +1,179 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// log is for logging in this package.
+var packagepromotionlog = logf.Log.WithName("packagepromotion-resource")
+
+// SetupPackagePromotionWebhookWithManager registers the webhooks for PackagePromotion in the manager.
+func SetupPackagePromotionWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&jcrsv1.PackagePromotion{}).
+		WithDefaulter(&PackagePromotionCustomDefaulter{}).
+		WithValidator(&PackagePromotionCustomValidator{}).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/mutate-jcrs-jcrs-dev-v1-packagepromotion,mutating=true,failurePolicy=fail,sideEffects=None,groups=jcrs.jcrs.dev,resources=packagepromotions;packagepromotions/status,verbs=update,versions=v1,name=mpackagepromotion-v1.kb.io,admissionReviewVersions=v1
+
+// PackagePromotionCustomDefaulter stamps the approvals added to PackagePromotions
+// with the user adding them.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as it is used only for temporary operations and does not need to be deeply copied.
+type PackagePromotionCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &PackagePromotionCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type PackagePromotion.
+// The approvals added by an update, which only the status subresource
+// persists, are approved by the user updating the promotion, now, whatever
+// they say.
+func (d *PackagePromotionCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	promo, ok := obj.(*jcrsv1.PackagePromotion)
+	if !ok {
+		return fmt.Errorf("expected a PackagePromotion object but got %T", obj)
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if req.Operation != admissionv1.Update {
+		return nil
+	}
+	old := &jcrsv1.PackagePromotion{}
+	if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+		return fmt.Errorf("decoding the PackagePromotion being updated: %w", err)
+	}
+
+	now := metav1.Now()
+	existing := len(old.Status.Approvals)
+	for i := range promo.Status.Approvals {
+		if i >= existing {
+			promo.Status.Approvals[i].ApprovedBy = req.UserInfo.Username
+			promo.Status.Approvals[i].ApprovedAt = now
+		}
+	}
+	return nil
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-jcrs-jcrs-dev-v1-packagepromotion,mutating=false,failurePolicy=fail,sideEffects=None,groups=jcrs.jcrs.dev,resources=packagepromotions;packagepromotions/status,verbs=update,versions=v1,name=vpackagepromotion-v1.kb.io,admissionReviewVersions=v1
+
+// PackagePromotionCustomValidator keeps the approvals of PackagePromotions
+// append-only, and approved by the users who added them.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type PackagePromotionCustomValidator struct{}
+
+var _ webhook.CustomValidator = &PackagePromotionCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type PackagePromotion.
+func (v *PackagePromotionCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type PackagePromotion.
+func (v *PackagePromotionCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	promo, ok := newObj.(*jcrsv1.PackagePromotion)
+	if !ok {
+		return nil, fmt.Errorf("expected a PackagePromotion object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*jcrsv1.PackagePromotion)
+	if !ok {
+		return nil, fmt.Errorf("expected a PackagePromotion object for the oldObj but got %T", oldObj)
+	}
+	packagepromotionlog.Info("Validation for PackagePromotion upon update", "name", promo.GetName())
+
+	allErrs := validateApprovals(ctx, old, promo)
+	if len(allErrs) == 0 {
+		return nil, nil
+	}
+	return nil, apierrors.NewInvalid(
+		schema.GroupKind{Group: jcrsv1.GroupVersion.Group, Kind: "PackagePromotion"},
+		promo.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type PackagePromotion.
+func (v *PackagePromotionCustomValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateApprovals validates the approvals promo is updated with from old.
+// Approvals are only added, one at a time, by the user approving, and the
+// rest of the status isn't changed along with them.
+func validateApprovals(ctx context.Context, old, promo *jcrsv1.PackagePromotion) field.ErrorList {
+	path := field.NewPath("status", "approvals")
+	approvals := promo.Status.Approvals
+	if equality.Semantic.DeepEqual(old.Status.Approvals, approvals) {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	if len(approvals) < len(old.Status.Approvals) ||
+		!equality.Semantic.DeepEqual(old.Status.Approvals, approvals[:len(old.Status.Approvals)]) {
+		allErrs = append(allErrs, field.Forbidden(path, "approvals can only be added"))
+	}
+	if len(approvals) > len(old.Status.Approvals)+1 {
+		allErrs = append(allErrs, field.Forbidden(path, "approvals are added one at a time"))
+	}
+	user := ""
+	if req, err := admission.RequestFromContext(ctx); err == nil {
+		user = req.UserInfo.Username
+	}
+	for i := len(old.Status.Approvals); i < len(approvals); i++ {
+		if approvals[i].ApprovedBy != user || user == "" {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("approvedBy"), approvals[i].ApprovedBy,
+				"must be the user adding the approval"))
+		}
+		for _, approval := range old.Status.Approvals {
+			if approval.ApprovedBy == approvals[i].ApprovedBy {
+				allErrs = append(allErrs, field.Duplicate(path.Index(i).Child("approvedBy"), approval.ApprovedBy))
+			}
+		}
+	}
+
+	rest, oldRest := promo.Status.DeepCopy(), old.Status.DeepCopy()
+	rest.Approvals, oldRest.Approvals = nil, nil
+	if !equality.Semantic.DeepEqual(oldRest, rest) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("status"), "only approvals can be changed along with approvals"))
+	}
+	return allErrs
+}
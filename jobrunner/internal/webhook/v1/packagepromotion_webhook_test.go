@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("PackagePromotion Webhook", func() {
+	var (
+		old       *jcrsv1.PackagePromotion
+		obj       *jcrsv1.PackagePromotion
+		defaulter PackagePromotionCustomDefaulter
+		validator PackagePromotionCustomValidator
+	)
+
+	// asUser returns the context of an update of old by user
+	asUser := func(user string) context.Context {
+		raw, err := json.Marshal(old)
+		Expect(err).NotTo(HaveOccurred())
+		return admission.NewContextWithRequest(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation:   admissionv1.Update,
+			SubResource: "status",
+			UserInfo:    authenticationv1.UserInfo{Username: user},
+			OldObject:   runtime.RawExtension{Raw: raw},
+		}})
+	}
+	// approve adds an approval of approvedBy to obj, as user
+	approve := func(user, approvedBy string) error {
+		GinkgoHelper()
+		obj.Status.Approvals = append(obj.Status.Approvals, jcrsv1.PromotionApproval{ApprovedBy: approvedBy, Comment: "CHG-42"})
+		Expect(defaulter.Default(asUser(user), obj)).To(Succeed())
+		_, err := validator.ValidateUpdate(asUser(user), old, obj)
+		return err
+	}
+
+	BeforeEach(func() {
+		old = &jcrsv1.PackagePromotion{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1.2.3", Namespace: "prod"},
+			Spec: jcrsv1.PackagePromotionSpec{
+				Source: jcrsv1.PromotionSource{Namespace: "dev", Build: "web"},
+				Target: jcrsv1.PromotionTarget{RegistryURL: "registry.example.com/prod"},
+				Store:  jcrsv1.OCIArtifactStore,
+			},
+			Status: jcrsv1.PackagePromotionStatus{
+				Approvals: []jcrsv1.PromotionApproval{{ApprovedBy: "alice", ApprovedAt: metav1.NewTime(time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC))}},
+				Digest:    "sha256:0123",
+			},
+		}
+		obj = old.DeepCopy()
+	})
+
+	It("Should stamp the approvals with the user adding them", func() {
+		Expect(approve("bob", "")).To(Succeed())
+		Expect(obj.Status.Approvals).To(HaveLen(2))
+		Expect(obj.Status.Approvals[1].ApprovedBy).To(Equal("bob"))
+		Expect(obj.Status.Approvals[1].ApprovedAt.IsZero()).To(BeFalse())
+		Expect(obj.Status.Approvals[1].Comment).To(Equal("CHG-42"))
+
+		By("whatever user the approval names")
+		obj = old.DeepCopy()
+		Expect(approve("bob", "carol")).To(Succeed())
+		Expect(obj.Status.Approvals[1].ApprovedBy).To(Equal("bob"))
+	})
+
+	It("Should deny approvals in the name of another user", func() {
+		obj.Status.Approvals = append(obj.Status.Approvals, jcrsv1.PromotionApproval{ApprovedBy: "carol"})
+		Expect(validator.ValidateUpdate(asUser("bob"), old, obj)).Error().To(MatchError(
+			ContainSubstring(`status.approvals[1].approvedBy: Invalid value: "carol": must be the user adding the approval`)))
+	})
+
+	It("Should deny a second approval of the same user", func() {
+		Expect(approve("alice", "")).To(MatchError(ContainSubstring(`status.approvals[1].approvedBy: Duplicate value: "alice"`)))
+	})
+
+	It("Should keep the approvals append-only", func() {
+		obj.Status.Approvals = nil
+		Expect(validator.ValidateUpdate(asUser("alice"), old, obj)).Error().To(MatchError(
+			ContainSubstring("status.approvals: Forbidden: approvals can only be added")))
+
+		obj.Status.Approvals = []jcrsv1.PromotionApproval{{ApprovedBy: "alice", Comment: "edited"}}
+		Expect(validator.ValidateUpdate(asUser("alice"), old, obj)).Error().To(MatchError(
+			ContainSubstring("approvals can only be added")))
+	})
+
+	It("Should deny changing the rest of the status along with approvals", func() {
+		obj.Status.Digest = "sha256:4567"
+		Expect(approve("bob", "")).To(MatchError(ContainSubstring("status: Forbidden: only approvals can be changed along with approvals")))
+
+		By("admitting the updates of the controller, which don't change approvals")
+		obj.Status.Approvals = old.Status.Approvals
+		Expect(validator.ValidateUpdate(asUser("system:serviceaccount:jobrunner-system:jobrunner-controller-manager"), old, obj)).Error().NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,166 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// log is for logging in this package.
+var publishapprovallog = logf.Log.WithName("publishapproval-resource")
+
+// SetupPublishApprovalWebhookWithManager registers the webhooks for PublishApproval in the manager.
+func SetupPublishApprovalWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&jcrsv1.PublishApproval{}).
+		WithDefaulter(&PublishApprovalCustomDefaulter{}).
+		WithValidator(&PublishApprovalCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/mutate-jcrs-jcrs-dev-v1-publishapproval,mutating=true,failurePolicy=fail,sideEffects=None,groups=jcrs.jcrs.dev,resources=publishapprovals,verbs=create,versions=v1,name=mpublishapproval-v1.kb.io,admissionReviewVersions=v1
+
+// PublishApprovalCustomDefaulter stamps PublishApprovals with the user creating them.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as it is used only for temporary operations and does not need to be deeply copied.
+type PublishApprovalCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &PublishApprovalCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type PublishApproval.
+// Approvals are approved by the user creating them, whatever they say.
+func (d *PublishApprovalCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	approval, ok := obj.(*jcrsv1.PublishApproval)
+	if !ok {
+		return fmt.Errorf("expected a PublishApproval object but got %T", obj)
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	approval.Spec.ApprovedBy = req.UserInfo.Username
+	return nil
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-jcrs-jcrs-dev-v1-publishapproval,mutating=false,failurePolicy=fail,sideEffects=None,groups=jcrs.jcrs.dev,resources=publishapprovals,verbs=create,versions=v1,name=vpublishapproval-v1.kb.io,admissionReviewVersions=v1
+
+// PublishApprovalCustomValidator admits the PublishApprovals of the approvers
+// of the builds they approve.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type PublishApprovalCustomValidator struct {
+	// Client reads the builds approved
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &PublishApprovalCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type PublishApproval.
+func (v *PublishApprovalCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	approval, ok := obj.(*jcrsv1.PublishApproval)
+	if !ok {
+		return nil, fmt.Errorf("expected a PublishApproval object but got %T", obj)
+	}
+	publishapprovallog.Info("Validation for PublishApproval upon creation", "name", approval.GetName())
+
+	allErrs, err := v.validateApprover(ctx, approval)
+	if err != nil {
+		return nil, err
+	}
+	if len(allErrs) == 0 {
+		return nil, nil
+	}
+	return nil, apierrors.NewInvalid(
+		schema.GroupKind{Group: jcrsv1.GroupVersion.Group, Kind: "PublishApproval"},
+		approval.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type PublishApproval.
+func (v *PublishApprovalCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type PublishApproval.
+func (v *PublishApprovalCustomValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateApprover checks that approval is given by the user creating it, to a
+// build requiring approvals, by one of the approvers of the build if it names any.
+func (v *PublishApprovalCustomValidator) validateApprover(ctx context.Context, approval *jcrsv1.PublishApproval) (field.ErrorList, error) {
+	var allErrs field.ErrorList
+	path := field.NewPath("spec")
+	var user string
+	var groups []string
+	if req, err := admission.RequestFromContext(ctx); err == nil {
+		user, groups = req.UserInfo.Username, req.UserInfo.Groups
+	}
+	if approval.Spec.ApprovedBy != user || user == "" {
+		allErrs = append(allErrs, field.Invalid(path.Child("approvedBy"), approval.Spec.ApprovedBy,
+			"must be the user creating the approval"))
+	}
+
+	lvBuild := &jcrsv1.LeviathanBuild{}
+	err := v.Client.Get(ctx, client.ObjectKey{Namespace: approval.Namespace, Name: approval.Spec.Build}, lvBuild)
+	if apierrors.IsNotFound(err) {
+		return append(allErrs, field.NotFound(path.Child("build"), approval.Spec.Build)), nil
+	} else if err != nil {
+		return nil, err
+	}
+	policy := lvBuild.Spec.PublishApproval
+	if policy == nil || !policy.Required {
+		return append(allErrs, field.Invalid(path.Child("build"), approval.Spec.Build,
+			"the build doesn't require approvals to publish")), nil
+	}
+	if len(policy.Approvers) > 0 && !isApprover(policy.Approvers, user, groups) {
+		allErrs = append(allErrs, field.Forbidden(path.Child("approvedBy"),
+			fmt.Sprintf("%s isn't an approver of LeviathanBuild %s", user, lvBuild.Name)))
+	}
+	return allErrs, nil
+}
+
+// isApprover reports whether user, a member of groups, is one of approvers.
+func isApprover(approvers []string, user string, groups []string) bool {
+	if slices.Contains(approvers, user) {
+		return true
+	}
+	for _, group := range groups {
+		if slices.Contains(approvers, "group:"+group) {
+			return true
+		}
+	}
+	return false
+}
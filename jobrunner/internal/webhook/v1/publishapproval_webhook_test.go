@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+var _ = Describe("PublishApproval Webhook", func() {
+	var (
+		lvBuild   *jcrsv1.LeviathanBuild
+		obj       *jcrsv1.PublishApproval
+		defaulter PublishApprovalCustomDefaulter
+		validator PublishApprovalCustomValidator
+	)
+
+	// withBuild serves lvBuild to the validator
+	withBuild := func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(jcrsv1.AddToScheme(scheme)).To(Succeed())
+		validator.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(lvBuild).Build()
+	}
+
+	// asUser returns the context of the creation of an approval by user, a member of groups
+	asUser := func(user string, groups ...string) context.Context {
+		return admission.NewContextWithRequest(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			UserInfo:  authenticationv1.UserInfo{Username: user, Groups: groups},
+		}})
+	}
+	// create defaults and validates obj, created by user
+	create := func(user string, groups ...string) error {
+		GinkgoHelper()
+		Expect(defaulter.Default(asUser(user, groups...), obj)).To(Succeed())
+		_, err := validator.ValidateCreate(asUser(user, groups...), obj)
+		return err
+	}
+
+	BeforeEach(func() {
+		lvBuild = &jcrsv1.LeviathanBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team"},
+			Spec: jcrsv1.LeviathanBuildSpec{
+				BuildType:       jcrsv1.BuildPublish,
+				PublishApproval: &jcrsv1.PublishApprovalPolicy{Required: true},
+			},
+		}
+		obj = &jcrsv1.PublishApproval{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-3", Namespace: "team"},
+			Spec:       jcrsv1.PublishApprovalSpec{Build: "web", RunIndex: 3, ApprovedBy: "mallory"},
+		}
+	})
+	JustBeforeEach(withBuild)
+
+	It("Should stamp the approval with the user creating it", func() {
+		Expect(create("alice")).To(Succeed())
+		Expect(obj.Spec.ApprovedBy).To(Equal("alice"))
+
+		By("denying approvals in the name of another user")
+		obj.Spec.ApprovedBy = "mallory"
+		Expect(validator.ValidateCreate(asUser("alice"), obj)).Error().To(MatchError(
+			ContainSubstring(`spec.approvedBy: Invalid value: "mallory": must be the user creating the approval`)))
+	})
+
+	It("Should deny approvals of builds that don't require them", func() {
+		obj.Spec.Build = "api"
+		Expect(create("alice")).To(MatchError(ContainSubstring(`spec.build: Not found: "api"`)))
+
+		obj.Spec.Build = "web"
+		lvBuild.Spec.PublishApproval = nil
+		withBuild()
+		Expect(create("alice")).To(MatchError(ContainSubstring("the build doesn't require approvals to publish")))
+	})
+
+	Context("when the build names its approvers", func() {
+		BeforeEach(func() {
+			lvBuild.Spec.PublishApproval.Approvers = []string{"alice", "group:release-managers"}
+		})
+
+		It("Should only admit the approvers by name or group", func() {
+			Expect(create("alice")).To(Succeed())
+			Expect(create("bob", "system:authenticated", "release-managers")).To(Succeed())
+			Expect(create("mallory", "system:authenticated")).To(MatchError(
+				ContainSubstring("spec.approvedBy: Forbidden: mallory isn't an approver of LeviathanBuild web")))
+		})
+	})
+})
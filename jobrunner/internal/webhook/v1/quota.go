@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+A runaway GitOps loop creating builds can fill etcd faster than retention deletes
+them, and every build costs the controller a reconcile. The quota caps the number
+of LeviathanBuilds a namespace may hold, whatever the scheduler would have made of
+them: the creation of a build is rejected once the namespace holds that many.
+
+The cap is configured for every namespace with the --max-builds-per-namespace flag
+of the manager, and overridden for a namespace with the max builds annotation of
+the Namespace. Builds are counted from the cache of the manager, so builds created
+in a burst may exceed the cap by the few the cache hasn't seen yet.
+*/
+
+// MaxBuildsAnnotation overrides the maximum number of LeviathanBuilds of a
+// Namespace. "0" lifts the cap.
+const MaxBuildsAnnotation = "jcrs.jcrs.dev/max-builds"
+
+// maxBuilds returns the maximum number of builds of namespace, 0 when unlimited.
+func (v *LeviathanBuildCustomValidator) maxBuilds(ctx context.Context, namespace string) (int, error) {
+	var ns corev1.Namespace
+	if err := v.Client.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	return MaxBuilds(&ns, v.MaxBuildsPerNamespace)
+}
+
+// MaxBuilds returns the maximum number of builds of ns, 0 when unlimited, given
+// the maximum of every namespace.
+func MaxBuilds(ns *corev1.Namespace, maxBuildsPerNamespace int) (int, error) {
+	value, ok := ns.Annotations[MaxBuildsAnnotation]
+	if !ok {
+		return maxBuildsPerNamespace, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("annotation %s of namespace %s must be a non-negative integer, not %q", MaxBuildsAnnotation, ns.Name, value)
+	}
+	return limit, nil
+}
+
+// checkQuota rejects the creation of lvBuild when its namespace already holds
+// the maximum number of builds.
+func (v *LeviathanBuildCustomValidator) checkQuota(ctx context.Context, lvBuild *jcrsv1.LeviathanBuild) error {
+	if v.Client == nil {
+		return nil
+	}
+	limit, err := v.maxBuilds(ctx, lvBuild.Namespace)
+	if err != nil {
+		return apierrors.NewForbidden(jcrsv1.GroupVersion.WithResource("leviathanbuilds").GroupResource(), lvBuild.Name, err)
+	}
+	if limit == 0 {
+		return nil
+	}
+	var builds jcrsv1.LeviathanBuildList
+	if err := v.Client.List(ctx, &builds, client.InNamespace(lvBuild.Namespace)); err != nil {
+		return err
+	}
+	if len(builds.Items) < limit {
+		return nil
+	}
+	return apierrors.NewForbidden(jcrsv1.GroupVersion.WithResource("leviathanbuilds").GroupResource(), lvBuild.Name,
+		fmt.Errorf("namespace %s already holds %d LeviathanBuilds, its quota is %d: delete builds, or raise the %s annotation of the namespace",
+			lvBuild.Namespace, len(builds.Items), limit, MaxBuildsAnnotation))
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+/*
+A typo in the name of an optional field, like soucePath for sourcePath, doesn't
+fail a build: the field is left unset and the build silently runs with its
+default. The schemas of the CRDs are structural, so the API server prunes the
+fields they don't declare as it decodes objects. It only rejects them for
+requests sent with strict field validation (kubectl --validate=strict), and only
+warns about them otherwise.
+
+In strict mode, the webhooks reject the fields under spec of the object they are
+sent that the Go types don't declare, suggesting the declared field nearest to
+each of them. As the API server prunes objects before calling webhooks, this
+only catches the unknown fields pruning let through: those of clusters serving
+CRDs that don't prune them, like the CRDs of older releases or CRDs patched to
+preserve unknown fields, which the controller would otherwise drop silently.
+Fields whose type decodes itself, like quantities and raw extensions, aren't
+checked.
+*/
+
+// unmarshalerType is the type of json.Unmarshaler.
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// strictSpecFields returns an error for every field under the spec of the
+// object of the admission request of ctx that specType doesn't declare.
+func strictSpecFields(ctx context.Context, specType reflect.Type) field.ErrorList {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil || len(req.Object.Raw) == 0 {
+		return nil
+	}
+	var obj struct {
+		Spec json.RawMessage `json:"spec"`
+	}
+	if err := json.Unmarshal(req.Object.Raw, &obj); err != nil || obj.Spec == nil {
+		return nil
+	}
+	return unknownFields(obj.Spec, specType, field.NewPath("spec"))
+}
+
+// unknownFields returns an error for every field of the JSON document raw,
+// decoded as t at fldPath, that t doesn't declare. Documents that don't decode
+// as t are left to the schema.
+func unknownFields(raw json.RawMessage, t reflect.Type, fldPath *field.Path) field.ErrorList {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	switch t.Kind() {
+	case reflect.Struct:
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil
+		}
+		fields := jsonFields(t)
+		for _, name := range slices.Sorted(maps.Keys(values)) {
+			if fieldType, ok := fields[name]; ok {
+				allErrs = append(allErrs, unknownFields(values[name], fieldType, fldPath.Child(name))...)
+				continue
+			}
+			detail := "unknown field"
+			if suggestion := nearestField(name, fields); suggestion != "" {
+				detail += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child(name), detail))
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil
+		}
+		for i, item := range items {
+			allErrs = append(allErrs, unknownFields(item, t.Elem(), fldPath.Index(i))...)
+		}
+	case reflect.Map:
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil
+		}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			allErrs = append(allErrs, unknownFields(values[key], t.Elem(), fldPath.Key(key))...)
+		}
+	}
+	return allErrs
+}
+
+// jsonFields returns the types of the fields of the struct t, by JSON name.
+// The fields of embedded structs without a name are fields of t.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				maps.Copy(fields, jsonFields(embedded))
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// nearestField returns the field of fields nearest to name by Levenshtein
+// distance, or "" when none is near enough to be what name was meant to be.
+func nearestField(name string, fields map[string]reflect.Type) string {
+	nearest, nearestDistance := "", len(name)/2+1
+	for _, candidate := range slices.Sorted(maps.Keys(fields)) {
+		if distance := levenshtein(strings.ToLower(name), strings.ToLower(candidate)); distance < nearestDistance {
+			nearest, nearestDistance = candidate, distance
+		}
+	}
+	return nearest
+}
+
+// levenshtein returns the number of single character insertions, deletions and
+// substitutions turning a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := range ra {
+		current[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			current[j+1] = min(previous[j+1]+1, current[j]+1, previous[j]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+/*
+Two builds of the same package in a namespace compete over its publish target:
+each publishes its own artifact under the same name, and the last one wins. When
+package names are unique, a build is denied while another active build of the
+namespace builds the same package. Builds being deleted aren't active, nor are
+Verify builds, which don't publish and are commonly run next to the build of the
+package they verify.
+
+Uniqueness is enforced for every namespace with the --unique-package-names flag
+of the manager, and turned on or off for a namespace with the unique package
+names annotation of the Namespace. The builds of a package are found through an
+index of the cache of the manager, so two builds of a package created at the same
+time may both be admitted.
+*/
+
+// UniquePackageNamesAnnotation turns the uniqueness of the package names of the
+// LeviathanBuilds of a Namespace on ("true") or off ("false").
+const UniquePackageNamesAnnotation = "jcrs.jcrs.dev/unique-package-names"
+
+// packageNameKey indexes active LeviathanBuilds by the package they build
+const packageNameKey = ".spec.packageName"
+
+// activePackageName returns the package lvBuild builds, or "" when it isn't an
+// active build of the package.
+func activePackageName(lvBuild *jcrsv1.LeviathanBuild) string {
+	if !lvBuild.DeletionTimestamp.IsZero() || lvBuild.Spec.BuildType == jcrsv1.Verify {
+		return ""
+	}
+	return ptr.Deref(lvBuild.Spec.PackageName, "")
+}
+
+// indexActivePackageName indexes the LeviathanBuild obj by the package it
+// actively builds.
+func indexActivePackageName(obj client.Object) []string {
+	if name := activePackageName(obj.(*jcrsv1.LeviathanBuild)); name != "" {
+		return []string{name}
+	}
+	return nil
+}
+
+// uniquePackageNames reports whether the package names of the builds of namespace must be unique.
+func (v *LeviathanBuildCustomValidator) uniquePackageNames(ctx context.Context, namespace string) (bool, error) {
+	var ns corev1.Namespace
+	if err := v.Client.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	value, ok := ns.Annotations[UniquePackageNamesAnnotation]
+	if !ok {
+		return v.UniquePackageNames, nil
+	}
+	unique, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("annotation %s of namespace %s must be true or false, not %q", UniquePackageNamesAnnotation, namespace, value)
+	}
+	return unique, nil
+}
+
+// checkPackageName rejects lvBuild when another active build of its namespace
+// builds the same package, and package names must be unique. old is the build
+// lvBuild updates, or nil; an update only checks a package lvBuild didn't
+// actively build already, so that duplicates admitted earlier can be changed.
+func (v *LeviathanBuildCustomValidator) checkPackageName(ctx context.Context, lvBuild, old *jcrsv1.LeviathanBuild) error {
+	name := activePackageName(lvBuild)
+	if v.Client == nil || name == "" || old != nil && activePackageName(old) == name {
+		return nil
+	}
+	unique, err := v.uniquePackageNames(ctx, lvBuild.Namespace)
+	if err != nil {
+		return apierrors.NewForbidden(jcrsv1.GroupVersion.WithResource("leviathanbuilds").GroupResource(), lvBuild.Name, err)
+	}
+	if !unique {
+		return nil
+	}
+	var builds jcrsv1.LeviathanBuildList
+	if err := v.Client.List(ctx, &builds, client.InNamespace(lvBuild.Namespace), client.MatchingFields{packageNameKey: name}); err != nil {
+		return err
+	}
+	for _, other := range builds.Items {
+		if other.Name == lvBuild.Name {
+			continue
+		}
+		return apierrors.NewForbidden(jcrsv1.GroupVersion.WithResource("leviathanbuilds").GroupResource(), lvBuild.Name,
+			fmt.Errorf("LeviathanBuild %s already builds package %s in namespace %s: delete it, build another package, or set the %s annotation of the namespace to false",
+				other.Name, name, lvBuild.Namespace, UniquePackageNamesAnnotation))
+	}
+	return nil
+}
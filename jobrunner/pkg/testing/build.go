@@ -0,0 +1,203 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testing provides fixtures and fakes to unit test code built on the
+// LeviathanBuild API without a cluster or envtest: a builder of LeviathanBuild
+// objects, a fake source server, and a fake publish target.
+package testing
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+)
+
+// LeviathanBuildWrapper builds a LeviathanBuild fixture. Its methods modify the
+// wrapped object and return the wrapper, so they can be chained.
+type LeviathanBuildWrapper struct {
+	jcrsv1.LeviathanBuild
+}
+
+// MakeLeviathanBuild returns a wrapper of a valid LeviathanBuild of the package
+// of the same name, running a single "build" container.
+func MakeLeviathanBuild(name, namespace string) *LeviathanBuildWrapper {
+	return &LeviathanBuildWrapper{jcrsv1.LeviathanBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			UID:        types.UID(name + "-uid"),
+			Generation: 1,
+		},
+		Spec: jcrsv1.LeviathanBuildSpec{
+			PackageName: ptr.To(name),
+			BuildType:   jcrsv1.Build,
+			JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    []corev1.Container{{Name: "build", Image: "busybox"}},
+				}},
+			}},
+		},
+	}}
+}
+
+// Obj returns the LeviathanBuild.
+func (w *LeviathanBuildWrapper) Obj() *jcrsv1.LeviathanBuild {
+	return &w.LeviathanBuild
+}
+
+// Clone returns a copy of the wrapper.
+func (w *LeviathanBuildWrapper) Clone() *LeviathanBuildWrapper {
+	return &LeviathanBuildWrapper{*w.DeepCopy()}
+}
+
+// Label sets a label of the build.
+func (w *LeviathanBuildWrapper) Label(key, value string) *LeviathanBuildWrapper {
+	if w.Labels == nil {
+		w.Labels = make(map[string]string)
+	}
+	w.Labels[key] = value
+	return w
+}
+
+// Annotation sets an annotation of the build.
+func (w *LeviathanBuildWrapper) Annotation(key, value string) *LeviathanBuildWrapper {
+	if w.Annotations == nil {
+		w.Annotations = make(map[string]string)
+	}
+	w.Annotations[key] = value
+	return w
+}
+
+// Generation sets the generation of the build.
+func (w *LeviathanBuildWrapper) Generation(generation int64) *LeviathanBuildWrapper {
+	w.LeviathanBuild.Generation = generation
+	return w
+}
+
+// PackageName sets the name of the built package.
+func (w *LeviathanBuildWrapper) PackageName(name string) *LeviathanBuildWrapper {
+	w.Spec.PackageName = ptr.To(name)
+	return w
+}
+
+// BuildType sets the type of the build.
+func (w *LeviathanBuildWrapper) BuildType(buildType jcrsv1.BuildType) *LeviathanBuildWrapper {
+	w.Spec.BuildType = buildType
+	return w
+}
+
+// Publish makes the build a BuildPublish build publishing version to registryURL.
+func (w *LeviathanBuildWrapper) Publish(registryURL, version string) *LeviathanBuildWrapper {
+	w.Spec.BuildType = jcrsv1.BuildPublish
+	w.Spec.PublishTarget = &jcrsv1.PublishTarget{
+		RegistryURL:    registryURL,
+		Version:        version,
+		ConflictPolicy: jcrsv1.FailOnConflict,
+	}
+	return w
+}
+
+// ConflictPolicy sets the conflict policy of the publish target, which must be set.
+func (w *LeviathanBuildWrapper) ConflictPolicy(policy jcrsv1.ConflictPolicy) *LeviathanBuildWrapper {
+	w.Spec.PublishTarget.ConflictPolicy = policy
+	return w
+}
+
+// SourceURL makes the build fetch its source from an HTTP archive.
+func (w *LeviathanBuildWrapper) SourceURL(url string) *LeviathanBuildWrapper {
+	w.Spec.SourceType = jcrsv1.HTTPSource
+	w.Spec.SourceURL = ptr.To(url)
+	return w
+}
+
+// Image sets the image of the "build" container.
+func (w *LeviathanBuildWrapper) Image(image string) *LeviathanBuildWrapper {
+	w.buildContainer().Image = image
+	return w
+}
+
+// Command sets the command of the "build" container.
+func (w *LeviathanBuildWrapper) Command(command ...string) *LeviathanBuildWrapper {
+	w.buildContainer().Command = command
+	return w
+}
+
+// Container adds a container to the jobTemplate, or replaces the container of the same name.
+func (w *LeviathanBuildWrapper) Container(container corev1.Container) *LeviathanBuildWrapper {
+	containers := &w.Spec.JobTemplate.Spec.Template.Spec.Containers
+	for i := range *containers {
+		if (*containers)[i].Name == container.Name {
+			(*containers)[i] = container
+			return w
+		}
+	}
+	*containers = append(*containers, container)
+	return w
+}
+
+// Sidecar adds a sidecar to the build.
+func (w *LeviathanBuildWrapper) Sidecar(sidecar corev1.Container) *LeviathanBuildWrapper {
+	w.Spec.Sidecars = append(w.Spec.Sidecars, sidecar)
+	return w
+}
+
+// MutexKey sets the mutex key of the build.
+func (w *LeviathanBuildWrapper) MutexKey(key string) *LeviathanBuildWrapper {
+	w.Spec.MutexKey = ptr.To(key)
+	return w
+}
+
+// SpotPolicy sets the spot policy of the build.
+func (w *LeviathanBuildWrapper) SpotPolicy(policy jcrsv1.SpotPolicy) *LeviathanBuildWrapper {
+	w.Spec.SpotPolicy = policy
+	return w
+}
+
+// ArtifactRetention sets the artifact retention policy of the build.
+func (w *LeviathanBuildWrapper) ArtifactRetention(retention jcrsv1.ArtifactRetention) *LeviathanBuildWrapper {
+	w.Spec.ArtifactRetention = &retention
+	return w
+}
+
+// RunIndex sets the index of the latest run of the build in its status.
+func (w *LeviathanBuildWrapper) RunIndex(runIndex int64) *LeviathanBuildWrapper {
+	w.Status.RunIndex = runIndex
+	return w
+}
+
+// Condition sets a condition in the status of the build.
+func (w *LeviathanBuildWrapper) Condition(condition metav1.Condition) *LeviathanBuildWrapper {
+	meta.SetStatusCondition(&w.Status.Conditions, condition)
+	return w
+}
+
+// buildContainer returns the "build" container of the jobTemplate, adding it if needed.
+func (w *LeviathanBuildWrapper) buildContainer() *corev1.Container {
+	podSpec := &w.Spec.JobTemplate.Spec.Template.Spec
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == "build" {
+			return &podSpec.Containers[i]
+		}
+	}
+	podSpec.Containers = append(podSpec.Containers, corev1.Container{Name: "build"})
+	return &podSpec.Containers[len(podSpec.Containers)-1]
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PublishTarget is a fake publish target. It implements the methods the
+// controller checks and prunes versions with, in memory, and serves the registry
+// protocol of publish targets over HTTP:
+//
+//	HEAD <registryURL>/<packageName>/<version>
+//	PUT <registryURL>/<packageName>/<version>, publishing the version
+//	DELETE <registryURL>/<packageName>/<version>
+//
+// The registryURL of the versions is ignored, so a single PublishTarget can
+// stand in for several registries.
+type PublishTarget struct {
+	mu       sync.Mutex
+	versions map[packageVersion]string
+	deleted  []string
+	errs     map[packageVersion]error
+}
+
+// packageVersion identifies a version of a package.
+type packageVersion struct {
+	packageName, version string
+}
+
+// NewPublishTarget returns an empty PublishTarget.
+func NewPublishTarget() *PublishTarget {
+	return &PublishTarget{versions: make(map[packageVersion]string), errs: make(map[packageVersion]error)}
+}
+
+// Publish publishes a version of a package with the given digest.
+func (p *PublishTarget) Publish(packageName, version, digest string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.versions[packageVersion{packageName, version}] = digest
+}
+
+// FailOn makes the requests for a version of a package fail with err, or succeed
+// again when err is nil.
+func (p *PublishTarget) FailOn(packageName, version string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		delete(p.errs, packageVersion{packageName, version})
+		return
+	}
+	p.errs[packageVersion{packageName, version}] = err
+}
+
+// Versions returns the published versions of a package, sorted.
+func (p *PublishTarget) Versions(packageName string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var versions []string
+	for key := range p.versions {
+		if key.packageName == packageName {
+			versions = append(versions, key.version)
+		}
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// Deleted returns the versions deleted so far, as <packageName>/<version>, in
+// the order they were deleted.
+func (p *PublishTarget) Deleted() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.deleted)
+}
+
+// Exists reports whether a version of a package is published.
+func (p *PublishTarget) Exists(_ context.Context, _, packageName, version string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := packageVersion{packageName, version}
+	if err := p.errs[key]; err != nil {
+		return false, err
+	}
+	_, ok := p.versions[key]
+	return ok, nil
+}
+
+// Delete deletes a version of a package. Deleting a missing version succeeds.
+func (p *PublishTarget) Delete(_ context.Context, _, packageName, version, _ string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := packageVersion{packageName, version}
+	if err := p.errs[key]; err != nil {
+		return err
+	}
+	if _, ok := p.versions[key]; ok {
+		delete(p.versions, key)
+		p.deleted = append(p.deleted, packageName+"/"+version)
+	}
+	return nil
+}
+
+// NewServer starts an HTTP server serving the registry protocol of publish
+// targets, whose URL is the registryURL of the PublishTarget. It is closed by Close.
+func (p *PublishTarget) NewServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Package names may span several segments, or contain escaped slashes: the
+		// version is the last segment
+		escapedPath := strings.TrimPrefix(r.URL.EscapedPath(), "/")
+		i := strings.LastIndex(escapedPath, "/")
+		if i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		escapedName, escapedVersion := escapedPath[:i], escapedPath[i+1:]
+		packageName, nameErr := url.PathUnescape(escapedName)
+		version, versionErr := url.PathUnescape(escapedVersion)
+		if nameErr != nil || versionErr != nil || packageName == "" || version == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodHead, http.MethodGet:
+			exists, err := p.Exists(r.Context(), "", packageName, version)
+			switch {
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			case !exists:
+				http.NotFound(w, r)
+			}
+		case http.MethodPut:
+			p.Publish(packageName, version, fmt.Sprintf("sha256:%s", digest([]byte(packageName+"/"+version))))
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			if err := p.Delete(r.Context(), "", packageName, version, ""); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+}
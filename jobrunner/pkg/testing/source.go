@@ -0,0 +1,124 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SourceServer is a fake source provider, serving the source of HTTP builds as a
+// tar.gz archive at its URL. It answers conditional requests like the servers the
+// fetch init container caches archives from.
+type SourceServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	archive  []byte
+	requests int
+}
+
+// NewSourceServer starts a SourceServer serving an archive of files, keyed by
+// their path. It is closed by Close.
+func NewSourceServer(files map[string]string) *SourceServer {
+	s := &SourceServer{}
+	s.SetFiles(files)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetFiles replaces the files served, changing the revision of the source.
+func (s *SourceServer) SetFiles(files map[string]string) {
+	archive := sourceArchive(files)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.archive = archive
+}
+
+// Revision returns the revision the fetch of the source currently served reports.
+func (s *SourceServer) Revision() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return "sha256:" + digest(s.archive)
+}
+
+// Requests returns the number of requests served, including the requests
+// answered with 304 Not Modified.
+func (s *SourceServer) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *SourceServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	archive := s.archive
+	s.mu.Unlock()
+
+	etag := `"` + digest(archive) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	_, _ = w.Write(archive)
+}
+
+// sourceArchive returns a tar.gz archive of files. The archive only depends on
+// the files, so serving the same files keeps the same revision.
+func sourceArchive(files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		content := files[name]
+		// Writes to a bytes.Buffer don't fail
+		_ = tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			ModTime:  time.Unix(0, 0),
+			Typeflag: tar.TypeReg,
+		})
+		_, _ = tw.Write([]byte(content))
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	return buf.Bytes()
+}
+
+// digest returns the hex encoded sha256 digest of data.
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
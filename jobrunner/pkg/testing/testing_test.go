@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	jcrsv1 "test.jcrs.dev/jobrunner/api/v1"
+	"test.jcrs.dev/jobrunner/internal/fetch"
+	"test.jcrs.dev/jobrunner/internal/registry"
+	"test.jcrs.dev/jobrunner/internal/retention"
+)
+
+// The fakes must keep up with the interfaces they stand in for
+var (
+	_ registry.Checker = &PublishTarget{}
+	_ retention.Pruner = &PublishTarget{}
+)
+
+var _ = Describe("LeviathanBuildWrapper", func() {
+	It("builds LeviathanBuild fixtures", func() {
+		lvBuild := MakeLeviathanBuild("web", "default").
+			Label("team", "frontend").
+			Publish("https://registry.example.com", "1.2.3").
+			SourceURL("https://example.com/web.tar.gz").
+			Image("node:22").
+			Command("npm", "run", "build").
+			Sidecar(corev1.Container{Name: "dind", Image: "docker:dind"}).
+			Obj()
+
+		Expect(lvBuild.Labels).To(HaveKeyWithValue("team", "frontend"))
+		Expect(*lvBuild.Spec.PackageName).To(Equal("web"))
+		Expect(lvBuild.Spec.BuildType).To(Equal(jcrsv1.BuildPublish))
+		Expect(lvBuild.Spec.PublishTarget.Version).To(Equal("1.2.3"))
+		Expect(lvBuild.Spec.SourceType).To(Equal(jcrsv1.HTTPSource))
+		containers := lvBuild.Spec.JobTemplate.Spec.Template.Spec.Containers
+		Expect(containers).To(HaveLen(1))
+		Expect(containers[0].Image).To(Equal("node:22"))
+		Expect(containers[0].Command).To(Equal([]string{"npm", "run", "build"}))
+		Expect(lvBuild.Spec.Sidecars).To(HaveLen(1))
+	})
+
+	It("clones fixtures", func() {
+		base := MakeLeviathanBuild("web", "default")
+		other := base.Clone().PackageName("api").Obj()
+		Expect(*base.Obj().Spec.PackageName).To(Equal("web"))
+		Expect(*other.Spec.PackageName).To(Equal("api"))
+	})
+})
+
+var _ = Describe("SourceServer", func() {
+	It("serves sources the fetch init container can fetch and cache", func() {
+		server := NewSourceServer(map[string]string{"package.json": `{"name":"web"}`, "src/index.js": "main()"})
+		DeferCleanup(server.Close)
+		dest, cache := GinkgoT().TempDir(), GinkgoT().TempDir()
+		opts := fetch.HTTPOptions{URL: server.URL, Dest: dest, CacheDir: cache}
+
+		result, err := fetch.HTTP(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Revision).To(Equal(server.Revision()))
+		Expect(os.ReadFile(filepath.Join(dest, "src", "index.js"))).To(BeEquivalentTo("main()"))
+
+		result, err = fetch.HTTP(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Cached).To(BeTrue())
+		Expect(server.Requests()).To(Equal(2))
+
+		By("changing the revision with the files")
+		previous := server.Revision()
+		server.SetFiles(map[string]string{"package.json": `{"name":"web","version":"2"}`})
+		Expect(server.Revision()).NotTo(Equal(previous))
+	})
+})
+
+var _ = Describe("PublishTarget", func() {
+	It("serves the registry protocol of publish targets", func() {
+		target := NewPublishTarget()
+		target.Publish("@scope/web", "1.0.0", "sha256:0123")
+		target.Publish("@scope/web", "1.1.0", "sha256:4567")
+		server := target.NewServer()
+		DeferCleanup(server.Close)
+
+		checker := registry.NewHTTPChecker(time.Second)
+		Expect(checker.Exists(context.Background(), server.URL, "@scope/web", "1.0.0")).To(BeTrue())
+		Expect(checker.Exists(context.Background(), server.URL, "@scope/web", "2.0.0")).To(BeFalse())
+		target.Publish("team/web ui", "1.0.0", "sha256:89ab")
+		Expect(checker.Exists(context.Background(), server.URL, "team/web ui", "1.0.0")).To(BeTrue())
+
+		req, err := http.NewRequest(http.MethodDelete, server.URL+"/%40scope%2Fweb/1.0.0", nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Body.Close()).To(Succeed())
+		Expect(target.Deleted()).To(Equal([]string{"@scope/web/1.0.0"}))
+		Expect(target.Versions("@scope/web")).To(Equal([]string{"1.1.0"}))
+	})
+
+	It("fails the requests for a version on demand", func() {
+		target := NewPublishTarget()
+		target.Publish("web", "1.0.0", "sha256:0123")
+		target.FailOn("web", "1.0.0", errors.New("unavailable"))
+		Expect(target.Delete(context.Background(), "", "web", "1.0.0", "")).To(MatchError("unavailable"))
+
+		target.FailOn("web", "1.0.0", nil)
+		Expect(target.Delete(context.Background(), "", "web", "1.0.0", "")).To(Succeed())
+		Expect(target.Versions("web")).To(BeEmpty())
+	})
+})